@@ -0,0 +1,281 @@
+package bios
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+)
+
+// contractABI is the part of a contract's ABI needed to serialize
+// action data.
+type contractABI struct {
+	Types []struct {
+		NewTypeName string `json:"new_type_name"`
+		Type        string `json:"type"`
+	} `json:"types"`
+	Structs []*abiStruct `json:"structs"`
+	Actions []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	} `json:"actions"`
+}
+
+type abiStruct struct {
+	Name   string `json:"name"`
+	Base   string `json:"base"`
+	Fields []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	} `json:"fields"`
+}
+
+// EncodeActionData serializes `data` (as decoded from JSON, with
+// numbers as `json.Number`) into the binary form of the `action` of a
+// contract, following its ABI.
+func EncodeActionData(rawABI []byte, action string, data interface{}) ([]byte, error) {
+	var abi contractABI
+	if err := json.Unmarshal(rawABI, &abi); err != nil {
+		return nil, fmt.Errorf("decoding ABI: %s", err)
+	}
+
+	for _, act := range abi.Actions {
+		if act.Name == action {
+			buf := &bytes.Buffer{}
+			if err := abi.encode(buf, act.Type, data); err != nil {
+				return nil, err
+			}
+			return buf.Bytes(), nil
+		}
+	}
+
+	return nil, fmt.Errorf("action %q not found in ABI", action)
+}
+
+func (abi *contractABI) resolve(typeName string) string {
+	for _, typedef := range abi.Types {
+		if typedef.NewTypeName == typeName {
+			return abi.resolve(typedef.Type)
+		}
+	}
+	return typeName
+}
+
+func (abi *contractABI) findStruct(name string) *abiStruct {
+	for _, s := range abi.Structs {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+func (abi *contractABI) encode(buf *bytes.Buffer, typeName string, value interface{}) error {
+	if strings.HasSuffix(typeName, "[]") {
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("expected a list for %s, got %T", typeName, value)
+		}
+		writeVaruint32(buf, uint32(len(items)))
+		for idx, item := range items {
+			if err := abi.encode(buf, strings.TrimSuffix(typeName, "[]"), item); err != nil {
+				return fmt.Errorf("[%d]: %s", idx, err)
+			}
+		}
+		return nil
+	}
+
+	if strings.HasSuffix(typeName, "?") {
+		if value == nil {
+			buf.WriteByte(0)
+			return nil
+		}
+		buf.WriteByte(1)
+		return abi.encode(buf, strings.TrimSuffix(typeName, "?"), value)
+	}
+
+	typeName = abi.resolve(typeName)
+
+	if s := abi.findStruct(typeName); s != nil {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected a map for %s, got %T", typeName, value)
+		}
+		if s.Base != "" {
+			if err := abi.encode(buf, s.Base, value); err != nil {
+				return err
+			}
+		}
+		for _, field := range s.Fields {
+			fieldValue, found := fields[field.Name]
+			if !found && !strings.HasSuffix(field.Type, "?") {
+				return fmt.Errorf("%s: missing field %q", typeName, field.Name)
+			}
+			if err := abi.encode(buf, field.Type, fieldValue); err != nil {
+				return fmt.Errorf("%s.%s: %s", typeName, field.Name, err)
+			}
+		}
+		return nil
+	}
+
+	return encodeBuiltin(buf, typeName, value)
+}
+
+func encodeBuiltin(buf *bytes.Buffer, typeName string, value interface{}) error {
+	str := fmt.Sprintf("%v", value)
+
+	switch typeName {
+	case "bool":
+		val, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("expected a boolean, got %T", value)
+		}
+		if val {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	case "int8", "int16", "int32", "int64":
+		bits, _ := strconv.Atoi(strings.TrimPrefix(typeName, "int"))
+		val, err := strconv.ParseInt(str, 10, bits)
+		if err != nil {
+			return err
+		}
+		switch bits {
+		case 8:
+			return binary.Write(buf, binary.LittleEndian, int8(val))
+		case 16:
+			return binary.Write(buf, binary.LittleEndian, int16(val))
+		case 32:
+			return binary.Write(buf, binary.LittleEndian, int32(val))
+		}
+		return binary.Write(buf, binary.LittleEndian, val)
+	case "uint8", "uint16", "uint32", "uint64":
+		bits, _ := strconv.Atoi(strings.TrimPrefix(typeName, "uint"))
+		val, err := strconv.ParseUint(str, 10, bits)
+		if err != nil {
+			return err
+		}
+		switch bits {
+		case 8:
+			return binary.Write(buf, binary.LittleEndian, uint8(val))
+		case 16:
+			return binary.Write(buf, binary.LittleEndian, uint16(val))
+		case 32:
+			return binary.Write(buf, binary.LittleEndian, uint32(val))
+		}
+		return binary.Write(buf, binary.LittleEndian, val)
+	case "varuint32":
+		val, err := strconv.ParseUint(str, 10, 32)
+		if err != nil {
+			return err
+		}
+		writeVaruint32(buf, uint32(val))
+	case "float64":
+		val, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return err
+		}
+		return binary.Write(buf, binary.LittleEndian, math.Float64bits(val))
+	case "name", "account_name", "action_name", "permission_name", "table_name", "scope_name":
+		val, err := eos.StringToName(str)
+		if err != nil {
+			return err
+		}
+		return binary.Write(buf, binary.LittleEndian, val)
+	case "string":
+		writeVaruint32(buf, uint32(len(str)))
+		buf.WriteString(str)
+	case "bytes":
+		val, err := hex.DecodeString(str)
+		if err != nil {
+			return err
+		}
+		writeVaruint32(buf, uint32(len(val)))
+		buf.Write(val)
+	case "checksum160", "checksum256", "checksum512":
+		val, err := hex.DecodeString(str)
+		if err != nil {
+			return err
+		}
+		size := map[string]int{"checksum160": 20, "checksum256": 32, "checksum512": 64}[typeName]
+		if len(val) != size {
+			return fmt.Errorf("%s should be %d bytes, got %d", typeName, size, len(val))
+		}
+		buf.Write(val)
+	case "time_point_sec":
+		val, err := time.Parse("2006-01-02T15:04:05", str)
+		if err != nil {
+			return err
+		}
+		return binary.Write(buf, binary.LittleEndian, uint32(val.Unix()))
+	case "symbol":
+		// "4,EOS"
+		parts := strings.Split(str, ",")
+		if len(parts) != 2 || len(parts[1]) > 7 {
+			return fmt.Errorf("invalid symbol %q, expected precision,CODE", str)
+		}
+		precision, err := strconv.ParseUint(parts[0], 10, 8)
+		if err != nil {
+			return fmt.Errorf("invalid symbol %q: %s", str, err)
+		}
+		code := make([]byte, 7)
+		copy(code, parts[1])
+		buf.WriteByte(byte(precision))
+		buf.Write(code)
+	case "asset":
+		val, err := eos.NewAsset(str)
+		if err != nil {
+			return err
+		}
+		return marshalInto(buf, val)
+	case "public_key":
+		val, err := ecc.NewPublicKey(str)
+		if err != nil {
+			return err
+		}
+		return marshalInto(buf, val)
+	case "signature":
+		val, err := ecc.NewSignature(str)
+		if err != nil {
+			return err
+		}
+		return marshalInto(buf, val)
+	default:
+		return fmt.Errorf("unsupported type %q", typeName)
+	}
+
+	return nil
+}
+
+func marshalInto(buf *bytes.Buffer, v interface{}) error {
+	cnt, err := eos.MarshalBinary(v)
+	if err != nil {
+		return err
+	}
+	buf.Write(cnt)
+	return nil
+}
+
+func writeVaruint32(buf *bytes.Buffer, val uint32) {
+	out := make([]byte, binary.MaxVarintLen32)
+	n := binary.PutUvarint(out, uint64(val))
+	buf.Write(out[:n])
+}
+
+// parsePermissionLevel reads an `actor@permission` string.
+func parsePermissionLevel(perm string) (out eos.PermissionLevel, err error) {
+	parts := strings.Split(perm, "@")
+	if len(parts) != 2 {
+		return out, fmt.Errorf("invalid authorization %q, expected actor@permission", perm)
+	}
+	return eos.PermissionLevel{Actor: AN(parts[0]), Permission: PN(parts[1])}, nil
+}
@@ -0,0 +1,60 @@
+package bios
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/stretchr/testify/assert"
+)
+
+const testABI = `{
+  "types": [{"new_type_name": "account_name", "type": "name"}, {"new_type_name": "weight", "type": "uint16"}],
+  "structs": [
+    {"name": "base", "base": "", "fields": [{"name": "poster", "type": "account_name"}]},
+    {"name": "post", "base": "base", "fields": [
+      {"name": "post_uuid", "type": "string"},
+      {"name": "weights", "type": "weight[]"},
+      {"name": "certify", "type": "bool"},
+      {"name": "reply_to", "type": "uint64?"},
+      {"name": "delta", "type": "int32"}
+    ]}
+  ],
+  "actions": [{"name": "post", "type": "post"}]
+}`
+
+func TestEncodeActionData(t *testing.T) {
+	var data interface{}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(`{"poster": "eoscanadacom", "post_uuid": "abc", "weights": [1, 300], "certify": true, "delta": -2}`)))
+	decoder.UseNumber()
+	assert.NoError(t, decoder.Decode(&data))
+
+	out, err := EncodeActionData([]byte(testABI), "post", data)
+	assert.NoError(t, err)
+
+	expected := &bytes.Buffer{}
+	poster, _ := eos.StringToName("eoscanadacom")
+	binary.Write(expected, binary.LittleEndian, poster)
+	expected.Write([]byte{3, 'a', 'b', 'c'})
+	expected.Write([]byte{2, 1, 0, 0x2c, 0x01})
+	expected.Write([]byte{1})                      // certify
+	expected.Write([]byte{0})                      // no reply_to
+	expected.Write([]byte{0xfe, 0xff, 0xff, 0xff}) // delta
+
+	assert.Equal(t, expected.Bytes(), out)
+}
+
+func TestEncodeActionDataErrors(t *testing.T) {
+	_, err := EncodeActionData([]byte(testABI), "vote", map[string]interface{}{})
+	assert.EqualError(t, err, `action "vote" not found in ABI`)
+
+	_, err = EncodeActionData([]byte(testABI), "post", map[string]interface{}{"poster": "eoscanadacom"})
+	assert.EqualError(t, err, `post: missing field "post_uuid"`)
+
+	_, err = EncodeActionData([]byte(testABI), "post", map[string]interface{}{
+		"poster": "eoscanadacom", "post_uuid": "abc", "weights": []interface{}{json.Number("70000")},
+	})
+	assert.Error(t, err)
+}
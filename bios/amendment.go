@@ -0,0 +1,181 @@
+package bios
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/eoscanada/eos-bios/bios/disco"
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+)
+
+// Amendment is a proposed change to the launch data agreed upon,
+// approved by the top producers with detached signatures of its hash.
+type Amendment struct {
+	Title          string             `json:"title"`
+	Description    string             `json:"description"`
+	ProposedBy     eos.AccountName    `json:"proposed_by"`
+	ProposedAt     time.Time          `json:"proposed_at"`
+	PreviousHash   string             `json:"previous_hash"`
+	TargetContents []disco.ContentRef `json:"target_contents"`
+}
+
+// AmendmentApproval is a producer's detached signature of an
+// amendment's hash, by a key of its seed network account's `active`
+// permission.
+type AmendmentApproval struct {
+	Account   eos.AccountName `json:"account"`
+	Hash      string          `json:"hash"`
+	Signature string          `json:"signature"`
+}
+
+// LaunchDataHash identifies a version of the launch data.
+func LaunchDataHash(contents []disco.ContentRef) string {
+	sorted := append([]disco.ContentRef{}, contents...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	h := sha256.New()
+	for _, content := range sorted {
+		fmt.Fprintf(h, "%s %s\n", content.Name, content.Ref)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Hash covers the launch data the amendment replaces and the one it
+// proposes, so an approval can't be replayed on another base.
+func (a *Amendment) Hash() []byte {
+	h := sha256.Sum256([]byte(a.PreviousHash + "\n" + LaunchDataHash(a.TargetContents)))
+	return h[:]
+}
+
+// NewAmendment proposes `contents` to replace the current launch data.
+func (net *Network) NewAmendment(title, description string, contents []disco.ContentRef) (*Amendment, error) {
+	launchDisco, err := net.ConsensusDiscovery()
+	if err != nil {
+		return nil, fmt.Errorf("getting consensus on launch data: %s", err)
+	}
+
+	return &Amendment{
+		Title:          title,
+		Description:    description,
+		ProposedBy:     net.MyPeer.Discovery.SeedNetworkAccountName,
+		ProposedAt:     time.Now().UTC(),
+		PreviousHash:   LaunchDataHash(launchDisco.TargetContents),
+		TargetContents: contents,
+	}, nil
+}
+
+// ApproveAmendment signs the amendment with the first of `keys` that
+// is part of the account's active permission.
+func (net *Network) ApproveAmendment(amendment *Amendment, keys []*ecc.PrivateKey) (*AmendmentApproval, error) {
+	account := net.MyPeer.Discovery.SeedNetworkAccountName
+	activeKeys, err := net.ActivePublicKeys(account)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := amendment.Hash()
+	sig, err := signWithActiveKey(keys, activeKeys, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AmendmentApproval{
+		Account:   account,
+		Hash:      hex.EncodeToString(hash),
+		Signature: sig.String(),
+	}, nil
+}
+
+// AmendmentStatus tells which of the top producers approved an
+// amendment, and whether the quorum is reached: more than two thirds
+// of the top producers, whose launch data forms the consensus.
+type AmendmentStatus struct {
+	Hash      string
+	Approvals []*AmendmentApprovalStatus
+	Approved  int
+	Quorum    int
+	Reached   bool
+}
+
+type AmendmentApprovalStatus struct {
+	Account eos.AccountName
+	Top     bool
+	Valid   bool
+	Error   string
+}
+
+func (net *Network) AmendmentStatus(amendment *Amendment, approvals []*AmendmentApproval) *AmendmentStatus {
+	hash := amendment.Hash()
+	status := &AmendmentStatus{Hash: hex.EncodeToString(hash)}
+
+	top := map[eos.AccountName]bool{}
+	for idx, peer := range net.OrderedPeers(net.MyNetwork()) {
+		if idx == ContentConsensusRequiredFromTop {
+			break
+		}
+		top[peer.Discovery.SeedNetworkAccountName] = true
+	}
+	status.Quorum = len(top)*2/3 + 1
+
+	seen := map[eos.AccountName]bool{}
+	for _, approval := range approvals {
+		approvalStatus := &AmendmentApprovalStatus{Account: approval.Account, Top: top[approval.Account]}
+		status.Approvals = append(status.Approvals, approvalStatus)
+
+		if err := net.verifyApproval(approval, hash); err != nil {
+			approvalStatus.Error = err.Error()
+			continue
+		}
+		approvalStatus.Valid = true
+
+		if approvalStatus.Top && !seen[approval.Account] {
+			seen[approval.Account] = true
+			status.Approved++
+		}
+	}
+
+	status.Reached = status.Approved >= status.Quorum
+
+	return status
+}
+
+func (net *Network) verifyApproval(approval *AmendmentApproval, hash []byte) error {
+	if approval.Hash != hex.EncodeToString(hash) {
+		return fmt.Errorf("approves another amendment")
+	}
+
+	sig, err := ecc.NewSignature(approval.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %s", err)
+	}
+
+	pubKey, err := sig.PublicKey(hash)
+	if err != nil {
+		return fmt.Errorf("recovering public key: %s", err)
+	}
+
+	activeKeys, err := net.ActivePublicKeys(approval.Account)
+	if err != nil {
+		return err
+	}
+	for _, key := range activeKeys {
+		if key.String() == pubKey.String() {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("signed by a key not in the account's active permission")
+}
+
+func LoadAmendment(cnt []byte) (*Amendment, error) {
+	var amendment Amendment
+	if err := json.Unmarshal(cnt, &amendment); err != nil {
+		return nil, err
+	}
+	return &amendment, nil
+}
@@ -0,0 +1,25 @@
+package bios
+
+import (
+	"testing"
+
+	"github.com/eoscanada/eos-bios/bios/disco"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAmendmentHash(t *testing.T) {
+	contents := []disco.ContentRef{
+		{Name: "boot_sequence.yaml", Ref: "/ipfs/Qm1"},
+		{Name: "snapshot.csv", Ref: "/ipfs/Qm2", Comment: "sha256:abc"},
+	}
+	reordered := []disco.ContentRef{contents[1], contents[0]}
+	changed := []disco.ContentRef{contents[0], {Name: "snapshot.csv", Ref: "/ipfs/Qm3"}}
+
+	assert.Equal(t, LaunchDataHash(contents), LaunchDataHash(reordered))
+	assert.NotEqual(t, LaunchDataHash(contents), LaunchDataHash(changed))
+
+	amendment := &Amendment{PreviousHash: LaunchDataHash(contents), TargetContents: changed}
+	onOtherBase := &Amendment{PreviousHash: LaunchDataHash(reordered[:1]), TargetContents: changed}
+	assert.NotEqual(t, amendment.Hash(), onOtherBase.Hash())
+	assert.Len(t, amendment.Hash(), 32)
+}
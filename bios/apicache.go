@@ -0,0 +1,79 @@
+package bios
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// APICache keeps the responses of third-party APIs (explorers,
+// Keybase, etc.) on disk, so repeated runs in the hours before the
+// launch don't get rate-limited.
+type APICache struct {
+	Dir string
+	// TTL is how long a response is served from the cache before
+	// being fetched again.
+	TTL time.Duration
+	// Offline serves everything from the cache, whatever its age,
+	// and fails for what's not cached.
+	Offline bool
+	Client  *http.Client
+}
+
+func NewAPICache(dir string, ttl time.Duration, offline bool) *APICache {
+	return &APICache{
+		Dir:     dir,
+		TTL:     ttl,
+		Offline: offline,
+		Client:  http.DefaultClient,
+	}
+}
+
+// Get returns the response to a GET on `url`, from the cache when
+// fresh enough. When the API fails, a stale response is preferred to
+// no response.
+func (c *APICache) Get(url string) ([]byte, error) {
+	fileName := filepath.Join(c.Dir, sha2([]byte(url)))
+
+	stat, statErr := os.Stat(fileName)
+	if statErr == nil && (c.Offline || time.Since(stat.ModTime()) < c.TTL) {
+		return ioutil.ReadFile(fileName)
+	}
+	if c.Offline {
+		return nil, fmt.Errorf("%s not in the API cache, and running with --offline-cache", url)
+	}
+
+	cnt, err := c.fetch(url)
+	if err != nil {
+		if statErr == nil {
+			return ioutil.ReadFile(fileName)
+		}
+		return nil, err
+	}
+
+	if err := os.MkdirAll(c.Dir, 0777); err != nil {
+		return nil, fmt.Errorf("creating API cache: %s", err)
+	}
+	if err := ioutil.WriteFile(fileName, cnt, 0666); err != nil {
+		return nil, fmt.Errorf("writing API cache: %s", err)
+	}
+
+	return cnt, nil
+}
+
+func (c *APICache) fetch(url string) ([]byte, error) {
+	resp, err := c.Client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
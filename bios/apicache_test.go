@@ -0,0 +1,63 @@
+package bios
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPICache(t *testing.T) {
+	hits := 0
+	failing := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		hits++
+		fmt.Fprintf(w, "response %d", hits)
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "apicache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cache := NewAPICache(dir, time.Hour, false)
+
+	cnt, err := cache.Get(ts.URL + "/user")
+	assert.NoError(t, err)
+	assert.Equal(t, "response 1", string(cnt))
+
+	cnt, err = cache.Get(ts.URL + "/user")
+	assert.NoError(t, err)
+	assert.Equal(t, "response 1", string(cnt))
+	assert.Equal(t, 1, hits)
+
+	// Expired, but the API rate-limits us: serve the stale response.
+	cache.TTL = 0
+	failing = true
+	cnt, err = cache.Get(ts.URL + "/user")
+	assert.NoError(t, err)
+	assert.Equal(t, "response 1", string(cnt))
+
+	failing = false
+	cnt, err = cache.Get(ts.URL + "/user")
+	assert.NoError(t, err)
+	assert.Equal(t, "response 2", string(cnt))
+
+	cache.Offline = true
+	cnt, err = cache.Get(ts.URL + "/user")
+	assert.NoError(t, err)
+	assert.Equal(t, "response 2", string(cnt))
+	assert.Equal(t, 2, hits)
+
+	_, err = cache.Get(ts.URL + "/other")
+	assert.Error(t, err)
+}
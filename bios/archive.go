@@ -0,0 +1,314 @@
+package bios
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+)
+
+// A launch archive is a `.tar.gz` holding everything needed to verify
+// a launch years later: the launch data and its contents (snapshot,
+// contracts, boot sequence, constitution), the transcript of this run,
+// the reports and the attestations (randomness, shuffle audit,
+// constitution acknowledgements, chain validation), along with a manifest of their
+// sha256, signed by the operator's seed network account.
+const (
+	archiveManifestFile  = "manifest.json"
+	archiveSignatureFile = "manifest.sig"
+)
+
+type ArchiveManifest struct {
+	CreatedAt      time.Time         `json:"created_at"`
+	Account        eos.AccountName   `json:"account"`
+	Role           string            `json:"role"`
+	LaunchDataHash string            `json:"launch_data_hash"`
+	TargetChainID  string            `json:"target_chain_id,omitempty"`
+	Files          map[string]string `json:"files"`
+	// Missing lists the launch contents that weren't in the local
+	// cache, and couldn't be archived.
+	Missing []string `json:"missing,omitempty"`
+}
+
+// ArchiveSignature is the signature of the manifest's sha256, by a key
+// of `account`'s `active` permission on the seed network.
+type ArchiveSignature struct {
+	Account        eos.AccountName `json:"account"`
+	ManifestSHA256 string          `json:"manifest_sha256"`
+	Signature      string          `json:"signature"`
+}
+
+// WriteLaunchArchive packages the launch into `filename`, signed with
+// `ArchiveKeys` when one of them is an active key of our seed network
+// account.
+func (b *BIOS) WriteLaunchArchive(filename string) (*ArchiveManifest, error) {
+	files, manifest, err := b.launchArchiveFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	for name, cnt := range files {
+		manifest.Files[name] = sha2(cnt)
+	}
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	files[archiveManifestFile] = manifestJSON
+
+	sig, err := b.signArchiveManifest(manifestJSON)
+	if err != nil {
+		b.Log.Printf("WARN: launch archive left unsigned: %s\n", err)
+	} else {
+		files[archiveSignatureFile], _ = json.MarshalIndent(sig, "", "  ")
+	}
+
+	if err := writeTarGz(filename, files, manifest.CreatedAt); err != nil {
+		return nil, fmt.Errorf("writing %q: %s", filename, err)
+	}
+
+	b.Log.Printf("Wrote launch archive with %d files to %q\n", len(manifest.Files), filename)
+	return manifest, nil
+}
+
+func (b *BIOS) launchArchiveFiles() (map[string][]byte, *ArchiveManifest, error) {
+	report := b.LaunchReport()
+	manifest := &ArchiveManifest{
+		CreatedAt:     time.Now().UTC().Truncate(time.Second),
+		Account:       b.Network.MyPeer.Discovery.SeedNetworkAccountName,
+		Role:          report.Role,
+		TargetChainID: report.TargetChainID,
+		Files:         map[string]string{},
+	}
+	files := map[string][]byte{}
+
+	addJSON := func(name string, v interface{}) error {
+		cnt, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encoding %s: %s", name, err)
+		}
+		files[name] = cnt
+		return nil
+	}
+
+	if b.LaunchDisco != nil {
+		manifest.LaunchDataHash = LaunchDataHash(b.LaunchDisco.TargetContents)
+		if err := addJSON("launch/discovery.json", b.LaunchDisco); err != nil {
+			return nil, nil, err
+		}
+
+		for _, content := range b.LaunchDisco.TargetContents {
+			cnt, err := b.Network.ReadFromCache(content.Ref)
+			if err != nil {
+				manifest.Missing = append(manifest.Missing, content.Name)
+				continue
+			}
+			files["launch/contents/"+filepath.Base(content.Name)] = cnt
+		}
+	}
+
+	for _, fileName := range []string{"output.log", "actions.jsonl", "missing_actions.jsonl"} {
+		if cnt, err := ioutil.ReadFile(fileName); err == nil {
+			files["transcript/"+fileName] = cnt
+		}
+	}
+	if b.ReportFile != "" {
+		if cnt, err := ioutil.ReadFile(b.ReportFile); err == nil {
+			files["reports/"+filepath.Base(b.ReportFile)] = cnt
+		}
+	}
+
+	if cnt, err := ioutil.ReadFile(ShuffleAuditFilename); err == nil {
+		files["attestations/"+ShuffleAuditFilename] = cnt
+	}
+	if err := addJSON("attestations/launch_report.json", report); err != nil {
+		return nil, nil, err
+	}
+	if err := addJSON("attestations/status.json", b.LaunchStatus()); err != nil {
+		return nil, nil, err
+	}
+
+	return files, manifest, nil
+}
+
+func (b *BIOS) signArchiveManifest(manifestJSON []byte) (*ArchiveSignature, error) {
+	if len(b.ArchiveKeys) == 0 {
+		return nil, fmt.Errorf("no seed network keys to sign with")
+	}
+
+	account := b.Network.MyPeer.Discovery.SeedNetworkAccountName
+	activeKeys, err := b.Network.ActivePublicKeys(account)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.Sum256(manifestJSON)
+	sig, err := signWithActiveKey(b.ArchiveKeys, activeKeys, hash[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &ArchiveSignature{
+		Account:        account,
+		ManifestSHA256: sha2(manifestJSON),
+		Signature:      sig.String(),
+	}, nil
+}
+
+func writeTarGz(filename string, files map[string][]byte, modTime time.Time) error {
+	fl, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer fl.Close()
+
+	gz := gzip.NewWriter(fl)
+	tw := tar.NewWriter(gz)
+
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		cnt := files[name]
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(cnt)), ModTime: modTime}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(cnt); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return fl.Close()
+}
+
+// VerifyLaunchArchive checks every file of the archive against its
+// manifest, and returns the manifest along with its signature, nil if
+// the archive isn't signed. Check the signature against the seed
+// network with `ArchiveSignature.Verify`.
+func VerifyLaunchArchive(filename string) (*ArchiveManifest, *ArchiveSignature, error) {
+	fl, err := os.Open(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer fl.Close()
+
+	gz, err := gzip.NewReader(fl)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading %q: %s", filename, err)
+	}
+
+	files := map[string][]byte{}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading %q: %s", filename, err)
+		}
+
+		cnt, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading %s: %s", header.Name, err)
+		}
+		files[header.Name] = cnt
+	}
+
+	manifestJSON, found := files[archiveManifestFile]
+	if !found {
+		return nil, nil, fmt.Errorf("no %s in the archive", archiveManifestFile)
+	}
+	var manifest *ArchiveManifest
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		return nil, nil, fmt.Errorf("decoding %s: %s", archiveManifestFile, err)
+	}
+
+	for name, hash := range manifest.Files {
+		cnt, found := files[name]
+		if !found {
+			return nil, nil, fmt.Errorf("%s is listed in the manifest, but missing from the archive", name)
+		}
+		if sha2(cnt) != hash {
+			return nil, nil, fmt.Errorf("%s has sha256 %s, manifest says %s", name, sha2(cnt), hash)
+		}
+	}
+	for name := range files {
+		if _, listed := manifest.Files[name]; !listed && name != archiveManifestFile && name != archiveSignatureFile {
+			return nil, nil, fmt.Errorf("%s isn't listed in the manifest", name)
+		}
+	}
+
+	sigJSON, found := files[archiveSignatureFile]
+	if !found {
+		return manifest, nil, nil
+	}
+	var sig *ArchiveSignature
+	if err := json.Unmarshal(sigJSON, &sig); err != nil {
+		return nil, nil, fmt.Errorf("decoding %s: %s", archiveSignatureFile, err)
+	}
+	if sig.ManifestSHA256 != sha2(manifestJSON) {
+		return nil, nil, fmt.Errorf("signature covers manifest %s, archive has %s", sig.ManifestSHA256, sha2(manifestJSON))
+	}
+
+	return manifest, sig, nil
+}
+
+// Verify checks the manifest was signed by a key of the account's
+// `active` permission on the seed network at `api`, and returns that
+// key.
+func (s *ArchiveSignature) Verify(api *eos.API) (string, error) {
+	return verifyActiveSignature(api, s.Account, s.ManifestSHA256, s.Signature)
+}
+
+// verifyActiveSignature checks `signature` is of the sha256 `sha256Hex`
+// by a key of the account's `active` permission on the seed network at
+// `api`, and returns that key.
+func verifyActiveSignature(api *eos.API, account eos.AccountName, sha256Hex, signature string) (string, error) {
+	sig, err := ecc.NewSignature(signature)
+	if err != nil {
+		return "", fmt.Errorf("invalid signature: %s", err)
+	}
+
+	hash, err := hex.DecodeString(sha256Hex)
+	if err != nil || len(hash) != sha256.Size {
+		return "", fmt.Errorf("invalid sha256 %q", sha256Hex)
+	}
+
+	pubKey, err := sig.PublicKey(hash)
+	if err != nil {
+		return "", fmt.Errorf("recovering public key: %s", err)
+	}
+
+	auth, err := activeAuthority(api, account)
+	if err != nil {
+		return "", err
+	}
+	for _, key := range auth.Keys {
+		if key.PublicKey.String() == pubKey.String() {
+			return pubKey.String(), nil
+		}
+	}
+
+	return pubKey.String(), fmt.Errorf("signed by %s, not in %s's active permission", pubKey, account)
+}
@@ -0,0 +1,75 @@
+package bios
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLaunchArchiveRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eos-bios-archive")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeArchive := func(manifest *ArchiveManifest, files map[string][]byte) string {
+		manifest.Files = map[string]string{}
+		for name, cnt := range files {
+			manifest.Files[name] = sha2(cnt)
+		}
+		manifestJSON, err := json.Marshal(manifest)
+		assert.NoError(t, err)
+
+		out := map[string][]byte{archiveManifestFile: manifestJSON}
+		for name, cnt := range files {
+			out[name] = cnt
+		}
+		filename := filepath.Join(dir, "archive.tar.gz")
+		assert.NoError(t, writeTarGz(filename, out, time.Now()))
+		return filename
+	}
+
+	files := map[string][]byte{
+		"launch/contents/snapshot.csv": []byte("0x1,EOS6...,1.0000 EOS\n"),
+		"transcript/output.log":        []byte("booting\n"),
+	}
+	filename := writeArchive(&ArchiveManifest{Account: "eosio", LaunchDataHash: "abcd"}, files)
+
+	manifest, sig, err := VerifyLaunchArchive(filename)
+	assert.NoError(t, err)
+	assert.Nil(t, sig)
+	assert.Equal(t, "abcd", manifest.LaunchDataHash)
+	assert.Len(t, manifest.Files, 2)
+
+	// A file that doesn't match its manifest entry
+	manifestJSON, _ := json.Marshal(&ArchiveManifest{Files: map[string]string{"transcript/output.log": sha2([]byte("other"))}})
+	assert.NoError(t, writeTarGz(filename, map[string][]byte{
+		archiveManifestFile:     manifestJSON,
+		"transcript/output.log": []byte("booting\n"),
+	}, time.Now()))
+	_, _, err = VerifyLaunchArchive(filename)
+	assert.Error(t, err)
+
+	// A file slipped in without being in the manifest
+	manifestJSON, _ = json.Marshal(&ArchiveManifest{Files: map[string]string{}})
+	assert.NoError(t, writeTarGz(filename, map[string][]byte{
+		archiveManifestFile: manifestJSON,
+		"extra.txt":         []byte("sneaky"),
+	}, time.Now()))
+	_, _, err = VerifyLaunchArchive(filename)
+	assert.Error(t, err)
+
+	// A signature over another manifest
+	manifestJSON, _ = json.Marshal(&ArchiveManifest{Files: map[string]string{}})
+	sigJSON, _ := json.Marshal(&ArchiveSignature{Account: "eosio", ManifestSHA256: sha2([]byte("other"))})
+	assert.NoError(t, writeTarGz(filename, map[string][]byte{
+		archiveManifestFile:  manifestJSON,
+		archiveSignatureFile: sigJSON,
+	}, time.Now()))
+	_, _, err = VerifyLaunchArchive(filename)
+	assert.Error(t, err)
+}
@@ -0,0 +1,399 @@
+package bios
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ArtifactStore publishes launch artifacts (launch reports, logs,
+// kickstart chunks) to infrastructure a team already operates.
+type ArtifactStore interface {
+	// Put stores `content` under `name`, and returns where it landed.
+	Put(name string, content []byte) (location string, err error)
+}
+
+// NewArtifactStore sets up a store from its URL:
+//
+//	s3://bucket/prefix[?region=us-east-1&endpoint=https://...]
+//	gs://bucket/prefix
+//	ipfs://host:port       (IPFS node API)
+//	sftp://user@host[:port]/directory
+//	file:///directory (file:///C:/directory on Windows), or a local directory
+//
+// Credentials come from the environment: AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN for S3,
+// GCS_ACCESS_TOKEN (or the instance's service account) for GCS, and
+// your SSH agent or config for SFTP.
+func NewArtifactStore(spec string) (ArtifactStore, error) {
+	if !strings.Contains(spec, "://") {
+		return &fileArtifactStore{dir: localPath(spec)}, nil
+	}
+
+	u, err := url.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid artifact store %q: %s", spec, err)
+	}
+	prefix := strings.Trim(u.Path, "/")
+
+	switch u.Scheme {
+	case "s3":
+		region := u.Query().Get("region")
+		if region == "" {
+			region = os.Getenv("AWS_REGION")
+		}
+		if region == "" {
+			region = "us-east-1"
+		}
+		endpoint := u.Query().Get("endpoint")
+		if endpoint == "" {
+			endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+		}
+		return &s3ArtifactStore{
+			endpoint:     strings.TrimSuffix(endpoint, "/"),
+			bucket:       u.Host,
+			prefix:       prefix,
+			region:       region,
+			accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+			secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		}, nil
+	case "gs":
+		return &gcsArtifactStore{
+			baseURL: "https://storage.googleapis.com",
+			bucket:  u.Host,
+			prefix:  prefix,
+			token:   os.Getenv("GCS_ACCESS_TOKEN"),
+		}, nil
+	case "ipfs":
+		return &ipfsArtifactStore{apiURL: "http://" + u.Host}, nil
+	case "sftp":
+		return &sftpArtifactStore{user: u.User.Username(), host: u.Hostname(), port: u.Port(), dir: u.Path}, nil
+	case "file":
+		return &fileArtifactStore{dir: localPath(spec)}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported artifact store %q, expected s3://, gs://, ipfs://, sftp:// or a local directory", spec)
+}
+
+// publishArtifact sends an artifact to all the configured stores.
+// Failing stores are reported, but never stop the launch.
+func (b *BIOS) publishArtifact(name string, content []byte) {
+	for _, store := range b.ArtifactStores {
+		location, err := store.Put(name, content)
+		if err != nil {
+			b.Log.Printf("WARN: publishing artifact %q: %s\n", name, err)
+			continue
+		}
+		b.Log.Printf("Published artifact %q to %s\n", name, location)
+	}
+}
+
+// publishLaunchArtifacts writes the launch archive, and sends it
+// along with the launch report and the log of this run to the artifact
+// stores.
+func (b *BIOS) publishLaunchArtifacts() {
+	if b.ArchiveFile != "" {
+		if _, err := b.WriteLaunchArchive(b.ArchiveFile); err != nil {
+			b.Log.Printf("WARN: writing launch archive: %s\n", err)
+		}
+	}
+
+	if len(b.ArtifactStores) == 0 {
+		return
+	}
+
+	for _, fileName := range []string{b.ReportFile, "output.log", b.ArchiveFile} {
+		if fileName == "" {
+			continue
+		}
+
+		cnt, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			b.Log.Printf("WARN: reading artifact %q: %s\n", fileName, err)
+			continue
+		}
+		b.publishArtifact(filepath.Base(fileName), cnt)
+	}
+}
+
+type fileArtifactStore struct {
+	dir string
+}
+
+func (s *fileArtifactStore) Put(name string, content []byte) (string, error) {
+	if err := os.MkdirAll(s.dir, 0777); err != nil {
+		return "", err
+	}
+
+	fileName := filepath.Join(s.dir, name)
+	if err := ioutil.WriteFile(fileName, content, 0644); err != nil {
+		return "", err
+	}
+	return fileName, nil
+}
+
+type s3ArtifactStore struct {
+	endpoint     string
+	bucket       string
+	prefix       string
+	region       string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+}
+
+func (s *s3ArtifactStore) Put(name string, content []byte) (string, error) {
+	if s.accessKey == "" || s.secretKey == "" {
+		return "", fmt.Errorf("missing AWS_ACCESS_KEY_ID or AWS_SECRET_ACCESS_KEY")
+	}
+
+	key := path.Join(s.prefix, name)
+	req, err := http.NewRequest("PUT", s.endpoint+"/"+s.bucket+"/"+awsURIEncode(key, false), bytes.NewReader(content))
+	if err != nil {
+		return "", err
+	}
+
+	payloadHash := sha2(content)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+	signAWSv4(req, payloadHash, s.accessKey, s.secretKey, s.region, "s3", time.Now())
+
+	if err := doArtifactRequest(req); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("s3://%s/%s", s.bucket, key), nil
+}
+
+// signAWSv4 adds the AWS Signature Version 4 authorization to `req`.
+func signAWSv4(req *http.Request, payloadHash, accessKey, secretKey, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(req.Header.Get(name))
+		}
+	}
+	var names []string
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders string
+	for _, name := range names {
+		canonicalHeaders += name + ":" + headers[name] + "\n"
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalURI := req.URL.EscapedPath()
+	if canonicalURI == "" {
+		canonicalURI = "/"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha2([]byte(canonicalRequest))
+
+	signingKey := hmacSHA256([]byte("AWS4"+secretKey), date)
+	signingKey = hmacSHA256(signingKey, region)
+	signingKey = hmacSHA256(signingKey, service)
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// awsURIEncode escapes everything but unreserved characters, and
+// slashes unless `encodeSlash`.
+func awsURIEncode(in string, encodeSlash bool) string {
+	var out strings.Builder
+	for _, c := range []byte(in) {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			out.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			out.WriteByte(c)
+		default:
+			fmt.Fprintf(&out, "%%%02X", c)
+		}
+	}
+	return out.String()
+}
+
+type gcsArtifactStore struct {
+	baseURL string
+	bucket  string
+	prefix  string
+	token   string
+}
+
+func (s *gcsArtifactStore) Put(name string, content []byte) (string, error) {
+	token := s.token
+	if token == "" {
+		var err error
+		if token, err = gcsMetadataToken(); err != nil {
+			return "", fmt.Errorf("no GCS_ACCESS_TOKEN, and no instance service account: %s", err)
+		}
+	}
+
+	object := path.Join(s.prefix, name)
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=media&name=%s", s.baseURL, s.bucket, url.QueryEscape(object)), bytes.NewReader(content))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	if err := doArtifactRequest(req); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("gs://%s/%s", s.bucket, object), nil
+}
+
+func gcsMetadataToken() (string, error) {
+	req, err := http.NewRequest("GET", "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
+type ipfsArtifactStore struct {
+	apiURL string
+}
+
+func (s *ipfsArtifactStore) Put(name string, content []byte) (string, error) {
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	part, err := form.CreateFormFile("file", name)
+	if err != nil {
+		return "", err
+	}
+	part.Write(content)
+	if err := form.Close(); err != nil {
+		return "", err
+	}
+
+	resp, err := http.Post(s.apiURL+"/api/v0/add?pin=true", form.FormDataContentType(), body)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("ipfs add returned status %d", resp.StatusCode)
+	}
+
+	var added struct {
+		Hash string
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&added); err != nil {
+		return "", fmt.Errorf("decoding ipfs add response: %s", err)
+	}
+	return "/ipfs/" + added.Hash, nil
+}
+
+// sftpArtifactStore uses the system's `sftp` client, so SSH keys,
+// agents and known hosts work as they do for the operator.
+type sftpArtifactStore struct {
+	user string
+	host string
+	port string
+	dir  string
+}
+
+func (s *sftpArtifactStore) Put(name string, content []byte) (string, error) {
+	tmp, err := ioutil.TempFile("", "eos-bios-artifact")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	tmp.Close()
+
+	target := s.host
+	if s.user != "" {
+		target = s.user + "@" + s.host
+	}
+	args := []string{"-b", "-"}
+	if s.port != "" {
+		args = append(args, "-P", s.port)
+	}
+	args = append(args, target)
+
+	remote := path.Join(s.dir, name)
+	cmd := exec.Command("sftp", args...)
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("put %q %q\n", tmp.Name(), remote))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("sftp: %s: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	return fmt.Sprintf("sftp://%s%s", target, remote), nil
+}
+
+func doArtifactRequest(req *http.Request) error {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%s returned status %d: %s", req.URL.Host, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
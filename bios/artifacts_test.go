@@ -0,0 +1,72 @@
+package bios
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignAWSv4(t *testing.T) {
+	// `get-vanilla` from the AWS Signature Version 4 test suite.
+	req, err := http.NewRequest("GET", "https://example.amazonaws.com/", nil)
+	assert.NoError(t, err)
+
+	now, _ := time.Parse("20060102T150405Z", "20150830T123600Z")
+	signAWSv4(req, sha2(nil), "AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "us-east-1", "service", now)
+
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31", req.Header.Get("Authorization"))
+}
+
+func TestArtifactStores(t *testing.T) {
+	var gotPath, gotQuery, gotAuth string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery, gotAuth = r.URL.EscapedPath(), r.URL.RawQuery, r.Header.Get("Authorization")
+		gotBody, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	s3 := &s3ArtifactStore{endpoint: server.URL, bucket: "launch", prefix: "stage1", region: "us-east-1", accessKey: "AK", secretKey: "SK"}
+	location, err := s3.Put("report 1.md", []byte("report"))
+	assert.NoError(t, err)
+	assert.Equal(t, "s3://launch/stage1/report 1.md", location)
+	assert.Equal(t, "/launch/stage1/report%201.md", gotPath)
+	assert.Contains(t, gotAuth, "Credential=AK/")
+	assert.Equal(t, "report", string(gotBody))
+
+	_, err = (&s3ArtifactStore{endpoint: server.URL, bucket: "launch"}).Put("report.md", nil)
+	assert.Error(t, err)
+
+	gcs := &gcsArtifactStore{baseURL: server.URL, bucket: "launch", prefix: "stage1", token: "tok"}
+	location, err = gcs.Put("output.log", []byte("log"))
+	assert.NoError(t, err)
+	assert.Equal(t, "gs://launch/stage1/output.log", location)
+	assert.Equal(t, "/upload/storage/v1/b/launch/o", gotPath)
+	assert.Equal(t, "uploadType=media&name=stage1%2Foutput.log", gotQuery)
+	assert.Equal(t, "Bearer tok", gotAuth)
+
+	dir, err := ioutil.TempDir("", "eos-bios-artifacts")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := NewArtifactStore(filepath.Join(dir, "launch"))
+	assert.NoError(t, err)
+	location, err = store.Put("kickstart.chunks", []byte("chunks"))
+	assert.NoError(t, err)
+	cnt, err := ioutil.ReadFile(location)
+	assert.NoError(t, err)
+	assert.Equal(t, "chunks", string(cnt))
+
+	store, err = NewArtifactStore("s3://launch/stage1?region=eu-west-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://s3.eu-west-1.amazonaws.com", store.(*s3ArtifactStore).endpoint)
+
+	_, err = NewArtifactStore("ftp://example.com/launch")
+	assert.Error(t, err)
+}
@@ -0,0 +1,59 @@
+package bios
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/eoscanada/eos-bios/bios/disco"
+)
+
+// ProducerRoster is the canonical list of the producers of a launch,
+// assembled by the coordinator from the discovery file each team
+// submitted. Its JSON is valid YAML too.
+type ProducerRoster struct {
+	Producers []*disco.Discovery `json:"producers"`
+}
+
+// AssembleProducers validates each submission and sorts them, by seed
+// network account name, with their peer links sorted too, so the same
+// submissions always assemble the same roster. It returns the
+// conflicts between submissions instead of a roster when there are
+// any.
+func AssembleProducers(submissions []*disco.Discovery) (*ProducerRoster, []*ProducerConflict, error) {
+	var peers []*Peer
+	for _, discovery := range submissions {
+		if err := ValidateDiscovery(discovery); err != nil {
+			return nil, nil, fmt.Errorf("%s: %s", discovery.SeedNetworkAccountName, err)
+		}
+		peers = append(peers, &Peer{Discovery: discovery})
+	}
+
+	if conflicts := ProducerConflicts(peers); len(conflicts) != 0 {
+		return nil, conflicts, nil
+	}
+
+	roster := &ProducerRoster{}
+	for _, discovery := range submissions {
+		sorted := *discovery
+		sorted.SeedNetworkPeers = append([]*disco.PeerLink{}, discovery.SeedNetworkPeers...)
+		sort.SliceStable(sorted.SeedNetworkPeers, func(i, j int) bool {
+			return sorted.SeedNetworkPeers[i].Account < sorted.SeedNetworkPeers[j].Account
+		})
+		roster.Producers = append(roster.Producers, &sorted)
+	}
+	sort.Slice(roster.Producers, func(i, j int) bool {
+		return roster.Producers[i].SeedNetworkAccountName < roster.Producers[j].SeedNetworkAccountName
+	})
+
+	return roster, nil, nil
+}
+
+// Marshal returns the roster's canonical form.
+func (r *ProducerRoster) Marshal() ([]byte, error) {
+	cnt, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(cnt, '\n'), nil
+}
@@ -0,0 +1,65 @@
+package bios
+
+import (
+	"testing"
+
+	"github.com/eoscanada/eos-bios/bios/disco"
+	eos "github.com/eoscanada/eos-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssembleProducers(t *testing.T) {
+	submission := func(seed, target string, links ...string) *disco.Discovery {
+		discovery := &disco.Discovery{
+			SeedNetworkAccountName: AN(seed),
+			TargetAccountName:      AN(target),
+			TargetP2PAddress:       "none",
+			TargetHTTPAddress:      "http://localhost:8888",
+		}
+		discovery.TargetInitialAuthority.Owner.Keys = []eos.KeyWeight{{Weight: 1}}
+		discovery.TargetInitialAuthority.Active.Keys = []eos.KeyWeight{{Weight: 1}}
+		for _, link := range links {
+			discovery.SeedNetworkPeers = append(discovery.SeedNetworkPeers, &disco.PeerLink{Account: AN(link), Weight: 10})
+		}
+		return discovery
+	}
+
+	first := []*disco.Discovery{
+		submission("bpthree", "bpthreetargt", "bptwo", "bpone"),
+		submission("bpone", "bponetargett", "bptwo"),
+		submission("bptwo", "bptwotargett"),
+	}
+	roster, conflicts, err := AssembleProducers(first)
+	assert.NoError(t, err)
+	assert.Empty(t, conflicts)
+	if assert.Len(t, roster.Producers, 3) {
+		assert.Equal(t, AN("bpone"), roster.Producers[0].SeedNetworkAccountName)
+		assert.Equal(t, AN("bpthree"), roster.Producers[1].SeedNetworkAccountName)
+		assert.Equal(t, AN("bpone"), roster.Producers[1].SeedNetworkPeers[0].Account)
+	}
+	// Submissions are left untouched.
+	assert.Equal(t, AN("bptwo"), first[0].SeedNetworkPeers[0].Account)
+
+	cnt, err := roster.Marshal()
+	assert.NoError(t, err)
+
+	// Submission order doesn't matter.
+	other, _, err := AssembleProducers([]*disco.Discovery{
+		submission("bptwo", "bptwotargett"),
+		submission("bpone", "bponetargett", "bptwo"),
+		submission("bpthree", "bpthreetargt", "bpone", "bptwo"),
+	})
+	assert.NoError(t, err)
+	otherCnt, err := other.Marshal()
+	assert.NoError(t, err)
+	assert.Equal(t, string(cnt), string(otherCnt))
+
+	roster, conflicts, err = AssembleProducers(append(first, submission("bpone", "bptwotargett")))
+	assert.NoError(t, err)
+	assert.Nil(t, roster)
+	assert.Equal(t, "seed_network_account_name bpone: bpone, bpone", conflicts[0].String())
+	assert.Equal(t, "target_account_name bptwotargett: bpone, bptwo", conflicts[1].String())
+
+	_, _, err = AssembleProducers([]*disco.Discovery{submission("bpfour", "short")})
+	assert.EqualError(t, err, "bpfour: target_account_name should be 12 chars")
+}
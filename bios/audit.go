@@ -0,0 +1,167 @@
+package bios
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+)
+
+// Audit log events.
+const (
+	AuditPushed   = "pushed"
+	AuditIncluded = "included"
+)
+
+// AuditEntry is a line of the transaction audit log. A `pushed` line
+// holds a transaction the boot node signed and pushed, an `included`
+// line the block it was later found in, by the chain validation.
+type AuditEntry struct {
+	Event         string                 `json:"event"`
+	Step          string                 `json:"step,omitempty"`
+	Label         string                 `json:"label,omitempty"`
+	TransactionID string                 `json:"transaction_id"`
+	Transaction   *eos.SignedTransaction `json:"transaction,omitempty"`
+	SignedAt      *time.Time             `json:"signed_at,omitempty"`
+	PushedAt      *time.Time             `json:"pushed_at,omitempty"`
+	BlockNum      uint32                 `json:"block_num,omitempty"`
+	BlockTime     *time.Time             `json:"block_time,omitempty"`
+	LoggedAt      time.Time              `json:"logged_at"`
+}
+
+// AuditLog is an append-only JSONL file recording every transaction
+// pushed to the target chain, for the community to audit the launch.
+// It's never truncated: later runs (like a resumed boot) append to it.
+type AuditLog struct {
+	lock    sync.Mutex
+	file    *os.File
+	pending map[string]bool
+	signed  map[*eos.Action]*signedTransaction
+}
+
+// signedTransaction is a transaction signed, not yet pushed, known by
+// its first action (transactions are pushed concurrently).
+type signedTransaction struct {
+	tx *eos.SignedTransaction
+	at time.Time
+}
+
+// OpenAuditLog opens `filename` for appending, creating it if needed.
+func OpenAuditLog(filename string) (*AuditLog, error) {
+	fl, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditLog{file: fl, pending: map[string]bool{}, signed: map[*eos.Action]*signedTransaction{}}, nil
+}
+
+// Close closes the audit log file.
+func (a *AuditLog) Close() error {
+	if a == nil {
+		return nil
+	}
+	return a.file.Close()
+}
+
+// sign records a transaction signed, pushed next.
+func (a *AuditLog) sign(tx *eos.SignedTransaction) {
+	if a == nil || tx == nil || tx.Transaction == nil || len(tx.Actions) == 0 {
+		return
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.signed[tx.Actions[0]] = &signedTransaction{tx: tx, at: time.Now().UTC()}
+}
+
+// pushed logs the transaction `transactionID` of `step`, as signed,
+// once pushed.
+func (a *AuditLog) pushed(step *OperationType, transactionID string, actions []*eos.Action) error {
+	if a == nil {
+		return nil
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	now := time.Now().UTC()
+	entry := &AuditEntry{
+		Event:         AuditPushed,
+		Step:          step.Op,
+		Label:         step.Label,
+		TransactionID: transactionID,
+		PushedAt:      &now,
+	}
+	if len(actions) != 0 {
+		if signed := a.signed[actions[0]]; signed != nil {
+			entry.Transaction, entry.SignedAt = signed.tx, &signed.at
+			delete(a.signed, actions[0])
+		}
+	}
+	a.pending[transactionID] = true
+
+	return a.write(entry)
+}
+
+// included logs the block a pushed transaction was found in.
+func (a *AuditLog) included(transactionID string, blockNum uint32, blockTime time.Time) error {
+	if a == nil {
+		return nil
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if !a.pending[transactionID] {
+		return nil
+	}
+	delete(a.pending, transactionID)
+
+	blockTime = blockTime.UTC()
+	return a.write(&AuditEntry{
+		Event:         AuditIncluded,
+		TransactionID: transactionID,
+		BlockNum:      blockNum,
+		BlockTime:     &blockTime,
+	})
+}
+
+// write appends a line, synced to disk before moving on.
+func (a *AuditLog) write(entry *AuditEntry) error {
+	entry.LoggedAt = time.Now().UTC()
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if _, err := a.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return a.file.Sync()
+}
+
+// auditSigner hands the transactions it signs to the audit log.
+type auditSigner struct {
+	eos.Signer
+	audit *AuditLog
+}
+
+func (s *auditSigner) Sign(tx *eos.SignedTransaction, chainID []byte, requiredKeys ...ecc.PublicKey) (*eos.SignedTransaction, error) {
+	out, err := s.Signer.Sign(tx, chainID, requiredKeys...)
+	if err == nil {
+		s.audit.sign(out)
+	}
+	return out, err
+}
+
+// audit alerts when the audit log can't be written.
+func (b *BIOS) audit(transactionID string, err error) {
+	if err != nil {
+		b.Log.Printf("WARNING: writing audit log: %s\n", err)
+		b.status.alert("critical", fmt.Sprintf("audit log not written for transaction %s: %s", transactionID, err))
+	}
+}
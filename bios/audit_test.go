@@ -0,0 +1,145 @@
+package bios
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+	"github.com/stretchr/testify/assert"
+)
+
+type passthroughSigner struct{ eos.Signer }
+
+func (s passthroughSigner) Sign(tx *eos.SignedTransaction, chainID []byte, requiredKeys ...ecc.PublicKey) (*eos.SignedTransaction, error) {
+	return tx, nil
+}
+
+func readAuditLog(t *testing.T, filename string) (entries []*AuditEntry) {
+	fl, err := os.Open(filename)
+	assert.NoError(t, err)
+	defer fl.Close()
+
+	scanner := bufio.NewScanner(fl)
+	for scanner.Scan() {
+		var entry *AuditEntry
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	return
+}
+
+func TestAuditLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eos-bios-audit")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "audit.jsonl")
+
+	audit, err := OpenAuditLog(filename)
+	assert.NoError(t, err)
+
+	signer := &auditSigner{Signer: passthroughSigner{}, audit: audit}
+	actions := []*eos.Action{{Account: AN("eosio"), Name: eos.ActN("newaccount")}}
+	other := []*eos.Action{{Account: AN("eosio"), Name: eos.ActN("newaccount")}}
+	for _, acts := range [][]*eos.Action{other, actions} {
+		_, err = signer.Sign(&eos.SignedTransaction{Transaction: &eos.Transaction{Actions: acts}}, nil)
+		assert.NoError(t, err)
+	}
+
+	step := &OperationType{Op: "system.newaccount", Label: "Creating accounts"}
+	assert.NoError(t, audit.pushed(step, "abcd", actions))
+	assert.NoError(t, audit.included("ffff", 11, time.Now()))
+	blockTime := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	assert.NoError(t, audit.included("abcd", 12, blockTime))
+	assert.NoError(t, audit.included("abcd", 13, blockTime))
+	assert.NoError(t, audit.Close())
+
+	entries := readAuditLog(t, filename)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, AuditPushed, entries[0].Event)
+	assert.Equal(t, "system.newaccount", entries[0].Step)
+	assert.Equal(t, "abcd", entries[0].TransactionID)
+	if assert.NotNil(t, entries[0].Transaction) {
+		assert.Len(t, entries[0].Transaction.Actions, 1)
+	}
+	assert.NotNil(t, entries[0].SignedAt)
+	assert.NotNil(t, entries[0].PushedAt)
+	assert.Equal(t, AuditIncluded, entries[1].Event)
+	assert.Equal(t, uint32(12), entries[1].BlockNum)
+	assert.Equal(t, blockTime, *entries[1].BlockTime)
+
+	// Appended to, never truncated
+	audit, err = OpenAuditLog(filename)
+	assert.NoError(t, err)
+	assert.NoError(t, audit.pushed(step, "beef", nil))
+	assert.NoError(t, audit.Close())
+
+	entries = readAuditLog(t, filename)
+	assert.Len(t, entries, 3)
+	assert.Nil(t, entries[2].Transaction)
+
+	var none *AuditLog
+	assert.NoError(t, none.pushed(step, "beef", nil))
+	assert.NoError(t, none.included("beef", 1, blockTime))
+}
+
+func TestReadAuditLog(t *testing.T) {
+	entries, err := ReadAuditLog(strings.NewReader(`{"event": "pushed", "step": "system.newaccount", "transaction_id": "abcd"}
+
+{"event": "included", "transaction_id": "abcd", "block_num": 12}
+`))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, uint32(12), entries[1].BlockNum)
+
+	_, err = ReadAuditLog(strings.NewReader(`{"event": "deleted", "transaction_id": "abcd"}`))
+	assert.EqualError(t, err, `line 1: unknown event "deleted"`)
+
+	_, err = ReadAuditLog(strings.NewReader("{\"event\": \"pushed\"}\nnot json\n"))
+	assert.Error(t, err)
+}
+
+func TestVerifyAuditLog(t *testing.T) {
+	var blocksRead []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/get_info") {
+			fmt.Fprint(w, `{"head_block_num": 4}`)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		blocksRead = append(blocksRead, string(body))
+		fmt.Fprint(w, `{"transactions": []}`)
+	}))
+	defer server.Close()
+
+	entries := []*AuditEntry{
+		{Event: AuditPushed, TransactionID: "abcd"},
+		{Event: AuditIncluded, TransactionID: "abcd", BlockNum: 2},
+		{Event: AuditIncluded, TransactionID: "beef", BlockNum: 3},
+	}
+	verification, err := VerifyAuditLog(eos.New(server.URL), entries)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, verification.Transactions)
+	assert.Equal(t, uint32(3), verification.LastBlock)
+	assert.Len(t, blocksRead, 3)
+	assert.Equal(t, []string{
+		"transaction beef claimed in block 3 was never logged as pushed",
+		"transaction abcd not found in blocks 1 to 3",
+	}, verification.Problems)
+
+	// Unclaimed transactions are looked up to the head block.
+	blocksRead = nil
+	verification, err = VerifyAuditLog(eos.New(server.URL), entries[:1])
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(4), verification.LastBlock)
+	assert.Len(t, blocksRead, 4)
+}
@@ -0,0 +1,132 @@
+package bios
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	eos "github.com/eoscanada/eos-go"
+)
+
+// ReadAuditLog parses the lines of an audit log (see AuditLog).
+func ReadAuditLog(r io.Reader) (entries []*AuditEntry, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024) // signed transactions can be large (setcode)
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var entry *AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+		if entry.Event != AuditPushed && entry.Event != AuditIncluded {
+			return nil, fmt.Errorf("line %d: unknown event %q", line, entry.Event)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// AuditVerification is the outcome of VerifyAuditLog.
+type AuditVerification struct {
+	Transactions int
+	// LastBlock ends the boot window, from block 1 to the last block
+	// claimed by the audit log, or to the head block when some of its
+	// transactions are claimed in none.
+	LastBlock uint32
+	Problems  []string
+}
+
+// VerifyAuditLog checks, on the chain of `api`, that every transaction
+// pushed in the audit log is in the block its `included` line claims,
+// and that no other transaction of the boot window has actions
+// authorized by `eosio`, apart from the boot lease marker.
+func VerifyAuditLog(api *eos.API, entries []*AuditEntry) (*AuditVerification, error) {
+	out := &AuditVerification{}
+
+	pushed := map[string]bool{}
+	claimed := map[string]uint32{}
+	var order []string
+	for _, entry := range entries {
+		switch entry.Event {
+		case AuditPushed:
+			if !pushed[entry.TransactionID] {
+				order = append(order, entry.TransactionID)
+			}
+			pushed[entry.TransactionID] = true
+		case AuditIncluded:
+			claimed[entry.TransactionID] = entry.BlockNum
+			if entry.BlockNum > out.LastBlock {
+				out.LastBlock = entry.BlockNum
+			}
+		}
+	}
+	for _, entry := range entries {
+		if entry.Event == AuditIncluded && !pushed[entry.TransactionID] {
+			out.Problems = append(out.Problems, fmt.Sprintf("transaction %s claimed in block %d was never logged as pushed", entry.TransactionID, entry.BlockNum))
+		}
+	}
+
+	unclaimed := false
+	for _, transactionID := range order {
+		unclaimed = unclaimed || claimed[transactionID] == 0
+	}
+	if unclaimed {
+		// Transactions weren't seen in blocks by the boot node (like
+		// when its validation was cut short), look up to the head block.
+		info, err := api.GetInfo()
+		if err != nil {
+			return nil, fmt.Errorf("get info: %s", err)
+		}
+		out.LastBlock = info.HeadBlockNum
+	}
+
+	found := map[string]uint32{}
+	for blockNum := uint32(1); blockNum <= out.LastBlock; blockNum++ {
+		block, err := api.GetBlockByNum(blockNum)
+		if err != nil {
+			return nil, fmt.Errorf("get block %d: %s", blockNum, err)
+		}
+
+		for _, receipt := range block.Transactions {
+			transactionID := receipt.Transaction.ID.String()
+			found[transactionID] = blockNum
+			if pushed[transactionID] {
+				continue
+			}
+
+			unpacked, err := receipt.Transaction.Packed.Unpack()
+			if err != nil {
+				return nil, fmt.Errorf("unpacking transaction %s in block %d: %s", transactionID, blockNum, err)
+			}
+			for _, act := range unpacked.Actions {
+				if isBootLeaseMarker(act) {
+					continue
+				}
+				for _, auth := range act.Authorization {
+					if auth.Actor == AN("eosio") {
+						out.Problems = append(out.Problems, fmt.Sprintf("block %d: transaction %s, missing from the audit log, has %s::%s authorized by eosio@%s", blockNum, transactionID, act.Account, act.Name, auth.Permission))
+					}
+				}
+			}
+		}
+	}
+
+	for _, transactionID := range order {
+		blockNum, ok := found[transactionID]
+		switch {
+		case !ok:
+			out.Problems = append(out.Problems, fmt.Sprintf("transaction %s not found in blocks 1 to %d", transactionID, out.LastBlock))
+		case claimed[transactionID] != 0 && claimed[transactionID] != blockNum:
+			out.Problems = append(out.Problems, fmt.Sprintf("transaction %s is in block %d, the audit log claims block %d", transactionID, blockNum, claimed[transactionID]))
+		default:
+			out.Transactions++
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,132 @@
+package bios
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/eoscanada/eos-bios/bios/beacon"
+)
+
+// BeaconPollInterval is how often a drand or NIST beacon is polled
+// while waiting for the launch round.
+var BeaconPollInterval = 5 * time.Second
+
+// DrandConfig is the boot sequence's `drand` section, for the `drand`
+// entropy provider: the `round` seeding the shuffle, read from every
+// relay of `urls` (`beacon.DefaultDrandURLs` when empty), on the
+// `chain_hash` chain (the relays' default one when empty).
+type DrandConfig struct {
+	Round     uint64   `json:"round"`
+	URLs      []string `json:"urls"`
+	ChainHash string   `json:"chain_hash"`
+}
+
+func (c *DrandConfig) validate() error {
+	if c == nil || c.Round == 0 {
+		return fmt.Errorf("entropy_provider: `drand` needs the `drand` section's `round`")
+	}
+	if c.Round > math.MaxUint32 {
+		return fmt.Errorf("drand: round %d too large", c.Round)
+	}
+	return nil
+}
+
+// NISTConfig is the boot sequence's `nist` section, for the `nist`
+// entropy provider: the `pulse` index seeding the shuffle, on the
+// NIST Randomness Beacon 2.0 `chain`, read from every endpoint of
+// `urls` (`beacon.DefaultNISTURLs` when empty).
+type NISTConfig struct {
+	Pulse uint64   `json:"pulse"`
+	Chain uint64   `json:"chain"`
+	URLs  []string `json:"urls"`
+}
+
+func (c *NISTConfig) validate() error {
+	if c == nil || c.Pulse == 0 || c.Chain == 0 {
+		return fmt.Errorf("entropy_provider: `nist` needs the `nist` section's `chain` and `pulse`")
+	}
+	if c.Pulse > math.MaxUint32 {
+		return fmt.Errorf("nist: pulse %d too large", c.Pulse)
+	}
+	return nil
+}
+
+type drandEntropy struct {
+	b      *BIOS
+	config *DrandConfig
+}
+
+func (e *drandEntropy) Name() string { return EntropyDrand }
+
+func (e *drandEntropy) Wait() (*RandomnessProof, error) {
+	client := &beacon.Drand{URLs: e.config.URLs, ChainHash: e.config.ChainHash}
+	return e.b.waitBeacon(EntropyDrand, "drand round", "drand randomness", e.config.Round, client.Round)
+}
+
+type nistEntropy struct {
+	b      *BIOS
+	config *NISTConfig
+}
+
+func (e *nistEntropy) Name() string { return EntropyNIST }
+
+func (e *nistEntropy) Wait() (*RandomnessProof, error) {
+	client := &beacon.NIST{URLs: e.config.URLs, Chain: e.config.Chain}
+	return e.b.waitBeacon(EntropyNIST, fmt.Sprintf("NIST beacon chain %d pulse", e.config.Chain), "NIST pulse output value", e.config.Pulse, client.Pulse)
+}
+
+// waitBeacon polls a beacon until `round` is out, and derives the seed
+// from the first 8 bytes of its output.
+func (b *BIOS) waitBeacon(provider, source, output string, round uint64, fetch func(uint64) (*beacon.Output, error)) (*RandomnessProof, error) {
+	b.Log.Printf("Polling the %s beacon until round %d\n", provider, round)
+	b.status.phase("waiting for launch block")
+
+	var rounds int
+	for {
+		b.markProgress("waiting for launch block")
+		rounds++
+
+		out, err := fetch(round)
+		if _, disagree := err.(*beacon.DisagreementError); disagree {
+			return nil, err
+		}
+		if err != nil {
+			b.Log.Printf("- %s %d not available: %s\n", source, round, err)
+			time.Sleep(BeaconPollInterval)
+			continue
+		}
+
+		seed, steps := beaconSeed(output, out.Value)
+		b.Log.Printf("- got %s %d - output is %s\n", source, round, hex.EncodeToString(out.Value))
+		b.printSeed(steps)
+
+		proof := &RandomnessProof{
+			Provider:       provider,
+			Source:         source,
+			BlockNum:       uint32(round),
+			BlockHash:      hex.EncodeToString(out.Value),
+			Seed:           seed,
+			SeedDerivation: fmt.Sprintf("first 8 bytes of the %s, as a big-endian int64", output),
+			Policy:         EntropyWait,
+			Decision:       fmt.Sprintf("all %d endpoints agree", len(out.Sources)),
+			Rounds:         rounds,
+		}
+		for _, src := range out.Sources {
+			proof.Observations = append(proof.Observations, &EntropyObservation{Source: src, BlockHash: proof.BlockHash})
+		}
+		return proof, nil
+	}
+}
+
+func beaconSeed(output string, value []byte) (seed int64, steps []string) {
+	seed = int64(binary.BigEndian.Uint64(value[:8]))
+	steps = []string{
+		fmt.Sprintf("%s: %s", output, hex.EncodeToString(value)),
+		fmt.Sprintf("first 8 bytes: %s", hex.EncodeToString(value[:8])),
+		fmt.Sprintf("seed (big-endian int64): %d", seed),
+	}
+	return
+}
@@ -0,0 +1,193 @@
+package beacon
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Output is a beacon's random value for a round (drand) or a pulse
+// (NIST), as answered by one or more agreeing endpoints.
+type Output struct {
+	Round     uint64
+	Value     []byte
+	Signature string
+	Time      time.Time
+	Sources   []string
+}
+
+// NotYetError means the round or pulse hasn't been published yet.
+type NotYetError struct {
+	Source string
+	Round  uint64
+}
+
+func (e *NotYetError) Error() string {
+	return fmt.Sprintf("%s: round %d not published yet", e.Source, e.Round)
+}
+
+// DisagreementError means two endpoints answered different values
+// for the same round.
+type DisagreementError struct {
+	Round   uint64
+	Answers map[string]string
+}
+
+func (e *DisagreementError) Error() string {
+	var answers []string
+	for source, value := range e.Answers {
+		answers = append(answers, fmt.Sprintf("%s: %s", source, value))
+	}
+	return fmt.Sprintf("endpoints disagree on round %d: %s", e.Round, strings.Join(answers, ", "))
+}
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// DefaultDrandURLs are drand HTTP relays of the League of Entropy, run
+// by independent operators.
+var DefaultDrandURLs = []string{
+	"https://api.drand.sh",
+	"https://drand.cloudflare.com",
+}
+
+// Drand reads a round of a drand beacon. ChainHash selects the chain,
+// the relays' default one when empty.
+type Drand struct {
+	URLs      []string
+	ChainHash string
+}
+
+// Round fetches `round` from every relay, checks its randomness is the
+// sha256 of its signature, and that they all agree.
+func (d *Drand) Round(round uint64) (*Output, error) {
+	urls := d.URLs
+	if len(urls) == 0 {
+		urls = DefaultDrandURLs
+	}
+
+	path := fmt.Sprintf("/public/%d", round)
+	if d.ChainHash != "" {
+		path = "/" + d.ChainHash + path
+	}
+
+	return agree(round, urls, func(url string) (*Output, error) {
+		var resp struct {
+			Round      uint64 `json:"round"`
+			Randomness string `json:"randomness"`
+			Signature  string `json:"signature"`
+		}
+		if err := getJSON(url, path, round, &resp); err != nil {
+			return nil, err
+		}
+		if resp.Round != round {
+			return nil, fmt.Errorf("%s: answered round %d instead of %d", url, resp.Round, round)
+		}
+
+		value, err := hex.DecodeString(resp.Randomness)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid randomness: %s", url, err)
+		}
+		signature, err := hex.DecodeString(resp.Signature)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid signature: %s", url, err)
+		}
+		if sum := sha256.Sum256(signature); !bytes.Equal(sum[:], value) {
+			return nil, fmt.Errorf("%s: randomness of round %d isn't the sha256 of its signature", url, round)
+		}
+
+		return &Output{Round: round, Value: value, Signature: resp.Signature}, nil
+	})
+}
+
+// DefaultNISTURLs is the NIST Randomness Beacon 2.0.
+var DefaultNISTURLs = []string{"https://beacon.nist.gov"}
+
+// NIST reads a pulse of a NIST Randomness Beacon 2.0 chain.
+type NIST struct {
+	URLs  []string
+	Chain uint64
+}
+
+// Pulse fetches pulse `index` from every endpoint, and checks they
+// all agree on its output value.
+func (n *NIST) Pulse(index uint64) (*Output, error) {
+	urls := n.URLs
+	if len(urls) == 0 {
+		urls = DefaultNISTURLs
+	}
+
+	path := fmt.Sprintf("/beacon/2.0/chain/%d/pulse/%d", n.Chain, index)
+
+	return agree(index, urls, func(url string) (*Output, error) {
+		var resp struct {
+			Pulse struct {
+				PulseIndex     uint64    `json:"pulseIndex"`
+				TimeStamp      time.Time `json:"timeStamp"`
+				SignatureValue string    `json:"signatureValue"`
+				OutputValue    string    `json:"outputValue"`
+			} `json:"pulse"`
+		}
+		if err := getJSON(url, path, index, &resp); err != nil {
+			return nil, err
+		}
+		if resp.Pulse.PulseIndex != index {
+			return nil, fmt.Errorf("%s: answered pulse %d instead of %d", url, resp.Pulse.PulseIndex, index)
+		}
+
+		value, err := hex.DecodeString(resp.Pulse.OutputValue)
+		if err != nil || len(value) != 64 {
+			return nil, fmt.Errorf("%s: invalid output value %q", url, resp.Pulse.OutputValue)
+		}
+
+		return &Output{Round: index, Value: value, Signature: strings.ToLower(resp.Pulse.SignatureValue), Time: resp.Pulse.TimeStamp}, nil
+	})
+}
+
+// agree fetches from every URL, and makes sure they answered the same
+// value.
+func agree(round uint64, urls []string, fetch func(url string) (*Output, error)) (*Output, error) {
+	var out *Output
+	answers := map[string]string{}
+	for _, url := range urls {
+		output, err := fetch(strings.TrimSuffix(url, "/"))
+		if err != nil {
+			return nil, err
+		}
+
+		answers[url] = hex.EncodeToString(output.Value)
+		if out == nil {
+			out = output
+		} else if !bytes.Equal(out.Value, output.Value) {
+			return nil, &DisagreementError{Round: round, Answers: answers}
+		}
+		out.Sources = append(out.Sources, url)
+	}
+	return out, nil
+}
+
+func getJSON(url, path string, round uint64, out interface{}) error {
+	resp, err := httpClient.Get(url + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case 200:
+	case 404, 425:
+		// drand answers 425 Too Early, NIST 404, for rounds to come.
+		return &NotYetError{Source: url, Round: round}
+	default:
+		return fmt.Errorf("%s: GET %s returned status %d", url, path, resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s: decoding %s: %s", url, path, err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package bios
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/eoscanada/eos-bios/bios/beacon"
+	"github.com/stretchr/testify/assert"
+)
+
+func drandServer(signature string) *httptest.Server {
+	sig, _ := hex.DecodeString(signature)
+	randomness := sha256.Sum256(sig)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/public/42":
+			fmt.Fprintf(w, `{"round":42,"randomness":%q,"signature":%q}`, hex.EncodeToString(randomness[:]), signature)
+		case "/public/43":
+			w.WriteHeader(425)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestDrandBeacon(t *testing.T) {
+	relay1 := drandServer("aabbcc")
+	defer relay1.Close()
+	relay2 := drandServer("aabbcc")
+	defer relay2.Close()
+
+	client := &beacon.Drand{URLs: []string{relay1.URL, relay2.URL}}
+	out, err := client.Round(42)
+	assert.NoError(t, err)
+	assert.Len(t, out.Sources, 2)
+
+	_, err = client.Round(43)
+	assert.IsType(t, &beacon.NotYetError{}, err)
+
+	liar := drandServer("ddeeff")
+	defer liar.Close()
+	_, err = (&beacon.Drand{URLs: []string{relay1.URL, liar.URL}}).Round(42)
+	assert.IsType(t, &beacon.DisagreementError{}, err)
+
+	seed, steps := beaconSeed("drand randomness", out.Value)
+	assert.Len(t, steps, 3)
+	again, _ := beaconSeed("drand randomness", out.Value)
+	assert.Equal(t, seed, again)
+}
+
+func TestNewEntropyProvider(t *testing.T) {
+	b := &BIOS{}
+
+	provider, err := b.newEntropyProvider("", nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, EntropySeedNetwork, provider.Name())
+
+	_, err = b.newEntropyProvider("bitcoin", nil, nil)
+	assert.Error(t, err)
+
+	b.LaunchBTCBlockHeight = 530000
+	provider, err = b.newEntropyProvider("", nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, EntropyBitcoin, provider.Name())
+
+	_, err = b.newEntropyProvider("drand", nil, nil)
+	assert.Error(t, err)
+	provider, err = b.newEntropyProvider("drand", &DrandConfig{Round: 42}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, EntropyDrand, provider.Name())
+
+	_, err = b.newEntropyProvider("nist", nil, &NISTConfig{Pulse: 1})
+	assert.Error(t, err)
+
+	_, err = b.newEntropyProvider("dice", nil, nil)
+	assert.Error(t, err)
+}
@@ -11,6 +11,7 @@ import (
 	"math/rand"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/eoscanada/eos-bios/bios/disco"
@@ -22,30 +23,224 @@ type BIOS struct {
 	Network *Network
 	// MyPeers represent the peers my local node will handle. It is
 	// plural because when launching a 3-node network, your peer will
-	// be cloned a few time to have a full schedule of 21 producers.
+	// be cloned a few time to have a full schedule of producers.
 	MyPeers []*Peer
 
 	SingleOnly               bool
 	OverrideBootSequenceFile string
 	Log                      *Logger
 
-	LaunchDisco        *disco.Discovery
-	TargetNetAPI       *eos.API
-	Snapshot           Snapshot
-	BootSequence       []*OperationType
-	WriteActions       bool
-	HackVotingAccounts bool
-	ReuseGenesis       bool
+	// Hooks are shell commands run for hooks, by hook name (see
+	// ParseHooks), instead of `hook_[name]` files.
+	Hooks map[string]string
+	// Webhooks are URLs POSTed a WebhookPayload for hooks, by hook
+	// name (see ParseWebhooks), on top of their shell hooks.
+	Webhooks map[string]string
+
+	// BootSequenceSHA256, when set, pins the boot sequence: loading
+	// one with another sha256 fails.
+	BootSequenceSHA256 string
+	BootSequenceHash   string
+	// BootSequenceSignatureQuorum, when set, is how many producers of
+	// the network must have signed the boot sequence (see
+	// ParseSignatureQuorum and BootSequenceSignaturesName).
+	BootSequenceSignatureQuorum string
+
+	// ReadOnly guarantees nothing gets signed or broadcast, for
+	// third-party auditors running against a live launch.
+	ReadOnly bool
+
+	LaunchDisco       *disco.Discovery
+	TargetNetAPI      *eos.API
+	Snapshot          Snapshot
+	BootSequence      []*OperationType
+	SnapshotTransform *ScriptRef
+	Profile           *Profile
+
+	// SnapshotConfig is the boot sequence's `snapshot` section,
+	// checked against the snapshot before injecting it.
+	SnapshotConfig *SnapshotConfig
+
+	// DNSSeeds are domains publishing p2p endpoints, as a fallback
+	// discovery channel, in addition to the boot sequence's `dns_seeds`.
+	DNSSeeds              []string
+	dnsSeedAddresses      map[eos.AccountName]string
+	dnsSeedExtraAddresses []string
+	WriteActions          bool
+	HackVotingAccounts    bool
+	ReuseGenesis          bool
+
+	// TargetReadyTimeout is how long to wait for the target node to
+	// produce blocks before the boot sequence, forever when zero.
+	TargetReadyTimeout time.Duration
+
+	// RegProducer, when set, registers our target account as a
+	// producer once the system contract is live, for the appointed
+	// block producers and participants.
+	RegProducer *RegProducerConfig
+
+	// AuditLog, when set, records every transaction pushed to the
+	// target chain, and the block it landed in.
+	AuditLog *AuditLog
+
+	// KickstartChunkSize, when set, splits the kickstart payload
+	// (genesis and p2p addresses) into compressed chunks of that many
+	// characters, for channels with limited message sizes.
+	KickstartChunkSize    int
+	kickstartP2PAddresses []string
+
+	// KickstartPGP encrypts the kickstart payload, with the ephemeral
+	// `eosio` key, to the appointed producers' PGP keys.
+	KickstartPGP bool
+
+	// KickstartIPFSAPI, when set, is the IPFS node API the encrypted
+	// kickstart payload is added to, and KickstartPinningService an
+	// IPFS Pinning Service API pinning it. KickstartIPFS is the CID of
+	// such a payload, and KickstartFile such a payload, to join with
+	// instead of waiting for the genesis. It's decrypted with the
+	// armored PGP secret key of KickstartKeyring, its passphrase from
+	// KickstartPassphrase, or else with the local `gpg`.
+	KickstartIPFSAPI        string
+	KickstartPinningService string
+	KickstartIPFS           string
+	KickstartFile           string
+	KickstartKeyring        []byte
+	KickstartPassphrase     func() (string, error)
+
+	// CanaryTransactions is the number of transactions of high-volume
+	// steps pushed and verified on chain before the rest.
+	CanaryTransactions int
+
+	// InjectionConcurrency is the number of transactions pushed at
+	// once, for steps that allow it (see ConcurrentOperation).
+	// InjectionMaxTPS caps the transactions pushed per second, 0 for
+	// no limit.
+	InjectionConcurrency int
+	InjectionMaxTPS      float64
+
+	// AppointedProducers is the size of the appointed producer
+	// schedule, from the boot sequence's `appointed_producers`.
+	AppointedProducers int
+
+	// AppointedSelection is the boot sequence's
+	// `appointed_selection` section, nil to appoint the top of the
+	// shuffle.
+	AppointedSelection *AppointedSelectionConfig
+
+	// Shuffle is the boot sequence's `shuffle` section, nil for a
+	// uniform shuffle.
+	Shuffle *ShuffleConfig
+
+	// Quorum is the boot sequence's `quorum` section, nil to boot
+	// without waiting on other producers.
+	Quorum *QuorumConfig
+
+	// Probe is the boot sequence's `probe` section, nil to shuffle
+	// the producers without probing their endpoints.
+	Probe *ProbeConfig
+
+	// LaunchTime is the boot sequence's `launch_time_utc`: the boot
+	// node doesn't sign anything before it. Zero when not set.
+	LaunchTime time.Time
+
+	// Heartbeat is the boot sequence's `heartbeat` section, nil when
+	// participants don't publish heartbeats.
+	Heartbeat *HeartbeatConfig
+
+	// SeedNetKeys are the keys of our seed network account, signing
+	// our heartbeats and shuffle audit.
+	SeedNetKeys []*ecc.PrivateKey
+
+	// Entropy is the boot sequence's `entropy` section, nil to only
+	// use the seed network API we're connected to.
+	Entropy *EntropyConfig
+
+	// LaunchBTCBlockHeight, from the boot sequence, seeds the shuffle
+	// with that Bitcoin block instead of the seed network's launch
+	// block. It's read from BitcoindRPC and the `bitcoin` section's
+	// explorers, polled every BitcoinPollInterval until it's there.
+	LaunchBTCBlockHeight uint32
+	Bitcoin              *BitcoinConfig
+	BitcoindRPC          string
+	BitcoinPollInterval  time.Duration
+
+	// EntropyProvider seeds the shuffle, from the boot sequence's
+	// `entropy_provider`.
+	EntropyProvider EntropyProvider
+
+	// PrintSeed logs each step of the shuffling seed's derivation.
+	PrintSeed bool
+
+	// ChainID is the boot sequence's `chain_id` section, selecting how
+	// the target chain ID is derived. TargetChainID is the outcome,
+	// nil when left to nodeos.
+	ChainID       *ChainIDConfig
+	TargetChainID eos.SHA256Bytes
+
+	// BreakBootLease proceeds even when the target chain is leased to
+	// another operator's boot.
+	BreakBootLease bool
+
+	// ExportAccountsFile, when set, receives the manifest of
+	// accounts created during injection (CSV, or JSON if it ends
+	// with `.json`).
+	ExportAccountsFile  string
+	accountTransactions map[eos.AccountName]*accountTransactions
+
+	// Firehose, when set, receives every action of the boot sequence
+	// confirmed in a block of the target network.
+	Firehose        Firehose
+	firehosePending map[string]*firehoseTransaction
+
+	// ArtifactStores receive the launch artifacts: reports, logs and
+	// kickstart chunks.
+	ArtifactStores []ArtifactStore
+
+	// ArchiveFile, when set, receives the signed archive of the
+	// launch (see WriteLaunchArchive), signed with one of ArchiveKeys.
+	ArchiveFile string
+	ArchiveKeys []*ecc.PrivateKey
+
+	// ReportFile, when set, receives a human-readable report of the
+	// launch (Markdown, or HTML if it ends with `.html`).
+	// ReportTransactionURL is a printf pattern with a `%s` for the
+	// transaction ID, used to link transactions to a block explorer.
+	ReportFile           string
+	ReportTransactionURL string
+	pushedTransactions   []*PushedTransaction
+	recordLock           sync.Mutex
+	chainValidation      *chainValidationOutcome
+	scheduleChanges      []*ScheduleChange
+	injectionMetrics     *InjectionMetrics
+	injectionLimiter     *injectionLimiter
 
 	Genesis *GenesisJSON
+	// GenesisConfig is the `genesis` of the boot sequence, which the
+	// genesis is derived from.
+	GenesisConfig *GenesisConfig
+	// ChainParams is the `chain_params` of the boot sequence, the
+	// blockchain parameters set by `system.setparams`.
+	ChainParams json.RawMessage
 
 	// ShuffledProducers is an ordered list of producers according to
 	// the shuffled peers.
 	RandSource        rand.Source
+	Randomness        *RandomnessProof
 	ShuffledProducers []*Peer
+	// shuffleCandidates are the producers ShuffledProducers were
+	// shuffled from, once the unreachable ones (shuffleExcluded, as
+	// published in shuffleExclusion) are left out, for the shuffle
+	// audit.
+	shuffleCandidates []*Peer
+	shuffleExcluded   []string
+	shuffleExclusion  *PublishedExclusion
 
 	EphemeralPrivateKey *ecc.PrivateKey
 	EphemeralPublicKey  ecc.PublicKey
+
+	progress  progressTracker
+	status    launchStatus
+	heartbeat heartbeatState
 }
 
 func NewBIOS(logger *Logger, network *Network, targetAPI *eos.API) *BIOS {
@@ -59,7 +254,7 @@ func NewBIOS(logger *Logger, network *Network, targetAPI *eos.API) *BIOS {
 }
 
 func (b *BIOS) SetGenesis(gen *GenesisJSON) {
-	b.Genesis = gen
+	b.setGenesis(gen)
 }
 
 func (b *BIOS) Init() error {
@@ -79,16 +274,6 @@ func (b *BIOS) Init() error {
 
 	b.LaunchDisco = launchDisco
 
-	// FIXME: we should call `setProducers()` after a call to `waitLaunchBlock()`, or call it again
-	// now that we have the shuffling ready..
-	if err := b.setProducers(); err != nil {
-		return err
-	}
-
-	if err = b.setMyPeers(); err != nil {
-		return fmt.Errorf("error setting my producer definitions: %s", err)
-	}
-
 	// Load Boot Sequence...
 	bootseqFile, err := b.GetContentsCacheRef("boot_sequence.yaml")
 	if err != nil {
@@ -103,6 +288,10 @@ func (b *BIOS) Init() error {
 		if err != nil {
 			return fmt.Errorf("reading overridden boot_sequence file: %s", err)
 		}
+
+		if agreed, err := b.Network.ReadFromCache(bootseqFile); err == nil && sha2(agreed) != sha2(rawBootSeq) {
+			b.Log.Printf("WARNING: the overridden boot sequence (sha256 %s) differs from the one agreed upon (sha256 %s), other nodes won't validate the chain it boots\n", sha2(rawBootSeq), sha2(agreed))
+		}
 	} else {
 		rawBootSeq, err = b.Network.ReadFromCache(bootseqFile)
 		if err != nil {
@@ -110,33 +299,147 @@ func (b *BIOS) Init() error {
 		}
 	}
 
-	var bootSeq struct {
-		BootSequence []*OperationType `json:"boot_sequence"`
+	if err := b.pinBootSequence(rawBootSeq); err != nil {
+		return err
 	}
-	if err := yamlUnmarshal(rawBootSeq, &bootSeq); err != nil {
+
+	if err := b.verifyBootSequenceSignatures(rawBootSeq); err != nil {
+		return err
+	}
+
+	bootSeq, err := loadBootSequence(rawBootSeq, b.Log)
+	if err != nil {
 		return fmt.Errorf("loading boot sequence: %s", err)
 	}
 
 	// TODO: we need to RELOAD the boot sequence from the selected
 	// decided upon, once the Launch Block is reached.
 	b.BootSequence = bootSeq.BootSequence
+	b.SnapshotTransform = bootSeq.SnapshotTransform
+
+	b.Profile, err = selectProfile(bootSeq.Profile, bootSeq.Profiles)
+	if err != nil {
+		return err
+	}
+
+	b.resolveDNSSeeds(append(append([]string{}, b.DNSSeeds...), bootSeq.DNSSeeds...))
+
+	b.AppointedProducers = DefaultAppointedProducers
+	if bootSeq.AppointedProducers != 0 {
+		b.AppointedProducers = bootSeq.AppointedProducers
+	}
+	if b.AppointedProducers < 1 || b.AppointedProducers > MaxAppointedProducers {
+		return fmt.Errorf("appointed_producers must be between 1 and %d, got %d", MaxAppointedProducers, b.AppointedProducers)
+	}
+
+	if err := bootSeq.AppointedSelection.validate(); err != nil {
+		return err
+	}
+	b.AppointedSelection = bootSeq.AppointedSelection
+
+	if err := bootSeq.Shuffle.validate(); err != nil {
+		return err
+	}
+	b.Shuffle = bootSeq.Shuffle
+
+	if err := bootSeq.Quorum.validate(); err != nil {
+		return err
+	}
+	b.Quorum = bootSeq.Quorum
+
+	if err := bootSeq.Probe.validate(); err != nil {
+		return err
+	}
+	b.Probe = bootSeq.Probe
+
+	b.LaunchTime, err = parseLaunchTimeUTC(bootSeq.LaunchTimeUTC)
+	if err != nil {
+		return err
+	}
+
+	if err := bootSeq.Heartbeat.validate(); err != nil {
+		return err
+	}
+	b.Heartbeat = bootSeq.Heartbeat
+
+	if err := bootSeq.Entropy.validate(); err != nil {
+		return err
+	}
+	b.Entropy = bootSeq.Entropy
+
+	if err := bootSeq.Bitcoin.validate(); err != nil {
+		return err
+	}
+	b.LaunchBTCBlockHeight = bootSeq.LaunchBTCBlockHeight
+	b.Bitcoin = bootSeq.Bitcoin
+
+	b.EntropyProvider, err = b.newEntropyProvider(bootSeq.EntropyProvider, bootSeq.Drand, bootSeq.NIST)
+	if err != nil {
+		return err
+	}
+
+	if err := bootSeq.Genesis.validate(); err != nil {
+		return err
+	}
+	b.GenesisConfig = bootSeq.Genesis
+
+	b.ChainParams = bootSeq.ChainParams
+	if _, err := b.chainParams(); err != nil {
+		return err
+	}
+
+	if err := bootSeq.Snapshot.validate(); err != nil {
+		return err
+	}
+	b.SnapshotConfig = bootSeq.Snapshot
+
+	if err := bootSeq.ChainID.validate(); err != nil {
+		return err
+	}
+	b.ChainID = bootSeq.ChainID
+	b.TargetChainID, err = b.deriveChainID()
+	if err != nil {
+		return err
+	}
+
+	// FIXME: we should call `setProducers()` after a call to `waitLaunchBlock()`, or call it again
+	// now that we have the shuffling ready..
+	if err := b.setProducers(); err != nil {
+		return err
+	}
+
+	if err = b.setMyPeers(); err != nil {
+		return fmt.Errorf("error setting my producer definitions: %s", err)
+	}
+
+	b.status.phase("launch data loaded")
 
 	return nil
 }
 
 func (b *BIOS) StartOrchestrate() error {
 	b.Log.Println("Starting Orchestraion process", time.Now())
+
+	heartbeatsDone := make(chan struct{})
+	defer close(heartbeatsDone)
+	go b.sendHeartbeats(heartbeatsDone)
+
 	b.Log.Println("Showing pre-randomized network discovered:")
 	b.PrintProducerSchedule(nil)
 
 	firstTarget := b.LaunchDisco.SeedNetworkLaunchBlock
 
-	b.RandSource = b.waitLaunchBlock()
+	randomness, err := b.EntropyProvider.Wait()
+	if err != nil {
+		return fmt.Errorf("%s entropy: %s", b.EntropyProvider.Name(), err)
+	}
+	b.Randomness = randomness
+	b.RandSource = rand.NewSource(randomness.Seed)
 
 	// Once we have it, we can discover the net again (unless it's been discovered VERY recently)
 	// and we b.Init() again.. so load the latest version of the LaunchData according to this
 	// potentially new discovery network.
-	b.Log.Println("Seed network block used to seed randomization, updating graph one last time...")
+	b.Log.Println("Launch block used to seed randomization, updating graph one last time...")
 
 	if err := b.Network.UpdateGraph(); err != nil {
 		return fmt.Errorf("update graph: %s", err)
@@ -154,29 +457,33 @@ func (b *BIOS) StartOrchestrate() error {
 	if err := b.setProducers(); err != nil {
 		return err
 	}
+	b.status.phase("producers shuffled")
+
+	if err := b.writeShuffleAudit(); err != nil {
+		b.Log.Printf("WARN: writing shuffle audit: %s\n", err)
+	}
 
 	b.Log.Println("Network used for launch:")
 	b.PrintProducerSchedule(b.ShuffledProducers)
 
 	if err := b.DispatchInit("orchestrate"); err != nil {
+		b.setHeartbeatStatus(HeartbeatFailed)
 		return fmt.Errorf("dispatch init hook: %s", err)
 	}
+	b.setHeartbeatStatus(HeartbeatReady)
 
-	switch b.MyRole() {
-	case RoleBootNode:
-		if err := b.RunBootSequence(); err != nil {
-			return fmt.Errorf("as boot node: %s", err)
-		}
-	case RoleABP:
-		if err := b.RunJoinNetwork(true, true); err != nil {
-			return fmt.Errorf("as abp: %s", err)
-		}
-	default:
-		if err := b.RunJoinNetwork(true, false); err != nil {
-			return fmt.Errorf("as participant: %s", err)
-		}
+	runner := b.RoleRunner()
+	b.Log.Printf("Acting as %s\n", runner.Role())
+	if err := runner.Run(); err != nil {
+		return fmt.Errorf("as %s: %s", runner.Role(), err)
+	}
+
+	if err := b.writeLaunchReport(); err != nil {
+		return fmt.Errorf("writing launch report: %s", err)
 	}
+	b.publishLaunchArtifacts()
 
+	b.status.phase("done")
 	return b.DispatchDone("orchestrate")
 }
 
@@ -193,6 +500,16 @@ func (b *BIOS) StartJoin(validate bool) error {
 		return fmt.Errorf("join network: %s", err)
 	}
 
+	if err := b.registerProducer(); err != nil {
+		return err
+	}
+
+	if err := b.writeLaunchReport(); err != nil {
+		return fmt.Errorf("writing launch report: %s", err)
+	}
+	b.publishLaunchArtifacts()
+
+	b.status.phase("done")
 	return b.DispatchDone("join")
 }
 
@@ -209,11 +526,17 @@ func (b *BIOS) StartBoot() error {
 		return fmt.Errorf("run bios boot: %s", err)
 	}
 
+	if err := b.writeLaunchReport(); err != nil {
+		return fmt.Errorf("writing launch report: %s", err)
+	}
+	b.publishLaunchArtifacts()
+
+	b.status.phase("done")
 	return b.DispatchDone("boot")
 }
 
 func (b *BIOS) PrintProducerSchedule(orderedPeers []*Peer) {
-	b.Network.PrintOrderedPeers(orderedPeers)
+	b.Network.PrintOrderedPeers(orderedPeers, b.AppointedProducers)
 
 	b.Log.Println("")
 	b.Log.Println("###############################################################################################")
@@ -226,13 +549,39 @@ func (b *BIOS) PrintProducerSchedule(orderedPeers []*Peer) {
 		b.Log.Println("                              MY ROLE: JOINING NETWORK")
 	}
 	b.Log.Println("")
+	b.Log.Printf("                    SCHEDULE: %d PRODUCERS, HANDOFF QUORUM: %d\n", b.scheduleSize(), b.HandoffQuorum())
+	b.Log.Println("")
 
 	b.Log.Println("###############################################################################################")
 	b.Log.Println("")
 }
 
 func (b *BIOS) RunBootSequence() error {
+	err := b.runBootSequence()
+	if err != nil {
+		b.status.fail(err)
+	}
+	return err
+}
+
+func (b *BIOS) runBootSequence() error {
+	if b.ReadOnly {
+		return fmt.Errorf("cannot run the boot sequence: %s", ErrReadOnly)
+	}
+
 	b.Log.Println("START BOOT SEQUENCE...")
+	defer b.closeFirehose()
+
+	// Whether booting with `boot` or chosen by `orchestrate`.
+	if err := b.waitQuorum(); err != nil {
+		return err
+	}
+
+	b.guardLaunchTime()
+
+	if err := b.validateSnapshot(); err != nil {
+		return err
+	}
 
 	var genesisData string
 	var pubKey ecc.PublicKey
@@ -291,7 +640,13 @@ func (b *BIOS) RunBootSequence() error {
 		return fmt.Errorf("writing actions to disk: %s", err)
 	}
 
-	if len(b.Network.MyPeer.Discovery.SeedNetworkPeers) > 0 && !b.SingleOnly {
+	// Everything is rendered and validated, nothing signed yet.
+	b.showReadinessBoard()
+	b.waitLaunchTime()
+
+	if b.Network.OfflineBundle != "" {
+		b.Log.Println("Booting from an offline bundle, not publishing genesis data to the seed network")
+	} else if len(b.Network.MyPeer.Discovery.SeedNetworkPeers) > 0 && !b.SingleOnly {
 
 		b.Log.Printf("Publishing genesis data to the seed network... ")
 		_, err := b.Network.SeedNetAPI.SignPushActions(
@@ -313,7 +668,21 @@ func (b *BIOS) RunBootSequence() error {
 		return fmt.Errorf("dispatch boot_node hook: %s", err)
 	}
 
-	b.pingTargetNetwork()
+	if b.KickstartChunkSize > 0 {
+		if err := b.publishKickstart(genesisData, otherPeers); err != nil {
+			return fmt.Errorf("publishing kickstart payload: %s", err)
+		}
+	}
+
+	if err := b.waitTargetReady(b.TargetReadyTimeout); err != nil {
+		return err
+	}
+
+	if b.KickstartPGP {
+		if err := b.writeEncryptedKickstart(genesisData, privKey, otherPeers); err != nil {
+			return fmt.Errorf("encrypting kickstart payload: %s", err)
+		}
+	}
 
 	b.Log.Println("In-memory keys:")
 	memkeys, _ := b.TargetNetAPI.Signer.AvailableKeys()
@@ -324,41 +693,174 @@ func (b *BIOS) RunBootSequence() error {
 
 	//eos.Debug = true
 
-	for _, step := range b.BootSequence {
-		b.Log.Printf("%s  [%s] ", step.Label, step.Op)
+	if err := b.acquireBootLease(); err != nil {
+		return fmt.Errorf("boot lease: %s", err)
+	}
 
-		if b.LaunchDisco.TargetNetworkIsTest == 0 {
-			step.Data.ResetTestnetOptions()
+	// When reusing a genesis, the chain might already hold part of the
+	// boot sequence (from an interrupted run): only push what's missing.
+	// The checkpoint of that run spares reading the blocks of what it
+	// already pushed.
+	checkpoint := b.newBootCheckpoint(CheckpointFile)
+	var resume *chainResume
+	if b.ReuseGenesis {
+		previous, err := b.loadBootCheckpoint(CheckpointFile)
+		if err != nil {
+			return fmt.Errorf("reading boot checkpoint: %s", err)
 		}
 
-		acts, err := step.Data.Actions(b)
+		from := uint32(1)
+		if previous != nil {
+			b.Log.Printf("Resuming from %s: step %d, chunk %d pushed, looking for later chunks from block %d\n", CheckpointFile, previous.Step+1, previous.Chunk+1, previous.BlockNum)
+			checkpoint = previous
+			if previous.BlockNum > from {
+				from = previous.BlockNum
+			}
+		}
+
+		resume, err = b.loadChainResume(from)
+		if err != nil {
+			return fmt.Errorf("reading existing chain state: %s", err)
+		}
+		if len(resume.applied) != 0 {
+			b.Log.Printf("Target chain already holds %d actions, skipping the steps already applied\n", len(resume.applied))
+		}
+		resume.window = b.InjectionConcurrency
+	}
+
+	metrics := NewInjectionMetrics()
+	b.injectionMetrics = metrics
+	b.injectionLimiter = newInjectionLimiter(b.InjectionMaxTPS)
+	b.setTargetSigner(&timedSigner{Signer: b.TargetNetAPI.Signer, metrics: metrics})
+	if b.AuditLog != nil {
+		b.setTargetSigner(&auditSigner{Signer: b.TargetNetAPI.Signer, audit: b.AuditLog})
+	}
+
+	stopFollowing := b.followFirehose()
+	defer stopFollowing()
+
+	b.status.phase("injecting boot sequence")
+	b.status.planSteps(b.BootSequence)
+
+	for stepIdx, step := range b.BootSequence {
+		b.Log.Printf("%s  [%s] ", step.Label, step.Op)
+
+		var acts []*eos.Action
+		var err error
+		metrics.time(StageParse, func() {
+			acts, err = b.stepActions(step)
+		})
 		if err != nil {
 			return fmt.Errorf("getting actions for step %q: %s", step.Op, err)
 		}
 
 		if len(acts) != 0 {
-			for idx, chunk := range ChunkifyActions(acts) {
-				err := Retry(25, time.Second, func() error {
-					_, err := b.TargetNetAPI.SignPushActions(chunk...)
+			var chunks [][]*eos.Action
+			metrics.time(StageBatch, func() {
+				chunks = ChunkifyActions(acts)
+			})
+
+			b.status.startStep(stepIdx, len(chunks))
+
+			canary, err := b.startCanary(step, len(chunks))
+			if err != nil {
+				return fmt.Errorf("step %q: canary: %s", step.Op, err)
+			}
+
+			deadline := b.watchStepDeadline(step)
+			var pool *injectionPool
+			if b.concurrentChunks(step) {
+				pool = b.newInjectionPool(stepIdx, step, deadline, checkpoint)
+			}
+			for idx, chunk := range chunks {
+				if checkpoint.done(stepIdx, idx) {
+					b.Log.Printf("s")
+					b.status.chunkDone()
+					continue
+				}
+
+				if resume != nil {
+					applied, err := b.chunkAlreadyApplied(resume, step, chunk)
 					if err != nil {
-						b.Log.Printf("r")
-						b.Log.Debugf("error pushing transaction for step %q, chunk %d: %s\n", step.Op, idx, err)
-						return fmt.Errorf("push actions for step %q, chunk %d: %s", step.Op, idx, err)
+						pool.wait()
+						deadline.stop()
+						b.Log.Printf(" failed\n")
+						return fmt.Errorf("step %q, chunk %d: %s", step.Op, idx, err)
+					}
+					if applied {
+						b.Log.Printf("s")
+						b.status.chunkDone()
+						pool.skipped(idx)
+						continue
+					}
+				}
+
+				// Canaries go one by one, the floodgates open once
+				// they're verified.
+				if pool != nil && !canary.pending() {
+					if pool.push(idx, chunk) != nil {
+						break // handled below
 					}
-					return nil
-				})
+					continue
+				}
+
+				err := b.pushChunk(step, idx, chunk, deadline)
+				if err == errStepSkipped {
+					b.Log.Printf(" skipped, over deadline")
+					break
+				}
 				if err != nil {
+					deadline.stop()
 					b.Log.Printf(" failed\n")
 					return err
 				}
 				b.Log.Printf(".")
+				b.status.chunkDone()
+
+				if err := b.checkpointPushed(checkpoint, stepIdx, idx); err != nil {
+					b.Log.Printf("\nWARNING: writing boot checkpoint: %s\n", err)
+				}
+
+				if canary.pushed(chunk) {
+					if err := b.verifyCanary(step, canary); err != nil {
+						deadline.stop()
+						b.Log.Printf(" failed\n")
+						return fmt.Errorf("step %q: canary: %s", step.Op, err)
+					}
+				}
+			}
+			if err := pool.wait(); err == errStepSkipped {
+				b.Log.Printf(" skipped, over deadline")
+			} else if err != nil {
+				deadline.stop()
+				b.Log.Printf(" failed\n")
+				return err
 			}
+			if confirmer, ok := step.Data.(Confirmer); ok {
+				if err := confirmer.Confirm(b); err != nil {
+					deadline.stop()
+					b.Log.Printf(" failed\n")
+					return fmt.Errorf("step %q: %s", step.Op, err)
+				}
+			}
+			deadline.stop()
 			b.Log.Printf(" done\n")
 		}
+		b.status.endStep(stepIdx)
+	}
+
+	metrics.finish()
+	metrics.Print(b.Log)
+
+	if b.ExportAccountsFile != "" {
+		if err := b.ExportCreatedAccounts(b.ExportAccountsFile); err != nil {
+			return fmt.Errorf("exporting created accounts: %s", err)
+		}
 	}
 
 	b.Log.Println("Waiting 2 seconds for transactions to flush to blocks")
 	time.Sleep(2 * time.Second)
+	stopFollowing()
 
 	// FIXME: don't do chain validation here..
 	isValid, err := b.RunChainValidation()
@@ -367,6 +869,10 @@ func (b *BIOS) RunBootSequence() error {
 	}
 	if !isValid {
 		b.Log.Println("WARNING: chain invalid, destroying network if possible")
+		b.status.alert("critical", "chain invalid")
+		if err := b.writeLaunchReport(); err != nil {
+			b.Log.Println("error writing launch report:", err)
+		}
 		os.Exit(0)
 	}
 
@@ -374,6 +880,7 @@ func (b *BIOS) RunBootSequence() error {
 	b.Log.Println("You should now mesh your node with the network.")
 	b.Log.Println("")
 
+	b.status.phase("meshing")
 	if err := b.DispatchBootMesh(); err != nil {
 		return fmt.Errorf("dispatch boot_mesh: %s", err)
 	}
@@ -391,11 +898,19 @@ func (b *BIOS) getMyPeerVariations() (out []*Peer) {
 }
 
 func (b *BIOS) RunJoinNetwork(validate, sabotage bool) error {
+	b.status.phase("waiting for genesis")
+	if b.Genesis == nil && (b.KickstartFile != "" || b.KickstartIPFS != "") {
+		genesis, err := b.kickstartGenesis()
+		if err != nil {
+			return fmt.Errorf("kickstart payload: %s", err)
+		}
+		b.setGenesis(genesis)
+	}
 	if b.Genesis == nil {
 		if b.SingleOnly {
-			b.Genesis = b.inputGenesisData()
+			b.setGenesis(b.inputGenesisData())
 		} else {
-			b.Genesis = b.pollGenesisData()
+			b.setGenesis(b.pollGenesisData())
 		}
 	}
 
@@ -403,6 +918,10 @@ func (b *BIOS) RunJoinNetwork(validate, sabotage bool) error {
 	if err != nil {
 		return fmt.Errorf("invalid genesis public key: %s", err)
 	}
+
+	if err := b.checkGenesis(b.Genesis); err != nil {
+		return err
+	}
 	b.EphemeralPublicKey = pubKey
 
 	if err := b.writeAllActionsToDisk(false); err != nil {
@@ -411,6 +930,7 @@ func (b *BIOS) RunJoinNetwork(validate, sabotage bool) error {
 
 	otherPeers := b.computeMyMeshP2PAddresses()
 
+	b.status.phase("joining network")
 	if err := b.DispatchJoinNetwork(b.Genesis, b.getMyPeerVariations(), otherPeers); err != nil {
 		return fmt.Errorf("dispatch join_network hook: %s", err)
 	}
@@ -425,6 +945,10 @@ func (b *BIOS) RunJoinNetwork(validate, sabotage bool) error {
 		}
 		if !isValid {
 			b.Log.Println("WARNING: CHAIN CONTAINS VALIDATION ERRORS")
+			b.status.alert("critical", "chain contains validation errors")
+			if err := b.writeLaunchReport(); err != nil {
+				b.Log.Println("error writing launch report:", err)
+			}
 			os.Exit(0)
 		}
 	} else {
@@ -445,11 +969,7 @@ func (b *BIOS) RunChainValidation() (bool, error) {
 	bootSeq := []*eos.Action{}
 
 	for _, step := range b.BootSequence {
-		if b.LaunchDisco.TargetNetworkIsTest == 0 {
-			step.Data.ResetTestnetOptions()
-		}
-
-		acts, err := step.Data.Actions(b)
+		acts, err := b.stepActions(step)
 		if err != nil {
 			return false, fmt.Errorf("validating: getting actions for step %q: %s", step.Op, err)
 		}
@@ -476,8 +996,36 @@ func (b *BIOS) RunChainValidation() (bool, error) {
 
 	}
 
+	b.status.phase("validating chain")
+
+	start := time.Now()
 	err := b.validateTargetNetwork(bootSeqMap, bootSeq)
+	if b.injectionMetrics != nil {
+		b.injectionMetrics.observe(StageConfirm, time.Since(start))
+	}
+	b.status.verified("boot sequence actions on chain", err)
+	if err == nil {
+		err = b.checkRequiredAccounts()
+		b.status.verified("required accounts", err)
+	}
+	if err == nil {
+		err = b.verifySteps()
+		b.status.verified("steps outcome", err)
+	}
+	if err == nil {
+		err = b.checkProducerSchedules()
+		b.status.verified("producer schedules", err)
+	}
+	if err == nil {
+		err = b.checkChainID()
+		b.status.verified("chain ID", err)
+	}
+	b.chainValidation = &chainValidationOutcome{
+		Actions:     len(bootSeq),
+		ValidatedAt: time.Now().UTC(),
+	}
 	if err != nil {
+		b.chainValidation.Error = err.Error()
 		b.Log.Printf("BOOT SEQUENCE VALIDATION FAILED:\n%s", err)
 		return false, nil
 	}
@@ -489,6 +1037,21 @@ func (b *BIOS) RunChainValidation() (bool, error) {
 	return true, nil
 }
 
+// verifySteps runs the on-chain checks of the steps that have some.
+func (b *BIOS) verifySteps() error {
+	for _, step := range b.BootSequence {
+		verifier, ok := step.Data.(Verifier)
+		if !ok {
+			continue
+		}
+
+		if err := verifier.Verify(b); err != nil {
+			return fmt.Errorf("verifying step %q (%s): %s", step.Op, step.Label, err)
+		}
+	}
+	return nil
+}
+
 func (b *BIOS) writeAllActionsToDisk(alwaysRun bool) error {
 	if !b.WriteActions && !alwaysRun {
 		b.Log.Println("Not writing actions to 'actions.jsonl'. Activate with --write-actions")
@@ -503,11 +1066,7 @@ func (b *BIOS) writeAllActionsToDisk(alwaysRun bool) error {
 	defer fl.Close()
 
 	for _, step := range b.BootSequence {
-		if b.LaunchDisco.TargetNetworkIsTest == 0 {
-			step.Data.ResetTestnetOptions()
-		}
-
-		acts, err := step.Data.Actions(b)
+		acts, err := b.stepActions(step)
 		if err != nil {
 			return fmt.Errorf("fetch step %q: %s", step.Op, err)
 		}
@@ -639,6 +1198,7 @@ func (b *BIOS) validateTargetNetwork(bootSeqMap ActionMap, bootSeq []*eos.Action
 			b.Log.Printf(".\n")
 		}
 
+		b.markProgress(fmt.Sprintf("validating block %d", blockHeight))
 		blockHeight++
 
 		b.Log.Printf("Receiving block height=%d producer=%s transactions=%d\n", m.BlockNumber(), m.Producer, len(m.Transactions))
@@ -652,6 +1212,10 @@ func (b *BIOS) validateTargetNetwork(bootSeqMap ActionMap, bootSeq []*eos.Action
 		}
 
 		for _, receipt := range m.Transactions {
+			transactionID := receipt.Transaction.ID.String()
+			b.audit(transactionID, b.AuditLog.included(transactionID, m.BlockNumber(), m.Timestamp.Time))
+			b.confirmFirehose(transactionID, m.BlockNumber(), m.Timestamp.Time)
+
 			unpacked, err := receipt.Transaction.Packed.Unpack()
 			if err != nil {
 				b.Log.Println("WARNING: Unable to unpack transaction, won't be able to fully validate:", err)
@@ -659,8 +1223,22 @@ func (b *BIOS) validateTargetNetwork(bootSeqMap ActionMap, bootSeq []*eos.Action
 			}
 
 			for _, act := range unpacked.Actions {
+				if isBootLeaseMarker(act) {
+					b.Log.Println("- Skipping the boot lease marker")
+					continue
+				}
+
 				act.SetToServer(false)
 				data, err := eos.MarshalBinary(act)
+				if err == nil && isProducerRegistration(act) {
+					// Producers register themselves once the boot
+					// is over, but those of a testnet keeping the
+					// system accounts can't tell when it is.
+					if _, ok := bootSeqMap[sha2(data)]; !ok {
+						b.Log.Println("- Skipping a producer registering itself")
+						continue
+					}
+				}
 				if err != nil {
 					b.Log.Printf("Error marshalling an action: %s\n", err)
 					validationErrors = append(validationErrors, ValidationError{
@@ -735,12 +1313,17 @@ func (b *BIOS) flushMissingActions(seenMap map[string]bool, bootSeq []*eos.Actio
 	}
 }
 
-func (b *BIOS) waitLaunchBlock() rand.Source {
+func (b *BIOS) waitLaunchBlock() *RandomnessProof {
 	targetBlockNum := uint32(b.LaunchDisco.SeedNetworkLaunchBlock)
 
 	b.Log.Println("Polling seed network until launch block, target:", targetBlockNum)
+	b.status.phase("waiting for launch block")
 
+	var reachedAt time.Time
+	var rounds int
 	for {
+		b.markProgress("waiting for launch block")
+
 		launchTime, _, err := b.Network.LaunchBlockTime(targetBlockNum)
 		if err != nil {
 			b.Log.Println(err.Error())
@@ -752,20 +1335,50 @@ func (b *BIOS) waitLaunchBlock() rand.Source {
 			continue
 		}
 
-		hash, err := b.Network.GetBlockHeight(targetBlockNum)
-		if err != nil {
-			b.Log.Println("error fetching seed network's target block hash:", err)
+		if reachedAt.IsZero() {
+			reachedAt = time.Now()
+		}
+		rounds++
+
+		observations := b.observeEntropy(targetBlockNum)
+		hashHex, decision := b.Entropy.decide(observations, time.Since(reachedAt))
+		if hashHex == "" {
+			b.Log.Printf("- can't settle on the target block hash (%s policy): %s\n", b.Entropy.policy(), decision)
+			for _, obs := range observations {
+				if obs.Error != "" {
+					b.Log.Printf("  - %s: %s\n", obs.Source, obs.Error)
+				}
+			}
 			time.Sleep(2 * time.Second)
 			continue
 		}
 
-		b.Log.Println("- got block", targetBlockNum, "- hash is", hex.EncodeToString(hash))
-		chksum := crc64.Checksum(hash, crc64.MakeTable(crc64.ECMA))
-		return rand.NewSource(int64(chksum))
-
+		hash, _ := hex.DecodeString(hashHex)
+		b.Log.Println("- got block", targetBlockNum, "- hash is", hashHex, "-", decision)
+		seed := seedFromBlockHash(hash)
+		b.printSeed([]string{
+			fmt.Sprintf("block hash:                %s", hashHex),
+			fmt.Sprintf("seed (crc64 ECMA, int64):  %d", seed),
+		})
+		return &RandomnessProof{
+			Provider:     EntropySeedNetwork,
+			Source:       "seed network block",
+			BlockNum:     targetBlockNum,
+			BlockHash:    hashHex,
+			Seed:         seed,
+			Policy:       b.Entropy.policy(),
+			Decision:     decision,
+			Rounds:       rounds,
+			Observations: observations,
+		}
 	}
 }
 
+// seedFromBlockHash derives the shuffling seed from a block hash.
+func seedFromBlockHash(hash []byte) int64 {
+	return int64(crc64.Checksum(hash, crc64.MakeTable(crc64.ECMA)))
+}
+
 func (b *BIOS) pollGenesisData() (genesis *GenesisJSON) {
 	b.Log.Println("")
 	b.Log.Println("Waiting for the BIOS Boot node to publish the genesis data to the seed network contract..")
@@ -774,6 +1387,7 @@ func (b *BIOS) pollGenesisData() (genesis *GenesisJSON) {
 
 	b.Log.Printf("Polling account %q...", bootNode.Discovery.SeedNetworkAccountName)
 	for {
+		b.markProgress("polling genesis data")
 		time.Sleep(500 * time.Millisecond)
 
 		b.Log.Printf(".")
@@ -816,6 +1430,16 @@ func (b *BIOS) inputGenesisData() (genesis *GenesisJSON) {
 			continue
 		}
 
+		if IsKickstartChunk(genesisData) {
+			payload, err := b.readKickstartPayload(genesisData)
+			if err != nil {
+				b.Log.Printf("Invalid kickstart payload: %s\n", err)
+				continue
+			}
+			genesisData = payload.Genesis
+			b.kickstartP2PAddresses = payload.P2PAddresses
+		}
+
 		err = json.Unmarshal([]byte(genesisData), &genesis)
 		if err != nil {
 			b.Log.Printf("Invalid genesis data: %s\n", err)
@@ -863,12 +1487,16 @@ func (b *BIOS) GenerateEphemeralPrivKey() (*ecc.PrivateKey, error) {
 	return ecc.NewRandomPrivateKey()
 }
 
+// GenerateGenesisJSON derives the genesis (see deriveGenesis), and
+// writes it to `genesis.json` for nodeos.
 func (b *BIOS) GenerateGenesisJSON(pubKey string) string {
-	// known not to fail
-	cnt, _ := json.Marshal(&GenesisJSON{
-		InitialTimestamp: time.Now().UTC().Format("2006-01-02T15:04:05"),
-		InitialKey:       pubKey,
-	})
+	// known not to fail, the boot sequence's `genesis` was validated
+	genesis, _ := b.deriveGenesis(pubKey, time.Now())
+
+	indented, _ := json.MarshalIndent(genesis, "", "  ")
+	b.writeToFile("genesis.json", string(indented)+"\n")
+
+	cnt, _ := json.Marshal(genesis)
 	return string(cnt)
 }
 
@@ -902,53 +1530,119 @@ func (b *BIOS) GetContentsCacheRef(filename string) (string, error) {
 	return "", fmt.Errorf("%q not found in target contents", filename)
 }
 
+// pinBootSequence records the sha256 of the boot sequence, and checks
+// it against BootSequenceSHA256 when set.
+func (b *BIOS) pinBootSequence(rawBootSeq []byte) error {
+	b.BootSequenceHash = sha2(rawBootSeq)
+	b.Log.Printf("Boot sequence sha256: %s\n", b.BootSequenceHash)
+
+	if b.BootSequenceSHA256 != "" && !strings.EqualFold(b.BootSequenceSHA256, b.BootSequenceHash) {
+		return fmt.Errorf("boot sequence has sha256 %s, pinned to %s", b.BootSequenceHash, b.BootSequenceSHA256)
+	}
+	return nil
+}
+
+// ReadContents reads a file of the `target_contents` from the local
+// cache, and checks its sha256 when `sha256` is not empty.
+func (b *BIOS) ReadContents(filename, sha256 string) ([]byte, error) {
+	ref, err := b.GetContentsCacheRef(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	cnt, err := b.Network.ReadFromCache(ref)
+	if err != nil {
+		return nil, fmt.Errorf("reading %q: %s", filename, err)
+	}
+
+	if sha256 != "" && sha2(cnt) != sha256 {
+		return nil, fmt.Errorf("%q has sha256 %s, expected %s", filename, sha2(cnt), sha256)
+	}
+
+	return cnt, nil
+}
+
 func (b *BIOS) setProducers() error {
 	network := b.Network.MyNetwork()
 	orderedPeers := b.Network.OrderedPeers(network)
 
+	if conflicts := ProducerConflicts(orderedPeers); len(conflicts) != 0 {
+		b.Log.Println("Conflicts between the producers of the launch:")
+		for _, conflict := range conflicts {
+			b.Log.Printf("  %s\n", conflict)
+		}
+		return fmt.Errorf("%d conflicts between the producers of the launch, they need to fix their discovery files", len(conflicts))
+	}
+
+	// Only probe when shuffling for the launch, all participants
+	// probing in the same window.
+	if b.RandSource != nil {
+		var err error
+		orderedPeers, err = b.excludeUnreachable(orderedPeers)
+		if err != nil {
+			return err
+		}
+		b.shuffleCandidates = append([]*Peer{}, orderedPeers...)
+	}
+
 	b.ShuffledProducers = orderedPeers
 
 	b.shuffleProducers() // conditionally
 
+	b.appointProducers()
+
+	return nil
+}
+
+// appointProducers orders the shuffled producers after the boot node
+// with the `appointed_selection` policy, for the seats of the schedule
+// left once `eosio` takes the first.
+func (b *BIOS) appointProducers() {
+	b.ShuffledProducers = selectAppointed(b.AppointedSelection, b.ShuffledProducers, b.scheduleSize()-1)
+
 	// We'll multiply the other producers as to have a full schedule
-	if len(b.ShuffledProducers) > 1 {
-		if numProds := len(b.ShuffledProducers); numProds < 22 {
-			cloneCount := numProds - 1
-			count := 0
-			for {
-				if len(b.ShuffledProducers) == 22 {
-					break
-				}
+	b.ShuffledProducers = fillProducerSchedule(b.ShuffledProducers, b.AppointedProducers)
+}
 
-				fromPeer := b.ShuffledProducers[1+count%cloneCount]
-				count++
+// fillProducerSchedule clones the producers after the boot node,
+// with variations of their target account names, until there are
+// enough for the appointed schedule.
+func fillProducerSchedule(producers []*Peer, appointedProducers int) []*Peer {
+	numProds := len(producers)
+	if numProds <= 1 || numProds >= 1+appointedProducers {
+		return producers
+	}
 
-				clonedDisco := *fromPeer.Discovery
+	cloneCount := numProds - 1
+	count := 0
+	for len(producers) < 1+appointedProducers {
+		fromPeer := producers[1+count%cloneCount]
+		count++
 
-				accountVar := eos.AccountName("")
-				for {
-					accountVar = accountVariation(fromPeer.Discovery.TargetAccountName, count)
-					if b.targetInShuffledProducers(accountVar) {
-						count++
-						continue
-					}
-					break
-				}
-				clonedDisco.TargetAccountName = accountVar
-				clonedPeer := &Peer{
-					Discovery: &clonedDisco,
-					UpdatedAt: fromPeer.UpdatedAt,
-				}
-				b.ShuffledProducers = append(b.ShuffledProducers, clonedPeer)
+		clonedDisco := *fromPeer.Discovery
+
+		accountVar := eos.AccountName("")
+		for {
+			accountVar = accountVariation(fromPeer.Discovery.TargetAccountName, count)
+			if targetInProducers(producers, accountVar) {
+				count++
+				continue
 			}
+			break
 		}
+		clonedDisco.TargetAccountName = accountVar
+		clonedPeer := &Peer{
+			Discovery: &clonedDisco,
+			UpdatedAt: fromPeer.UpdatedAt,
+		}
+		producers = append(producers, clonedPeer)
 	}
 
-	return nil
+	return producers
 }
 
-func (b *BIOS) targetInShuffledProducers(acct eos.AccountName) bool {
-	for _, prod := range b.ShuffledProducers {
+func targetInProducers(producers []*Peer, acct eos.AccountName) bool {
+	for _, prod := range producers {
 		if prod.Discovery.TargetAccountName == acct {
 			return true
 		}
@@ -963,24 +1657,39 @@ func (b *BIOS) shuffleProducers() {
 	}
 
 	b.Log.Println("Shuffling producers listed in the launch file")
-	r := rand.New(b.RandSource)
-	// shuffle top 25%, capped to 5
-	shuffleHowMany := int64(math.Min(math.Ceil(float64(len(b.ShuffledProducers))*0.25), RandomBootFromTop))
-	if shuffleHowMany > 1 {
-		b.Log.Println("- Shuffling top", shuffleHowMany)
-		for round := 0; round < 100; round++ {
-			from := r.Int63() % shuffleHowMany
-			to := r.Int63() % shuffleHowMany
-			if from == to {
-				continue
-			}
-
-			//b.Log.Println("Swapping from", from, "to", to)
-			b.ShuffledProducers[from], b.ShuffledProducers[to] = b.ShuffledProducers[to], b.ShuffledProducers[from]
-		}
+	if b.Shuffle.weighted() {
+		b.Log.Printf("- Weighted by %s\n", b.Shuffle.Weighted)
+	}
+	if shuffled := shuffleTopPeersWith(b.Shuffle, b.ShuffledProducers, b.RandSource); shuffled > 1 {
+		b.Log.Println("- Shuffled top", shuffled)
 	} else {
 		b.Log.Println("- No shuffling, network too small")
 	}
+	if policy := b.AppointedSelection.policy(); policy != defaultAppointedSelection {
+		b.Log.Printf("- Appointed producers selected with the %s policy\n", policy)
+	}
+}
+
+// shuffleTopPeers shuffles the top 25% of `peers` in place, capped to
+// `RandomBootFromTop`, and returns how many were in the shuffle.
+func shuffleTopPeers(peers []*Peer, src rand.Source) int64 {
+	r := rand.New(src)
+	shuffleHowMany := int64(math.Min(math.Ceil(float64(len(peers))*0.25), RandomBootFromTop))
+	if shuffleHowMany <= 1 {
+		return shuffleHowMany
+	}
+
+	for round := 0; round < 100; round++ {
+		from := r.Int63() % shuffleHowMany
+		to := r.Int63() % shuffleHowMany
+		if from == to {
+			continue
+		}
+
+		peers[from], peers[to] = peers[to], peers[from]
+	}
+
+	return shuffleHowMany
 }
 
 func (b *BIOS) IsBootNode(account string) bool {
@@ -1001,7 +1710,7 @@ func (b *BIOS) MyRole() Role {
 }
 
 func (b *BIOS) IsAppointedBlockProducer(account string) bool {
-	for i := 1; i < 22 && len(b.ShuffledProducers) > i; i++ {
+	for i := 1; i <= b.AppointedProducers && len(b.ShuffledProducers) > i; i++ {
 		if string(b.ShuffledProducers[i].Discovery.TargetAccountName) == account {
 			return true
 		}
@@ -1013,8 +1722,37 @@ func (b *BIOS) AmIAppointedBlockProducer() bool {
 	return b.IsAppointedBlockProducer(string(b.Network.MyPeer.Discovery.TargetAccountName))
 }
 
+// DefaultAppointedProducers is the size of the appointed producer
+// schedule when the boot sequence doesn't set `appointed_producers`.
+const DefaultAppointedProducers = 21
+
+// MaxAppointedProducers is the largest schedule EOSIO accepts (its
+// `max_producers`).
+const MaxAppointedProducers = 125
+
+// scheduleSize is the number of producers `setprods` appoints: `eosio`
+// (standing for the boot node), then the shuffled producers, up to
+// `AppointedProducers`.
+func (b *BIOS) scheduleSize() int {
+	size := b.AppointedProducers
+	if len(b.ShuffledProducers) < size {
+		size = len(b.ShuffledProducers)
+	}
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+// HandoffQuorum is the number of appointed producers the
+// `eosio.prods` authority requires, once the system accounts are
+// resigned: two thirds of the schedule, plus one.
+func (b *BIOS) HandoffQuorum() int {
+	return b.scheduleSize()*2/3 + 1
+}
+
 // MyProducerDefs will provide more than one producer def ONLY when
-// your launch files contains LESS than 21 potential appointed block
+// your launch files contains LESS than `appointed_producers` potential appointed block
 // producers.  This way, you can have your nodes respond to many
 // account names and have the network function. Your producer will
 // simply produce more blocks, under different names.
@@ -1052,14 +1790,25 @@ func ChunkifyActions(actions []*eos.Action) (out [][]*eos.Action) {
 	return
 }
 
+// variationChars are the characters of account names, apart from the
+// dot, letters first so the first variations stay `a` to `z`.
+const variationChars = "abcdefghijklmnopqrstuvwxyz12345"
+
+// accountVariation suffixes `acct` with the `variation`th name among
+// `a` to `5`, then `aa` to `55`, and so on, truncating it to stay
+// within the 12 characters of account names.
 func accountVariation(acct eos.AccountName, variation int) eos.AccountName {
+	var suffix []byte
+	for n := variation; n > 0; n = (n - 1) / len(variationChars) {
+		suffix = append([]byte{variationChars[(n-1)%len(variationChars)]}, suffix...)
+	}
+
 	name := string(acct)
-	if len(name) > 11 {
-		name = name[:11]
+	if max := 12 - len(suffix); len(name) > max {
+		name = name[:max]
 	}
-	variedName := name + string([]byte{'a' + byte(variation-1)})
 
-	return eos.AccountName(variedName)
+	return eos.AccountName(name + string(suffix))
 }
 
 func readPrivKeyFromFile(filename string) (*ecc.PrivateKey, error) {
@@ -0,0 +1,192 @@
+package bios
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eoscanada/eos-bios/bios/btc"
+)
+
+// BitcoinConfig is the boot sequence's `bitcoin` section, used when
+// `launch_btc_block_height` is set: the shuffle is then seeded by that
+// Bitcoin block instead of the seed network's launch block. The block
+// is read from the operator's bitcoind (`--bitcoind-rpc`) and every
+// explorer, which must all agree on it.
+type BitcoinConfig struct {
+	// Explorers are Esplora API endpoints, `btc.DefaultExplorers` when
+	// empty. At least two are needed.
+	Explorers []string `json:"explorers"`
+	// Confirmations needed on each source before reading the block,
+	// 6 by default.
+	Confirmations uint32 `json:"confirmations"`
+}
+
+const DefaultBitcoinConfirmations = 6
+
+// BitcoinBlockInterval is the expected time between Bitcoin blocks,
+// to estimate how long until the launch block.
+const BitcoinBlockInterval = 10 * time.Minute
+
+// DefaultBitcoinPollInterval is how often the sources are polled while
+// waiting for the launch block, unless `BitcoinPollInterval` is set.
+const DefaultBitcoinPollInterval = 30 * time.Second
+
+func (c *BitcoinConfig) validate() error {
+	if c == nil {
+		return nil
+	}
+	if len(c.Explorers) == 1 {
+		return fmt.Errorf("bitcoin: at least two explorers are needed, got %q", c.Explorers[0])
+	}
+	return nil
+}
+
+func (c *BitcoinConfig) explorers() []string {
+	if c == nil || len(c.Explorers) == 0 {
+		return btc.DefaultExplorers
+	}
+	return c.Explorers
+}
+
+func (c *BitcoinConfig) confirmations() uint32 {
+	if c == nil || c.Confirmations == 0 {
+		return DefaultBitcoinConfirmations
+	}
+	return c.Confirmations
+}
+
+// bitcoinSources are the local bitcoind, then the explorers.
+func (b *BIOS) bitcoinSources() ([]btc.Source, error) {
+	if b.BitcoindRPC == "" {
+		return nil, fmt.Errorf("launch_btc_block_height is set, pass your bitcoind's --bitcoind-rpc to verify the block")
+	}
+
+	sources := []btc.Source{&btc.Bitcoind{URL: b.BitcoindRPC}}
+	for _, explorer := range b.Bitcoin.explorers() {
+		sources = append(sources, &btc.Esplora{URL: explorer})
+	}
+	return sources, nil
+}
+
+func (b *BIOS) bitcoinPollInterval() time.Duration {
+	if b.BitcoinPollInterval <= 0 {
+		return DefaultBitcoinPollInterval
+	}
+	return b.BitcoinPollInterval
+}
+
+// waitBitcoinBlock sleeps until the launch block is mined and
+// confirmed on all the sources, polling them every
+// `BitcoinPollInterval`, then returns the seed it derives.
+func (b *BIOS) waitBitcoinBlock() (*RandomnessProof, error) {
+	height := b.LaunchBTCBlockHeight
+	confirmations := b.Bitcoin.confirmations()
+
+	sources, err := b.bitcoinSources()
+	if err != nil {
+		return nil, err
+	}
+
+	b.Log.Printf("Polling %d bitcoin sources until block %d has %d confirmations\n", len(sources), height, confirmations)
+	b.status.phase("waiting for launch block")
+
+	var rounds int
+	for {
+		b.markProgress("waiting for launch block")
+		rounds++
+
+		header, observations, err := btc.FetchBlock(sources, height, confirmations)
+		if _, disagree := err.(*btc.DisagreementError); disagree {
+			return nil, err
+		}
+		if pending, ok := err.(*btc.PendingError); ok {
+			toGo := pending.BlocksToGo()
+			b.Log.Printf("- bitcoin block %d: %d blocks to go (about %s), checking again in %s\n", height, toGo, time.Duration(toGo)*BitcoinBlockInterval, b.bitcoinPollInterval())
+			time.Sleep(b.bitcoinPollInterval())
+			continue
+		}
+		if err != nil {
+			b.Log.Printf("- bitcoin block %d not settled: %s\n", height, err)
+			time.Sleep(b.bitcoinPollInterval())
+			continue
+		}
+
+		b.Log.Println("- got bitcoin block", height, "- hash is", header.Hash, "- merkle root is", header.MerkleRoot)
+		seed, steps, err := BitcoinSeed(header.Hash, header.MerkleRoot)
+		if err != nil {
+			return nil, err
+		}
+		b.printSeed(steps)
+
+		proof := &RandomnessProof{
+			Provider:       EntropyBitcoin,
+			Source:         "bitcoin block",
+			BlockNum:       height,
+			BlockHash:      header.Hash,
+			MerkleRoot:     header.MerkleRoot,
+			BlockTime:      &header.Time,
+			Seed:           seed,
+			SeedDerivation: BitcoinSeedDerivation,
+			Policy:         EntropyWait,
+			Decision:       fmt.Sprintf("all %d sources agree", len(sources)),
+			Rounds:         rounds,
+		}
+		for _, obs := range observations {
+			proof.Observations = append(proof.Observations, &EntropyObservation{Source: obs.Source, BlockHash: obs.BlockHash})
+		}
+		return proof, nil
+	}
+}
+
+const BitcoinSeedDerivation = "first 8 bytes of sha256(block hash || merkle root), as a big-endian int64"
+
+// BitcoinSeed derives the shuffling seed from a Bitcoin block: the
+// block hash and merkle root are decoded from their usual hex (as
+// shown by explorers and bitcoind), concatenated, and the first 8
+// bytes of their sha256 read as a big-endian int64. It also returns
+// each step, for audit.
+func BitcoinSeed(blockHash, merkleRoot string) (seed int64, steps []string, err error) {
+	hash, err := hex.DecodeString(blockHash)
+	if err != nil || len(hash) != sha256.Size {
+		return 0, nil, fmt.Errorf("invalid block hash %q", blockHash)
+	}
+	merkle, err := hex.DecodeString(merkleRoot)
+	if err != nil || len(merkle) != sha256.Size {
+		return 0, nil, fmt.Errorf("invalid merkle root %q", merkleRoot)
+	}
+
+	digest := sha256.Sum256(append(hash, merkle...))
+	seed = int64(binary.BigEndian.Uint64(digest[:8]))
+
+	steps = []string{
+		fmt.Sprintf("block hash:              %s", blockHash),
+		fmt.Sprintf("merkle root:             %s", merkleRoot),
+		fmt.Sprintf("sha256(hash || merkle):  %s", hex.EncodeToString(digest[:])),
+		fmt.Sprintf("first 8 bytes:           %s", hex.EncodeToString(digest[:8])),
+		fmt.Sprintf("seed (big-endian int64): %d", seed),
+	}
+	return seed, steps, nil
+}
+
+func (b *BIOS) preflightBitcoin() *PreflightCheck {
+	sources, err := b.bitcoinSources()
+	if err != nil {
+		return preflightResult("bitcoin", "", err)
+	}
+
+	var failed []string
+	for _, source := range sources {
+		if _, err := source.TipHeight(); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", source.Name(), err))
+		}
+	}
+	if len(failed) != 0 {
+		return preflightResult("bitcoin", "", fmt.Errorf("unreachable: %s", strings.Join(failed, "; ")))
+	}
+
+	return preflightResult("bitcoin", fmt.Sprintf("%d sources reachable for block %d", len(sources), b.LaunchBTCBlockHeight), nil)
+}
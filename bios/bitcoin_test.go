@@ -0,0 +1,111 @@
+package bios
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/eoscanada/eos-bios/bios/btc"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	btcGenesisHash   = "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f"
+	btcGenesisHeader = "0100000000000000000000000000000000000000000000000000000000000000000000003ba3edfd7a7b12b27ac72c3e67768f617fc81bc3888a51323a9fb8aa4b1e5e4a29ab5f49ffff001d1dac2b7c"
+	btcBlock1Hash    = "00000000839a8e6886ab5951d76f411475428afc90947ee320161bbf18eb6048"
+	btcBlock1Header  = "010000006fe28c0ab6f1b372c1a6a246ae63f74f931e8365e15a089c68d6190000000000982051fd1e4ba744bbbe680e1fee14677ba1a3c3540bf7b1cdb606e857233e0e61bc6649ffff001d01e36299"
+)
+
+func esploraServer(tip, hash, header string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/blocks/tip/height":
+			w.Write([]byte(tip))
+		case "/block-height/0":
+			w.Write([]byte(hash))
+		case "/block/" + hash + "/header":
+			w.Write([]byte(header))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func bitcoindServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		results := map[string]interface{}{
+			"getblockcount":  10,
+			"getblockhash":   btcGenesisHash,
+			"getblockheader": btcGenesisHeader,
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": results[req.Method], "error": nil})
+	}))
+}
+
+func TestBitcoinFetchBlock(t *testing.T) {
+	bitcoind := bitcoindServer()
+	defer bitcoind.Close()
+	explorer1 := esploraServer("10", btcGenesisHash, btcGenesisHeader)
+	defer explorer1.Close()
+	explorer2 := esploraServer("10", btcGenesisHash, btcGenesisHeader)
+	defer explorer2.Close()
+
+	sources := []btc.Source{&btc.Bitcoind{URL: bitcoind.URL}, &btc.Esplora{URL: explorer1.URL}, &btc.Esplora{URL: explorer2.URL}}
+	header, observations, err := btc.FetchBlock(sources, 0, 6)
+	assert.NoError(t, err)
+	assert.Equal(t, btcGenesisHash, header.Hash)
+	assert.Equal(t, "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b", header.MerkleRoot)
+	assert.Len(t, observations, 3)
+
+	// Not enough confirmations yet
+	_, _, err = btc.FetchBlock(sources, 0, 12)
+	if assert.IsType(t, &btc.PendingError{}, err) {
+		assert.Equal(t, uint32(1), err.(*btc.PendingError).BlocksToGo())
+	}
+
+	// An explorer answering another block
+	liar := esploraServer("10", btcBlock1Hash, btcBlock1Header)
+	defer liar.Close()
+	_, _, err = btc.FetchBlock(append(sources, &btc.Esplora{URL: liar.URL}), 0, 6)
+	assert.IsType(t, &btc.DisagreementError{}, err)
+}
+
+func TestBitcoinParseHeader(t *testing.T) {
+	raw, _ := hex.DecodeString(btcGenesisHeader)
+	_, err := btc.ParseHeader(0, btcGenesisHash, raw)
+	assert.NoError(t, err)
+
+	_, err = btc.ParseHeader(0, btcBlock1Hash, raw)
+	assert.Error(t, err)
+
+	// Changing the nonce breaks the hash
+	raw[79]++
+	_, err = btc.ParseHeader(0, btcGenesisHash, raw)
+	assert.Error(t, err)
+
+	assert.Error(t, (&BitcoinConfig{Explorers: []string{"https://blockstream.info/api"}}).validate())
+	assert.Equal(t, uint32(DefaultBitcoinConfirmations), (*BitcoinConfig)(nil).confirmations())
+}
+
+func TestBitcoinSeed(t *testing.T) {
+	seed, steps, err := BitcoinSeed(btcGenesisHash, "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b")
+	assert.NoError(t, err)
+	assert.Len(t, steps, 5)
+
+	// Same block, same seed, on every machine
+	again, _, _ := BitcoinSeed(btcGenesisHash, "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b")
+	assert.Equal(t, seed, again)
+
+	other, _, _ := BitcoinSeed(btcGenesisHash, btcBlock1Hash)
+	assert.NotEqual(t, seed, other)
+
+	_, _, err = BitcoinSeed("nothex", btcGenesisHash)
+	assert.Error(t, err)
+}
@@ -0,0 +1,185 @@
+package bios
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	eos "github.com/eoscanada/eos-go"
+	"golang.org/x/crypto/openpgp"
+)
+
+// BootSequenceSignaturesName is the companion file of the boot
+// sequence, in the `target_contents`, holding detached PGP signatures
+// of `boot_sequence.yaml` by the producers, made with their Keybase
+// key (`gpg --armor --detach-sign boot_sequence.yaml`):
+//
+//	signatures:
+//	- account: eoscanadacom  # seed network account
+//	  signature: |
+//	    -----BEGIN PGP SIGNATURE-----
+//	    ...
+const BootSequenceSignaturesName = "boot_sequence.signatures.yaml"
+
+type BootSequenceSignature struct {
+	Account   eos.AccountName `json:"account"`
+	Signature string          `json:"signature"`
+}
+
+// BootSequenceSignatures tells which producers of the network signed
+// the boot sequence, and whether the quorum is reached.
+type BootSequenceSignatures struct {
+	Signers []*BootSequenceSigner
+	Signed  int
+	Quorum  int
+	Reached bool
+}
+
+type BootSequenceSigner struct {
+	Account eos.AccountName
+	Valid   bool
+	Error   string
+}
+
+// ParseSignatureQuorum returns the number of producers, out of
+// `producers`, a quorum written as a number (`14`) or a fraction of
+// the producers, with an optional addend (`2/3+1`), requires.
+func ParseSignatureQuorum(quorum string, producers int) (int, error) {
+	invalid := fmt.Errorf("invalid quorum %q, use a number of producers (14) or a fraction of them (2/3+1)", quorum)
+
+	fraction, addend := quorum, "0"
+	if idx := strings.Index(quorum, "+"); idx != -1 {
+		fraction, addend = quorum[:idx], quorum[idx+1:]
+	}
+	add, err := strconv.Atoi(strings.TrimSpace(addend))
+	if err != nil || add < 0 {
+		return 0, invalid
+	}
+
+	if !strings.Contains(fraction, "/") {
+		count, err := strconv.Atoi(strings.TrimSpace(fraction))
+		if err != nil || count < 1 || add != 0 {
+			return 0, invalid
+		}
+		return count, nil
+	}
+
+	parts := strings.SplitN(fraction, "/", 2)
+	num, err1 := strconv.Atoi(strings.TrimSpace(parts[0]))
+	den, err2 := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err1 != nil || err2 != nil || num < 0 || den < 1 || num > den {
+		return 0, invalid
+	}
+
+	count := producers*num/den + add
+	if count < 1 {
+		return 0, invalid
+	}
+	return count, nil
+}
+
+// VerifyBootSequenceSignatures checks the detached signatures of
+// `rawBootSeq` against the PGP keys of `peers`, counting each signer
+// once.
+func VerifyBootSequenceSignatures(rawBootSeq []byte, signatures []*BootSequenceSignature, peers []*Peer, quorum int) *BootSequenceSignatures {
+	status := &BootSequenceSignatures{Quorum: quorum}
+
+	byAccount := map[eos.AccountName]*Peer{}
+	for _, peer := range peers {
+		byAccount[peer.Discovery.SeedNetworkAccountName] = peer
+	}
+
+	seen := map[eos.AccountName]bool{}
+	for _, signature := range signatures {
+		signer := &BootSequenceSigner{Account: signature.Account}
+		status.Signers = append(status.Signers, signer)
+
+		if err := verifyBootSequenceSignature(rawBootSeq, signature, byAccount[signature.Account]); err != nil {
+			signer.Error = err.Error()
+			continue
+		}
+		signer.Valid = true
+
+		if !seen[signature.Account] {
+			seen[signature.Account] = true
+			status.Signed++
+		}
+	}
+
+	status.Reached = status.Signed >= status.Quorum
+
+	return status
+}
+
+func verifyBootSequenceSignature(rawBootSeq []byte, signature *BootSequenceSignature, peer *Peer) error {
+	if peer == nil {
+		return fmt.Errorf("not a producer of the network")
+	}
+	if peer.PGPPublicKey == "" {
+		return fmt.Errorf("no PGP key, list a Keybase user in your discovery file's urls")
+	}
+
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(peer.PGPPublicKey))
+	if err != nil {
+		return fmt.Errorf("reading PGP key: %s", err)
+	}
+
+	if _, err := openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader(rawBootSeq), strings.NewReader(signature.Signature)); err != nil {
+		return fmt.Errorf("invalid signature: %s", err)
+	}
+	return nil
+}
+
+// verifyBootSequenceSignatures enforces BootSequenceSignatureQuorum,
+// when set, on the boot sequence loaded.
+func (b *BIOS) verifyBootSequenceSignatures(rawBootSeq []byte) error {
+	if b.BootSequenceSignatureQuorum == "" {
+		return nil
+	}
+
+	peers := b.Network.OrderedPeers(b.Network.MyNetwork())
+	quorum, err := ParseSignatureQuorum(b.BootSequenceSignatureQuorum, len(peers))
+	if err != nil {
+		return err
+	}
+
+	signatures, err := b.readBootSequenceSignatures()
+	if err != nil {
+		return err
+	}
+
+	status := VerifyBootSequenceSignatures(rawBootSeq, signatures, peers, quorum)
+	for _, signer := range status.Signers {
+		if !signer.Valid {
+			b.Log.Printf("WARNING: boot sequence signature of %s: %s\n", signer.Account, signer.Error)
+		}
+	}
+
+	if !status.Reached {
+		return fmt.Errorf("boot sequence signed by %d of %d producers, %d required", status.Signed, len(peers), status.Quorum)
+	}
+
+	b.Log.Printf("Boot sequence signed by %d of %d producers (%d required)\n", status.Signed, len(peers), status.Quorum)
+	return nil
+}
+
+func (b *BIOS) readBootSequenceSignatures() ([]*BootSequenceSignature, error) {
+	ref, err := b.GetContentsCacheRef(BootSequenceSignaturesName)
+	if err != nil {
+		return nil, err
+	}
+
+	cnt, err := b.Network.ReadFromCache(ref)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %s", BootSequenceSignaturesName, err)
+	}
+
+	var file struct {
+		Signatures []*BootSequenceSignature `json:"signatures"`
+	}
+	if err := yamlUnmarshal(cnt, &file); err != nil {
+		return nil, fmt.Errorf("loading %s: %s", BootSequenceSignaturesName, err)
+	}
+	return file.Signatures, nil
+}
@@ -0,0 +1,77 @@
+package bios
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/eoscanada/eos-bios/bios/disco"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+func TestParseSignatureQuorum(t *testing.T) {
+	for quorum, expected := range map[string]int{
+		"14":      14,
+		"2/3+1":   15,
+		"2/3":     14,
+		"1/2 + 1": 11,
+		"1/1":     21,
+	} {
+		count, err := ParseSignatureQuorum(quorum, 21)
+		assert.NoError(t, err, quorum)
+		assert.Equal(t, expected, count, quorum)
+	}
+
+	for _, quorum := range []string{"", "0", "-1", "two", "3/2", "1/0", "14+1", "2/3+x", "0/3"} {
+		_, err := ParseSignatureQuorum(quorum, 21)
+		assert.Error(t, err, quorum)
+	}
+}
+
+func TestVerifyBootSequenceSignatures(t *testing.T) {
+	rawBootSeq := []byte("version: 1\nboot_sequence: []\n")
+
+	entities := map[string]*openpgp.Entity{}
+	var peers []*Peer
+	for _, name := range []string{"bpone", "bptwo", "bpthree"} {
+		entity, err := openpgp.NewEntity(name, "", name+"@example.com", nil)
+		assert.NoError(t, err)
+		entities[name] = entity
+
+		buf := &bytes.Buffer{}
+		w, err := armor.Encode(buf, openpgp.PublicKeyType, nil)
+		assert.NoError(t, err)
+		assert.NoError(t, entity.Serialize(w))
+		assert.NoError(t, w.Close())
+
+		peers = append(peers, &Peer{Discovery: &disco.Discovery{SeedNetworkAccountName: AN(name)}, PGPPublicKey: buf.String()})
+	}
+	peers = append(peers, &Peer{Discovery: &disco.Discovery{SeedNetworkAccountName: AN("bpnokey")}})
+
+	sign := func(name string, content []byte) *BootSequenceSignature {
+		buf := &bytes.Buffer{}
+		assert.NoError(t, openpgp.ArmoredDetachSign(buf, entities[name], bytes.NewReader(content), nil))
+		return &BootSequenceSignature{Account: AN(name), Signature: buf.String()}
+	}
+
+	signatures := []*BootSequenceSignature{
+		sign("bpone", rawBootSeq),
+		sign("bpone", rawBootSeq),
+		sign("bptwo", []byte("boot_sequence: [tampered]\n")),
+		{Account: AN("bpnokey"), Signature: "whatever"},
+		{Account: AN("outsider"), Signature: "whatever"},
+	}
+
+	status := VerifyBootSequenceSignatures(rawBootSeq, signatures, peers, 2)
+	assert.Equal(t, 1, status.Signed)
+	assert.False(t, status.Reached)
+	assert.True(t, status.Signers[0].Valid)
+	assert.Contains(t, status.Signers[2].Error, "invalid signature")
+	assert.Contains(t, status.Signers[3].Error, "no PGP key")
+	assert.Equal(t, "not a producer of the network", status.Signers[4].Error)
+
+	status = VerifyBootSequenceSignatures(rawBootSeq, append(signatures, sign("bpthree", rawBootSeq)), peers, 2)
+	assert.Equal(t, 2, status.Signed)
+	assert.True(t, status.Reached)
+}
@@ -0,0 +1,181 @@
+package bios
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	yaml2json "github.com/bronze1man/go-yaml2json"
+)
+
+// BootSequenceVersion is the version of the boot sequence format this
+// eos-bios reads, stated by boot sequences in their `version`. Bump it
+// along with a migration in bootSequenceMigrations whenever a field is
+// renamed, moved or changes meaning.
+const BootSequenceVersion = 1
+
+// bootSequenceMigrations upgrade a boot sequence from the version of
+// their index to the next one, logging what they changed.
+var bootSequenceMigrations = []func(bootSeq map[string]interface{}, log *Logger) error{
+	migrateBootSequenceV0,
+}
+
+// bootSequenceFile is the boot sequence, as agreed upon in the launch
+// data.
+type bootSequenceFile struct {
+	Version            int                       `json:"version"`
+	BootSequence       []*OperationType          `json:"boot_sequence"`
+	SnapshotTransform  *ScriptRef                `json:"snapshot_transform"`
+	Profile            string                    `json:"profile"`
+	DNSSeeds           []string                  `json:"dns_seeds"`
+	Profiles           map[string]*Profile       `json:"profiles"`
+	AppointedProducers int                       `json:"appointed_producers"`
+	AppointedSelection *AppointedSelectionConfig `json:"appointed_selection"`
+	Shuffle            *ShuffleConfig            `json:"shuffle"`
+	Quorum             *QuorumConfig             `json:"quorum"`
+	Probe              *ProbeConfig              `json:"probe"`
+	LaunchTimeUTC      string                    `json:"launch_time_utc"`
+	Heartbeat          *HeartbeatConfig          `json:"heartbeat"`
+	Entropy            *EntropyConfig            `json:"entropy"`
+	ChainID            *ChainIDConfig            `json:"chain_id"`
+	Genesis            *GenesisConfig            `json:"genesis"`
+	Snapshot           *SnapshotConfig           `json:"snapshot"`
+	ChainParams        json.RawMessage           `json:"chain_params"`
+
+	LaunchBTCBlockHeight uint32         `json:"launch_btc_block_height"`
+	Bitcoin              *BitcoinConfig `json:"bitcoin"`
+
+	EntropyProvider string       `json:"entropy_provider"`
+	Drand           *DrandConfig `json:"drand"`
+	NIST            *NISTConfig  `json:"nist"`
+}
+
+// bootSequenceStepFields are the keys of a boot sequence step, see
+// OperationType.
+var bootSequenceStepFields = []string{"op", "label", "data", "deadline", "on_deadline", "canary"}
+
+// loadBootSequence upgrades `rawBootSeq` to the current version, and
+// decodes it, failing on fields this eos-bios doesn't know rather than
+// ignoring them.
+func loadBootSequence(rawBootSeq []byte, log *Logger) (*bootSequenceFile, error) {
+	jsonBootSeq, err := yaml2json.Convert(rawBootSeq)
+	if err != nil {
+		return nil, err
+	}
+
+	var generic map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(jsonBootSeq))
+	decoder.UseNumber()
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+	if generic == nil {
+		return nil, fmt.Errorf("empty boot sequence")
+	}
+
+	version, err := bootSequenceVersion(generic)
+	if err != nil {
+		return nil, err
+	}
+	if version > BootSequenceVersion {
+		return nil, fmt.Errorf("boot sequence is version %d, this eos-bios reads up to version %d: upgrade eos-bios", version, BootSequenceVersion)
+	}
+
+	for ; version < BootSequenceVersion; version++ {
+		log.Printf("Upgrading boot sequence from version %d to %d\n", version, version+1)
+		if err := bootSequenceMigrations[version](generic, log); err != nil {
+			return nil, fmt.Errorf("upgrading boot sequence from version %d: %s", version, err)
+		}
+	}
+	generic["version"] = BootSequenceVersion
+
+	jsonBootSeq, err = json.Marshal(generic)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &bootSequenceFile{}
+	decoder = json.NewDecoder(bytes.NewReader(jsonBootSeq))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// bootSequenceVersion returns the `version` of a boot sequence, 0 for
+// those written before boot sequences were versioned.
+func bootSequenceVersion(bootSeq map[string]interface{}) (int, error) {
+	raw, found := bootSeq["version"]
+	if !found {
+		return 0, nil
+	}
+
+	number, ok := raw.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("boot sequence version must be a number, got %v", raw)
+	}
+	version, err := number.Int64()
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid boot sequence version %s", number)
+	}
+	return int(version), nil
+}
+
+// migrateBootSequenceV0 upgrades boot sequences written before they
+// were versioned, when unknown fields were silently ignored: it drops
+// them, with a warning, as they never had an effect.
+func migrateBootSequenceV0(bootSeq map[string]interface{}, log *Logger) error {
+	for _, key := range unknownKeys(bootSeq, jsonFieldNames(reflect.TypeOf(bootSequenceFile{}))) {
+		log.Printf("WARNING: ignoring unknown boot sequence field %q\n", key)
+		delete(bootSeq, key)
+	}
+
+	steps, _ := bootSeq["boot_sequence"].([]interface{})
+	for idx, rawStep := range steps {
+		step, ok := rawStep.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, key := range unknownKeys(step, bootSequenceStepFields) {
+			log.Printf("WARNING: ignoring unknown field %q of boot sequence step %d (%v)\n", key, idx+1, step["label"])
+			delete(step, key)
+		}
+	}
+
+	return nil
+}
+
+// jsonFieldNames returns the JSON names of the fields of a struct type.
+func jsonFieldNames(structType reflect.Type) (out []string) {
+	for i := 0; i < structType.NumField(); i++ {
+		name := strings.Split(structType.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = structType.Field(i).Name
+		}
+		out = append(out, name)
+	}
+	return
+}
+
+// unknownKeys returns the keys of `object` not in `known`, which, like
+// `encoding/json`, match regardless of case.
+func unknownKeys(object map[string]interface{}, known []string) (out []string) {
+	for key := range object {
+		found := false
+		for _, name := range known {
+			if strings.EqualFold(key, name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			out = append(out, key)
+		}
+	}
+	sort.Strings(out)
+	return
+}
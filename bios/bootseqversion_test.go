@@ -0,0 +1,62 @@
+package bios
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadBootSequence(t *testing.T) {
+	bootSeq, err := loadBootSequence([]byte(`
+version: 1
+appointed_producers: 21
+boot_sequence:
+- op: system.setpriv
+  label: Privilege eosio.msig
+  data:
+    account: eosio.msig
+`), NewLogger())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, bootSeq.Version)
+	assert.Equal(t, 21, bootSeq.AppointedProducers)
+	if assert.Len(t, bootSeq.BootSequence, 1) {
+		assert.Equal(t, AN("eosio.msig"), bootSeq.BootSequence[0].Data.(*OpSetPriv).Account)
+	}
+
+	// Written before versioning: unknown fields are dropped.
+	bootSeq, err = loadBootSequence([]byte(`
+appointed_producer: 5
+boot_sequence:
+- op: system.setpriv
+  label: Privilege eosio.msig
+  deadlin: 10m
+  data:
+    account: eosio.msig
+`), NewLogger())
+	assert.NoError(t, err)
+	assert.Equal(t, BootSequenceVersion, bootSeq.Version)
+	assert.Equal(t, 0, bootSeq.AppointedProducers)
+	assert.Len(t, bootSeq.BootSequence, 1)
+
+	_, err = loadBootSequence([]byte("version: 1\nappointed_producer: 5\nboot_sequence: []\n"), NewLogger())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown field "appointed_producer"`)
+
+	_, err = loadBootSequence([]byte("version: 1\nboot_sequence:\n- op: system.setpriv\n  deadlin: 10m\n"), NewLogger())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown field "deadlin"`)
+
+	_, err = loadBootSequence([]byte("version: 2\nboot_sequence: []\n"), NewLogger())
+	assert.EqualError(t, err, "boot sequence is version 2, this eos-bios reads up to version 1: upgrade eos-bios")
+
+	_, err = loadBootSequence([]byte("version: 0\nboot_sequence: []\n"), NewLogger())
+	assert.EqualError(t, err, "invalid boot sequence version 0")
+
+	// The boot sequence shipped with eos-bios is current.
+	raw, err := ioutil.ReadFile("../files/boot_sequence.yaml")
+	assert.NoError(t, err)
+	bootSeq, err = loadBootSequence(raw, NewLogger())
+	assert.NoError(t, err)
+	assert.NotEmpty(t, bootSeq.BootSequence)
+}
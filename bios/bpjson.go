@@ -0,0 +1,124 @@
+package bios
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+)
+
+// Producers can state the name of their organization in the `urls`
+// of their discovery file, checked against their `bp.json`:
+//
+//	org:EOS Canada
+const orgNamePrefix = "org:"
+
+var bpJSONClient = &http.Client{Timeout: 10 * time.Second}
+
+// BPJSON is the part of the producer information file, served at
+// `/bp.json` of producers' websites, we check against the launch data.
+type BPJSON struct {
+	ProducerAccountName eos.AccountName `json:"producer_account_name"`
+	ProducerPublicKey   string          `json:"producer_public_key"`
+	Org                 struct {
+		CandidateName string `json:"candidate_name"`
+	} `json:"org"`
+}
+
+// BPJSONCheck lists what doesn't match between a producer's discovery
+// file and the `bp.json` of the websites in its `urls`.
+type BPJSONCheck struct {
+	Account  eos.AccountName
+	URLs     []string
+	Problems []string
+}
+
+// orgName returns the organization name listed in the `urls` of a
+// discovery file.
+func orgName(urls []string) string {
+	for _, url := range urls {
+		if strings.HasPrefix(url, orgNamePrefix) {
+			return strings.TrimSpace(strings.TrimPrefix(url, orgNamePrefix))
+		}
+	}
+	return ""
+}
+
+// CheckBPJSON fetches the `bp.json` of each peer's websites, and
+// checks its account name, block signing key and organization name
+// against the peer's discovery file.
+func CheckBPJSON(peers []*Peer) (out []*BPJSONCheck) {
+	for _, peer := range peers {
+		disco := peer.Discovery
+		check := &BPJSONCheck{Account: disco.TargetAccountName}
+		out = append(out, check)
+
+		for _, url := range disco.URLs {
+			if strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") {
+				check.URLs = append(check.URLs, strings.TrimSuffix(url, "/")+"/bp.json")
+			}
+		}
+		if len(check.URLs) == 0 {
+			check.Problems = append(check.Problems, "no website in urls to fetch bp.json from")
+			continue
+		}
+
+		for _, url := range check.URLs {
+			bpJSON, err := fetchBPJSON(url)
+			if err != nil {
+				check.Problems = append(check.Problems, fmt.Sprintf("%s: %s", url, err))
+				continue
+			}
+
+			for _, problem := range bpJSON.problems(disco.TargetAccountName, disco.TargetAppointedBlockProducerSigningKey, orgName(disco.URLs)) {
+				check.Problems = append(check.Problems, fmt.Sprintf("%s: %s", url, problem))
+			}
+		}
+	}
+	return
+}
+
+func fetchBPJSON(url string) (*BPJSON, error) {
+	resp, err := bpJSONClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("http status %s", resp.Status)
+	}
+
+	var out *BPJSON
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decoding: %s", err)
+	}
+	if out == nil {
+		return nil, fmt.Errorf("empty bp.json")
+	}
+	return out, nil
+}
+
+func (j *BPJSON) problems(account eos.AccountName, signingKey ecc.PublicKey, org string) (out []string) {
+	if j.ProducerAccountName != account {
+		out = append(out, fmt.Sprintf("producer_account_name is %q, launch data has %q", j.ProducerAccountName, account))
+	}
+
+	if j.ProducerPublicKey == "" {
+		out = append(out, "no producer_public_key")
+	} else if key, err := ecc.NewPublicKey(j.ProducerPublicKey); err != nil {
+		out = append(out, fmt.Sprintf("invalid producer_public_key: %s", err))
+	} else if key.String() != signingKey.String() {
+		out = append(out, fmt.Sprintf("producer_public_key is %s, launch data has %s", key, signingKey))
+	}
+
+	if org != "" && !strings.EqualFold(strings.TrimSpace(j.Org.CandidateName), org) {
+		out = append(out, fmt.Sprintf("org.candidate_name is %q, launch data has %q", j.Org.CandidateName, org))
+	}
+
+	return
+}
@@ -0,0 +1,54 @@
+package bios
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/eoscanada/eos-bios/bios/disco"
+	"github.com/eoscanada/eos-go/ecc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckBPJSON(t *testing.T) {
+	key, err := ecc.NewPublicKey("EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV")
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/good/bp.json":
+			fmt.Fprint(w, `{"producer_account_name": "producer1", "producer_public_key": "EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV", "org": {"candidate_name": "Example Corp"}}`)
+		case "/bad/bp.json":
+			fmt.Fprint(w, `{"producer_account_name": "producer9", "producer_public_key": "EOS5cujNHGMYZZ2tgByyNEUaoPLFhZVmGXbZc9BLJeQkKZFqGYEiQ", "org": {"candidate_name": "Other Corp"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	peer := func(account string, urls ...string) *Peer {
+		return &Peer{Discovery: &disco.Discovery{TargetAccountName: AN(account), TargetAppointedBlockProducerSigningKey: key, URLs: urls}}
+	}
+
+	checks := CheckBPJSON([]*Peer{
+		peer("producer1", server.URL+"/good/", "org:example corp", "keybase:producer1"),
+		peer("producer2", server.URL+"/bad", "org:Example Corp"),
+		peer("producer3", server.URL+"/missing"),
+		peer("producer4", "keybase:producer4"),
+	})
+
+	assert.Len(t, checks, 4)
+	assert.Equal(t, []string{server.URL + "/good/bp.json"}, checks[0].URLs)
+	assert.Empty(t, checks[0].Problems)
+
+	url := server.URL + "/bad/bp.json"
+	assert.Equal(t, []string{
+		url + `: producer_account_name is "producer9", launch data has "producer2"`,
+		url + `: producer_public_key is EOS5cujNHGMYZZ2tgByyNEUaoPLFhZVmGXbZc9BLJeQkKZFqGYEiQ, launch data has EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV`,
+		url + `: org.candidate_name is "Other Corp", launch data has "Example Corp"`,
+	}, checks[1].Problems)
+
+	assert.Equal(t, []string{server.URL + "/missing/bp.json: http status 404 Not Found"}, checks[2].Problems)
+	assert.Equal(t, []string{"no website in urls to fetch bp.json from"}, checks[3].Problems)
+}
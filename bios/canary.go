@@ -0,0 +1,127 @@
+package bios
+
+import (
+	"fmt"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+)
+
+// Steps with at least CanaryMinTransactions transactions are
+// high-volume: a few canary transactions are pushed and verified on
+// chain before the rest, to catch a misconfiguration with two
+// accounts rather than two hundred thousand.
+var CanaryMinTransactions = 50
+
+// CanaryTimeout is how long to wait for canary transactions to show
+// up on chain.
+var CanaryTimeout = 30 * time.Second
+
+// canarySize returns how many transactions of a step are canaries.
+func (b *BIOS) canarySize(step *OperationType, transactions int) int {
+	canary := step.Canary
+	if canary == 0 && transactions >= CanaryMinTransactions {
+		canary = b.CanaryTransactions
+	}
+	if canary >= transactions {
+		return 0 // no floodgates to open
+	}
+	return canary
+}
+
+// stepCanary tracks the canary transactions of a step, until they're
+// verified.
+type stepCanary struct {
+	size      int
+	fromBlock uint32
+	chunks    [][]*eos.Action
+}
+
+// startCanary records where the chain stands before the canary
+// transactions get pushed.
+func (b *BIOS) startCanary(step *OperationType, transactions int) (*stepCanary, error) {
+	size := b.canarySize(step, transactions)
+	if size == 0 {
+		return nil, nil
+	}
+
+	info, err := b.TargetNetAPI.GetInfo()
+	if err != nil {
+		return nil, fmt.Errorf("get info: %s", err)
+	}
+
+	return &stepCanary{size: size, fromBlock: info.HeadBlockNum}, nil
+}
+
+// pushed records a pushed transaction, and returns whether all the
+// canaries are now out.
+func (c *stepCanary) pushed(chunk []*eos.Action) bool {
+	if c == nil || len(c.chunks) == c.size {
+		return false
+	}
+	c.chunks = append(c.chunks, chunk)
+	return len(c.chunks) == c.size
+}
+
+// pending returns whether canary transactions remain to be pushed.
+func (c *stepCanary) pending() bool {
+	return c != nil && len(c.chunks) < c.size
+}
+
+// verifyCanary waits for the canary transactions to be in blocks, and
+// for operations that can tell, checks their outcome in chain state.
+func (b *BIOS) verifyCanary(step *OperationType, canary *stepCanary) error {
+	b.Log.Printf(" [canary: verifying %d transactions] ", len(canary.chunks))
+
+	wanted := map[string]bool{}
+	for _, chunk := range canary.chunks {
+		for _, act := range chunk {
+			key, err := actionKey(act)
+			if err != nil {
+				return err
+			}
+			wanted[key] = true
+		}
+	}
+
+	timeout := time.Now().Add(CanaryTimeout)
+	nextBlock := canary.fromBlock + 1
+	for len(wanted) != 0 {
+		info, err := b.TargetNetAPI.GetInfo()
+		if err != nil {
+			return fmt.Errorf("get info: %s", err)
+		}
+
+		for ; nextBlock <= info.HeadBlockNum; nextBlock++ {
+			keys, err := b.blockActionKeys(nextBlock)
+			if err != nil {
+				return err
+			}
+			for _, key := range keys {
+				delete(wanted, key)
+			}
+		}
+
+		if len(wanted) == 0 {
+			break
+		}
+		if time.Now().After(timeout) {
+			return fmt.Errorf("%d canary actions not found on chain after %s", len(wanted), CanaryTimeout)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+
+	if skipper, ok := step.Data.(ChunkSkipper); ok {
+		for idx, chunk := range canary.chunks {
+			applied, decided, err := skipper.AlreadyApplied(b, chunk)
+			if err != nil {
+				return fmt.Errorf("canary transaction %d: %s", idx+1, err)
+			}
+			if decided && !applied {
+				return fmt.Errorf("canary transaction %d is on chain, but its outcome isn't in chain state", idx+1)
+			}
+		}
+	}
+
+	return nil
+}
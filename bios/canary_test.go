@@ -0,0 +1,33 @@
+package bios
+
+import (
+	"testing"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanarySize(t *testing.T) {
+	b := &BIOS{CanaryTransactions: 2}
+
+	assert.Equal(t, 0, b.canarySize(&OperationType{}, 10))
+	assert.Equal(t, 2, b.canarySize(&OperationType{}, CanaryMinTransactions))
+	assert.Equal(t, 1, b.canarySize(&OperationType{Canary: 1}, 10))
+	assert.Equal(t, 0, b.canarySize(&OperationType{Canary: 3}, 3))
+
+	b.CanaryTransactions = 0
+	assert.Equal(t, 0, b.canarySize(&OperationType{}, 1000))
+	assert.Equal(t, 5, b.canarySize(&OperationType{Canary: 5}, 1000))
+}
+
+func TestStepCanaryPushed(t *testing.T) {
+	var none *stepCanary
+	assert.False(t, none.pushed(nil))
+
+	canary := &stepCanary{size: 2}
+	chunk := []*eos.Action{{Account: AN("eosio"), Name: eos.ActionName("newaccount")}}
+	assert.False(t, canary.pushed(chunk))
+	assert.True(t, canary.pushed(chunk))
+	assert.False(t, canary.pushed(chunk))
+	assert.Len(t, canary.chunks, 2)
+}
@@ -0,0 +1,159 @@
+package bios
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	eos "github.com/eoscanada/eos-go"
+)
+
+// ChainIDConfig is the boot sequence's `chain_id` section, selecting
+// how the target chain ID is derived:
+//
+//	genesis       nodeos derives it from the genesis (default), checked
+//	              against the launch discovery's `target_chain_id` if set
+//	constitution  the sha256 of the `constitution.md` target content, or
+//	              of the local `constitution_path` file
+//	launch_data   the sha256 of the canonical launch data, see LaunchDataHash
+//	explicit      `value`, 64 hex characters
+//
+// Anything but `genesis` is written to the genesis' `initial_chain_id`.
+// With `constitution_hash`, the constitution must hash to it. Setting
+// `constitution_path` or `constitution_hash` implies the
+// `constitution` derivation.
+type ChainIDConfig struct {
+	Derivation       string `json:"derivation"`
+	Value            string `json:"value"`
+	ConstitutionPath string `json:"constitution_path"`
+	ConstitutionHash string `json:"constitution_hash"`
+}
+
+const (
+	ChainIDFromGenesis      = "genesis"
+	ChainIDFromConstitution = "constitution"
+	ChainIDFromLaunchData   = "launch_data"
+	ChainIDExplicit         = "explicit"
+)
+
+func (c *ChainIDConfig) validate() error {
+	if c == nil {
+		return nil
+	}
+
+	if c.ConstitutionPath != "" || c.ConstitutionHash != "" {
+		if c.derivation() != ChainIDFromConstitution {
+			return fmt.Errorf("chain_id: `constitution_path` and `constitution_hash` are only used with the `constitution` derivation")
+		}
+		if c.ConstitutionHash != "" {
+			hash, err := hex.DecodeString(c.ConstitutionHash)
+			if err != nil || len(hash) != sha256.Size {
+				return fmt.Errorf("chain_id: `constitution_hash` should be 64 hex characters, got %q", c.ConstitutionHash)
+			}
+		}
+	}
+
+	switch c.Derivation {
+	case "", ChainIDFromGenesis, ChainIDFromConstitution, ChainIDFromLaunchData:
+		if c.Value != "" {
+			return fmt.Errorf("chain_id: `value` is only used with the `explicit` derivation")
+		}
+	case ChainIDExplicit:
+		value, err := hex.DecodeString(c.Value)
+		if err != nil || len(value) != sha256.Size {
+			return fmt.Errorf("chain_id: `value` should be 64 hex characters, got %q", c.Value)
+		}
+	default:
+		return fmt.Errorf("chain_id: unknown derivation %q, expected `genesis`, `constitution`, `launch_data` or `explicit`", c.Derivation)
+	}
+	return nil
+}
+
+func (c *ChainIDConfig) derivation() string {
+	if c == nil {
+		return ChainIDFromGenesis
+	}
+	if c.Derivation == "" {
+		if c.ConstitutionPath != "" || c.ConstitutionHash != "" {
+			return ChainIDFromConstitution
+		}
+		return ChainIDFromGenesis
+	}
+	return c.Derivation
+}
+
+// deriveChainID computes the chain ID the target chain must have,
+// according to the selected derivation. It's nil when left to nodeos
+// and not set in the launch discovery.
+func (b *BIOS) deriveChainID() (eos.SHA256Bytes, error) {
+	switch b.ChainID.derivation() {
+	case ChainIDFromGenesis:
+		if len(b.LaunchDisco.TargetChainID) != 0 {
+			return b.LaunchDisco.TargetChainID, nil
+		}
+		return nil, nil
+	case ChainIDFromConstitution:
+		return b.constitutionChainID()
+	case ChainIDFromLaunchData:
+		return hex.DecodeString(LaunchDataHash(b.LaunchDisco.TargetContents))
+	}
+
+	value, _ := hex.DecodeString(b.ChainID.Value) // checked by validate()
+	return value, nil
+}
+
+// readConstitution reads the local `constitution_path` when set,
+// the `constitution.md` target content otherwise.
+func (b *BIOS) readConstitution() ([]byte, error) {
+	if b.ChainID != nil && b.ChainID.ConstitutionPath != "" {
+		return ioutil.ReadFile(b.ChainID.ConstitutionPath)
+	}
+	return b.ReadContents(ConstitutionFile, "")
+}
+
+func (b *BIOS) constitutionChainID() (eos.SHA256Bytes, error) {
+	constitution, err := b.readConstitution()
+	if err != nil {
+		return nil, fmt.Errorf("chain ID from constitution: %s", err)
+	}
+
+	hash := ConstitutionHash(constitution)
+	if expected := b.ChainID.ConstitutionHash; expected != "" && !strings.EqualFold(hex.EncodeToString(hash), expected) {
+		return nil, fmt.Errorf("chain ID from constitution: it hashes to %x, `constitution_hash` says %s", hash, expected)
+	}
+	return hash, nil
+}
+
+// checkChainID verifies the running target chain has the chain ID of
+// the selected derivation.
+func (b *BIOS) checkChainID() error {
+	if len(b.TargetChainID) == 0 {
+		return nil
+	}
+
+	info, err := b.TargetNetAPI.GetInfo()
+	if err != nil {
+		return fmt.Errorf("get info: %s", err)
+	}
+
+	if !bytes.Equal(info.ChainID, b.TargetChainID) {
+		return fmt.Errorf("target chain ID is %s, the %s derivation gives %s", info.ChainID, b.ChainID.derivation(), b.TargetChainID)
+	}
+	return nil
+}
+
+// checkGenesisChainID verifies a genesis received from the boot node
+// carries the chain ID of the selected derivation.
+func (b *BIOS) checkGenesisChainID(genesis *GenesisJSON) error {
+	if b.ChainID.derivation() == ChainIDFromGenesis {
+		return nil
+	}
+
+	if genesis.InitialChainID != hex.EncodeToString(b.TargetChainID) {
+		return fmt.Errorf("genesis has initial_chain_id %q, the %s derivation gives %s", genesis.InitialChainID, b.ChainID.derivation(), b.TargetChainID)
+	}
+	return nil
+}
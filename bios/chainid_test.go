@@ -0,0 +1,112 @@
+package bios
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eoscanada/eos-bios/bios/disco"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChainIDConfigValidate(t *testing.T) {
+	var none *ChainIDConfig
+	assert.NoError(t, none.validate())
+	assert.Equal(t, ChainIDFromGenesis, none.derivation())
+
+	assert.NoError(t, (&ChainIDConfig{Derivation: "constitution"}).validate())
+	assert.NoError(t, (&ChainIDConfig{Derivation: "explicit", Value: "00000000000000000000000000000000000000000000000000000000000000ff"}).validate())
+	assert.Error(t, (&ChainIDConfig{Derivation: "explicit", Value: "ff"}).validate())
+	assert.Error(t, (&ChainIDConfig{Derivation: "launch_data", Value: "00"}).validate())
+	assert.Error(t, (&ChainIDConfig{Derivation: "random"}).validate())
+}
+
+func TestDeriveChainID(t *testing.T) {
+	contents := []disco.ContentRef{
+		{Name: "snapshot.csv", Ref: "/ipfs/Qm2"},
+		{Name: "boot_sequence.yaml", Ref: "/ipfs/Qm1", Comment: "left out"},
+	}
+	b := &BIOS{LaunchDisco: &disco.Discovery{TargetContents: contents}}
+
+	id, err := b.deriveChainID()
+	assert.NoError(t, err)
+	assert.Nil(t, id)
+
+	b.LaunchDisco.TargetChainID = []byte{1, 2, 3}
+	id, err = b.deriveChainID()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3}, []byte(id))
+
+	b.ChainID = &ChainIDConfig{Derivation: ChainIDFromLaunchData}
+	id, err = b.deriveChainID()
+	assert.NoError(t, err)
+	assert.Equal(t, LaunchDataHash(contents), hex.EncodeToString(id))
+
+	// Same launch data in another order, same chain ID.
+	b.LaunchDisco.TargetContents = []disco.ContentRef{contents[1], contents[0]}
+	again, err := b.deriveChainID()
+	assert.NoError(t, err)
+	assert.Equal(t, id, again)
+
+	b.ChainID = &ChainIDConfig{Derivation: ChainIDExplicit, Value: "00000000000000000000000000000000000000000000000000000000000000ff"}
+	id, err = b.deriveChainID()
+	assert.NoError(t, err)
+	assert.Equal(t, "00000000000000000000000000000000000000000000000000000000000000ff", hex.EncodeToString(id))
+
+	b.TargetChainID = id
+	assert.NoError(t, b.checkGenesisChainID(&GenesisJSON{InitialChainID: "00000000000000000000000000000000000000000000000000000000000000ff"}))
+	assert.Error(t, b.checkGenesisChainID(&GenesisJSON{}))
+}
+
+func TestConstitutionPathChainID(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eos-bios-chainid")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "constitution.md")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("Article I\n"), 0644))
+	expected := hex.EncodeToString(ConstitutionHash([]byte("Article I\n")))
+
+	config := &ChainIDConfig{ConstitutionPath: path, ConstitutionHash: expected}
+	assert.NoError(t, config.validate())
+	assert.Equal(t, ChainIDFromConstitution, config.derivation())
+
+	b := &BIOS{ChainID: config}
+	id, err := b.deriveChainID()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, hex.EncodeToString(id))
+
+	config.ConstitutionHash = "00000000000000000000000000000000000000000000000000000000000000ff"
+	_, err = b.deriveChainID()
+	assert.Error(t, err)
+
+	assert.Error(t, (&ChainIDConfig{ConstitutionHash: "ff"}).validate())
+	assert.Error(t, (&ChainIDConfig{Derivation: "explicit", ConstitutionPath: path}).validate())
+}
+
+func TestSetConstitution(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eos-bios-setconstitution")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "constitution.md")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("Article I\n"), 0644))
+
+	b := &BIOS{ChainID: &ChainIDConfig{ConstitutionPath: path}}
+	b.TargetChainID, err = b.deriveChainID()
+	assert.NoError(t, err)
+
+	actions, err := (&OpSetConstitution{}).Actions(b)
+	assert.NoError(t, err)
+	if assert.Len(t, actions, 1) {
+		assert.Equal(t, AN("eosio"), actions[0].Account)
+		assert.Equal(t, SetConstitution{Constitution: "Article I\n"}, actions[0].ActionData.Data)
+	}
+
+	// Amended after the chain ID was derived
+	assert.NoError(t, ioutil.WriteFile(path, []byte("Article I, amended\n"), 0644))
+	_, err = (&OpSetConstitution{}).Actions(b)
+	assert.Error(t, err)
+}
@@ -0,0 +1,57 @@
+package bios
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// chainParams returns the blockchain parameters of the boot sequence's
+// `chain_params` section, over those of the genesis, nil when there's
+// no such section.
+func (b *BIOS) chainParams() (*ChainConfiguration, error) {
+	if len(b.ChainParams) == 0 {
+		return nil, nil
+	}
+
+	config, err := b.GenesisConfig.chainConfiguration()
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(b.ChainParams))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(config); err != nil {
+		return nil, fmt.Errorf("chain_params: %s", err)
+	}
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("chain_params: %s", err)
+	}
+	return config, nil
+}
+
+// validate runs the checks nodeos runs on `setparams`, so a bad value
+// is caught before the launch rather than in the middle of the boot.
+func (c *ChainConfiguration) validate() error {
+	switch {
+	case c.TargetBlockNetUsagePct > 10000:
+		return fmt.Errorf("target_block_net_usage_pct can't be more than 10000 (100%%)")
+	case c.TargetBlockCPUUsagePct > 10000:
+		return fmt.Errorf("target_block_cpu_usage_pct can't be more than 10000 (100%%)")
+	case uint64(c.MaxTransactionNetUsage) >= c.MaxBlockNetUsage:
+		return fmt.Errorf("max_transaction_net_usage must be less than max_block_net_usage")
+	case c.BasePerTransactionNetUsage >= c.MaxTransactionNetUsage:
+		return fmt.Errorf("base_per_transaction_net_usage must be less than max_transaction_net_usage")
+	case c.MaxTransactionNetUsage-c.BasePerTransactionNetUsage < 10*1024:
+		return fmt.Errorf("max_transaction_net_usage must leave at least 10KiB over base_per_transaction_net_usage")
+	case c.ContextFreeDiscountNetUsageDen == 0 || c.ContextFreeDiscountNetUsageNum > c.ContextFreeDiscountNetUsageDen:
+		return fmt.Errorf("context_free_discount_net_usage_num/den must be a ratio between 0 and 1")
+	case c.MaxTransactionCPUUsage >= c.MaxBlockCPUUsage:
+		return fmt.Errorf("max_transaction_cpu_usage must be less than max_block_cpu_usage")
+	case c.MinTransactionCPUUsage > c.MaxTransactionCPUUsage:
+		return fmt.Errorf("min_transaction_cpu_usage can't be more than max_transaction_cpu_usage")
+	case c.MaxAuthorityDepth < 1:
+		return fmt.Errorf("max_authority_depth must be at least 1")
+	}
+	return nil
+}
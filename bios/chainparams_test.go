@@ -0,0 +1,67 @@
+package bios
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChainParams(t *testing.T) {
+	b := &BIOS{}
+	params, err := b.chainParams()
+	assert.NoError(t, err)
+	assert.Nil(t, params)
+	_, err = (&OpSetParams{}).Actions(b)
+	assert.Error(t, err)
+
+	b.GenesisConfig = &GenesisConfig{InitialConfiguration: json.RawMessage(`{"max_block_cpu_usage": 400000}`)}
+	b.ChainParams = json.RawMessage(`{"max_transaction_cpu_usage": 300000}`)
+	params, err = b.chainParams()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(400000), params.MaxBlockCPUUsage)
+	assert.Equal(t, uint32(300000), params.MaxTransactionCPUUsage)
+
+	acts, err := (&OpSetParams{}).Actions(b)
+	assert.NoError(t, err)
+	if assert.Len(t, acts, 1) {
+		assert.Equal(t, eos.ActN("setparams"), acts[0].Name)
+		assert.Equal(t, *params, acts[0].ActionData.Data.(SetParams).Params)
+	}
+
+	for _, bad := range []string{
+		`{"max_block_cpu": 1}`,
+		`{"max_transaction_cpu_usage": 500000}`,
+		`{"target_block_net_usage_pct": 10001}`,
+		`{"max_transaction_net_usage": 2097152}`,
+		`{"context_free_discount_net_usage_den": 0}`,
+		`{"max_authority_depth": 0}`,
+	} {
+		b.ChainParams = json.RawMessage(bad)
+		_, err := b.chainParams()
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestSetParamsVerify(t *testing.T) {
+	params := DefaultChainConfiguration()
+	params.MaxBlockCPUUsage = 400000
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		row, _ := json.Marshal(params)
+		fmt.Fprintf(w, `{"rows": [%s]}`, row)
+	}))
+	defer server.Close()
+
+	b := &BIOS{TargetNetAPI: eos.New(server.URL), ChainParams: json.RawMessage(`{"max_block_cpu_usage": 400000}`)}
+	assert.NoError(t, (&OpSetParams{}).Verify(b))
+
+	params.MaxBlockCPUUsage = 200000
+	err := (&OpSetParams{}).Verify(b)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `"max_block_cpu_usage":200000`)
+}
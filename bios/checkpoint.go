@@ -0,0 +1,125 @@
+package bios
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// CheckpointFile holds the progress of the boot sequence injection,
+// next to `genesis.json`, for `--reuse-genesis` to resume from.
+const CheckpointFile = "boot_checkpoint.json"
+
+// checkpointRefresh is how often the checkpoint's block number is
+// refreshed from the target node.
+var checkpointRefresh = 10 * time.Second
+
+// bootCheckpoint is the last chunk of the boot sequence pushed. The
+// chunks after it were all pushed after BlockNum was the head block,
+// so resuming only needs to look for them from there on.
+type bootCheckpoint struct {
+	BootSequenceHash string    `json:"boot_sequence_hash"`
+	InitialKey       string    `json:"initial_key"`
+	Step             int       `json:"step"`
+	Chunk            int       `json:"chunk"`
+	BlockNum         uint32    `json:"block_num"`
+	UpdatedAt        time.Time `json:"updated_at"`
+
+	filename    string
+	head        uint32
+	refreshedAt time.Time
+}
+
+// newBootCheckpoint starts a checkpoint, before any chunk is pushed.
+func (b *BIOS) newBootCheckpoint(filename string) *bootCheckpoint {
+	return &bootCheckpoint{
+		BootSequenceHash: b.BootSequenceHash,
+		InitialKey:       b.EphemeralPublicKey.String(),
+		Step:             -1,
+		Chunk:            -1,
+		filename:         filename,
+	}
+}
+
+// loadBootCheckpoint reads the checkpoint of a previous run, when it
+// was booting the same boot sequence on the same genesis. It's nil
+// otherwise.
+func (b *BIOS) loadBootCheckpoint(filename string) (*bootCheckpoint, error) {
+	cnt, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cp := b.newBootCheckpoint(filename)
+	if err := json.Unmarshal(cnt, cp); err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err)
+	}
+
+	if cp.BootSequenceHash != b.BootSequenceHash || cp.InitialKey != b.EphemeralPublicKey.String() {
+		b.Log.Printf("Ignoring %s, written for another boot sequence or genesis\n", filename)
+		return nil, nil
+	}
+	cp.head = cp.BlockNum
+	return cp, nil
+}
+
+// done returns whether the chunk `chunk` of step `step` was pushed
+// before the checkpoint.
+func (cp *bootCheckpoint) done(step, chunk int) bool {
+	if cp == nil {
+		return false
+	}
+	return step < cp.Step || (step == cp.Step && chunk <= cp.Chunk)
+}
+
+// checkpointPushed moves the checkpoint to a chunk just pushed, with
+// nothing pushed after it, and writes it.
+func (b *BIOS) checkpointPushed(cp *bootCheckpoint, step, chunk int) error {
+	if err := b.refreshCheckpoint(cp, false); err != nil {
+		return err
+	}
+	return cp.moveTo(step, chunk, cp.head)
+}
+
+// refreshCheckpoint reads the head block, to look for the chunks
+// pushed from now on after it. Unless forced, it only does so every
+// checkpointRefresh.
+func (b *BIOS) refreshCheckpoint(cp *bootCheckpoint, force bool) error {
+	if !force && time.Since(cp.refreshedAt) < checkpointRefresh {
+		return nil
+	}
+
+	info, err := b.TargetNetAPI.GetInfo()
+	if err != nil {
+		return fmt.Errorf("get info: %s", err)
+	}
+	cp.head, cp.refreshedAt = info.HeadBlockNum, time.Now()
+	return nil
+}
+
+// moveTo moves the checkpoint to a chunk, the chunks after it being
+// all pushed after `blockNum` was the head block, and writes it.
+func (cp *bootCheckpoint) moveTo(step, chunk int, blockNum uint32) error {
+	cp.Step, cp.Chunk, cp.BlockNum = step, chunk, blockNum
+	return cp.write()
+}
+
+// write replaces the checkpoint file, atomically.
+func (cp *bootCheckpoint) write() error {
+	cp.UpdatedAt = time.Now().UTC()
+	cnt, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := cp.filename + ".tmp"
+	if err := ioutil.WriteFile(tmp, cnt, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, cp.filename)
+}
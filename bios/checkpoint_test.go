@@ -0,0 +1,62 @@
+package bios
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBootCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eos-bios-checkpoint")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, CheckpointFile)
+
+	server := getInfoServer(strings.Repeat("ab", 32), 120, 180)
+	defer server.Close()
+
+	pubKey, err := ecc.NewPublicKey("EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV")
+	assert.NoError(t, err)
+	b := &BIOS{Log: NewLogger(), TargetNetAPI: eos.New(server.URL), BootSequenceHash: "abcd", EphemeralPublicKey: pubKey}
+
+	// Nothing to resume from.
+	cp, err := b.loadBootCheckpoint(filename)
+	assert.NoError(t, err)
+	assert.Nil(t, cp)
+	assert.False(t, cp.done(0, 0))
+
+	cp = b.newBootCheckpoint(filename)
+	assert.False(t, cp.done(0, 0))
+	assert.NoError(t, b.checkpointPushed(cp, 2, 4))
+	// The block number is refreshed every checkpointRefresh only.
+	assert.NoError(t, b.checkpointPushed(cp, 2, 5))
+
+	cp, err = b.loadBootCheckpoint(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, cp.Step)
+	assert.Equal(t, 5, cp.Chunk)
+	assert.Equal(t, uint32(120), cp.BlockNum)
+	assert.True(t, cp.done(1, 30))
+	assert.True(t, cp.done(2, 5))
+	assert.False(t, cp.done(2, 6))
+	assert.False(t, cp.done(3, 0))
+
+	assert.NoError(t, b.refreshCheckpoint(cp, true))
+	assert.Equal(t, uint32(180), cp.head)
+	assert.NoError(t, cp.moveTo(3, 0, 150))
+	cp, err = b.loadBootCheckpoint(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(150), cp.BlockNum)
+
+	// Another boot sequence doesn't resume from it.
+	b.BootSequenceHash = "beef"
+	cp, err = b.loadBootCheckpoint(filename)
+	assert.NoError(t, err)
+	assert.Nil(t, cp)
+}
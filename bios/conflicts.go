@@ -0,0 +1,83 @@
+package bios
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	eos "github.com/eoscanada/eos-go"
+)
+
+// ProducerConflict is a value claimed by several producers of the
+// launch, who'd collide on the booted chain, or for the data
+// encrypted to them.
+type ProducerConflict struct {
+	Field    string
+	Value    string
+	Accounts []eos.AccountName
+}
+
+func (c *ProducerConflict) String() string {
+	var accounts []string
+	for _, account := range c.Accounts {
+		accounts = append(accounts, string(account))
+	}
+	return fmt.Sprintf("%s %s: %s", c.Field, c.Value, strings.Join(accounts, ", "))
+}
+
+// ProducerConflicts returns the account names, block signing keys,
+// Keybase users and PGP fingerprints shared by several peers, sorted by
+// field and value.
+func ProducerConflicts(peers []*Peer) (out []*ProducerConflict) {
+	claims := map[string]map[string][]eos.AccountName{}
+	claim := func(field, value string, account eos.AccountName) {
+		if value == "" {
+			return
+		}
+		if claims[field] == nil {
+			claims[field] = map[string][]eos.AccountName{}
+		}
+		claims[field][value] = append(claims[field][value], account)
+	}
+
+	for _, peer := range peers {
+		disco := peer.Discovery
+		account := disco.SeedNetworkAccountName
+
+		claim("seed_network_account_name", string(account), account)
+		claim("target_account_name", string(disco.TargetAccountName), account)
+		if len(disco.TargetAppointedBlockProducerSigningKey.Content) != 0 {
+			claim("block_signing_key", disco.TargetAppointedBlockProducerSigningKey.String(), account)
+		}
+
+		user, fingerprint := keybaseUser(disco.URLs)
+		claim("keybase", strings.ToLower(user), account)
+		claim("pgp_fingerprint", fingerprint, account)
+	}
+
+	for field, values := range claims {
+		for value, accounts := range values {
+			if len(accounts) > 1 {
+				sort.Slice(accounts, func(i, j int) bool { return accounts[i] < accounts[j] })
+				out = append(out, &ProducerConflict{Field: field, Value: value, Accounts: accounts})
+			}
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Field != out[j].Field {
+			return out[i].Field < out[j].Field
+		}
+		return out[i].Value < out[j].Value
+	})
+	return
+}
+
+// PrintProducerConflicts writes one conflict per line, in a stable
+// order, so reports of several participants can be diffed.
+func PrintProducerConflicts(w io.Writer, conflicts []*ProducerConflict) {
+	for _, conflict := range conflicts {
+		fmt.Fprintln(w, conflict)
+	}
+}
@@ -0,0 +1,50 @@
+package bios
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/eoscanada/eos-bios/bios/disco"
+	"github.com/eoscanada/eos-go/ecc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProducerConflicts(t *testing.T) {
+	key := func(s string) ecc.PublicKey {
+		k, err := ecc.NewPublicKey(s)
+		assert.NoError(t, err)
+		return k
+	}
+	peer := func(seed, target string, signingKey ecc.PublicKey, urls ...string) *Peer {
+		return &Peer{Discovery: &disco.Discovery{
+			SeedNetworkAccountName:                 AN(seed),
+			TargetAccountName:                      AN(target),
+			TargetAppointedBlockProducerSigningKey: signingKey,
+			URLs:                                   urls,
+		}}
+	}
+
+	key1 := key("EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV")
+	key2 := key("EOS5cujNHGMYZZ2tgByyNEUaoPLFhZVmGXbZc9BLJeQkKZFqGYEiQ")
+
+	assert.Empty(t, ProducerConflicts([]*Peer{
+		peer("seed1", "target1", key1, "keybase:one"),
+		peer("seed2", "target2", key2, "keybase:two"),
+		peer("seed3", "target3", ecc.PublicKey{}),
+		peer("seed4", "target4", ecc.PublicKey{}),
+	}))
+
+	conflicts := ProducerConflicts([]*Peer{
+		peer("seed3", "target1", key2, "keybase:One"),
+		peer("seed1", "target1", key1, "keybase:one", "pgp-fingerprint:ABCD"),
+		peer("seed2", "target2", key1, "pgp-fingerprint:abcd"),
+	})
+
+	var out bytes.Buffer
+	PrintProducerConflicts(&out, conflicts)
+	assert.Equal(t, `block_signing_key EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV: seed1, seed2
+keybase one: seed1, seed3
+pgp_fingerprint abcd: seed1, seed2
+target_account_name target1: seed1, seed3
+`, out.String())
+}
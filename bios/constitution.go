@@ -0,0 +1,193 @@
+package bios
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+)
+
+// ConstitutionFile is the name of the governing document in the
+// launch data's `target_contents`.
+const ConstitutionFile = "constitution.md"
+
+// Acknowledgements are published in the `urls` of the discovery
+// file, as `constitution-ack:<sha256 of the constitution>/<signature>`,
+// signed by a key of the seed network account's `active` permission.
+const constitutionAckPrefix = "constitution-ack:"
+
+// ConstitutionAck is a launch participant's acknowledgement of the
+// constitution, as verified against the seed network.
+type ConstitutionAck struct {
+	Account   eos.AccountName
+	Hash      string
+	Signature string
+	PublicKey string
+	Valid     bool
+	Error     string
+}
+
+// ConstitutionHash returns the sha256 of the constitution.
+func ConstitutionHash(content []byte) []byte {
+	h := sha256.Sum256(content)
+	return h[:]
+}
+
+// SignConstitution signs the constitution hash with the first of
+// `keys` that is part of `allowed`, and returns the entry to add to
+// the `urls` of the discovery file.
+func SignConstitution(keys []*ecc.PrivateKey, allowed []ecc.PublicKey, hash []byte) (string, error) {
+	sig, err := signWithActiveKey(keys, allowed, hash)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s%s/%s", constitutionAckPrefix, hex.EncodeToString(hash), sig.String()), nil
+}
+
+// signWithActiveKey signs `hash` with the first of `keys` that is
+// part of `allowed`.
+func signWithActiveKey(keys []*ecc.PrivateKey, allowed []ecc.PublicKey, hash []byte) (sig ecc.Signature, err error) {
+	for _, key := range keys {
+		pubKey := key.PublicKey()
+		for _, allowedKey := range allowed {
+			if pubKey.String() != allowedKey.String() {
+				continue
+			}
+
+			sig, err = key.Sign(hash)
+			if err != nil {
+				return sig, fmt.Errorf("signing: %s", err)
+			}
+			return sig, nil
+		}
+	}
+
+	return sig, fmt.Errorf("none of the keys provided is part of the account's active permission")
+}
+
+func parseConstitutionAck(url string) (hash string, sig ecc.Signature, err error) {
+	parts := strings.Split(strings.TrimPrefix(url, constitutionAckPrefix), "/")
+	if len(parts) != 2 {
+		return "", sig, fmt.Errorf("malformed acknowledgement %q", url)
+	}
+
+	sig, err = ecc.NewSignature(parts[1])
+	if err != nil {
+		return "", sig, fmt.Errorf("invalid signature: %s", err)
+	}
+
+	return parts[0], sig, nil
+}
+
+// ConsensusConstitution reads the constitution agreed upon in the
+// launch data, from the local cache.
+func (net *Network) ConsensusConstitution() ([]byte, error) {
+	launchDisco, err := net.ConsensusDiscovery()
+	if err != nil {
+		return nil, fmt.Errorf("getting consensus on launch data: %s", err)
+	}
+
+	for _, content := range launchDisco.TargetContents {
+		if content.Name == ConstitutionFile {
+			return net.ReadFromCache(content.Ref)
+		}
+	}
+
+	return nil, fmt.Errorf("%q not found in target contents", ConstitutionFile)
+}
+
+// ActiveAuthority returns the `active` permission of an account on the
+// seed network.
+func (net *Network) ActiveAuthority(account eos.AccountName) (auth eos.Authority, err error) {
+	return activeAuthority(net.SeedNetAPI, account)
+}
+
+func activeAuthority(api *eos.API, account eos.AccountName) (auth eos.Authority, err error) {
+	resp, err := api.GetAccount(account)
+	if err != nil {
+		return auth, fmt.Errorf("getting seed network account %q: %s", account, err)
+	}
+
+	for _, perm := range resp.Permissions {
+		if perm.PermName == "active" {
+			return perm.RequiredAuth, nil
+		}
+	}
+
+	return auth, fmt.Errorf("seed network account %q has no active permission", account)
+}
+
+// ActivePublicKeys lists the keys of the `active` permission of an
+// account on the seed network.
+func (net *Network) ActivePublicKeys(account eos.AccountName) (out []ecc.PublicKey, err error) {
+	auth, err := net.ActiveAuthority(account)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range auth.Keys {
+		out = append(out, key.PublicKey)
+	}
+
+	return
+}
+
+// ConstitutionAcks verifies the acknowledgements of `hash` published
+// by each peer. Peers that published none are listed, not valid.
+func (net *Network) ConstitutionAcks(peers []*Peer, hash []byte) (out []*ConstitutionAck) {
+	expectedHash := hex.EncodeToString(hash)
+
+	for _, peer := range peers {
+		account := peer.Discovery.SeedNetworkAccountName
+		ack := &ConstitutionAck{Account: account, Error: "no acknowledgement published"}
+		out = append(out, ack)
+
+		for _, url := range peer.Discovery.URLs {
+			if !strings.HasPrefix(url, constitutionAckPrefix) {
+				continue
+			}
+
+			var sig ecc.Signature
+			var err error
+			ack.Hash, sig, err = parseConstitutionAck(url)
+			if err != nil {
+				ack.Error = err.Error()
+				break
+			}
+			ack.Signature = sig.String()
+
+			if ack.Hash != expectedHash {
+				ack.Error = "acknowledged another version of the constitution"
+				break
+			}
+
+			pubKey, err := sig.PublicKey(hash)
+			if err != nil {
+				ack.Error = fmt.Sprintf("recovering public key: %s", err)
+				break
+			}
+			ack.PublicKey = pubKey.String()
+
+			activeKeys, err := net.ActivePublicKeys(account)
+			if err != nil {
+				ack.Error = err.Error()
+				break
+			}
+
+			ack.Error = "signed by a key not in the account's active permission"
+			for _, key := range activeKeys {
+				if key.String() == pubKey.String() {
+					ack.Valid = true
+					ack.Error = ""
+				}
+			}
+			break
+		}
+	}
+
+	return
+}
@@ -0,0 +1,274 @@
+package bios
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// launchStatus follows the launch for the dashboard: the phases gone
+// through, the boot sequence steps, the verifications and the alerts.
+type launchStatus struct {
+	lock          sync.Mutex
+	phases        []*StatusPhase
+	steps         []*StatusStep
+	verifications []*StatusVerification
+	alerts        []*StatusAlert
+}
+
+type StatusPhase struct {
+	Name      string    `json:"name"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+type StatusStep struct {
+	Op           string `json:"op"`
+	Label        string `json:"label"`
+	State        string `json:"state"` // pending, running, done or failed
+	Transactions int    `json:"transactions"`
+	Done         int    `json:"done"`
+	Error        string `json:"error,omitempty"`
+}
+
+type StatusVerification struct {
+	Name  string    `json:"name"`
+	OK    bool      `json:"ok"`
+	Error string    `json:"error,omitempty"`
+	At    time.Time `json:"at"`
+}
+
+type StatusAlert struct {
+	Level   string    `json:"level"`
+	Message string    `json:"message"`
+	At      time.Time `json:"at"`
+}
+
+// LaunchStatus is what `/status.json` serves.
+type LaunchStatus struct {
+	GeneratedAt   time.Time               `json:"generated_at"`
+	Role          string                  `json:"role"`
+	Phases        []*StatusPhase          `json:"phases"`
+	LastProgress  string                  `json:"last_progress"`
+	Randomness    *RandomnessProof        `json:"randomness"`
+	Producers     []*LaunchReportProducer `json:"producers"`
+	Steps         []*StatusStep           `json:"steps"`
+	Verifications []*StatusVerification   `json:"verifications"`
+	Alerts        []*StatusAlert          `json:"alerts"`
+}
+
+func (s *launchStatus) phase(name string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.phases) != 0 && s.phases[len(s.phases)-1].Name == name {
+		return
+	}
+	s.phases = append(s.phases, &StatusPhase{Name: name, StartedAt: time.Now().UTC()})
+}
+
+func (s *launchStatus) planSteps(steps []*OperationType) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.steps = nil
+	for _, step := range steps {
+		s.steps = append(s.steps, &StatusStep{Op: step.Op, Label: step.Label, State: "pending"})
+	}
+}
+
+// currentStep returns the running step, if any.
+func (s *launchStatus) currentStep() *StatusStep {
+	for _, step := range s.steps {
+		if step.State == "running" {
+			return step
+		}
+	}
+	return nil
+}
+
+func (s *launchStatus) startStep(idx, transactions int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if idx < len(s.steps) {
+		s.steps[idx].State = "running"
+		s.steps[idx].Transactions = transactions
+	}
+}
+
+// chunkDone counts a transaction of the running step, pushed or
+// found already applied.
+func (s *launchStatus) chunkDone() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if step := s.currentStep(); step != nil {
+		step.Done++
+	}
+}
+
+func (s *launchStatus) endStep(idx int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if idx < len(s.steps) {
+		s.steps[idx].State = "done"
+	}
+}
+
+// fail marks the running step as failed, and raises an alert.
+func (s *launchStatus) fail(err error) {
+	s.lock.Lock()
+	if step := s.currentStep(); step != nil {
+		step.State = "failed"
+		step.Error = err.Error()
+	}
+	s.lock.Unlock()
+
+	s.alert("critical", err.Error())
+}
+
+func (s *launchStatus) verified(name string, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	verification := &StatusVerification{Name: name, OK: err == nil, At: time.Now().UTC()}
+	if err != nil {
+		verification.Error = err.Error()
+	}
+	s.verifications = append(s.verifications, verification)
+}
+
+func (s *launchStatus) alert(level, message string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.alerts = append(s.alerts, &StatusAlert{Level: level, Message: message, At: time.Now().UTC()})
+}
+
+// LaunchStatus takes a snapshot of the launch, for the dashboard.
+func (b *BIOS) LaunchStatus() *LaunchStatus {
+	status := &LaunchStatus{
+		GeneratedAt: time.Now().UTC(),
+		Randomness:  b.Randomness,
+	}
+	status.Role, status.Producers = b.reportSchedule()
+	_, status.LastProgress = b.lastProgress()
+
+	b.status.lock.Lock()
+	defer b.status.lock.Unlock()
+
+	status.Phases = append(status.Phases, b.status.phases...)
+	for _, step := range b.status.steps {
+		copied := *step
+		status.Steps = append(status.Steps, &copied)
+	}
+	status.Verifications = append(status.Verifications, b.status.verifications...)
+	status.Alerts = append(status.Alerts, b.status.alerts...)
+
+	return status
+}
+
+// ServeDashboard serves a web page following the launch (phases,
+// shuffle, injection progress, verifications and alerts), from the
+// `/status.json` it also serves, for the people in the war room who'd
+// rather not read the terminal.
+func (b *BIOS) ServeDashboard(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(dashboardHTML))
+	})
+	mux.HandleFunc("/status.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(b.LaunchStatus())
+	})
+
+	b.Log.Printf("Serving the launch dashboard on http://%s/\n", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		b.Log.Println("ERROR listening on dashboard endpoint:", err)
+	}
+}
+
+var dashboardHTML = `<!DOCTYPE html>
+<html><head>
+<meta charset="utf-8">
+<title>eos-bios launch</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+h2 { border-bottom: 1px solid #ccc; }
+table { border-collapse: collapse; }
+td, th { padding: 0.2em 0.8em; text-align: left; }
+.pending { color: #888; }
+.running { color: #06c; font-weight: bold; }
+.done, .ok { color: #080; }
+.failed, .critical, .emergency { color: #c00; font-weight: bold; }
+.warning { color: #c60; }
+progress { width: 12em; }
+</style>
+</head><body>
+<h1>eos-bios launch <small id="role"></small></h1>
+<p id="progress" class="pending"></p>
+
+<h2>Alerts</h2><div id="alerts"></div>
+<h2>Phases</h2><div id="phases"></div>
+<h2>Injection</h2><div id="steps"></div>
+<h2>Verifications</h2><div id="verifications"></div>
+<h2>Shuffle</h2><div id="randomness"></div><div id="producers"></div>
+
+<script>
+function esc(s) {
+  return String(s == null ? "" : s).replace(/[&<>"]/g, function(c) {
+    return {"&": "&amp;", "<": "&lt;", ">": "&gt;", '"': "&quot;"}[c];
+  });
+}
+function time(t) { return new Date(t).toLocaleTimeString(); }
+function table(rows, header) {
+  if (!rows || rows.length == 0) { return '<p class="pending">Nothing yet.</p>'; }
+  return "<table><tr><th>" + header.join("</th><th>") + "</th></tr>" + rows.join("") + "</table>";
+}
+function render(s) {
+  document.getElementById("role").textContent = s.role ? "(" + s.role + ")" : "";
+  document.getElementById("progress").textContent = s.last_progress ? "Last activity: " + s.last_progress : "";
+
+  document.getElementById("alerts").innerHTML = table((s.alerts || []).slice().reverse().map(function(a) {
+    return '<tr class="' + esc(a.level) + '"><td>' + time(a.at) + "</td><td>" + esc(a.level) + "</td><td>" + esc(a.message) + "</td></tr>";
+  }), ["Time", "Level", "Message"]);
+
+  document.getElementById("phases").innerHTML = table((s.phases || []).map(function(p, i, all) {
+    var cls = i == all.length - 1 ? "running" : "done";
+    return '<tr class="' + cls + '"><td>' + time(p.started_at) + "</td><td>" + esc(p.name) + "</td></tr>";
+  }), ["Started", "Phase"]);
+
+  document.getElementById("steps").innerHTML = table((s.steps || []).map(function(st) {
+    var progress = st.transactions ? '<progress max="' + st.transactions + '" value="' + st.done + '"></progress> ' + st.done + "/" + st.transactions : "";
+    return '<tr class="' + esc(st.state) + '"><td>' + esc(st.label) + "</td><td><code>" + esc(st.op) + "</code></td><td>" + esc(st.state) + "</td><td>" + progress + "</td><td>" + esc(st.error) + "</td></tr>";
+  }), ["Step", "Operation", "State", "Transactions", ""]);
+
+  document.getElementById("verifications").innerHTML = table((s.verifications || []).map(function(v) {
+    return '<tr class="' + (v.ok ? "ok" : "failed") + '"><td>' + time(v.at) + "</td><td>" + esc(v.name) + "</td><td>" + (v.ok ? "OK" : "FAILED: " + esc(v.error)) + "</td></tr>";
+  }), ["Time", "Check", "Outcome"]);
+
+  var r = s.randomness;
+  document.getElementById("randomness").innerHTML = r ? "<p>Shuffled with the " + esc(r.source) + " #" + r.block_num + ", hash <code>" + esc(r.block_hash) + "</code>" + (r.decision ? " (" + esc(r.decision) + ")" : "") + ".</p>" : '<p class="pending">Not shuffled yet.</p>';
+  document.getElementById("producers").innerHTML = table((s.producers || []).map(function(p) {
+    return "<tr><td>" + p.Position + "</td><td>" + esc(p.Role) + "</td><td>" + esc(p.SeedAccount) + "</td><td>" + esc(p.TargetAccount) + "</td><td>" + p.Weight + "</td></tr>";
+  }), ["#", "Role", "Seed account", "Target account", "Weight"]);
+}
+function refresh() {
+  fetch("status.json").then(function(resp) { return resp.json(); }).then(render).catch(function(err) {
+    document.getElementById("progress").textContent = "Lost contact with eos-bios: " + err;
+  });
+}
+refresh();
+setInterval(refresh, 2000);
+</script>
+</body></html>
+`
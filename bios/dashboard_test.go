@@ -0,0 +1,51 @@
+package bios
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLaunchStatus(t *testing.T) {
+	b := &BIOS{}
+
+	b.status.phase("launch data loaded")
+	b.status.phase("injecting boot sequence")
+	b.status.phase("injecting boot sequence")
+	b.status.planSteps([]*OperationType{
+		{Op: "system.setcode", Label: "Set code"},
+		{Op: "snapshot.create_accounts", Label: "Snapshot"},
+		{Op: "system.setprods", Label: "Set producers"},
+	})
+
+	b.status.startStep(0, 1)
+	b.status.chunkDone()
+	b.status.endStep(0)
+	b.status.startStep(1, 10)
+	b.status.chunkDone()
+	b.status.chunkDone()
+	b.status.fail(errors.New("push failed"))
+	b.status.verified("required accounts", nil)
+
+	status := b.LaunchStatus()
+
+	if assert.Len(t, status.Phases, 2) {
+		assert.Equal(t, "injecting boot sequence", status.Phases[1].Name)
+	}
+	if assert.Len(t, status.Steps, 3) {
+		assert.Equal(t, "done", status.Steps[0].State)
+		assert.Equal(t, 1, status.Steps[0].Done)
+		assert.Equal(t, "failed", status.Steps[1].State)
+		assert.Equal(t, 2, status.Steps[1].Done)
+		assert.Equal(t, 10, status.Steps[1].Transactions)
+		assert.Equal(t, "push failed", status.Steps[1].Error)
+		assert.Equal(t, "pending", status.Steps[2].State)
+	}
+	if assert.Len(t, status.Alerts, 1) {
+		assert.Equal(t, "critical", status.Alerts[0].Level)
+	}
+	if assert.Len(t, status.Verifications, 1) {
+		assert.True(t, status.Verifications[0].OK)
+	}
+}
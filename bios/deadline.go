@@ -0,0 +1,164 @@
+package bios
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+)
+
+// Fallback behaviors of a step exceeding its deadline (`on_deadline`
+// in the boot sequence). Without one, alerts are only dispatched.
+const (
+	// DeadlineRetry gives the step a fresh budget and a fresh set of
+	// attempts, once. Exceeding it again aborts.
+	DeadlineRetry = "retry"
+	// DeadlineSkip drops the step's remaining transactions and moves
+	// on. Chain validation will report them missing.
+	DeadlineSkip = "skip"
+	// DeadlineAbort stops the boot sequence.
+	DeadlineAbort = "abort"
+)
+
+var errStepSkipped = errors.New("step skipped after exceeding its deadline")
+var errStepRetry = errors.New("step retried after exceeding its deadline")
+
+type stepDeadline struct {
+	b     *BIOS
+	step  *OperationType
+	done  chan struct{}
+	reset chan struct{}
+
+	lock    sync.Mutex
+	start   time.Time
+	retried bool
+}
+
+// watchStepDeadline starts dispatching escalating alerts each time the
+// step's budget is exceeded, until `stop()`. A retry starts over from
+// the first alert. Returns nil for steps without a deadline.
+func (b *BIOS) watchStepDeadline(step *OperationType) *stepDeadline {
+	if step.Deadline == 0 {
+		return nil
+	}
+
+	d := &stepDeadline{b: b, step: step, start: time.Now(), done: make(chan struct{}), reset: make(chan struct{}, 1)}
+	go func() {
+		ticker := time.NewTicker(step.Deadline)
+		defer func() { ticker.Stop() }()
+
+		for overruns := 1; ; overruns++ {
+			select {
+			case <-d.done:
+				return
+			case <-d.reset:
+				ticker.Stop()
+				ticker = time.NewTicker(step.Deadline)
+				overruns = 0
+				continue
+			case <-ticker.C:
+			}
+
+			level := "emergency"
+			switch overruns {
+			case 1:
+				level = "warning"
+			case 2:
+				level = "critical"
+			}
+
+			elapsed := d.elapsed()
+			b.Log.Printf("\n%s: step %q [%s] running for %s, over its deadline of %s\n", level, step.Label, step.Op, elapsed, step.Deadline)
+			b.status.alert(level, fmt.Sprintf("step %q [%s] running for %s, over its deadline of %s", step.Label, step.Op, elapsed.Round(time.Second), step.Deadline))
+			if err := b.DispatchStepDeadline(level, step.Label, step.Op, elapsed, step.Deadline, step.OnDeadline); err != nil {
+				b.Log.Println("error dispatching step_deadline hook:", err)
+			}
+		}
+	}()
+
+	return d
+}
+
+func (d *stepDeadline) stop() {
+	if d != nil {
+		close(d.done)
+	}
+}
+
+func (d *stepDeadline) elapsed() time.Duration {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return time.Since(d.start)
+}
+
+// check applies the step's fallback behavior once its budget is
+// exceeded.
+func (d *stepDeadline) check() error {
+	if d == nil || d.step.OnDeadline == "" || d.elapsed() < d.step.Deadline {
+		return nil
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	switch d.step.OnDeadline {
+	case DeadlineSkip:
+		return errStepSkipped
+	case DeadlineRetry:
+		if !d.retried {
+			d.retried = true
+			d.start = time.Now()
+			select {
+			case d.reset <- struct{}{}:
+			default:
+			}
+			return errStepRetry
+		}
+	}
+
+	return fmt.Errorf("step %q exceeded its deadline of %s", d.step.Op, d.step.Deadline)
+}
+
+// pushChunk pushes one transaction of a step, with retries, within the
+// step's deadline.
+func (b *BIOS) pushChunk(step *OperationType, idx int, chunk []*eos.Action, deadline *stepDeadline) error {
+	for {
+		var deadlineErr error
+		err := Retry(25, time.Second, func() error {
+			if deadlineErr = deadline.check(); deadlineErr != nil {
+				return nil // stop retrying, handled below
+			}
+
+			b.injectionLimiter.wait()
+
+			// Signing happens within SignPushActions, and is accounted
+			// separately by the signer.
+			start, signedBefore := time.Now(), b.injectionMetrics.stage(StageSign)
+			resp, err := b.TargetNetAPI.SignPushActions(chunk...)
+			b.injectionMetrics.observe(StagePush, time.Since(start)-(b.injectionMetrics.stage(StageSign)-signedBefore))
+			if err != nil {
+				if isBusyError(err) {
+					b.injectionLimiter.busy()
+				}
+				b.Log.Printf("r")
+				b.Log.Debugf("error pushing transaction for step %q, chunk %d: %s\n", step.Op, idx, err)
+				return fmt.Errorf("push actions for step %q, chunk %d: %s", step.Op, idx, err)
+			}
+			b.injectionLimiter.ok()
+			b.recordPushedTransaction(step, resp.TransactionID, chunk)
+			b.injectionMetrics.pushed(len(chunk))
+			return nil
+		})
+
+		if deadlineErr == errStepRetry {
+			b.Log.Printf(" (deadline exceeded, retrying) ")
+			continue
+		}
+		if deadlineErr != nil {
+			return deadlineErr
+		}
+		return err
+	}
+}
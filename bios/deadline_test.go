@@ -0,0 +1,72 @@
+package bios
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOperationDeadline(t *testing.T) {
+	var op OperationType
+	err := json.Unmarshal([]byte(`{"op": "system.setprods", "label": "Set prods", "deadline": "2m", "on_deadline": "skip"}`), &op)
+	assert.NoError(t, err)
+	assert.Equal(t, 2*time.Minute, op.Deadline)
+	assert.Equal(t, DeadlineSkip, op.OnDeadline)
+
+	err = json.Unmarshal([]byte(`{"op": "system.setprods", "deadline": "soon"}`), &op)
+	assert.Error(t, err)
+
+	err = json.Unmarshal([]byte(`{"op": "system.setprods", "deadline": "1m", "on_deadline": "panic"}`), &op)
+	assert.Error(t, err)
+}
+
+func TestStepDeadlineCheck(t *testing.T) {
+	overdue := func(fallback string) *stepDeadline {
+		step := &OperationType{Op: "system.setprods", Deadline: time.Second, OnDeadline: fallback}
+		return &stepDeadline{step: step, start: time.Now().Add(-2 * time.Second)}
+	}
+
+	var nilDeadline *stepDeadline
+	assert.NoError(t, nilDeadline.check())
+	assert.NoError(t, overdue("").check())
+	assert.Equal(t, errStepSkipped, overdue(DeadlineSkip).check())
+	assert.Error(t, overdue(DeadlineAbort).check())
+
+	d := overdue(DeadlineRetry)
+	assert.Equal(t, errStepRetry, d.check())
+	assert.NoError(t, d.check())
+
+	d.start = time.Now().Add(-2 * time.Second)
+	assert.EqualError(t, d.check(), `step "system.setprods" exceeded its deadline of 1s`)
+}
+
+func TestStepDeadlineRetryResetsAlerts(t *testing.T) {
+	b := &BIOS{Log: NewLogger()}
+	step := &OperationType{Op: "system.setprods", Label: "Set prods", Deadline: 50 * time.Millisecond, OnDeadline: DeadlineRetry}
+
+	levels := func() (out []string) {
+		b.status.lock.Lock()
+		defer b.status.lock.Unlock()
+		for _, alert := range b.status.alerts {
+			out = append(out, alert.Level)
+		}
+		return
+	}
+	waitAlerts := func(count int) {
+		for i := 0; i < 1000 && len(levels()) < count; i++ {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	d := b.watchStepDeadline(step)
+	waitAlerts(2)
+	assert.Equal(t, errStepRetry, d.check())
+
+	// The retry gets a fresh budget, and alerts escalate from the
+	// first level again.
+	waitAlerts(3)
+	d.stop()
+	assert.Equal(t, []string{"warning", "critical", "warning"}, levels())
+}
@@ -0,0 +1,106 @@
+package bios
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	eos "github.com/eoscanada/eos-go"
+)
+
+// DNS seeds are a low-tech fallback channel to find the producers'
+// p2p endpoints. A seed domain holds TXT records like:
+//
+//	eos-bios-p2p=eoscanadacom@p2p.eoscanada.com:9876
+//
+// and/or SRV records under `_eos-p2p._tcp.<domain>`, for endpoints
+// not tied to a launch participant.
+const dnsSeedTXTPrefix = "eos-bios-p2p="
+
+type DNSSeedRecord struct {
+	Account    eos.AccountName // empty for SRV records
+	P2PAddress string
+}
+
+// ResolveDNSSeed fetches the p2p endpoints published under `domain`.
+func ResolveDNSSeed(domain string) (out []*DNSSeedRecord, err error) {
+	txts, txtErr := net.LookupTXT(domain)
+	for _, txt := range txts {
+		if !strings.HasPrefix(txt, dnsSeedTXTPrefix) {
+			continue
+		}
+
+		parts := strings.SplitN(strings.TrimPrefix(txt, dnsSeedTXTPrefix), "@", 2)
+		if len(parts) != 2 || !strings.Contains(parts[1], ":") {
+			continue
+		}
+
+		out = append(out, &DNSSeedRecord{
+			Account:    eos.AccountName(parts[0]),
+			P2PAddress: parts[1],
+		})
+	}
+
+	_, srvs, srvErr := net.LookupSRV("eos-p2p", "tcp", domain)
+	for _, srv := range srvs {
+		out = append(out, &DNSSeedRecord{
+			P2PAddress: fmt.Sprintf("%s:%d", strings.TrimSuffix(srv.Target, "."), srv.Port),
+		})
+	}
+
+	if txtErr != nil && srvErr != nil {
+		return nil, fmt.Errorf("no TXT (%s) nor SRV (%s) records", txtErr, srvErr)
+	}
+
+	return out, nil
+}
+
+// DNSSeedZone renders the TXT records to publish under `domain`, for
+// the given peers, in zone file format.
+func DNSSeedZone(domain string, peers []*Peer) (out []string) {
+	seen := map[eos.AccountName]bool{}
+	for _, peer := range peers {
+		account := peer.Discovery.TargetAccountName
+		p2pAddr := peer.Discovery.TargetP2PAddress
+		if seen[account] || p2pAddr == "" || p2pAddr == "none" {
+			continue
+		}
+		seen[account] = true
+
+		out = append(out, fmt.Sprintf("%s. 300 IN TXT %q", strings.TrimSuffix(domain, "."), dnsSeedTXTPrefix+string(account)+"@"+p2pAddr))
+	}
+	return
+}
+
+// resolveDNSSeeds loads the endpoints of all the DNS seeds. Being a
+// fallback, failures are only logged.
+func (b *BIOS) resolveDNSSeeds(domains []string) {
+	b.dnsSeedAddresses = map[eos.AccountName]string{}
+	b.dnsSeedExtraAddresses = nil
+
+	for _, domain := range domains {
+		records, err := ResolveDNSSeed(domain)
+		if err != nil {
+			b.Log.Printf("WARN: resolving DNS seed %q: %s\n", domain, err)
+			continue
+		}
+
+		b.Log.Debugf("DNS seed %q: %d records\n", domain, len(records))
+		for _, record := range records {
+			if record.Account == "" {
+				b.dnsSeedExtraAddresses = append(b.dnsSeedExtraAddresses, record.P2PAddress)
+			} else if _, found := b.dnsSeedAddresses[record.Account]; !found {
+				b.dnsSeedAddresses[record.Account] = record.P2PAddress
+			}
+		}
+	}
+}
+
+// p2pAddress returns the peer's endpoint, from the DNS seeds if they
+// list one (being easier to update), from its discovery otherwise.
+func (b *BIOS) p2pAddress(peer *Peer) string {
+	if addr, found := b.dnsSeedAddresses[peer.Discovery.TargetAccountName]; found {
+		return addr
+	}
+	return peer.Discovery.TargetP2PAddress
+}
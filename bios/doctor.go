@@ -0,0 +1,306 @@
+package bios
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+type DoctorStatus string
+
+const (
+	DoctorOK   DoctorStatus = "OK"
+	DoctorWarn DoctorStatus = "WARN"
+	DoctorFail DoctorStatus = "FAIL"
+)
+
+// DoctorCheck is a diagnostic of the local environment, with what to
+// do about it when it isn't OK.
+type DoctorCheck struct {
+	Name   string
+	Status DoctorStatus
+	Detail string
+	Remedy string
+}
+
+// DoctorConfig is what `doctor` looks at. Everything is optional,
+// missing endpoints are skipped.
+type DoctorConfig struct {
+	// TargetAPI is your nodeos HTTP endpoint, KeosdURL your wallet's.
+	TargetAPI string
+	KeosdURL  string
+	// Listen are the `host:port` your node listens on (p2p, http).
+	Listen []string
+	// DataDir holds the chain data, and needs MinFreeDisk bytes.
+	DataDir     string
+	MinFreeDisk uint64
+	// Endpoints are remote HTTP endpoints the launch depends on (seed
+	// network, IPFS gateways), for DNS and proxy checks.
+	Endpoints []string
+}
+
+// Doctor diagnoses the local environment ahead of a launch. Unlike
+// `preflight`, it needs no launch data.
+func Doctor(config *DoctorConfig) (out []*DoctorCheck) {
+	out = append(out, doctorNodeos(config.TargetAPI), doctorKeosd(config.KeosdURL))
+	for _, addr := range config.Listen {
+		out = append(out, doctorPort(addr))
+	}
+	out = append(out, doctorDisk(config.DataDir, config.MinFreeDisk))
+	out = append(out, doctorBinaries()...)
+	out = append(out, doctorProxy(config.TargetAPI, config.KeosdURL))
+	for _, endpoint := range config.Endpoints {
+		out = append(out, doctorDNS(endpoint))
+	}
+	return
+}
+
+// DoctorPassed returns whether none of the checks failed.
+func DoctorPassed(checks []*DoctorCheck) bool {
+	for _, check := range checks {
+		if check.Status == DoctorFail {
+			return false
+		}
+	}
+	return true
+}
+
+func PrintDoctor(w io.Writer, checks []*DoctorCheck) {
+	var remedies []*DoctorCheck
+	for _, check := range checks {
+		fmt.Fprintf(w, "[%-4s] %-16s %s\n", check.Status, check.Name, check.Detail)
+		if check.Status != DoctorOK && check.Remedy != "" {
+			remedies = append(remedies, check)
+		}
+	}
+
+	if len(remedies) != 0 {
+		fmt.Fprintln(w, "")
+		fmt.Fprintln(w, "To fix:")
+		for _, check := range remedies {
+			fmt.Fprintf(w, "- %s: %s\n", check.Name, check.Remedy)
+		}
+	}
+}
+
+func doctorNodeos(targetAPI string) *DoctorCheck {
+	check := &DoctorCheck{Name: "nodeos"}
+	if targetAPI == "" {
+		check.Status, check.Detail = DoctorWarn, "no target API given, skipped"
+		check.Remedy = "pass --target-api (ex: http://127.0.0.1:8888) to check your node"
+		return check
+	}
+
+	var info struct {
+		ServerVersion string `json:"server_version"`
+		HeadBlockNum  uint32 `json:"head_block_num"`
+	}
+	if err := doctorPost(strings.TrimSuffix(targetAPI, "/")+"/v1/chain/get_info", &info); err != nil {
+		check.Status, check.Detail = DoctorFail, fmt.Sprintf("%s unreachable: %s", targetAPI, err)
+		check.Remedy = "start nodeos with `--plugin eosio::chain_api_plugin` and `http-server-address` matching --target-api"
+		return check
+	}
+
+	check.Status, check.Detail = DoctorOK, fmt.Sprintf("%s running %s, at block %d", targetAPI, info.ServerVersion, info.HeadBlockNum)
+	return check
+}
+
+func doctorKeosd(keosdURL string) *DoctorCheck {
+	check := &DoctorCheck{Name: "keosd"}
+
+	var wallets []string
+	if err := doctorPost(strings.TrimSuffix(keosdURL, "/")+"/v1/wallet/list_wallets", &wallets); err != nil {
+		// Keys can also come from files, a wallet is optional.
+		check.Status, check.Detail = DoctorWarn, fmt.Sprintf("%s unreachable: %s", keosdURL, err)
+		check.Remedy = "start keosd if you sign with `--seednet-signer wallet:`, or set KEOSD_URL to where it listens"
+		return check
+	}
+
+	check.Status, check.Detail = DoctorOK, fmt.Sprintf("%s has %d wallets", keosdURL, len(wallets))
+	return check
+}
+
+// doctorPort checks something listens on `addr`, or that it's free
+// for nodeos to take.
+func doctorPort(addr string) *DoctorCheck {
+	check := &DoctorCheck{Name: "port " + addr}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		check.Status, check.Detail = DoctorFail, err.Error()
+		return check
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+
+	if conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, port), 2*time.Second); err == nil {
+		conn.Close()
+		check.Status, check.Detail = DoctorOK, "listening"
+		return check
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		check.Status, check.Detail = DoctorFail, fmt.Sprintf("nothing listening, and can't bind it: %s", err)
+		check.Remedy = fmt.Sprintf("free port %s, or run as a user allowed to bind it", port)
+		return check
+	}
+	listener.Close()
+
+	check.Status, check.Detail = DoctorWarn, "nothing listening yet, port is free"
+	check.Remedy = fmt.Sprintf("make sure your node listens on %s once started, and that your firewall lets it through", addr)
+	return check
+}
+
+func doctorDisk(dir string, minFree uint64) *DoctorCheck {
+	check := &DoctorCheck{Name: "disk"}
+	if dir == "" {
+		dir = "."
+	}
+
+	free, err := diskFree(dir)
+	if err != nil {
+		check.Status, check.Detail = DoctorWarn, fmt.Sprintf("can't tell the free space of %q: %s", dir, err)
+		return check
+	}
+
+	detail := fmt.Sprintf("%.1f GB free in %q", float64(free)/1e9, dir)
+	if free < minFree {
+		check.Status, check.Detail = DoctorFail, fmt.Sprintf("%s, need %.1f GB", detail, float64(minFree)/1e9)
+		check.Remedy = "free some space, or point your chain data (and --doctor-data-dir) to a larger volume"
+		return check
+	}
+
+	check.Status, check.Detail = DoctorOK, detail
+	return check
+}
+
+type doctorBinary struct {
+	name   string
+	args   []string
+	remedy string
+}
+
+var doctorBinaryList = []doctorBinary{
+	{"nodeos", []string{"--version"}, "install EOSIO, unless your node runs in a container (the sample hooks use Docker)"},
+	{"keosd", []string{"--version"}, "install EOSIO if you sign with a local wallet"},
+	{"cleos", []string{"version", "client"}, "install EOSIO to inspect the chain by hand on launch day"},
+	{"docker", []string{"--version"}, "install Docker if your hooks use it, like the sample ones"},
+	{"gpg", []string{"--version"}, "install GnuPG to verify the PGP signatures of the other participants"},
+	{"keybase", []string{"version"}, "install Keybase to fetch the participants' PGP keys and chat on launch day"},
+}
+
+func doctorBinaries() (out []*DoctorCheck) {
+	for _, bin := range doctorBinaryList {
+		out = append(out, doctorVersion(bin))
+	}
+	return
+}
+
+func doctorVersion(bin doctorBinary) *DoctorCheck {
+	check := &DoctorCheck{Name: bin.name}
+
+	path, err := exec.LookPath(bin.name)
+	if err != nil {
+		check.Status, check.Detail, check.Remedy = DoctorWarn, "not found in PATH", bin.remedy
+		return check
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cnt, err := exec.CommandContext(ctx, path, bin.args...).CombinedOutput()
+	if err != nil {
+		check.Status, check.Detail = DoctorWarn, fmt.Sprintf("%s found, but `%s %s` failed: %s", path, bin.name, strings.Join(bin.args, " "), err)
+		check.Remedy = "reinstall " + bin.name
+		return check
+	}
+
+	version, _ := bufio.NewReader(bytes.NewReader(cnt)).ReadString('\n')
+	check.Status, check.Detail = DoctorOK, fmt.Sprintf("%s (%s)", strings.TrimSpace(version), path)
+	return check
+}
+
+// doctorProxy makes sure local endpoints aren't sent to a proxy
+// configured for the outside world.
+func doctorProxy(localEndpoints ...string) *DoctorCheck {
+	check := &DoctorCheck{Name: "proxy"}
+
+	var proxied []string
+	for _, endpoint := range localEndpoints {
+		if endpoint == "" {
+			continue
+		}
+		req, err := http.NewRequest("GET", endpoint, nil)
+		if err != nil {
+			continue
+		}
+		if proxy, err := http.ProxyFromEnvironment(req); err == nil && proxy != nil {
+			proxied = append(proxied, fmt.Sprintf("%s through %s", endpoint, proxy.Host))
+		}
+	}
+
+	if len(proxied) != 0 {
+		check.Status, check.Detail = DoctorWarn, "local endpoints go through a proxy: "+strings.Join(proxied, ", ")
+		check.Remedy = "add your node and wallet hosts to NO_PROXY"
+		return check
+	}
+
+	for _, env := range []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy"} {
+		if proxy := os.Getenv(env); proxy != "" {
+			check.Status, check.Detail = DoctorOK, fmt.Sprintf("%s is %s, local endpoints bypass it", env, proxy)
+			return check
+		}
+	}
+
+	check.Status, check.Detail = DoctorOK, "no proxy configured"
+	return check
+}
+
+func doctorDNS(endpoint string) *DoctorCheck {
+	check := &DoctorCheck{Name: "dns"}
+
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Hostname() == "" {
+		check.Status, check.Detail = DoctorFail, fmt.Sprintf("invalid endpoint %q", endpoint)
+		check.Remedy = "endpoints should include the protocol, like https://"
+		return check
+	}
+	check.Name = "dns " + u.Hostname()
+
+	addrs, err := net.LookupHost(u.Hostname())
+	if err != nil {
+		check.Status, check.Detail = DoctorFail, err.Error()
+		check.Remedy = "check your DNS resolver (/etc/resolv.conf, or your network settings on Windows)"
+		return check
+	}
+
+	check.Status, check.Detail = DoctorOK, "resolves to "+strings.Join(addrs, ", ")
+	return check
+}
+
+func doctorPost(endpoint string, out interface{}) error {
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Post(endpoint, "application/json", strings.NewReader(""))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("returned status %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
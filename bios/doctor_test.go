@@ -0,0 +1,70 @@
+package bios
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDoctorEndpoints(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/chain/get_info":
+			w.Write([]byte(`{"server_version":"v1.0.5","head_block_num":42}`))
+		case "/v1/wallet/list_wallets":
+			w.Write([]byte(`["default *"]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	check := doctorNodeos(server.URL)
+	assert.Equal(t, DoctorOK, check.Status)
+	assert.Contains(t, check.Detail, "running v1.0.5, at block 42")
+
+	check = doctorKeosd(server.URL)
+	assert.Equal(t, DoctorOK, check.Status)
+	assert.Contains(t, check.Detail, "has 1 wallets")
+
+	check = doctorNodeos(server.URL + "/nowhere")
+	assert.Equal(t, DoctorFail, check.Status)
+	assert.NotEmpty(t, check.Remedy)
+
+	assert.Equal(t, DoctorWarn, doctorNodeos("").Status)
+}
+
+func TestDoctorPort(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := listener.Addr().String()
+
+	assert.Equal(t, DoctorOK, doctorPort(addr).Status)
+
+	listener.Close()
+	assert.Equal(t, DoctorWarn, doctorPort(addr).Status)
+
+	assert.Equal(t, DoctorFail, doctorPort("no port").Status)
+}
+
+func TestPrintDoctor(t *testing.T) {
+	checks := []*DoctorCheck{
+		{Name: "nodeos", Status: DoctorOK, Detail: "fine", Remedy: "not shown"},
+		{Name: "disk", Status: DoctorFail, Detail: "full", Remedy: "free some space"},
+	}
+	assert.False(t, DoctorPassed(checks))
+	assert.True(t, DoctorPassed(checks[:1]))
+
+	buf := &bytes.Buffer{}
+	PrintDoctor(buf, checks)
+	assert.Contains(t, buf.String(), "[FAIL] disk")
+	assert.Contains(t, buf.String(), "- disk: free some space")
+	assert.NotContains(t, buf.String(), "not shown")
+
+	assert.Equal(t, DoctorFail, doctorDNS("no-protocol").Status)
+	assert.Equal(t, DoctorFail, doctorDisk(".", 1<<62).Status)
+}
@@ -0,0 +1,223 @@
+package bios
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+)
+
+// EntropyConfig is the boot sequence's `entropy` section: which seed
+// network endpoints give the launch block hash seeding the shuffle,
+// and what happens when they disagree or are unreachable.
+//
+// The seed network API we're connected to is always the first source,
+// `sources` are read after it. Policies are:
+//
+//	wait      all sources must answer the same hash, retry until they do (default)
+//	majority  more than half of all the sources answer the same hash
+//	fallback  the first source decides, and after `fallback_after`
+//	          (10m by default) without it, the next one answering does
+type EntropyConfig struct {
+	Sources       []string `json:"sources"`
+	Policy        string   `json:"policy"`
+	FallbackAfter string   `json:"fallback_after"`
+
+	fallbackAfter time.Duration
+}
+
+const (
+	EntropyWait     = "wait"
+	EntropyMajority = "majority"
+	EntropyFallback = "fallback"
+)
+
+// EntropyObservation is the answer of one source, recorded for audit.
+type EntropyObservation struct {
+	Source    string `json:"source"`
+	BlockHash string `json:"block_hash,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+func (c *EntropyConfig) validate() error {
+	if c == nil {
+		return nil
+	}
+
+	switch c.Policy {
+	case "", EntropyWait, EntropyMajority, EntropyFallback:
+	default:
+		return fmt.Errorf("entropy: unknown policy %q, expected `wait`, `majority` or `fallback`", c.Policy)
+	}
+
+	c.fallbackAfter = 10 * time.Minute
+	if c.FallbackAfter != "" {
+		after, err := time.ParseDuration(c.FallbackAfter)
+		if err != nil {
+			return fmt.Errorf("entropy: fallback_after: %s", err)
+		}
+		c.fallbackAfter = after
+	}
+
+	return nil
+}
+
+func (c *EntropyConfig) policy() string {
+	if c == nil || c.Policy == "" {
+		return EntropyWait
+	}
+	return c.Policy
+}
+
+// observeEntropy asks all the sources for the hash of `blockNum`.
+func (b *BIOS) observeEntropy(blockNum uint32) (out []*EntropyObservation) {
+	observe := func(source string, hash eos.SHA256Bytes, err error) {
+		obs := &EntropyObservation{Source: source}
+		if err != nil {
+			obs.Error = err.Error()
+		} else {
+			obs.BlockHash = hex.EncodeToString(hash)
+		}
+		out = append(out, obs)
+	}
+
+	hash, err := b.Network.GetBlockHeight(blockNum)
+	observe("seed network", hash, err)
+
+	if b.Entropy != nil {
+		for _, source := range b.Entropy.Sources {
+			resp, err := eos.New(source).GetBlockByNum(blockNum)
+			if err != nil {
+				observe(source, nil, err)
+				continue
+			}
+			observe(source, resp.ID, nil)
+		}
+	}
+
+	return
+}
+
+// decide applies the policy to a round of observations, made `waited`
+// after the launch block. It returns the hash to seed the shuffle
+// with, or an empty hash to retry, and what was decided.
+func (c *EntropyConfig) decide(observations []*EntropyObservation, waited time.Duration) (hash string, decision string) {
+	counts := map[string]int{}
+	answered := 0
+	for _, obs := range observations {
+		if obs.Error == "" {
+			counts[obs.BlockHash]++
+			answered++
+		}
+	}
+
+	switch c.policy() {
+	case EntropyMajority:
+		for candidate, count := range counts {
+			if count*2 > len(observations) {
+				return candidate, fmt.Sprintf("%d of %d sources agree", count, len(observations))
+			}
+		}
+		return "", fmt.Sprintf("no majority among %d sources, %d answered with %d different hashes", len(observations), answered, len(counts))
+
+	case EntropyFallback:
+		if observations[0].Error == "" {
+			return observations[0].BlockHash, fmt.Sprintf("primary source %q answered", observations[0].Source)
+		}
+		if waited < c.fallbackAfter {
+			return "", fmt.Sprintf("primary source %q unreachable, falling back in %s", observations[0].Source, (c.fallbackAfter - waited).Round(time.Second))
+		}
+		for _, obs := range observations[1:] {
+			if obs.Error == "" {
+				return obs.BlockHash, fmt.Sprintf("primary source %q unreachable for %s, fell back to %q", observations[0].Source, waited.Round(time.Second), obs.Source)
+			}
+		}
+		return "", "no source answered"
+	}
+
+	if answered != len(observations) {
+		return "", fmt.Sprintf("%d of %d sources answered", answered, len(observations))
+	}
+	if len(counts) != 1 {
+		return "", fmt.Sprintf("%d sources answered %d different hashes", len(observations), len(counts))
+	}
+	return observations[0].BlockHash, fmt.Sprintf("all %d sources agree", len(observations))
+}
+
+// EntropyProvider is where the shuffling seed comes from, picked by
+// the boot sequence's `entropy_provider`:
+//
+//	seed_network  the seed network's launch block (default)
+//	bitcoin       the block at `launch_btc_block_height` (default when set)
+//	drand         the `drand` section's round of a drand beacon
+//	nist          the `nist` section's pulse of the NIST beacon
+//
+// The proof records the provider and its block, round or pulse, so
+// anyone can reproduce the shuffle.
+type EntropyProvider interface {
+	Name() string
+	// Wait blocks until the provider's value for the launch is out.
+	Wait() (*RandomnessProof, error)
+}
+
+const (
+	EntropySeedNetwork = "seed_network"
+	EntropyBitcoin     = "bitcoin"
+	EntropyDrand       = "drand"
+	EntropyNIST        = "nist"
+)
+
+func (b *BIOS) newEntropyProvider(name string, drand *DrandConfig, nist *NISTConfig) (EntropyProvider, error) {
+	if name == "" {
+		name = EntropySeedNetwork
+		if b.LaunchBTCBlockHeight != 0 {
+			name = EntropyBitcoin
+		}
+	}
+
+	switch name {
+	case EntropySeedNetwork:
+		return &seedNetworkEntropy{b}, nil
+	case EntropyBitcoin:
+		if b.LaunchBTCBlockHeight == 0 {
+			return nil, fmt.Errorf("entropy_provider: `bitcoin` needs `launch_btc_block_height`")
+		}
+		return &bitcoinEntropy{b}, nil
+	case EntropyDrand:
+		if err := drand.validate(); err != nil {
+			return nil, err
+		}
+		return &drandEntropy{b, drand}, nil
+	case EntropyNIST:
+		if err := nist.validate(); err != nil {
+			return nil, err
+		}
+		return &nistEntropy{b, nist}, nil
+	}
+
+	return nil, fmt.Errorf("entropy_provider: unknown provider %q, expected `seed_network`, `bitcoin`, `drand` or `nist`", name)
+}
+
+type seedNetworkEntropy struct{ b *BIOS }
+
+func (e *seedNetworkEntropy) Name() string { return EntropySeedNetwork }
+
+func (e *seedNetworkEntropy) Wait() (*RandomnessProof, error) { return e.b.waitLaunchBlock(), nil }
+
+type bitcoinEntropy struct{ b *BIOS }
+
+func (e *bitcoinEntropy) Name() string { return EntropyBitcoin }
+
+func (e *bitcoinEntropy) Wait() (*RandomnessProof, error) { return e.b.waitBitcoinBlock() }
+
+// printSeed logs the seed's derivation, with `--print-seed`.
+func (b *BIOS) printSeed(steps []string) {
+	if !b.PrintSeed {
+		return
+	}
+	b.Log.Println("Seed derivation:")
+	for _, step := range steps {
+		b.Log.Println("-", step)
+	}
+}
@@ -0,0 +1,51 @@
+package bios
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntropyPolicies(t *testing.T) {
+	ok := func(source, hash string) *EntropyObservation {
+		return &EntropyObservation{Source: source, BlockHash: hash}
+	}
+	failed := func(source string) *EntropyObservation {
+		return &EntropyObservation{Source: source, Error: "unreachable"}
+	}
+
+	tests := []struct {
+		policy       string
+		observations []*EntropyObservation
+		waited       time.Duration
+		hash         string
+	}{
+		{"", []*EntropyObservation{ok("a", "aa"), ok("b", "aa")}, 0, "aa"},
+		{"", []*EntropyObservation{ok("a", "aa"), ok("b", "bb")}, time.Hour, ""},
+		{"wait", []*EntropyObservation{ok("a", "aa"), failed("b")}, time.Hour, ""},
+
+		{"majority", []*EntropyObservation{ok("a", "aa"), ok("b", "bb"), ok("c", "aa")}, 0, "aa"},
+		{"majority", []*EntropyObservation{ok("a", "aa"), failed("b"), failed("c")}, 0, ""},
+		{"majority", []*EntropyObservation{ok("a", "aa"), ok("b", "bb")}, 0, ""},
+
+		{"fallback", []*EntropyObservation{ok("a", "aa"), ok("b", "bb")}, 0, "aa"},
+		{"fallback", []*EntropyObservation{failed("a"), ok("b", "bb")}, 5 * time.Minute, ""},
+		{"fallback", []*EntropyObservation{failed("a"), failed("b"), ok("c", "cc")}, 10 * time.Minute, "cc"},
+	}
+
+	for _, test := range tests {
+		config := &EntropyConfig{Policy: test.policy}
+		assert.NoError(t, config.validate())
+
+		hash, decision := config.decide(test.observations, test.waited)
+		assert.Equal(t, test.hash, hash, "%s: %s", test.policy, decision)
+	}
+
+	var none *EntropyConfig
+	hash, _ := none.decide([]*EntropyObservation{ok("seed network", "aa")}, 0)
+	assert.Equal(t, "aa", hash)
+
+	assert.Error(t, (&EntropyConfig{Policy: "vote"}).validate())
+	assert.Error(t, (&EntropyConfig{Policy: "fallback", FallbackAfter: "soon"}).validate())
+}
@@ -0,0 +1,158 @@
+package bios
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/system"
+	"github.com/eoscanada/eos-go/token"
+)
+
+// CreatedAccount is a line of the created accounts manifest, which
+// exchanges and wallet providers can use to bootstrap their account
+// databases on day one.
+type CreatedAccount struct {
+	AccountName          eos.AccountName `json:"account_name"`
+	EthereumAddress      string          `json:"ethereum_address"`
+	PublicKey            string          `json:"public_key"`
+	Balance              eos.Asset       `json:"balance"`
+	StakedCPU            eos.Asset       `json:"staked_cpu"`
+	StakedNet            eos.Asset       `json:"staked_net"`
+	Liquid               eos.Asset       `json:"liquid"`
+	CreatedInTransaction string          `json:"created_in_transaction"`
+	FundedInTransaction  string          `json:"funded_in_transaction"`
+}
+
+// accountTransactions keeps track of the transactions that touched
+// an account during the boot sequence.
+type accountTransactions struct {
+	Created string
+	Funded  string
+}
+
+// recordPushedTransaction is called for every transaction the boot
+// node successfully pushed to the target network.
+func (b *BIOS) recordPushedTransaction(step *OperationType, transactionID string, actions []*eos.Action) {
+	b.recordLock.Lock()
+	defer b.recordLock.Unlock()
+
+	b.markProgress(fmt.Sprintf("pushed transaction %s for step %q", transactionID, step.Op))
+	b.queueForFirehose(step, transactionID, actions)
+	b.audit(transactionID, b.AuditLog.pushed(step, transactionID, actions))
+
+	b.pushedTransactions = append(b.pushedTransactions, &PushedTransaction{
+		Step:          step.Op,
+		Label:         step.Label,
+		TransactionID: transactionID,
+		Actions:       len(actions),
+		PushedAt:      time.Now().UTC(),
+	})
+
+	if b.accountTransactions == nil {
+		b.accountTransactions = map[eos.AccountName]*accountTransactions{}
+	}
+
+	get := func(account eos.AccountName) *accountTransactions {
+		txs := b.accountTransactions[account]
+		if txs == nil {
+			txs = &accountTransactions{}
+			b.accountTransactions[account] = txs
+		}
+		return txs
+	}
+
+	for _, act := range actions {
+		switch data := act.ActionData.Data.(type) {
+		case system.NewAccount:
+			get(data.Name).Created = transactionID
+		case token.Transfer:
+			if act.Account == AN("eosio.token") {
+				get(data.To).Funded = transactionID
+			}
+		}
+	}
+}
+
+// CreatedAccounts lists the snapshot accounts that were created by
+// this boot node, with the transactions that created and funded them.
+func (b *BIOS) CreatedAccounts() (out []*CreatedAccount, err error) {
+	snapshotData, err := b.LoadSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hodler := range snapshotData {
+		txs := b.accountTransactions[AN(hodler.AccountName)]
+		if txs == nil {
+			continue // truncated, or not injected by us
+		}
+
+		cpuStake, netStake, rest := b.SnapshotConfig.splitStakes(hodler.Balance)
+
+		out = append(out, &CreatedAccount{
+			AccountName:          AN(hodler.AccountName),
+			EthereumAddress:      hodler.EthereumAddress,
+			PublicKey:            b.snapshotPublicKey(hodler).String(),
+			Balance:              hodler.Balance,
+			StakedCPU:            cpuStake,
+			StakedNet:            netStake,
+			Liquid:               rest,
+			CreatedInTransaction: txs.Created,
+			FundedInTransaction:  txs.Funded,
+		})
+	}
+
+	return
+}
+
+// ExportCreatedAccounts writes the created accounts manifest to
+// `filename`, as JSON if it ends with `.json`, as CSV otherwise.
+func (b *BIOS) ExportCreatedAccounts(filename string) error {
+	accounts, err := b.CreatedAccounts()
+	if err != nil {
+		return err
+	}
+
+	fl, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer fl.Close()
+
+	if filepath.Ext(filename) == ".json" {
+		enc := json.NewEncoder(fl)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(accounts); err != nil {
+			return err
+		}
+	} else {
+		w := csv.NewWriter(fl)
+		_ = w.Write([]string{"account_name", "ethereum_address", "public_key", "balance", "staked_cpu", "staked_net", "liquid", "created_in_transaction", "funded_in_transaction"})
+		for _, acct := range accounts {
+			_ = w.Write([]string{
+				string(acct.AccountName),
+				acct.EthereumAddress,
+				acct.PublicKey,
+				acct.Balance.String(),
+				acct.StakedCPU.String(),
+				acct.StakedNet.String(),
+				acct.Liquid.String(),
+				acct.CreatedInTransaction,
+				acct.FundedInTransaction,
+			})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+	}
+
+	b.Log.Printf("Wrote %d created accounts to %q\n", len(accounts), filename)
+
+	return nil
+}
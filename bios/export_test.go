@@ -0,0 +1,80 @@
+package bios
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eoscanada/eos-bios/bios/disco"
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/system"
+	"github.com/eoscanada/eos-go/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportCreatedAccounts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eos-bios-export")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	snapshot := `0x01,holder1,EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV,100.0000 EOS
+0x02,holder2,EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV,5.0000 EOS
+0x03,holder3,EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV,1.0000 EOS
+`
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, replaceAllWeirdities("/ipfs/Qmsnapshot")), []byte(snapshot), 0644))
+
+	b := &BIOS{
+		Log:     NewLogger(),
+		Network: &Network{cachePath: dir},
+		LaunchDisco: &disco.Discovery{
+			TargetContents: []disco.ContentRef{{Name: "snapshot.csv", Ref: "/ipfs/Qmsnapshot"}},
+		},
+	}
+
+	// holder3 isn't injected by us.
+	step := &OperationType{Op: "snapshot.create_accounts", Label: "Injecting the snapshot"}
+	b.recordPushedTransaction(step, "tx1", []*eos.Action{
+		system.NewNewAccount(AN("eosio"), AN("holder1"), wellKnownPubkey),
+		system.NewNewAccount(AN("eosio"), AN("holder2"), wellKnownPubkey),
+	})
+	b.recordPushedTransaction(step, "tx2", []*eos.Action{
+		token.NewTransfer(AN("eosio"), AN("holder1"), eos.NewEOSAsset(100000), ""),
+	})
+	notToken := token.NewTransfer(AN("eosio"), AN("holder2"), eos.NewEOSAsset(45000), "")
+	notToken.Account = AN("fake.token")
+	b.recordPushedTransaction(step, "tx3", []*eos.Action{notToken})
+
+	csvFile := filepath.Join(dir, "accounts.csv")
+	assert.NoError(t, b.ExportCreatedAccounts(csvFile))
+	fl, err := os.Open(csvFile)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer fl.Close()
+	rows, err := csv.NewReader(fl).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"account_name", "ethereum_address", "public_key", "balance", "staked_cpu", "staked_net", "liquid", "created_in_transaction", "funded_in_transaction"},
+		{"holder1", "0x01", wellKnownPubkey.String(), "100.0000 EOS", "45.0000 EOS", "45.0000 EOS", "10.0000 EOS", "tx1", "tx2"},
+		{"holder2", "0x02", wellKnownPubkey.String(), "5.0000 EOS", "0.2500 EOS", "0.2500 EOS", "4.5000 EOS", "tx1", ""},
+	}, rows)
+
+	jsonFile := filepath.Join(dir, "accounts.json")
+	assert.NoError(t, b.ExportCreatedAccounts(jsonFile))
+	cnt, err := ioutil.ReadFile(jsonFile)
+	assert.NoError(t, err)
+	var accounts []*CreatedAccount
+	assert.NoError(t, json.Unmarshal(cnt, &accounts))
+	if assert.Len(t, accounts, 2) {
+		assert.Equal(t, AN("holder1"), accounts[0].AccountName)
+		assert.Equal(t, eos.NewEOSAsset(450000), accounts[0].StakedCPU)
+		assert.Equal(t, eos.NewEOSAsset(100000), accounts[0].Liquid)
+		assert.Equal(t, "tx1", accounts[0].CreatedInTransaction)
+		assert.Equal(t, "tx2", accounts[0].FundedInTransaction)
+		assert.Equal(t, AN("holder2"), accounts[1].AccountName)
+		assert.Equal(t, "", accounts[1].FundedInTransaction)
+	}
+}
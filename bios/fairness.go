@@ -0,0 +1,247 @@
+package bios
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+
+	"github.com/ryanuber/columnize"
+)
+
+// FairnessReport holds positional statistics of the shuffle, over
+// many block hashes, for the peers in their pre-shuffle order.
+type FairnessReport struct {
+	Samples  int
+	Shuffled int
+	Peers    []*Peer
+	Weighted string
+	Provider string
+
+	// Positions[i][j] counts how many times the peer initially at
+	// position `i` ended up at position `j`, Expected[i][j] the odds
+	// of it: uniform, or from the weights of a weighted shuffle.
+	Positions [][]int
+	Expected  [][]float64
+
+	// ChiSquare tests the hypothesis that the positions follow the
+	// expected odds. Unexpected counts landings of odds zero, which
+	// alone reject it.
+	ChiSquare         float64
+	DegreesOfFreedom  int
+	ChiSquareCritical float64
+	Unexpected        int
+}
+
+// AnalyzeShuffleFairness runs the shuffle of the launch, as set by
+// the boot sequence's `shuffle` section, over each of `hashes`, with
+// the seed derivation of the `provider` entropy provider.
+func AnalyzeShuffleFairness(config *ShuffleConfig, provider string, peers []*Peer, hashes [][]byte) *FairnessReport {
+	report := &FairnessReport{
+		Samples:  len(hashes),
+		Peers:    peers,
+		Provider: provider,
+	}
+	if config.weighted() {
+		report.Weighted = config.Weighted
+	}
+
+	for _, hash := range hashes {
+		shuffled := make([]*Peer, len(peers))
+		copy(shuffled, peers)
+
+		report.Shuffled = int(shuffleTopPeersWith(config, shuffled, rand.NewSource(fairnessSeed(provider, hash))))
+
+		if report.Positions == nil {
+			report.Positions = make([][]int, report.Shuffled)
+			for i := range report.Positions {
+				report.Positions[i] = make([]int, report.Shuffled)
+			}
+		}
+
+		for to := 0; to < report.Shuffled; to++ {
+			for from := 0; from < report.Shuffled; from++ {
+				if shuffled[to] == peers[from] {
+					report.Positions[from][to]++
+				}
+			}
+		}
+	}
+
+	if report.Shuffled > 1 && report.Samples > 0 {
+		report.Expected = expectedPositions(config, peers[:report.Shuffled])
+
+		// A square of counts with fixed row and column totals has
+		// (k-1)^2 degrees of freedom, k being the peers not placed for
+		// sure: a weighted shuffle puts the peers of weight zero last,
+		// in order, which leaves nothing to test in their rows and
+		// columns.
+		uncertain := 0
+		for from, row := range report.Positions {
+			certain := false
+			for to, count := range row {
+				expected := report.Expected[from][to] * float64(report.Samples)
+				if report.Expected[from][to] == 1 {
+					certain = true
+				}
+				if expected == 0 {
+					report.Unexpected += count
+					continue
+				}
+				diff := float64(count) - expected
+				report.ChiSquare += diff * diff / expected
+			}
+			if !certain {
+				uncertain++
+			}
+		}
+		df := (uncertain - 1) * (uncertain - 1)
+
+		// The 95% critical value uses the Wilson-Hilferty
+		// approximation.
+		if df > 0 {
+			report.DegreesOfFreedom = df
+			report.ChiSquareCritical = float64(df) * math.Pow(1-2/(9*float64(df))+1.6449*math.Sqrt(2/(9*float64(df))), 3)
+		}
+	}
+
+	return report
+}
+
+// fairnessSeed derives a seed from a sample hash as `provider` does
+// from its block hash or beacon output. Bitcoin also needs a merkle
+// root, the sha256 of the sample stands in for it.
+func fairnessSeed(provider string, hash []byte) int64 {
+	switch provider {
+	case EntropyBitcoin:
+		merkle := sha256.Sum256(hash)
+		seed, _, err := BitcoinSeed(hex.EncodeToString(hash), hex.EncodeToString(merkle[:]))
+		if err == nil {
+			return seed
+		}
+	case EntropyDrand, EntropyNIST:
+		if len(hash) >= 8 {
+			seed, _ := beaconSeed("beacon output", hash)
+			return seed
+		}
+	}
+	return seedFromBlockHash(hash)
+}
+
+// expectedPositions returns the odds of each of the top `peers` to
+// land at each position: uniform, or for a weighted shuffle, summed
+// over all the orders its draws can come to.
+func expectedPositions(config *ShuffleConfig, peers []*Peer) [][]float64 {
+	odds := make([][]float64, len(peers))
+	for i := range odds {
+		odds[i] = make([]float64, len(peers))
+	}
+
+	if !config.weighted() {
+		for i := range odds {
+			for j := range odds[i] {
+				odds[i][j] = 1 / float64(len(peers))
+			}
+		}
+		return odds
+	}
+
+	var draw func(remaining []int, pos int, p float64)
+	draw = func(remaining []int, pos int, p float64) {
+		var total int64
+		for _, idx := range remaining {
+			if w := config.weight(peers[idx]); w > 0 {
+				total += w
+			}
+		}
+		if total == 0 {
+			// Peers of weight zero keep their order, after the others.
+			for offset, idx := range remaining {
+				odds[idx][pos+offset] += p
+			}
+			return
+		}
+
+		for i, idx := range remaining {
+			w := config.weight(peers[idx])
+			if w <= 0 {
+				continue
+			}
+			odds[idx][pos] += p * float64(w) / float64(total)
+
+			rest := append(append([]int{}, remaining[:i]...), remaining[i+1:]...)
+			draw(rest, pos+1, p*float64(w)/float64(total))
+		}
+	}
+
+	all := make([]int, len(peers))
+	for i := range all {
+		all[i] = i
+	}
+	draw(all, 0, 1)
+
+	return odds
+}
+
+// Biased tells whether the statistics reject the expected odds of the
+// shuffle, at the 5% significance level.
+func (r *FairnessReport) Biased() bool {
+	return r.Unexpected > 0 || (r.DegreesOfFreedom > 0 && r.ChiSquare > r.ChiSquareCritical)
+}
+
+func (r *FairnessReport) provider() string {
+	if r.Provider == "" {
+		return EntropySeedNetwork
+	}
+	return r.Provider
+}
+
+func (r *FairnessReport) Print(w io.Writer) {
+	if r.Shuffled <= 1 {
+		fmt.Fprintf(w, "Network too small, only %d peer(s) in the shuffle.\n", r.Shuffled)
+		return
+	}
+
+	header := "Initial | Seed Account | Weight"
+	sep := "------- | ------------ | ------"
+	for pos := 0; pos < r.Shuffled; pos++ {
+		if pos == 0 {
+			header += " | Boot node"
+			sep += " | ---------"
+			continue
+		}
+		header += fmt.Sprintf(" | Pos. %d", pos+1)
+		sep += " | ------"
+	}
+
+	columns := []string{header, sep}
+	for from, row := range r.Positions {
+		line := fmt.Sprintf("%d | %s | %d", from+1, r.Peers[from].Discovery.SeedNetworkAccountName, r.Peers[from].TotalWeight)
+		for to, count := range row {
+			line += fmt.Sprintf(" | %.1f%%", 100*float64(count)/float64(r.Samples))
+			if r.Weighted != "" {
+				line += fmt.Sprintf(" (%.1f%%)", 100*r.Expected[from][to])
+			}
+		}
+		columns = append(columns, line)
+	}
+
+	if r.Weighted != "" {
+		fmt.Fprintf(w, "Shuffled the top %d of %d peers weighted by %s, seeded as the %s provider does, over %d block hashes (expected odds in parentheses):\n\n", r.Shuffled, len(r.Peers), r.Weighted, r.provider(), r.Samples)
+	} else {
+		fmt.Fprintf(w, "Shuffled the top %d of %d peers, seeded as the %s provider does, over %d block hashes (uniform is %.1f%%):\n\n", r.Shuffled, len(r.Peers), r.provider(), r.Samples, 100/float64(r.Shuffled))
+	}
+	fmt.Fprintln(w, columnize.SimpleFormat(columns))
+	fmt.Fprintln(w, "")
+	fmt.Fprintf(w, "Chi-square: %.2f, with %d degrees of freedom (critical value at 5%%: %.2f)\n", r.ChiSquare, r.DegreesOfFreedom, r.ChiSquareCritical)
+	if r.Unexpected > 0 {
+		fmt.Fprintf(w, "%d peers landed where they had no odds to.\n", r.Unexpected)
+	}
+	if r.Biased() {
+		fmt.Fprintln(w, "=> BIASED: the positions don't follow the expected odds.")
+	} else {
+		fmt.Fprintln(w, "=> No bias detected.")
+	}
+}
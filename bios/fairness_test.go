@@ -0,0 +1,79 @@
+package bios
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+
+	"github.com/eoscanada/eos-bios/bios/disco"
+	eos "github.com/eoscanada/eos-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyzeShuffleFairness(t *testing.T) {
+	var peers []*Peer
+	for _, name := range []string{"bpa", "bpb", "bpc", "bpd", "bpe", "bpf", "bpg", "bph", "bpi", "bpj", "bpk", "bpl", "bpm", "bpn", "bpo", "bpp", "bpq", "bpr", "bps", "bpt"} {
+		peers = append(peers, &Peer{Discovery: &disco.Discovery{SeedNetworkAccountName: eos.AccountName(name)}})
+	}
+
+	var hashes [][]byte
+	for i := 0; i < 2000; i++ {
+		num := make([]byte, 8)
+		binary.LittleEndian.PutUint64(num, uint64(i))
+		hash := sha256.Sum256(num)
+		hashes = append(hashes, hash[:])
+	}
+
+	report := AnalyzeShuffleFairness(nil, EntropySeedNetwork, peers, hashes)
+	assert.Equal(t, 5, report.Shuffled)
+	assert.Equal(t, 16, report.DegreesOfFreedom)
+	assert.InDelta(t, 26.3, report.ChiSquareCritical, 0.1)
+	assert.False(t, report.Biased())
+
+	for _, row := range report.Positions {
+		total := 0
+		for _, count := range row {
+			total += count
+		}
+		assert.Equal(t, 2000, total)
+	}
+
+	// The input list is left untouched.
+	assert.Equal(t, eos.AccountName("bpa"), peers[0].Discovery.SeedNetworkAccountName)
+}
+
+func TestAnalyzeWeightedShuffleFairness(t *testing.T) {
+	var peers []*Peer
+	for _, name := range []string{"bpa", "bpb", "bpc", "bpd", "bpe", "bpf", "bpg", "bph", "bpi", "bpj", "bpk", "bpl", "bpm", "bpn", "bpo", "bpp", "bpq", "bpr", "bps", "bpt"} {
+		peers = append(peers, &Peer{Discovery: &disco.Discovery{SeedNetworkAccountName: eos.AccountName(name)}})
+	}
+	config := &ShuffleConfig{Weighted: "scores", Scores: map[eos.AccountName]int64{"bpa": 4, "bpb": 2, "bpc": 1, "bpd": 1}}
+
+	var hashes [][]byte
+	for i := 0; i < 4000; i++ {
+		num := make([]byte, 8)
+		binary.LittleEndian.PutUint64(num, uint64(i))
+		hash := sha256.Sum256(num)
+		hashes = append(hashes, hash[:])
+	}
+
+	for _, provider := range []string{EntropySeedNetwork, EntropyBitcoin, EntropyDrand} {
+		report := AnalyzeShuffleFairness(config, provider, peers, hashes)
+		assert.Equal(t, 5, report.Shuffled)
+		assert.Equal(t, "scores", report.Weighted)
+		assert.Equal(t, 0, report.Unexpected)
+
+		// bpe, of no score, is always last, leaving the 4 others to
+		// test.
+		assert.Equal(t, 9, report.DegreesOfFreedom)
+		assert.Equal(t, 1.0, report.Expected[4][4])
+		assert.Equal(t, len(hashes), report.Positions[4][4])
+
+		// bpa, of half the weight, comes first half the time.
+		assert.InDelta(t, 0.5, report.Expected[0][0], 1e-9)
+		assert.InDelta(t, 0.5, float64(report.Positions[0][0])/float64(len(hashes)), 0.03, provider)
+	}
+
+	report := AnalyzeShuffleFairness(config, EntropySeedNetwork, peers, hashes)
+	assert.False(t, report.Biased())
+}
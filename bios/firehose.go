@@ -0,0 +1,313 @@
+package bios
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+)
+
+// Firehose receives every action of the boot sequence, as JSON lines,
+// once it's found in a block of the target network, so that pushed
+// transactions that were dropped are never streamed. Blocks are
+// followed during the injection, and the chain validation catches
+// what's left.
+// Block explorers can index the genesis period as it's confirmed
+// instead of re-crawling it.
+type Firehose interface {
+	Send(line []byte) error
+	Close() error
+}
+
+// FirehoseAction is a line sent to the Firehose.
+type FirehoseAction struct {
+	Step          string      `json:"step"`
+	Label         string      `json:"label"`
+	TransactionID string      `json:"transaction_id"`
+	ActionIndex   int         `json:"action_index"`
+	Action        *eos.Action `json:"action"`
+	BlockNum      uint32      `json:"block_num"`
+	Timestamp     time.Time   `json:"timestamp"`
+}
+
+// firehoseTransaction is a pushed transaction, streamed once found in
+// a block.
+type firehoseTransaction struct {
+	step    *OperationType
+	actions []*eos.Action
+}
+
+// NewFirehose sets up a firehose from a target that is either a
+// `nats://host:port/subject` URL, an `http://` or `https://` URL to
+// which each line is POSTed, or a local file to append lines to.
+func NewFirehose(target string) (Firehose, error) {
+	switch {
+	case strings.HasPrefix(target, "nats://"):
+		return newNATSFirehose(target)
+	case strings.HasPrefix(target, "http://"), strings.HasPrefix(target, "https://"):
+		return &httpFirehose{url: target}, nil
+	default:
+		fl, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		return &fileFirehose{fl: fl}, nil
+	}
+}
+
+// queueForFirehose holds the actions of a pushed transaction until
+// confirmFirehose finds it in a block. Called with the recordLock.
+func (b *BIOS) queueForFirehose(step *OperationType, transactionID string, actions []*eos.Action) {
+	if b.Firehose == nil {
+		return
+	}
+
+	if b.firehosePending == nil {
+		b.firehosePending = map[string]*firehoseTransaction{}
+	}
+	b.firehosePending[transactionID] = &firehoseTransaction{step: step, actions: actions}
+}
+
+// confirmFirehose streams the actions of a pushed transaction found in
+// block `blockNum`, stamped with the block's time.
+func (b *BIOS) confirmFirehose(transactionID string, blockNum uint32, blockTime time.Time) {
+	if b.Firehose == nil {
+		return
+	}
+
+	b.recordLock.Lock()
+	tx := b.firehosePending[transactionID]
+	delete(b.firehosePending, transactionID)
+	b.recordLock.Unlock()
+	if tx == nil {
+		return
+	}
+
+	for idx, act := range tx.actions {
+		act.SetToServer(false)
+		line, err := json.Marshal(&FirehoseAction{
+			Step:          tx.step.Op,
+			Label:         tx.step.Label,
+			TransactionID: transactionID,
+			ActionIndex:   idx,
+			Action:        act,
+			BlockNum:      blockNum,
+			Timestamp:     blockTime.UTC(),
+		})
+		if err != nil {
+			b.Log.Debugf("firehose: marshalling action: %s\n", err)
+			continue
+		}
+
+		if err := b.Firehose.Send(line); err != nil {
+			b.Log.Debugf("firehose: sending action: %s\n", err)
+		}
+	}
+}
+
+// firehoseFollowInterval is how long followFirehose waits for the next
+// block to be produced.
+var firehoseFollowInterval = 500 * time.Millisecond
+
+// followFirehose follows the blocks of the target network from its
+// head, confirming to the firehose the pushed transactions they hold,
+// until the returned `stop` is called.
+func (b *BIOS) followFirehose() (stop func()) {
+	if b.Firehose == nil {
+		return func() {}
+	}
+
+	done := make(chan bool)
+	finished := make(chan bool)
+	go func() {
+		defer close(finished)
+
+		blockNum := uint32(1)
+		if info, err := b.TargetNetAPI.GetInfo(); err == nil {
+			blockNum = info.HeadBlockNum + 1
+		}
+
+		for {
+			block, err := b.TargetNetAPI.GetBlockByNum(blockNum)
+			if err == nil {
+				for _, receipt := range block.Transactions {
+					b.confirmFirehose(receipt.Transaction.ID.String(), blockNum, block.Timestamp.Time)
+				}
+				blockNum++
+			}
+
+			wait := firehoseFollowInterval
+			if err == nil {
+				wait = 0
+			}
+			select {
+			case <-done:
+				return
+			case <-time.After(wait):
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-finished
+		})
+	}
+}
+
+// closeFirehose closes the firehose when the boot ends, warning about
+// the pushed transactions never found in a block.
+func (b *BIOS) closeFirehose() {
+	if b.Firehose == nil {
+		return
+	}
+
+	b.recordLock.Lock()
+	if len(b.firehosePending) != 0 {
+		b.Log.Printf("WARNING: firehose: %d pushed transactions weren't found in a block, and weren't streamed\n", len(b.firehosePending))
+	}
+	b.firehosePending = nil
+	b.recordLock.Unlock()
+
+	if err := b.Firehose.Close(); err != nil {
+		b.Log.Printf("WARNING: closing firehose: %s\n", err)
+	}
+}
+
+type fileFirehose struct {
+	fl *os.File
+}
+
+func (f *fileFirehose) Send(line []byte) error {
+	_, err := f.fl.Write(append(line, '\n'))
+	return err
+}
+
+func (f *fileFirehose) Close() error {
+	return f.fl.Close()
+}
+
+type httpFirehose struct {
+	url string
+}
+
+func (f *httpFirehose) Send(line []byte) error {
+	resp, err := http.Post(f.url, "application/json", bytes.NewReader(line))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s returned status %d", f.url, resp.StatusCode)
+	}
+	return nil
+}
+
+func (f *httpFirehose) Close() error {
+	return nil
+}
+
+// natsFirehose speaks the NATS text protocol, which is simple enough
+// not to pull a client library.
+type natsFirehose struct {
+	conn    net.Conn
+	subject string
+	lock    sync.Mutex
+	pong    chan bool
+}
+
+func newNATSFirehose(target string) (*natsFirehose, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+
+	subject := strings.Replace(strings.Trim(u.Path, "/"), "/", ".", -1)
+	if subject == "" {
+		subject = "eos-bios.actions"
+	}
+
+	conn, err := net.DialTimeout("tcp", u.Host, 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	info, err := reader.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("reading nats server info: %s", err)
+	}
+	if !strings.HasPrefix(info, "INFO") {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected nats greeting: %q", info)
+	}
+
+	f := &natsFirehose{
+		conn:    conn,
+		subject: subject,
+		pong:    make(chan bool, 1),
+	}
+
+	if err := f.write("CONNECT {\"verbose\":false,\"pedantic\":false}\r\n"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// The server pings us periodically, and drops us if we don't pong.
+	go func() {
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if strings.HasPrefix(line, "PING") {
+				_ = f.write("PONG\r\n")
+			}
+			if strings.HasPrefix(line, "PONG") {
+				select {
+				case f.pong <- true:
+				default:
+				}
+			}
+		}
+	}()
+
+	return f, nil
+}
+
+func (f *natsFirehose) write(msg string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	_, err := f.conn.Write([]byte(msg))
+	return err
+}
+
+func (f *natsFirehose) Send(line []byte) error {
+	return f.write(fmt.Sprintf("PUB %s %d\r\n%s\r\n", f.subject, len(line), line))
+}
+
+// Close flushes the published messages with a PING, which the server
+// answers once it processed them, and disconnects.
+func (f *natsFirehose) Close() error {
+	if err := f.write("PING\r\n"); err == nil {
+		select {
+		case <-f.pong:
+		case <-time.After(5 * time.Second):
+		}
+	}
+	return f.conn.Close()
+}
@@ -0,0 +1,245 @@
+package bios
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryFirehose struct {
+	lines  []string
+	closed bool
+}
+
+func (f *memoryFirehose) Send(line []byte) error {
+	f.lines = append(f.lines, string(line))
+	return nil
+}
+
+func (f *memoryFirehose) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestFirehoseOnConfirmation(t *testing.T) {
+	firehose := &memoryFirehose{}
+	b := &BIOS{Log: NewLogger(), Firehose: firehose}
+	step := &OperationType{Op: "system.setcode", Label: "Setting code"}
+	actions := []*eos.Action{
+		{Account: AN("eosio"), Name: eos.ActN("setcode")},
+		{Account: AN("eosio"), Name: eos.ActN("setabi")},
+	}
+
+	b.recordPushedTransaction(step, "tx1", actions)
+	b.recordPushedTransaction(step, "tx2", actions[:1])
+	assert.Len(t, firehose.lines, 0, "nothing streamed before being in a block")
+
+	blockTime := time.Date(2018, 6, 3, 12, 0, 0, 0, time.UTC)
+	b.confirmFirehose("tx1", 42, blockTime)
+	b.confirmFirehose("tx1", 43, blockTime) // seen again
+	b.confirmFirehose("unknown", 43, blockTime)
+	if assert.Len(t, firehose.lines, 2) {
+		var line FirehoseAction
+		assert.NoError(t, json.Unmarshal([]byte(firehose.lines[1]), &line))
+		assert.Equal(t, "system.setcode", line.Step)
+		assert.Equal(t, "tx1", line.TransactionID)
+		assert.Equal(t, 1, line.ActionIndex)
+		assert.Equal(t, uint32(42), line.BlockNum)
+		assert.Equal(t, blockTime, line.Timestamp)
+	}
+
+	// tx2 was never found in a block.
+	b.closeFirehose()
+	assert.True(t, firehose.closed)
+	assert.Len(t, firehose.lines, 2)
+}
+
+func TestFileFirehose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eos-bios-firehose")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "actions.jsonl")
+	assert.NoError(t, ioutil.WriteFile(filename, []byte("{\"earlier\":true}\n"), 0644))
+
+	firehose, err := NewFirehose(filename)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, firehose.Send([]byte(`{"a":1}`)))
+	assert.NoError(t, firehose.Send([]byte(`{"a":2}`)))
+	assert.NoError(t, firehose.Close())
+	assert.Error(t, firehose.Send([]byte(`{"a":3}`)))
+
+	content, err := ioutil.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"earlier\":true}\n{\"a\":1}\n{\"a\":2}\n", string(content))
+}
+
+func TestHTTPFirehose(t *testing.T) {
+	var posted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		posted = append(posted, string(body))
+		if strings.Contains(string(body), "refused") {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	firehose, err := NewFirehose(server.URL + "/actions")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, firehose.Send([]byte(`{"a":1}`)))
+	assert.EqualError(t, firehose.Send([]byte(`{"refused":true}`)), server.URL+"/actions returned status 400")
+	assert.NoError(t, firehose.Close())
+	assert.Equal(t, []string{`{"a":1}`, `{"refused":true}`}, posted)
+}
+
+func TestNATSFirehose(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer listener.Close()
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		conn.Write([]byte("INFO {\"server_id\":\"test\"}\r\n"))
+		var lines []string
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				break
+			}
+			lines = append(lines, line)
+			if line == "PING\r\n" {
+				conn.Write([]byte("PONG\r\n"))
+			}
+		}
+		received <- lines
+	}()
+
+	firehose, err := NewFirehose("nats://" + listener.Addr().String() + "/eos/actions")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, firehose.Send([]byte(`{"a":1}`)))
+	assert.NoError(t, firehose.Close())
+
+	select {
+	case lines := <-received:
+		assert.Equal(t, []string{
+			"CONNECT {\"verbose\":false,\"pedantic\":false}\r\n",
+			"PUB eos.actions 7\r\n",
+			"{\"a\":1}\r\n",
+			"PING\r\n",
+		}, lines)
+	case <-time.After(5 * time.Second):
+		t.Error("nats server never saw the connection closed")
+	}
+}
+
+func TestFollowFirehose(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/get_info") {
+			fmt.Fprint(w, `{"head_block_num": 10}`)
+			return
+		}
+		var params struct {
+			BlockNumOrID uint32 `json:"block_num_or_id"`
+		}
+		json.NewDecoder(r.Body).Decode(&params)
+		switch params.BlockNumOrID {
+		case 11:
+			fmt.Fprint(w, `{"block_num": 11, "transactions": [{"trx": "aa01"}]}`)
+		case 12:
+			fmt.Fprint(w, `{"block_num": 12, "transactions": [{"trx": "bb02"}, {"trx": "aa02"}]}`)
+		default:
+			// Not produced yet.
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	defer func(interval time.Duration) { firehoseFollowInterval = interval }(firehoseFollowInterval)
+	firehoseFollowInterval = 10 * time.Millisecond
+
+	firehose := &memoryFirehose{}
+	b := &BIOS{Log: NewLogger(), Firehose: firehose, TargetNetAPI: eos.New(server.URL)}
+	step := &OperationType{Op: "system.newaccount", Label: "Creating accounts"}
+	b.recordPushedTransaction(step, "aa01", []*eos.Action{{Account: AN("eosio"), Name: eos.ActN("newaccount")}})
+	b.recordPushedTransaction(step, "aa02", []*eos.Action{{Account: AN("eosio"), Name: eos.ActN("newaccount")}})
+
+	stop := b.followFirehose()
+	for i := 0; i < 500; i++ {
+		b.recordLock.Lock()
+		pending := len(b.firehosePending)
+		b.recordLock.Unlock()
+		if pending == 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	stop()
+	stop()
+
+	if assert.Len(t, firehose.lines, 2, "confirmed before the chain validation") {
+		var line FirehoseAction
+		assert.NoError(t, json.Unmarshal([]byte(firehose.lines[1]), &line))
+		assert.Equal(t, "aa02", line.TransactionID)
+		assert.Equal(t, uint32(12), line.BlockNum)
+	}
+}
+
+func TestNATSFirehoseBadGreeting(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer listener.Close()
+
+	closed := make(chan bool, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		conn.Write([]byte("-ERR 'Authorization Violation'\r\n"))
+		_, err = conn.Read(make([]byte, 1))
+		closed <- err != nil
+	}()
+
+	_, err = NewFirehose("nats://" + listener.Addr().String() + "/eos/actions")
+	assert.EqualError(t, err, `unexpected nats greeting: "-ERR 'Authorization Violation'\r\n"`)
+
+	select {
+	case ok := <-closed:
+		assert.True(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Error("nats connection left open")
+	}
+}
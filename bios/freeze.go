@@ -0,0 +1,456 @@
+package bios
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+	"golang.org/x/crypto/sha3"
+)
+
+// FreezeConfig describes the ERC-20 freeze: the token whose balances
+// are frozen, the contract where holders registered their EOS public
+// key, and the Ethereum block agreed upon for the freeze.
+type FreezeConfig struct {
+	EthereumRPC          string
+	TokenContract        string
+	RegistrationContract string
+	FreezeBlock          uint64
+
+	// FromBlock is where to start reading the contracts' logs, like
+	// the block the token was deployed at.
+	FromBlock uint64
+	// Confirmations are the blocks to wait past the freeze block,
+	// to be safe from reorgs.
+	Confirmations uint64
+	// LogRange is the number of blocks per `eth_getLogs` call.
+	LogRange uint64
+
+	Log *Logger
+}
+
+const (
+	DefaultTokenContract        = "0x86fa049857e0209aa7d9e616f7eb3b3b78ecfdb0"
+	DefaultRegistrationContract = "0xd0a6e6c54dbc68db5db3a091b171a77407ff7ccf"
+)
+
+var (
+	transferTopic    = ethTopic("Transfer(address,address,uint256)")
+	logRegisterTopic = ethTopic("LogRegister(address,string)")
+)
+
+// FreezeSnapshot is the outcome of the freeze: the canonical
+// `snapshot.csv` (`eth_address,account_name,eos_public_key,balance`,
+// sorted by Ethereum address) for the registered holders, and the
+// `snapshot_unregistered.csv` (`eth_address,account_name,balance`)
+// for the others, with their sha256.
+type FreezeSnapshot struct {
+	FreezeBlock        uint64 `json:"freeze_block"`
+	FreezeBlockHash    string `json:"freeze_block_hash"`
+	Snapshot           []byte `json:"-"`
+	SnapshotSHA256     string `json:"snapshot_sha256"`
+	Unregistered       []byte `json:"-"`
+	UnregisteredSHA256 string `json:"unregistered_sha256"`
+	RegisteredCount    int    `json:"registered_count"`
+	UnregisteredCount  int    `json:"unregistered_count"`
+	InvalidKeys        int    `json:"invalid_keys"`
+}
+
+// WaitFreezeBlock waits for the freeze block to be buried under
+// enough confirmations, and returns its hash.
+func (c *FreezeConfig) WaitFreezeBlock() (string, error) {
+	rpc := &ethRPC{url: c.EthereumRPC}
+	for {
+		var head string
+		if err := rpc.call("eth_blockNumber", []interface{}{}, &head); err != nil {
+			c.Log.Printf("Couldn't get the Ethereum head block, retrying: %s\n", err)
+		} else {
+			headNum, err := parseQuantity(head)
+			if err != nil {
+				return "", fmt.Errorf("eth_blockNumber: %s", err)
+			}
+			if headNum >= c.FreezeBlock+c.Confirmations {
+				break
+			}
+			c.Log.Printf("Ethereum at block %d, waiting for the freeze block %d and %d confirmations (%d to go)\n", headNum, c.FreezeBlock, c.Confirmations, c.FreezeBlock+c.Confirmations-headNum)
+		}
+		time.Sleep(15 * time.Second)
+	}
+
+	var block struct {
+		Hash string `json:"hash"`
+	}
+	if err := rpc.call("eth_getBlockByNumber", []interface{}{quantity(c.FreezeBlock), false}, &block); err != nil {
+		return "", fmt.Errorf("freeze block: %s", err)
+	}
+	return block.Hash, nil
+}
+
+// Snapshot extracts the registrations and balances exactly at the
+// freeze block. Holders are those who ever received the token, their
+// balance is the token's `balanceOf` at the freeze block. Their key is
+// the last one registered up to the freeze block, and holders without
+// a valid key end up in the unregistered snapshot. Tokens still
+// unclaimed in the sale contract aren't counted, have them claimed
+// before the freeze.
+func (c *FreezeConfig) Snapshot(freezeBlockHash string) (*FreezeSnapshot, error) {
+	rpc := &ethRPC{url: c.EthereumRPC}
+
+	keys := map[string]string{}
+	err := c.eachLog(rpc, c.RegistrationContract, logRegisterTopic, func(log *ethLog) error {
+		user, key, err := decodeLogRegister(log.Data)
+		if err != nil {
+			return fmt.Errorf("registration in tx %s: %s", log.TransactionHash, err)
+		}
+		keys[user] = key
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading registrations: %s", err)
+	}
+	c.Log.Printf("Found %d registrations\n", len(keys))
+
+	holders := map[string]bool{}
+	err = c.eachLog(rpc, c.TokenContract, transferTopic, func(log *ethLog) error {
+		if len(log.Topics) != 3 {
+			return nil
+		}
+		holders[topicAddress(log.Topics[2])] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading transfers: %s", err)
+	}
+	c.Log.Printf("Found %d token holders, getting their balances at block %d\n", len(holders), c.FreezeBlock)
+
+	var addresses []string
+	for address := range holders {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	out := &FreezeSnapshot{FreezeBlock: c.FreezeBlock, FreezeBlockHash: freezeBlockHash}
+	var registered, unregistered bytes.Buffer
+	registeredCSV, unregisteredCSV := csv.NewWriter(&registered), csv.NewWriter(&unregistered)
+	accountNames := map[string]string{}
+
+	for _, address := range addresses {
+		balance, err := c.balanceAt(rpc, address)
+		if err != nil {
+			return nil, fmt.Errorf("balance of %s: %s", address, err)
+		}
+		if balance == "" {
+			continue
+		}
+
+		accountName := freezeAccountName(address)
+		if other, found := accountNames[accountName]; found {
+			return nil, fmt.Errorf("account name %q derived from both %s and %s", accountName, other, address)
+		}
+		accountNames[accountName] = address
+
+		key, found := keys[address]
+		if found {
+			pubKey, err := ecc.NewPublicKey(key)
+			if err != nil {
+				c.Log.Printf("- %s registered an invalid key %q, left unregistered\n", address, key)
+				out.InvalidKeys++
+				found = false
+			} else {
+				key = pubKey.String()
+			}
+		}
+
+		if found {
+			_ = registeredCSV.Write([]string{address, accountName, key, balance})
+			out.RegisteredCount++
+		} else {
+			_ = unregisteredCSV.Write([]string{address, accountName, balance})
+			out.UnregisteredCount++
+		}
+	}
+
+	registeredCSV.Flush()
+	unregisteredCSV.Flush()
+
+	out.Snapshot = registered.Bytes()
+	out.SnapshotSHA256 = sha2(out.Snapshot)
+	out.Unregistered = unregistered.Bytes()
+	out.UnregisteredSHA256 = sha2(out.Unregistered)
+
+	return out, nil
+}
+
+// VerifySnapshot recomputes the registered balances and keys at the
+// freeze block, and diffs them against the rows of a snapshot,
+// matched by Ethereum address. Account names aren't checked, they
+// can be assigned differently.
+func (c *FreezeConfig) VerifySnapshot(content []byte) (*SnapshotReport, error) {
+	rows, err := snapshotRecords(content)
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshot: %s", err)
+	}
+
+	computed, err := c.Snapshot("")
+	if err != nil {
+		return nil, err
+	}
+	registered, err := csv.NewReader(bytes.NewReader(computed.Snapshot)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	unregistered, err := csv.NewReader(bytes.NewReader(computed.Unregistered)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	expected := map[string][]string{} // address, to key and balance
+	for _, row := range registered {
+		expected[row[0]] = []string{row[2], row[3]}
+	}
+	notRegistered := map[string]bool{}
+	for _, row := range unregistered {
+		notRegistered[row[0]] = true
+	}
+
+	report := &SnapshotReport{Total: eos.NewEOSAsset(0)}
+	seen := map[string]bool{}
+	for idx, el := range rows {
+		line := idx + 1
+		report.Rows++
+
+		if len(el) != 4 {
+			report.problem(line, "", "should have 4 elements, has %d", len(el))
+			continue
+		}
+
+		address, account, key := strings.ToLower(el[0]), el[1], el[2]
+		balance, err := eos.NewEOSAssetFromString(el[3])
+		if err != nil {
+			report.problem(line, account, "malformed balance %q: %s", el[3], err)
+			continue
+		}
+		report.Total.Amount += balance.Amount
+
+		if seen[address] {
+			report.problem(line, account, "duplicate Ethereum address %s", address)
+			continue
+		}
+		seen[address] = true
+
+		want, found := expected[address]
+		if !found {
+			if notRegistered[address] {
+				report.problem(line, account, "%s has no valid EOS key registered at block %d", address, c.FreezeBlock)
+			} else {
+				report.problem(line, account, "%s holds no tokens at block %d", address, c.FreezeBlock)
+			}
+			continue
+		}
+
+		if pubKey, err := ecc.NewPublicKey(key); err != nil || pubKey.String() != want[0] {
+			report.problem(line, account, "key %q, %s registered %s", key, address, want[0])
+		}
+		if holds, _ := eos.NewEOSAssetFromString(want[1]); balance.Amount != holds.Amount {
+			report.problem(line, account, "balance %s, %s holds %s", balance, address, holds)
+		}
+	}
+
+	for _, row := range registered {
+		if !seen[row[0]] {
+			report.problem(0, "", "%s is missing, registered %s with a balance of %s", row[0], row[2], row[3])
+		}
+	}
+
+	return report, nil
+}
+
+// eachLog goes through the logs of `topic` emitted by `contract`, up
+// to the freeze block, in chain order.
+func (c *FreezeConfig) eachLog(rpc *ethRPC, contract, topic string, f func(*ethLog) error) error {
+	step := c.LogRange
+	if step == 0 {
+		step = 5000
+	}
+
+	for from := c.FromBlock; from <= c.FreezeBlock; from += step {
+		to := from + step - 1
+		if to > c.FreezeBlock {
+			to = c.FreezeBlock
+		}
+
+		var logs []*ethLog
+		err := rpc.call("eth_getLogs", []interface{}{map[string]interface{}{
+			"address":   contract,
+			"topics":    []string{topic},
+			"fromBlock": quantity(from),
+			"toBlock":   quantity(to),
+		}}, &logs)
+		if err != nil {
+			return fmt.Errorf("blocks %d to %d: %s", from, to, err)
+		}
+
+		for _, log := range logs {
+			if log.Removed {
+				continue
+			}
+			if err := f(log); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// balanceAt returns the token balance of `address` at the freeze
+// block, converted from 18 to 4 decimals (truncated), or an empty
+// string when that's zero.
+func (c *FreezeConfig) balanceAt(rpc *ethRPC, address string) (string, error) {
+	var result string
+	err := rpc.call("eth_call", []interface{}{map[string]string{
+		"to":   c.TokenContract,
+		"data": "0x70a08231" + strings.Repeat("0", 24) + strings.TrimPrefix(address, "0x"), // balanceOf(address)
+	}, quantity(c.FreezeBlock)}, &result)
+	if err != nil {
+		return "", err
+	}
+
+	wei, ok := new(big.Int).SetString(strings.TrimPrefix(result, "0x"), 16)
+	if !ok {
+		return "", fmt.Errorf("invalid balance %q", result)
+	}
+
+	units := new(big.Int).Div(wei, big.NewInt(100000000000000)) // 10^14, from 18 to 4 decimals
+	if units.Sign() == 0 {
+		return "", nil
+	}
+
+	whole, fraction := new(big.Int).DivMod(units, big.NewInt(10000), new(big.Int))
+	return fmt.Sprintf("%s.%04d", whole, fraction.Int64()), nil
+}
+
+const accountNameChars = "12345abcdefghijklmnopqrstuvwxyz"
+
+// freezeAccountName derives the 12 characters account name of an
+// Ethereum address, from the sha256 of its lowercase form, so anyone
+// can rebuild it.
+func freezeAccountName(address string) string {
+	hash := sha256.Sum256([]byte(strings.ToLower(address)))
+	name := make([]byte, 12)
+	for i := range name {
+		name[i] = accountNameChars[int(hash[i])%len(accountNameChars)]
+	}
+	return string(name)
+}
+
+// decodeLogRegister decodes the ABI-encoded `(address user, string
+// key)` of a `LogRegister` event.
+func decodeLogRegister(data string) (user string, key string, err error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(data, "0x"))
+	if err != nil {
+		return "", "", err
+	}
+	if len(raw) < 96 {
+		return "", "", fmt.Errorf("log data too short")
+	}
+
+	offset := new(big.Int).SetBytes(raw[32:64])
+	if !offset.IsUint64() || offset.Uint64()+32 > uint64(len(raw)) {
+		return "", "", fmt.Errorf("invalid key offset")
+	}
+	start := offset.Uint64() + 32
+	length := new(big.Int).SetBytes(raw[offset.Uint64():start])
+	if !length.IsUint64() || start+length.Uint64() > uint64(len(raw)) {
+		return "", "", fmt.Errorf("invalid key length")
+	}
+
+	user = "0x" + hex.EncodeToString(raw[12:32])
+	key = strings.TrimSpace(string(raw[start : start+length.Uint64()]))
+	return user, key, nil
+}
+
+func topicAddress(topic string) string {
+	topic = strings.TrimPrefix(topic, "0x")
+	if len(topic) < 40 {
+		return "0x" + strings.ToLower(topic)
+	}
+	return "0x" + strings.ToLower(topic[len(topic)-40:])
+}
+
+func ethTopic(signature string) string {
+	hash := sha3.NewLegacyKeccak256()
+	_, _ = hash.Write([]byte(signature)) // can't fail
+	return "0x" + hex.EncodeToString(hash.Sum(nil))
+}
+
+func quantity(num uint64) string {
+	return "0x" + strconv.FormatUint(num, 16)
+}
+
+func parseQuantity(hexNum string) (uint64, error) {
+	return strconv.ParseUint(strings.TrimPrefix(hexNum, "0x"), 16, 64)
+}
+
+type ethLog struct {
+	Topics          []string `json:"topics"`
+	Data            string   `json:"data"`
+	TransactionHash string   `json:"transactionHash"`
+	Removed         bool     `json:"removed"`
+}
+
+// ethRPC is a minimal Ethereum JSON-RPC client.
+type ethRPC struct {
+	url string
+	id  int
+}
+
+func (r *ethRPC) call(method string, params []interface{}, out interface{}) error {
+	r.id++
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      r.id,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.Post(r.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("%s returned status %d", method, resp.StatusCode)
+	}
+
+	var reply struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return fmt.Errorf("%s: %s", method, err)
+	}
+	if reply.Error != nil {
+		return fmt.Errorf("%s: %s (code %d)", method, reply.Error.Message, reply.Error.Code)
+	}
+
+	return json.Unmarshal(reply.Result, out)
+}
@@ -0,0 +1,207 @@
+package bios
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encodeLogRegister(user, key string) string {
+	word := func(b []byte) string {
+		return fmt.Sprintf("%064s", hex.EncodeToString(b))
+	}
+	padded := hex.EncodeToString([]byte(key))
+	padded += strings.Repeat("0", (64-len(padded)%64)%64)
+	return "0x" + strings.Repeat("0", 24) + strings.TrimPrefix(user, "0x") + word([]byte{64}) + word([]byte{byte(len(key))}) + padded
+}
+
+func TestDecodeLogRegister(t *testing.T) {
+	user, key, err := decodeLogRegister(encodeLogRegister("0x00000000000000000000000000000000000000aa", "EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV "))
+	assert.NoError(t, err)
+	assert.Equal(t, "0x00000000000000000000000000000000000000aa", user)
+	assert.Equal(t, "EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV", key)
+
+	_, _, err = decodeLogRegister("0x1234")
+	assert.Error(t, err)
+}
+
+func TestEthTopic(t *testing.T) {
+	assert.Equal(t, "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef", transferTopic)
+}
+
+func TestFreezeSnapshot(t *testing.T) {
+	const (
+		alice = "0x00000000000000000000000000000000000000aa"
+		bob   = "0x00000000000000000000000000000000000000bb"
+		carol = "0x00000000000000000000000000000000000000cc"
+		dave  = "0x00000000000000000000000000000000000000dd"
+	)
+	balances := map[string]string{
+		alice: "0x3635c9adc5dea00000", // 1000 tokens
+		bob:   "0x1bc16d674ec80000",   // 2 tokens
+		carol: "0x38d7ea4c68000",      // 0.001 token
+		dave:  "0x5af3107a3fff",       // under 0.0001, dropped
+	}
+
+	var calledAt []interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     int             `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		var result interface{}
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x70"
+		case "eth_getBlockByNumber":
+			result = map[string]string{"hash": "0xfeed"}
+		case "eth_getLogs":
+			var params []map[string]interface{}
+			assert.NoError(t, json.Unmarshal(req.Params, &params))
+			assert.Equal(t, "0x64", params[0]["toBlock"])
+
+			logs := []map[string]interface{}{}
+			if params[0]["address"] == "0xregistration" {
+				logs = append(logs,
+					map[string]interface{}{"data": encodeLogRegister(alice, "EOSold")},
+					map[string]interface{}{"data": encodeLogRegister(alice, "EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV")},
+					map[string]interface{}{"data": encodeLogRegister(bob, "not a key")},
+				)
+			} else {
+				for _, to := range []string{alice, bob, carol, dave, bob} {
+					logs = append(logs, map[string]interface{}{"topics": []string{transferTopic, "0x0", "0x000000000000000000000000" + strings.TrimPrefix(to, "0x")}})
+				}
+			}
+			result = logs
+		case "eth_call":
+			var params []interface{}
+			assert.NoError(t, json.Unmarshal(req.Params, &params))
+			calledAt = append(calledAt, params[1])
+			data := params[0].(map[string]interface{})["data"].(string)
+			result = balances["0x"+data[len(data)-40:]]
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}))
+	defer server.Close()
+
+	config := &FreezeConfig{
+		EthereumRPC:          server.URL,
+		TokenContract:        "0xtoken",
+		RegistrationContract: "0xregistration",
+		FreezeBlock:          100,
+		Confirmations:        12,
+	}
+
+	hash, err := config.WaitFreezeBlock()
+	assert.NoError(t, err)
+	assert.Equal(t, "0xfeed", hash)
+
+	snapshot, err := config.Snapshot(hash)
+	assert.NoError(t, err)
+
+	assert.Equal(t, fmt.Sprintf("%s,%s,EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV,1000.0000\n", alice, freezeAccountName(alice)), string(snapshot.Snapshot))
+	assert.Equal(t, fmt.Sprintf("%s,%s,2.0000\n%s,%s,0.0010\n", bob, freezeAccountName(bob), carol, freezeAccountName(carol)), string(snapshot.Unregistered))
+	assert.Equal(t, 1, snapshot.RegisteredCount)
+	assert.Equal(t, 2, snapshot.UnregisteredCount)
+	assert.Equal(t, 1, snapshot.InvalidKeys)
+	assert.Equal(t, sha2(snapshot.Snapshot), snapshot.SnapshotSHA256)
+	for _, at := range calledAt {
+		assert.Equal(t, "0x64", at)
+	}
+
+	assert.Len(t, freezeAccountName(alice), 12)
+	assert.Equal(t, freezeAccountName(alice), freezeAccountName(strings.ToUpper(alice)))
+}
+
+func TestVerifySnapshot(t *testing.T) {
+	const (
+		alice = "0x00000000000000000000000000000000000000aa"
+		bob   = "0x00000000000000000000000000000000000000bb"
+		carol = "0x00000000000000000000000000000000000000cc"
+		dave  = "0x00000000000000000000000000000000000000dd"
+		key   = "EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV"
+		other = "EOS5MHPYyhjBjnQZejzZHqHewPWhGTfQWSVTWYEhDmJu4SXkzgweP"
+	)
+	balances := map[string]string{
+		alice: "0x3635c9adc5dea00000", // 1000 tokens
+		bob:   "0x1bc16d674ec80000",   // 2 tokens
+		carol: "0x38d7ea4c68000",      // 0.001 token
+		dave:  "0x1bc16d674ec80000",   // 2 tokens
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     int             `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		var result interface{}
+		switch req.Method {
+		case "eth_getLogs":
+			var params []map[string]interface{}
+			assert.NoError(t, json.Unmarshal(req.Params, &params))
+
+			logs := []map[string]interface{}{}
+			if params[0]["address"] == "0xregistration" {
+				for _, user := range []string{alice, bob, dave} {
+					logs = append(logs, map[string]interface{}{"data": encodeLogRegister(user, key)})
+				}
+			} else {
+				for _, to := range []string{alice, bob, carol, dave} {
+					logs = append(logs, map[string]interface{}{"topics": []string{transferTopic, "0x0", "0x000000000000000000000000" + strings.TrimPrefix(to, "0x")}})
+				}
+			}
+			result = logs
+		case "eth_call":
+			var params []interface{}
+			assert.NoError(t, json.Unmarshal(req.Params, &params))
+			data := params[0].(map[string]interface{})["data"].(string)
+			result = balances["0x"+data[len(data)-40:]]
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}))
+	defer server.Close()
+
+	config := &FreezeConfig{
+		EthereumRPC:          server.URL,
+		TokenContract:        "0xtoken",
+		RegistrationContract: "0xregistration",
+		FreezeBlock:          100,
+		Log:                  NewLogger(),
+	}
+
+	// The canonical snapshot matches, with account names assigned
+	// differently.
+	report, err := config.VerifySnapshot([]byte(fmt.Sprintf("%s,alice,%s,1000.0000\n%s,bob,%s,2.0000\n%s,dave,%s,2.0000\n", alice, key, strings.ToUpper(bob), key, dave, key)))
+	assert.NoError(t, err)
+	assert.Len(t, report.Problems, 0)
+	assert.Equal(t, 3, report.Rows)
+
+	report, err = config.VerifySnapshot([]byte(fmt.Sprintf("%s,alice,%s,1000.0000\n%s,bob,%s,20.0000\n%s,carol,%s,0.0010\n%s,again,%s,1000.0000\n", alice, other, bob, key, carol, key, alice, key)))
+	assert.NoError(t, err)
+	if assert.Len(t, report.Problems, 5) {
+		assert.Equal(t, 1, report.Problems[0].Line)
+		assert.Contains(t, report.Problems[0].Problem, "registered "+key)
+		assert.Equal(t, 2, report.Problems[1].Line)
+		assert.Contains(t, report.Problems[1].Problem, "holds 2.0000 EOS")
+		assert.Equal(t, 3, report.Problems[2].Line)
+		assert.Contains(t, report.Problems[2].Problem, "no valid EOS key registered")
+		assert.Equal(t, 4, report.Problems[3].Line)
+		assert.Contains(t, report.Problems[3].Problem, "duplicate Ethereum address")
+		assert.Equal(t, 0, report.Problems[4].Line)
+		assert.Contains(t, report.Problems[4].Problem, dave+" is missing")
+	}
+}
@@ -1,16 +1,185 @@
 package bios
 
 import (
+	"bytes"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
+	"time"
 )
 
-// TODO: update with latest GenesisJSON with the basic parameters...
+// GenesisJSON is the `genesis.json` nodeos starts the chain with.
 type GenesisJSON struct {
-	InitialTimestamp string `json:"initial_timestamp"`
-	InitialKey       string `json:"initial_key"`
+	InitialTimestamp     string              `json:"initial_timestamp"`
+	InitialKey           string              `json:"initial_key"`
+	InitialConfiguration *ChainConfiguration `json:"initial_configuration,omitempty"`
+	InitialChainID       string              `json:"initial_chain_id,omitempty"`
+}
+
+// ChainConfiguration holds the chain parameters of a genesis.
+type ChainConfiguration struct {
+	MaxBlockNetUsage               uint64 `json:"max_block_net_usage"`
+	TargetBlockNetUsagePct         uint32 `json:"target_block_net_usage_pct"`
+	MaxTransactionNetUsage         uint32 `json:"max_transaction_net_usage"`
+	BasePerTransactionNetUsage     uint32 `json:"base_per_transaction_net_usage"`
+	NetUsageLeeway                 uint32 `json:"net_usage_leeway"`
+	ContextFreeDiscountNetUsageNum uint32 `json:"context_free_discount_net_usage_num"`
+	ContextFreeDiscountNetUsageDen uint32 `json:"context_free_discount_net_usage_den"`
+	MaxBlockCPUUsage               uint32 `json:"max_block_cpu_usage"`
+	TargetBlockCPUUsagePct         uint32 `json:"target_block_cpu_usage_pct"`
+	MaxTransactionCPUUsage         uint32 `json:"max_transaction_cpu_usage"`
+	MinTransactionCPUUsage         uint32 `json:"min_transaction_cpu_usage"`
+	MaxTransactionLifetime         uint32 `json:"max_transaction_lifetime"`
+	DeferredTrxExpirationWindow    uint32 `json:"deferred_trx_expiration_window"`
+	MaxTransactionDelay            uint32 `json:"max_transaction_delay"`
+	MaxInlineActionSize            uint32 `json:"max_inline_action_size"`
+	MaxInlineActionDepth           uint16 `json:"max_inline_action_depth"`
+	MaxAuthorityDepth              uint16 `json:"max_authority_depth"`
+}
+
+// DefaultChainConfiguration returns the chain parameters nodeos uses
+// when the genesis doesn't set them.
+func DefaultChainConfiguration() *ChainConfiguration {
+	return &ChainConfiguration{
+		MaxBlockNetUsage:               1024 * 1024,
+		TargetBlockNetUsagePct:         1000, // 10%
+		MaxTransactionNetUsage:         512 * 1024,
+		BasePerTransactionNetUsage:     12,
+		NetUsageLeeway:                 500,
+		ContextFreeDiscountNetUsageNum: 20,
+		ContextFreeDiscountNetUsageDen: 100,
+		MaxBlockCPUUsage:               200000,
+		TargetBlockCPUUsagePct:         1000, // 10%
+		MaxTransactionCPUUsage:         150000,
+		MinTransactionCPUUsage:         100,
+		MaxTransactionLifetime:         60 * 60,
+		DeferredTrxExpirationWindow:    10 * 60,
+		MaxTransactionDelay:            45 * 24 * 3600,
+		MaxInlineActionSize:            4 * 1024,
+		MaxInlineActionDepth:           4,
+		MaxAuthorityDepth:              6,
+	}
+}
+
+// genesisTimestampFormat is how nodeos writes `initial_timestamp`.
+const genesisTimestampFormat = "2006-01-02T15:04:05"
+
+// GenesisConfig is the `genesis` section of the boot sequence, which
+// the genesis is derived from, along with the boot node's ephemeral
+// key and the chain ID (see `chain_id`).
+//
+// Without `initial_timestamp`, the boot node stamps the genesis when
+// it boots. `initial_configuration` overrides some of the default
+// chain parameters (see DefaultChainConfiguration).
+type GenesisConfig struct {
+	InitialTimestamp     string          `json:"initial_timestamp"`
+	InitialConfiguration json.RawMessage `json:"initial_configuration"`
+}
+
+func (c *GenesisConfig) validate() error {
+	if c == nil {
+		return nil
+	}
+
+	if c.InitialTimestamp != "" {
+		if _, err := parseGenesisTimestamp(c.InitialTimestamp); err != nil {
+			return fmt.Errorf("genesis: %s", err)
+		}
+	}
+
+	if _, err := c.chainConfiguration(); err != nil {
+		return fmt.Errorf("genesis: %s", err)
+	}
+	return nil
+}
+
+// chainConfiguration returns the default chain parameters, with the
+// overrides of `initial_configuration`.
+func (c *GenesisConfig) chainConfiguration() (*ChainConfiguration, error) {
+	config := DefaultChainConfiguration()
+	if c == nil || len(c.InitialConfiguration) == 0 {
+		return config, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(c.InitialConfiguration))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(config); err != nil {
+		return nil, fmt.Errorf("initial_configuration: %s", err)
+	}
+	return config, nil
+}
+
+func parseGenesisTimestamp(timestamp string) (time.Time, error) {
+	t, err := time.Parse(genesisTimestampFormat, strings.TrimSuffix(timestamp, ".000"))
+	if err != nil {
+		return t, fmt.Errorf("invalid initial_timestamp %q, expected like %q", timestamp, genesisTimestampFormat)
+	}
+	return t, nil
+}
+
+// deriveGenesis derives the genesis from the launch data, with the
+// ephemeral public key of the boot node, stamped `now` unless the boot
+// sequence sets `initial_timestamp`.
+func (b *BIOS) deriveGenesis(pubKey string, now time.Time) (*GenesisJSON, error) {
+	config, err := b.GenesisConfig.chainConfiguration()
+	if err != nil {
+		return nil, err
+	}
+
+	genesis := &GenesisJSON{
+		InitialTimestamp:     now.UTC().Format(genesisTimestampFormat),
+		InitialKey:           pubKey,
+		InitialConfiguration: config,
+	}
+	if b.GenesisConfig != nil && b.GenesisConfig.InitialTimestamp != "" {
+		genesis.InitialTimestamp = b.GenesisConfig.InitialTimestamp
+	}
+	if b.ChainID.derivation() != ChainIDFromGenesis {
+		genesis.InitialChainID = hex.EncodeToString(b.TargetChainID)
+	}
+	return genesis, nil
+}
+
+// genesisClockSkew is how far in the future a received genesis can be
+// stamped, for the boot node's clock being ahead of ours.
+const genesisClockSkew = time.Minute
+
+// checkGenesis verifies a genesis received from the boot node (in the
+// kickstart payload, or through the seed network) against the same
+// derivation from the launch data.
+func (b *BIOS) checkGenesis(genesis *GenesisJSON) error {
+	timestamp, err := parseGenesisTimestamp(genesis.InitialTimestamp)
+	if err != nil {
+		return fmt.Errorf("genesis: %s", err)
+	}
+	if b.GenesisConfig != nil && b.GenesisConfig.InitialTimestamp != "" {
+		expected, _ := parseGenesisTimestamp(b.GenesisConfig.InitialTimestamp) // checked by validate()
+		if !timestamp.Equal(expected) {
+			return fmt.Errorf("genesis has initial_timestamp %q, the boot sequence sets %q", genesis.InitialTimestamp, b.GenesisConfig.InitialTimestamp)
+		}
+	} else if timestamp.After(time.Now().Add(genesisClockSkew)) {
+		return fmt.Errorf("genesis has initial_timestamp %q, in the future", genesis.InitialTimestamp)
+	}
+
+	expected, err := b.GenesisConfig.chainConfiguration()
+	if err != nil {
+		return err
+	}
+	config := genesis.InitialConfiguration
+	if config == nil {
+		// nodeos takes the defaults
+		config = DefaultChainConfiguration()
+	}
+	if *config != *expected {
+		got, _ := json.Marshal(config)
+		want, _ := json.Marshal(expected)
+		return fmt.Errorf("genesis has initial_configuration %s, the boot sequence gives %s", got, want)
+	}
+
+	return b.checkGenesisChainID(genesis)
 }
 
 func readGenesisData(text string, ipfs *IPFS) (out *GenesisJSON, err error) {
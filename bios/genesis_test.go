@@ -0,0 +1,64 @@
+package bios
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testGenesisKey = "EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV"
+
+func TestGenesisConfig(t *testing.T) {
+	var config *GenesisConfig
+	assert.NoError(t, config.validate())
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"initial_timestamp": "2018-06-01T12:00:00.000", "initial_configuration": {"max_block_cpu_usage": 400000}}`), &config))
+	assert.NoError(t, config.validate())
+
+	chainConfig, err := config.chainConfiguration()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(400000), chainConfig.MaxBlockCPUUsage)
+	assert.Equal(t, uint32(150000), chainConfig.MaxTransactionCPUUsage)
+
+	assert.Error(t, (&GenesisConfig{InitialTimestamp: "June 1st"}).validate())
+	assert.Error(t, (&GenesisConfig{InitialConfiguration: json.RawMessage(`{"max_block_cpu": 1}`)}).validate())
+}
+
+func TestDeriveGenesis(t *testing.T) {
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	b := &BIOS{}
+	genesis, err := b.deriveGenesis(testGenesisKey, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "2018-06-01T12:00:00", genesis.InitialTimestamp)
+	assert.Equal(t, testGenesisKey, genesis.InitialKey)
+	assert.Equal(t, DefaultChainConfiguration(), genesis.InitialConfiguration)
+	assert.Equal(t, "", genesis.InitialChainID)
+	assert.NoError(t, b.checkGenesis(genesis))
+
+	// Older boot nodes leave the configuration to nodeos' defaults.
+	genesis.InitialConfiguration = nil
+	assert.NoError(t, b.checkGenesis(genesis))
+
+	genesis.InitialTimestamp = time.Now().Add(time.Hour).UTC().Format(genesisTimestampFormat)
+	assert.Error(t, b.checkGenesis(genesis))
+
+	b.GenesisConfig = &GenesisConfig{
+		InitialTimestamp:     "2018-06-02T00:00:00.000",
+		InitialConfiguration: json.RawMessage(`{"max_transaction_lifetime": 7200}`),
+	}
+	genesis, err = b.deriveGenesis(testGenesisKey, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "2018-06-02T00:00:00.000", genesis.InitialTimestamp)
+	assert.Equal(t, uint32(7200), genesis.InitialConfiguration.MaxTransactionLifetime)
+	assert.NoError(t, b.checkGenesis(genesis))
+
+	genesis.InitialConfiguration.MaxTransactionLifetime = 3600
+	assert.Error(t, b.checkGenesis(genesis))
+
+	genesis, _ = b.deriveGenesis(testGenesisKey, now)
+	genesis.InitialTimestamp = "2018-06-01T12:00:00"
+	assert.EqualError(t, b.checkGenesis(genesis), `genesis has initial_timestamp "2018-06-01T12:00:00", the boot sequence sets "2018-06-02T00:00:00.000"`)
+}
@@ -0,0 +1,178 @@
+package bios
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ContentHash is the hash of a local file that is meant to be
+// referenced in the `target_contents` of a discovery file.
+type ContentHash struct {
+	Name   string
+	Path   string
+	SHA256 string
+
+	// ContractHash is set on `.wasm` files that have a matching
+	// `.abi` file next to them. See `HashCodeFiles`.
+	ContractHash string
+}
+
+// HashFile returns the hex-encoded sha256 of the file's content.
+func HashFile(filename string) (string, error) {
+	fl, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer fl.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, fl); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// HashCodeFiles computes the hash pinning a contract: the sha256 of
+// the concatenation of the raw sha256 of the `.wasm` file and the raw
+// sha256 of the `.abi` file.
+func HashCodeFiles(wasmFile, abiFile string) (string, error) {
+	h := sha256.New()
+	for _, filename := range []string{wasmFile, abiFile} {
+		fileHash, err := HashFile(filename)
+		if err != nil {
+			return "", err
+		}
+
+		raw, _ := hex.DecodeString(fileHash) // we just encoded it
+		_, _ = h.Write(raw)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// IsLaunchContentFile returns whether the file name is one that can
+// be referenced by `target_contents` (boot sequence, snapshots,
+// special accounts, constitution, contracts code and ABIs, Starlark
+// scripts).
+func IsLaunchContentFile(name string) bool {
+	switch {
+	case name == "boot_sequence.yaml", name == ConstitutionFile, name == SpecialAccountsFile:
+		return true
+	case strings.HasPrefix(name, "snapshot") && (strings.HasSuffix(name, ".csv") || strings.HasSuffix(name, ".csv.gz") || strings.HasSuffix(name, ".json")):
+		return true
+	case strings.HasSuffix(name, ".wasm"), strings.HasSuffix(name, ".abi"), strings.HasSuffix(name, ".star"):
+		return true
+	}
+	return false
+}
+
+// LaunchContentHashes hashes all the launch content files found in
+// `dir`, sorted by name.
+func LaunchContentHashes(dir string) (out []*ContentHash, err error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fl := range files {
+		if fl.IsDir() || !IsLaunchContentFile(fl.Name()) {
+			continue
+		}
+
+		path := filepath.Join(dir, fl.Name())
+		fileHash, err := HashFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("hashing %q: %s", path, err)
+		}
+
+		content := &ContentHash{
+			Name:   fl.Name(),
+			Path:   path,
+			SHA256: fileHash,
+		}
+
+		if strings.HasSuffix(fl.Name(), ".wasm") {
+			abiPath := strings.TrimSuffix(path, ".wasm") + ".abi"
+			if _, err := os.Stat(abiPath); err == nil {
+				content.ContractHash, err = HashCodeFiles(path, abiPath)
+				if err != nil {
+					return nil, fmt.Errorf("hashing contract %q: %s", path, err)
+				}
+			}
+		}
+
+		out = append(out, content)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+
+	return
+}
+
+// ContentSHA256FromComment extracts the `sha256:<hex>` written in a
+// `target_contents` comment by `eos-bios launch hash`.
+func ContentSHA256FromComment(comment string) string {
+	return commentField(comment, "sha256:")
+}
+
+// ContentContractHashFromComment extracts the `contract:<hex>` written
+// in the `target_contents` comment of a `.wasm` by `eos-bios launch
+// hash`, checked when the contract is set.
+func ContentContractHashFromComment(comment string) string {
+	return commentField(comment, "contract:")
+}
+
+func commentField(comment, prefix string) string {
+	for _, field := range strings.Fields(comment) {
+		if strings.HasPrefix(field, prefix) {
+			return strings.TrimPrefix(field, prefix)
+		}
+	}
+	return ""
+}
+
+// verifyContractHash checks the code and ABI files of the contract
+// `name` against the contract hash pinned in the comment of its
+// `.wasm`, when there's one.
+func (b *BIOS) verifyContractHash(name, wasmFile, abiFile string) error {
+	var expected string
+	for _, content := range b.LaunchDisco.TargetContents {
+		if content.Name == name+".wasm" {
+			expected = ContentContractHashFromComment(content.Comment)
+		}
+	}
+	if expected == "" {
+		return nil
+	}
+
+	actual, err := HashCodeFiles(wasmFile, abiFile)
+	if err != nil {
+		return fmt.Errorf("hashing contract %q: %s", name, err)
+	}
+	if !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("contract %q has hash %s, pinned to %s", name, actual, expected)
+	}
+	return nil
+}
+
+// ContentMirrorsFromComment extracts the `mirror:<url>` written in a
+// `target_contents` comment, the URLs the content is cross-checked
+// against.
+func ContentMirrorsFromComment(comment string) (out []string) {
+	for _, field := range strings.Fields(comment) {
+		if strings.HasPrefix(field, "mirror:") {
+			out = append(out, strings.TrimPrefix(field, "mirror:"))
+		}
+	}
+	return
+}
@@ -0,0 +1,100 @@
+package bios
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eoscanada/eos-bios/bios/disco"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLaunchContentHashes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eos-bios-hash")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for name, content := range map[string]string{
+		"boot_sequence.yaml": "boot_sequence: []\n",
+		"snapshot.csv":       "",
+		"eosio.token.wasm":   "code",
+		"eosio.token.abi":    "abi",
+		"eosio.msig.wasm":    "lonely code",
+		"README.md":          "not a launch file",
+	} {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	contents, err := LaunchContentHashes(dir)
+	assert.NoError(t, err)
+
+	var names []string
+	for _, content := range contents {
+		names = append(names, content.Name)
+	}
+	assert.Equal(t, []string{"boot_sequence.yaml", "eosio.msig.wasm", "eosio.token.abi", "eosio.token.wasm", "snapshot.csv"}, names)
+
+	// sha256("")
+	assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", contents[4].SHA256)
+
+	assert.Equal(t, "", contents[1].ContractHash)
+	// sha256(sha256("code") + sha256("abi"))
+	assert.Equal(t, "ae5aefab1cd4c5d6e237033ccd28979c63768f171acf50d8aaed73e69b292462", contents[3].ContractHash)
+}
+
+func TestSetCodeContractHash(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eos-bios-hash")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, replaceAllWeirdities("/ipfs/Qmcode")), []byte("code"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, replaceAllWeirdities("/ipfs/Qmabi")), []byte("abi"), 0644))
+
+	// sha256(sha256("code") + sha256("abi"))
+	pinned := "ae5aefab1cd4c5d6e237033ccd28979c63768f171acf50d8aaed73e69b292462"
+	b := &BIOS{
+		Log:     NewLogger(),
+		Network: &Network{cachePath: dir},
+		LaunchDisco: &disco.Discovery{
+			TargetContents: []disco.ContentRef{
+				{Name: "eosio.token.wasm", Ref: "/ipfs/Qmcode", Comment: "sha256:5694d08a2e53ffcae0c3103e5ad6f6076abd960eb1f8a56577040bc1028f702b contract:" + pinned},
+				{Name: "eosio.token.abi", Ref: "/ipfs/Qmabi"},
+			},
+		},
+	}
+	assert.Equal(t, pinned, ContentContractHashFromComment(b.LaunchDisco.TargetContents[0].Comment))
+
+	op := &OpSetCode{Account: AN("eosio.token"), ContractNameRef: "eosio.token"}
+	_, err = op.Actions(b)
+	assert.NoError(t, err)
+
+	// Another ABI than the one pinned.
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, replaceAllWeirdities("/ipfs/Qmabi")), []byte("other abi"), 0644))
+	_, err = op.Actions(b)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "contract \"eosio.token\" has hash ")
+		assert.Contains(t, err.Error(), ", pinned to "+pinned)
+	}
+
+	// Nothing pinned, nothing to check.
+	b.LaunchDisco.TargetContents[0].Comment = ""
+	_, err = op.Actions(b)
+	assert.NoError(t, err)
+}
+
+func TestPinBootSequence(t *testing.T) {
+	raw := []byte("boot_sequence: []\n")
+	b := &BIOS{Log: NewLogger()}
+
+	assert.NoError(t, b.pinBootSequence(raw))
+	assert.Equal(t, sha2(raw), b.BootSequenceHash)
+
+	b.BootSequenceSHA256 = strings.ToUpper(sha2(raw))
+	assert.NoError(t, b.pinBootSequence(raw))
+
+	b.BootSequenceSHA256 = sha2([]byte("boot_sequence: [other]\n"))
+	assert.EqualError(t, b.pinBootSequence(raw), fmt.Sprintf("boot sequence has sha256 %s, pinned to %s", sha2(raw), b.BootSequenceSHA256))
+}
@@ -0,0 +1,157 @@
+package bios
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+)
+
+// HealthStallTimeout is how long the main loop can go without making
+// progress before `/healthz` reports the process as wedged.
+var HealthStallTimeout = 10 * time.Minute
+
+type progressTracker struct {
+	lock sync.Mutex
+	last time.Time
+	what string
+}
+
+// markProgress records that the main loop is alive and moving.
+func (b *BIOS) markProgress(what string) {
+	b.progress.lock.Lock()
+	defer b.progress.lock.Unlock()
+
+	b.progress.last = time.Now()
+	b.progress.what = what
+}
+
+func (b *BIOS) lastProgress() (time.Time, string) {
+	b.progress.lock.Lock()
+	defer b.progress.lock.Unlock()
+
+	return b.progress.last, b.progress.what
+}
+
+// setGenesis and setTargetSigner take the progress lock, as `/readyz`
+// reads the genesis and the signer while the boot sequence sets them.
+func (b *BIOS) setGenesis(gen *GenesisJSON) {
+	b.progress.lock.Lock()
+	defer b.progress.lock.Unlock()
+
+	b.Genesis = gen
+}
+
+func (b *BIOS) setTargetSigner(signer eos.Signer) {
+	b.progress.lock.Lock()
+	defer b.progress.lock.Unlock()
+
+	b.TargetNetAPI.Signer = signer
+}
+
+func (b *BIOS) targetSnapshot() (*GenesisJSON, eos.Signer) {
+	b.progress.lock.Lock()
+	defer b.progress.lock.Unlock()
+
+	return b.Genesis, b.TargetNetAPI.Signer
+}
+
+type healthCheck struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// ServeHealth serves `/healthz` (liveness: the process is making
+// progress and can write its state), and `/readyz` (readiness: the
+// wallet and the chains are reachable), for supervisors like systemd
+// or Kubernetes to restart a wedged instance.
+func (b *BIOS) ServeHealth(addr string) {
+	b.markProgress("starting")
+
+	b.Log.Printf("Serving health checks on http://%s/healthz and /readyz\n", addr)
+	if err := http.ListenAndServe(addr, b.healthHandler()); err != nil {
+		b.Log.Println("ERROR listening on health checks endpoint:", err)
+	}
+}
+
+func (b *BIOS) healthHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeHealthChecks(w, b.checkProgress(), b.checkStateStore())
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		genesis, signer := b.targetSnapshot()
+		checks := []*healthCheck{checkWallet(signer), b.checkSeedNetwork()}
+		if genesis != nil {
+			// The target network only exists once the genesis is known.
+			checks = append(checks, b.checkTargetNetwork())
+		}
+		writeHealthChecks(w, checks...)
+	})
+	return mux
+}
+
+func writeHealthChecks(w http.ResponseWriter, checks ...*healthCheck) {
+	status := http.StatusOK
+	for _, check := range checks {
+		if !check.OK {
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(checks)
+}
+
+func newHealthCheck(name string, err error) *healthCheck {
+	check := &healthCheck{Name: name, OK: err == nil}
+	if err != nil {
+		check.Error = err.Error()
+	}
+	return check
+}
+
+func (b *BIOS) checkProgress() *healthCheck {
+	last, what := b.lastProgress()
+	var err error
+	if since := time.Since(last); since > HealthStallTimeout {
+		err = fmt.Errorf("no progress for %s, last was: %s", since, what)
+	}
+	return newHealthCheck("progress", err)
+}
+
+func (b *BIOS) checkStateStore() *healthCheck {
+	fl, err := ioutil.TempFile(b.Network.cachePath, ".healthz")
+	if err == nil {
+		fl.Close()
+		err = os.Remove(fl.Name())
+	}
+	return newHealthCheck("state_store", err)
+}
+
+func checkWallet(signer eos.Signer) *healthCheck {
+	_, err := signer.AvailableKeys()
+	return newHealthCheck("wallet", err)
+}
+
+func (b *BIOS) checkSeedNetwork() *healthCheck {
+	if b.SingleOnly {
+		return newHealthCheck("seed_network", nil)
+	}
+	_, err := b.Network.SeedNetAPI.GetInfo()
+	return newHealthCheck("seed_network", err)
+}
+
+func (b *BIOS) checkTargetNetwork() *healthCheck {
+	_, err := b.TargetNetAPI.GetInfo()
+	return newHealthCheck("target_network", err)
+}
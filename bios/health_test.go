@@ -0,0 +1,96 @@
+package bios
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/system"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadyzWhileBooting(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer target.Close()
+
+	api := eos.New(target.URL)
+	api.SetSigner(eos.NewKeyBag())
+	b := &BIOS{
+		Log:          NewLogger(),
+		Network:      &Network{},
+		TargetNetAPI: api,
+		SingleOnly:   true,
+	}
+	health := httptest.NewServer(b.healthHandler())
+	defer health.Close()
+
+	// The boot sequence sets the genesis and wraps the signer while
+	// `/readyz` is polled, which `go test -race` checks.
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		b.SetGenesis(&GenesisJSON{})
+		for i := 0; i < 20; i++ {
+			b.setTargetSigner(&timedSigner{Signer: b.TargetNetAPI.Signer, metrics: NewInjectionMetrics()})
+		}
+	}()
+
+	for i := 0; i < 20; i++ {
+		resp, err := http.Get(health.URL + "/readyz")
+		if !assert.NoError(t, err) {
+			break
+		}
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	wg.Wait()
+}
+
+func TestValidationMarksProgress(t *testing.T) {
+	act := system.NewNewAccount(AN("eosio"), AN("holder1"), wellKnownPubkey)
+	packed, err := eos.NewSignedTransaction(&eos.Transaction{Actions: []*eos.Action{act}}).Pack(eos.CompressionNone)
+	if !assert.NoError(t, err) {
+		return
+	}
+	trx, err := json.Marshal(packed)
+	assert.NoError(t, err)
+
+	var progress []string
+	var b *BIOS
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/get_info") {
+			fmt.Fprint(w, `{"head_block_num": 2}`)
+			return
+		}
+		_, what := b.lastProgress()
+		progress = append(progress, what)
+
+		var params struct {
+			BlockNumOrID uint32 `json:"block_num_or_id"`
+		}
+		json.NewDecoder(r.Body).Decode(&params)
+		if params.BlockNumOrID == 1 {
+			fmt.Fprint(w, `{"transactions": []}`)
+			return
+		}
+		fmt.Fprintf(w, `{"transactions": [{"trx": [1, %s]}]}`, trx)
+	}))
+	defer target.Close()
+
+	b = &BIOS{Log: NewLogger(), TargetNetAPI: eos.New(target.URL)}
+	key, err := actionKey(act)
+	assert.NoError(t, err)
+	assert.NoError(t, b.validateTargetNetwork(ActionMap{key: act}, []*eos.Action{act}))
+
+	_, what := b.lastProgress()
+	assert.Equal(t, []string{"", "validating block 1"}, progress)
+	assert.Equal(t, "validating block 2", what)
+}
@@ -0,0 +1,462 @@
+package bios
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+	"github.com/ryanuber/columnize"
+)
+
+// HeartbeatConfig is the boot sequence's `heartbeat` section: while
+// waiting for the launch, every participant's eos-bios publishes a
+// signed heartbeat with its status to the coordination endpoint at
+// `url` (see `eos-bios heartbeat-server`) every `interval` (1m by
+// default), and the boot node shows who's ready before booting.
+type HeartbeatConfig struct {
+	URL      string `json:"url"`
+	Interval string `json:"interval"`
+
+	interval time.Duration
+}
+
+const defaultHeartbeatInterval = time.Minute
+
+func (c *HeartbeatConfig) validate() error {
+	if c == nil {
+		return nil
+	}
+	if !strings.HasPrefix(c.URL, "http://") && !strings.HasPrefix(c.URL, "https://") {
+		return fmt.Errorf("heartbeat: url must be an http(s) URL, got %q", c.URL)
+	}
+	c.interval = defaultHeartbeatInterval
+	if c.Interval != "" {
+		interval, err := time.ParseDuration(c.Interval)
+		if err != nil {
+			return fmt.Errorf("heartbeat: interval: %s", err)
+		}
+		c.interval = interval
+	}
+	return nil
+}
+
+// Statuses of a heartbeat: a participant is waiting from the start of
+// orchestration, until the producers are shuffled and its node
+// prepared for its role (by the `init` hook). It's then ready, or
+// failed.
+const (
+	HeartbeatWaiting = "waiting"
+	HeartbeatReady   = "ready"
+	HeartbeatFailed  = "failed"
+)
+
+// heartbeatMaxSkew is how far ahead of our clock a heartbeat can be
+// sent, for clocks a bit off.
+var heartbeatMaxSkew = time.Minute
+
+// heartbeatState is the status our heartbeats publish.
+type heartbeatState struct {
+	lock    sync.Mutex
+	status  string
+	changed chan struct{}
+}
+
+// setHeartbeatStatus changes the status of our heartbeats, sending one
+// right away.
+func (b *BIOS) setHeartbeatStatus(status string) {
+	b.heartbeat.lock.Lock()
+	defer b.heartbeat.lock.Unlock()
+
+	b.heartbeat.status = status
+	select {
+	case b.heartbeat.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (b *BIOS) heartbeatStatus() string {
+	b.heartbeat.lock.Lock()
+	defer b.heartbeat.lock.Unlock()
+
+	if b.heartbeat.status == "" {
+		return HeartbeatWaiting
+	}
+	return b.heartbeat.status
+}
+
+// Heartbeat is a participant's signed statement of readiness, for the
+// boot sequence of `BootSequenceHash`. It's signed with a key of its
+// seed network account's `active` permission.
+type Heartbeat struct {
+	Account          eos.AccountName `json:"account"`
+	Status           string          `json:"status"`
+	BootSequenceHash string          `json:"boot_sequence_hash"`
+	SentAt           time.Time       `json:"sent_at"`
+	Signature        string          `json:"signature"`
+}
+
+// Hash covers all of the heartbeat but its signature.
+func (h *Heartbeat) Hash() []byte {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s\n%s\n%s\n%s", h.Account, h.Status, h.BootSequenceHash, h.SentAt.UTC().Format(time.RFC3339))))
+	return hash[:]
+}
+
+// newHeartbeat signs a heartbeat of our current status with the first
+// of SeedNetKeys in our seed network account's active permission.
+func (b *BIOS) newHeartbeat() (*Heartbeat, error) {
+	if len(b.SeedNetKeys) == 0 {
+		return nil, fmt.Errorf("no seed network keys to sign with")
+	}
+
+	account := b.Network.MyPeer.Discovery.SeedNetworkAccountName
+	activeKeys, err := b.Network.ActivePublicKeys(account)
+	if err != nil {
+		return nil, err
+	}
+
+	heartbeat := &Heartbeat{
+		Account:          account,
+		Status:           b.heartbeatStatus(),
+		BootSequenceHash: b.BootSequenceHash,
+		SentAt:           time.Now().UTC().Truncate(time.Second),
+	}
+	sig, err := signWithActiveKey(b.SeedNetKeys, activeKeys, heartbeat.Hash())
+	if err != nil {
+		return nil, err
+	}
+	heartbeat.Signature = sig.String()
+
+	return heartbeat, nil
+}
+
+// sendHeartbeats publishes our heartbeat every interval, until `done`
+// is closed. Failures are logged, never fatal.
+func (b *BIOS) sendHeartbeats(done <-chan struct{}) {
+	config := b.Heartbeat
+	if config == nil {
+		return
+	}
+
+	b.heartbeat.lock.Lock()
+	b.heartbeat.changed = make(chan struct{}, 1)
+	changed := b.heartbeat.changed
+	b.heartbeat.lock.Unlock()
+
+	for {
+		b.sendHeartbeat(config.URL)
+
+		select {
+		case <-done:
+			// A last one, when the status just changed.
+			select {
+			case <-changed:
+				b.sendHeartbeat(config.URL)
+			default:
+			}
+			return
+		case <-changed:
+		case <-time.After(config.interval):
+		}
+	}
+}
+
+func (b *BIOS) sendHeartbeat(url string) {
+	heartbeat, err := b.newHeartbeat()
+	if err == nil {
+		err = postHeartbeat(url, heartbeat)
+	}
+	if err != nil {
+		b.Log.Println("WARNING: sending heartbeat:", err)
+	}
+}
+
+var heartbeatClient = &http.Client{Timeout: 10 * time.Second}
+
+func postHeartbeat(url string, heartbeat *Heartbeat) error {
+	return postJSON(strings.TrimRight(url, "/")+"/heartbeats", heartbeat)
+}
+
+func postJSON(url string, v interface{}) error {
+	cnt, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	resp, err := heartbeatClient.Post(url, "application/json", bytes.NewReader(cnt))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+func fetchHeartbeats(url string) (out []*Heartbeat, err error) {
+	resp, err := heartbeatClient.Get(strings.TrimRight(url, "/") + "/heartbeats")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching heartbeats: %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decoding heartbeats: %s", err)
+	}
+	return
+}
+
+// HeartbeatServer is the coordination endpoint participants publish
+// their heartbeats to: it keeps the latest of each account, and lists
+// them on `GET /heartbeats`. It refuses heartbeats sent in the future,
+// which would shadow the real ones, and with a seed network to check
+// them against, those not signed by their account. Their readers
+// verify them all the same. It also relays the probe exclusions (see
+// PublishedExclusion) on `/exclusion`.
+type HeartbeatServer struct {
+	lock       sync.Mutex
+	heartbeats map[eos.AccountName]*Heartbeat
+	exclusions map[eos.AccountName]*PublishedExclusion
+	verify     func(account eos.AccountName, hash []byte, signature string) error
+}
+
+// NewHeartbeatServer returns a server verifying signatures against the
+// seed network at `seedNetAPI`, unless it's nil.
+func NewHeartbeatServer(seedNetAPI *eos.API) *HeartbeatServer {
+	s := &HeartbeatServer{
+		heartbeats: map[eos.AccountName]*Heartbeat{},
+		exclusions: map[eos.AccountName]*PublishedExclusion{},
+	}
+	if seedNetAPI != nil {
+		s.verify = func(account eos.AccountName, hash []byte, signature string) error {
+			_, err := verifyActiveSignature(seedNetAPI, account, hex.EncodeToString(hash), signature)
+			return err
+		}
+	}
+	return s
+}
+
+func (s *HeartbeatServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/heartbeats":
+	case "/exclusion":
+		s.serveExclusion(w, r)
+		return
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.lock.Lock()
+		var out []*Heartbeat
+		for _, heartbeat := range s.heartbeats {
+			out = append(out, heartbeat)
+		}
+		s.lock.Unlock()
+		sort.Slice(out, func(i, j int) bool { return out[i].Account < out[j].Account })
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(out)
+
+	case http.MethodPost:
+		var heartbeat *Heartbeat
+		if err := json.NewDecoder(io.LimitReader(r.Body, 64*1024)).Decode(&heartbeat); err != nil || heartbeat == nil || heartbeat.Account == "" {
+			http.Error(w, "invalid heartbeat", http.StatusBadRequest)
+			return
+		}
+		if heartbeat.SentAt.After(time.Now().Add(heartbeatMaxSkew)) {
+			http.Error(w, "heartbeat sent in the future", http.StatusBadRequest)
+			return
+		}
+		if s.verify != nil {
+			if err := s.verify(heartbeat.Account, heartbeat.Hash(), heartbeat.Signature); err != nil {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+		}
+
+		s.lock.Lock()
+		if previous := s.heartbeats[heartbeat.Account]; previous == nil || !heartbeat.SentAt.Before(previous.SentAt) {
+			s.heartbeats[heartbeat.Account] = heartbeat
+		}
+		s.lock.Unlock()
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Readiness is where a producer of the launch stands, from its latest
+// heartbeat.
+type Readiness struct {
+	Account  eos.AccountName
+	Ready    bool
+	LastSeen time.Time
+	Problem  string
+}
+
+// heartbeatReadiness checks the latest heartbeat of each of `accounts`
+// with `verify`, and against our boot sequence: heartbeats older than
+// `staleAfter`, or sent in the future, don't count.
+func heartbeatReadiness(accounts []eos.AccountName, heartbeats []*Heartbeat, bootSequenceHash string, staleAfter time.Duration, verify func(*Heartbeat) error) (out []*Readiness) {
+	latest := map[eos.AccountName]*Heartbeat{}
+	for _, heartbeat := range heartbeats {
+		if previous := latest[heartbeat.Account]; previous == nil || heartbeat.SentAt.After(previous.SentAt) {
+			latest[heartbeat.Account] = heartbeat
+		}
+	}
+
+	for _, account := range accounts {
+		readiness := &Readiness{Account: account}
+		out = append(out, readiness)
+
+		heartbeat := latest[account]
+		if heartbeat == nil {
+			readiness.Problem = "no heartbeat"
+			continue
+		}
+		readiness.LastSeen = heartbeat.SentAt
+
+		switch {
+		case heartbeat.SentAt.After(time.Now().Add(heartbeatMaxSkew)):
+			readiness.Problem = fmt.Sprintf("sent in the future (%s)", heartbeat.SentAt.UTC().Format(time.RFC3339))
+		case time.Since(heartbeat.SentAt) > staleAfter:
+			readiness.Problem = "stale"
+		case heartbeat.Status != HeartbeatReady:
+			readiness.Problem = fmt.Sprintf("status %q", heartbeat.Status)
+		case heartbeat.BootSequenceHash != bootSequenceHash:
+			readiness.Problem = fmt.Sprintf("on another boot sequence (%s)", heartbeat.BootSequenceHash)
+		default:
+			if err := verify(heartbeat); err != nil {
+				readiness.Problem = err.Error()
+				continue
+			}
+			readiness.Ready = true
+		}
+	}
+
+	return
+}
+
+func (net *Network) verifyHeartbeat(heartbeat *Heartbeat) error {
+	return net.verifyAccountSignature(heartbeat.Account, heartbeat.Hash(), heartbeat.Signature)
+}
+
+// verifyAccountSignature checks `signature` of `hash` is by a key of
+// `account`'s active permission on the seed network.
+func (net *Network) verifyAccountSignature(account eos.AccountName, hash []byte, signature string) error {
+	sig, err := ecc.NewSignature(signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %s", err)
+	}
+
+	pubKey, err := sig.PublicKey(hash)
+	if err != nil {
+		return fmt.Errorf("recovering public key: %s", err)
+	}
+
+	activeKeys, err := net.ActivePublicKeys(account)
+	if err != nil {
+		return err
+	}
+	for _, key := range activeKeys {
+		if key.String() == pubKey.String() {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("signed by a key not in the account's active permission")
+}
+
+// ReadinessBoard fetches the heartbeats of the producers of the launch,
+// besides us.
+func (b *BIOS) ReadinessBoard() ([]*Readiness, error) {
+	if b.Heartbeat == nil {
+		return nil, nil
+	}
+
+	heartbeats, err := fetchHeartbeats(b.Heartbeat.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	myAccount := b.Network.MyPeer.Discovery.SeedNetworkAccountName
+	var accounts []eos.AccountName
+	seen := map[eos.AccountName]bool{}
+	for _, peer := range b.ShuffledProducers {
+		account := peer.Discovery.SeedNetworkAccountName
+		if account != myAccount && !seen[account] {
+			seen[account] = true
+			accounts = append(accounts, account)
+		}
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i] < accounts[j] })
+
+	return heartbeatReadiness(accounts, heartbeats, b.BootSequenceHash, 3*b.Heartbeat.interval, b.Network.verifyHeartbeat), nil
+}
+
+// PrintReadinessBoard prints who's ready for the launch.
+func PrintReadinessBoard(w io.Writer, board []*Readiness) {
+	ready := 0
+	columns := []string{
+		"Seed Account | Ready | Last heartbeat | Problem",
+		"------------ | ----- | -------------- | -------",
+	}
+	for _, readiness := range board {
+		lastSeen := "-"
+		switch since := time.Since(readiness.LastSeen); {
+		case readiness.LastSeen.IsZero():
+		case since < 0:
+			lastSeen = "in the future"
+		default:
+			lastSeen = fmt.Sprintf("%s ago", since.Round(time.Second))
+		}
+		problem := readiness.Problem
+		if problem == "" {
+			problem = "-"
+		}
+		if readiness.Ready {
+			ready++
+		}
+		columns = append(columns, fmt.Sprintf("%s | %t | %s | %s", readiness.Account, readiness.Ready, lastSeen, problem))
+	}
+	fmt.Fprintln(w, columnize.SimpleFormat(columns))
+	fmt.Fprintf(w, "\n%d of %d producers ready\n", ready, len(board))
+}
+
+// showReadinessBoard logs the readiness board, when the boot sequence
+// has a `heartbeat` section.
+func (b *BIOS) showReadinessBoard() {
+	if b.Heartbeat == nil {
+		return
+	}
+
+	board, err := b.ReadinessBoard()
+	if err != nil {
+		b.Log.Println("WARNING: readiness board:", err)
+		return
+	}
+
+	var out bytes.Buffer
+	PrintReadinessBoard(&out, board)
+	b.Log.Println("Readiness board:")
+	b.Log.Println(out.String())
+}
@@ -0,0 +1,125 @@
+package bios
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeartbeatServer(t *testing.T) {
+	server := httptest.NewServer(NewHeartbeatServer(nil))
+	defer server.Close()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	assert.NoError(t, postHeartbeat(server.URL, &Heartbeat{Account: AN("bob"), Status: HeartbeatReady, SentAt: now}))
+	assert.NoError(t, postHeartbeat(server.URL+"/", &Heartbeat{Account: AN("alice"), Status: HeartbeatReady, SentAt: now}))
+	assert.NoError(t, postHeartbeat(server.URL, &Heartbeat{Account: AN("alice"), Status: "late", SentAt: now.Add(-time.Minute)}))
+	assert.Error(t, postHeartbeat(server.URL, &Heartbeat{}))
+
+	// A forged heartbeat from the future doesn't shadow bob's.
+	assert.EqualError(t, postHeartbeat(server.URL, &Heartbeat{Account: AN("bob"), Status: "late", SentAt: now.AddDate(80, 0, 0)}), "400 Bad Request: heartbeat sent in the future")
+
+	heartbeats, err := fetchHeartbeats(server.URL)
+	assert.NoError(t, err)
+	if assert.Len(t, heartbeats, 2) {
+		assert.Equal(t, AN("alice"), heartbeats[0].Account)
+		assert.Equal(t, HeartbeatReady, heartbeats[0].Status)
+		assert.Equal(t, now, heartbeats[0].SentAt.UTC())
+		assert.Equal(t, HeartbeatReady, heartbeats[1].Status)
+	}
+}
+
+func TestHeartbeatServerVerify(t *testing.T) {
+	heartbeatServer := NewHeartbeatServer(nil)
+	heartbeatServer.verify = func(account eos.AccountName, hash []byte, signature string) error {
+		if signature != "good" {
+			return fmt.Errorf("signed by a key not in the account's active permission")
+		}
+		return nil
+	}
+	server := httptest.NewServer(heartbeatServer)
+	defer server.Close()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	assert.NoError(t, postHeartbeat(server.URL, &Heartbeat{Account: AN("alice"), Status: HeartbeatReady, SentAt: now, Signature: "good"}))
+	assert.EqualError(t, postHeartbeat(server.URL, &Heartbeat{Account: AN("alice"), Status: HeartbeatFailed, SentAt: now.Add(time.Second), Signature: "forged"}), "403 Forbidden: signed by a key not in the account's active permission")
+
+	heartbeats, err := fetchHeartbeats(server.URL)
+	assert.NoError(t, err)
+	if assert.Len(t, heartbeats, 1) {
+		assert.Equal(t, HeartbeatReady, heartbeats[0].Status)
+	}
+}
+
+func TestHeartbeatStatus(t *testing.T) {
+	received := make(chan *Heartbeat, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var heartbeat *Heartbeat
+		_ = json.NewDecoder(r.Body).Decode(&heartbeat)
+		received <- heartbeat
+	}))
+	defer server.Close()
+
+	b := &BIOS{Log: NewLogger(), Heartbeat: &HeartbeatConfig{URL: server.URL, Interval: "1h"}}
+	assert.NoError(t, b.Heartbeat.validate())
+	assert.Equal(t, HeartbeatWaiting, b.heartbeatStatus())
+
+	// Without keys, nothing is signed nor sent.
+	done := make(chan struct{})
+	go b.sendHeartbeats(done)
+	b.setHeartbeatStatus(HeartbeatReady)
+	close(done)
+	assert.Equal(t, HeartbeatReady, b.heartbeatStatus())
+	assert.Len(t, received, 0)
+}
+
+func TestHeartbeatReadiness(t *testing.T) {
+	now := time.Now().UTC()
+	heartbeat := func(account, status, bootSeqHash string, sentAt time.Time) *Heartbeat {
+		return &Heartbeat{Account: AN(account), Status: status, BootSequenceHash: bootSeqHash, SentAt: sentAt}
+	}
+	heartbeats := []*Heartbeat{
+		heartbeat("alice", HeartbeatReady, "abcd", now.Add(-time.Hour)),
+		heartbeat("alice", HeartbeatReady, "abcd", now),
+		heartbeat("bob", HeartbeatReady, "abcd", now.Add(-10*time.Minute)),
+		heartbeat("carol", HeartbeatReady, "beef", now),
+		heartbeat("dave", HeartbeatReady, "abcd", now),
+		heartbeat("frank", HeartbeatWaiting, "abcd", now),
+		heartbeat("mallory", HeartbeatReady, "abcd", now.AddDate(80, 0, 0)),
+	}
+	verify := func(heartbeat *Heartbeat) error {
+		if heartbeat.Account == AN("dave") {
+			return fmt.Errorf("signed by a key not in the account's active permission")
+		}
+		return nil
+	}
+
+	board := heartbeatReadiness([]eos.AccountName{AN("alice"), AN("bob"), AN("carol"), AN("dave"), AN("eve"), AN("frank"), AN("mallory")}, heartbeats, "abcd", 3*time.Minute, verify)
+	if !assert.Len(t, board, 7) {
+		return
+	}
+	assert.True(t, board[0].Ready)
+	assert.Equal(t, "stale", board[1].Problem)
+	assert.Equal(t, "on another boot sequence (beef)", board[2].Problem)
+	assert.Equal(t, "signed by a key not in the account's active permission", board[3].Problem)
+	assert.Equal(t, "no heartbeat", board[4].Problem)
+	assert.Equal(t, `status "waiting"`, board[5].Problem)
+	assert.Contains(t, board[6].Problem, "sent in the future")
+
+	var out bytes.Buffer
+	PrintReadinessBoard(&out, board)
+	assert.Contains(t, out.String(), "eve           false  -               no heartbeat")
+	assert.Contains(t, out.String(), "1 of 7 producers ready")
+
+	assert.Error(t, (&HeartbeatConfig{URL: "ipfs://topic"}).validate())
+	config := &HeartbeatConfig{URL: "https://heartbeat.example.com"}
+	assert.NoError(t, config.validate())
+	assert.Equal(t, defaultHeartbeatInterval, config.interval)
+}
@@ -6,13 +6,19 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"time"
 )
 
 func (b *BIOS) DispatchInit(operation string) error {
 	return b.dispatch("init", []string{
 		operation, // "join", "orchestrate", "boot"
-	}, nil)
+	}, map[string]string{
+		"EOS_BIOS_OPERATION": operation,
+	})
 }
 
 func (b *BIOS) DispatchBootPublishGenesis(genesisJSON string) error {
@@ -21,7 +27,20 @@ func (b *BIOS) DispatchBootPublishGenesis(genesisJSON string) error {
 	return b.dispatch("boot_publish_genesis", []string{
 		encodedGenesis,
 		genesisJSON,
-	}, nil)
+	}, map[string]string{
+		"EOS_BIOS_GENESIS_BASE64": encodedGenesis,
+		"EOS_BIOS_GENESIS_JSON":   genesisJSON,
+	})
+}
+
+func (b *BIOS) DispatchBootPublishKickstart(chunks []string) error {
+	return b.dispatch("boot_publish_kickstart", append([]string{
+		fmt.Sprintf("%d", len(chunks)),
+		"kickstart.chunks",
+	}, chunks...), map[string]string{
+		"EOS_BIOS_KICKSTART_CHUNKS":      fmt.Sprintf("%d", len(chunks)),
+		"EOS_BIOS_KICKSTART_CHUNKS_FILE": "kickstart.chunks",
+	})
 }
 
 func (b *BIOS) DispatchBootNode(genesisJSON, publicKey, privateKey string, otherPeers []string) error {
@@ -31,7 +50,12 @@ func (b *BIOS) DispatchBootNode(genesisJSON, publicKey, privateKey string, other
 		privateKey,
 		"# p2p-peer-address = " + strings.Join(otherPeers, "\n# p2p-peer-address = "),
 		strings.Join(otherPeers, ","),
-	}, nil)
+	}, map[string]string{
+		"EOS_BIOS_GENESIS_JSON": genesisJSON,
+		"EOS_BIOS_PUBLIC_KEY":   publicKey,
+		"EOS_BIOS_PRIVATE_KEY":  privateKey,
+		"EOS_BIOS_P2P_PEERS":    strings.Join(otherPeers, ","),
+	})
 }
 
 func (b *BIOS) DispatchJoinNetwork(genesis *GenesisJSON, peerDefs []*Peer, otherPeers []string) error {
@@ -51,7 +75,12 @@ func (b *BIOS) DispatchJoinNetwork(genesis *GenesisJSON, peerDefs []*Peer, other
 		strings.Join(otherPeers, ","),
 		"producer-name = " + strings.Join(names, "\nproducer-name = "),
 		strings.Join(names, ","),
-	}, nil)
+	}, map[string]string{
+		"EOS_BIOS_GENESIS_JSON":   string(cnt),
+		"EOS_BIOS_PUBLIC_KEY":     genesis.InitialKey,
+		"EOS_BIOS_P2P_PEERS":      strings.Join(otherPeers, ","),
+		"EOS_BIOS_PRODUCER_NAMES": strings.Join(names, ","),
+	})
 }
 
 func (b *BIOS) DispatchBootMesh() error {
@@ -61,36 +90,63 @@ func (b *BIOS) DispatchBootMesh() error {
 func (b *BIOS) DispatchDone(operation string) error {
 	return b.dispatch("done", []string{
 		operation, // "join", "orchestrate", "boot"
-	}, nil)
+	}, map[string]string{
+		"EOS_BIOS_OPERATION": operation,
+	})
 }
 
-// dispatch to both exec calls, and remote web hooks.
-func (b *BIOS) dispatch(hookName string, args []string, f func() error) error {
+// dispatch to both exec calls, and remote web hooks. The hook gets
+// `args` as arguments, and `env` (plus EOS_BIOS_HOOK, its name) in its
+// environment.
+func (b *BIOS) dispatch(hookName string, args []string, env map[string]string) error {
 	b.Log.Printf("---- BEGIN HOOK %q ----\n", hookName)
 
-	// check if `hook_[hookName]` exists or `hook_[hookName].sh` exists, and use that as a command,
-	// otherwise, print that the hook is not present.
-	filePaths := []string{
-		fmt.Sprintf("./hook_%s", hookName),
-		fmt.Sprintf("./hook_%s.sh", hookName),
-	}
-	var executable string
-	for _, fl := range filePaths {
-		if _, err := os.Stat(fl); err == nil {
-			executable = fl
+	if url, ok := b.Webhooks[hookName]; ok {
+		b.Log.Printf("  Posting to webhook: %s\n", url)
+		if err := postWebhook(url, hookName, args, env); err != nil {
+			return fmt.Errorf("webhook: %s", err)
 		}
 	}
 
-	if executable == "" {
-		b.Log.Printf("  - Hook not found (searched %q)\n", filePaths)
-		return nil
+	var cmd *exec.Cmd
+	if command, ok := b.Hooks[hookName]; ok {
+		// Configured in the local config
+		cmd = shellHookCommand(command, args)
+	} else {
+		// check if `hook_[hookName]` exists, or one of its scripting
+		// variants, and use that as a command, otherwise, print that
+		// the hook is not present.
+		var filePaths []string
+		for _, ext := range hookExtensions() {
+			filePaths = append(filePaths, fmt.Sprintf("./hook_%s%s", hookName, ext))
+		}
+		var executable string
+		for _, fl := range filePaths {
+			if _, err := os.Stat(fl); err == nil {
+				executable = fl
+			}
+		}
+
+		if executable == "" {
+			b.Log.Printf("  - Hook not found (searched %q)\n", filePaths)
+			return nil
+		}
+
+		cmd = hookCommand(executable, args)
 	}
 
-	cmd := exec.Command(executable, args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
-	cmd.Env = os.Environ()
+	cmd.Env = append(os.Environ(), "EOS_BIOS_HOOK="+hookName)
+	var keys []string
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		cmd.Env = append(cmd.Env, key+"="+env[key])
+	}
 
 	fmt.Printf("  Executing hook: %q\n", cmd.Args)
 
@@ -103,3 +159,112 @@ func (b *BIOS) dispatch(hookName string, args []string, f func() error) error {
 
 	return nil
 }
+
+// HookNames lists the hooks dispatched during a launch.
+var HookNames = []string{"init", "boot_publish_genesis", "boot_publish_kickstart", "boot_node", "join_network", "boot_mesh", "step_deadline", "done"}
+
+// hookAliases are other names of hooks, accepted in local configs.
+var hookAliases = map[string]string{
+	"publish_kickstart": "boot_publish_kickstart",
+	"connect_to_bios":   "join_network",
+}
+
+// ParseHooks validates the `hooks` of a local config, mapping hook
+// names (see HookNames and their aliases) to shell commands, and
+// returns the commands by hook name.
+func ParseHooks(config map[string]string) (map[string]string, error) {
+	return parseHooksConfig(config, func(command string) error {
+		if strings.TrimSpace(command) == "" {
+			return fmt.Errorf("empty command")
+		}
+		return nil
+	})
+}
+
+func parseHooksConfig(config map[string]string, check func(value string) error) (map[string]string, error) {
+	hooks := map[string]string{}
+	for name, value := range config {
+		hookName := name
+		if alias, ok := hookAliases[name]; ok {
+			hookName = alias
+		}
+
+		known := false
+		for _, candidate := range HookNames {
+			known = known || candidate == hookName
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown hook %q, use one of: %s", name, strings.Join(HookNames, ", "))
+		}
+		if _, ok := hooks[hookName]; ok {
+			return nil, fmt.Errorf("hook %q configured twice", hookName)
+		}
+		if err := check(value); err != nil {
+			return nil, fmt.Errorf("hook %q: %s", name, err)
+		}
+
+		hooks[hookName] = value
+	}
+	return hooks, nil
+}
+
+// shellHookCommand runs a hook command of the local config through the
+// shell, `args` being its positional parameters ($1, $2, ...). On
+// Windows, cmd.exe runs it, with the args only in the environment.
+func shellHookCommand(command string, args []string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd.exe", "/C", command)
+	}
+	return exec.Command("sh", append([]string{"-c", command, "sh"}, args...)...)
+}
+
+// hookExtensions lists the hook file variants, by increasing
+// precedence. Windows can't exec shell scripts directly, so it also
+// looks for PowerShell and batch hooks.
+func hookExtensions() []string {
+	if runtime.GOOS == "windows" {
+		return []string{"", ".sh", ".cmd", ".bat", ".ps1", ".exe"}
+	}
+	return []string{"", ".sh"}
+}
+
+// hookCommand runs the hook with the interpreter its extension calls
+// for, where the OS won't figure it out by itself.
+func hookCommand(executable string, args []string) *exec.Cmd {
+	if runtime.GOOS != "windows" {
+		return exec.Command(executable, args...)
+	}
+
+	executable = filepath.FromSlash(executable)
+	switch strings.ToLower(filepath.Ext(executable)) {
+	case ".ps1":
+		return exec.Command("powershell.exe", append([]string{"-NoProfile", "-ExecutionPolicy", "Bypass", "-File", executable}, args...)...)
+	case ".cmd", ".bat":
+		return exec.Command("cmd.exe", append([]string{"/C", executable}, args...)...)
+	case ".exe":
+		return exec.Command(executable, args...)
+	}
+	// `hook_name` or `hook_name.sh`, through Git Bash, MSYS or WSL's bash.
+	return exec.Command("bash", append([]string{filepath.ToSlash(executable)}, args...)...)
+}
+
+// DispatchStepDeadline alerts that a boot sequence step is running
+// past its time budget. `level` escalates from "warning" to
+// "critical" to "emergency" as the overrun grows.
+func (b *BIOS) DispatchStepDeadline(level, label, op string, elapsed, budget time.Duration, fallback string) error {
+	return b.dispatch("step_deadline", []string{
+		level,
+		label,
+		op,
+		elapsed.String(),
+		budget.String(),
+		fallback, // "", "retry", "skip" or "abort"
+	}, map[string]string{
+		"EOS_BIOS_DEADLINE_LEVEL":    level,
+		"EOS_BIOS_STEP_LABEL":        label,
+		"EOS_BIOS_STEP_OP":           op,
+		"EOS_BIOS_STEP_ELAPSED":      elapsed.String(),
+		"EOS_BIOS_STEP_BUDGET":       budget.String(),
+		"EOS_BIOS_DEADLINE_FALLBACK": fallback,
+	})
+}
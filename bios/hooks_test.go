@@ -0,0 +1,98 @@
+package bios
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/eoscanada/eos-bios/bios/disco"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHooks(t *testing.T) {
+	hooks, err := ParseHooks(map[string]string{
+		"init":              "echo init",
+		"publish_kickstart": "echo kickstart",
+		"connect_to_bios":   "echo join",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"init":                   "echo init",
+		"boot_publish_kickstart": "echo kickstart",
+		"join_network":           "echo join",
+	}, hooks)
+
+	_, err = ParseHooks(map[string]string{"practice_done": "echo"})
+	assert.Error(t, err)
+
+	_, err = ParseHooks(map[string]string{"join_network": "echo a", "connect_to_bios": "echo b"})
+	assert.EqualError(t, err, `hook "join_network" configured twice`)
+
+	_, err = ParseHooks(map[string]string{"done": " "})
+	assert.EqualError(t, err, `hook "done": empty command`)
+}
+
+func TestDispatchConfiguredHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell hooks run through cmd.exe on Windows")
+	}
+
+	dir, err := ioutil.TempDir("", "eos-bios-hooks")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	out := filepath.Join(dir, "out")
+	b := &BIOS{
+		Log:   NewLogger(),
+		Hooks: map[string]string{"init": `echo "$EOS_BIOS_HOOK $1 $EOS_BIOS_OPERATION" > ` + out},
+	}
+
+	assert.NoError(t, b.DispatchInit("join"))
+	cnt, err := ioutil.ReadFile(out)
+	assert.NoError(t, err)
+	assert.Equal(t, "init join join\n", string(cnt))
+
+	b.Hooks["done"] = "exit 3"
+	assert.Error(t, b.DispatchDone("join"))
+}
+
+func TestDispatchWebhook(t *testing.T) {
+	var payload WebhookPayload
+	var auth string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	webhooks, err := ParseWebhooks(map[string]string{"connect_to_bios": server.URL + "/join"})
+	assert.NoError(t, err)
+
+	os.Setenv(WebhookTokenEnv, "secret")
+	defer os.Unsetenv(WebhookTokenEnv)
+
+	b := &BIOS{Log: NewLogger(), Webhooks: webhooks}
+	genesis := &GenesisJSON{InitialKey: "EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV"}
+	peers := []*Peer{{Discovery: &disco.Discovery{TargetAccountName: "eosio"}}}
+	assert.NoError(t, b.DispatchJoinNetwork(genesis, peers, []string{"1.2.3.4:9876"}))
+
+	assert.Equal(t, "Bearer secret", auth)
+	assert.Equal(t, "join_network", payload.Hook)
+	assert.Len(t, payload.Args, 5)
+	assert.Equal(t, "1.2.3.4:9876", payload.Env["EOS_BIOS_P2P_PEERS"])
+	assert.Equal(t, "eosio", payload.Env["EOS_BIOS_PRODUCER_NAMES"])
+	assert.Equal(t, genesis.InitialKey, payload.Env["EOS_BIOS_PUBLIC_KEY"])
+
+	status = http.StatusInternalServerError
+	assert.Error(t, b.DispatchJoinNetwork(genesis, peers, nil))
+
+	_, err = ParseWebhooks(map[string]string{"done": "ftp://example.com"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,260 @@
+package bios
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+)
+
+// ConcurrentOperation is implemented by operations whose transactions
+// don't depend on one another within the step, so they can be pushed
+// concurrently (see BIOS.InjectionConcurrency).
+type ConcurrentOperation interface {
+	ConcurrentChunks() bool
+}
+
+// concurrentChunks returns whether the chunks of `step` go through an
+// injection pool.
+func (b *BIOS) concurrentChunks(step *OperationType) bool {
+	if b.InjectionConcurrency <= 1 {
+		return false
+	}
+	op, ok := step.Data.(ConcurrentOperation)
+	return ok && op.ConcurrentChunks()
+}
+
+// busyErrors are the push errors of a node that can't keep up, or of
+// blocks filled up: waiting a bit before the next push helps.
+var busyErrors = []string{
+	"busy",
+	"exhausted",
+	"too many requests",
+	"service unavailable",
+	"deadline_exception",
+	"block_cpu_usage_exceeded",
+	"block_net_usage_exceeded",
+	"too much for the remaining allowable usage of the current block",
+}
+
+func isBusyError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, busy := range busyErrors {
+		if strings.Contains(msg, busy) {
+			return true
+		}
+	}
+	return false
+}
+
+// Backoff applied to all pushes when the node reports it's busy,
+// doubled at each busy error in a row.
+var (
+	injectionMinBackoff = 500 * time.Millisecond
+	injectionMaxBackoff = 10 * time.Second
+)
+
+// injectionLimiter paces pushes to a maximum rate of transactions per
+// second (none when 0). When the node reports it's busy, all pushes
+// pause for a while and the rate is halved, then recovers with each
+// successful push.
+type injectionLimiter struct {
+	lock        sync.Mutex
+	maxTPS      float64
+	rate        float64
+	next        time.Time
+	backoff     time.Duration
+	pausedUntil time.Time
+}
+
+func newInjectionLimiter(maxTPS float64) *injectionLimiter {
+	return &injectionLimiter{maxTPS: maxTPS, rate: maxTPS}
+}
+
+// wait blocks until the next push is allowed.
+func (l *injectionLimiter) wait() {
+	if l == nil {
+		return
+	}
+
+	l.lock.Lock()
+	at := time.Now()
+	if l.pausedUntil.After(at) {
+		at = l.pausedUntil
+	}
+	if l.rate > 0 {
+		if l.next.After(at) {
+			at = l.next
+		}
+		l.next = at.Add(time.Duration(float64(time.Second) / l.rate))
+	}
+	l.lock.Unlock()
+
+	time.Sleep(time.Until(at))
+}
+
+// busy slows pushes down, after the node reported it's busy.
+func (l *injectionLimiter) busy() {
+	if l == nil {
+		return
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.backoff == 0 {
+		l.backoff = injectionMinBackoff
+	} else if l.backoff *= 2; l.backoff > injectionMaxBackoff {
+		l.backoff = injectionMaxBackoff
+	}
+	l.pausedUntil = time.Now().Add(l.backoff)
+
+	if l.rate /= 2; l.maxTPS > 0 && l.rate < 1 {
+		l.rate = 1
+	}
+}
+
+// ok speeds pushes back up, after a successful one.
+func (l *injectionLimiter) ok() {
+	if l == nil {
+		return
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.backoff = 0
+	if l.rate += l.maxTPS / 10; l.rate > l.maxTPS {
+		l.rate = l.maxTPS
+	}
+}
+
+// injectionPool pushes the chunks of a step on concurrent workers. It
+// only moves the checkpoint past chunks with all the previous ones
+// pushed: a chunk that failed, or was skipped over the deadline, stays
+// a floor the checkpoint never goes past.
+type injectionPool struct {
+	b          *BIOS
+	stepIdx    int
+	checkpoint *bootCheckpoint
+
+	jobs     chan injectionJob
+	results  chan injectionJob
+	inFlight map[int]uint32 // chunk index, to head block before its dispatch
+	failed   map[int]uint32 // chunk index, to head block before its dispatch
+	position int            // last chunk dispatched, or skipped
+	err      error
+}
+
+type injectionJob struct {
+	idx   int
+	chunk []*eos.Action
+	err   error
+}
+
+func (b *BIOS) newInjectionPool(stepIdx int, step *OperationType, deadline *stepDeadline, checkpoint *bootCheckpoint) *injectionPool {
+	p := &injectionPool{
+		b:          b,
+		stepIdx:    stepIdx,
+		checkpoint: checkpoint,
+		jobs:       make(chan injectionJob),
+		results:    make(chan injectionJob, b.InjectionConcurrency),
+		inFlight:   map[int]uint32{},
+		failed:     map[int]uint32{},
+		position:   -1,
+	}
+
+	for i := 0; i < b.InjectionConcurrency; i++ {
+		go func() {
+			for job := range p.jobs {
+				job.err = b.pushChunk(step, job.idx, job.chunk, deadline)
+				p.results <- job
+			}
+		}()
+	}
+	return p
+}
+
+// push hands a chunk to the next free worker. It returns the first
+// error of the pushes done so far, after which no more chunks should
+// be pushed.
+func (p *injectionPool) push(idx int, chunk []*eos.Action) error {
+	for p.err == nil && len(p.inFlight) == p.b.InjectionConcurrency {
+		p.done(<-p.results)
+	}
+	if p.err != nil {
+		return p.err
+	}
+
+	// Chunks pushed from now on are after the head block read here.
+	if err := p.b.refreshCheckpoint(p.checkpoint, false); err != nil {
+		p.b.Log.Debugf("refreshing boot checkpoint: %s\n", err)
+	}
+	p.inFlight[idx] = p.checkpoint.head
+	p.position = idx
+	p.jobs <- injectionJob{idx: idx, chunk: chunk}
+	return nil
+}
+
+// skipped records a chunk found already applied.
+func (p *injectionPool) skipped(idx int) {
+	if p != nil {
+		p.position = idx
+	}
+}
+
+// done records a finished push.
+func (p *injectionPool) done(job injectionJob) {
+	head := p.inFlight[job.idx]
+	delete(p.inFlight, job.idx)
+	if job.err != nil {
+		if p.err == nil {
+			p.err = job.err
+		}
+		p.failed[job.idx] = head
+		return
+	}
+
+	p.b.Log.Printf(".")
+	p.b.status.chunkDone()
+
+	// The checkpoint moves right before the first chunk still in
+	// flight or failed, looked for from the head block read before it
+	// was dispatched, or to the last chunk dispatched when all are
+	// done.
+	chunk, blockNum := p.position, uint32(0)
+	for _, pending := range []map[int]uint32{p.inFlight, p.failed} {
+		for idx, head := range pending {
+			if idx <= chunk {
+				chunk, blockNum = idx-1, head
+			}
+		}
+	}
+	if len(p.inFlight) == 0 && len(p.failed) == 0 {
+		if err := p.b.refreshCheckpoint(p.checkpoint, false); err != nil {
+			p.b.Log.Debugf("refreshing boot checkpoint: %s\n", err)
+		}
+		blockNum = p.checkpoint.head
+	}
+
+	if !p.checkpoint.done(p.stepIdx, chunk) {
+		if err := p.checkpoint.moveTo(p.stepIdx, chunk, blockNum); err != nil {
+			p.b.Log.Printf("\nWARNING: writing boot checkpoint: %s\n", err)
+		}
+	}
+}
+
+// wait waits for the pushes in flight, stops the workers, and returns
+// the first error of the pushes.
+func (p *injectionPool) wait() error {
+	if p == nil {
+		return nil
+	}
+
+	for len(p.inFlight) != 0 {
+		p.done(<-p.results)
+	}
+	close(p.jobs)
+	return p.err
+}
@@ -0,0 +1,169 @@
+package bios
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsBusyError(t *testing.T) {
+	assert.True(t, isBusyError(errors.New("push actions: status 503: Service Unavailable")))
+	assert.True(t, isBusyError(errors.New("Transaction CPU usage is too much for the remaining allowable usage of the current block")))
+	assert.False(t, isBusyError(errors.New("account name already exists")))
+}
+
+func TestInjectionLimiter(t *testing.T) {
+	defer func(backoff time.Duration) { injectionMinBackoff = backoff }(injectionMinBackoff)
+	injectionMinBackoff = 20 * time.Millisecond
+
+	l := newInjectionLimiter(100)
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		l.wait()
+	}
+	assert.True(t, time.Since(start) >= 40*time.Millisecond)
+
+	l.busy()
+	assert.Equal(t, 50.0, l.rate)
+	start = time.Now()
+	l.wait()
+	assert.True(t, time.Since(start) >= 10*time.Millisecond)
+
+	l.busy()
+	assert.Equal(t, 40*time.Millisecond, l.backoff)
+	l.ok()
+	assert.Equal(t, time.Duration(0), l.backoff)
+	assert.Equal(t, 35.0, l.rate)
+
+	// No rate limit, only the backoff.
+	l = newInjectionLimiter(0)
+	l.busy()
+	l.ok()
+	assert.Equal(t, 0.0, l.rate)
+
+	var none *injectionLimiter
+	none.wait()
+	none.busy()
+	none.ok()
+}
+
+func TestInjectionPoolCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eos-bios-injection")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	server := getInfoServer(strings.Repeat("ab", 32), 20)
+	defer server.Close()
+	b := &BIOS{Log: NewLogger(), TargetNetAPI: eos.New(server.URL)}
+
+	cp := b.newBootCheckpoint(filepath.Join(dir, CheckpointFile))
+	p := &injectionPool{b: b, stepIdx: 4, checkpoint: cp, inFlight: map[int]uint32{0: 10, 1: 11, 2: 12}, failed: map[int]uint32{}, position: 2}
+
+	p.done(injectionJob{idx: 1})
+	assert.Equal(t, 4, cp.Step)
+	assert.Equal(t, -1, cp.Chunk)
+	assert.Equal(t, uint32(10), cp.BlockNum)
+
+	p.done(injectionJob{idx: 0})
+	assert.Equal(t, 1, cp.Chunk)
+	assert.Equal(t, uint32(12), cp.BlockNum)
+
+	p.done(injectionJob{idx: 2})
+	assert.Equal(t, 2, cp.Chunk)
+	assert.Equal(t, uint32(20), cp.BlockNum)
+
+	p.inFlight[3] = 20
+	p.done(injectionJob{idx: 3, err: errStepSkipped})
+	assert.Equal(t, errStepSkipped, p.err)
+	assert.Equal(t, 2, cp.Chunk)
+}
+
+func TestInjectionPoolCheckpointFailed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eos-bios-injection")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	server := getInfoServer(strings.Repeat("ab", 32), 20)
+	defer server.Close()
+	b := &BIOS{Log: NewLogger(), TargetNetAPI: eos.New(server.URL)}
+
+	cp := b.newBootCheckpoint(filepath.Join(dir, CheckpointFile))
+	p := &injectionPool{b: b, stepIdx: 4, checkpoint: cp, inFlight: map[int]uint32{0: 10, 1: 11, 2: 12}, failed: map[int]uint32{}, position: 2}
+
+	// Chunk 0 fails, chunks 1 and 2 finish after it.
+	p.done(injectionJob{idx: 0, err: errors.New("push failed")})
+	p.done(injectionJob{idx: 1})
+	p.done(injectionJob{idx: 2})
+	assert.EqualError(t, p.err, "push failed")
+	assert.Len(t, p.inFlight, 0)
+
+	assert.Equal(t, 4, cp.Step)
+	assert.Equal(t, -1, cp.Chunk)
+	assert.Equal(t, uint32(10), cp.BlockNum)
+
+	// Resuming pushes chunk 0 again.
+	previous, err := b.loadBootCheckpoint(filepath.Join(dir, CheckpointFile))
+	assert.NoError(t, err)
+	assert.False(t, previous.done(4, 0))
+}
+
+func TestInjectionPool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eos-bios-injection")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var head, pushes, concurrent, maxConcurrent int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/get_info") {
+			fmt.Fprintf(w, `{"head_block_num": %d}`, atomic.AddInt32(&head, 1))
+			return
+		}
+
+		now := atomic.AddInt32(&concurrent, 1)
+		defer atomic.AddInt32(&concurrent, -1)
+		for {
+			max := atomic.LoadInt32(&maxConcurrent)
+			if now <= max || atomic.CompareAndSwapInt32(&maxConcurrent, max, now) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		fmt.Fprintf(w, `{"transaction_id": "%04d"}`, atomic.AddInt32(&pushes, 1))
+	}))
+	defer server.Close()
+
+	b := &BIOS{
+		Log:                  NewLogger(),
+		TargetNetAPI:         eos.New(server.URL),
+		InjectionConcurrency: 3,
+		injectionMetrics:     NewInjectionMetrics(),
+	}
+	step := &OperationType{Op: "snapshot.create_accounts", Data: &OpSnapshotCreateAccounts{BatchSize: 10}}
+	assert.True(t, b.concurrentChunks(step))
+
+	cp := b.newBootCheckpoint(filepath.Join(dir, CheckpointFile))
+	pool := b.newInjectionPool(0, step, nil, cp)
+	for idx := 0; idx < 10; idx++ {
+		assert.NoError(t, pool.push(idx, []*eos.Action{{Account: AN("eosio")}}))
+	}
+	assert.NoError(t, pool.wait())
+
+	assert.Equal(t, int32(10), pushes)
+	assert.Equal(t, int32(3), maxConcurrent)
+	assert.Len(t, b.pushedTransactions, 10)
+	assert.Equal(t, 9, cp.Chunk)
+
+	b.InjectionConcurrency = 1
+	assert.False(t, b.concurrentChunks(step))
+}
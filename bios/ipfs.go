@@ -1,7 +1,6 @@
 package bios
 
 import (
-	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -9,19 +8,33 @@ import (
 
 type IPFS struct {
 	GatewayAddressURL string
-	Client            *http.Client
+	// FallbackGatewayURLs are tried in order when the main gateway
+	// fails.
+	FallbackGatewayURLs []string
+	Client              *http.Client
 }
 
-func NewIPFS(gatewayAddress string) (out *IPFS) {
+func NewIPFS(gatewayAddress string, fallbackGatewayAddresses ...string) (out *IPFS) {
 	out = &IPFS{
-		Client:            http.DefaultClient,
-		GatewayAddressURL: gatewayAddress,
+		Client:              http.DefaultClient,
+		GatewayAddressURL:   gatewayAddress,
+		FallbackGatewayURLs: fallbackGatewayAddresses,
 	}
 	return
 }
 
-func (i *IPFS) Get(ref string) ([]byte, error) {
-	destURL := i.GatewayAddressURL + ref
+func (i *IPFS) Get(ref string) (cnt []byte, err error) {
+	for _, gateway := range append([]string{i.GatewayAddressURL}, i.FallbackGatewayURLs...) {
+		cnt, err = i.getFrom(gateway, ref)
+		if err == nil {
+			return cnt, nil
+		}
+	}
+	return nil, err
+}
+
+func (i *IPFS) getFrom(gatewayAddress, ref string) ([]byte, error) {
+	destURL := gatewayAddress + ref
 	req, err := http.NewRequest("GET", destURL, nil)
 	if err != nil {
 		return nil, err
@@ -30,7 +43,7 @@ func (i *IPFS) Get(ref string) ([]byte, error) {
 	// fmt.Printf("Fetching %q from %q...", ref, i.GatewayAddressURL.String())
 	resp, err := i.Client.Do(req)
 	if err != nil {
-		return nil, errors.New("download attempts failed")
+		return nil, fmt.Errorf("fetching %s: %s", destURL, err)
 	}
 	defer resp.Body.Close()
 
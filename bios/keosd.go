@@ -0,0 +1,80 @@
+package bios
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+const defaultKeosdURL = "http://127.0.0.1:8900"
+
+// keosdDataDir is where `keosd` keeps its wallets and `config.ini`
+// when not told otherwise: `~/eosio-wallet`, or under
+// `%LOCALAPPDATA%` (or the user profile) on Windows.
+func keosdDataDir() string {
+	if runtime.GOOS == "windows" {
+		for _, env := range []string{"LOCALAPPDATA", "USERPROFILE"} {
+			if dir := os.Getenv(env); dir != "" {
+				return filepath.Join(dir, "eosio-wallet")
+			}
+		}
+	}
+
+	home, err := homedir.Dir()
+	if err != nil {
+		return "eosio-wallet"
+	}
+	return filepath.Join(home, "eosio-wallet")
+}
+
+// DiscoverKeosdURL finds the local `keosd`: from `KEOSD_URL` if set,
+// then from the `http-server-address` of its `config.ini`, and
+// finally its default address.
+func DiscoverKeosdURL() string {
+	if keosdURL := os.Getenv("KEOSD_URL"); keosdURL != "" {
+		return keosdURL
+	}
+
+	if addr := keosdConfigAddress(filepath.Join(keosdDataDir(), "config.ini")); addr != "" {
+		return addr
+	}
+
+	return defaultKeosdURL
+}
+
+// keosdConfigAddress reads `http-server-address` from a `keosd`
+// config file. Addresses listening on all interfaces are reached
+// through the loopback.
+func keosdConfigAddress(configFile string) string {
+	fl, err := os.Open(configFile)
+	if err != nil {
+		return ""
+	}
+	defer fl.Close()
+
+	scanner := bufio.NewScanner(fl)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) != "http-server-address" {
+			continue
+		}
+
+		addr := strings.TrimSpace(parts[1])
+		if addr == "" {
+			return ""
+		}
+		if strings.HasPrefix(addr, "0.0.0.0:") {
+			addr = "127.0.0.1:" + strings.TrimPrefix(addr, "0.0.0.0:")
+		} else if strings.HasPrefix(addr, ":") {
+			addr = "127.0.0.1" + addr
+		}
+		return "http://" + addr
+	}
+
+	return ""
+}
@@ -0,0 +1,49 @@
+package bios
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeosdConfigAddress(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keosd")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		config string
+		expect string
+	}{
+		{"# http-server-address = 127.0.0.1:8888\nhttp-server-address = 127.0.0.1:6666\n", "http://127.0.0.1:6666"},
+		{"http-server-address=0.0.0.0:8900", "http://127.0.0.1:8900"},
+		{"http-server-address = :8901", "http://127.0.0.1:8901"},
+		{"http-server-address =\nunix-socket-path = keosd.sock", ""},
+		{"wallet-dir = .", ""},
+	}
+
+	configFile := filepath.Join(dir, "config.ini")
+	for _, test := range tests {
+		assert.NoError(t, ioutil.WriteFile(configFile, []byte(test.config), 0666))
+		assert.Equal(t, test.expect, keosdConfigAddress(configFile), test.config)
+	}
+
+	assert.Equal(t, "", keosdConfigAddress(filepath.Join(dir, "missing.ini")))
+}
+
+func TestLocalPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		assert.Equal(t, `C:\launch\mirror`, localPath("file:///C:/launch/mirror"))
+		assert.Equal(t, `\\server\share\mirror`, localPath("file://server/share/mirror"))
+		assert.Equal(t, `launch\mirror`, localPath("launch/mirror"))
+		return
+	}
+
+	assert.Equal(t, "/srv/mirror", localPath("file:///srv/mirror"))
+	assert.Equal(t, "/srv/mirror", localPath("file://localhost/srv/mirror"))
+	assert.Equal(t, "launch/mirror", localPath("launch/mirror"))
+}
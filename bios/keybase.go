@@ -0,0 +1,162 @@
+package bios
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// Producers point to their Keybase user, and optionally pin the
+// fingerprint of its PGP key, in the `urls` of their discovery file:
+//
+//	keybase:eoscanada
+//	pgp-fingerprint:0123456789abcdef0123456789abcdef01234567
+const (
+	keybaseUserPrefix    = "keybase:"
+	pgpFingerprintPrefix = "pgp-fingerprint:"
+)
+
+// KeybaseURL is where PGP keys of Keybase users are fetched.
+var KeybaseURL = "https://keybase.io"
+
+// keybaseUser returns the Keybase user and PGP key fingerprint listed
+// in the `urls` of a discovery file.
+func keybaseUser(urls []string) (user, fingerprint string) {
+	for _, url := range urls {
+		if strings.HasPrefix(url, keybaseUserPrefix) {
+			user = strings.TrimPrefix(url, keybaseUserPrefix)
+		}
+		if strings.HasPrefix(url, pgpFingerprintPrefix) {
+			fingerprint = strings.ToLower(strings.Replace(strings.TrimPrefix(url, pgpFingerprintPrefix), " ", "", -1))
+		}
+	}
+	return
+}
+
+// keybaseLookup is the part of the answer of Keybase's user lookup
+// API we use.
+type keybaseLookup struct {
+	Status struct {
+		Code int    `json:"code"`
+		Desc string `json:"desc"`
+	} `json:"status"`
+	Them []*struct {
+		Basics struct {
+			Username string `json:"username"`
+		} `json:"basics"`
+		PublicKeys struct {
+			Primary struct {
+				KeyFingerprint string `json:"key_fingerprint"`
+				Bundle         string `json:"bundle"`
+			} `json:"primary"`
+		} `json:"public_keys"`
+	} `json:"them"`
+}
+
+// PGPKeyMismatchError is a PGP key not matching the fingerprint
+// published for it, either pinned in the discovery file or claimed by
+// Keybase: a possible key substitution, which must stop the launch.
+type PGPKeyMismatchError struct {
+	User        string
+	Fingerprint string
+	Expected    string
+	Source      string
+}
+
+func (e *PGPKeyMismatchError) Error() string {
+	return fmt.Sprintf("PGP key of keybase user %q has fingerprint %s, but %s says %s", e.User, e.Fingerprint, e.Source, e.Expected)
+}
+
+// FetchKeybasePGPKey retrieves the armored primary PGP public key of a
+// Keybase user through the Keybase API, and checks its fingerprint is
+// the one Keybase publishes for it and, when given, the expected one.
+// It returns the fingerprint of the key. Mismatches are
+// PGPKeyMismatchErrors.
+func (net *Network) FetchKeybasePGPKey(user, expectedFingerprint string) (armored, fingerprint string, err error) {
+	cnt, err := net.APICache.Get(fmt.Sprintf("%s/_/api/1.0/user/lookup.json?usernames=%s&fields=basics,public_keys", KeybaseURL, url.QueryEscape(user)))
+	if err != nil {
+		return "", "", fmt.Errorf("looking up keybase user %q: %s", user, err)
+	}
+
+	var lookup keybaseLookup
+	if err := json.Unmarshal(cnt, &lookup); err != nil {
+		return "", "", fmt.Errorf("looking up keybase user %q: decoding: %s", user, err)
+	}
+	if lookup.Status.Code != 0 {
+		return "", "", fmt.Errorf("looking up keybase user %q: %s (code %d)", user, lookup.Status.Desc, lookup.Status.Code)
+	}
+	if len(lookup.Them) != 1 || lookup.Them[0] == nil || !strings.EqualFold(lookup.Them[0].Basics.Username, user) {
+		return "", "", fmt.Errorf("keybase user %q not found", user)
+	}
+
+	primary := lookup.Them[0].PublicKeys.Primary
+	if primary.Bundle == "" {
+		return "", "", fmt.Errorf("keybase user %q has no PGP key", user)
+	}
+
+	fingerprint, err = pgpKeyFingerprint([]byte(primary.Bundle))
+	if err != nil {
+		return "", "", fmt.Errorf("PGP key of keybase user %q: %s", user, err)
+	}
+
+	if claimed := strings.ToLower(primary.KeyFingerprint); fingerprint != claimed {
+		return "", "", &PGPKeyMismatchError{User: user, Fingerprint: fingerprint, Expected: claimed, Source: "keybase"}
+	}
+	if expectedFingerprint != "" && fingerprint != expectedFingerprint {
+		return "", "", &PGPKeyMismatchError{User: user, Fingerprint: fingerprint, Expected: expectedFingerprint, Source: "the discovery file"}
+	}
+
+	return primary.Bundle, fingerprint, nil
+}
+
+// pgpKeyFingerprint returns the fingerprint of the primary key in
+// `armored`.
+func pgpKeyFingerprint(armored []byte) (string, error) {
+	entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(armored))
+	if err != nil {
+		return "", fmt.Errorf("reading: %s", err)
+	}
+	if len(entities) != 1 {
+		return "", fmt.Errorf("expected one key, found %d", len(entities))
+	}
+
+	return hex.EncodeToString(entities[0].PrimaryKey.Fingerprint[:]), nil
+}
+
+// fetchPGPKeys embeds the PGP keys of the peers listing a Keybase user
+// in their discovery file. Keys not matching their published
+// fingerprint fail the fetch, other failures are logged, and the peer
+// left without a key.
+func (net *Network) fetchPGPKeys() error {
+	var mismatches []string
+	for _, peer := range net.OrderedPeers(net.MyNetwork()) {
+		user, fingerprint := keybaseUser(peer.Discovery.URLs)
+		if user == "" {
+			continue
+		}
+
+		armored, fingerprint, err := net.FetchKeybasePGPKey(user, fingerprint)
+		if _, ok := err.(*PGPKeyMismatchError); ok {
+			net.Log.Printf("ERROR: %s: %s\n", peer.Discovery.SeedNetworkAccountName, err)
+			mismatches = append(mismatches, string(peer.Discovery.SeedNetworkAccountName))
+			continue
+		}
+		if err != nil {
+			net.Log.Printf("WARN: %s: %s\n", peer.Discovery.SeedNetworkAccountName, err)
+			continue
+		}
+
+		peer.PGPPublicKey = armored
+		peer.PGPFingerprint = fingerprint
+	}
+
+	if len(mismatches) != 0 {
+		return fmt.Errorf("PGP keys of %s don't match their published fingerprints, possibly substituted", strings.Join(mismatches, ", "))
+	}
+	return nil
+}
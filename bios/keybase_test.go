@@ -0,0 +1,88 @@
+package bios
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeybaseUser(t *testing.T) {
+	user, fingerprint := keybaseUser([]string{"https://eoscanada.com", "keybase:eoscanada", "pgp-fingerprint:0123 4567 89AB"})
+	assert.Equal(t, "eoscanada", user)
+	assert.Equal(t, "0123456789ab", fingerprint)
+
+	user, _ = keybaseUser([]string{"https://eoscanada.com"})
+	assert.Equal(t, "", user)
+}
+
+func TestFetchKeybasePGPKey(t *testing.T) {
+	entity, armored := testPGPEntity(t, "eoscanada")
+	expected := hex.EncodeToString(entity.PrimaryKey.Fingerprint[:])
+	_, otherArmored := testPGPEntity(t, "mallory")
+
+	lookup := func(username, fingerprint, bundle string) string {
+		them, _ := json.Marshal(map[string]interface{}{
+			"basics":      map[string]string{"username": username},
+			"public_keys": map[string]interface{}{"primary": map[string]string{"key_fingerprint": fingerprint, "bundle": bundle}},
+		})
+		return `{"status":{"code":0,"name":"OK"},"them":[` + string(them) + `]}`
+	}
+	users := map[string]string{
+		"eoscanada":   lookup("eoscanada", strings.ToUpper(expected), armored),
+		"substituted": lookup("substituted", expected, otherArmored),
+		"nokey":       lookup("nokey", "", ""),
+		"someoneelse": `{"status":{"code":0,"name":"OK"},"them":[null]}`,
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_/api/1.0/user/lookup.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(users[r.URL.Query().Get("usernames")]))
+	}))
+	defer ts.Close()
+	defer func(url string) { KeybaseURL = url }(KeybaseURL)
+	KeybaseURL = ts.URL
+
+	dir, err := ioutil.TempDir("", "keybase")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	net := &Network{APICache: NewAPICache(dir, time.Hour, false)}
+
+	key, fingerprint, err := net.FetchKeybasePGPKey("eoscanada", "")
+	assert.NoError(t, err)
+	assert.Equal(t, armored, key)
+	assert.Equal(t, expected, fingerprint)
+
+	_, fingerprint, err = net.FetchKeybasePGPKey("eoscanada", expected)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, fingerprint)
+
+	// The key isn't the one pinned in the discovery file
+	_, _, err = net.FetchKeybasePGPKey("eoscanada", "0123456789abcdef0123456789abcdef01234567")
+	if assert.IsType(t, &PGPKeyMismatchError{}, err) {
+		assert.Equal(t, "the discovery file", err.(*PGPKeyMismatchError).Source)
+	}
+
+	// The key isn't the one Keybase claims
+	_, _, err = net.FetchKeybasePGPKey("substituted", "")
+	if assert.IsType(t, &PGPKeyMismatchError{}, err) {
+		assert.Equal(t, "keybase", err.(*PGPKeyMismatchError).Source)
+	}
+
+	for _, user := range []string{"nokey", "someoneelse", "unknown"} {
+		_, _, err = net.FetchKeybasePGPKey(user, "")
+		assert.Error(t, err)
+		assert.IsType(t, fmt.Errorf(""), err)
+	}
+}
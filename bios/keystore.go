@@ -0,0 +1,144 @@
+package bios
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	eos "github.com/eoscanada/eos-go"
+	"golang.org/x/crypto/scrypt"
+)
+
+// KeystorePassphraseEnv holds the passphrase of encrypted keystores.
+const KeystorePassphraseEnv = "EOS_BIOS_KEYSTORE_PASSPHRASE"
+
+// Keystore is a keys file (private keys, one per line) encrypted with
+// AES-256-GCM, under a key derived from a passphrase with scrypt.
+type Keystore struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Salt       string `json:"salt"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// EncryptKeystore encrypts the content of a keys file.
+func EncryptKeystore(keys []byte, passphrase string) ([]byte, error) {
+	ks := &Keystore{Version: 1, KDF: "scrypt", N: 1 << 15, R: 8, P: 1}
+
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	aead, err := ks.aead(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	ks.Salt = hex.EncodeToString(salt)
+	ks.Nonce = hex.EncodeToString(nonce)
+	ks.Ciphertext = hex.EncodeToString(aead.Seal(nil, nonce, keys, nil))
+
+	return json.MarshalIndent(ks, "", "  ")
+}
+
+// DecryptKeystore returns the keys file encrypted in `content`.
+func DecryptKeystore(content []byte, passphrase string) ([]byte, error) {
+	var ks *Keystore
+	if err := json.Unmarshal(content, &ks); err != nil {
+		return nil, fmt.Errorf("decoding keystore: %s", err)
+	}
+	if ks.Version != 1 || ks.KDF != "scrypt" {
+		return nil, fmt.Errorf("unsupported keystore version %d, kdf %q", ks.Version, ks.KDF)
+	}
+
+	salt, err := hex.DecodeString(ks.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt: %s", err)
+	}
+	nonce, err := hex.DecodeString(ks.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nonce: %s", err)
+	}
+	ciphertext, err := hex.DecodeString(ks.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ciphertext: %s", err)
+	}
+
+	aead, err := ks.aead(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce size %d", len(nonce))
+	}
+
+	keys, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("wrong passphrase, or corrupted keystore")
+	}
+	return keys, nil
+}
+
+func (ks *Keystore) aead(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, ks.N, ks.R, ks.P, 32)
+	if err != nil {
+		return nil, fmt.Errorf("deriving key: %s", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// LoadKeystore decrypts a keystore into a key bag, with the passphrase
+// from $EOS_BIOS_KEYSTORE_PASSPHRASE.
+func LoadKeystore(filename string) (*eos.KeyBag, error) {
+	passphrase := os.Getenv(KeystorePassphraseEnv)
+	if passphrase == "" {
+		return nil, fmt.Errorf("set %s to the passphrase of %q", KeystorePassphraseEnv, filename)
+	}
+
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := DecryptKeystore(content, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("%q: %s", filename, err)
+	}
+
+	keyBag := eos.NewKeyBag()
+	scanner := bufio.NewScanner(bytes.NewReader(keys))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := keyBag.Add(strings.Fields(line)[0]); err != nil {
+			return nil, fmt.Errorf("%q: invalid private key: %s", filename, err)
+		}
+	}
+
+	return keyBag, nil
+}
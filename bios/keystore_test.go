@@ -0,0 +1,25 @@
+package bios
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeystoreRoundTrip(t *testing.T) {
+	keys := []byte("5KQwrPbwdL6PhXujxW37FSSQZ1JiwsST4cqQzDeyXtP79zkvFD3\n")
+
+	keystore, err := EncryptKeystore(keys, "correct horse")
+	assert.NoError(t, err)
+	assert.NotContains(t, string(keystore), "5KQwrPbwdL6PhXujxW37FSSQZ1JiwsST4cqQzDeyXtP79zkvFD3")
+
+	decrypted, err := DecryptKeystore(keystore, "correct horse")
+	assert.NoError(t, err)
+	assert.Equal(t, keys, decrypted)
+
+	_, err = DecryptKeystore(keystore, "battery staple")
+	assert.Error(t, err)
+
+	_, err = DecryptKeystore([]byte(`{"version":2}`), "correct horse")
+	assert.Error(t, err)
+}
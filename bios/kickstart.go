@@ -0,0 +1,483 @@
+package bios
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/eoscanada/eos-go/ecc"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+// KickstartPayload is what participants need to join a network
+// booted by someone else: its genesis, where to connect, and any extra
+// artifacts the boot node wants to hand out. The chain ID, the boot
+// node's own address and the ephemeral `eosio` key are only in the
+// payload encrypted to the appointed producers (see
+// writeEncryptedKickstart).
+type KickstartPayload struct {
+	Genesis             string            `json:"genesis"`
+	P2PAddresses        []string          `json:"p2p_addresses"`
+	Artifacts           map[string]string `json:"artifacts,omitempty"`
+	ChainID             string            `json:"chain_id,omitempty"`
+	BootP2PAddress      string            `json:"boot_p2p_address,omitempty"`
+	EphemeralPrivateKey string            `json:"ephemeral_private_key,omitempty"`
+}
+
+// Kickstart chunks look like `eosks1:<id>:<index>:<total>:<crc32>:<data>`,
+// where `id` is the start of the payload's sha256, `crc32` covers the
+// chunk's data, and the data of all chunks, in order, is the base64 of
+// the gzipped payload.
+const kickstartChunkPrefix = "eosks1:"
+
+// IsKickstartChunk tells whether a pasted line is a kickstart chunk.
+func IsKickstartChunk(text string) bool {
+	return strings.HasPrefix(strings.TrimSpace(text), kickstartChunkPrefix)
+}
+
+// EncodeKickstart compresses `payload`, and splits it into chunks
+// carrying at most `chunkSize` characters of data each.
+func EncodeKickstart(payload []byte, chunkSize int) ([]string, error) {
+	if chunkSize <= 0 {
+		return nil, fmt.Errorf("invalid chunk size %d", chunkSize)
+	}
+
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	if _, err := gz.Write(payload); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(buf.Bytes())
+	id := sha2(payload)[:16]
+	total := (len(encoded) + chunkSize - 1) / chunkSize
+
+	var out []string
+	for idx := 0; idx < total; idx++ {
+		end := (idx + 1) * chunkSize
+		if end > len(encoded) {
+			end = len(encoded)
+		}
+		data := encoded[idx*chunkSize : end]
+		out = append(out, fmt.Sprintf("%s%s:%d:%d:%08x:%s", kickstartChunkPrefix, id, idx+1, total, crc32.ChecksumIEEE([]byte(data)), data))
+	}
+
+	return out, nil
+}
+
+// KickstartAssembler gathers chunks, in any order, until the payload
+// is complete.
+type KickstartAssembler struct {
+	id     string
+	total  int
+	chunks map[int]string
+}
+
+// Add verifies and records a chunk, and returns whether all chunks
+// are now in.
+func (a *KickstartAssembler) Add(chunk string) (bool, error) {
+	chunk = strings.TrimSpace(chunk)
+	if !IsKickstartChunk(chunk) {
+		return false, fmt.Errorf("not a kickstart chunk")
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(chunk, kickstartChunkPrefix), ":", 5)
+	if len(parts) != 5 {
+		return false, fmt.Errorf("malformed kickstart chunk")
+	}
+	id, data := parts[0], parts[4]
+
+	idx, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return false, fmt.Errorf("invalid chunk index: %s", err)
+	}
+	total, err := strconv.Atoi(parts[2])
+	if err != nil || total < 1 || idx < 1 || idx > total {
+		return false, fmt.Errorf("invalid chunk position %s/%s", parts[1], parts[2])
+	}
+
+	if checksum := fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte(data))); checksum != parts[3] {
+		return false, fmt.Errorf("chunk %d/%d is corrupted (crc32 %s, expected %s)", idx, total, checksum, parts[3])
+	}
+
+	if a.chunks == nil {
+		a.id, a.total, a.chunks = id, total, map[int]string{}
+	}
+	if id != a.id || total != a.total {
+		return false, fmt.Errorf("chunk %d/%d belongs to another payload (%s, expected %s)", idx, total, id, a.id)
+	}
+
+	a.chunks[idx] = data
+
+	return a.Complete(), nil
+}
+
+func (a *KickstartAssembler) Complete() bool {
+	return a.chunks != nil && len(a.chunks) == a.total
+}
+
+// Missing lists the chunk indexes not received yet.
+func (a *KickstartAssembler) Missing() (out []int) {
+	for idx := 1; idx <= a.total; idx++ {
+		if _, found := a.chunks[idx]; !found {
+			out = append(out, idx)
+		}
+	}
+	return
+}
+
+// Payload reassembles, decompresses and verifies the payload.
+func (a *KickstartAssembler) Payload() ([]byte, error) {
+	if !a.Complete() {
+		return nil, fmt.Errorf("missing chunks %v", a.Missing())
+	}
+
+	var encoded string
+	for idx := 1; idx <= a.total; idx++ {
+		encoded += a.chunks[idx]
+	}
+
+	compressed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("decoding: %s", err)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("decompressing: %s", err)
+	}
+	payload, err := ioutil.ReadAll(gz)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing: %s", err)
+	}
+
+	if id := sha2(payload)[:16]; id != a.id {
+		return nil, fmt.Errorf("reassembled payload has id %s, expected %s", id, a.id)
+	}
+
+	return payload, nil
+}
+
+// publishKickstart splits the kickstart payload into chunks, writes
+// them to `kickstart.chunks` (one per line) and hands them to the
+// `boot_publish_kickstart` hook.
+func (b *BIOS) publishKickstart(genesisData string, p2pAddresses []string) error {
+	payload, err := json.Marshal(&KickstartPayload{
+		Genesis:      genesisData,
+		P2PAddresses: p2pAddresses,
+	})
+	if err != nil {
+		return err
+	}
+
+	chunks, err := EncodeKickstart(payload, b.KickstartChunkSize)
+	if err != nil {
+		return err
+	}
+
+	content := strings.Join(chunks, "\n") + "\n"
+	b.writeToFile("kickstart.chunks", content)
+	b.publishArtifact("kickstart.chunks", []byte(content))
+	b.Log.Printf("Kickstart payload of %d bytes split in %d chunks, written to kickstart.chunks\n", len(payload), len(chunks))
+
+	return b.DispatchBootPublishKickstart(chunks)
+}
+
+// readKickstartPayload asks for the remaining chunks of a kickstart
+// payload, from the first one pasted.
+func (b *BIOS) readKickstartPayload(firstChunk string) (*KickstartPayload, error) {
+	assembler := &KickstartAssembler{}
+	complete, err := assembler.Add(firstChunk)
+	if err != nil {
+		return nil, err
+	}
+
+	for !complete {
+		b.Log.Printf("Please input the next kickstart chunk (missing %v): ", assembler.Missing())
+		line, err := ScanSingleLine()
+		if err != nil {
+			b.Log.Println("error reading:", err)
+			continue
+		}
+
+		complete, err = assembler.Add(line)
+		if err != nil {
+			b.Log.Printf("Invalid chunk: %s\n", err)
+		}
+	}
+
+	cnt, err := assembler.Payload()
+	if err != nil {
+		return nil, err
+	}
+
+	var payload *KickstartPayload
+	if err := json.Unmarshal(cnt, &payload); err != nil {
+		return nil, fmt.Errorf("decoding kickstart payload: %s", err)
+	}
+	return payload, nil
+}
+
+// EncryptKickstart encrypts `payload` to all the armored PGP public
+// keys, as an armored PGP message any of their owners can decrypt
+// with `gpg --decrypt`.
+func EncryptKickstart(payload []byte, armoredKeys []string) ([]byte, error) {
+	var recipients openpgp.EntityList
+	for _, armored := range armoredKeys {
+		entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armored))
+		if err != nil {
+			return nil, fmt.Errorf("reading PGP key: %s", err)
+		}
+		recipients = append(recipients, entities...)
+	}
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("no recipients")
+	}
+
+	buf := &bytes.Buffer{}
+	armored, err := armor.Encode(buf, "PGP MESSAGE", nil)
+	if err != nil {
+		return nil, err
+	}
+	w, err := openpgp.Encrypt(armored, recipients, nil, &openpgp.FileHints{FileName: "kickstart.json"}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("encrypting: %s", err)
+	}
+	if _, err := w.Write(payload); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	if err := armored.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// appointedPeers are the appointed producers of the shuffle, after the
+// boot node, as set by `system.setprods`.
+func (b *BIOS) appointedPeers() []*Peer {
+	end := b.scheduleSize()
+	if end > len(b.ShuffledProducers) {
+		end = len(b.ShuffledProducers)
+	}
+	if end < 1 {
+		return nil
+	}
+	return b.ShuffledProducers[1:end]
+}
+
+// writeEncryptedKickstart encrypts the full kickstart payload (with
+// the chain ID, the boot node's p2p address and the ephemeral `eosio`
+// private key) to the PGP keys of the appointed producers, and writes
+// it to `kickstart.pgp`, ready for distribution. Producers without a
+// PGP key (see fetchPGPKeys) are left out, with a warning.
+func (b *BIOS) writeEncryptedKickstart(genesisData, privKey string, p2pAddresses []string) error {
+	chainID := hex.EncodeToString(b.TargetChainID)
+	if chainID == "" {
+		info, err := b.TargetNetAPI.GetInfo()
+		if err != nil {
+			return fmt.Errorf("get info: %s", err)
+		}
+		chainID = hex.EncodeToString(info.ChainID)
+	}
+
+	payload, err := json.Marshal(&KickstartPayload{
+		Genesis:             genesisData,
+		P2PAddresses:        p2pAddresses,
+		ChainID:             chainID,
+		BootP2PAddress:      b.Network.MyPeer.Discovery.TargetP2PAddress,
+		EphemeralPrivateKey: privKey,
+	})
+	if err != nil {
+		return err
+	}
+
+	var keys, recipients []string
+	seen := map[string]bool{}
+	for _, peer := range b.appointedPeers() {
+		account := string(peer.Discovery.SeedNetworkAccountName)
+		if peer.PGPPublicKey == "" {
+			b.Log.Printf("WARN: appointed producer %s has no PGP key (see above, or no keybase user in its discovery file), it won't be able to decrypt kickstart.pgp\n", account)
+			continue
+		}
+		// Cloned peers share the key of the peer they were cloned from.
+		if seen[peer.PGPFingerprint] {
+			continue
+		}
+		seen[peer.PGPFingerprint] = true
+		keys = append(keys, peer.PGPPublicKey)
+		recipients = append(recipients, fmt.Sprintf("%s (%s)", account, peer.PGPFingerprint))
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("none of the appointed producers has a PGP key")
+	}
+
+	encrypted, err := EncryptKickstart(payload, keys)
+	if err != nil {
+		return err
+	}
+
+	b.writeToFile("kickstart.pgp", string(encrypted))
+	b.publishArtifact("kickstart.pgp", encrypted)
+	b.Log.Printf("Kickstart payload encrypted to %d appointed producers, written to kickstart.pgp: %s\n", len(keys), strings.Join(recipients, ", "))
+
+	if b.KickstartIPFSAPI != "" {
+		if _, err := b.publishKickstartIPFS(encrypted); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PGPPassphraseEnv holds the passphrase of the PGP secret key
+// decrypting kickstart payloads, for unattended runs.
+const PGPPassphraseEnv = "EOS_BIOS_PGP_PASSPHRASE"
+
+// DecryptKickstart decrypts a kickstart payload (armored or not) with
+// the armored PGP secret keys of `keyring`, asking `passphrase` for
+// their passphrase when they're encrypted.
+func DecryptKickstart(encrypted, keyring []byte, passphrase func() (string, error)) ([]byte, error) {
+	entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(keyring))
+	if err != nil {
+		return nil, fmt.Errorf("reading PGP secret key: %s", err)
+	}
+
+	var in io.Reader = bytes.NewReader(encrypted)
+	if bytes.HasPrefix(bytes.TrimSpace(encrypted), []byte("-----BEGIN PGP MESSAGE-----")) {
+		block, err := armor.Decode(in)
+		if err != nil {
+			return nil, fmt.Errorf("decoding PGP armor: %s", err)
+		}
+		in = block.Body
+	}
+
+	// The prompt is called again after a wrong passphrase, until it
+	// gives up: only try once.
+	tried := false
+	md, err := openpgp.ReadMessage(in, entities, func(keys []openpgp.Key, symmetric bool) ([]byte, error) {
+		if symmetric || tried {
+			return nil, fmt.Errorf("wrong passphrase")
+		}
+		tried = true
+
+		pass, err := passphrase()
+		if err != nil {
+			return nil, fmt.Errorf("reading passphrase: %s", err)
+		}
+		for _, key := range keys {
+			if key.PrivateKey != nil && key.PrivateKey.Encrypted {
+				key.PrivateKey.Decrypt([]byte(pass))
+			}
+		}
+		return nil, nil
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting: %s", err)
+	}
+
+	out, err := ioutil.ReadAll(md.UnverifiedBody)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting: %s", err)
+	}
+	return out, nil
+}
+
+// gpgDecrypt decrypts with the local `gpg`, which finds the secret key
+// and prompts for its passphrase.
+var gpgDecrypt = func(encrypted []byte) ([]byte, error) {
+	out := &bytes.Buffer{}
+	cmd := exec.Command("gpg", "--decrypt", "--quiet")
+	cmd.Stdin = bytes.NewReader(encrypted)
+	cmd.Stdout = out
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("gpg --decrypt: %s", err)
+	}
+	return out.Bytes(), nil
+}
+
+// kickstartGenesis obtains the genesis from the encrypted kickstart
+// payload of KickstartFile, or of the KickstartIPFS CID.
+func (b *BIOS) kickstartGenesis() (*GenesisJSON, error) {
+	if b.KickstartFile != "" {
+		encrypted, err := ioutil.ReadFile(b.KickstartFile)
+		if err != nil {
+			return nil, err
+		}
+		return b.consumeKickstart(encrypted)
+	}
+	return b.fetchKickstartIPFS(b.KickstartIPFS)
+}
+
+// consumeKickstart decrypts an encrypted kickstart payload, with the
+// KickstartKeyring or the local `gpg`, checks it's consistent (chain
+// ID, ephemeral key) and returns its genesis. Its p2p addresses, with
+// the boot node's first, are meshed with.
+func (b *BIOS) consumeKickstart(encrypted []byte) (*GenesisJSON, error) {
+	var cnt []byte
+	var err error
+	if len(b.KickstartKeyring) != 0 {
+		cnt, err = DecryptKickstart(encrypted, b.KickstartKeyring, b.KickstartPassphrase)
+	} else {
+		cnt, err = gpgDecrypt(encrypted)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var payload *KickstartPayload
+	if err := json.Unmarshal(cnt, &payload); err != nil {
+		return nil, fmt.Errorf("decoding kickstart payload: %s", err)
+	}
+
+	if len(b.TargetChainID) != 0 && payload.ChainID != hex.EncodeToString(b.TargetChainID) {
+		return nil, fmt.Errorf("kickstart payload has chain ID %s, the %s derivation gives %s", payload.ChainID, b.ChainID.derivation(), b.TargetChainID)
+	}
+
+	var genesis *GenesisJSON
+	if err := json.Unmarshal([]byte(payload.Genesis), &genesis); err != nil {
+		return nil, fmt.Errorf("invalid genesis data: %s", err)
+	}
+
+	if payload.EphemeralPrivateKey != "" {
+		key, err := ecc.NewPrivateKey(payload.EphemeralPrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ephemeral private key: %s", err)
+		}
+		if pubKey := key.PublicKey().String(); pubKey != genesis.InitialKey {
+			return nil, fmt.Errorf("ephemeral private key is for %s, the genesis' initial key is %s", pubKey, genesis.InitialKey)
+		}
+		b.Log.Println("Ephemeral private key of the kickstart payload matches the genesis' initial key")
+	}
+
+	b.kickstartP2PAddresses = payload.P2PAddresses
+	if payload.BootP2PAddress != "" {
+		b.kickstartP2PAddresses = []string{payload.BootP2PAddress}
+		for _, address := range payload.P2PAddresses {
+			if address != payload.BootP2PAddress {
+				b.kickstartP2PAddresses = append(b.kickstartP2PAddresses, address)
+			}
+		}
+	}
+	b.Log.Printf("Got the kickstart payload, chain ID %s, boot node at %s\n", payload.ChainID, payload.BootP2PAddress)
+
+	return genesis, nil
+}
@@ -0,0 +1,304 @@
+package bios
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/eoscanada/eos-bios/bios/disco"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+func TestKickstartChunks(t *testing.T) {
+	payload := []byte(`{"genesis":"{\"initial_key\":\"EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV\"}","p2p_addresses":["` + strings.Repeat("peer.example.com:9876\",\"", 200) + `"]}`)
+
+	chunks, err := EncodeKickstart(payload, 64)
+	assert.NoError(t, err)
+	assert.True(t, len(chunks) > 1)
+	for _, chunk := range chunks {
+		assert.True(t, IsKickstartChunk(chunk))
+	}
+
+	// Out of order, with a duplicate
+	assembler := &KickstartAssembler{}
+	for idx := len(chunks) - 1; idx >= 0; idx-- {
+		complete, err := assembler.Add(chunks[idx])
+		assert.NoError(t, err)
+		assert.Equal(t, idx == 0, complete)
+		if idx == len(chunks)-1 {
+			_, err := assembler.Payload()
+			assert.Error(t, err)
+			_, err = assembler.Add(chunks[idx])
+			assert.NoError(t, err)
+		}
+	}
+	out, err := assembler.Payload()
+	assert.NoError(t, err)
+	assert.Equal(t, payload, out)
+
+	// Corrupted data
+	corrupted := chunks[0][:len(chunks[0])-1] + "A"
+	if corrupted == chunks[0] {
+		corrupted = chunks[0][:len(chunks[0])-1] + "B"
+	}
+	_, err = (&KickstartAssembler{}).Add(corrupted)
+	assert.Error(t, err)
+
+	// Chunks from another payload
+	other, err := EncodeKickstart([]byte("other payload"), 64)
+	assert.NoError(t, err)
+	assembler = &KickstartAssembler{}
+	_, err = assembler.Add(chunks[0])
+	assert.NoError(t, err)
+	_, err = assembler.Add(other[0])
+	assert.Error(t, err)
+
+	_, err = EncodeKickstart(payload, 0)
+	assert.Error(t, err)
+}
+
+func testPGPEntity(t *testing.T, name string) (*openpgp.Entity, string) {
+	entity, err := openpgp.NewEntity(name, "", name+"@example.com", nil)
+	assert.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	w, err := armor.Encode(buf, openpgp.PublicKeyType, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, entity.Serialize(w))
+	assert.NoError(t, w.Close())
+	return entity, buf.String()
+}
+
+func testPGPSecretKey(t *testing.T, entity *openpgp.Entity) []byte {
+	buf := &bytes.Buffer{}
+	w, err := armor.Encode(buf, openpgp.PrivateKeyType, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, entity.SerializePrivate(w, nil))
+	assert.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func decryptKickstartWith(t *testing.T, encrypted []byte, entity *openpgp.Entity) *KickstartPayload {
+	block, err := armor.Decode(bytes.NewReader(encrypted))
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	md, err := openpgp.ReadMessage(block.Body, openpgp.EntityList{entity}, nil, nil)
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	cnt, err := ioutil.ReadAll(md.UnverifiedBody)
+	assert.NoError(t, err)
+
+	var payload *KickstartPayload
+	assert.NoError(t, json.Unmarshal(cnt, &payload))
+	return payload
+}
+
+func TestEncryptKickstart(t *testing.T) {
+	alice, aliceKey := testPGPEntity(t, "alice")
+	bob, bobKey := testPGPEntity(t, "bob")
+	eve, _ := testPGPEntity(t, "eve")
+
+	encrypted, err := EncryptKickstart([]byte(`{"genesis":"{}","p2p_addresses":null}`), []string{aliceKey, bobKey})
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(encrypted, []byte("-----BEGIN PGP MESSAGE-----")))
+
+	for _, entity := range []*openpgp.Entity{alice, bob} {
+		payload := decryptKickstartWith(t, encrypted, entity)
+		assert.Equal(t, "{}", payload.Genesis)
+	}
+
+	block, err := armor.Decode(bytes.NewReader(encrypted))
+	assert.NoError(t, err)
+	_, err = openpgp.ReadMessage(block.Body, openpgp.EntityList{eve}, nil, nil)
+	assert.Error(t, err)
+
+	_, err = EncryptKickstart([]byte("{}"), nil)
+	assert.Error(t, err)
+	_, err = EncryptKickstart([]byte("{}"), []string{"not a key"})
+	assert.Error(t, err)
+}
+
+func TestWriteEncryptedKickstart(t *testing.T) {
+	alice, aliceKey := testPGPEntity(t, "alice")
+	aliceFingerprint := hex.EncodeToString(alice.PrimaryKey.Fingerprint[:])
+
+	peer := func(account, key, fingerprint string) *Peer {
+		return &Peer{
+			Discovery: &disco.Discovery{
+				SeedNetworkAccountName: AN(account),
+				TargetP2PAddress:       account + ".example.com:9876",
+			},
+			PGPPublicKey:   key,
+			PGPFingerprint: fingerprint,
+		}
+	}
+	boot := peer("bootnode", "", "")
+
+	b := &BIOS{
+		Log:                NewLogger(),
+		Network:            &Network{MyPeer: boot},
+		AppointedProducers: 4,
+		TargetChainID:      bytes.Repeat([]byte{0xcf}, 32),
+		ShuffledProducers: []*Peer{
+			boot,
+			peer("alice", aliceKey, aliceFingerprint),
+			peer("nokey", "", ""),
+			peer("alice", aliceKey, aliceFingerprint), // cloned
+			peer("notappointed", aliceKey, aliceFingerprint),
+		},
+	}
+	assert.Len(t, b.appointedPeers(), 3)
+
+	dir, err := ioutil.TempDir("", "kickstart")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	cwd, err := os.Getwd()
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chdir(dir))
+	defer os.Chdir(cwd)
+
+	assert.NoError(t, b.writeEncryptedKickstart(`{"initial_key":"EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV"}`, "5KQwrPbwdL6PhXujxW37FSSQZ1JiwsST4cqQzDeyXtP79zkvFD3", []string{"alice.example.com:9876"}))
+
+	encrypted, err := ioutil.ReadFile("kickstart.pgp")
+	assert.NoError(t, err)
+	payload := decryptKickstartWith(t, encrypted, alice)
+	assert.Equal(t, strings.Repeat("cf", 32), payload.ChainID)
+	assert.Equal(t, "bootnode.example.com:9876", payload.BootP2PAddress)
+	assert.Equal(t, "5KQwrPbwdL6PhXujxW37FSSQZ1JiwsST4cqQzDeyXtP79zkvFD3", payload.EphemeralPrivateKey)
+	assert.Equal(t, []string{"alice.example.com:9876"}, payload.P2PAddresses)
+	assert.Contains(t, payload.Genesis, "initial_key")
+
+	b.ShuffledProducers[1].PGPPublicKey = ""
+	b.ShuffledProducers[3].PGPPublicKey = ""
+	assert.Error(t, b.writeEncryptedKickstart("{}", "", nil))
+}
+
+func TestKickstartIPFS(t *testing.T) {
+	alice, aliceKey := testPGPEntity(t, "alice")
+
+	payload, err := json.Marshal(&KickstartPayload{
+		Genesis:        `{"initial_key":"EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV"}`,
+		P2PAddresses:   []string{"alice.example.com:9876", "boot.example.com:9876"},
+		ChainID:        strings.Repeat("cf", 32),
+		BootP2PAddress: "boot.example.com:9876",
+	})
+	assert.NoError(t, err)
+	encrypted, err := EncryptKickstart(payload, []string{aliceKey})
+	assert.NoError(t, err)
+
+	var added []byte
+	ipfsNode := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v0/add":
+			file, _, err := r.FormFile("file")
+			assert.NoError(t, err)
+			added, _ = ioutil.ReadAll(file)
+			w.Write([]byte(`{"Name":"kickstart.pgp","Hash":"QmKickstart"}`))
+		case "/ipfs/QmKickstart":
+			w.Write(added)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ipfsNode.Close()
+
+	var pinned map[string]string
+	pinningService := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/psa/pins", r.URL.Path)
+		assert.Equal(t, "Bearer s3cr3t", r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&pinned))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer pinningService.Close()
+	defer os.Setenv(PinningServiceTokenEnv, os.Getenv(PinningServiceTokenEnv))
+	os.Setenv(PinningServiceTokenEnv, "s3cr3t")
+
+	boot := &BIOS{
+		Log:                     NewLogger(),
+		KickstartIPFSAPI:        strings.TrimPrefix(ipfsNode.URL, "http://"),
+		KickstartPinningService: pinningService.URL + "/psa",
+	}
+	cid, err := boot.publishKickstartIPFS(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "QmKickstart", cid)
+	assert.Equal(t, encrypted, added)
+	assert.Equal(t, "QmKickstart", pinned["cid"])
+
+	join := &BIOS{
+		Log:              NewLogger(),
+		Network:          &Network{ipfs: NewIPFS(ipfsNode.URL)},
+		KickstartKeyring: testPGPSecretKey(t, alice),
+	}
+	genesis, err := join.fetchKickstartIPFS("/ipfs/QmKickstart")
+	assert.NoError(t, err)
+	assert.Equal(t, "EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV", genesis.InitialKey)
+	assert.Equal(t, []string{"boot.example.com:9876", "alice.example.com:9876"}, join.kickstartP2PAddresses)
+
+	join.ChainID = &ChainIDConfig{Derivation: ChainIDExplicit}
+	join.TargetChainID = bytes.Repeat([]byte{0x01}, 32)
+	_, err = join.fetchKickstartIPFS("QmKickstart")
+	assert.Error(t, err)
+
+	_, err = join.fetchKickstartIPFS("QmKickstart/../other")
+	assert.Error(t, err)
+}
+
+func TestConsumeKickstartFile(t *testing.T) {
+	alice, aliceKey := testPGPEntity(t, "alice")
+	eve, _ := testPGPEntity(t, "eve")
+
+	dir, err := ioutil.TempDir("", "kickstart")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writePayload := func(payload *KickstartPayload) string {
+		cnt, err := json.Marshal(payload)
+		assert.NoError(t, err)
+		encrypted, err := EncryptKickstart(cnt, []string{aliceKey})
+		assert.NoError(t, err)
+		filename := dir + "/kickstart.pgp"
+		assert.NoError(t, ioutil.WriteFile(filename, encrypted, 0600))
+		return filename
+	}
+
+	b := &BIOS{
+		Log:              NewLogger(),
+		KickstartKeyring: testPGPSecretKey(t, alice),
+		KickstartFile: writePayload(&KickstartPayload{
+			Genesis:             `{"initial_key":"EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV"}`,
+			BootP2PAddress:      "boot.example.com:9876",
+			EphemeralPrivateKey: "5KQwrPbwdL6PhXujxW37FSSQZ1JiwsST4cqQzDeyXtP79zkvFD3",
+		}),
+	}
+	genesis, err := b.kickstartGenesis()
+	assert.NoError(t, err)
+	assert.Equal(t, "EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV", genesis.InitialKey)
+	assert.Equal(t, []string{"boot.example.com:9876"}, b.kickstartP2PAddresses)
+
+	// The ephemeral key doesn't match the genesis
+	b.KickstartFile = writePayload(&KickstartPayload{
+		Genesis:             `{"initial_key":"EOS5cujNHGMYZZ2tgByyNEUaoPLFhZVmGXbZc9BLJeQkKZFqGYEiQ"}`,
+		EphemeralPrivateKey: "5KQwrPbwdL6PhXujxW37FSSQZ1JiwsST4cqQzDeyXtP79zkvFD3",
+	})
+	_, err = b.kickstartGenesis()
+	assert.Error(t, err)
+
+	// Not encrypted to us
+	b.KickstartKeyring = testPGPSecretKey(t, eve)
+	_, err = b.kickstartGenesis()
+	assert.Error(t, err)
+
+	b.KickstartFile = dir + "/missing.pgp"
+	_, err = b.kickstartGenesis()
+	assert.Error(t, err)
+}
@@ -0,0 +1,100 @@
+package bios
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// PinningServiceTokenEnv holds the access token of the IPFS pinning
+// service the kickstart payload is pinned to.
+const PinningServiceTokenEnv = "EOS_BIOS_PINNING_SERVICE_TOKEN"
+
+// publishKickstartIPFS adds the encrypted kickstart payload to the
+// IPFS node at KickstartIPFSAPI and, when set, pins it with the
+// KickstartPinningService. The boot node then only has to hand out
+// its CID.
+func (b *BIOS) publishKickstartIPFS(encrypted []byte) (string, error) {
+	store := &ipfsArtifactStore{apiURL: ipfsAPIURL(b.KickstartIPFSAPI)}
+	location, err := store.Put("kickstart.pgp", encrypted)
+	if err != nil {
+		return "", fmt.Errorf("adding to IPFS: %s", err)
+	}
+	cid := strings.TrimPrefix(location, "/ipfs/")
+
+	if b.KickstartPinningService != "" {
+		if err := pinRemotely(b.KickstartPinningService, cid, "eos-bios kickstart.pgp"); err != nil {
+			return "", fmt.Errorf("pinning: %s", err)
+		}
+		b.Log.Printf("Kickstart payload pinned with %s\n", b.KickstartPinningService)
+	}
+
+	b.Log.Println("")
+	b.Log.Printf("Kickstart payload published to IPFS, communicate its CID to the appointed producers: %s\n", cid)
+	b.Log.Printf("They join with: --kickstart-ipfs=%s\n", cid)
+	b.Log.Println("")
+
+	return cid, nil
+}
+
+func ipfsAPIURL(address string) string {
+	if strings.HasPrefix(address, "http://") || strings.HasPrefix(address, "https://") {
+		return strings.TrimSuffix(address, "/")
+	}
+	return "http://" + address
+}
+
+// pinRemotely asks a service implementing the IPFS Pinning Service
+// API (at `endpoint`, like https://api.pinata.cloud/psa) to pin `cid`.
+// The service fetches the content from the IPFS network by itself.
+func pinRemotely(endpoint, cid, name string) error {
+	body, err := json.Marshal(map[string]string{"cid": cid, "name": name})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", strings.TrimSuffix(endpoint, "/")+"/pins", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token := os.Getenv(PinningServiceTokenEnv); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
+		cnt, _ := ioutil.ReadAll(resp.Body)
+		if len(cnt) > 200 {
+			cnt = cnt[:200]
+		}
+		return fmt.Errorf("%s returned status %d: %q", endpoint, resp.StatusCode, cnt)
+	}
+	return nil
+}
+
+// fetchKickstartIPFS fetches the encrypted kickstart payload with CID
+// `cid` from the IPFS gateways, and consumes it (see consumeKickstart).
+func (b *BIOS) fetchKickstartIPFS(cid string) (*GenesisJSON, error) {
+	cid = strings.TrimPrefix(strings.TrimSpace(cid), "/ipfs/")
+	if cid == "" || strings.ContainsAny(cid, "/?#") {
+		return nil, fmt.Errorf("invalid CID %q", cid)
+	}
+
+	b.Log.Printf("Fetching the kickstart payload /ipfs/%s\n", cid)
+	encrypted, err := b.Network.ipfs.Get("/ipfs/" + cid)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.consumeKickstart(encrypted)
+}
@@ -0,0 +1,107 @@
+package bios
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+)
+
+// parseLaunchTimeUTC parses the boot sequence's `launch_time_utc`, an
+// RFC 3339 timestamp which must be in UTC, so nobody reads it in their
+// own time zone.
+func parseLaunchTimeUTC(launchTime string) (time.Time, error) {
+	if launchTime == "" {
+		return time.Time{}, nil
+	}
+	if !strings.HasSuffix(launchTime, "Z") {
+		return time.Time{}, fmt.Errorf("launch_time_utc %q must be in UTC, ending with Z (ex: 2018-06-03T12:00:00Z)", launchTime)
+	}
+	at, err := time.Parse(time.RFC3339, launchTime)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("launch_time_utc: %s", err)
+	}
+	return at, nil
+}
+
+var (
+	launchTimeNow   = time.Now
+	launchTimeSleep = time.Sleep
+)
+
+// launchTimeSigner refuses to sign anything before the launch time. It
+// is installed on the APIs of the boot node before the boot sequence
+// runs, so no code path can push a transaction early.
+type launchTimeSigner struct {
+	eos.Signer
+	at time.Time
+}
+
+func (s *launchTimeSigner) Sign(tx *eos.SignedTransaction, chainID []byte, requiredKeys ...ecc.PublicKey) (*eos.SignedTransaction, error) {
+	if now := launchTimeNow(); now.Before(s.at) {
+		return nil, fmt.Errorf("refusing to sign before the launch time, %s (%s to go)", s.at.Format(time.RFC3339), s.at.Sub(now).Round(time.Second))
+	}
+	return s.Signer.Sign(tx, chainID, requiredKeys...)
+}
+
+// guardLaunchTime installs a launchTimeSigner on our APIs, when the
+// boot sequence has a `launch_time_utc`.
+func (b *BIOS) guardLaunchTime() {
+	if b.LaunchTime.IsZero() {
+		return
+	}
+	b.setTargetSigner(&launchTimeSigner{Signer: b.TargetNetAPI.Signer, at: b.LaunchTime})
+	if api := b.Network.SeedNetAPI; api != nil {
+		api.Signer = &launchTimeSigner{Signer: api.Signer, at: b.LaunchTime}
+	}
+}
+
+// waitLaunchTime sleeps until the launch time, counting down, and
+// refreshing the readiness board every minute or more. It wakes up
+// often enough for `/healthz` to see it's alive.
+func (b *BIOS) waitLaunchTime() {
+	if b.LaunchTime.IsZero() {
+		return
+	}
+
+	b.status.phase("waiting for launch time")
+	for {
+		b.markProgress("waiting for launch time")
+
+		remaining := b.LaunchTime.Sub(launchTimeNow())
+		if remaining <= 0 {
+			b.Log.Printf("Launch time %s reached\n", b.LaunchTime.Format(time.RFC3339))
+			return
+		}
+
+		b.Log.Printf("Launching at %s, in %s\n", b.LaunchTime.Format(time.RFC3339), remaining.Round(time.Second))
+		step := countdownStep(remaining)
+		if step > HealthStallTimeout/2 {
+			step = HealthStallTimeout / 2
+		}
+		if step > remaining {
+			step = remaining
+		}
+		launchTimeSleep(step)
+
+		if step >= time.Minute {
+			b.showReadinessBoard()
+		}
+	}
+}
+
+// countdownStep is how often the countdown is logged, more often as
+// the launch time gets closer.
+func countdownStep(remaining time.Duration) time.Duration {
+	switch {
+	case remaining > time.Hour:
+		return 10 * time.Minute
+	case remaining > 10*time.Minute:
+		return time.Minute
+	case remaining > time.Minute:
+		return 10 * time.Second
+	}
+	return time.Second
+}
@@ -0,0 +1,56 @@
+package bios
+
+import (
+	"testing"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLaunchTimeUTC(t *testing.T) {
+	at, err := parseLaunchTimeUTC("2018-06-03T12:00:00Z")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2018, 6, 3, 12, 0, 0, 0, time.UTC), at.UTC())
+
+	at, err = parseLaunchTimeUTC("")
+	assert.NoError(t, err)
+	assert.True(t, at.IsZero())
+
+	_, err = parseLaunchTimeUTC("2018-06-03T12:00:00+02:00")
+	assert.Error(t, err)
+	_, err = parseLaunchTimeUTC("June 3rd, noon Z")
+	assert.Error(t, err)
+}
+
+func TestWaitLaunchTime(t *testing.T) {
+	defer func(now func() time.Time, sleep func(time.Duration)) {
+		launchTimeNow, launchTimeSleep = now, sleep
+	}(launchTimeNow, launchTimeSleep)
+
+	now := time.Date(2018, 6, 3, 10, 0, 0, 0, time.UTC)
+	var slept []time.Duration
+	launchTimeNow = func() time.Time { return now }
+	launchTimeSleep = func(d time.Duration) {
+		slept = append(slept, d)
+		now = now.Add(d)
+	}
+
+	launchTime := now.Add(time.Hour + 30*time.Second)
+	b := &BIOS{Log: NewLogger(), LaunchTime: launchTime}
+
+	signer := &launchTimeSigner{Signer: eos.NewKeyBag(), at: launchTime}
+	_, err := signer.Sign(&eos.SignedTransaction{}, nil)
+	assert.EqualError(t, err, "refusing to sign before the launch time, 2018-06-03T11:00:30Z (1h0m30s to go)")
+
+	b.waitLaunchTime()
+	assert.Equal(t, launchTime, now)
+	assert.Equal(t, HealthStallTimeout/2, slept[0])
+	assert.Equal(t, time.Second, slept[len(slept)-1])
+	_, what := b.lastProgress()
+	assert.Equal(t, "waiting for launch time", what)
+
+	if _, err = signer.Sign(&eos.SignedTransaction{}, nil); err != nil {
+		assert.NotContains(t, err.Error(), "refusing to sign")
+	}
+}
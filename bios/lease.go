@@ -0,0 +1,131 @@
+package bios
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+	"github.com/eoscanada/eos-go/system"
+)
+
+// BootLeaseAccount marks a target chain as being booted. Before
+// pushing the boot sequence, it is created with a key unique to the
+// operator (kept in `lease.key`, next to `genesis.key`) as its active
+// permission, so that two operators sharing a genesis can't run
+// competing boots against the same chain. Its owner is `eosio@active`,
+// and `system.resign_accounts` hands it over with the system accounts.
+var BootLeaseAccount = AN("eosio.lease")
+
+const bootLeaseKeyFile = "lease.key"
+
+// isBootLeaseMarker tells whether an action is the creation of the
+// boot lease marker, which isn't part of the launch data.
+func isBootLeaseMarker(act *eos.Action) bool {
+	if act.Account != AN("eosio") || act.Name != eos.ActN("newaccount") {
+		return false
+	}
+	var data system.NewAccount
+	return decodeActionData(act, &data) && data.Name == BootLeaseAccount
+}
+
+// bootLeaseKey loads the operator's lease key, or generates it on the
+// first boot from this directory.
+func (b *BIOS) bootLeaseKey() (*ecc.PrivateKey, error) {
+	if _, err := os.Stat(bootLeaseKeyFile); err == nil {
+		return readPrivKeyFromFile(bootLeaseKeyFile)
+	}
+
+	key, err := ecc.NewRandomPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	b.writeToFile(bootLeaseKeyFile, key.String())
+	return key, nil
+}
+
+// newBootLeaseMarker creates the lease account, held by `key`.
+func newBootLeaseMarker(key ecc.PublicKey) *eos.Action {
+	act := system.NewNewAccount(AN("eosio"), BootLeaseAccount, key)
+	act.ActionData = eos.NewActionData(system.NewAccount{
+		Creator: AN("eosio"),
+		Name:    BootLeaseAccount,
+		Owner:   permissionAuthority(AN("eosio")),
+		Active: eos.Authority{
+			Threshold: 1,
+			Keys:      []eos.KeyWeight{{PublicKey: key, Weight: 1}},
+		},
+	})
+	return act
+}
+
+// bootLeaseHolder returns the public key holding the lease on the
+// target chain, if any: the key of its active permission, or of its
+// owner for leases written before. Only an unknown account means
+// there's no lease: other errors, like the node being unreachable, are
+// returned.
+func (b *BIOS) bootLeaseHolder() (holder string, found bool, err error) {
+	resp, err := b.TargetNetAPI.GetAccount(BootLeaseAccount)
+	if err != nil {
+		if isUnknownAccount(err) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+
+	for _, name := range []string{"active", "owner"} {
+		for _, perm := range resp.Permissions {
+			if perm.PermName == name && len(perm.RequiredAuth.Keys) != 0 {
+				return perm.RequiredAuth.Keys[0].PublicKey.String(), true, nil
+			}
+		}
+	}
+	return "", true, nil
+}
+
+// isUnknownAccount tells whether `get_account` failed because the
+// account doesn't exist, which nodeos reports as an `unknown key`.
+func isUnknownAccount(err error) bool {
+	return strings.Contains(err.Error(), "unknown key")
+}
+
+// acquireBootLease writes the lease marker to the target chain, or
+// checks we're the ones holding it when it's already there (like when
+// resuming with `--reuse-genesis`).
+func (b *BIOS) acquireBootLease() error {
+	key, err := b.bootLeaseKey()
+	if err != nil {
+		return fmt.Errorf("lease key: %s", err)
+	}
+	ours := key.PublicKey().String()
+
+	holder, found, err := b.bootLeaseHolder()
+	if err != nil {
+		return fmt.Errorf("reading lease marker: %s", err)
+	}
+	if !found {
+		_, pushErr := b.TargetNetAPI.SignPushActions(newBootLeaseMarker(key.PublicKey()))
+
+		// Someone else might have won the race, check again either way.
+		holder, found, err = b.bootLeaseHolder()
+		if err != nil {
+			return fmt.Errorf("reading lease marker: %s", err)
+		}
+		if !found {
+			return fmt.Errorf("writing lease marker: %s", pushErr)
+		}
+	}
+
+	if holder == ours {
+		b.Log.Printf("Holding the boot lease on the target chain (%s)\n", ours)
+		return nil
+	}
+
+	if b.BreakBootLease {
+		b.Log.Printf("WARNING: target chain is leased to another operator (%s), proceeding anyway as requested\n", holder)
+		return nil
+	}
+
+	return fmt.Errorf("another operator (lease key %s) is already booting this chain, our lease key is %s from %q. Coordinate with them, or use --break-lease if that boot is abandoned", holder, ours, bootLeaseKeyFile)
+}
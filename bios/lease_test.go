@@ -0,0 +1,62 @@
+package bios
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+	"github.com/eoscanada/eos-go/system"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsBootLeaseMarker(t *testing.T) {
+	key, err := ecc.NewRandomPrivateKey()
+	assert.NoError(t, err)
+
+	assert.True(t, isBootLeaseMarker(system.NewNewAccount(AN("eosio"), BootLeaseAccount, key.PublicKey())))
+	assert.True(t, isBootLeaseMarker(newBootLeaseMarker(key.PublicKey())))
+
+	// Resigned with the system accounts, by `eosio`.
+	data := newBootLeaseMarker(key.PublicKey()).ActionData.Data.(system.NewAccount)
+	assert.True(t, sameAuthority(permissionAuthority(AN("eosio")), data.Owner))
+	if assert.Len(t, data.Active.Keys, 1) {
+		assert.Equal(t, key.PublicKey().String(), data.Active.Keys[0].PublicKey.String())
+	}
+	assert.False(t, isBootLeaseMarker(system.NewNewAccount(AN("eosio"), AN("eosio.token"), key.PublicKey())))
+	assert.False(t, isBootLeaseMarker(&eos.Action{Account: AN("eosio"), Name: eos.ActN("setcode")}))
+}
+
+func TestBootLeaseHolder(t *testing.T) {
+	key, err := ecc.NewRandomPrivateKey()
+	assert.NoError(t, err)
+
+	leased := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !leased {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"code": 500, "message": "Internal Service Error", "error": {"name": "exception", "what": "unspecified", "details": [{"message": "unknown key (eosio::chain::name): eosio.lease"}]}}`)
+			return
+		}
+		fmt.Fprintf(w, `{"permissions": [{"perm_name": "active", "required_auth": {"threshold": 1, "keys": [{"key": %q, "weight": 1}]}}, {"perm_name": "owner", "required_auth": {"threshold": 1, "accounts": [{"permission": {"actor": "eosio", "permission": "active"}, "weight": 1}]}}]}`, key.PublicKey().String())
+	}))
+
+	b := &BIOS{Log: NewLogger(), TargetNetAPI: eos.New(server.URL)}
+	_, found, err := b.bootLeaseHolder()
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	leased = true
+	holder, found, err := b.bootLeaseHolder()
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, key.PublicKey().String(), holder)
+
+	// An unreachable node isn't a missing lease.
+	server.Close()
+	_, found, err = b.bootLeaseHolder()
+	assert.Error(t, err)
+	assert.False(t, found)
+}
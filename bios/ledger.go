@@ -0,0 +1,418 @@
+package bios
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+	"github.com/karalabe/hid"
+)
+
+// LedgerSigner signs with the EOS app of a Ledger device, plugged in
+// over USB. Each transaction is shown, and must be approved, on the
+// device. The EOS app only signs the actions it knows how to display,
+// unless its "arbitrary data" setting is on.
+type LedgerSigner struct {
+	// Path is the BIP32 derivation path of the key.
+	Path []uint32
+
+	transport ledgerTransport
+	publicKey *ecc.PublicKey
+}
+
+// DefaultLedgerPath is the derivation path of the first EOS key of a
+// Ledger device, as used by the wallets supporting it.
+const DefaultLedgerPath = "44'/194'/0'/0/0"
+
+const (
+	ledgerVendorID = 0x2c97
+
+	ledgerCLA             = 0xd4
+	ledgerInsGetPublicKey = 0x02
+	ledgerInsSign         = 0x04
+	ledgerSignFirstChunk  = 0x00
+	ledgerSignMoreChunks  = 0x80
+	ledgerSignChunkSize   = 150
+	ledgerPacketSize      = 64
+	ledgerChannel         = 0x0101
+	ledgerTagAPDU         = 0x05
+	ledgerStatusOK        = 0x9000
+	ledgerStatusDenied    = 0x6985
+	ledgerStatusWrongINS  = 0x6d00
+	ledgerStatusWrongCLA  = 0x6e00
+	ledgerStatusSecurity  = 0x6982
+	ledgerStatusLocked    = 0x5515
+)
+
+// ledgerTransport exchanges APDUs with a device.
+type ledgerTransport interface {
+	Exchange(apdu []byte) ([]byte, error)
+}
+
+// NewLedgerSigner creates a signer for the key at `path` (like
+// `44'/194'/0'/0/0`, DefaultLedgerPath when empty) of the first Ledger
+// device found. The device is only opened when first used.
+func NewLedgerSigner(path string) (*LedgerSigner, error) {
+	if path == "" {
+		path = DefaultLedgerPath
+	}
+	bip32, err := ParseBIP32Path(path)
+	if err != nil {
+		return nil, err
+	}
+	return &LedgerSigner{Path: bip32}, nil
+}
+
+// ParseBIP32Path parses a derivation path like `44'/194'/0'/0/0`,
+// where `'` marks hardened indexes.
+func ParseBIP32Path(path string) (out []uint32, err error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "m"), "/")
+	if path == "" {
+		return nil, fmt.Errorf("empty derivation path")
+	}
+
+	for _, part := range strings.Split(path, "/") {
+		hardened := strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h")
+		if hardened {
+			part = part[:len(part)-1]
+		}
+		index, err := strconv.ParseUint(part, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("invalid derivation path %q: %s", path, err)
+		}
+		if hardened {
+			index += 0x80000000
+		}
+		out = append(out, uint32(index))
+	}
+
+	if len(out) > 10 {
+		return nil, fmt.Errorf("invalid derivation path %q: too deep", path)
+	}
+	return out, nil
+}
+
+func (s *LedgerSigner) AvailableKeys() (out []ecc.PublicKey, err error) {
+	key, err := s.loadPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	return []ecc.PublicKey{*key}, nil
+}
+
+func (s *LedgerSigner) ImportPrivateKey(wifPrivKey string) error {
+	return fmt.Errorf("ledger signer doesn't import private keys")
+}
+
+func (s *LedgerSigner) Sign(tx *eos.SignedTransaction, chainID []byte, requiredKeys ...ecc.PublicKey) (*eos.SignedTransaction, error) {
+	transport, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := LedgerTransaction(tx.Transaction, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("ledger: %s", err)
+	}
+	payload = append(s.encodePath(), payload...)
+
+	var resp []byte
+	for offset := 0; offset < len(payload); offset += ledgerSignChunkSize {
+		end := offset + ledgerSignChunkSize
+		if end > len(payload) {
+			end = len(payload)
+		}
+		p1 := byte(ledgerSignMoreChunks)
+		if offset == 0 {
+			p1 = ledgerSignFirstChunk
+		}
+
+		resp, err = transport.Exchange(ledgerAPDU(ledgerInsSign, p1, 0x00, payload[offset:end]))
+		if err != nil {
+			return nil, fmt.Errorf("ledger: signing: %s", err)
+		}
+	}
+
+	if len(resp) != 65 {
+		return nil, fmt.Errorf("ledger: signing: unexpected response of %d bytes", len(resp))
+	}
+
+	// The EOS app answers with a compact, canonical signature: its
+	// recovery header, then r and s.
+	sig, err := ecc.NewSignature("SIG_K1_" + base58Check(resp, "K1"))
+	if err != nil {
+		return nil, fmt.Errorf("ledger: %s", err)
+	}
+
+	tx.Signatures = append(tx.Signatures, sig)
+	return tx, nil
+}
+
+func (s *LedgerSigner) loadPublicKey() (*ecc.PublicKey, error) {
+	if s.publicKey != nil {
+		return s.publicKey, nil
+	}
+
+	transport, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := transport.Exchange(ledgerAPDU(ledgerInsGetPublicKey, 0x00, 0x00, s.encodePath()))
+	if err != nil {
+		return nil, fmt.Errorf("ledger: getting public key: %s", err)
+	}
+
+	// The response is the length of the uncompressed public key (65),
+	// the key, then its address as the app displays it.
+	if len(resp) < 66 || resp[0] != 65 || resp[1] != 0x04 {
+		return nil, fmt.Errorf("ledger: getting public key: unexpected response")
+	}
+	compressed := append([]byte{0x02 | resp[65]&1}, resp[2:34]...)
+
+	key, err := ecc.NewPublicKey("EOS" + base58Check(compressed, ""))
+	if err != nil {
+		return nil, fmt.Errorf("ledger: %s", err)
+	}
+
+	s.publicKey = &key
+	return s.publicKey, nil
+}
+
+func (s *LedgerSigner) open() (ledgerTransport, error) {
+	if s.transport != nil {
+		return s.transport, nil
+	}
+
+	transport, err := openLedgerHID()
+	if err != nil {
+		return nil, err
+	}
+
+	s.transport = transport
+	return transport, nil
+}
+
+func (s *LedgerSigner) encodePath() []byte {
+	out := []byte{byte(len(s.Path))}
+	for _, index := range s.Path {
+		out = append(out, byte(index>>24), byte(index>>16), byte(index>>8), byte(index))
+	}
+	return out
+}
+
+func ledgerAPDU(ins, p1, p2 byte, data []byte) []byte {
+	return append([]byte{ledgerCLA, ins, p1, p2, byte(len(data))}, data...)
+}
+
+// LedgerTransaction serializes `tx` the way the EOS app of a Ledger
+// parses it: each field in its binary form, prefixed with an ASN.1
+// octet string header, starting with the chain ID and ending with the
+// (empty) context-free data's hash. The device signs the digest of the
+// fields' values, which is the transaction's signing digest.
+func LedgerTransaction(tx *eos.Transaction, chainID []byte) ([]byte, error) {
+	if len(chainID) != 32 {
+		return nil, fmt.Errorf("invalid chain ID of %d bytes", len(chainID))
+	}
+	if len(tx.ContextFreeActions) != 0 {
+		return nil, fmt.Errorf("context-free actions are not supported")
+	}
+
+	var buf bytes.Buffer
+	field := func(value []byte) {
+		buf.WriteByte(0x04)
+		switch l := len(value); {
+		case l < 0x80:
+			buf.WriteByte(byte(l))
+		case l <= 0xff:
+			buf.Write([]byte{0x81, byte(l)})
+		default:
+			buf.Write([]byte{0x82, byte(l >> 8), byte(l)})
+		}
+		buf.Write(value)
+	}
+	name := func(n string) error {
+		val, err := eos.StringToName(n)
+		if err != nil {
+			return fmt.Errorf("invalid name %q: %s", n, err)
+		}
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], val)
+		field(b[:])
+		return nil
+	}
+
+	var expiration [4]byte
+	binary.LittleEndian.PutUint32(expiration[:], uint32(tx.Expiration.Unix()))
+	var refBlockNum [2]byte
+	binary.LittleEndian.PutUint16(refBlockNum[:], tx.RefBlockNum)
+	var refBlockPrefix [4]byte
+	binary.LittleEndian.PutUint32(refBlockPrefix[:], tx.RefBlockPrefix)
+
+	field(chainID)
+	field(expiration[:])
+	field(refBlockNum[:])
+	field(refBlockPrefix[:])
+	field(varuint32(uint32(tx.MaxNetUsageWords)))
+	field([]byte{tx.MaxCPUUsageMS})
+	field(varuint32(uint32(tx.DelaySec)))
+	field(varuint32(0))
+	field(varuint32(uint32(len(tx.Actions))))
+
+	for _, act := range tx.Actions {
+		if err := name(string(act.Account)); err != nil {
+			return nil, err
+		}
+		if err := name(string(act.Name)); err != nil {
+			return nil, err
+		}
+
+		field(varuint32(uint32(len(act.Authorization))))
+		for _, perm := range act.Authorization {
+			if err := name(string(perm.Actor)); err != nil {
+				return nil, err
+			}
+			if err := name(string(perm.Permission)); err != nil {
+				return nil, err
+			}
+		}
+
+		data := []byte(act.HexData)
+		if len(data) == 0 && act.Data != nil {
+			var err error
+			data, err = eos.MarshalBinary(act.Data)
+			if err != nil {
+				return nil, fmt.Errorf("encoding %s::%s data: %s", act.Account, act.Name, err)
+			}
+		}
+		if len(data) > 0xffff {
+			return nil, fmt.Errorf("%s::%s data too large for the device", act.Account, act.Name)
+		}
+		field(varuint32(uint32(len(data))))
+		field(data)
+	}
+
+	field(varuint32(0))
+	field(make([]byte, 32))
+
+	return buf.Bytes(), nil
+}
+
+func varuint32(v uint32) (out []byte) {
+	for v >= 0x80 {
+		out = append(out, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(out, byte(v))
+}
+
+// ledgerFrames splits an APDU in the HID packets of the Ledger
+// transport: channel, tag and sequence number, the APDU's length in
+// the first one, and the APDU, zero padded.
+func ledgerFrames(apdu []byte) (out [][]byte) {
+	data := make([]byte, 2+len(apdu))
+	binary.BigEndian.PutUint16(data, uint16(len(apdu)))
+	copy(data[2:], apdu)
+
+	for seq := 0; len(data) > 0; seq++ {
+		packet := make([]byte, ledgerPacketSize)
+		binary.BigEndian.PutUint16(packet[0:], ledgerChannel)
+		packet[2] = ledgerTagAPDU
+		binary.BigEndian.PutUint16(packet[3:], uint16(seq))
+
+		n := copy(packet[5:], data)
+		data = data[n:]
+		out = append(out, packet)
+	}
+	return
+}
+
+// readLedgerFrames reassembles a response from the HID packets read
+// with `read`, and checks its status word.
+func readLedgerFrames(read func() ([]byte, error)) ([]byte, error) {
+	var resp []byte
+	total := -1
+
+	for seq := 0; total < 0 || len(resp) < total; seq++ {
+		packet, err := read()
+		if err != nil {
+			return nil, err
+		}
+		if len(packet) < 7 || binary.BigEndian.Uint16(packet[0:]) != ledgerChannel || packet[2] != ledgerTagAPDU {
+			return nil, fmt.Errorf("invalid response packet")
+		}
+		if int(binary.BigEndian.Uint16(packet[3:])) != seq {
+			return nil, fmt.Errorf("response packet %d out of sequence", seq)
+		}
+
+		data := packet[5:]
+		if seq == 0 {
+			total = int(binary.BigEndian.Uint16(data))
+			data = data[2:]
+		}
+		resp = append(resp, data...)
+	}
+	resp = resp[:total]
+
+	if len(resp) < 2 {
+		return nil, fmt.Errorf("response without status")
+	}
+	status := binary.BigEndian.Uint16(resp[len(resp)-2:])
+	switch status {
+	case ledgerStatusOK:
+		return resp[:len(resp)-2], nil
+	case ledgerStatusDenied:
+		return nil, fmt.Errorf("denied on the device")
+	case ledgerStatusWrongCLA, ledgerStatusWrongINS:
+		return nil, fmt.Errorf("the EOS app isn't open on the device")
+	case ledgerStatusSecurity, ledgerStatusLocked:
+		return nil, fmt.Errorf("the device is locked")
+	}
+	return nil, fmt.Errorf("device error, status 0x%04x", status)
+}
+
+type ledgerHID struct {
+	device *hid.Device
+}
+
+func openLedgerHID() (*ledgerHID, error) {
+	if !hid.Supported() {
+		return nil, fmt.Errorf("ledger: USB HID isn't supported by this build (it needs cgo)")
+	}
+
+	for _, info := range hid.Enumerate(ledgerVendorID, 0) {
+		// The APDU interface is the first one, or has its own usage
+		// page, depending on the platform.
+		if info.Interface != 0 && info.UsagePage != 0xffa0 {
+			continue
+		}
+		device, err := info.Open()
+		if err != nil {
+			return nil, fmt.Errorf("ledger: opening %s: %s", info.Product, err)
+		}
+		return &ledgerHID{device: device}, nil
+	}
+
+	return nil, fmt.Errorf("ledger: no device found, is it plugged in and unlocked?")
+}
+
+func (l *ledgerHID) Exchange(apdu []byte) ([]byte, error) {
+	for _, packet := range ledgerFrames(apdu) {
+		if _, err := l.device.Write(packet); err != nil {
+			return nil, err
+		}
+	}
+
+	return readLedgerFrames(func() ([]byte, error) {
+		packet := make([]byte, ledgerPacketSize)
+		n, err := l.device.Read(packet)
+		if err != nil {
+			return nil, err
+		}
+		return packet[:n], nil
+	})
+}
@@ -0,0 +1,180 @@
+package bios
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"testing"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBIP32Path(t *testing.T) {
+	path, err := ParseBIP32Path(DefaultLedgerPath)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{0x8000002c, 0x800000c2, 0x80000000, 0, 0}, path)
+
+	path, err = ParseBIP32Path("m/44h/194h/0h/0/3")
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{0x8000002c, 0x800000c2, 0x80000000, 0, 3}, path)
+
+	for _, invalid := range []string{"", "m/", "44'/x/0", "44'//0", "2147483648/0", "0/0/0/0/0/0/0/0/0/0/0"} {
+		_, err := ParseBIP32Path(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestLedgerFrames(t *testing.T) {
+	apdu := bytes.Repeat([]byte{0xab}, 200)
+
+	frames := ledgerFrames(apdu)
+	assert.Len(t, frames, 4)
+	for seq, frame := range frames {
+		assert.Len(t, frame, ledgerPacketSize)
+		assert.Equal(t, []byte{0x01, 0x01, 0x05, 0x00, byte(seq)}, frame[:5])
+	}
+	assert.Equal(t, []byte{0x00, 200}, frames[0][5:7])
+
+	read := func(frames [][]byte) func() ([]byte, error) {
+		return func() ([]byte, error) {
+			if len(frames) == 0 {
+				return nil, fmt.Errorf("no more packets")
+			}
+			frame := frames[0]
+			frames = frames[1:]
+			return frame, nil
+		}
+	}
+
+	resp, err := readLedgerFrames(read(ledgerFrames(append(apdu, 0x90, 0x00))))
+	assert.NoError(t, err)
+	assert.Equal(t, apdu, resp)
+
+	_, err = readLedgerFrames(read(ledgerFrames([]byte{0x69, 0x85})))
+	assert.EqualError(t, err, "denied on the device")
+
+	_, err = readLedgerFrames(read(ledgerFrames([]byte{0x6e, 0x00})))
+	assert.EqualError(t, err, "the EOS app isn't open on the device")
+
+	outOfSequence := ledgerFrames(append(apdu, 0x90, 0x00))
+	outOfSequence[1], outOfSequence[2] = outOfSequence[2], outOfSequence[1]
+	_, err = readLedgerFrames(read(outOfSequence))
+	assert.Error(t, err)
+}
+
+func ledgerTestTransaction() *eos.Transaction {
+	tx := &eos.Transaction{
+		Actions: []*eos.Action{
+			{
+				Account:       "eosio.token",
+				Name:          "transfer",
+				Authorization: []eos.PermissionLevel{{Actor: "eosio", Permission: "active"}},
+				ActionData:    eos.ActionData{HexData: eos.HexBytes{0x01, 0x02, 0x03}},
+			},
+		},
+	}
+	tx.Expiration = eos.JSONTime{Time: time.Unix(0x5b000000, 0)}
+	tx.RefBlockNum = 0x1234
+	tx.RefBlockPrefix = 0xdeadbeef
+	return tx
+}
+
+func TestLedgerTransaction(t *testing.T) {
+	chainID := bytes.Repeat([]byte{0xcf}, 32)
+
+	out, err := LedgerTransaction(ledgerTestTransaction(), chainID)
+	assert.NoError(t, err)
+
+	assert.Equal(t, append([]byte{0x04, 0x20}, chainID...), out[:34])
+	out = out[34:]
+	assert.Equal(t, "04040000005b"+"04023412"+"0404efbeadde"+"040100"+"040100"+"040100"+"040100"+"040101", hex.EncodeToString(out[:31]))
+	out = out[31:]
+
+	// account, name, one authorization, then the data's length and data
+	assert.Equal(t, []byte{0x04, 0x08}, out[:2])
+	out = out[10+10+3+10+10:]
+	assert.Equal(t, "040103"+"0403010203", hex.EncodeToString(out[:8]))
+	out = out[8:]
+
+	assert.Equal(t, append([]byte{0x04, 0x01, 0x00, 0x04, 0x20}, make([]byte, 32)...), out)
+
+	_, err = LedgerTransaction(ledgerTestTransaction(), []byte{0x01})
+	assert.Error(t, err)
+
+	tx := ledgerTestTransaction()
+	tx.ContextFreeActions = tx.Actions
+	_, err = LedgerTransaction(tx, chainID)
+	assert.Error(t, err)
+
+	tx = ledgerTestTransaction()
+	tx.Actions[0].HexData = make([]byte, 300)
+	out, err = LedgerTransaction(tx, chainID)
+	assert.NoError(t, err)
+	assert.True(t, bytes.Contains(out, []byte{0x04, 0x82, 0x01, 0x2c}))
+}
+
+type fakeLedger struct {
+	apdus     [][]byte
+	responses [][]byte
+}
+
+func (l *fakeLedger) Exchange(apdu []byte) ([]byte, error) {
+	l.apdus = append(l.apdus, apdu)
+	if len(l.responses) == 0 {
+		return nil, fmt.Errorf("unexpected APDU")
+	}
+	resp := l.responses[0]
+	l.responses = l.responses[1:]
+	return resp, nil
+}
+
+func TestLedgerSigner(t *testing.T) {
+	signer, err := NewLedgerSigner("")
+	assert.NoError(t, err)
+
+	// The public key of the private key 1.
+	pubKey, _ := hex.DecodeString("0479be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8")
+	device := &fakeLedger{responses: [][]byte{append([]byte{65}, pubKey...)}}
+	signer.transport = device
+
+	keys, err := signer.AvailableKeys()
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+	assert.Equal(t, "EOS5p78kHbL33Rn3JWkTWRE2B9uz6gy4r1KbfAKLNQGE3ovMBS5bu", keys[0].String())
+	assert.Equal(t, "d402000015058000002c800000c2800000000000000000000000", hex.EncodeToString(device.apdus[0]))
+
+	// Cached
+	_, err = signer.AvailableKeys()
+	assert.NoError(t, err)
+	assert.Len(t, device.apdus, 1)
+
+	tx := ledgerTestTransaction()
+	tx.Actions[0].HexData = make([]byte, 200)
+	device.responses = [][]byte{nil, nil, append([]byte{0x1f}, bytes.Repeat([]byte{0x01}, 64)...)}
+
+	signed, err := signer.Sign(&eos.SignedTransaction{Transaction: tx}, bytes.Repeat([]byte{0xcf}, 32))
+	assert.NoError(t, err)
+	assert.Len(t, signed.Signatures, 1)
+
+	apdus := device.apdus[1:]
+	assert.Len(t, apdus, 3)
+	assert.Equal(t, []byte{0xd4, 0x04, 0x00, 0x00, 150}, apdus[0][:5])
+	assert.Equal(t, signer.encodePath(), apdus[0][5:26])
+	assert.Equal(t, []byte{0xd4, 0x04, 0x80, 0x00, 150}, apdus[1][:5])
+	assert.Equal(t, byte(0x80), apdus[2][2])
+
+	device.responses = [][]byte{{0x00}}
+	_, err = signer.Sign(&eos.SignedTransaction{Transaction: ledgerTestTransaction()}, bytes.Repeat([]byte{0xcf}, 32))
+	assert.Error(t, err)
+}
+
+func TestNewLedgerSignerSpec(t *testing.T) {
+	signer, err := NewSigner("ledger:44'/194'/0'/0/1")
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{0x8000002c, 0x800000c2, 0x80000000, 0, 1}, signer.(*LedgerSigner).Path)
+
+	_, err = NewSigner("ledger:44'/nope")
+	assert.Error(t, err)
+}
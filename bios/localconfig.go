@@ -0,0 +1,117 @@
+package bios
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"golang.org/x/crypto/openpgp"
+	pgparmor "golang.org/x/crypto/openpgp/armor"
+)
+
+// LocalConfigPassphraseEnv holds the passphrase of an encrypted local
+// config, for unattended runs. Without it, the passphrase is prompted.
+const LocalConfigPassphraseEnv = "EOS_BIOS_LOCAL_CONFIG_PASSPHRASE"
+
+// Local config encryptions, as detected by DecryptLocalConfig.
+const (
+	LocalConfigPlain = "plain"
+	LocalConfigAge   = "age"
+	LocalConfigPGP   = "pgp"
+)
+
+// LocalConfigEncryption tells how `content` is encrypted: with a
+// passphrase by `age -p` (armored or not), with a passphrase by
+// `gpg --symmetric` (armored or not), or not at all.
+func LocalConfigEncryption(content []byte) string {
+	switch {
+	case bytes.HasPrefix(content, []byte("age-encryption.org/")),
+		bytes.HasPrefix(content, []byte(armor.Header)):
+		return LocalConfigAge
+	case bytes.HasPrefix(content, []byte("-----BEGIN PGP MESSAGE-----")),
+		len(content) > 0 && isEncryptedSessionKeyTag(content[0]):
+		return LocalConfigPGP
+	}
+	return LocalConfigPlain
+}
+
+// isEncryptedSessionKeyTag tells whether `tag` is the tag of the
+// packet binary OpenPGP messages start with: an encrypted session key,
+// for a passphrase (`gpg --symmetric`) or a public key. Other bytes
+// with the high bit set, like those of a UTF-8 byte order mark, are
+// not.
+func isEncryptedSessionKeyTag(tag byte) bool {
+	if tag&0x80 == 0 {
+		return false
+	}
+
+	packetType := tag & 0x3f // new format
+	if tag&0x40 == 0 {
+		packetType = (tag >> 2) & 0x0f // old format
+	}
+	return packetType == 1 || packetType == 3
+}
+
+// DecryptLocalConfig decrypts an age or OpenPGP encrypted local config
+// in memory, asking `passphrase` for the passphrase. Plain content is
+// returned as is.
+func DecryptLocalConfig(content []byte, passphrase func() (string, error)) ([]byte, error) {
+	encryption := LocalConfigEncryption(content)
+	if encryption == LocalConfigPlain {
+		return content, nil
+	}
+
+	pass, err := passphrase()
+	if err != nil {
+		return nil, fmt.Errorf("reading passphrase: %s", err)
+	}
+
+	if encryption == LocalConfigAge {
+		identity, err := age.NewScryptIdentity(pass)
+		if err != nil {
+			return nil, err
+		}
+
+		var in io.Reader = bytes.NewReader(content)
+		if bytes.HasPrefix(content, []byte(armor.Header)) {
+			in = armor.NewReader(in)
+		}
+		out, err := age.Decrypt(in, identity)
+		if err != nil {
+			return nil, fmt.Errorf("decrypting age config: %s", err)
+		}
+		return ioutil.ReadAll(out)
+	}
+
+	var in io.Reader = bytes.NewReader(content)
+	if bytes.HasPrefix(content, []byte("-----BEGIN PGP MESSAGE-----")) {
+		block, err := pgparmor.Decode(in)
+		if err != nil {
+			return nil, fmt.Errorf("decoding PGP armor: %s", err)
+		}
+		in = block.Body
+	}
+
+	// The prompt is called again after a wrong passphrase, until it
+	// gives up: only try once.
+	tried := false
+	md, err := openpgp.ReadMessage(in, nil, func(keys []openpgp.Key, symmetric bool) ([]byte, error) {
+		if !symmetric || tried {
+			return nil, fmt.Errorf("wrong passphrase, or not encrypted with a passphrase (gpg --symmetric)")
+		}
+		tried = true
+		return []byte(pass), nil
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting PGP config: %s", err)
+	}
+
+	out, err := ioutil.ReadAll(md.UnverifiedBody)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting PGP config: %s", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,95 @@
+package bios
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp"
+	pgparmor "golang.org/x/crypto/openpgp/armor"
+)
+
+const testLocalConfig = "seednet-api: http://localhost:8888\nseednet-private-keys:\n- 5KQwrPbwdL6PhXujxW37FSSQZ1JiwsST4cqQzDeyXtP79zkvFD3\n"
+
+func encryptAge(t *testing.T, passphrase string, armored bool) []byte {
+	recipient, err := age.NewScryptRecipient(passphrase)
+	assert.NoError(t, err)
+	recipient.SetWorkFactor(10)
+
+	var buf bytes.Buffer
+	var out io.WriteCloser = nopCloser{&buf}
+	if armored {
+		out = armor.NewWriter(&buf)
+	}
+	w, err := age.Encrypt(out, recipient)
+	assert.NoError(t, err)
+	_, err = w.Write([]byte(testLocalConfig))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	assert.NoError(t, out.Close())
+	return buf.Bytes()
+}
+
+func encryptPGP(t *testing.T, passphrase string, armored bool) []byte {
+	var buf bytes.Buffer
+	var out io.WriteCloser = nopCloser{&buf}
+	if armored {
+		var err error
+		out, err = pgparmor.Encode(&buf, "PGP MESSAGE", nil)
+		assert.NoError(t, err)
+	}
+	w, err := openpgp.SymmetricallyEncrypt(out, []byte(passphrase), nil, nil)
+	assert.NoError(t, err)
+	_, err = w.Write([]byte(testLocalConfig))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	assert.NoError(t, out.Close())
+	return buf.Bytes()
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+func TestDecryptLocalConfig(t *testing.T) {
+	passphrase := func(pass string) func() (string, error) {
+		return func() (string, error) { return pass, nil }
+	}
+
+	for _, test := range []struct {
+		name       string
+		content    []byte
+		encryption string
+	}{
+		{"age", encryptAge(t, "secret", false), LocalConfigAge},
+		{"age armored", encryptAge(t, "secret", true), LocalConfigAge},
+		{"pgp", encryptPGP(t, "secret", false), LocalConfigPGP},
+		{"pgp armored", encryptPGP(t, "secret", true), LocalConfigPGP},
+	} {
+		assert.Equal(t, test.encryption, LocalConfigEncryption(test.content), test.name)
+
+		plain, err := DecryptLocalConfig(test.content, passphrase("secret"))
+		assert.NoError(t, err, test.name)
+		assert.Equal(t, testLocalConfig, string(plain), test.name)
+
+		_, err = DecryptLocalConfig(test.content, passphrase("wrong"))
+		assert.Error(t, err, test.name)
+
+		_, err = DecryptLocalConfig(test.content, func() (string, error) { return "", fmt.Errorf("no terminal") })
+		assert.EqualError(t, err, "reading passphrase: no terminal", test.name)
+	}
+
+	assert.Equal(t, LocalConfigPlain, LocalConfigEncryption([]byte(testLocalConfig)))
+	assert.Equal(t, LocalConfigPlain, LocalConfigEncryption([]byte("\xef\xbb\xbf"+testLocalConfig)), "byte order mark")
+	assert.Equal(t, LocalConfigPGP, LocalConfigEncryption([]byte{0xc3, 0x0d, 0x04}), "new format packet")
+	plain, err := DecryptLocalConfig([]byte(testLocalConfig), func() (string, error) {
+		t.Error("plain configs need no passphrase")
+		return "", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, testLocalConfig, string(plain))
+}
@@ -42,13 +42,19 @@ func (b *BIOS) computeMyMeshP2PAddresses() []string {
 	if myPosition != -1 {
 		peerIDs := getPeerIndexesToMeshWith(len(meshableProducers), myPosition)
 		for idx, peer := range meshableProducers {
-			p2pAddr := peer.Discovery.TargetP2PAddress
+			p2pAddr := b.p2pAddress(peer)
 			if peerIDs[idx] && !otherPeersMap[p2pAddr] {
 				otherPeers = append(otherPeers, p2pAddr)
 				otherPeersMap[p2pAddr] = true
 			}
 		}
 	}
+	for _, p2pAddr := range b.extraP2PAddresses() {
+		if !otherPeersMap[p2pAddr] {
+			otherPeers = append(otherPeers, p2pAddr)
+			otherPeersMap[p2pAddr] = true
+		}
+	}
 	return otherPeers
 }
 
@@ -85,9 +91,16 @@ func (b *BIOS) someTopmostPeersAddresses() []string {
 	listOfPeers := b.getPeersForBootNode(meshableProducers, rand.NewSource(time.Now().UTC().UnixNano()))
 	otherPeers := []string{}
 	for _, peer := range listOfPeers {
-		otherPeers = append(otherPeers, peer.Discovery.TargetP2PAddress)
+		otherPeers = append(otherPeers, b.p2pAddress(peer))
 	}
-	return otherPeers
+	return append(otherPeers, b.extraP2PAddresses()...)
+}
+
+// extraP2PAddresses are the addresses learned outside of the seed
+// network: from DNS seeds, and from the kickstart payload.
+func (b *BIOS) extraP2PAddresses() (out []string) {
+	out = append(out, b.dnsSeedExtraAddresses...)
+	return append(out, b.kickstartP2PAddresses...)
 }
 
 func (b *BIOS) meshableShuffledProducers() []*Peer {
@@ -0,0 +1,182 @@
+package bios
+
+import (
+	"sync"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+)
+
+// Stages of the injection pipeline, timed separately to find which
+// one limits throughput.
+const (
+	StageParse   = "parse"   // rendering a step's actions, loading its contents
+	StageBatch   = "batch"   // splitting actions into transactions
+	StageSign    = "sign"    // signing transactions
+	StagePush    = "push"    // round-trips to the target node
+	StageConfirm = "confirm" // reading blocks back to validate the chain
+)
+
+var injectionStages = []string{StageParse, StageBatch, StageSign, StagePush, StageConfirm}
+
+var stageHints = map[string]string{
+	StageParse:   "loading launch data dominates: warm the contents and API caches before the launch",
+	StageBatch:   "splitting actions dominates: this is unexpected, report it with the boot sequence used",
+	StageSign:    "signing dominates: sign with in-memory keys rather than a remote wallet",
+	StagePush:    "node round-trips dominate: push to a closer or less loaded endpoint, or pack more actions per transaction",
+	StageConfirm: "reading blocks dominates: validate against a closer API endpoint",
+}
+
+// InjectionMetrics accumulates the time spent in each stage of the
+// injection pipeline, and what went through it.
+type InjectionMetrics struct {
+	lock sync.Mutex
+
+	Stages       map[string]time.Duration `json:"stages"`
+	Transactions int                      `json:"transactions"`
+	Actions      int                      `json:"actions"`
+	Started      time.Time                `json:"started"`
+	Finished     time.Time                `json:"finished"`
+}
+
+func NewInjectionMetrics() *InjectionMetrics {
+	return &InjectionMetrics{
+		Stages:  map[string]time.Duration{},
+		Started: time.Now(),
+	}
+}
+
+// observe adds `d` to the time spent in `stage`.
+func (m *InjectionMetrics) observe(stage string, d time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.Stages[stage] += d
+}
+
+func (m *InjectionMetrics) stage(stage string) time.Duration {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.Stages[stage]
+}
+
+// time runs `f`, accounting its duration to `stage`.
+func (m *InjectionMetrics) time(stage string, f func()) {
+	start := time.Now()
+	f()
+	m.observe(stage, time.Since(start))
+}
+
+func (m *InjectionMetrics) pushed(actions int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.Transactions++
+	m.Actions += actions
+}
+
+func (m *InjectionMetrics) finish() {
+	m.Finished = time.Now()
+}
+
+// Elapsed is the wall time of the injection, not counting the
+// confirmation.
+func (m *InjectionMetrics) Elapsed() time.Duration {
+	finished := m.Finished
+	if finished.IsZero() {
+		finished = time.Now()
+	}
+	return finished.Sub(m.Started).Round(time.Millisecond)
+}
+
+// TPS is the observed rate of transactions pushed per second.
+func (m *InjectionMetrics) TPS() float64 {
+	return perSecond(m.Transactions, m.Elapsed())
+}
+
+// ActionsPerSecond is the observed rate of actions pushed per second.
+func (m *InjectionMetrics) ActionsPerSecond() float64 {
+	return perSecond(m.Actions, m.Elapsed())
+}
+
+// Bottleneck is the stage where the most time was spent, or "" when
+// nothing was timed.
+func (m *InjectionMetrics) Bottleneck() string {
+	var bottleneck string
+	var max time.Duration
+	for _, stage := range injectionStages {
+		if d := m.Stages[stage]; d > max {
+			bottleneck, max = stage, d
+		}
+	}
+	return bottleneck
+}
+
+// StageShare is the fraction of the total timed duration spent in
+// `stage`.
+func (m *InjectionMetrics) StageShare(stage string) float64 {
+	var total time.Duration
+	for _, d := range m.Stages {
+		total += d
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(m.Stages[stage]) / float64(total)
+}
+
+// StageMetric is the time spent in a stage of the pipeline.
+type StageMetric struct {
+	Stage    string
+	Duration time.Duration
+	Share    float64
+}
+
+// Breakdown lists the time spent in each stage, in pipeline order.
+func (m *InjectionMetrics) Breakdown() (out []*StageMetric) {
+	for _, stage := range injectionStages {
+		out = append(out, &StageMetric{
+			Stage:    stage,
+			Duration: m.Stages[stage].Round(time.Millisecond),
+			Share:    100 * m.StageShare(stage),
+		})
+	}
+	return
+}
+
+// BottleneckHint tells how to relieve the limiting stage.
+func (m *InjectionMetrics) BottleneckHint() string {
+	return stageHints[m.Bottleneck()]
+}
+
+// Print writes the throughput summary to the log.
+func (m *InjectionMetrics) Print(log *Logger) {
+	log.Println("Injection throughput:")
+	log.Printf("  %d transactions, %d actions in %s: %.1f TPS, %.1f actions/s\n", m.Transactions, m.Actions, m.Elapsed(), m.TPS(), m.ActionsPerSecond())
+	for _, stage := range m.Breakdown() {
+		log.Printf("  %-8s %12s  %5.1f%%\n", stage.Stage, stage.Duration, stage.Share)
+	}
+	if bottleneck := m.Bottleneck(); bottleneck != "" {
+		log.Printf("  Limiting stage: %s (%s)\n", bottleneck, m.BottleneckHint())
+	}
+	log.Println("")
+}
+
+func perSecond(count int, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(count) / d.Seconds()
+}
+
+// timedSigner accounts the time spent signing to the sign stage.
+type timedSigner struct {
+	eos.Signer
+	metrics *InjectionMetrics
+}
+
+func (s *timedSigner) Sign(tx *eos.SignedTransaction, chainID []byte, requiredKeys ...ecc.PublicKey) (out *eos.SignedTransaction, err error) {
+	s.metrics.time(StageSign, func() {
+		out, err = s.Signer.Sign(tx, chainID, requiredKeys...)
+	})
+	return
+}
@@ -0,0 +1,35 @@
+package bios
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInjectionMetrics(t *testing.T) {
+	m := NewInjectionMetrics()
+	assert.Equal(t, "", m.Bottleneck())
+
+	m.Started = time.Now().Add(-10 * time.Second)
+	m.observe(StageParse, time.Second)
+	m.observe(StageSign, time.Second)
+	m.observe(StagePush, 3*time.Second)
+	m.observe(StagePush, 3*time.Second)
+	m.observe(StageConfirm, 2*time.Second)
+	for i := 0; i < 50; i++ {
+		m.pushed(4)
+	}
+	m.Finished = m.Started.Add(10 * time.Second)
+
+	assert.Equal(t, StagePush, m.Bottleneck())
+	assert.Equal(t, 0.6, m.StageShare(StagePush))
+	assert.Equal(t, 0.0, m.StageShare(StageBatch))
+	assert.Equal(t, 5.0, m.TPS())
+	assert.Equal(t, 20.0, m.ActionsPerSecond())
+
+	breakdown := m.Breakdown()
+	assert.Len(t, breakdown, 5)
+	assert.Equal(t, StageParse, breakdown[0].Stage)
+	assert.Equal(t, 60.0, breakdown[3].Share)
+}
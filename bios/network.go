@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"path/filepath"
 	"sort"
@@ -40,11 +41,23 @@ type Network struct {
 	ipfs           *IPFS
 	ipfsReferences []ipfsRef
 	cachePath      string
+
+	// Mirrors are fallbacks to IPFS to fetch the launch content.
+	Mirrors []string
+
+	// APICache holds the responses of third-party APIs.
+	APICache *APICache
+
+	// OfflineBundle, when set, is the directory of the offline bundle
+	// everything is read from (see `UseOfflineBundle`).
+	OfflineBundle string
 }
 
 type ipfsRef struct {
 	Name          string
 	Reference     string
+	SHA256        string   // from the `comment`, when published by `launch hash`
+	Mirrors       []string // from the `comment`, to cross-check the content
 	SourceAccount string
 }
 
@@ -54,6 +67,7 @@ func NewNetwork(cachePath string, myDiscovery *disco.Discovery, ipfs *IPFS, seed
 		ipfs:            ipfs,
 		cachePath:       cachePath,
 		seedNetContract: seedNetContract,
+		APICache:        NewAPICache(filepath.Join(cachePath, "api"), time.Hour, false),
 		MyPeer: &Peer{
 			Discovery: myDiscovery,
 			UpdatedAt: time.Now(),
@@ -68,6 +82,32 @@ func (net *Network) SetLocalNetwork() {
 }
 
 func (net *Network) UpdateGraph() error {
+	if err := net.updateDiscoveryGraph(); err != nil {
+		return err
+	}
+
+	count := 0
+	for _, peer := range net.OrderedPeers(net.MyNetwork()) {
+		count++
+
+		if count > ContentConsensusRequiredFromTop {
+			break
+		}
+		if err := net.LoadTargetContentsRefs(peer); err != nil {
+			return fmt.Errorf("loading target contents refs: %s", err)
+		}
+	}
+
+	if err := net.fetchPGPKeys(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// updateDiscoveryGraph fetches the discovery files, and weights the
+// networks their peer links form.
+func (net *Network) updateDiscoveryGraph() error {
 	net.allNodes = simple.NewWeightedDirectedGraph(0, 0)
 
 	if err := net.allNodesFetchFunc(); err != nil {
@@ -85,18 +125,6 @@ func (net *Network) UpdateGraph() error {
 
 	net.CalculateNetworkWeights("") // no forced election
 
-	count := 0
-	for _, peer := range net.OrderedPeers(net.MyNetwork()) {
-		count++
-
-		if count > ContentConsensusRequiredFromTop {
-			break
-		}
-		if err := net.LoadTargetContentsRefs(peer); err != nil {
-			return fmt.Errorf("loading target contents refs: %s", err)
-		}
-	}
-
 	return nil
 }
 
@@ -130,15 +158,24 @@ func (net *Network) fetchGraphFromSeedNetwork() error {
 		return fmt.Errorf("get discovery rows: %s", err)
 	}
 
-	var rows []struct {
-		ID        eos.AccountName  `json:"id"`
-		Discovery *disco.Discovery `json:"content"`
-		UpdatedAt eos.JSONTime     `json:"updated_at"`
-	}
+	var rows []*discoveryRow
 	if err := rowsJSON.JSONToStructs(&rows); err != nil {
 		return fmt.Errorf("reading discovery from table: %s", err)
 	}
 
+	net.addDiscoveryRows(rows)
+
+	return nil
+}
+
+// discoveryRow is a row of the seed network's `discovery` table.
+type discoveryRow struct {
+	ID        eos.AccountName  `json:"id"`
+	Discovery *disco.Discovery `json:"content"`
+	UpdatedAt eos.JSONTime     `json:"updated_at"`
+}
+
+func (net *Network) addDiscoveryRows(rows []*discoveryRow) {
 	for _, cand := range rows {
 		if err := ValidateDiscovery(cand.Discovery); err != nil {
 			net.Log.Printf("Skipping invalid discovery file from %q: %s\n", cand.ID, err)
@@ -156,8 +193,6 @@ func (net *Network) fetchGraphFromSeedNetwork() error {
 			net.allNodes.AddNode(newPeer)
 		}
 	}
-
-	return nil
 }
 
 func (net *Network) LoadTargetContentsRefs(peer *Peer) error {
@@ -167,14 +202,23 @@ func (net *Network) LoadTargetContentsRefs(peer *Peer) error {
 			continue
 		}
 
-		if !strings.HasPrefix(contentRef.Ref, "/ipfs/") {
-			net.Log.Debugf("  - WARN: %q has a ref that doesn't start with '/ipfs/' for name=%q\n", peer.Discovery.SeedNetworkAccountName, contentRef.Name)
-			continue
+		sha256 := ContentSHA256FromComment(contentRef.Comment)
+		if _, isIPFS := ipfsPath(contentRef.Ref); !isIPFS {
+			if !strings.HasPrefix(contentRef.Ref, "https://") {
+				net.Log.Debugf("  - WARN: %q has a ref that isn't an /ipfs/, ipfs:// or https:// one for name=%q\n", peer.Discovery.SeedNetworkAccountName, contentRef.Name)
+				continue
+			}
+			if sha256 == "" {
+				net.Log.Debugf("  - WARN: %q has an https:// ref without a sha256 in its comment for name=%q\n", peer.Discovery.SeedNetworkAccountName, contentRef.Name)
+				continue
+			}
 		}
 
 		net.ipfsReferences = append(net.ipfsReferences, ipfsRef{
 			Name:          contentRef.Name,
 			Reference:     contentRef.Ref,
+			SHA256:        sha256,
+			Mirrors:       ContentMirrorsFromComment(contentRef.Comment),
 			SourceAccount: string(peer.Discovery.SeedNetworkAccountName),
 		})
 	}
@@ -259,7 +303,7 @@ func (net *Network) DownloadReferences() error {
 
 		contentRef := contentRef
 		eg.Go(func() error {
-			if err := net.DownloadIPFSRef(contentRef.Reference); err != nil {
+			if err := net.downloadContent(contentRef); err != nil {
 				return fmt.Errorf("content %q: %s", contentRef.Name, err)
 			}
 			return nil
@@ -297,6 +341,170 @@ func (net *Network) DownloadIPFSRef(ref string) error {
 	return nil
 }
 
+// ipfsPath returns the `/ipfs/<hash>` path of `/ipfs/` and `ipfs://`
+// refs.
+func ipfsPath(ref string) (string, bool) {
+	switch {
+	case strings.HasPrefix(ref, "/ipfs/"):
+		return ref, true
+	case strings.HasPrefix(ref, "ipfs://"):
+		return "/ipfs/" + strings.TrimPrefix(ref, "ipfs://"), true
+	}
+	return "", false
+}
+
+// downloadContent fetches launch content from IPFS, or from its
+// https:// URL, and falls back on the mirrors. Content fetched from a
+// URL or a mirror is only accepted when it matches the sha256
+// published in the launch data. It's cached under its ref either way.
+// Content with mirrors published along is cross-checked against them
+// instead (see crossCheckMirrors).
+func (net *Network) downloadContent(ref ipfsRef) error {
+	if net.isInCache(ref.Reference) {
+		return nil
+	}
+	if net.OfflineBundle != "" {
+		return fmt.Errorf("%q is not in the offline bundle", ref.Reference)
+	}
+
+	var cnt []byte
+	var err error
+	source := "IPFS"
+	if path, isIPFS := ipfsPath(ref.Reference); isIPFS {
+		net.Log.Printf("Downloading and caching content from IPFS: %q\n", ref.Reference)
+		cnt, err = net.ipfs.Get(path)
+	} else {
+		source = ref.Reference
+		net.Log.Printf("Downloading and caching content from %s\n", ref.Reference)
+		cnt, err = httpGet(ref.Reference)
+	}
+	if err == nil && ref.SHA256 != "" && sha2(cnt) != ref.SHA256 {
+		err = fmt.Errorf("got sha256 %s, expected %s", sha2(cnt), ref.SHA256)
+	}
+
+	if len(ref.Mirrors) != 0 {
+		cnt, err = net.crossCheckMirrors(ref, source, cnt, err)
+	} else if err != nil && len(net.Mirrors) != 0 {
+		net.Log.Printf("- %q failed from %s (%s), trying mirrors\n", ref.Name, source, err)
+		if ref.SHA256 == "" {
+			return fmt.Errorf("%s, and no sha256 in the launch data to verify mirrored content", err)
+		}
+		cnt, err = net.getFromMirrors(ref)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := net.writeToCache(ref.Reference, cnt); err != nil {
+		return err
+	}
+
+	net.Log.Printf("- %q done\n", ref.Reference)
+
+	return nil
+}
+
+// crossCheckMirrors fetches the content from all the mirrors published
+// along with it, in its `target_contents` comment. At least two of its
+// sources, counting the ref itself, must serve it, all with the same
+// sha256: a single one serving a tampered file stops the launch.
+func (net *Network) crossCheckMirrors(ref ipfsRef, source string, cnt []byte, err error) ([]byte, error) {
+	var sources []string
+	hashes := map[string]string{}
+	if err != nil {
+		net.Log.Printf("- WARN: %q failed from %s: %s\n", ref.Name, source, err)
+	} else {
+		sources = append(sources, source)
+		hashes[source] = sha2(cnt)
+	}
+
+	for _, mirror := range ref.Mirrors {
+		mirrored, err := httpGet(mirror)
+		if err != nil {
+			net.Log.Printf("- WARN: %q failed from mirror %s: %s\n", ref.Name, mirror, err)
+			continue
+		}
+		if cnt == nil {
+			cnt = mirrored
+		}
+		sources = append(sources, mirror)
+		hashes[mirror] = sha2(mirrored)
+	}
+
+	if len(sources) < 2 {
+		return nil, fmt.Errorf("only %d of its %d sources could be fetched, at least 2 are needed to cross-check it", len(sources), len(ref.Mirrors)+1)
+	}
+
+	expected := ref.SHA256
+	if expected == "" {
+		expected = hashes[sources[0]]
+	}
+	var diverging []string
+	for _, source := range sources {
+		if hashes[source] != expected {
+			diverging = append(diverging, fmt.Sprintf("%s has sha256 %s", source, hashes[source]))
+		}
+	}
+	if len(diverging) != 0 {
+		return nil, fmt.Errorf("sources diverge, expected sha256 %s but %s", expected, strings.Join(diverging, ", "))
+	}
+
+	net.Log.Printf("- %q cross-checked on %d sources\n", ref.Name, len(sources))
+	return cnt, nil
+}
+
+// getFromMirrors tries `<mirror>/<name>` and `<mirror>/<ipfs hash>` on
+// each mirror, which are HTTP(S) URLs or local directories (where
+// files fetched through torrents can be dropped).
+func (net *Network) getFromMirrors(ref ipfsRef) ([]byte, error) {
+	names := []string{ref.Name}
+	if path, isIPFS := ipfsPath(ref.Reference); isIPFS {
+		names = append(names, strings.TrimPrefix(path, "/ipfs/"))
+	}
+
+	var lastErr error
+	for _, mirror := range net.Mirrors {
+		for _, name := range names {
+			var cnt []byte
+			var err error
+			if strings.HasPrefix(mirror, "http://") || strings.HasPrefix(mirror, "https://") {
+				cnt, err = httpGet(strings.TrimSuffix(mirror, "/") + "/" + name)
+			} else {
+				cnt, err = ioutil.ReadFile(filepath.Join(localPath(mirror), name))
+			}
+			if err != nil {
+				lastErr = err
+				continue
+			}
+
+			if sha2(cnt) != ref.SHA256 {
+				lastErr = fmt.Errorf("%s/%s has sha256 %s, expected %s", mirror, name, sha2(cnt), ref.SHA256)
+				net.Log.Printf("- WARN: %s\n", lastErr)
+				continue
+			}
+
+			net.Log.Printf("- %q fetched from mirror %s\n", ref.Name, mirror)
+			return cnt, nil
+		}
+	}
+
+	return nil, fmt.Errorf("not found on any mirror, last error: %s", lastErr)
+}
+
+func httpGet(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
 func (net *Network) writeToCache(ref string, content []byte) error {
 	fileName := replaceAllWeirdities(ref)
 	return ioutil.WriteFile(filepath.Join(net.cachePath, fileName), content, 0666)
@@ -326,12 +534,6 @@ func (net *Network) FileNameFromCache(ref string) string {
 	return filepath.Join(net.cachePath, fileName)
 }
 
-func (net *Network) ChainID() []byte {
-	// TODO: compute based on all the hashes in the elected launchdata?
-	// have a value be voted for ?
-	return make([]byte, 32, 32)
-}
-
 //
 // Graph weighting...
 //
@@ -526,7 +728,7 @@ func (net *Network) MyNetwork() *simple.WeightedDirectedGraph {
 	return network
 }
 
-func (net *Network) PrintOrderedPeers(orderedPeers []*Peer) {
+func (net *Network) PrintOrderedPeers(orderedPeers []*Peer, appointedProducers int) {
 	if orderedPeers == nil {
 		network := net.MyNetwork()
 		orderedPeers = net.OrderedPeers(network)
@@ -551,10 +753,10 @@ func (net *Network) PrintOrderedPeers(orderedPeers []*Peer) {
 	for i := 0; i < RandomBootFromTop && len(orderedPeers) > i; i++ {
 		columns = append(columns, fmt.Sprintf("BOOT CAND. | %s | %s", orderedPeers[i].Columns(), peerContent[i]))
 	}
-	for i := RandomBootFromTop; i < 21 && len(orderedPeers) > i; i++ {
+	for i := RandomBootFromTop; i <= appointedProducers && len(orderedPeers) > i; i++ {
 		columns = append(columns, fmt.Sprintf("ABP %02d | %s | %s", i+1, orderedPeers[i].Columns(), peerContent[i]))
 	}
-	for i := 21; len(orderedPeers) > i; i++ {
+	for i := appointedProducers + 1; len(orderedPeers) > i; i++ {
 		columns = append(columns, fmt.Sprintf("Part. %02d | %s | %s", i+1, orderedPeers[i].Columns(), peerContent[i]))
 	}
 	net.Log.Println(columnize.SimpleFormat(columns))
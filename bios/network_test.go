@@ -1,6 +1,13 @@
 package bios
 
 import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/eoscanada/eos-bios/bios/disco"
@@ -47,3 +54,118 @@ func bootFilterPeers(in string) []*Peer {
 	}
 	return out
 }
+
+func TestGetFromMirrors(t *testing.T) {
+	badMirror, err := ioutil.TempDir("", "eos-bios-mirror")
+	assert.NoError(t, err)
+	defer os.RemoveAll(badMirror)
+	goodMirror, err := ioutil.TempDir("", "eos-bios-mirror")
+	assert.NoError(t, err)
+	defer os.RemoveAll(goodMirror)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(badMirror, "snapshot.csv"), []byte("tampered"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(goodMirror, "QmSnapshot"), []byte("content"), 0644))
+
+	net := &Network{Log: NewLogger(), Mirrors: []string{badMirror, "file://" + goodMirror}}
+	ref := ipfsRef{
+		Name:      "snapshot.csv",
+		Reference: "/ipfs/QmSnapshot",
+		SHA256:    ContentSHA256FromComment("sha256:ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73 contract:abc"),
+	}
+
+	cnt, err := net.getFromMirrors(ref)
+	assert.NoError(t, err)
+	assert.Equal(t, "content", string(cnt))
+
+	net.Mirrors = []string{badMirror}
+	_, err = net.getFromMirrors(ref)
+	assert.Error(t, err)
+}
+
+func TestDownloadContentFromURL(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "eos-bios-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "content")
+	}))
+	defer server.Close()
+	defer func(client *http.Client) { http.DefaultClient = client }(http.DefaultClient)
+	http.DefaultClient = server.Client()
+
+	net := &Network{Log: NewLogger(), cachePath: cacheDir}
+	peer := &Peer{Discovery: &disco.Discovery{
+		SeedNetworkAccountName: AN("producer"),
+		TargetContents: []disco.ContentRef{
+			{Name: "snapshot.csv", Ref: server.URL + "/snapshot.csv", Comment: "sha256:ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73"},
+			{Name: "tampered.csv", Ref: server.URL + "/tampered.csv", Comment: "sha256:" + strings.Repeat("0", 64)},
+			{Name: "unpinned.csv", Ref: server.URL + "/unpinned.csv"},
+			{Name: "plain.csv", Ref: "http://example.com/plain.csv", Comment: "sha256:ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73"},
+			{Name: "ipfs.csv", Ref: "ipfs://QmSnapshot"},
+		},
+	}}
+	assert.NoError(t, net.LoadTargetContentsRefs(peer))
+	if assert.Len(t, net.ipfsReferences, 3) {
+		assert.Equal(t, "ipfs://QmSnapshot", net.ipfsReferences[2].Reference)
+	}
+
+	assert.NoError(t, net.downloadContent(net.ipfsReferences[0]))
+	cnt, err := net.ReadFromCache(server.URL + "/snapshot.csv")
+	assert.NoError(t, err)
+	assert.Equal(t, "content", string(cnt))
+
+	assert.Error(t, net.downloadContent(net.ipfsReferences[1]))
+	assert.False(t, net.isInCache(server.URL+"/tampered.csv"))
+
+	path, isIPFS := ipfsPath("ipfs://QmSnapshot")
+	assert.True(t, isIPFS)
+	assert.Equal(t, "/ipfs/QmSnapshot", path)
+}
+
+func TestCrossCheckMirrors(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "eos-bios-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/good/snapshot.csv", "/other/snapshot.csv":
+			fmt.Fprint(w, "content")
+		case "/tampered/snapshot.csv":
+			fmt.Fprint(w, "tampered")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	assert.Equal(t, []string{server.URL + "/good/snapshot.csv", server.URL + "/other/snapshot.csv"}, ContentMirrorsFromComment(fmt.Sprintf("sha256:abc mirror:%s/good/snapshot.csv mirror:%s/other/snapshot.csv", server.URL, server.URL)))
+
+	net := &Network{Log: NewLogger(), cachePath: cacheDir}
+	ref := ipfsRef{Name: "snapshot.csv", Reference: "/ipfs/QmSnapshot"}
+
+	// IPFS failed, two mirrors agree.
+	ref.Mirrors = []string{server.URL + "/good/snapshot.csv", server.URL + "/missing/snapshot.csv", server.URL + "/other/snapshot.csv"}
+	cnt, err := net.crossCheckMirrors(ref, "IPFS", nil, fmt.Errorf("timeout"))
+	assert.NoError(t, err)
+	assert.Equal(t, "content", string(cnt))
+
+	// A single source isn't enough.
+	ref.Mirrors = []string{server.URL + "/missing/snapshot.csv"}
+	_, err = net.crossCheckMirrors(ref, "IPFS", []byte("content"), nil)
+	assert.Error(t, err)
+
+	// One mirror diverges.
+	ref.Mirrors = []string{server.URL + "/good/snapshot.csv", server.URL + "/tampered/snapshot.csv"}
+	_, err = net.crossCheckMirrors(ref, "IPFS", []byte("content"), nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "/tampered/snapshot.csv has sha256 "+sha2([]byte("tampered")))
+	}
+
+	// All agree, but not with the pinned sha256.
+	ref.SHA256 = strings.Repeat("0", 64)
+	ref.Mirrors = []string{server.URL + "/good/snapshot.csv", server.URL + "/other/snapshot.csv"}
+	_, err = net.crossCheckMirrors(ref, "IPFS", nil, fmt.Errorf("got sha256 ..."))
+	assert.Error(t, err)
+}
@@ -0,0 +1,205 @@
+package bios
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+)
+
+// An offline bundle is a directory holding everything a boot reads
+// from the outside world: the seed network's discovery table, the
+// launch contents and the third-party API responses (like Keybase PGP
+// keys). Booting from it only needs the target node.
+const (
+	offlineManifestFile  = "manifest.json"
+	offlineDiscoveryFile = "discovery.json"
+	offlineCacheDir      = "cache"
+)
+
+// OfflineManifest lists the files of a bundle with their sha256, so a
+// bundle carried to another machine can be verified before use.
+type OfflineManifest struct {
+	CreatedAt      time.Time         `json:"created_at"`
+	LaunchDataHash string            `json:"launch_data_hash"`
+	Peers          int               `json:"peers"`
+	Files          map[string]string `json:"files"`
+}
+
+// PrepareOfflineBundle writes the discovered network, its downloaded
+// contents and the API cache to `dir`.
+func (net *Network) PrepareOfflineBundle(dir string) (*OfflineManifest, error) {
+	launchDisco, err := net.ConsensusDiscovery()
+	if err != nil {
+		return nil, fmt.Errorf("getting consensus on launch data: %s", err)
+	}
+
+	manifest := &OfflineManifest{
+		CreatedAt:      time.Now().UTC(),
+		LaunchDataHash: LaunchDataHash(launchDisco.TargetContents),
+		Files:          map[string]string{},
+	}
+
+	cacheDir := filepath.Join(dir, offlineCacheDir)
+	if err := os.MkdirAll(filepath.Join(cacheDir, "api"), 0777); err != nil {
+		return nil, fmt.Errorf("creating bundle: %s", err)
+	}
+
+	var rows []*discoveryRow
+	for _, node := range net.allNodes.Nodes() {
+		peer := node.(*Peer)
+		rows = append(rows, &discoveryRow{
+			ID:        peer.Discovery.SeedNetworkAccountName,
+			Discovery: peer.Discovery,
+			UpdatedAt: eos.JSONTime{Time: peer.UpdatedAt},
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].ID < rows[j].ID })
+	manifest.Peers = len(rows)
+
+	cnt, err := json.MarshalIndent(rows, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := manifest.writeFile(dir, offlineDiscoveryFile, cnt); err != nil {
+		return nil, err
+	}
+
+	for _, ref := range net.ipfsReferences {
+		fileName := replaceAllWeirdities(ref.Reference)
+		if _, found := manifest.Files[offlineCacheDir+"/"+fileName]; found {
+			continue
+		}
+
+		cnt, err := net.ReadFromCache(ref.Reference)
+		if err != nil {
+			return nil, fmt.Errorf("content %q (from %s) not downloaded: %s", ref.Name, ref.SourceAccount, err)
+		}
+		if ref.SHA256 != "" && sha2(cnt) != ref.SHA256 {
+			return nil, fmt.Errorf("content %q has sha256 %s, expected %s", ref.Name, sha2(cnt), ref.SHA256)
+		}
+
+		if err := manifest.writeFile(dir, filepath.Join(offlineCacheDir, fileName), cnt); err != nil {
+			return nil, err
+		}
+	}
+
+	apiFiles, err := ioutil.ReadDir(net.APICache.Dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading API cache: %s", err)
+	}
+	for _, file := range apiFiles {
+		if file.IsDir() {
+			continue
+		}
+
+		cnt, err := ioutil.ReadFile(filepath.Join(net.APICache.Dir, file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading API cache: %s", err)
+		}
+		if err := manifest.writeFile(dir, filepath.Join(offlineCacheDir, "api", file.Name()), cnt); err != nil {
+			return nil, err
+		}
+	}
+
+	cnt, err = json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, offlineManifestFile), cnt, 0666); err != nil {
+		return nil, fmt.Errorf("writing manifest: %s", err)
+	}
+
+	return manifest, nil
+}
+
+// CheckOfflineContents makes sure the downloaded contents match the
+// launch data, and the whole boot sequence renders from them, before
+// they are bundled.
+func (b *BIOS) CheckOfflineContents() error {
+	for _, check := range []*PreflightCheck{b.preflightContents(), b.preflightBootSequence()} {
+		if check.Status == PreflightNoGo {
+			return fmt.Errorf("%s: %s", check.Name, check.Detail)
+		}
+	}
+	return nil
+}
+
+func (m *OfflineManifest) writeFile(dir, name string, cnt []byte) error {
+	if err := ioutil.WriteFile(filepath.Join(dir, name), cnt, 0666); err != nil {
+		return fmt.Errorf("writing %s: %s", name, err)
+	}
+	m.Files[filepath.ToSlash(name)] = sha2(cnt)
+	return nil
+}
+
+// VerifyOfflineBundle checks every file of the bundle in `dir`
+// against its manifest.
+func VerifyOfflineBundle(dir string) (*OfflineManifest, error) {
+	cnt, err := ioutil.ReadFile(filepath.Join(dir, offlineManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %s", err)
+	}
+
+	var manifest OfflineManifest
+	if err := json.Unmarshal(cnt, &manifest); err != nil {
+		return nil, fmt.Errorf("decoding manifest: %s", err)
+	}
+
+	if _, found := manifest.Files[offlineDiscoveryFile]; !found {
+		return nil, fmt.Errorf("manifest doesn't list %s", offlineDiscoveryFile)
+	}
+
+	for name, hash := range manifest.Files {
+		cnt, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			return nil, fmt.Errorf("bundle file %s: %s", name, err)
+		}
+		if sha2(cnt) != hash {
+			return nil, fmt.Errorf("bundle file %s has sha256 %s, manifest says %s", name, sha2(cnt), hash)
+		}
+	}
+
+	return &manifest, nil
+}
+
+// UseOfflineBundle makes the network read the discovery table, the
+// contents and the API responses from a verified bundle, never from
+// the seed network, IPFS or third-party APIs.
+func (net *Network) UseOfflineBundle(dir string) (*OfflineManifest, error) {
+	manifest, err := VerifyOfflineBundle(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	net.OfflineBundle = dir
+	net.cachePath = filepath.Join(dir, offlineCacheDir)
+	net.APICache.Dir = filepath.Join(net.cachePath, "api")
+	net.APICache.Offline = true
+	net.allNodesFetchFunc = net.fetchGraphFromBundle
+
+	return manifest, nil
+}
+
+func (net *Network) fetchGraphFromBundle() error {
+	net.Log.Println("Loading network graph from offline bundle", net.OfflineBundle)
+
+	cnt, err := ioutil.ReadFile(filepath.Join(net.OfflineBundle, offlineDiscoveryFile))
+	if err != nil {
+		return err
+	}
+
+	var rows []*discoveryRow
+	if err := json.Unmarshal(cnt, &rows); err != nil {
+		return fmt.Errorf("reading discovery from bundle: %s", err)
+	}
+
+	net.addDiscoveryRows(rows)
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package bios
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eoscanada/eos-bios/bios/disco"
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOfflineBundle(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "eos-bios-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+	bundleDir, err := ioutil.TempDir("", "eos-bios-bundle")
+	assert.NoError(t, err)
+	defer os.RemoveAll(bundleDir)
+
+	key, err := ecc.NewPublicKey("EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV")
+	assert.NoError(t, err)
+	discovery := func(account, peer string) *disco.Discovery {
+		d := &disco.Discovery{
+			SeedNetworkAccountName: AN(account),
+			SeedNetworkPeers:       []*disco.PeerLink{{Account: AN(peer), Weight: 50}},
+			TargetAccountName:      AN(account + "aaaaaaa"),
+			TargetP2PAddress:       "none",
+			TargetHTTPAddress:      "http://localhost:8888",
+			TargetContents:         []disco.ContentRef{{Name: "boot_sequence.yaml", Ref: "/ipfs/QmBootSeq"}},
+		}
+		authority := eos.Authority{Threshold: 1, Keys: []eos.KeyWeight{{PublicKey: key, Weight: 1}}}
+		d.TargetInitialAuthority.Owner = authority
+		d.TargetInitialAuthority.Active = authority
+		return d
+	}
+	rows := []*discoveryRow{
+		{ID: AN("bpone"), Discovery: discovery("bpone", "bptwo")},
+		{ID: AN("bptwo"), Discovery: discovery("bptwo", "bpone")},
+	}
+
+	net := NewNetwork(cacheDir, discovery("bpone", "bptwo"), nil, "eosio.disco", nil)
+	net.Log = NewLogger()
+	net.allNodesFetchFunc = func() error {
+		net.addDiscoveryRows(rows)
+		return nil
+	}
+	assert.NoError(t, net.UpdateGraph())
+
+	_, err = net.PrepareOfflineBundle(bundleDir)
+	assert.Error(t, err) // contents not downloaded
+
+	assert.NoError(t, net.writeToCache("/ipfs/QmBootSeq", []byte("boot_sequence: []")))
+	manifest, err := net.PrepareOfflineBundle(bundleDir)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, manifest.Peers)
+	assert.Len(t, manifest.Files, 2)
+
+	offline := NewNetwork(filepath.Join(cacheDir, "unused"), discovery("bpone", "bptwo"), nil, "eosio.disco", nil)
+	offline.Log = NewLogger()
+	_, err = offline.UseOfflineBundle(bundleDir)
+	assert.NoError(t, err)
+	assert.True(t, offline.APICache.Offline)
+	assert.NoError(t, offline.UpdateGraph())
+	assert.Len(t, offline.OrderedPeers(offline.MyNetwork()), 2)
+
+	cnt, err := offline.ReadFromCache("/ipfs/QmBootSeq")
+	assert.NoError(t, err)
+	assert.Equal(t, "boot_sequence: []", string(cnt))
+	assert.NoError(t, offline.DownloadReferences())
+
+	assert.NoError(t, ioutil.WriteFile(offline.FileNameFromCache("/ipfs/QmBootSeq"), []byte("tampered"), 0666))
+	_, err = VerifyOfflineBundle(bundleDir)
+	assert.Error(t, err)
+}
@@ -2,9 +2,13 @@ package bios
 
 import (
 	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
+	"time"
 
 	"github.com/eoscanada/eos-bios/bios/unregd"
 	eos "github.com/eoscanada/eos-go"
@@ -18,11 +22,34 @@ type Operation interface {
 	ResetTestnetOptions() // TODO: implement the DISABLING of all testnet options when `mainnet` is voted in the `discovery`.
 }
 
+// Verifier is implemented by operations that can check their outcome
+// on the booted chain, beyond the presence of their actions in blocks.
+type Verifier interface {
+	Verify(b *BIOS) error
+}
+
+// Confirmer is implemented by operations whose outcome must show on
+// the chain before the boot sequence moves on.
+type Confirmer interface {
+	Confirm(b *BIOS) error
+}
+
+// ChunkSkipper is implemented by operations that can tell, from the
+// chain's state, whether one of their transactions was applied by a
+// previous partial run. `decided` is false when it can't tell.
+type ChunkSkipper interface {
+	AlreadyApplied(b *BIOS, chunk []*eos.Action) (applied, decided bool, err error)
+}
+
 var operationsRegistry = map[string]Operation{
 	"system.setcode":             &OpSetCode{},
 	"system.setram":              &OpSetRAM{},
+	"system.setparams":           &OpSetParams{},
 	"system.newaccount":          &OpNewAccount{},
+	"system.create_accounts":     &OpCreateSystemAccounts{},
 	"system.setpriv":             &OpSetPriv{},
+	"system.setup_wrap":          &OpSetupWrap{},
+	"system.setconstitution":     &OpSetConstitution{},
 	"token.create":               &OpCreateToken{},
 	"token.issue":                &OpIssueToken{},
 	"producers.create_accounts":  &OpCreateProducers{},
@@ -31,26 +58,73 @@ var operationsRegistry = map[string]Operation{
 	"system.setprods":            &OpSetProds{},
 	"snapshot.create_accounts":   &OpSnapshotCreateAccounts{},
 	"snapshot.load_unregistered": &OpInjectUnregdSnapshot{},
+	"snapshot.distribute_token":  &OpDistributeToken{},
+	"snapshot.special_accounts":  &OpSpecialAccounts{},
 	"system.resign_accounts":     &OpResignAccounts{},
 	"system.create_voters":       &OpCreateVoters{},
+	"system.seed_votes":          &OpSeedVotes{},
+	"script.actions":             &OpScriptActions{},
+	"custom.action":              &OpCustomAction{},
+}
+
+// RegisterOperation makes `op` available to boot sequences under
+// `name`, for launches needing operations of their own. It fails when
+// the name is taken.
+func RegisterOperation(name string, op Operation) error {
+	if _, found := operationsRegistry[name]; found {
+		return fmt.Errorf("operation type %q already registered", name)
+	}
+	operationsRegistry[name] = op
+	return nil
 }
 
 type OperationType struct {
 	Op    string
 	Label string
 	Data  Operation
+
+	// Deadline is the time budget of the step. When exceeded, alerts
+	// are dispatched through the `step_deadline` hook, escalating each
+	// time the budget is exceeded again, and OnDeadline is applied.
+	Deadline   time.Duration
+	OnDeadline string
+
+	// Canary is the number of transactions pushed and verified on
+	// chain before the rest of the step. When zero, high-volume steps
+	// get the BIOS' default canary.
+	Canary int
 }
 
 func (o *OperationType) UnmarshalJSON(data []byte) error {
 	opData := struct {
-		Op    string
-		Label string
-		Data  json.RawMessage
+		Op         string
+		Label      string
+		Data       json.RawMessage
+		Deadline   string `json:"deadline"`
+		OnDeadline string `json:"on_deadline"`
+		Canary     int    `json:"canary"`
 	}{}
-	if err := json.Unmarshal(data, &opData); err != nil {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&opData); err != nil {
 		return err
 	}
 
+	var deadline time.Duration
+	if opData.Deadline != "" {
+		var err error
+		deadline, err = time.ParseDuration(opData.Deadline)
+		if err != nil {
+			return fmt.Errorf("operation %q: invalid deadline: %s", opData.Op, err)
+		}
+	}
+
+	switch opData.OnDeadline {
+	case "", DeadlineRetry, DeadlineSkip, DeadlineAbort:
+	default:
+		return fmt.Errorf("operation %q: on_deadline must be one of %q, %q or %q", opData.Op, DeadlineRetry, DeadlineSkip, DeadlineAbort)
+	}
+
 	opType, found := operationsRegistry[opData.Op]
 	if !found {
 		return fmt.Errorf("operation type %q invalid, use one of: %q", opData.Op, operationsRegistry)
@@ -74,9 +148,12 @@ func (o *OperationType) UnmarshalJSON(data []byte) error {
 	}
 
 	*o = OperationType{
-		Op:    opData.Op,
-		Label: opData.Label,
-		Data:  opIface,
+		Op:         opData.Op,
+		Label:      opData.Label,
+		Data:       opIface,
+		Deadline:   deadline,
+		OnDeadline: opData.OnDeadline,
+		Canary:     opData.Canary,
 	}
 
 	return nil
@@ -108,11 +185,12 @@ func (op *OpSetCode) Actions(b *BIOS) (out []*eos.Action, err error) {
 		return nil, err
 	}
 
-	setCode, err := system.NewSetCodeTx(
-		op.Account,
-		b.Network.FileNameFromCache(wasmFileRef),
-		b.Network.FileNameFromCache(abiFileRef),
-	)
+	wasmFile, abiFile := b.Network.FileNameFromCache(wasmFileRef), b.Network.FileNameFromCache(abiFileRef)
+	if err := b.verifyContractHash(op.ContractNameRef, wasmFile, abiFile); err != nil {
+		return nil, err
+	}
+
+	setCode, err := system.NewSetCodeTx(op.Account, wasmFile, abiFile)
 	if err != nil {
 		return nil, fmt.Errorf("NewSetCodeTx %s: %s", op.ContractNameRef, err)
 	}
@@ -133,6 +211,72 @@ func (op *OpSetRAM) Actions(b *BIOS) (out []*eos.Action, err error) {
 
 //
 
+// OpSetParams sets the blockchain parameters of the boot sequence's
+// `chain_params` section with `setparams`, through the bios or system
+// contract.
+type OpSetParams struct{}
+
+// SetParams is the data of the `setparams` action.
+type SetParams struct {
+	Params ChainConfiguration `json:"params"`
+}
+
+func (op *OpSetParams) ResetTestnetOptions() {}
+func (op *OpSetParams) Actions(b *BIOS) (out []*eos.Action, err error) {
+	params, err := b.chainParams()
+	if err != nil {
+		return nil, err
+	}
+	if params == nil {
+		return nil, fmt.Errorf("no `chain_params` in the boot sequence")
+	}
+
+	return append(out, &eos.Action{
+		Account:       AN("eosio"),
+		Name:          eos.ActN("setparams"),
+		Authorization: []eos.PermissionLevel{{Actor: AN("eosio"), Permission: PN("active")}},
+		ActionData:    eos.NewActionData(SetParams{Params: *params}),
+	}), nil
+}
+
+// Verify reads the parameters back from the `global` table of the
+// system contract, which copies them when it's set.
+func (op *OpSetParams) Verify(b *BIOS) error {
+	params, err := b.chainParams()
+	if err != nil || params == nil {
+		return err
+	}
+
+	rowsJSON, err := b.TargetNetAPI.GetTableRows(
+		eos.GetTableRowsRequest{
+			JSON:  true,
+			Scope: "eosio",
+			Code:  "eosio",
+			Table: "global",
+			Limit: 1,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("get global rows: %s", err)
+	}
+
+	var rows []ChainConfiguration
+	if err := rowsJSON.JSONToStructs(&rows); err != nil {
+		return fmt.Errorf("reading global rows: %s", err)
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("no global row, is the system contract set?")
+	}
+	if rows[0] != *params {
+		expected, _ := json.Marshal(params)
+		actual, _ := json.Marshal(rows[0])
+		return fmt.Errorf("chain parameters are %s, expected %s", actual, expected)
+	}
+	return nil
+}
+
+//
+
 type OpNewAccount struct {
 	Creator    eos.AccountName
 	NewAccount eos.AccountName `json:"new_account"`
@@ -161,6 +305,56 @@ func (op *OpNewAccount) Actions(b *BIOS) (out []*eos.Action, err error) {
 	return append(out, system.NewNewAccount(op.Creator, op.NewAccount, pubKey)), nil
 }
 
+// OpCreateSystemAccounts creates the system accounts (`eosio.token`,
+// `eosio.msig`, `eosio.ram`, ...) before their contracts are set,
+// owned by Authority, `eosio@active` by default. They are rewired by
+// `system.resign_accounts` at the end of the boot.
+type OpCreateSystemAccounts struct {
+	Creator   eos.AccountName
+	Accounts  []eos.AccountName
+	Authority *eos.Authority
+}
+
+func (op *OpCreateSystemAccounts) ResetTestnetOptions() {}
+
+func (op *OpCreateSystemAccounts) Actions(b *BIOS) (out []*eos.Action, err error) {
+	creator := op.Creator
+	if creator == "" {
+		creator = AN("eosio")
+	}
+
+	authority := eos.Authority{
+		Threshold: 1,
+		Accounts: []eos.PermissionLevelWeight{
+			{Permission: eos.PermissionLevel{Actor: AN("eosio"), Permission: PN("active")}, Weight: 1},
+		},
+	}
+	if op.Authority != nil {
+		authority = *op.Authority
+	}
+	if authority.Threshold == 0 || len(authority.Keys)+len(authority.Accounts) == 0 {
+		return nil, fmt.Errorf("system accounts authority must have a threshold, and keys or accounts")
+	}
+
+	seen := map[eos.AccountName]bool{}
+	for _, account := range op.Accounts {
+		if seen[account] {
+			return nil, fmt.Errorf("system account %q listed twice", account)
+		}
+		seen[account] = true
+
+		newAccount := system.NewNewAccount(creator, account, ecc.PublicKey{}) // overridden just below
+		newAccount.ActionData = eos.NewActionData(system.NewAccount{
+			Creator: creator,
+			Name:    account,
+			Owner:   authority,
+			Active:  authority,
+		})
+		out = append(out, newAccount)
+	}
+	return
+}
+
 type OpCreateVoters struct {
 	Creator eos.AccountName
 	Pubkey  string
@@ -191,6 +385,124 @@ func (op *OpCreateVoters) Actions(b *BIOS) (out []*eos.Action, err error) {
 	return
 }
 
+// OpSeedVotes pre-wires the election, for networks wanting one
+// shortly after launch: it registers proxies, and casts the votes of
+// declared staking accounts, for producers or through a proxy.
+//
+// Proxies and voters must be controlled by the ephemeral key at this
+// point (like accounts created with `pubkey: ephemeral`, handed over
+// later), and hold stake already, or get some from `eosio` with
+// `stake_cpu` and `stake_net`.
+type OpSeedVotes struct {
+	Proxies []eos.AccountName
+	Votes   []*SeededVote
+}
+
+type SeededVote struct {
+	Voter     eos.AccountName
+	Proxy     eos.AccountName
+	Producers []eos.AccountName
+
+	// VoteAppointed adds the appointed producers, only known once
+	// shuffled, to `producers`.
+	VoteAppointed bool   `json:"vote_appointed"`
+	StakeCPU      string `json:"stake_cpu"`
+	StakeNet      string `json:"stake_net"`
+}
+
+// maxVotedProducers is the system contract's limit.
+const maxVotedProducers = 30
+
+func (op *OpSeedVotes) ResetTestnetOptions() { return }
+func (op *OpSeedVotes) Actions(b *BIOS) (out []*eos.Action, err error) {
+	for _, proxy := range op.Proxies {
+		out = append(out, newRegProxy(proxy))
+	}
+	if len(op.Proxies) != 0 {
+		out = append(out, nil)
+	}
+
+	for _, vote := range op.Votes {
+		var producers []eos.AccountName
+		seen := map[eos.AccountName]bool{}
+		addProducer := func(name eos.AccountName) {
+			if !seen[name] {
+				seen[name] = true
+				producers = append(producers, name)
+			}
+		}
+		for _, name := range vote.Producers {
+			addProducer(name)
+		}
+		if vote.VoteAppointed {
+			for idx, prod := range b.ShuffledProducers {
+				if idx == 0 {
+					continue
+				}
+				if idx >= b.scheduleSize() {
+					break
+				}
+				addProducer(prod.Discovery.TargetAccountName)
+			}
+		}
+
+		if vote.Proxy != "" && len(producers) != 0 {
+			return nil, fmt.Errorf("voter %q: vote either through a proxy or for producers, not both", vote.Voter)
+		}
+		if vote.Proxy == "" && len(producers) == 0 {
+			return nil, fmt.Errorf("voter %q: no proxy nor producers to vote for", vote.Voter)
+		}
+		if len(producers) > maxVotedProducers {
+			return nil, fmt.Errorf("voter %q: voting for %d producers, the maximum is %d", vote.Voter, len(producers), maxVotedProducers)
+		}
+
+		// The system contract wants them sorted by name value.
+		sort.Slice(producers, func(i, j int) bool {
+			left, _ := eos.StringToName(string(producers[i]))
+			right, _ := eos.StringToName(string(producers[j]))
+			return left < right
+		})
+
+		if vote.StakeCPU != "" || vote.StakeNet != "" {
+			stakeCPU, err := parseStake(vote.StakeCPU)
+			if err != nil {
+				return nil, fmt.Errorf("voter %q: stake_cpu: %s", vote.Voter, err)
+			}
+			stakeNet, err := parseStake(vote.StakeNet)
+			if err != nil {
+				return nil, fmt.Errorf("voter %q: stake_net: %s", vote.Voter, err)
+			}
+			out = append(out, system.NewDelegateBW(AN("eosio"), vote.Voter, stakeCPU, stakeNet, true))
+		}
+
+		out = append(out, system.NewVoteProducer(vote.Voter, vote.Proxy, producers...), nil)
+	}
+
+	return
+}
+
+func parseStake(in string) (eos.Asset, error) {
+	if in == "" {
+		return eos.NewEOSAsset(0), nil
+	}
+	return eos.NewAsset(in)
+}
+
+// RegProxy is the system contract's `regproxy` action.
+type RegProxy struct {
+	Proxy   eos.AccountName `json:"proxy"`
+	IsProxy bool            `json:"isproxy"`
+}
+
+func newRegProxy(proxy eos.AccountName) *eos.Action {
+	return &eos.Action{
+		Account:       AN("eosio"),
+		Name:          eos.ActN("regproxy"),
+		Authorization: []eos.PermissionLevel{{Actor: proxy, Permission: PN("active")}},
+		ActionData:    eos.NewActionData(RegProxy{Proxy: proxy, IsProxy: true}),
+	}
+}
+
 const charset = "abcdefghijklmnopqrstuvwxyz"
 
 func voterName(index int) string {
@@ -198,13 +510,257 @@ func voterName(index int) string {
 	return "voter" + padding
 }
 
+// OpSetPriv marks the contracts needing it, like `eosio.msig`, as
+// privileged, through the bios contract. Accounts are in addition to
+// Account.
 type OpSetPriv struct {
-	Account eos.AccountName
+	Account  eos.AccountName
+	Accounts []eos.AccountName
+}
+
+func (op *OpSetPriv) accounts() []eos.AccountName {
+	if op.Account == "" {
+		return op.Accounts
+	}
+	return append([]eos.AccountName{op.Account}, op.Accounts...)
 }
 
 func (op *OpSetPriv) ResetTestnetOptions() { return }
 func (op *OpSetPriv) Actions(b *BIOS) (out []*eos.Action, err error) {
-	return append(out, system.NewSetPriv(op.Account)), nil
+	accounts := op.accounts()
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("no account to mark privileged")
+	}
+
+	for _, account := range accounts {
+		out = append(out, system.NewSetPriv(account))
+	}
+	return
+}
+
+// Verify checks the accounts are privileged on chain.
+func (op *OpSetPriv) Verify(b *BIOS) error {
+	for _, account := range op.accounts() {
+		acct, err := b.TargetNetAPI.GetAccount(account)
+		if err != nil {
+			return fmt.Errorf("getting account %q: %s", account, err)
+		}
+		if !acct.Privileged {
+			return fmt.Errorf("%q is not privileged", account)
+		}
+	}
+	return nil
+}
+
+//
+
+// OpCustomAction pushes any action, for one-off chain-specific
+// operations. Its `data` is serialized with the ABI of the contract
+// found in the launch data (`<contract_name_ref>.abi`), or given
+// already serialized in `hex_data`.
+type OpCustomAction struct {
+	Account         eos.AccountName
+	Name            eos.ActionName
+	Authorization   []string
+	ContractNameRef string          `json:"contract_name_ref"`
+	Data            json.RawMessage `json:"data"`
+	HexData         string          `json:"hex_data"`
+}
+
+func (op *OpCustomAction) ResetTestnetOptions() { return }
+
+func (op *OpCustomAction) Actions(b *BIOS) (out []*eos.Action, err error) {
+	act := &eos.Action{
+		Account: op.Account,
+		Name:    op.Name,
+	}
+
+	for _, perm := range op.Authorization {
+		level, err := parsePermissionLevel(perm)
+		if err != nil {
+			return nil, err
+		}
+		act.Authorization = append(act.Authorization, level)
+	}
+
+	if op.HexData != "" {
+		act.HexData, err = hex.DecodeString(op.HexData)
+		if err != nil {
+			return nil, fmt.Errorf("hex_data: %s", err)
+		}
+		return append(out, act), nil
+	}
+
+	if op.ContractNameRef == "" {
+		return nil, fmt.Errorf("either `contract_name_ref`, to serialize `data` with its ABI, or `hex_data` is required")
+	}
+
+	abiFileRef, err := b.GetContentsCacheRef(fmt.Sprintf("%s.abi", op.ContractNameRef))
+	if err != nil {
+		return nil, err
+	}
+	rawABI, err := b.Network.ReadFromCache(abiFileRef)
+	if err != nil {
+		return nil, fmt.Errorf("reading ABI: %s", err)
+	}
+
+	var data interface{}
+	decoder := json.NewDecoder(bytes.NewReader(op.Data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		return nil, fmt.Errorf("decoding data: %s", err)
+	}
+
+	act.HexData, err = EncodeActionData(rawABI, string(op.Name), data)
+	if err != nil {
+		return nil, fmt.Errorf("serializing %s::%s: %s", op.Account, op.Name, err)
+	}
+
+	return append(out, act), nil
+}
+
+//
+
+// OpSetupWrap deploys `eosio.wrap`, the privileged contract through
+// which the block producers can execute any action as any account,
+// with an msig proposal approved by `eosio.prods`. Its authority is
+// handed to `eosio@active`, which is itself tied to `eosio.prods`
+// upon `system.resign_accounts`.
+type OpSetupWrap struct {
+	Account         eos.AccountName
+	ContractNameRef string `json:"contract_name_ref"`
+}
+
+func (op *OpSetupWrap) account() eos.AccountName {
+	if op.Account == "" {
+		return AN("eosio.wrap")
+	}
+	return op.Account
+}
+
+func (op *OpSetupWrap) contractNameRef() string {
+	if op.ContractNameRef == "" {
+		return "eosio.wrap"
+	}
+	return op.ContractNameRef
+}
+
+func (op *OpSetupWrap) ResetTestnetOptions() {}
+func (op *OpSetupWrap) Actions(b *BIOS) (out []*eos.Action, err error) {
+	wrap := op.account()
+
+	out = append(out, system.NewNewAccount(AN("eosio"), wrap, b.EphemeralPublicKey), nil)
+	out = append(out, system.NewSetPriv(wrap), nil)
+
+	setCode, err := (&OpSetCode{Account: wrap, ContractNameRef: op.contractNameRef()}).Actions(b)
+	if err != nil {
+		return nil, err
+	}
+	out = append(out, setCode...)
+	out = append(out, nil)
+
+	eosioActive := eos.Authority{
+		Threshold: 1,
+		Accounts: []eos.PermissionLevelWeight{
+			eos.PermissionLevelWeight{
+				Permission: eos.PermissionLevel{
+					Actor:      AN("eosio"),
+					Permission: PN("active"),
+				},
+				Weight: 1,
+			},
+		},
+	}
+	out = append(out,
+		system.NewUpdateAuth(wrap, PN("active"), PN("owner"), eosioActive, PN("active")),
+		system.NewUpdateAuth(wrap, PN("owner"), PN(""), eosioActive, PN("owner")),
+		nil,
+	)
+
+	return
+}
+
+// Verify checks `eosio.wrap` is privileged, runs the agreed upon
+// code, and is controlled by `eosio@active` only.
+func (op *OpSetupWrap) Verify(b *BIOS) error {
+	wrap := op.account()
+
+	acct, err := b.TargetNetAPI.GetAccount(wrap)
+	if err != nil {
+		return fmt.Errorf("getting account %q: %s", wrap, err)
+	}
+	if !acct.Privileged {
+		return fmt.Errorf("%q is not privileged", wrap)
+	}
+
+	for _, perm := range acct.Permissions {
+		auth := perm.RequiredAuth
+		if len(auth.Keys) != 0 || len(auth.Accounts) != 1 || auth.Accounts[0].Permission.Actor != AN("eosio") || auth.Accounts[0].Permission.Permission != PN("active") {
+			return fmt.Errorf("permission %q of %q should be held by eosio@active only", perm.PermName, wrap)
+		}
+	}
+
+	wasmRef, err := b.GetContentsCacheRef(fmt.Sprintf("%s.wasm", op.contractNameRef()))
+	if err != nil {
+		return err
+	}
+	wasm, err := b.Network.ReadFromCache(wasmRef)
+	if err != nil {
+		return fmt.Errorf("reading %s.wasm: %s", op.contractNameRef(), err)
+	}
+
+	code, err := b.TargetNetAPI.GetCode(wrap)
+	if err != nil {
+		return fmt.Errorf("getting code of %q: %s", wrap, err)
+	}
+	if code.CodeHash != sha2(wasm) {
+		return fmt.Errorf("code of %q has hash %s, expected %s", wrap, code.CodeHash, sha2(wasm))
+	}
+
+	return nil
+}
+
+//
+
+// OpSetConstitution pushes the constitution text on chain, as the
+// `constitution` argument of `account::action` (`eosio::setconstitution`
+// by default), so the chain carries the ratified text. When the chain
+// ID is derived from the constitution, the text must hash to it.
+type OpSetConstitution struct {
+	Account eos.AccountName
+	Action  eos.ActionName
+}
+
+// SetConstitution is the data of the `setconstitution` action.
+type SetConstitution struct {
+	Constitution string `json:"constitution"`
+}
+
+func (op *OpSetConstitution) ResetTestnetOptions() {}
+func (op *OpSetConstitution) Actions(b *BIOS) (out []*eos.Action, err error) {
+	constitution, err := b.readConstitution()
+	if err != nil {
+		return nil, fmt.Errorf("reading constitution: %s", err)
+	}
+
+	if b.ChainID.derivation() == ChainIDFromConstitution && !bytes.Equal(ConstitutionHash(constitution), b.TargetChainID) {
+		return nil, fmt.Errorf("constitution hashes to %x, but the chain ID was derived as %s", ConstitutionHash(constitution), b.TargetChainID)
+	}
+
+	account, action := op.Account, op.Action
+	if account == "" {
+		account = AN("eosio")
+	}
+	if action == "" {
+		action = eos.ActN("setconstitution")
+	}
+
+	return append(out, &eos.Action{
+		Account:       account,
+		Name:          action,
+		Authorization: []eos.PermissionLevel{{Actor: account, Permission: PN("active")}},
+		ActionData:    eos.NewActionData(SetConstitution{Constitution: string(constitution)}),
+	}), nil
 }
 
 //
@@ -328,9 +884,77 @@ func (op *OpEnrichProducers) Actions(b *BIOS) (out []*eos.Action, err error) {
 
 //
 
+// RAMPurchase is the RAM `eosio` buys for each injected account,
+// written as a number of bytes (`8192`), or an amount of EOS
+// (`1.0000 EOS`).
+type RAMPurchase struct {
+	Bytes uint32
+	EOS   *eos.Asset
+}
+
+func (r *RAMPurchase) UnmarshalJSON(data []byte) error {
+	var ramBytes uint32
+	if err := json.Unmarshal(data, &ramBytes); err == nil {
+		*r = RAMPurchase{Bytes: ramBytes}
+		return nil
+	}
+
+	var amount string
+	if err := json.Unmarshal(data, &amount); err != nil {
+		return fmt.Errorf("RAM purchase must be a number of bytes, or an amount of EOS")
+	}
+	if ramBytes, err := strconv.ParseUint(amount, 10, 32); err == nil {
+		*r = RAMPurchase{Bytes: uint32(ramBytes)}
+		return nil
+	}
+	asset, err := eos.NewEOSAssetFromString(amount)
+	if err != nil || asset.Amount < 0 {
+		return fmt.Errorf("invalid RAM purchase %q, use a number of bytes or an amount of EOS", amount)
+	}
+	*r = RAMPurchase{EOS: &asset}
+	return nil
+}
+
+// action buys the RAM for `receiver`, nil when there's none to buy.
+func (r *RAMPurchase) action(receiver eos.AccountName) *eos.Action {
+	switch {
+	case r == nil:
+		return nil
+	case r.EOS != nil && r.EOS.Amount > 0:
+		return system.NewBuyRAM(AN("eosio"), receiver, uint64(r.EOS.Amount))
+	case r.EOS == nil && r.Bytes > 0:
+		return system.NewBuyRAMBytes(AN("eosio"), receiver, r.Bytes)
+	}
+	return nil
+}
+
+// ramPurchase returns `buyRAM`, or `ramBytes` when it's not set.
+func ramPurchase(buyRAM *RAMPurchase, ramBytes uint64) *RAMPurchase {
+	if buyRAM != nil {
+		return buyRAM
+	}
+	return &RAMPurchase{Bytes: uint32(ramBytes)}
+}
+
 type OpSnapshotCreateAccounts struct {
-	BuyRAM                  uint64 `json:"buy_ram_bytes"`
-	TestnetTruncateSnapshot int    `json:"TESTNET_TRUNCATE_SNAPSHOT"`
+	// BuyRAM is the RAM bought for each account, as bytes or EOS.
+	BuyRAM *RAMPurchase `json:"buy_ram"`
+	// BuyRAMBytes is the RAM bought for each account, unless BuyRAM
+	// is set.
+	BuyRAMBytes uint64 `json:"buy_ram_bytes"`
+	// BatchSize packs the accounts of that many snapshot rows (with
+	// their stake, RAM and transfer) in each transaction, within the
+	// chain's maximum transaction size.
+	BatchSize int `json:"batch_size"`
+	// LookupBatch, when re-running the boot sequence, looks up that
+	// many snapshot accounts at once on the target chain, ahead of
+	// their transactions, instead of one by one.
+	LookupBatch             int `json:"lookup_batch"`
+	TestnetTruncateSnapshot int `json:"TESTNET_TRUNCATE_SNAPSHOT"`
+
+	accounts []eos.AccountName
+	position map[eos.AccountName]int
+	lookups  map[eos.AccountName]*snapshotAccount
 }
 
 func (op *OpSnapshotCreateAccounts) ResetTestnetOptions() {
@@ -338,27 +962,32 @@ func (op *OpSnapshotCreateAccounts) ResetTestnetOptions() {
 }
 
 func (op *OpSnapshotCreateAccounts) Actions(b *BIOS) (out []*eos.Action, err error) {
-	snapshotFile, err := b.GetContentsCacheRef("snapshot.csv")
+	snapshotData, err := b.LoadSnapshot()
 	if err != nil {
 		return nil, err
 	}
 
-	rawSnapshot, err := b.Network.ReadFromCache(snapshotFile)
+	config, err := b.GenesisConfig.chainConfiguration()
 	if err != nil {
-		return nil, fmt.Errorf("reading snapshot file: %s", err)
+		return nil, err
 	}
+	maxSize := int(config.MaxTransactionNetUsage) - transactionSizeMargin
 
-	snapshotData, err := NewSnapshot(rawSnapshot)
-	if err != nil {
-		return nil, fmt.Errorf("loading snapshot csv: %s", err)
+	// rows and size of the transaction being batched
+	var rows, size int
+	endTransaction := func() {
+		if rows != 0 {
+			out = append(out, nil)
+		}
+		rows, size = 0, 0
 	}
 
-	if len(snapshotData) == 0 {
-		return nil, fmt.Errorf("snapshot is empty or not loaded")
+	special, err := b.specialAccountNames()
+	if err != nil {
+		return nil, err
 	}
 
-	wellKnownPubkey, _ := ecc.NewPublicKey("EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV")
-
+	op.accounts, op.position, op.lookups = nil, map[eos.AccountName]int{}, nil
 	for idx, hodler := range snapshotData {
 		if trunc := op.TestnetTruncateSnapshot; trunc != 0 {
 			if idx == trunc {
@@ -367,64 +996,305 @@ func (op *OpSnapshotCreateAccounts) Actions(b *BIOS) (out []*eos.Action, err err
 			}
 		}
 
-		destAccount := AN(hodler.AccountName)
-		destPubKey := hodler.EOSPublicKey
-		if b.HackVotingAccounts {
-			destPubKey = wellKnownPubkey
+		if special[hodler.AccountName] {
+			b.Log.Debugf("- snapshot row %d skipped, %q is a special account\n", idx+1, hodler.AccountName)
+			continue
 		}
 
+		destAccount := AN(hodler.AccountName)
+		destPubKey := b.snapshotPublicKey(hodler)
+
+		var row []*eos.Action
+
 		// we should have created the account before loading `eosio.system`, otherwise
 		// b1 wouldn't have been accepted.
-		if hodler.EthereumAddress != "0x00000000000000000000000000000000000000b1" {
+		isB1 := hodler.EthereumAddress == "0x00000000000000000000000000000000000000b1"
+		if !isB1 {
 			// create all other accounts, but not `b1`.. because it's a short name..
-			out = append(out, system.NewNewAccount(AN("eosio"), destAccount, destPubKey))
+			row = append(row, system.NewNewAccount(AN("eosio"), destAccount, destPubKey))
+			op.position[destAccount] = len(op.accounts)
+			op.accounts = append(op.accounts, destAccount)
 		}
 
-		cpuStake, netStake, rest := splitSnapshotStakes(hodler.Balance)
+		cpuStake, netStake, rest := b.SnapshotConfig.splitStakes(hodler.Balance)
 
 		// special case `transfer` for `b1` ?
-		out = append(out, system.NewDelegateBW(AN("eosio"), destAccount, cpuStake, netStake, true))
-		out = append(out, system.NewBuyRAMBytes(AN("eosio"), destAccount, uint32(op.BuyRAM)))
-		out = append(out, nil) // end transaction
+		row = append(row, system.NewDelegateBW(AN("eosio"), destAccount, cpuStake, netStake, true))
+		if buyRAM := ramPurchase(op.BuyRAM, op.BuyRAMBytes).action(destAccount); buyRAM != nil {
+			row = append(row, buyRAM)
+		}
 
 		memo := "Welcome " + hodler.EthereumAddress[len(hodler.EthereumAddress)-6:]
-		out = append(out, token.NewTransfer(AN("eosio"), destAccount, rest, memo), nil)
+		transfer := token.NewTransfer(AN("eosio"), destAccount, rest, memo)
+
+		if op.BatchSize <= 1 {
+			out = append(out, row...)
+			out = append(out, nil) // end transaction
+			out = append(out, transfer, nil)
+			continue
+		}
+
+		row = append(row, transfer)
+		rowSize, err := packedActionsSize(row)
+		if err != nil {
+			return nil, fmt.Errorf("snapshot row %d: %s", idx+1, err)
+		}
+		if rowSize > maxSize {
+			return nil, fmt.Errorf("snapshot row %d: its actions take %d bytes, over the maximum transaction size", idx+1, rowSize)
+		}
+
+		// `b1` goes alone, it's checked differently on re-runs.
+		if isB1 || rows == op.BatchSize || size+rowSize > maxSize {
+			endTransaction()
+		}
+		out = append(out, row...)
+		rows, size = rows+1, size+rowSize
+		if isB1 {
+			endTransaction()
+		}
 	}
+	endTransaction()
 
 	return
 }
 
-func splitSnapshotStakes(balance eos.Asset) (cpu, net, xfer eos.Asset) {
-	if balance.Amount < 5000 {
+// ConcurrentChunks allows batched rows to be pushed concurrently:
+// their transactions each create accounts along with their transfers.
+func (op *OpSnapshotCreateAccounts) ConcurrentChunks() bool {
+	return op.BatchSize > 1
+}
+
+// transactionSizeMargin leaves room in a transaction for its header
+// and signatures, when packing actions up to the maximum size.
+const transactionSizeMargin = 1024
+
+// packedActionsSize is the size the actions take packed in a
+// transaction.
+func packedActionsSize(acts []*eos.Action) (size int, err error) {
+	for _, act := range acts {
+		act.SetToServer(true)
+		data, err := eos.MarshalBinary(act)
+		if err != nil {
+			return 0, fmt.Errorf("binary marshalling: %s", err)
+		}
+		size += len(data)
+	}
+	return size, nil
+}
+
+// AlreadyApplied checks the accounts of the transaction's snapshot
+// rows exist with the expected keys, stake and balance (their
+// creation, stake, RAM purchase and, when batched, transfer go in the
+// same transaction), or that the account of a lone transfer already
+// holds its liquid balance.
+func (op *OpSnapshotCreateAccounts) AlreadyApplied(b *BIOS, chunk []*eos.Action) (applied, decided bool, err error) {
+	if len(chunk) == 0 {
 		return
 	}
 
-	// everyone has minimum 0.25 EOS staked
-	// some 10 EOS unstaked
-	// the rest split between the two
+	switch data := chunk[0].Data.(type) {
+	case system.NewAccount:
+		created, missing := 0, 0
+		for idx, act := range chunk {
+			newAccount, ok := act.Data.(system.NewAccount)
+			if !ok {
+				continue
+			}
+
+			exists, err := op.accountCreated(b, newAccount, chunk[idx+1:])
+			if err != nil {
+				return false, true, err
+			}
+			if exists {
+				created++
+			} else {
+				missing++
+			}
+		}
+
+		if created != 0 && missing != 0 {
+			return false, true, fmt.Errorf("only %d of the %d accounts created by this transaction (from %q) exist", created, created+missing, data.Name)
+		}
+		return missing == 0, true, nil
+
+	case token.Transfer:
+		resp, err := b.TargetNetAPI.GetAccount(data.To)
+		if err != nil {
+			return false, true, fmt.Errorf("account %q should exist before its transfer: %s", data.To, err)
+		}
+
+		switch resp.CoreLiquidBalance.Amount {
+		case 0:
+			return false, true, nil
+		case data.Quantity.Amount:
+			return true, true, nil
+		}
+		return false, true, fmt.Errorf("account %q holds %s, expected 0 or %s", data.To, resp.CoreLiquidBalance, data.Quantity)
+	}
+
+	// The `b1` row, whose account is created earlier.
+	return
+}
+
+// accountCreated checks the account of `data` exists, as set up by
+// the actions following its creation, up to the next account.
+func (op *OpSnapshotCreateAccounts) accountCreated(b *BIOS, data system.NewAccount, following []*eos.Action) (bool, error) {
+	existing := op.lookupAccount(b, data.Name)
+	if existing.account == nil {
+		return false, nil
+	}
+	if existing.err != nil {
+		return false, existing.err
+	}
+
+	for _, perm := range existing.account.Permissions {
+		expected := data.Active
+		if perm.PermName == "owner" {
+			expected = data.Owner
+		}
+		if !sameKeys(perm.RequiredAuth, expected) {
+			return false, fmt.Errorf("account %q exists with a different %s authority than the snapshot's", data.Name, perm.PermName)
+		}
+	}
+
+	for _, act := range following {
+		switch setup := act.Data.(type) {
+		case system.NewAccount:
+			return true, nil
+		case system.DelegateBW:
+			if existing.cpu.Amount != setup.StakeCPU.Amount || existing.net.Amount != setup.StakeNet.Amount {
+				return false, fmt.Errorf("account %q exists with %s staked for CPU and %s for network, expected %s and %s", data.Name, existing.cpu, existing.net, setup.StakeCPU, setup.StakeNet)
+			}
+		case token.Transfer:
+			if existing.account.CoreLiquidBalance.Amount != setup.Quantity.Amount {
+				return false, fmt.Errorf("account %q exists holding %s, expected %s", data.Name, existing.account.CoreLiquidBalance, setup.Quantity)
+			}
+		}
+	}
+	return true, nil
+}
+
+func sameKeys(a, b eos.Authority) bool {
+	if len(a.Keys) != len(b.Keys) {
+		return false
+	}
+	for idx := range a.Keys {
+		if a.Keys[idx].PublicKey.String() != b.Keys[idx].PublicKey.String() {
+			return false
+		}
+	}
+	return true
+}
+
+//
+
+// OpDistributeToken creates an additional token on `contract`,
+// issues what the `snapshot_ref` distributes, and transfers it to the
+// snapshot accounts. Those must exist already, like the ones created
+// by `snapshot.create_accounts`.
+type OpDistributeToken struct {
+	Contract                eos.AccountName `json:"contract"`
+	Issuer                  eos.AccountName `json:"issuer"`
+	MaxSupply               eos.Asset       `json:"max_supply"`
+	SnapshotRef             string          `json:"snapshot_ref"`
+	SHA256                  string          `json:"sha256"`
+	Memo                    string          `json:"memo"`
+	TestnetTruncateSnapshot int             `json:"TESTNET_TRUNCATE_SNAPSHOT"`
+}
+
+func (op *OpDistributeToken) ResetTestnetOptions() {
+	op.TestnetTruncateSnapshot = 0
+}
 
-	cpu = eos.NewEOSAsset(2500)
-	net = eos.NewEOSAsset(2500)
+func (op *OpDistributeToken) Actions(b *BIOS) (out []*eos.Action, err error) {
+	rawSnapshot, err := b.ReadContents(op.SnapshotRef, op.SHA256)
+	if err != nil {
+		return nil, err
+	}
 
-	remainder := eos.NewEOSAsset(balance.Amount - cpu.Amount - net.Amount)
+	snapshotData, err := NewTokenSnapshot(rawSnapshot)
+	if err != nil {
+		return nil, fmt.Errorf("loading %q: %s", op.SnapshotRef, err)
+	}
+
+	if trunc := op.TestnetTruncateSnapshot; trunc != 0 && trunc < len(snapshotData) {
+		b.Log.Debugf("- DEBUG: truncated %q to %d rows\n", op.SnapshotRef, trunc)
+		snapshotData = snapshotData[:trunc]
+	}
 
-	if remainder.Amount <= 100000 /* 10.0 EOS */ {
-		return cpu, net, remainder
+	total := eos.Asset{Symbol: op.MaxSupply.Symbol}
+	for idx, hodler := range snapshotData {
+		if hodler.Balance.Symbol != op.MaxSupply.Symbol {
+			return nil, fmt.Errorf("%q line %d: balance %s doesn't match the symbol of max_supply %s", op.SnapshotRef, idx+1, hodler.Balance, op.MaxSupply)
+		}
+		total.Amount += hodler.Balance.Amount
+	}
+	if total.Amount > op.MaxSupply.Amount {
+		return nil, fmt.Errorf("%q distributes %s, more than the max_supply of %s", op.SnapshotRef, total, op.MaxSupply)
 	}
 
-	remainder.Amount -= 100000 // keep them floating, unstaked
+	// The `token` helpers target `eosio.token`, point them to the
+	// contract of this token.
+	create := token.NewCreate(op.Issuer, op.MaxSupply)
+	create.Account = op.Contract
+	create.Authorization = []eos.PermissionLevel{{Actor: op.Contract, Permission: PN("active")}}
 
-	firstHalf := remainder.Amount / 2
-	cpu.Amount += firstHalf
-	net.Amount += remainder.Amount - firstHalf
+	issue := token.NewIssue(op.Issuer, total, op.Memo)
+	issue.Account = op.Contract
+	issue.Authorization = []eos.PermissionLevel{{Actor: op.Issuer, Permission: PN("active")}}
 
-	return cpu, net, eos.NewEOSAsset(100000)
+	out = append(out, create, issue, nil)
+
+	for _, hodler := range snapshotData {
+		transfer := token.NewTransfer(op.Issuer, AN(hodler.AccountName), hodler.Balance, op.Memo)
+		transfer.Account = op.Contract
+		out = append(out, transfer, nil)
+	}
+
+	return
 }
 
 //
 
+// Policies for the token holders who never registered an EOS key.
+const (
+	// UnregdPolicyClaim records them in `eosio.unregd`, where they
+	// can later claim their account with a signature of their
+	// Ethereum key.
+	UnregdPolicyClaim = "unregd"
+	// UnregdPolicySkip leaves them out of the chain.
+	UnregdPolicySkip = "skip"
+	// UnregdPolicyFallback creates their account, controlled by the
+	// agreed FallbackAuthority.
+	UnregdPolicyFallback = "fallback"
+)
+
 type OpInjectUnregdSnapshot struct {
-	TestnetTruncateSnapshot int `json:"TESTNET_TRUNCATE_SNAPSHOT"`
+	// Policy is one of the UnregdPolicy*, `unregd` by default.
+	Policy string `json:"policy"`
+	// FallbackAuthority is the owner and active authority of the
+	// accounts created with the `fallback` policy, like a recovery
+	// multisig of the community.
+	FallbackAuthority *eos.Authority `json:"fallback_authority"`
+	// BuyRAM, or BuyRAMBytes, is the RAM bought for each account
+	// created with the `fallback` policy.
+	BuyRAM                  *RAMPurchase `json:"buy_ram"`
+	BuyRAMBytes             uint64       `json:"buy_ram_bytes"`
+	TestnetTruncateSnapshot int          `json:"TESTNET_TRUNCATE_SNAPSHOT"`
+}
+
+func (op *OpInjectUnregdSnapshot) validate() error {
+	switch op.Policy {
+	case "", UnregdPolicyClaim, UnregdPolicySkip:
+		return nil
+	case UnregdPolicyFallback:
+		auth := op.FallbackAuthority
+		if auth == nil || auth.Threshold == 0 || (len(auth.Keys) == 0 && len(auth.Accounts) == 0) {
+			return fmt.Errorf("the %q policy needs a fallback_authority with a threshold, and keys or accounts", UnregdPolicyFallback)
+		}
+		return nil
+	}
+	return fmt.Errorf("policy must be one of %q, %q or %q", UnregdPolicyClaim, UnregdPolicySkip, UnregdPolicyFallback)
 }
 
 func (op *OpInjectUnregdSnapshot) ResetTestnetOptions() {
@@ -432,6 +1302,14 @@ func (op *OpInjectUnregdSnapshot) ResetTestnetOptions() {
 }
 
 func (op *OpInjectUnregdSnapshot) Actions(b *BIOS) (out []*eos.Action, err error) {
+	if err := op.validate(); err != nil {
+		return nil, err
+	}
+	if op.Policy == UnregdPolicySkip {
+		b.Log.Printf("Unregistered token holders skipped, per the boot sequence's policy\n")
+		return nil, nil
+	}
+
 	snapshotFile, err := b.GetContentsCacheRef("snapshot_unregistered.csv")
 	if err != nil {
 		return nil, err
@@ -459,7 +1337,10 @@ func (op *OpInjectUnregdSnapshot) Actions(b *BIOS) (out []*eos.Action, err error
 			}
 		}
 
-		//system.NewDelegatedNewAccount(AN("eosio"), AN(hodler.AccountName), AN("eosio.unregd"))
+		if op.Policy == UnregdPolicyFallback {
+			out = append(out, op.fallbackAccount(b, hodler)...)
+			continue
+		}
 
 		out = append(out,
 			unregd.NewAdd(hodler.EthereumAddress, hodler.Balance),
@@ -471,6 +1352,31 @@ func (op *OpInjectUnregdSnapshot) Actions(b *BIOS) (out []*eos.Action, err error
 	return
 }
 
+// fallbackAccount creates the account of an unregistered holder with
+// the fallback authority, staked and funded like registered ones.
+func (op *OpInjectUnregdSnapshot) fallbackAccount(b *BIOS, hodler UnregdSnapshotLine) []*eos.Action {
+	destAccount := AN(hodler.AccountName)
+
+	newAccount := system.NewNewAccount(AN("eosio"), destAccount, ecc.PublicKey{}) // overridden just below
+	newAccount.ActionData = eos.NewActionData(system.NewAccount{
+		Creator: AN("eosio"),
+		Name:    destAccount,
+		Owner:   *op.FallbackAuthority,
+		Active:  *op.FallbackAuthority,
+	})
+
+	cpuStake, netStake, rest := b.SnapshotConfig.splitStakes(hodler.Balance)
+	memo := "Welcome " + hodler.EthereumAddress[len(hodler.EthereumAddress)-6:]
+	out := []*eos.Action{
+		newAccount,
+		system.NewDelegateBW(AN("eosio"), destAccount, cpuStake, netStake, true),
+	}
+	if buyRAM := ramPurchase(op.BuyRAM, op.BuyRAMBytes).action(destAccount); buyRAM != nil {
+		out = append(out, buyRAM)
+	}
+	return append(out, nil, token.NewTransfer(AN("eosio"), destAccount, rest, memo), nil)
+}
+
 //
 
 type OpSetProds struct{}
@@ -478,6 +1384,16 @@ type OpSetProds struct{}
 func (op *OpSetProds) ResetTestnetOptions() {}
 
 func (op *OpSetProds) Actions(b *BIOS) (out []*eos.Action, err error) {
+	return append(out, system.NewSetProds(op.producerKeys(b))), nil
+}
+
+// Confirm waits for the schedule to be pending or active on chain.
+func (op *OpSetProds) Confirm(b *BIOS) error {
+	return b.waitProducerSchedule(op.producerKeys(b), ScheduleConfirmTimeout)
+}
+
+// producerKeys returns the appointed schedule, in the shuffled order.
+func (op *OpSetProds) producerKeys(b *BIOS) []system.ProducerKey {
 	// We he can at least process the last few blocks, that wrap up
 	// and resigns the system accounts.
 	prodkeys := []system.ProducerKey{system.ProducerKey{
@@ -492,27 +1408,45 @@ func (op *OpSetProds) Actions(b *BIOS) (out []*eos.Action, err error) {
 		if idx == 0 {
 			continue
 		}
+		if len(prodkeys) >= b.scheduleSize() {
+			break
+		}
 		targetKey := prod.Discovery.TargetAppointedBlockProducerSigningKey
 		targetAcct := prod.Discovery.TargetAccountName
 		if targetAcct == AN("eosio") {
 			targetKey = b.EphemeralPublicKey
 		}
 		prodkeys = append(prodkeys, system.ProducerKey{targetAcct, targetKey})
-		if len(prodkeys) >= 21 {
-			break
-		}
 	}
 
-	out = append(out, system.NewSetProds(prodkeys))
-
-	return
+	return prodkeys
 }
 
 //
 
+// Resignation schemes of `system.resign_accounts`, for the system
+// accounts. `eosio` itself is always handed to `eosio.prods`.
+const (
+	// ResignToEOSIO hands the system accounts to `eosio@active`, so to
+	// the producers through `eosio`.
+	ResignToEOSIO = "eosio"
+	// ResignToProds hands them to `eosio.prods@active` directly.
+	ResignToProds = "prods"
+	// ResignToNull sets them to the null key, so no one controls them
+	// anymore, including the producers.
+	ResignToNull = "null"
+)
+
+// OpResignAccounts is the last step of the boot: it hands `eosio` to
+// the block producers' msig authority, and the system accounts to the
+// Scheme authority, so no single party keeps control of the chain.
+// With AllSystemAccounts, the accounts of the `system.create_accounts`
+// steps are resigned too. The boot lease marker always is.
 type OpResignAccounts struct {
 	Accounts            []eos.AccountName
-	TestnetKeepAccounts bool `json:"TESTNET_KEEP_ACCOUNTS"`
+	AllSystemAccounts   bool   `json:"all_system_accounts"`
+	Scheme              string `json:"scheme"`
+	TestnetKeepAccounts bool   `json:"TESTNET_KEEP_ACCOUNTS"`
 	IsMainnet           bool
 }
 
@@ -521,76 +1455,152 @@ func (op *OpResignAccounts) ResetTestnetOptions() {
 	op.IsMainnet = true
 }
 
+// nullKey is the public key no one holds the private key of,
+// `EOS1111111111111111111111111111111114T1Anm`.
+var nullKey = ecc.PublicKey{Curve: ecc.CurveK1, Content: make([]byte, 33, 33)}
+
+func permissionAuthority(actor eos.AccountName) eos.Authority {
+	return eos.Authority{
+		Threshold: 1,
+		Accounts: []eos.PermissionLevelWeight{
+			eos.PermissionLevelWeight{
+				Permission: eos.PermissionLevel{
+					Actor:      actor,
+					Permission: PN("active"),
+				},
+				Weight: 1,
+			},
+		},
+	}
+}
+
+// authority returns the authority the system accounts are handed to.
+func (op *OpResignAccounts) authority() (eos.Authority, error) {
+	switch op.Scheme {
+	case "", ResignToEOSIO:
+		return permissionAuthority(AN("eosio")), nil
+	case ResignToProds:
+		return permissionAuthority(AN("eosio.prods")), nil
+	case ResignToNull:
+		return eos.Authority{
+			Threshold: 1,
+			Keys:      []eos.KeyWeight{{PublicKey: nullKey, Weight: 1}},
+		}, nil
+	}
+	return eos.Authority{}, fmt.Errorf("unknown resignation scheme %q, expected %q, %q or %q", op.Scheme, ResignToEOSIO, ResignToProds, ResignToNull)
+}
+
+// accounts returns the system accounts to resign, `eosio` excluded.
+func (op *OpResignAccounts) accounts(b *BIOS) (out []eos.AccountName) {
+	seen := map[eos.AccountName]bool{AN("eosio"): true}
+	if op.IsMainnet {
+		seen[AN("eosio.disco")] = true
+	}
+
+	add := func(accounts []eos.AccountName) {
+		for _, acct := range accounts {
+			if !seen[acct] {
+				seen[acct] = true
+				out = append(out, acct)
+			}
+		}
+	}
+	add(op.Accounts)
+	add([]eos.AccountName{BootLeaseAccount})
+	if op.AllSystemAccounts {
+		for _, step := range b.BootSequence {
+			if create, ok := step.Data.(*OpCreateSystemAccounts); ok {
+				add(create.Accounts)
+			}
+		}
+	}
+	return
+}
+
 func (op *OpResignAccounts) Actions(b *BIOS) (out []*eos.Action, err error) {
 	if op.TestnetKeepAccounts {
 		b.Log.Debugln("DEBUG: Keeping system accounts around, for testing purposes.")
 		return
 	}
 
-	systemAccount := AN("eosio")
-	prodsAccount := AN("eosio.prods") // this is a special system account that is granted by 2/3 + 1 of the current BP schedule.
+	authority, err := op.authority()
+	if err != nil {
+		return nil, err
+	}
 
-	//nullKey := ecc.PublicKey{Curve: ecc.CurveK1, Content: make([]byte, 33, 33)}
-	for _, acct := range op.Accounts {
-		if acct == systemAccount || (op.IsMainnet && acct == eos.AccountName("eosio.disco")) {
-			continue // special treatment for `eosio` below
+	for _, acct := range op.accounts(b) {
+		// The lease marker's active permission is the operator's key,
+		// its owner `eosio@active`.
+		using := PN("active")
+		if acct == BootLeaseAccount {
+			using = PN("owner")
 		}
 		out = append(out,
-			system.NewUpdateAuth(acct, PN("active"), PN("owner"), eos.Authority{
-				Threshold: 1,
-				Accounts: []eos.PermissionLevelWeight{
-					eos.PermissionLevelWeight{
-						Permission: eos.PermissionLevel{
-							Actor:      AN("eosio"),
-							Permission: PN("active"),
-						},
-						Weight: 1,
-					},
-				},
-			}, PN("active")),
-			system.NewUpdateAuth(acct, PN("owner"), PN(""), eos.Authority{
-				Threshold: 1,
-				Accounts: []eos.PermissionLevelWeight{
-					eos.PermissionLevelWeight{
-						Permission: eos.PermissionLevel{
-							Actor:      AN("eosio"),
-							Permission: PN("active"),
-						},
-						Weight: 1,
-					},
-				},
-			}, PN("owner")),
+			system.NewUpdateAuth(acct, PN("active"), PN("owner"), authority, using),
+			system.NewUpdateAuth(acct, PN("owner"), PN(""), authority, PN("owner")),
 		)
 	}
 
+	// `eosio.prods` is a special system account that is granted by
+	// 2/3 + 1 of the current BP schedule, see `HandoffQuorum()`.
+	prodsAuthority := permissionAuthority(AN("eosio.prods"))
 	out = append(out,
-		system.NewUpdateAuth(systemAccount, PN("active"), PN("owner"), eos.Authority{
-			Threshold: 1,
-			Accounts: []eos.PermissionLevelWeight{
-				eos.PermissionLevelWeight{
-					Permission: eos.PermissionLevel{
-						Actor:      prodsAccount,
-						Permission: PN("active"),
-					},
-					Weight: 1,
-				},
-			},
-		}, PN("active")),
-		system.NewUpdateAuth(systemAccount, PN("owner"), PN(""), eos.Authority{
-			Threshold: 1,
-			Accounts: []eos.PermissionLevelWeight{
-				eos.PermissionLevelWeight{
-					Permission: eos.PermissionLevel{
-						Actor:      prodsAccount,
-						Permission: PN("active"),
-					},
-					Weight: 1,
-				},
-			},
-		}, PN("owner")),
+		system.NewUpdateAuth(AN("eosio"), PN("active"), PN("owner"), prodsAuthority, PN("active")),
+		system.NewUpdateAuth(AN("eosio"), PN("owner"), PN(""), prodsAuthority, PN("owner")),
 	)
 
 	out = append(out, nil)
 
 	return
 }
+
+// Verify checks the owner and active authorities of `eosio` and of the
+// system accounts are the ones they were handed to.
+func (op *OpResignAccounts) Verify(b *BIOS) error {
+	if op.TestnetKeepAccounts {
+		return nil
+	}
+
+	authority, err := op.authority()
+	if err != nil {
+		return err
+	}
+
+	expected := map[eos.AccountName]eos.Authority{AN("eosio"): permissionAuthority(AN("eosio.prods"))}
+	for _, acct := range op.accounts(b) {
+		expected[acct] = authority
+	}
+
+	for acct, auth := range expected {
+		resp, err := b.TargetNetAPI.GetAccount(acct)
+		if err != nil {
+			return fmt.Errorf("getting account %q: %s", acct, err)
+		}
+		for _, perm := range resp.Permissions {
+			if perm.PermName != "owner" && perm.PermName != "active" {
+				continue
+			}
+			if !sameAuthority(perm.RequiredAuth, auth) {
+				return fmt.Errorf("permission %q of %q wasn't resigned", perm.PermName, acct)
+			}
+		}
+	}
+	return nil
+}
+
+func sameAuthority(a, b eos.Authority) bool {
+	if a.Threshold != b.Threshold || len(a.Keys) != len(b.Keys) || len(a.Accounts) != len(b.Accounts) || len(a.Waits) != len(b.Waits) {
+		return false
+	}
+	for idx, key := range a.Keys {
+		if key.PublicKey.String() != b.Keys[idx].PublicKey.String() || key.Weight != b.Keys[idx].Weight {
+			return false
+		}
+	}
+	for idx, account := range a.Accounts {
+		if account != b.Accounts[idx] {
+			return false
+		}
+	}
+	return true
+}
@@ -1,10 +1,22 @@
 package bios
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/eoscanada/eos-bios/bios/disco"
 	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+	"github.com/eoscanada/eos-go/system"
+	"github.com/eoscanada/eos-go/token"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -54,9 +66,424 @@ func TestSnapshotDelegationAmounts(t *testing.T) {
 	}
 
 	for idx, test := range tests {
-		cpuStake, netStake, xfer := splitSnapshotStakes(test.balance)
+		cpuStake, netStake, xfer := defaultStakeSplit.apply(test.balance)
 		assert.Equal(t, test.cpuStake, cpuStake, fmt.Sprintf("idx=%d", idx))
 		assert.Equal(t, test.netStake, netStake, fmt.Sprintf("idx=%d", idx))
 		assert.Equal(t, test.xfer, xfer, fmt.Sprintf("idx=%d", idx))
 	}
 }
+
+func TestSeedVotes(t *testing.T) {
+	b := &BIOS{AppointedProducers: 2}
+	for _, name := range []string{"eosio", "prodb", "proda", "prodc"} {
+		b.ShuffledProducers = append(b.ShuffledProducers, &Peer{Discovery: &disco.Discovery{TargetAccountName: AN(name)}})
+	}
+
+	op := &OpSeedVotes{
+		Proxies: []eos.AccountName{"proxy1"},
+		Votes: []*SeededVote{
+			{Voter: "staker1", Proxy: "proxy1", StakeCPU: "10.0000 EOS"},
+			{Voter: "proxy1", Producers: []eos.AccountName{"proda"}, VoteAppointed: true},
+		},
+	}
+
+	acts, err := op.Actions(b)
+	assert.NoError(t, err)
+	if assert.Len(t, acts, 7) {
+		assert.Equal(t, eos.ActN("regproxy"), acts[0].Name)
+		assert.Nil(t, acts[1])
+		assert.Equal(t, eos.ActN("delegatebw"), acts[2].Name)
+		assert.Equal(t, eos.ActN("voteproducer"), acts[3].Name)
+		assert.Nil(t, acts[4])
+
+		vote := acts[5].Data.(system.VoteProducer)
+		assert.Equal(t, AN("proxy1"), vote.Voter)
+		assert.Equal(t, []eos.AccountName{"proda", "prodb"}, vote.Producers)
+	}
+
+	op.Votes = []*SeededVote{{Voter: "confused", Proxy: "proxy1", Producers: []eos.AccountName{"proda"}}}
+	_, err = op.Actions(b)
+	assert.Error(t, err)
+}
+
+type testOperation struct {
+	Account eos.AccountName
+}
+
+func (op *testOperation) Actions(b *BIOS) ([]*eos.Action, error) { return nil, nil }
+func (op *testOperation) ResetTestnetOptions()                   {}
+
+func TestRegisterOperation(t *testing.T) {
+	assert.NoError(t, RegisterOperation("test.operation", &testOperation{}))
+	defer delete(operationsRegistry, "test.operation")
+
+	assert.EqualError(t, RegisterOperation("test.operation", &testOperation{}), `operation type "test.operation" already registered`)
+	assert.Error(t, RegisterOperation("system.setcode", &testOperation{}))
+
+	var bootSeq struct {
+		BootSequence []*OperationType `json:"boot_sequence"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(`{"boot_sequence": [{"op": "test.operation", "label": "Custom", "data": {"account": "eosio"}}]}`), &bootSeq))
+	assert.Equal(t, AN("eosio"), bootSeq.BootSequence[0].Data.(*testOperation).Account)
+}
+
+func TestSnapshotCreateAccountsAlreadyApplied(t *testing.T) {
+	var lock sync.Mutex
+	var lookups []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var params struct {
+			AccountName string `json:"account_name"`
+			Scope       string `json:"scope"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&params))
+
+		if strings.HasSuffix(r.URL.Path, "/get_account") {
+			lock.Lock()
+			lookups = append(lookups, params.AccountName)
+			lock.Unlock()
+			if params.AccountName == "a3" {
+				http.Error(w, "unknown key", http.StatusInternalServerError)
+				return
+			}
+			fmt.Fprintf(w, `{"account_name": %q, "core_liquid_balance": "10.0000 EOS"}`, params.AccountName)
+			return
+		}
+
+		cpu := "0.2500 EOS"
+		if params.Scope == "a2" {
+			cpu = "0.1000 EOS"
+		}
+		fmt.Fprintf(w, `{"rows": [{"owner": %q, "cpu_weight": %q, "net_weight": "0.2500 EOS"}]}`, params.Scope, cpu)
+	}))
+	defer server.Close()
+
+	b := &BIOS{Log: NewLogger(), TargetNetAPI: eos.New(server.URL)}
+	chunk := func(name string) []*eos.Action {
+		return []*eos.Action{
+			system.NewNewAccount(AN("eosio"), AN(name), ecc.PublicKey{}),
+			system.NewDelegateBW(AN("eosio"), AN(name), eos.NewEOSAsset(2500), eos.NewEOSAsset(2500), true),
+		}
+	}
+
+	op := &OpSnapshotCreateAccounts{
+		LookupBatch: 3,
+		accounts:    []eos.AccountName{AN("a1"), AN("a2"), AN("a3")},
+		position:    map[eos.AccountName]int{AN("a1"): 0, AN("a2"): 1, AN("a3"): 2},
+	}
+
+	applied, decided, err := op.AlreadyApplied(b, chunk("a1"))
+	assert.NoError(t, err)
+	assert.True(t, applied && decided)
+	assert.Len(t, lookups, 3)
+
+	_, _, err = op.AlreadyApplied(b, chunk("a2"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `account "a2" exists with`)
+
+	applied, decided, err = op.AlreadyApplied(b, chunk("a3"))
+	assert.NoError(t, err)
+	assert.True(t, decided)
+	assert.False(t, applied)
+	assert.Len(t, lookups, 3)
+
+	// Looked up accounts are used once, then looked up again.
+	applied, _, err = op.AlreadyApplied(b, chunk("a3"))
+	assert.NoError(t, err)
+	assert.False(t, applied)
+	assert.Len(t, lookups, 4)
+
+	// Batched rows, created along with their transfer.
+	transfer := func(name string, amount int64) *eos.Action {
+		return token.NewTransfer(AN("eosio"), AN(name), eos.NewEOSAsset(amount), "Welcome")
+	}
+	op.LookupBatch = 0
+	batch := append(append(chunk("a1"), transfer("a1", 100000)), chunk("a1")...)
+	applied, decided, err = op.AlreadyApplied(b, batch)
+	assert.NoError(t, err)
+	assert.True(t, applied && decided)
+
+	_, _, err = op.AlreadyApplied(b, append(append(chunk("a1"), transfer("a1", 100000)), chunk("a3")...))
+	assert.EqualError(t, err, `only 1 of the 2 accounts created by this transaction (from "a1") exist`)
+
+	_, _, err = op.AlreadyApplied(b, append(chunk("a1"), transfer("a1", 5)))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `account "a1" exists holding`)
+}
+
+func TestSnapshotCreateAccountsBatches(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eos-bios-snapshot")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var rows []string
+	for _, row := range []string{"0x0000000000000000000000000000000000000001,a1", "0x00000000000000000000000000000000000000b1,b1", "0x0000000000000000000000000000000000000003,a3", "0x0000000000000000000000000000000000000004,a4", "0x0000000000000000000000000000000000000005,a5"} {
+		rows = append(rows, row+",EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV,10.0000 EOS")
+	}
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, replaceAllWeirdities("/ipfs/Qmsnapshot")), []byte(strings.Join(rows, "\n")), 0644))
+
+	b := &BIOS{
+		Log:     NewLogger(),
+		Network: &Network{cachePath: dir},
+		LaunchDisco: &disco.Discovery{
+			TargetContents: []disco.ContentRef{{Name: "snapshot.csv", Ref: "/ipfs/Qmsnapshot"}},
+		},
+	}
+
+	var chunkSizes []int
+	op := &OpSnapshotCreateAccounts{BatchSize: 2, BuyRAMBytes: 8192}
+	acts, err := op.Actions(b)
+	assert.NoError(t, err)
+	for _, chunk := range ChunkifyActions(acts) {
+		chunkSizes = append(chunkSizes, len(chunk))
+	}
+	// `b1` goes alone, and isn't created.
+	assert.Equal(t, []int{4, 3, 8, 4}, chunkSizes)
+	assert.Equal(t, []eos.AccountName{AN("a1"), AN("a3"), AN("a4"), AN("a5")}, op.accounts)
+
+	chunkSizes = nil
+	op = &OpSnapshotCreateAccounts{BuyRAMBytes: 8192}
+	acts, err = op.Actions(b)
+	assert.NoError(t, err)
+	for _, chunk := range ChunkifyActions(acts) {
+		chunkSizes = append(chunkSizes, len(chunk))
+	}
+	assert.Equal(t, []int{3, 1, 2, 1, 3, 1, 3, 1, 3, 1}, chunkSizes)
+}
+
+func TestCreateSystemAccounts(t *testing.T) {
+	var data struct {
+		Data *OpCreateSystemAccounts
+	}
+	assert.NoError(t, yamlUnmarshal([]byte("data:\n  accounts: [eosio.token, eosio.msig]\n"), &data))
+	op := data.Data
+	acts, err := op.Actions(&BIOS{})
+	assert.NoError(t, err)
+	if assert.Len(t, acts, 2) {
+		newAccount := acts[1].ActionData.Data.(system.NewAccount)
+		assert.Equal(t, AN("eosio"), newAccount.Creator)
+		assert.Equal(t, AN("eosio.msig"), newAccount.Name)
+		assert.Equal(t, AN("eosio"), newAccount.Owner.Accounts[0].Permission.Actor)
+		assert.Equal(t, newAccount.Owner, newAccount.Active)
+	}
+
+	op.Authority = &eos.Authority{Threshold: 1, Accounts: []eos.PermissionLevelWeight{{Permission: eos.PermissionLevel{Actor: AN("eosio.prods"), Permission: PN("active")}, Weight: 1}}}
+	acts, err = op.Actions(&BIOS{})
+	assert.NoError(t, err)
+	assert.Equal(t, AN("eosio.prods"), acts[0].ActionData.Data.(system.NewAccount).Active.Accounts[0].Permission.Actor)
+
+	op.Authority = &eos.Authority{}
+	_, err = op.Actions(&BIOS{})
+	assert.Error(t, err)
+
+	op = &OpCreateSystemAccounts{Accounts: []eos.AccountName{"eosio.ram", "eosio.ram"}}
+	_, err = op.Actions(&BIOS{})
+	assert.EqualError(t, err, `system account "eosio.ram" listed twice`)
+}
+
+func TestSetPriv(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var params struct {
+			AccountName string `json:"account_name"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&params))
+		fmt.Fprintf(w, `{"account_name": %q, "privileged": %t}`, params.AccountName, params.AccountName != "eosio.wrap")
+	}))
+	defer server.Close()
+	b := &BIOS{TargetNetAPI: eos.New(server.URL)}
+
+	op := &OpSetPriv{Account: "eosio.msig", Accounts: []eos.AccountName{"eosio.forum"}}
+	acts, err := op.Actions(b)
+	assert.NoError(t, err)
+	assert.Len(t, acts, 2)
+	assert.Equal(t, eos.ActN("setpriv"), acts[1].Name)
+	assert.NoError(t, op.Verify(b))
+
+	op.Accounts = append(op.Accounts, "eosio.wrap")
+	assert.EqualError(t, op.Verify(b), `"eosio.wrap" is not privileged`)
+
+	_, err = (&OpSetPriv{}).Actions(b)
+	assert.Error(t, err)
+}
+
+func TestResignAccounts(t *testing.T) {
+	b := &BIOS{
+		Log: NewLogger(),
+		BootSequence: []*OperationType{
+			{Op: "system.create_accounts", Data: &OpCreateSystemAccounts{Accounts: []eos.AccountName{"eosio.token", "eosio.ram"}}},
+		},
+	}
+
+	op := &OpResignAccounts{Accounts: []eos.AccountName{"eosio", "eosio.msig", "eosio.token"}}
+	acts, err := op.Actions(b)
+	assert.NoError(t, err)
+	assert.Len(t, acts, 9) // eosio.msig, eosio.token, eosio.lease, then eosio
+	assert.Nil(t, acts[8])
+	assert.Equal(t, []eos.PermissionLevel{{Actor: BootLeaseAccount, Permission: PN("owner")}}, acts[4].Authorization)
+
+	op.AllSystemAccounts = true
+	assert.Equal(t, []eos.AccountName{"eosio.msig", "eosio.token", "eosio.lease", "eosio.ram"}, op.accounts(b))
+
+	auth, err := op.authority()
+	assert.NoError(t, err)
+	assert.True(t, sameAuthority(permissionAuthority(AN("eosio")), auth))
+
+	op.Scheme = ResignToNull
+	auth, err = op.authority()
+	assert.NoError(t, err)
+	if assert.Len(t, auth.Keys, 1) {
+		assert.Equal(t, nullKey.String(), auth.Keys[0].PublicKey.String())
+	}
+
+	op.Scheme = "nobody"
+	_, err = op.Actions(b)
+	assert.Error(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var params struct {
+			AccountName string `json:"account_name"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&params))
+		actor := "eosio"
+		if params.AccountName == "eosio" || params.AccountName == "eosio.ram" {
+			actor = "eosio.prods"
+		}
+		perm := fmt.Sprintf(`{"threshold": 1, "accounts": [{"permission": {"actor": %q, "permission": "active"}, "weight": 1}]}`, actor)
+		fmt.Fprintf(w, `{"permissions": [{"perm_name": "active", "required_auth": %s}, {"perm_name": "owner", "required_auth": %s}]}`, perm, perm)
+	}))
+	defer server.Close()
+	b.TargetNetAPI = eos.New(server.URL)
+
+	op = &OpResignAccounts{Accounts: []eos.AccountName{"eosio.msig"}}
+	assert.NoError(t, op.Verify(b))
+	op.AllSystemAccounts = true
+	assert.Error(t, op.Verify(b), "eosio.ram was handed to eosio.prods")
+
+	op = &OpResignAccounts{TestnetKeepAccounts: true, Scheme: ResignToProds}
+	acts, err = op.Actions(b)
+	assert.NoError(t, err)
+	assert.Len(t, acts, 0)
+	assert.NoError(t, op.Verify(b))
+}
+
+func TestRAMPurchase(t *testing.T) {
+	var purchase struct {
+		BuyRAM *RAMPurchase `json:"buy_ram"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(`{"buy_ram": 8192}`), &purchase))
+	assert.Equal(t, &RAMPurchase{Bytes: 8192}, purchase.BuyRAM)
+	assert.NoError(t, json.Unmarshal([]byte(`{"buy_ram": "8192"}`), &purchase))
+	assert.Equal(t, &RAMPurchase{Bytes: 8192}, purchase.BuyRAM)
+	assert.NoError(t, json.Unmarshal([]byte(`{"buy_ram": "1.0000 EOS"}`), &purchase))
+	if assert.NotNil(t, purchase.BuyRAM.EOS) {
+		assert.Equal(t, int64(10000), purchase.BuyRAM.EOS.Amount)
+	}
+	assert.Error(t, json.Unmarshal([]byte(`{"buy_ram": "lots"}`), &purchase))
+	assert.Error(t, json.Unmarshal([]byte(`{"buy_ram": "-1.0000 EOS"}`), &purchase))
+
+	assert.Equal(t, eos.ActN("buyram"), purchase.BuyRAM.action(AN("a1")).Name)
+	assert.Equal(t, eos.ActN("buyrambytes"), ramPurchase(nil, 8192).action(AN("a1")).Name)
+	assert.Nil(t, ramPurchase(nil, 0).action(AN("a1")))
+	assert.Nil(t, (*RAMPurchase)(nil).action(AN("a1")))
+}
+
+func TestInjectUnregdSnapshotPolicies(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eos-bios-snapshot")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	unregistered := "0x0000000000000000000000000000000000000001,holder1,10.0000\n0x0000000000000000000000000000000000000002,holder2,20.0000\n"
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, replaceAllWeirdities("/ipfs/Qmunregd")), []byte(unregistered), 0644))
+
+	b := &BIOS{
+		Log:     NewLogger(),
+		Network: &Network{cachePath: dir},
+		LaunchDisco: &disco.Discovery{
+			TargetContents: []disco.ContentRef{{Name: "snapshot_unregistered.csv", Ref: "/ipfs/Qmunregd"}},
+		},
+	}
+
+	op := &OpInjectUnregdSnapshot{}
+	acts, err := op.Actions(b)
+	assert.NoError(t, err)
+	assert.Len(t, ChunkifyActions(acts), 2)
+	assert.Equal(t, AN("eosio.unregd"), acts[0].Account)
+
+	op = &OpInjectUnregdSnapshot{Policy: UnregdPolicySkip}
+	acts, err = op.Actions(b)
+	assert.NoError(t, err)
+	assert.Len(t, acts, 0)
+
+	op = &OpInjectUnregdSnapshot{Policy: UnregdPolicyFallback}
+	_, err = op.Actions(b)
+	assert.Error(t, err)
+
+	op = &OpInjectUnregdSnapshot{Policy: "burn"}
+	_, err = op.Actions(b)
+	assert.Error(t, err)
+
+	var data struct {
+		Data *OpInjectUnregdSnapshot
+	}
+	assert.NoError(t, json.Unmarshal([]byte(`{"data": {"policy": "fallback", "buy_ram_bytes": 8192, "fallback_authority": {"threshold": 1, "accounts": [{"permission": {"actor": "eosio.prods", "permission": "active"}, "weight": 1}]}}}`), &data))
+	op = data.Data
+	acts, err = op.Actions(b)
+	assert.NoError(t, err)
+	chunks := ChunkifyActions(acts)
+	if assert.Len(t, chunks, 4) {
+		assert.Equal(t, eos.ActN("newaccount"), chunks[0][0].Name)
+		newAccount := chunks[0][0].ActionData.Data.(system.NewAccount)
+		assert.Equal(t, AN("holder1"), newAccount.Name)
+		assert.Equal(t, *op.FallbackAuthority, newAccount.Owner)
+		assert.Equal(t, *op.FallbackAuthority, newAccount.Active)
+		assert.Equal(t, eos.ActN("transfer"), chunks[1][0].Name)
+	}
+}
+
+func TestDistributeToken(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eos-bios-snapshot")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, replaceAllWeirdities("/ipfs/Qmtoken")), []byte("holder1,10.0000 COMM\nholder2,0.5000 COMM\n"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, replaceAllWeirdities("/ipfs/Qmother")), []byte("holder1,10.0000 COMM\nholder2,0.5000 OTHER\n"), 0644))
+
+	b := &BIOS{
+		Log:     NewLogger(),
+		Network: &Network{cachePath: dir},
+		LaunchDisco: &disco.Discovery{
+			TargetContents: []disco.ContentRef{
+				{Name: "snapshot_comm.csv", Ref: "/ipfs/Qmtoken"},
+				{Name: "snapshot_other.csv", Ref: "/ipfs/Qmother"},
+			},
+		},
+	}
+
+	maxSupply, err := eos.NewAsset("100.0000 COMM")
+	assert.NoError(t, err)
+	op := &OpDistributeToken{Contract: AN("comm.token"), Issuer: AN("comm"), MaxSupply: maxSupply, SnapshotRef: "snapshot_comm.csv", Memo: "airdrop"}
+	acts, err := op.Actions(b)
+	assert.NoError(t, err)
+	chunks := ChunkifyActions(acts)
+	if assert.Len(t, chunks, 3) && assert.Len(t, chunks[0], 2) {
+		create, issue := chunks[0][0], chunks[0][1]
+		assert.Equal(t, AN("comm.token"), create.Account)
+		assert.Equal(t, eos.ActN("create"), create.Name)
+		assert.Equal(t, []eos.PermissionLevel{{Actor: AN("comm.token"), Permission: PN("active")}}, create.Authorization)
+		assert.Equal(t, AN("comm.token"), issue.Account)
+		assert.Equal(t, []eos.PermissionLevel{{Actor: AN("comm"), Permission: PN("active")}}, issue.Authorization)
+		assert.Equal(t, int64(105000), issue.ActionData.Data.(token.Issue).Quantity.Amount)
+
+		transfer := chunks[2][0]
+		assert.Equal(t, AN("comm.token"), transfer.Account)
+		assert.Equal(t, AN("holder2"), transfer.ActionData.Data.(token.Transfer).To)
+	}
+
+	op.MaxSupply.Amount = 100000
+	_, err = op.Actions(b)
+	assert.EqualError(t, err, `"snapshot_comm.csv" distributes 10.5000 COMM, more than the max_supply of 10.0000 COMM`)
+
+	op.MaxSupply.Amount = 1000000
+	op.SnapshotRef = "snapshot_other.csv"
+	_, err = op.Actions(b)
+	assert.EqualError(t, err, `"snapshot_other.csv" line 2: balance 0.5000 OTHER doesn't match the symbol of max_supply 100.0000 COMM`)
+}
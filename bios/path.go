@@ -1,7 +1,10 @@
 package bios
 
 import (
+	"net/url"
+	"path/filepath"
 	"regexp"
+	"strings"
 )
 
 var weirdities = regexp.MustCompile("[^a-zA-Z0-9]")
@@ -9,3 +12,27 @@ var weirdities = regexp.MustCompile("[^a-zA-Z0-9]")
 func replaceAllWeirdities(input string) string {
 	return weirdities.ReplaceAllString(input, "_")
 }
+
+var windowsDrive = regexp.MustCompile(`^/[a-zA-Z]:`)
+
+// localPath turns a `file://` URL, or a plain path, into a path for
+// this OS. `file:///C:/launch` gives `C:\launch` on Windows, and
+// `file:///srv/launch` gives `/srv/launch` elsewhere.
+func localPath(location string) string {
+	if !strings.HasPrefix(location, "file://") {
+		return filepath.FromSlash(location)
+	}
+
+	path := strings.TrimPrefix(location, "file://")
+	if u, err := url.Parse(location); err == nil {
+		path = u.Path
+		if u.Host != "" && u.Host != "localhost" {
+			// UNC share, `file://server/share/dir`
+			path = "//" + u.Host + u.Path
+		}
+	}
+	if windowsDrive.MatchString(path) {
+		path = path[1:]
+	}
+	return filepath.FromSlash(path)
+}
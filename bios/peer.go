@@ -14,6 +14,12 @@ type Peer struct {
 	UpdatedAt   time.Time
 	TotalWeight int
 
+	// PGPPublicKey is the armored key of the Keybase user listed in
+	// the discovery file, with its PGPFingerprint, for the steps
+	// encrypting data to the producers.
+	PGPPublicKey   string
+	PGPFingerprint string
+
 	// ClonedAccountName string // A variation on the `Discovery`'s
 }
 
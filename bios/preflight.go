@@ -0,0 +1,322 @@
+package bios
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// PreflightMaxClockDrift is the largest difference tolerated between
+// the local clock and the seed network's head block time.
+var PreflightMaxClockDrift = 5 * time.Second
+
+type PreflightStatus string
+
+const (
+	PreflightGo   PreflightStatus = "GO"
+	PreflightWarn PreflightStatus = "WARN"
+	PreflightNoGo PreflightStatus = "NO-GO"
+)
+
+type PreflightCheck struct {
+	Name   string
+	Status PreflightStatus
+	Detail string
+}
+
+func preflightResult(name, detail string, err error) *PreflightCheck {
+	if err != nil {
+		return &PreflightCheck{Name: name, Status: PreflightNoGo, Detail: err.Error()}
+	}
+	return &PreflightCheck{Name: name, Status: PreflightGo, Detail: detail}
+}
+
+// Preflight runs every check we can do ahead of the launch window,
+// on an `Init()`ed BIOS. `nodeVersion`, when set, is the prefix the
+// target node's `server_version` must have.
+func (b *BIOS) Preflight(nodeVersion string) (out []*PreflightCheck) {
+	out = append(out,
+		b.preflightLaunchData(),
+		b.preflightContents(),
+		b.preflightSnapshot(),
+		b.preflightBootSequence(),
+		b.preflightWalletKeys(),
+		b.preflightSeedNetwork(),
+		b.preflightClock(),
+		b.preflightEntropy(),
+		b.preflightTargetNode(nodeVersion),
+		b.preflightPeers(),
+		b.preflightBPJSON(),
+		b.preflightQuorum(),
+		b.preflightLaunchTime(),
+	)
+	return
+}
+
+// PreflightPassed returns whether none of the checks is a no-go.
+func PreflightPassed(checks []*PreflightCheck) bool {
+	for _, check := range checks {
+		if check.Status == PreflightNoGo {
+			return false
+		}
+	}
+	return true
+}
+
+func PrintPreflight(w io.Writer, checks []*PreflightCheck) {
+	for _, check := range checks {
+		fmt.Fprintf(w, "[%-5s] %-14s %s\n", check.Status, check.Name, check.Detail)
+	}
+	fmt.Fprintln(w, "")
+	if PreflightPassed(checks) {
+		fmt.Fprintln(w, "GO for launch.")
+	} else {
+		fmt.Fprintln(w, "NO-GO for launch.")
+	}
+}
+
+func (b *BIOS) preflightLaunchData() *PreflightCheck {
+	if err := ValidateDiscovery(b.Network.MyPeer.Discovery); err != nil {
+		return preflightResult("launch_data", "", fmt.Errorf("my discovery file: %s", err))
+	}
+
+	if b.LaunchDisco.SeedNetworkLaunchBlock == 0 {
+		return preflightResult("launch_data", "", fmt.Errorf("no launch block agreed upon"))
+	}
+
+	return preflightResult("launch_data", fmt.Sprintf("consensus reached, launch block %d, %d contents", b.LaunchDisco.SeedNetworkLaunchBlock, len(b.LaunchDisco.TargetContents)), nil)
+}
+
+func (b *BIOS) preflightContents() *PreflightCheck {
+	verified := 0
+	for _, content := range b.LaunchDisco.TargetContents {
+		cnt, err := b.Network.ReadFromCache(content.Ref)
+		if err != nil {
+			return preflightResult("contents", "", fmt.Errorf("%q not downloaded: %s", content.Name, err))
+		}
+
+		if expected := ContentSHA256FromComment(content.Comment); expected != "" {
+			if actual := sha2(cnt); actual != expected {
+				return preflightResult("contents", "", fmt.Errorf("%q has sha256 %s, expected %s", content.Name, actual, expected))
+			}
+			verified++
+		}
+	}
+
+	contracts, err := b.preflightContractHashes()
+	if err != nil {
+		return preflightResult("contents", "", err)
+	}
+
+	return preflightResult("contents", fmt.Sprintf("%d files cached, %d verified against their sha256, %d contracts against their contract hash", len(b.LaunchDisco.TargetContents), verified, contracts), nil)
+}
+
+// preflightContractHashes checks the contracts pinned with a
+// `contract:` hash, over their cached `.wasm` and `.abi`, as setting
+// their code does.
+func (b *BIOS) preflightContractHashes() (verified int, err error) {
+	for _, content := range b.LaunchDisco.TargetContents {
+		if !strings.HasSuffix(content.Name, ".wasm") || ContentContractHashFromComment(content.Comment) == "" {
+			continue
+		}
+
+		name := strings.TrimSuffix(content.Name, ".wasm")
+		abiRef, err := b.GetContentsCacheRef(name + ".abi")
+		if err != nil {
+			return verified, fmt.Errorf("contract %q pinned without its abi: %s", name, err)
+		}
+
+		if err := b.verifyContractHash(name, b.Network.FileNameFromCache(content.Ref), b.Network.FileNameFromCache(abiRef)); err != nil {
+			return verified, err
+		}
+		verified++
+	}
+	return verified, nil
+}
+
+func (b *BIOS) preflightSnapshot() *PreflightCheck {
+	if _, err := b.snapshotContentRef(); err != nil {
+		return &PreflightCheck{Name: "snapshot", Status: PreflightWarn, Detail: "no snapshot in the launch data"}
+	}
+	return preflightResult("snapshot", "no duplicate accounts, malformed keys or balances", b.validateSnapshot())
+}
+
+// preflightBootSequence renders the actions of every step, which loads
+// the snapshot, contracts and scripts they reference.
+func (b *BIOS) preflightBootSequence() *PreflightCheck {
+	count := 0
+	for _, step := range b.BootSequence {
+		acts, err := b.stepActions(step)
+		if err != nil {
+			return preflightResult("boot_sequence", "", fmt.Errorf("step %q [%s]: %s", step.Label, step.Op, err))
+		}
+		count += len(acts)
+	}
+
+	return preflightResult("boot_sequence", fmt.Sprintf("%d steps render %d actions (snapshot and contracts loaded)", len(b.BootSequence), count), nil)
+}
+
+func (b *BIOS) preflightWalletKeys() *PreflightCheck {
+	if b.ReadOnly {
+		return &PreflightCheck{Name: "wallet_keys", Status: PreflightWarn, Detail: "read-only mode, no keys loaded"}
+	}
+
+	keys, err := b.Network.SeedNetAPI.Signer.AvailableKeys()
+	if err != nil {
+		return preflightResult("wallet_keys", "", fmt.Errorf("listing seed network keys: %s", err))
+	}
+
+	account := b.Network.MyPeer.Discovery.SeedNetworkAccountName
+	auth, err := b.Network.ActiveAuthority(account)
+	if err != nil {
+		return preflightResult("wallet_keys", "", err)
+	}
+
+	// Authorities can require several keys, possibly held by several
+	// signers (see `--seednet-signer`).
+	weight := AuthorityWeight(auth, keys)
+	if weight < auth.Threshold {
+		return preflightResult("wallet_keys", "", fmt.Errorf("the %d keys loaded weigh %d for %s@active, threshold is %d", len(keys), weight, account, auth.Threshold))
+	}
+
+	return preflightResult("wallet_keys", fmt.Sprintf("can sign for %s@active (weight %d, threshold %d)", account, weight, auth.Threshold), nil)
+}
+
+func (b *BIOS) preflightSeedNetwork() *PreflightCheck {
+	info, err := b.Network.SeedNetAPI.GetInfo()
+	if err != nil {
+		return preflightResult("seed_network", "", fmt.Errorf("get info: %s", err))
+	}
+
+	expectedChainID := b.Network.MyPeer.Discovery.SeedNetworkChainID
+	if len(expectedChainID) != 0 && !bytes.Equal(expectedChainID, info.ChainID) {
+		return preflightResult("seed_network", "", fmt.Errorf("chain ID is %s, expected %s", info.ChainID, expectedChainID))
+	}
+
+	launchBlock := b.LaunchDisco.SeedNetworkLaunchBlock
+	if uint64(info.HeadBlockNum) >= launchBlock {
+		return preflightResult("seed_network", "", fmt.Errorf("head block %d is past the launch block %d", info.HeadBlockNum, launchBlock))
+	}
+
+	// Blocks are produced every half-second.
+	eta := time.Duration(launchBlock-uint64(info.HeadBlockNum)) * 500 * time.Millisecond
+	return preflightResult("seed_network", fmt.Sprintf("%s at block %d, launch block in ~%s (source of the shuffle randomness)", info.ServerVersion, info.HeadBlockNum, eta), nil)
+}
+
+func (b *BIOS) preflightClock() *PreflightCheck {
+	info, err := b.Network.SeedNetAPI.GetInfo()
+	if err != nil {
+		return preflightResult("clock", "", fmt.Errorf("get seed network info: %s", err))
+	}
+
+	drift := time.Since(info.HeadBlockTime.Time)
+	if drift < 0 {
+		drift = -drift
+	}
+	if drift > PreflightMaxClockDrift {
+		return preflightResult("clock", "", fmt.Errorf("local clock is %s off the seed network's head block time", drift))
+	}
+
+	return preflightResult("clock", fmt.Sprintf("within %s of the seed network's head block time", drift), nil)
+}
+
+func (b *BIOS) preflightTargetNode(nodeVersion string) *PreflightCheck {
+	info, err := b.TargetNetAPI.GetInfo()
+	if err != nil {
+		return preflightResult("target_node", "", fmt.Errorf("get info: %s", err))
+	}
+
+	if nodeVersion != "" && !strings.HasPrefix(info.ServerVersion, nodeVersion) {
+		return preflightResult("target_node", "", fmt.Errorf("running version %s, expected %s", info.ServerVersion, nodeVersion))
+	}
+
+	return preflightResult("target_node", fmt.Sprintf("reachable, running version %s", info.ServerVersion), nil)
+}
+
+// preflightPeers dials the p2p endpoints of the other participants.
+// Hours before the launch, their nodes might not be up yet, so this
+// only warns.
+func (b *BIOS) preflightPeers() *PreflightCheck {
+	var unreachable []string
+	total := 0
+	for _, peer := range b.Network.OrderedPeers(b.Network.MyNetwork()) {
+		addr := b.p2pAddress(peer)
+		if addr == "" || addr == "none" || peer.Discovery.TargetAccountName == b.Network.MyPeer.Discovery.TargetAccountName {
+			continue
+		}
+		total++
+
+		conn, err := net.DialTimeout("tcp", addr, 3*time.Second)
+		if err != nil {
+			unreachable = append(unreachable, fmt.Sprintf("%s (%s)", peer.Discovery.TargetAccountName, addr))
+			continue
+		}
+		conn.Close()
+	}
+
+	detail := fmt.Sprintf("%d of %d p2p endpoints reachable", total-len(unreachable), total)
+	if len(unreachable) != 0 {
+		return &PreflightCheck{Name: "peers", Status: PreflightWarn, Detail: detail + ", unreachable: " + strings.Join(unreachable, ", ")}
+	}
+	return preflightResult("peers", detail, nil)
+}
+
+// preflightBPJSON checks the `bp.json` of the participants against
+// their discovery files. A misconfigured website doesn't stop the
+// launch, so this only warns.
+func (b *BIOS) preflightBPJSON() *PreflightCheck {
+	checks := CheckBPJSON(b.Network.OrderedPeers(b.Network.MyNetwork()))
+
+	var problems []string
+	for _, check := range checks {
+		for _, problem := range check.Problems {
+			problems = append(problems, fmt.Sprintf("%s: %s", check.Account, problem))
+		}
+	}
+
+	if len(problems) != 0 {
+		return &PreflightCheck{Name: "bp_json", Status: PreflightWarn, Detail: fmt.Sprintf("%d inconsistencies: %s", len(problems), strings.Join(problems, "; "))}
+	}
+	return preflightResult("bp_json", fmt.Sprintf("%d producers' bp.json match the launch data", len(checks)), nil)
+}
+
+// preflightQuorum only warns, as producers may well bring their nodes
+// up closer to the launch; the boot node waits for them then.
+func (b *BIOS) preflightQuorum() *PreflightCheck {
+	if b.Quorum == nil {
+		return preflightResult("quorum", "no minimum of producers required to boot", nil)
+	}
+
+	present, missing := b.quorumPresence(b.Network.OrderedPeers(b.Network.MyNetwork()))
+	if len(present) < b.Quorum.MinProducers {
+		return &PreflightCheck{Name: "quorum", Status: PreflightWarn, Detail: fmt.Sprintf("%d of %d producers required to boot present, missing: %s", len(present), b.Quorum.MinProducers, strings.Join(missing, ", "))}
+	}
+	return preflightResult("quorum", fmt.Sprintf("%d producers present, %d required to boot", len(present), b.Quorum.MinProducers), nil)
+}
+
+func (b *BIOS) preflightLaunchTime() *PreflightCheck {
+	if b.LaunchTime.IsZero() {
+		return preflightResult("launch_time", "no launch time, the boot node boots as soon as it's ready", nil)
+	}
+
+	at := b.LaunchTime.Format(time.RFC3339)
+	if remaining := time.Until(b.LaunchTime); remaining > 0 {
+		return preflightResult("launch_time", fmt.Sprintf("boot node signs nothing before %s, in %s", at, remaining.Round(time.Second)), nil)
+	}
+	return &PreflightCheck{Name: "launch_time", Status: PreflightWarn, Detail: fmt.Sprintf("launch time %s already passed", at)}
+}
+
+func (b *BIOS) preflightEntropy() *PreflightCheck {
+	switch provider := b.EntropyProvider.(type) {
+	case *bitcoinEntropy:
+		return b.preflightBitcoin()
+	case *drandEntropy:
+		return preflightResult("entropy", fmt.Sprintf("drand round %d seeds the shuffle", provider.config.Round), nil)
+	case *nistEntropy:
+		return preflightResult("entropy", fmt.Sprintf("NIST beacon chain %d pulse %d seeds the shuffle", provider.config.Chain, provider.config.Pulse), nil)
+	}
+	return preflightResult("entropy", "the seed network launch block seeds the shuffle", nil)
+}
@@ -0,0 +1,66 @@
+package bios
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eoscanada/eos-bios/bios/disco"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreflightVerdict(t *testing.T) {
+	checks := []*PreflightCheck{
+		preflightResult("contents", "all good", nil),
+		{Name: "peers", Status: PreflightWarn, Detail: "1 of 2 p2p endpoints reachable"},
+	}
+	assert.True(t, PreflightPassed(checks))
+
+	buf := &bytes.Buffer{}
+	PrintPreflight(buf, checks)
+	assert.Contains(t, buf.String(), "[GO   ] contents")
+	assert.Contains(t, buf.String(), "GO for launch.")
+
+	checks = append(checks, preflightResult("clock", "", errors.New("local clock is 10s off")))
+	assert.False(t, PreflightPassed(checks))
+
+	buf.Reset()
+	PrintPreflight(buf, checks)
+	assert.Contains(t, buf.String(), "[NO-GO] clock          local clock is 10s off")
+	assert.Contains(t, buf.String(), "NO-GO for launch.")
+}
+
+func TestPreflightContractHashes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eos-bios-preflight")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, replaceAllWeirdities("/ipfs/Qmcode")), []byte("code"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, replaceAllWeirdities("/ipfs/Qmabi")), []byte("abi"), 0644))
+
+	wasm := disco.ContentRef{Name: "eosio.token.wasm", Ref: "/ipfs/Qmcode", Comment: "contract:ae5aefab1cd4c5d6e237033ccd28979c63768f171acf50d8aaed73e69b292462"}
+	b := &BIOS{
+		Log:     NewLogger(),
+		Network: &Network{cachePath: dir},
+		LaunchDisco: &disco.Discovery{
+			TargetContents: []disco.ContentRef{wasm, {Name: "eosio.token.abi", Ref: "/ipfs/Qmabi"}},
+		},
+	}
+
+	check := b.preflightContents()
+	assert.Equal(t, PreflightGo, check.Status)
+	assert.Equal(t, "2 files cached, 0 verified against their sha256, 1 contracts against their contract hash", check.Detail)
+
+	b.LaunchDisco.TargetContents[0].Comment = "contract:0000000000000000000000000000000000000000000000000000000000000000"
+	check = b.preflightContents()
+	assert.Equal(t, PreflightNoGo, check.Status)
+	assert.Contains(t, check.Detail, `contract "eosio.token" has hash ae5aefab`)
+
+	b.LaunchDisco.TargetContents = []disco.ContentRef{wasm}
+	check = b.preflightContents()
+	assert.Equal(t, PreflightNoGo, check.Status)
+	assert.Contains(t, check.Detail, `contract "eosio.token" pinned without its abi`)
+}
@@ -0,0 +1,432 @@
+package bios
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/ryanuber/columnize"
+)
+
+// ProbeConfig is the boot sequence's `probe` section: once the launch
+// block is known, before shuffling, every participant probes the
+// producers' target API and p2p endpoints, retrying those not
+// answering for `window` (a single round when not set). With
+// `exclude_unreachable`, producers with an endpoint which never
+// answered are left out of the shuffle, so can't be the boot node nor
+// appointed.
+//
+// All participants need to leave out the same producers, or they
+// won't agree on the boot node, while each probes from its own
+// network: the exclusion is the one `publisher` came to, which it
+// signs and publishes to the coordination endpoint at `url` (see
+// `eos-bios heartbeat-server`). The others wait for it, and don't
+// shuffle without it.
+type ProbeConfig struct {
+	ExcludeUnreachable bool            `json:"exclude_unreachable"`
+	Window             string          `json:"window"`
+	Publisher          eos.AccountName `json:"publisher"`
+	URL                string          `json:"url"`
+
+	window time.Duration
+}
+
+func (c *ProbeConfig) validate() error {
+	if c == nil {
+		return nil
+	}
+	if c.Window != "" {
+		window, err := time.ParseDuration(c.Window)
+		if err != nil {
+			return fmt.Errorf("probe: window: %s", err)
+		}
+		c.window = window
+	}
+	if c.ExcludeUnreachable {
+		if c.Publisher == "" {
+			return fmt.Errorf("probe: exclude_unreachable needs a publisher, for all participants to agree on the exclusion")
+		}
+		if !strings.HasPrefix(c.URL, "http://") && !strings.HasPrefix(c.URL, "https://") {
+			return fmt.Errorf("probe: url must be an http(s) URL, got %q", c.URL)
+		}
+	}
+	return nil
+}
+
+var probeRetryInterval = 10 * time.Second
+
+// publishedExclusionTimeout is how long participants wait for the
+// publisher's exclusion, after probing themselves.
+var publishedExclusionTimeout = 10 * time.Minute
+
+// dialEndpoint checks something answers on `addr`, a `host:port`.
+var dialEndpoint = func(addr string) error {
+	conn, err := net.DialTimeout("tcp", addr, 3*time.Second)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// ProbeResult is how a producer's endpoints answered the probe. Their
+// errors are empty when they answered, or weren't listed.
+type ProbeResult struct {
+	Account  string
+	API      string
+	APIError string
+	P2P      string
+	P2PError string
+
+	probed bool
+}
+
+// Reachable returns whether all the endpoints the producer listed
+// answered.
+func (r *ProbeResult) Reachable() bool {
+	return r.APIError == "" && r.P2PError == ""
+}
+
+// ProbeProducers probes the target API and p2p endpoints of `peers`,
+// once per seed network account, for `window`, until they all answered.
+// Results are sorted by account.
+func (b *BIOS) ProbeProducers(peers []*Peer, window time.Duration) (out []*ProbeResult) {
+	seen := map[string]bool{}
+	for _, peer := range peers {
+		account := string(peer.Discovery.SeedNetworkAccountName)
+		if seen[account] {
+			continue
+		}
+		seen[account] = true
+
+		result := &ProbeResult{Account: account, API: peer.Discovery.TargetHTTPAddress}
+		if addr := b.p2pAddress(peer); addr != "none" {
+			result.P2P = addr
+		}
+		out = append(out, result)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Account < out[j].Account })
+
+	deadline := time.Now().Add(window)
+	for round := 0; ; round++ {
+		unreachable := 0
+		for _, result := range out {
+			if round == 0 || !result.Reachable() {
+				result.probe()
+			}
+			if !result.Reachable() {
+				unreachable++
+			}
+		}
+
+		if unreachable == 0 || !time.Now().Add(probeRetryInterval).Before(deadline) {
+			return
+		}
+		b.Log.Printf("%d producers unreachable, probing them again in %s\n", unreachable, probeRetryInterval)
+		time.Sleep(probeRetryInterval)
+	}
+}
+
+// probe dials the endpoints listed which haven't answered yet.
+func (r *ProbeResult) probe() {
+	if r.API != "" && (r.APIError != "" || !r.probed) {
+		r.APIError = ""
+		if addr, err := apiEndpointAddress(r.API); err != nil {
+			r.APIError = err.Error()
+		} else if err := dialEndpoint(addr); err != nil {
+			r.APIError = err.Error()
+		}
+	}
+	if r.P2P != "" && (r.P2PError != "" || !r.probed) {
+		r.P2PError = ""
+		if err := dialEndpoint(r.P2P); err != nil {
+			r.P2PError = err.Error()
+		}
+	}
+	r.probed = true
+}
+
+// apiEndpointAddress returns the `host:port` of a target API URL.
+func apiEndpointAddress(apiURL string) (string, error) {
+	u, err := url.Parse(apiURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Port() != "" {
+		return u.Host, nil
+	}
+	if u.Scheme == "https" {
+		return net.JoinHostPort(u.Hostname(), "443"), nil
+	}
+	return net.JoinHostPort(u.Hostname(), "80"), nil
+}
+
+// ProbeExclusion returns the accounts of the unreachable producers in
+// `results`, and a hash of that list for participants to compare.
+func ProbeExclusion(results []*ProbeResult) (excluded []string, hash string) {
+	for _, result := range results {
+		if !result.Reachable() {
+			excluded = append(excluded, result.Account)
+		}
+	}
+	sum := sha256.Sum256([]byte(strings.Join(excluded, "\n")))
+	return excluded, hex.EncodeToString(sum[:])
+}
+
+// PrintProbeResults prints how each producer's endpoints answered, and
+// the resulting exclusion.
+func PrintProbeResults(w io.Writer, results []*ProbeResult) {
+	columns := []string{
+		"Seed Account | API | P2P | Reachable",
+		"------------ | --- | --- | ---------",
+	}
+	for _, result := range results {
+		columns = append(columns, fmt.Sprintf("%s | %s | %s | %t", result.Account, probeStatus(result.API, result.APIError), probeStatus(result.P2P, result.P2PError), result.Reachable()))
+	}
+	fmt.Fprintln(w, columnize.SimpleFormat(columns))
+
+	excluded, hash := ProbeExclusion(results)
+	fmt.Fprintf(w, "\nUnreachable: %s\n", orNone(excluded))
+	fmt.Fprintf(w, "Exclusion hash: %s\n", hash)
+}
+
+func probeStatus(endpoint, err string) string {
+	switch {
+	case endpoint == "":
+		return "-"
+	case err != "":
+		return endpoint + " (" + err + ")"
+	}
+	return endpoint + " (ok)"
+}
+
+// ProbeLaunchProducers probes the producers of the launch as the boot
+// sequence's `probe` does before shuffling, for `eos-bios probe`.
+func (b *BIOS) ProbeLaunchProducers() []*ProbeResult {
+	var window time.Duration
+	if b.Probe != nil {
+		window = b.Probe.window
+	}
+	return b.ProbeProducers(b.Network.OrderedPeers(b.Network.MyNetwork()), window)
+}
+
+// excludeUnreachable drops the producers the publisher of the boot
+// sequence's `probe` found unreachable from `peers`.
+func (b *BIOS) excludeUnreachable(peers []*Peer) ([]*Peer, error) {
+	b.shuffleExcluded, b.shuffleExclusion = nil, nil
+	if b.Probe == nil {
+		return peers, nil
+	}
+
+	b.Log.Println("Probing the producers' endpoints")
+	results := b.ProbeProducers(peers, b.Probe.window)
+	var report bytes.Buffer
+	PrintProbeResults(&report, results)
+	b.Log.Println(report.String())
+
+	local, _ := ProbeExclusion(results)
+	if !b.Probe.ExcludeUnreachable {
+		return peers, nil
+	}
+
+	exclusion, err := b.agreedExclusion(local)
+	if err != nil {
+		return nil, fmt.Errorf("probe: %s", err)
+	}
+	b.shuffleExclusion = exclusion
+	excluded := exclusion.Excluded
+	if len(excluded) == 0 {
+		return peers, nil
+	}
+
+	isExcluded := map[string]bool{}
+	for _, account := range excluded {
+		isExcluded[account] = true
+	}
+	var out []*Peer
+	for _, peer := range peers {
+		if !isExcluded[string(peer.Discovery.SeedNetworkAccountName)] {
+			out = append(out, peer)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("probe: all producers unreachable, nothing left to launch with")
+	}
+
+	b.shuffleExcluded = excluded
+	b.Log.Printf("Excluding %d unreachable producers from the launch, as published by %s: %s\n", len(excluded), exclusion.Account, strings.Join(excluded, ", "))
+	return out, nil
+}
+
+// PublishedExclusion is the exclusion the `probe` section's publisher
+// came to, for the launch seeded by `Seed`, signed with a key of its
+// seed network account's `active` permission.
+type PublishedExclusion struct {
+	Account          eos.AccountName `json:"account"`
+	BootSequenceHash string          `json:"boot_sequence_hash"`
+	Seed             int64           `json:"seed"`
+	Excluded         []string        `json:"excluded"`
+	Signature        string          `json:"signature"`
+}
+
+// Hash covers all of the exclusion but its signature.
+func (e *PublishedExclusion) Hash() []byte {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s\n%s\n%d\n%s", e.Account, e.BootSequenceHash, e.Seed, strings.Join(e.Excluded, ","))))
+	return hash[:]
+}
+
+// Verify checks the exclusion was signed by a key of the publisher's
+// `active` permission on the seed network at `api`, and returns that
+// key.
+func (e *PublishedExclusion) Verify(api *eos.API) (string, error) {
+	return verifyActiveSignature(api, e.Account, hex.EncodeToString(e.Hash()), e.Signature)
+}
+
+var verifyPublishedExclusion = func(net *Network, exclusion *PublishedExclusion) error {
+	return net.verifyAccountSignature(exclusion.Account, exclusion.Hash(), exclusion.Signature)
+}
+
+// agreedExclusion is the exclusion the publisher came to: when we're
+// the publisher, `local` signed and published, otherwise the one we
+// wait for, warning when it differs from `local`.
+func (b *BIOS) agreedExclusion(local []string) (*PublishedExclusion, error) {
+	config := b.Probe
+	if b.Network.MyPeer.Discovery.SeedNetworkAccountName == config.Publisher {
+		exclusion, err := b.newPublishedExclusion(local)
+		if err != nil {
+			return nil, fmt.Errorf("signing exclusion: %s", err)
+		}
+		if err := postJSON(strings.TrimRight(config.URL, "/")+"/exclusion", exclusion); err != nil {
+			return nil, fmt.Errorf("publishing exclusion: %s", err)
+		}
+		b.Log.Printf("Published our exclusion to %s\n", config.URL)
+		return exclusion, nil
+	}
+
+	b.Log.Printf("Waiting for the exclusion published by %s\n", config.Publisher)
+	deadline := time.Now().Add(publishedExclusionTimeout)
+	for {
+		b.markProgress("waiting for the published exclusion")
+
+		exclusion, err := fetchExclusion(config.URL, config.Publisher)
+		if err == nil {
+			err = b.checkExclusion(exclusion)
+		}
+		if err == nil {
+			if strings.Join(exclusion.Excluded, ",") != strings.Join(local, ",") {
+				b.Log.Printf("WARNING: %s excluded %s, our probe found %s unreachable: going with the published exclusion\n", exclusion.Account, orNone(exclusion.Excluded), orNone(local))
+			}
+			return exclusion, nil
+		}
+
+		if !time.Now().Before(deadline) {
+			return nil, fmt.Errorf("no valid exclusion published by %s after %s (%s), not shuffling without it", config.Publisher, publishedExclusionTimeout, err)
+		}
+		b.Log.Debugf("published exclusion: %s\n", err)
+		time.Sleep(probeRetryInterval)
+	}
+}
+
+func (b *BIOS) newPublishedExclusion(excluded []string) (*PublishedExclusion, error) {
+	if len(b.SeedNetKeys) == 0 {
+		return nil, fmt.Errorf("no seed network keys to sign with")
+	}
+
+	account := b.Network.MyPeer.Discovery.SeedNetworkAccountName
+	activeKeys, err := b.Network.ActivePublicKeys(account)
+	if err != nil {
+		return nil, err
+	}
+
+	exclusion := &PublishedExclusion{
+		Account:          account,
+		BootSequenceHash: b.BootSequenceHash,
+		Seed:             b.Randomness.Seed,
+		Excluded:         excluded,
+	}
+	sig, err := signWithActiveKey(b.SeedNetKeys, activeKeys, exclusion.Hash())
+	if err != nil {
+		return nil, err
+	}
+	exclusion.Signature = sig.String()
+
+	return exclusion, nil
+}
+
+// checkExclusion checks a published exclusion is the publisher's, for
+// this launch.
+func (b *BIOS) checkExclusion(exclusion *PublishedExclusion) error {
+	switch {
+	case exclusion.Account != b.Probe.Publisher:
+		return fmt.Errorf("exclusion published by %s, expected %s", exclusion.Account, b.Probe.Publisher)
+	case exclusion.BootSequenceHash != b.BootSequenceHash:
+		return fmt.Errorf("exclusion for another boot sequence (%s)", exclusion.BootSequenceHash)
+	case exclusion.Seed != b.Randomness.Seed:
+		return fmt.Errorf("exclusion for another launch (seed %d)", exclusion.Seed)
+	}
+	return verifyPublishedExclusion(b.Network, exclusion)
+}
+
+func fetchExclusion(url string, account eos.AccountName) (*PublishedExclusion, error) {
+	resp, err := heartbeatClient.Get(strings.TrimRight(url, "/") + "/exclusion?account=" + string(account))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching exclusion: %s", resp.Status)
+	}
+	var exclusion *PublishedExclusion
+	if err := json.NewDecoder(resp.Body).Decode(&exclusion); err != nil || exclusion == nil {
+		return nil, fmt.Errorf("decoding exclusion: %v", err)
+	}
+	return exclusion, nil
+}
+
+// serveExclusion keeps the latest exclusion published by each account,
+// served on `GET /exclusion?account=...`.
+func (s *HeartbeatServer) serveExclusion(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.lock.Lock()
+		exclusion := s.exclusions[eos.AccountName(r.URL.Query().Get("account"))]
+		s.lock.Unlock()
+		if exclusion == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(exclusion)
+
+	case http.MethodPost:
+		var exclusion *PublishedExclusion
+		if err := json.NewDecoder(io.LimitReader(r.Body, 64*1024)).Decode(&exclusion); err != nil || exclusion == nil || exclusion.Account == "" {
+			http.Error(w, "invalid exclusion", http.StatusBadRequest)
+			return
+		}
+		if s.verify != nil {
+			if err := s.verify(exclusion.Account, exclusion.Hash(), exclusion.Signature); err != nil {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+		}
+
+		s.lock.Lock()
+		s.exclusions[exclusion.Account] = exclusion
+		s.lock.Unlock()
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,135 @@
+package bios
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/eoscanada/eos-bios/bios/disco"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProbeProducers(t *testing.T) {
+	defer func(dial func(string) error) { dialEndpoint = dial }(dialEndpoint)
+	dialed := map[string]int{}
+	dialEndpoint = func(addr string) error {
+		dialed[addr]++
+		if addr == "down:9876" || addr == "downapi:443" {
+			return fmt.Errorf("connection refused")
+		}
+		return nil
+	}
+
+	peer := func(account, api, p2p string) *Peer {
+		return &Peer{Discovery: &disco.Discovery{SeedNetworkAccountName: AN(account), TargetAccountName: AN(account), TargetHTTPAddress: api, TargetP2PAddress: p2p}}
+	}
+	peers := []*Peer{
+		peer("bob", "http://bob:8888", "down:9876"),
+		peer("alice", "http://alice", "alice:9876"),
+		peer("alice", "http://alice", "alice:9876"), // cloned
+		peer("carol", "https://downapi", "none"),
+	}
+
+	b := &BIOS{Log: NewLogger()}
+	results := b.ProbeProducers(peers, 0)
+	if !assert.Len(t, results, 3) {
+		return
+	}
+	assert.Equal(t, "alice", results[0].Account)
+	assert.True(t, results[0].Reachable())
+	assert.Equal(t, "", results[2].P2P)
+	assert.Equal(t, 1, dialed["alice:80"])
+
+	excluded, hash := ProbeExclusion(results)
+	assert.Equal(t, []string{"bob", "carol"}, excluded)
+	assert.Len(t, hash, 64)
+
+	var out bytes.Buffer
+	PrintProbeResults(&out, results)
+	assert.Contains(t, out.String(), "bob           http://bob:8888 (ok)")
+	assert.Contains(t, out.String(), "down:9876 (connection refused)")
+	assert.Contains(t, out.String(), "Unreachable: bob, carol\nExclusion hash: "+hash)
+
+	b.RandSource = rand.NewSource(1)
+	b.Probe = &ProbeConfig{}
+	kept, err := b.excludeUnreachable(peers)
+	assert.NoError(t, err)
+	assert.Len(t, kept, 4)
+
+	assert.Error(t, (&ProbeConfig{Window: "later"}).validate())
+	assert.EqualError(t, (&ProbeConfig{ExcludeUnreachable: true}).validate(), "probe: exclude_unreachable needs a publisher, for all participants to agree on the exclusion")
+}
+
+func TestPublishedExclusion(t *testing.T) {
+	defer func(dial func(string) error) { dialEndpoint = dial }(dialEndpoint)
+	dialEndpoint = func(addr string) error {
+		if addr == "down:9876" {
+			return fmt.Errorf("connection refused")
+		}
+		return nil
+	}
+	defer func(verify func(*Network, *PublishedExclusion) error) { verifyPublishedExclusion = verify }(verifyPublishedExclusion)
+	verifyPublishedExclusion = func(net *Network, exclusion *PublishedExclusion) error {
+		if exclusion.Signature != "good" {
+			return fmt.Errorf("signed by a key not in the account's active permission")
+		}
+		return nil
+	}
+	defer func(timeout, interval time.Duration) {
+		publishedExclusionTimeout, probeRetryInterval = timeout, interval
+	}(publishedExclusionTimeout, probeRetryInterval)
+	publishedExclusionTimeout, probeRetryInterval = 50*time.Millisecond, 10*time.Millisecond
+
+	server := httptest.NewServer(NewHeartbeatServer(nil))
+	defer server.Close()
+
+	peer := func(account, p2p string) *Peer {
+		return &Peer{Discovery: &disco.Discovery{SeedNetworkAccountName: AN(account), TargetAccountName: AN(account), TargetP2PAddress: p2p}}
+	}
+	peers := []*Peer{peer("alice", "alice:9876"), peer("bob", "bob:9876"), peer("carol", "down:9876")}
+
+	b := &BIOS{
+		Log:              NewLogger(),
+		Network:          &Network{MyPeer: peers[0]},
+		BootSequenceHash: "abcd",
+		Randomness:       &RandomnessProof{Seed: 42},
+		RandSource:       rand.NewSource(42),
+		Probe:            &ProbeConfig{ExcludeUnreachable: true, Publisher: AN("bob"), URL: server.URL},
+	}
+	assert.NoError(t, b.Probe.validate())
+
+	// Nothing published, no shuffle.
+	_, err := b.excludeUnreachable(peers)
+	assert.Contains(t, err.Error(), "probe: no valid exclusion published by bob after 50ms")
+
+	// Forged, or for another launch, it isn't either.
+	exclusion := &PublishedExclusion{Account: AN("bob"), BootSequenceHash: "abcd", Seed: 42, Excluded: []string{"carol"}, Signature: "forged"}
+	assert.NoError(t, postJSON(server.URL+"/exclusion", exclusion))
+	_, err = b.excludeUnreachable(peers)
+	assert.Contains(t, err.Error(), "signed by a key not in the account's active permission")
+
+	exclusion.Signature, exclusion.Seed = "good", 7
+	assert.NoError(t, postJSON(server.URL+"/exclusion", exclusion))
+	_, err = b.excludeUnreachable(peers)
+	assert.Contains(t, err.Error(), "exclusion for another launch (seed 7)")
+
+	// The published exclusion goes, even when our probe differs.
+	exclusion.Seed, exclusion.Excluded = 42, []string{"bob", "carol"}
+	assert.NoError(t, postJSON(server.URL+"/exclusion", exclusion))
+	kept, err := b.excludeUnreachable(peers)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Peer{peers[0]}, kept)
+	assert.Equal(t, []string{"bob", "carol"}, b.shuffleExcluded)
+	assert.Equal(t, exclusion, b.shuffleExclusion)
+
+	_, err = b.excludeUnreachable(peers[1:])
+	assert.EqualError(t, err, "probe: all producers unreachable, nothing left to launch with")
+
+	// The publisher signs its own.
+	b.Network.MyPeer = peers[1]
+	_, err = b.excludeUnreachable(peers)
+	assert.EqualError(t, err, "probe: signing exclusion: no seed network keys to sign with")
+}
@@ -0,0 +1,126 @@
+package bios
+
+import (
+	"fmt"
+	"strings"
+
+	eos "github.com/eoscanada/eos-go"
+)
+
+// Profile adapts the boot sequence to an EOSIO fork whose system
+// contracts were modified. The operations always produce actions for
+// the standard contracts, which the profile then renames.
+type Profile struct {
+	Name string `json:"name"`
+
+	// Contracts maps standard contract accounts (like `eosio.token`)
+	// to the fork's, for the contract called as well as for
+	// authorizations.
+	Contracts map[eos.AccountName]eos.AccountName `json:"contracts"`
+
+	// Actions maps standard `contract::action` names to the fork's
+	// action names (like `eosio::delegatebw: stake`).
+	Actions map[string]eos.ActionName `json:"actions"`
+
+	// RequiredAccounts must exist on the target network once booted.
+	RequiredAccounts []eos.AccountName `json:"required_accounts"`
+}
+
+var profilesRegistry = map[string]*Profile{
+	"eosio": {
+		Name: "eosio",
+		RequiredAccounts: []eos.AccountName{
+			"eosio", "eosio.token", "eosio.msig",
+			"eosio.ram", "eosio.ramfee", "eosio.stake", "eosio.names",
+			"eosio.saving", "eosio.bpay", "eosio.vpay",
+		},
+	},
+}
+
+// selectProfile picks the profile named in the boot sequence, among
+// the built-in ones and the ones declared along with it.
+func selectProfile(name string, declared map[string]*Profile) (*Profile, error) {
+	if name == "" {
+		name = "eosio"
+	}
+
+	if profile, found := declared[name]; found {
+		profile.Name = name
+		return profile, nil
+	}
+
+	if profile, found := profilesRegistry[name]; found {
+		return profile, nil
+	}
+
+	var names []string
+	for known := range profilesRegistry {
+		names = append(names, known)
+	}
+	return nil, fmt.Errorf("compatibility profile %q not found, declare it under `profiles` or use one of: %q", name, names)
+}
+
+// Apply renames the contracts and actions of `acts` in place.
+func (p *Profile) Apply(acts []*eos.Action) {
+	if p == nil {
+		return
+	}
+
+	for _, act := range acts {
+		if act == nil {
+			continue
+		}
+
+		if name, found := p.Actions[fmt.Sprintf("%s::%s", act.Account, act.Name)]; found {
+			act.Name = name
+		}
+
+		if account, found := p.Contracts[act.Account]; found {
+			act.Account = account
+		}
+
+		for idx, perm := range act.Authorization {
+			if account, found := p.Contracts[perm.Actor]; found {
+				act.Authorization[idx].Actor = account
+			}
+		}
+	}
+}
+
+// checkRequiredAccounts verifies the accounts the profile requires
+// exist on the target network.
+func (b *BIOS) checkRequiredAccounts() error {
+	if b.Profile == nil {
+		return nil
+	}
+
+	var missing []string
+	for _, account := range b.Profile.RequiredAccounts {
+		if _, err := b.TargetNetAPI.GetAccount(account); err != nil {
+			missing = append(missing, string(account))
+		}
+	}
+
+	if len(missing) != 0 {
+		return fmt.Errorf("accounts required by the %q profile missing on chain: %s", b.Profile.Name, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// stepActions gets the actions of a boot sequence step, adapted to
+// the target network's flavor.
+func (b *BIOS) stepActions(step *OperationType) ([]*eos.Action, error) {
+	if b.LaunchDisco.TargetNetworkIsTest == 0 {
+		step.Data.ResetTestnetOptions()
+	}
+
+	acts, err := step.Data.Actions(b)
+	if err != nil {
+		return nil, err
+	}
+
+	b.Profile.Apply(acts)
+
+	return acts, nil
+}
@@ -0,0 +1,35 @@
+package bios
+
+import (
+	"testing"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfileApply(t *testing.T) {
+	profile, err := selectProfile("myfork", map[string]*Profile{
+		"myfork": {
+			Contracts: map[eos.AccountName]eos.AccountName{"eosio.token": "fork.token"},
+			Actions:   map[string]eos.ActionName{"eosio::delegatebw": "stake"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "myfork", profile.Name)
+
+	acts := []*eos.Action{
+		{Account: AN("eosio"), Name: eos.ActN("delegatebw"), Authorization: []eos.PermissionLevel{{Actor: AN("eosio"), Permission: PN("active")}}},
+		nil,
+		{Account: AN("eosio.token"), Name: eos.ActN("create"), Authorization: []eos.PermissionLevel{{Actor: AN("eosio.token"), Permission: PN("active")}}},
+	}
+	profile.Apply(acts)
+
+	assert.Equal(t, eos.ActionName("stake"), acts[0].Name)
+	assert.Equal(t, AN("eosio"), acts[0].Account)
+	assert.Equal(t, AN("fork.token"), acts[2].Account)
+	assert.Equal(t, eos.ActionName("create"), acts[2].Name)
+	assert.Equal(t, AN("fork.token"), acts[2].Authorization[0].Actor)
+
+	_, err = selectProfile("unknown", nil)
+	assert.Error(t, err)
+}
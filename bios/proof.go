@@ -0,0 +1,194 @@
+package bios
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/system"
+	"github.com/eoscanada/eos-go/token"
+)
+
+// AccountProof traces an account's genesis balance to the
+// transactions, and blocks, that created, staked and funded it.
+type AccountProof struct {
+	Account              eos.AccountName `json:"account"`
+	CreatedInTransaction string          `json:"created_in_transaction"`
+	CreatedInBlock       uint32          `json:"created_in_block"`
+	StakedCPU            eos.Asset       `json:"staked_cpu"`
+	StakedNet            eos.Asset       `json:"staked_net"`
+	StakedInTransaction  string          `json:"staked_in_transaction"`
+	StakedInBlock        uint32          `json:"staked_in_block"`
+	Liquid               eos.Asset       `json:"liquid"`
+	FundedInTransaction  string          `json:"funded_in_transaction"`
+	FundedInBlock        uint32          `json:"funded_in_block"`
+}
+
+// AccountProofIndex maps accounts to their creation proof. Only the
+// first creation, stake and transfer from `eosio` of each account are
+// recorded: those of the genesis.
+type AccountProofIndex map[eos.AccountName]*AccountProof
+
+func (idx AccountProofIndex) get(account eos.AccountName) *AccountProof {
+	proof := idx[account]
+	if proof == nil {
+		proof = &AccountProof{Account: account}
+		idx[account] = proof
+	}
+	return proof
+}
+
+func (idx AccountProofIndex) addTransaction(blockNum uint32, transactionID string, actions []*eos.Action) {
+	for _, act := range actions {
+		switch {
+		case act.Account == AN("eosio") && act.Name == eos.ActN("newaccount"):
+			var data system.NewAccount
+			if decodeActionData(act, &data) && data.Name != BootLeaseAccount {
+				proof := idx.get(data.Name)
+				if proof.CreatedInTransaction == "" {
+					proof.CreatedInTransaction, proof.CreatedInBlock = transactionID, blockNum
+				}
+			}
+		case act.Account == AN("eosio") && act.Name == eos.ActN("delegatebw"):
+			var data system.DelegateBW
+			if decodeActionData(act, &data) && data.From == AN("eosio") {
+				proof := idx.get(data.Receiver)
+				if proof.StakedInTransaction == "" {
+					proof.StakedInTransaction, proof.StakedInBlock = transactionID, blockNum
+					proof.StakedCPU, proof.StakedNet = data.StakeCPU, data.StakeNet
+				}
+			}
+		case act.Account == AN("eosio.token") && act.Name == eos.ActN("transfer"):
+			var data token.Transfer
+			if decodeActionData(act, &data) && data.From == AN("eosio") {
+				proof := idx.get(data.To)
+				if proof.FundedInTransaction == "" {
+					proof.FundedInTransaction, proof.FundedInBlock = transactionID, blockNum
+					proof.Liquid = data.Quantity
+				}
+			}
+		}
+	}
+}
+
+// decodeActionData fills `out` with the action's data, decoded already
+// or from its binary form.
+func decodeActionData(act *eos.Action, out interface{}) bool {
+	if act.ActionData.Data != nil {
+		data := reflect.Indirect(reflect.ValueOf(act.ActionData.Data))
+		target := reflect.ValueOf(out).Elem()
+		if data.Type() == target.Type() {
+			target.Set(data)
+			return true
+		}
+	}
+
+	return len(act.HexData) != 0 && eos.UnmarshalBinary(act.HexData, out) == nil
+}
+
+// BuildAccountProofIndex reads the blocks `from` to `to` (the head
+// block when zero) of a chain, and indexes the accounts created.
+func BuildAccountProofIndex(api *eos.API, from, to uint32) (AccountProofIndex, error) {
+	if to == 0 {
+		info, err := api.GetInfo()
+		if err != nil {
+			return nil, fmt.Errorf("get info: %s", err)
+		}
+		to = info.HeadBlockNum
+	}
+
+	idx := AccountProofIndex{}
+	for blockNum := from; blockNum <= to; blockNum++ {
+		block, err := api.GetBlockByNum(blockNum)
+		if err != nil {
+			return nil, fmt.Errorf("get block %d: %s", blockNum, err)
+		}
+
+		for _, receipt := range block.Transactions {
+			unpacked, err := receipt.Transaction.Packed.Unpack()
+			if err != nil {
+				return nil, fmt.Errorf("unpacking transaction in block %d: %s", blockNum, err)
+			}
+			idx.addTransaction(blockNum, receipt.Transaction.ID.String(), unpacked.Actions)
+		}
+	}
+
+	return idx, nil
+}
+
+// Sorted lists the proofs by account name.
+func (idx AccountProofIndex) Sorted() (out []*AccountProof) {
+	for _, proof := range idx {
+		out = append(out, proof)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Account < out[j].Account })
+	return
+}
+
+// Write exports the index to `filename`, as JSON if it ends with
+// `.json`, as CSV otherwise.
+func (idx AccountProofIndex) Write(filename string) error {
+	fl, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer fl.Close()
+
+	if filepath.Ext(filename) == ".json" {
+		enc := json.NewEncoder(fl)
+		enc.SetIndent("", "  ")
+		return enc.Encode(idx.Sorted())
+	}
+
+	w := csv.NewWriter(fl)
+	_ = w.Write([]string{"account", "created_in_transaction", "created_in_block", "staked_cpu", "staked_net", "staked_in_transaction", "staked_in_block", "liquid", "funded_in_transaction", "funded_in_block"})
+	for _, proof := range idx.Sorted() {
+		_ = w.Write([]string{
+			string(proof.Account),
+			proof.CreatedInTransaction,
+			blockNumString(proof.CreatedInBlock),
+			proof.StakedCPU.String(),
+			proof.StakedNet.String(),
+			proof.StakedInTransaction,
+			blockNumString(proof.StakedInBlock),
+			proof.Liquid.String(),
+			proof.FundedInTransaction,
+			blockNumString(proof.FundedInBlock),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func blockNumString(blockNum uint32) string {
+	if blockNum == 0 {
+		return ""
+	}
+	return strconv.FormatUint(uint64(blockNum), 10)
+}
+
+// LoadAccountProofIndex reads an index written as JSON by `Write`.
+func LoadAccountProofIndex(filename string) (AccountProofIndex, error) {
+	cnt, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var proofs []*AccountProof
+	if err := json.Unmarshal(cnt, &proofs); err != nil {
+		return nil, fmt.Errorf("decoding %q: %s", filename, err)
+	}
+
+	idx := AccountProofIndex{}
+	for _, proof := range proofs {
+		idx[proof.Account] = proof
+	}
+	return idx, nil
+}
@@ -0,0 +1,58 @@
+package bios
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/system"
+	"github.com/eoscanada/eos-go/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountProofIndex(t *testing.T) {
+	newAccount := &eos.Action{
+		Account:    AN("eosio"),
+		Name:       eos.ActN("newaccount"),
+		ActionData: eos.NewActionData(system.NewAccount{Creator: AN("eosio"), Name: AN("holder")}),
+	}
+
+	idx := AccountProofIndex{}
+	idx.addTransaction(10, "tx1", []*eos.Action{
+		newAccount,
+		system.NewDelegateBW(AN("eosio"), AN("holder"), eos.NewEOSAsset(1000), eos.NewEOSAsset(2000), true),
+	})
+	idx.addTransaction(11, "tx2", []*eos.Action{
+		token.NewTransfer(AN("eosio"), AN("holder"), eos.NewEOSAsset(5000), ""),
+	})
+	// Later transfers aren't part of the genesis.
+	idx.addTransaction(50, "tx3", []*eos.Action{
+		token.NewTransfer(AN("eosio"), AN("holder"), eos.NewEOSAsset(7000), ""),
+		token.NewTransfer(AN("someone"), AN("other"), eos.NewEOSAsset(7000), ""),
+	})
+
+	assert.Len(t, idx, 1)
+	proof := idx[AN("holder")]
+	if assert.NotNil(t, proof) {
+		assert.Equal(t, "tx1", proof.CreatedInTransaction)
+		assert.Equal(t, uint32(10), proof.CreatedInBlock)
+		assert.Equal(t, "tx1", proof.StakedInTransaction)
+		assert.Equal(t, int64(1000), int64(proof.StakedCPU.Amount))
+		assert.Equal(t, int64(2000), int64(proof.StakedNet.Amount))
+		assert.Equal(t, "tx2", proof.FundedInTransaction)
+		assert.Equal(t, uint32(11), proof.FundedInBlock)
+		assert.Equal(t, int64(5000), int64(proof.Liquid.Amount))
+	}
+
+	dir, err := ioutil.TempDir("", "eos-bios-proof")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "proofs.json")
+	assert.NoError(t, idx.Write(filename))
+	loaded, err := LoadAccountProofIndex(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, idx, loaded)
+}
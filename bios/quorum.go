@@ -0,0 +1,107 @@
+package bios
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+)
+
+// QuorumConfig is the boot sequence's `quorum` section: the boot node
+// only boots once `min_producers` producers, besides itself, are
+// present, waiting for them up to `timeout` (checking once when not
+// set). A producer is present when its p2p endpoint answers, or, when
+// it opted out of meshing (`none`), when it published its discovery
+// file recently. Clones of a producer count once.
+type QuorumConfig struct {
+	MinProducers int    `json:"min_producers"`
+	Timeout      string `json:"timeout"`
+
+	timeout time.Duration
+}
+
+func (c *QuorumConfig) validate() error {
+	if c == nil {
+		return nil
+	}
+	if c.MinProducers < 1 {
+		return fmt.Errorf("quorum: min_producers must be at least 1")
+	}
+	if c.Timeout != "" {
+		timeout, err := time.ParseDuration(c.Timeout)
+		if err != nil {
+			return fmt.Errorf("quorum: timeout: %s", err)
+		}
+		c.timeout = timeout
+	}
+	return nil
+}
+
+var quorumPollInterval = 10 * time.Second
+
+// quorumPresence returns the producers among `peers`, besides us, who
+// are present and those who aren't, by seed network account.
+func (b *BIOS) quorumPresence(peers []*Peer) (present, missing []string) {
+	myAccount := b.Network.MyPeer.Discovery.SeedNetworkAccountName
+	seen := map[eos.AccountName]bool{}
+
+	for _, peer := range peers {
+		account := peer.Discovery.SeedNetworkAccountName
+		if account == myAccount || seen[account] {
+			continue
+		}
+		seen[account] = true
+
+		addr := b.p2pAddress(peer)
+		switch {
+		case addr == "none" && peer.Active():
+			present = append(present, string(account))
+		case addr == "none", addr == "":
+			missing = append(missing, fmt.Sprintf("%s (no p2p address, discovery not updated lately)", account))
+		default:
+			if err := dialEndpoint(addr); err != nil {
+				missing = append(missing, fmt.Sprintf("%s (%s unreachable)", account, addr))
+				continue
+			}
+			present = append(present, string(account))
+		}
+	}
+
+	sort.Strings(present)
+	sort.Strings(missing)
+	return
+}
+
+// waitQuorum blocks until the boot sequence's `quorum` is reached, and
+// fails with the producers missing when it's not, before its timeout.
+func (b *BIOS) waitQuorum() error {
+	config := b.Quorum
+	if config == nil {
+		return nil
+	}
+
+	b.Log.Printf("Waiting for %d producers to be present before booting\n", config.MinProducers)
+	deadline := time.Now().Add(config.timeout)
+	for {
+		b.markProgress("waiting for quorum")
+
+		present, missing := b.quorumPresence(b.ShuffledProducers)
+		if len(present) >= config.MinProducers {
+			b.Log.Printf("Quorum reached: %d producers present (%s)\n", len(present), strings.Join(present, ", "))
+			return nil
+		}
+
+		if !time.Now().Before(deadline) {
+			b.Log.Printf("Quorum not reached, %d of %d producers present. Missing:\n", len(present), config.MinProducers)
+			for _, account := range missing {
+				b.Log.Printf("  - %s\n", account)
+			}
+			return fmt.Errorf("quorum not reached: %d producers present, %d required, not booting", len(present), config.MinProducers)
+		}
+
+		b.Log.Printf("%d of %d producers present, missing: %s\n", len(present), config.MinProducers, strings.Join(missing, ", "))
+		time.Sleep(quorumPollInterval)
+	}
+}
@@ -0,0 +1,63 @@
+package bios
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/eoscanada/eos-bios/bios/disco"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitQuorum(t *testing.T) {
+	defer func(dial func(string) error) { dialEndpoint = dial }(dialEndpoint)
+	dialEndpoint = func(addr string) error {
+		if addr == "down:9876" {
+			return fmt.Errorf("connection refused")
+		}
+		return nil
+	}
+
+	peer := func(account, p2pAddress string, updatedAt time.Time) *Peer {
+		return &Peer{
+			Discovery: &disco.Discovery{SeedNetworkAccountName: AN(account), TargetAccountName: AN(account), TargetP2PAddress: p2pAddress},
+			UpdatedAt: updatedAt,
+		}
+	}
+	boot := peer("bootnode", "boot:9876", time.Now())
+	b := &BIOS{
+		Log:     NewLogger(),
+		Network: &Network{MyPeer: boot},
+		ShuffledProducers: []*Peer{
+			boot,
+			peer("alice", "alice:9876", time.Now()),
+			peer("alice", "alice2:9876", time.Now()), // cloned
+			peer("bob", "down:9876", time.Now()),
+			peer("carol", "none", time.Now()),
+			peer("dave", "none", time.Now().Add(-time.Hour)),
+		},
+	}
+
+	present, missing := b.quorumPresence(b.ShuffledProducers)
+	assert.Equal(t, []string{"alice", "carol"}, present)
+	assert.Equal(t, []string{"bob (down:9876 unreachable)", "dave (no p2p address, discovery not updated lately)"}, missing)
+
+	assert.NoError(t, b.waitQuorum())
+
+	b.Quorum = &QuorumConfig{MinProducers: 2}
+	assert.NoError(t, b.Quorum.validate())
+	assert.NoError(t, b.waitQuorum())
+	_, what := b.lastProgress()
+	assert.Equal(t, "waiting for quorum", what)
+
+	b.Quorum = &QuorumConfig{MinProducers: 3}
+	assert.EqualError(t, b.waitQuorum(), "quorum not reached: 2 producers present, 3 required, not booting")
+
+	// A boot node chosen by `orchestrate` doesn't boot either.
+	runner := b.RoleRunner()
+	assert.Equal(t, RoleBootNode, runner.Role())
+	assert.EqualError(t, runner.Run(), "quorum not reached: 2 producers present, 3 required, not booting")
+
+	assert.EqualError(t, (&QuorumConfig{}).validate(), "quorum: min_producers must be at least 1")
+	assert.Error(t, (&QuorumConfig{MinProducers: 1, Timeout: "soon"}).validate())
+}
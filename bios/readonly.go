@@ -0,0 +1,27 @@
+package bios
+
+import (
+	"errors"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+)
+
+var ErrReadOnly = errors.New("eos-bios is running in read-only mode, refusing to sign or broadcast")
+
+// ReadOnlySigner refuses to import keys and to sign anything. It is
+// installed on every API in read-only mode, so that no code path can
+// broadcast a transaction, whatever the flags and keys lying around.
+type ReadOnlySigner struct{}
+
+func (s ReadOnlySigner) AvailableKeys() (out []ecc.PublicKey, err error) {
+	return nil, nil
+}
+
+func (s ReadOnlySigner) ImportPrivateKey(wifPrivKey string) error {
+	return ErrReadOnly
+}
+
+func (s ReadOnlySigner) Sign(tx *eos.SignedTransaction, chainID []byte, requiredKeys ...ecc.PublicKey) (*eos.SignedTransaction, error) {
+	return nil, ErrReadOnly
+}
@@ -0,0 +1,80 @@
+package bios
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+)
+
+// DefaultTargetReadyTimeout is how long to wait for the target node to
+// produce blocks, before pushing the boot sequence.
+const DefaultTargetReadyTimeout = 5 * time.Minute
+
+var targetReadyPollInterval = time.Second
+
+// waitTargetReady polls `get_info` on the target node until it answers
+// with the expected chain ID, and its head block advances. After
+// `timeout` (never when zero), it fails with a diagnosis of what's
+// missing.
+func (b *BIOS) waitTargetReady(timeout time.Duration) error {
+	b.status.phase("waiting for target node")
+	b.Log.Printf("Waiting for the target node at %q to produce blocks...", b.TargetNetAPI.BaseURL)
+
+	var deadline time.Time
+	if timeout != 0 {
+		deadline = time.Now().Add(timeout)
+	}
+
+	var lastErr error
+	var answered bool
+	var firstHead, head uint32
+	var producer eos.AccountName
+	for {
+		b.markProgress("waiting for target node")
+
+		info, err := b.TargetNetAPI.GetInfo()
+		if err != nil {
+			b.Log.Debugf("target node error: %s\n", err)
+			b.Log.Printf("e")
+			lastErr = err
+		} else {
+			lastErr = nil
+
+			if len(b.TargetChainID) != 0 && !bytes.Equal(info.ChainID, b.TargetChainID) {
+				b.Log.Println("")
+				return fmt.Errorf("target node runs chain ID %s, the %s derivation gives %s: is it started with the genesis.json of this launch?", info.ChainID, b.ChainID.derivation(), b.TargetChainID)
+			}
+
+			if !answered {
+				answered, firstHead = true, info.HeadBlockNum
+			}
+			head, producer = info.HeadBlockNum, info.HeadBlockProducer
+			if head >= 2 && head > firstHead {
+				break
+			}
+			b.Log.Debugf("target node: head block %d, waiting for it to advance\n", head)
+			b.Log.Printf(".")
+		}
+
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			b.Log.Println("")
+			return fmt.Errorf("target node not ready after %s: %s", timeout, targetNotReadyDiagnosis(b.TargetNetAPI.BaseURL, lastErr, answered, head))
+		}
+		time.Sleep(targetReadyPollInterval)
+	}
+
+	b.Log.Printf(" touchdown! (head block %d, produced by %s)\n", head, producer)
+	return nil
+}
+
+func targetNotReadyDiagnosis(url string, lastErr error, answered bool, head uint32) string {
+	switch {
+	case lastErr != nil && !answered:
+		return fmt.Sprintf("%s is unreachable (%s), is nodeos running, with its http-server-address matching --target-api?", url, lastErr)
+	case lastErr != nil:
+		return fmt.Sprintf("%s stopped answering (%s) at head block %d, did nodeos crash?", url, lastErr, head)
+	}
+	return fmt.Sprintf("head block stuck at %d, nodeos isn't producing: check it has enable-stale-production, producer-name = eosio, and the genesis key in its signature-provider (or private-key)", head)
+}
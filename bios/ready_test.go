@@ -0,0 +1,53 @@
+package bios
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func getInfoServer(chainID string, heads ...uint32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		head := heads[0]
+		if len(heads) > 1 {
+			heads = heads[1:]
+		}
+		fmt.Fprintf(w, `{"chain_id": %q, "head_block_num": %d, "head_block_producer": "eosio"}`, chainID, head)
+	}))
+}
+
+func TestWaitTargetReady(t *testing.T) {
+	defer func(interval time.Duration) { targetReadyPollInterval = interval }(targetReadyPollInterval)
+	targetReadyPollInterval = time.Millisecond
+
+	chainID := strings.Repeat("ab", 32)
+
+	server := getInfoServer(chainID, 1, 1, 2, 3)
+	defer server.Close()
+	b := &BIOS{Log: NewLogger(), TargetNetAPI: eos.New(server.URL)}
+	assert.NoError(t, b.waitTargetReady(time.Second))
+
+	stuck := getInfoServer(chainID, 1)
+	defer stuck.Close()
+	b.TargetNetAPI = eos.New(stuck.URL)
+	err := b.waitTargetReady(20 * time.Millisecond)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "head block stuck at 1, nodeos isn't producing")
+
+	b.TargetChainID = eos.SHA256Bytes(make([]byte, 32))
+	b.TargetNetAPI = eos.New(server.URL)
+	err = b.waitTargetReady(time.Second)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "target node runs chain ID "+chainID)
+
+	b.TargetNetAPI = eos.New("http://127.0.0.1:1")
+	err = b.waitTargetReady(20 * time.Millisecond)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "http://127.0.0.1:1 is unreachable")
+}
@@ -0,0 +1,132 @@
+package bios
+
+import (
+	"fmt"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+	"github.com/eoscanada/eos-go/system"
+)
+
+// DefaultRegProducerTimeout is how long appointed block producers and
+// participants wait for the boot to finish, to register as producers.
+const DefaultRegProducerTimeout = 2 * time.Hour
+
+var regProducerPollInterval = 5 * time.Second
+
+// RegProducerConfig registers our target account as a producer, once
+// the boot is over, so no separate `cleos system regproducer` is
+// needed on launch night.
+type RegProducerConfig struct {
+	BlockSigningPublicKey ecc.PublicKey
+	URL                   string
+	Location              uint16
+	// Signer holds a key of our target account's active permission.
+	Signer  eos.Signer
+	Timeout time.Duration
+}
+
+// registerProducer waits for the boot sequence to be over on the
+// target chain, and pushes `regproducer` for our target account.
+func (b *BIOS) registerProducer() error {
+	config := b.RegProducer
+	if config == nil {
+		return nil
+	}
+
+	account := b.Network.MyPeer.Discovery.TargetAccountName
+	if b.ReadOnly {
+		b.Log.Printf("Read-only mode, not registering %q as a producer\n", account)
+		return nil
+	}
+
+	wasmRef, err := b.GetContentsCacheRef("eosio.system.wasm")
+	if err != nil {
+		return err
+	}
+	wasm, err := b.Network.ReadFromCache(wasmRef)
+	if err != nil {
+		return fmt.Errorf("reading eosio.system.wasm: %s", err)
+	}
+
+	b.Log.Printf("Waiting for the boot to finish, to register %q as a producer", account)
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = DefaultRegProducerTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		waiting := b.bootPending(wasm)
+		if waiting == "" {
+			break
+		}
+		if time.Now().After(deadline) {
+			b.Log.Println("")
+			return fmt.Errorf("%s after %s, not registering as a producer", waiting, timeout)
+		}
+		b.Log.Printf(".")
+		time.Sleep(regProducerPollInterval)
+	}
+	b.Log.Println("")
+
+	api := eos.New(b.TargetNetAPI.BaseURL)
+	api.SetSigner(config.Signer)
+	if _, err := api.SignPushActions(system.NewRegProducer(account, config.BlockSigningPublicKey, config.URL, config.Location)); err != nil {
+		return fmt.Errorf("regproducer for %q: %s", account, err)
+	}
+
+	b.Log.Printf("Registered %q as a producer, signing with %s\n", account, config.BlockSigningPublicKey)
+	return nil
+}
+
+// bootPending tells what the target chain still lacks for the boot to
+// be over, if anything. `regproducer` needs the system contract, and
+// must not land between the actions of the boot sequence, which the
+// boot node validates: when the boot sequence resigns the accounts,
+// its last step hands `eosio` to `eosio.prods`.
+func (b *BIOS) bootPending(wasm []byte) string {
+	code, err := b.TargetNetAPI.GetCode(AN("eosio"))
+	if err != nil || code.CodeHash != sha2(wasm) {
+		return "system contract not set on eosio"
+	}
+
+	if !b.resignsAccounts() {
+		return ""
+	}
+
+	resp, err := b.TargetNetAPI.GetAccount(AN("eosio"))
+	if err == nil {
+		for _, perm := range resp.Permissions {
+			if perm.PermName == "active" && sameAuthority(perm.RequiredAuth, permissionAuthority(AN("eosio.prods"))) {
+				return ""
+			}
+		}
+	}
+	return "eosio not handed to eosio.prods"
+}
+
+// resignsAccounts tells whether the boot sequence ends handing `eosio`
+// to `eosio.prods`.
+func (b *BIOS) resignsAccounts() bool {
+	for _, step := range b.BootSequence {
+		if op, ok := step.Data.(*OpResignAccounts); ok && !op.TestnetKeepAccounts {
+			return true
+		}
+	}
+	return false
+}
+
+// isProducerRegistration tells whether an action is a `regproducer` a
+// producer pushed itself, not part of the boot sequence.
+func isProducerRegistration(act *eos.Action) bool {
+	if act.Account != AN("eosio") || act.Name != eos.ActN("regproducer") {
+		return false
+	}
+	for _, auth := range act.Authorization {
+		if auth.Actor == AN("eosio") {
+			return false
+		}
+	}
+	return true
+}
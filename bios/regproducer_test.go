@@ -0,0 +1,140 @@
+package bios
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eoscanada/eos-bios/bios/disco"
+	eos "github.com/eoscanada/eos-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterProducer(t *testing.T) {
+	defer func(interval time.Duration) { regProducerPollInterval = interval }(regProducerPollInterval)
+	regProducerPollInterval = time.Millisecond
+
+	dir, err := ioutil.TempDir("", "eos-bios-regproducer")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	wasm := []byte("system contract")
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, replaceAllWeirdities("/ipfs/Qmsystem")), wasm, 0644))
+
+	var codeCalls, pushes int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/get_code"):
+			hash := "bios"
+			if atomic.AddInt32(&codeCalls, 1) >= 3 {
+				hash = sha2(wasm)
+			}
+			fmt.Fprintf(w, `{"account_name": "eosio", "code_hash": %q}`, hash)
+		case strings.HasSuffix(r.URL.Path, "/push_transaction"):
+			atomic.AddInt32(&pushes, 1)
+			fmt.Fprint(w, `{"transaction_id": "abcd"}`)
+		}
+	}))
+	defer server.Close()
+
+	b := &BIOS{
+		Log:          NewLogger(),
+		TargetNetAPI: eos.New(server.URL),
+		Network:      &Network{cachePath: dir, MyPeer: &Peer{Discovery: &disco.Discovery{TargetAccountName: AN("p1")}}},
+		LaunchDisco: &disco.Discovery{
+			TargetContents: []disco.ContentRef{{Name: "eosio.system.wasm", Ref: "/ipfs/Qmsystem"}},
+		},
+	}
+
+	// Not asked to.
+	assert.NoError(t, b.registerProducer())
+	assert.Equal(t, int32(0), codeCalls)
+
+	b.RegProducer = &RegProducerConfig{URL: "https://p1.example.com", Signer: eos.NewKeyBag(), Timeout: time.Second}
+	assert.NoError(t, b.registerProducer())
+	assert.Equal(t, int32(3), codeCalls)
+	assert.Equal(t, int32(1), pushes)
+
+	// The system contract never shows up.
+	wasm = []byte("another system contract")
+	b.RegProducer.Timeout = 10 * time.Millisecond
+	err = b.registerProducer()
+	assert.EqualError(t, err, "system contract not set on eosio after 10ms, not registering as a producer")
+	assert.Equal(t, int32(1), pushes)
+}
+
+func TestRegisterProducerAfterBoot(t *testing.T) {
+	defer func(interval time.Duration) { regProducerPollInterval = interval }(regProducerPollInterval)
+	regProducerPollInterval = time.Millisecond
+
+	dir, err := ioutil.TempDir("", "eos-bios-regproducer")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	wasm := []byte("system contract")
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, replaceAllWeirdities("/ipfs/Qmsystem")), wasm, 0644))
+
+	// The system contract is set mid-boot, `eosio` is handed to
+	// `eosio.prods` by the last step.
+	var accountCalls int32
+	var pushedAt []int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/get_code"):
+			fmt.Fprintf(w, `{"account_name": "eosio", "code_hash": %q}`, sha2(wasm))
+		case strings.HasSuffix(r.URL.Path, "/get_account"):
+			actor := "eosio"
+			if atomic.AddInt32(&accountCalls, 1) >= 3 {
+				actor = "eosio.prods"
+			}
+			fmt.Fprintf(w, `{"permissions": [{"perm_name": "active", "required_auth": {"threshold": 1, "accounts": [{"permission": {"actor": %q, "permission": "active"}, "weight": 1}]}}]}`, actor)
+		case strings.HasSuffix(r.URL.Path, "/push_transaction"):
+			pushedAt = append(pushedAt, atomic.LoadInt32(&accountCalls))
+			fmt.Fprint(w, `{"transaction_id": "abcd"}`)
+		}
+	}))
+	defer server.Close()
+
+	b := &BIOS{
+		Log:          NewLogger(),
+		TargetNetAPI: eos.New(server.URL),
+		Network:      &Network{cachePath: dir, MyPeer: &Peer{Discovery: &disco.Discovery{TargetAccountName: AN("p1")}}},
+		LaunchDisco: &disco.Discovery{
+			TargetContents: []disco.ContentRef{{Name: "eosio.system.wasm", Ref: "/ipfs/Qmsystem"}},
+		},
+		BootSequence: []*OperationType{{Op: "system.resign_accounts", Data: &OpResignAccounts{}}},
+		RegProducer:  &RegProducerConfig{Signer: eos.NewKeyBag(), Timeout: time.Second},
+	}
+	assert.NoError(t, b.registerProducer())
+	assert.Equal(t, []int32{3}, pushedAt, "pushed once eosio was handed over")
+
+	b.RegProducer.Timeout = 10 * time.Millisecond
+	atomic.StoreInt32(&accountCalls, -1000)
+	assert.EqualError(t, b.registerProducer(), "eosio not handed to eosio.prods after 10ms, not registering as a producer")
+	assert.Len(t, pushedAt, 1)
+
+	// A testnet keeping its accounts never hands `eosio` over.
+	b.BootSequence[0].Data = &OpResignAccounts{TestnetKeepAccounts: true}
+	assert.NoError(t, b.registerProducer())
+	assert.Len(t, pushedAt, 2)
+}
+
+func TestIsProducerRegistration(t *testing.T) {
+	regproducer := func(actor string) *eos.Action {
+		return &eos.Action{
+			Account:       AN("eosio"),
+			Name:          eos.ActN("regproducer"),
+			Authorization: []eos.PermissionLevel{{Actor: AN(actor), Permission: PN("active")}},
+		}
+	}
+	assert.True(t, isProducerRegistration(regproducer("p1")))
+	assert.False(t, isProducerRegistration(regproducer("eosio")))
+	assert.False(t, isProducerRegistration(&eos.Action{Account: AN("eosio"), Name: eos.ActN("setprods")}))
+}
@@ -0,0 +1,395 @@
+package bios
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+	"golang.org/x/crypto/ripemd160"
+)
+
+// Remote signers sign transactions without the private keys ever
+// reaching this host. They're regular `eos.Signer`s, set up with
+// `--seednet-signer` (see NewSigner).
+
+// RemoteSignerTokenEnv holds the bearer token sent to HTTP signing
+// services.
+const RemoteSignerTokenEnv = "EOS_BIOS_REMOTE_SIGNER_TOKEN"
+
+var remoteSignerClient = &http.Client{Timeout: 30 * time.Second}
+
+// HTTPSigner delegates signing to a service exposing:
+//
+//	GET  <url>/v1/keys  -> {"keys": ["EOS6..."]}
+//	POST <url>/v1/sign  <- {"chain_id", "digest", "public_keys", "transaction"}
+//	                    -> {"signatures": ["SIG_K1_..."]}
+//
+// `digest` is the hex of the transaction's signing digest, the
+// transaction is there for the service to enforce its own policies.
+type HTTPSigner struct {
+	URL   string
+	Token string
+}
+
+func NewHTTPSigner(url string) *HTTPSigner {
+	return &HTTPSigner{URL: strings.TrimSuffix(url, "/"), Token: os.Getenv(RemoteSignerTokenEnv)}
+}
+
+func (s *HTTPSigner) AvailableKeys() (out []ecc.PublicKey, err error) {
+	var resp struct {
+		Keys []string `json:"keys"`
+	}
+	if err := s.call("GET", "/v1/keys", nil, &resp); err != nil {
+		return nil, err
+	}
+	return parsePublicKeys(resp.Keys)
+}
+
+func (s *HTTPSigner) ImportPrivateKey(wifPrivKey string) error {
+	return fmt.Errorf("remote signer %s doesn't import private keys", s.URL)
+}
+
+func (s *HTTPSigner) Sign(tx *eos.SignedTransaction, chainID []byte, requiredKeys ...ecc.PublicKey) (*eos.SignedTransaction, error) {
+	digest, err := sigDigest(tx, chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	req := map[string]interface{}{
+		"chain_id":    hex.EncodeToString(chainID),
+		"digest":      hex.EncodeToString(digest),
+		"public_keys": keyStrings(requiredKeys),
+		"transaction": tx.Transaction,
+	}
+	var resp struct {
+		Signatures []string `json:"signatures"`
+	}
+	if err := s.call("POST", "/v1/sign", req, &resp); err != nil {
+		return nil, err
+	}
+
+	for _, sigString := range resp.Signatures {
+		sig, err := ecc.NewSignature(sigString)
+		if err != nil {
+			return nil, fmt.Errorf("remote signer %s: invalid signature %q: %s", s.URL, sigString, err)
+		}
+		tx.Signatures = append(tx.Signatures, sig)
+	}
+	return tx, nil
+}
+
+func (s *HTTPSigner) call(method, path string, body, out interface{}) error {
+	var reqBody []byte
+	if body != nil {
+		var err error
+		if reqBody, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, s.URL+path, bytes.NewReader(reqBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+
+	return doSignerRequest(req, s.URL, out)
+}
+
+// VaultSigner signs with a key of HashiCorp Vault's transit secrets
+// engine. Transit has no secp256k1 keys, so the key must be of type
+// `ecdsa-p256`: it is an EOS `R1` key, its `PUB_R1_` public key (see
+// AvailableKeys) goes in the account's authority. The Vault token is
+// taken from $VAULT_TOKEN.
+type VaultSigner struct {
+	Address string
+	Mount   string
+	Key     string
+	Token   string
+
+	publicKey *ecdsa.PublicKey
+}
+
+// NewVaultSigner takes `<vault address>/<transit mount>/<key name>`,
+// like `https://vault.example.com:8200/transit/eos-bp`.
+func NewVaultSigner(location string) (*VaultSigner, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vault location %q: %s", location, err)
+	}
+
+	path := strings.Trim(u.Path, "/")
+	idx := strings.LastIndex(path, "/")
+	if idx == -1 {
+		return nil, fmt.Errorf("invalid vault location %q, expected <address>/<transit mount>/<key name>", location)
+	}
+
+	s := &VaultSigner{Mount: path[:idx], Key: path[idx+1:], Token: os.Getenv("VAULT_TOKEN")}
+	u.Path = ""
+	s.Address = u.String()
+	return s, nil
+}
+
+func (s *VaultSigner) AvailableKeys() (out []ecc.PublicKey, err error) {
+	pubKey, err := s.loadPublicKey()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := ecc.NewPublicKey(R1PublicKeyString(pubKey))
+	if err != nil {
+		return nil, err
+	}
+	return []ecc.PublicKey{key}, nil
+}
+
+func (s *VaultSigner) ImportPrivateKey(wifPrivKey string) error {
+	return fmt.Errorf("vault signer %s doesn't import private keys", s.Key)
+}
+
+func (s *VaultSigner) Sign(tx *eos.SignedTransaction, chainID []byte, requiredKeys ...ecc.PublicKey) (*eos.SignedTransaction, error) {
+	pubKey, err := s.loadPublicKey()
+	if err != nil {
+		return nil, err
+	}
+
+	digest, err := sigDigest(tx, chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	req := map[string]interface{}{
+		"input":                base64.StdEncoding.EncodeToString(digest),
+		"prehashed":            true,
+		"hash_algorithm":       "sha2-256",
+		"marshaling_algorithm": "asn1",
+	}
+	var resp struct {
+		Data struct {
+			Signature string `json:"signature"`
+		} `json:"data"`
+	}
+	if err := s.call("POST", fmt.Sprintf("/v1/%s/sign/%s", s.Mount, s.Key), req, &resp); err != nil {
+		return nil, err
+	}
+
+	// Signatures look like `vault:v1:<base64 DER>`.
+	parts := strings.Split(resp.Data.Signature, ":")
+	der, err := base64.StdEncoding.DecodeString(parts[len(parts)-1])
+	if err != nil {
+		return nil, fmt.Errorf("vault: invalid signature %q: %s", resp.Data.Signature, err)
+	}
+	var rs struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(der, &rs); err != nil {
+		return nil, fmt.Errorf("vault: invalid signature: %s", err)
+	}
+
+	sigString, err := R1SignatureString(pubKey, digest, rs.R, rs.S)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := ecc.NewSignature(sigString)
+	if err != nil {
+		return nil, err
+	}
+
+	tx.Signatures = append(tx.Signatures, sig)
+	return tx, nil
+}
+
+func (s *VaultSigner) loadPublicKey() (*ecdsa.PublicKey, error) {
+	if s.publicKey != nil {
+		return s.publicKey, nil
+	}
+
+	var resp struct {
+		Data struct {
+			Type          string `json:"type"`
+			LatestVersion int    `json:"latest_version"`
+			Keys          map[string]struct {
+				PublicKey string `json:"public_key"`
+			} `json:"keys"`
+		} `json:"data"`
+	}
+	if err := s.call("GET", fmt.Sprintf("/v1/%s/keys/%s", s.Mount, s.Key), nil, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Data.Type != "ecdsa-p256" {
+		return nil, fmt.Errorf("vault key %q is of type %q, only ecdsa-p256 keys can sign for EOS", s.Key, resp.Data.Type)
+	}
+
+	block, _ := pem.Decode([]byte(resp.Data.Keys[fmt.Sprintf("%d", resp.Data.LatestVersion)].PublicKey))
+	if block == nil {
+		return nil, fmt.Errorf("vault key %q: no public key for version %d", s.Key, resp.Data.LatestVersion)
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("vault key %q: %s", s.Key, err)
+	}
+	ecdsaPub, ok := pub.(*ecdsa.PublicKey)
+	if !ok || ecdsaPub.Curve != elliptic.P256() {
+		return nil, fmt.Errorf("vault key %q isn't a P-256 key", s.Key)
+	}
+
+	s.publicKey = ecdsaPub
+	return ecdsaPub, nil
+}
+
+func (s *VaultSigner) call(method, path string, body, out interface{}) error {
+	var reqBody []byte
+	if body != nil {
+		var err error
+		if reqBody, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, s.Address+path, bytes.NewReader(reqBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", s.Token)
+
+	return doSignerRequest(req, "vault "+s.Address, out)
+}
+
+func doSignerRequest(req *http.Request, name string, out interface{}) error {
+	resp, err := remoteSignerClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %s", name, err)
+	}
+	defer resp.Body.Close()
+
+	cnt, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s: %s", name, err)
+	}
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("%s: %s returned status %d: %s", name, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(cnt)))
+	}
+
+	if err := json.Unmarshal(cnt, out); err != nil {
+		return fmt.Errorf("%s: decoding %s: %s", name, req.URL.Path, err)
+	}
+	return nil
+}
+
+func sigDigest(tx *eos.SignedTransaction, chainID []byte) ([]byte, error) {
+	txData, cfd, err := tx.PackedTransactionAndCFD()
+	if err != nil {
+		return nil, fmt.Errorf("packing transaction: %s", err)
+	}
+	return eos.SigDigest(chainID, txData, cfd), nil
+}
+
+func parsePublicKeys(keys []string) (out []ecc.PublicKey, err error) {
+	for _, key := range keys {
+		pubKey, err := ecc.NewPublicKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key %q: %s", key, err)
+		}
+		out = append(out, pubKey)
+	}
+	return
+}
+
+func keyStrings(keys []ecc.PublicKey) (out []string) {
+	for _, key := range keys {
+		out = append(out, key.String())
+	}
+	return
+}
+
+// R1PublicKeyString encodes a P-256 public key the EOS way:
+// `PUB_R1_`, then the base58 of the compressed point and its checksum.
+func R1PublicKeyString(pub *ecdsa.PublicKey) string {
+	return "PUB_R1_" + base58Check(elliptic.MarshalCompressed(elliptic.P256(), pub.X, pub.Y), "R1")
+}
+
+// R1SignatureString encodes an ECDSA P-256 signature of `digest` by
+// `pub` the EOS way: a compact, recoverable signature with a low S,
+// as `SIG_R1_` and the base58 of it and its checksum.
+func R1SignatureString(pub *ecdsa.PublicKey, digest []byte, r, s *big.Int) (string, error) {
+	curve := elliptic.P256()
+	n := curve.Params().N
+
+	s = new(big.Int).Set(s)
+	if s.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+		s.Sub(n, s)
+	}
+	if !ecdsa.Verify(pub, digest, r, s) {
+		return "", fmt.Errorf("signature doesn't match the public key")
+	}
+
+	// The recovery ID is the parity of R = s^-1 (e.G + r.Q).
+	sInv := new(big.Int).ModInverse(s, n)
+	e := new(big.Int).SetBytes(digest)
+	u1 := new(big.Int).Mod(new(big.Int).Mul(e, sInv), n)
+	u2 := new(big.Int).Mod(new(big.Int).Mul(r, sInv), n)
+	x1, y1 := curve.ScalarBaseMult(u1.Bytes())
+	x2, y2 := curve.ScalarMult(pub.X, pub.Y, u2.Bytes())
+	rx, ry := curve.Add(x1, y1, x2, y2)
+	if rx.Cmp(r) != 0 {
+		return "", fmt.Errorf("can't compute the recovery ID of the signature")
+	}
+
+	compact := make([]byte, 65)
+	compact[0] = byte(27 + 4 + ry.Bit(0))
+	r.FillBytes(compact[1:33])
+	s.FillBytes(compact[33:65])
+
+	return "SIG_R1_" + base58Check(compact, "R1"), nil
+}
+
+// base58Check encodes `data` with its checksum, the ripemd160 of it
+// and of the key type suffix (none for legacy `EOS` keys).
+func base58Check(data []byte, suffix string) string {
+	h := ripemd160.New()
+	h.Write(data)
+	h.Write([]byte(suffix))
+	return base58Encode(append(append([]byte{}, data...), h.Sum(nil)[:4]...))
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+func base58Encode(data []byte) string {
+	num := new(big.Int).SetBytes(data)
+	base := big.NewInt(58)
+	mod := new(big.Int)
+
+	var out []byte
+	for num.Sign() > 0 {
+		num.DivMod(num, base, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
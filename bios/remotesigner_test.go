@@ -0,0 +1,84 @@
+package bios
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestR1Signature(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(R1PublicKeyString(&key.PublicKey), "PUB_R1_"))
+
+	for i := 0; i < 10; i++ {
+		digest := sha256.Sum256([]byte(fmt.Sprintf("transaction %d", i)))
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		assert.NoError(t, err)
+
+		sig, err := R1SignatureString(&key.PublicKey, digest[:], r, s)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(sig, "SIG_R1_"))
+
+		_, err = R1SignatureString(&other.PublicKey, digest[:], r, s)
+		assert.Error(t, err)
+	}
+
+	assert.Equal(t, "11", base58Encode([]byte{0, 0}))
+	assert.Equal(t, "2g", base58Encode([]byte{'a'}))
+}
+
+func TestVaultSignerKeys(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.NoError(t, err)
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	keyType := "ecdsa-p256"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/transit/keys/eos-bp" || r.Header.Get("X-Vault-Token") != "s.token" {
+			http.Error(w, "permission denied", 403)
+			return
+		}
+		fmt.Fprintf(w, `{"data":{"type":%q,"latest_version":1,"keys":{"1":{"public_key":%q}}}}`, keyType, pemKey)
+	}))
+	defer server.Close()
+
+	signer, err := NewVaultSigner(server.URL + "/transit/eos-bp")
+	assert.NoError(t, err)
+	assert.Equal(t, "transit", signer.Mount)
+	assert.Equal(t, "eos-bp", signer.Key)
+
+	_, err = signer.AvailableKeys()
+	assert.Error(t, err)
+
+	signer.Token = "s.token"
+	keys, err := signer.AvailableKeys()
+	assert.NoError(t, err)
+	if assert.Len(t, keys, 1) {
+		assert.Equal(t, R1PublicKeyString(&key.PublicKey), keys[0].String())
+	}
+
+	keyType = "ed25519"
+	signer, _ = NewVaultSigner(server.URL + "/transit/eos-bp")
+	signer.Token = "s.token"
+	_, err = signer.AvailableKeys()
+	assert.Error(t, err)
+
+	_, err = NewVaultSigner("https://vault.example.com/eos-bp")
+	assert.Error(t, err)
+}
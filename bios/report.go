@@ -0,0 +1,378 @@
+package bios
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	htmltemplate "html/template"
+	"io/ioutil"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"github.com/eoscanada/eos-bios/bios/disco"
+)
+
+// RandomnessProof records where the shuffling seed came from, so
+// anyone can fetch the same block and reproduce the shuffle.
+type RandomnessProof struct {
+	// Provider is the `entropy_provider` used. BlockNum is the block
+	// number, or the beacon's round or pulse index, and BlockHash the
+	// block hash, or the beacon's output.
+	Provider  string `json:"provider,omitempty"`
+	Source    string `json:"source"`
+	BlockNum  uint32 `json:"block_num"`
+	BlockHash string `json:"block_hash"`
+	Seed      int64  `json:"seed"`
+
+	// MerkleRoot and BlockTime are the Bitcoin block's, when seeded
+	// from one. SeedDerivation is how Seed was computed, the crc64
+	// ECMA of the block hash when empty.
+	MerkleRoot     string     `json:"merkle_root,omitempty"`
+	BlockTime      *time.Time `json:"block_time,omitempty"`
+	SeedDerivation string     `json:"seed_derivation,omitempty"`
+
+	// Policy is how the sources were reconciled, Decision what came
+	// out of the deciding round (the Rounds-th), and Observations what
+	// each source answered then.
+	Policy       string                `json:"policy,omitempty"`
+	Decision     string                `json:"decision,omitempty"`
+	Rounds       int                   `json:"rounds,omitempty"`
+	Observations []*EntropyObservation `json:"observations,omitempty"`
+}
+
+// ValueLabel names what BlockHash holds, for reports.
+func (p *RandomnessProof) ValueLabel() string {
+	switch p.Provider {
+	case EntropyDrand, EntropyNIST:
+		return "Beacon output"
+	}
+	return "Block hash"
+}
+
+// PushedTransaction is a transaction the boot node pushed to the
+// target network, during a step of the boot sequence.
+type PushedTransaction struct {
+	Step          string    `json:"step"`
+	Label         string    `json:"label"`
+	TransactionID string    `json:"transaction_id"`
+	Actions       int       `json:"actions"`
+	PushedAt      time.Time `json:"pushed_at"`
+}
+
+type chainValidationOutcome struct {
+	Actions     int
+	Error       string
+	ValidatedAt time.Time
+}
+
+// LaunchReport summarizes a launch for publication to the community.
+type LaunchReport struct {
+	GeneratedAt   time.Time
+	Role          string
+	TargetChainID string
+	// ChainIDDerivation is how TargetChainID was derived.
+	ChainIDDerivation string
+	Contents          []disco.ContentRef
+	BootSequenceHash  string
+	Randomness        *RandomnessProof
+	Producers         []*LaunchReportProducer
+	Transactions      []*PushedTransaction
+	TransactionURL    string
+
+	ConstitutionHash string
+	ConstitutionAcks []*ConstitutionAck
+
+	Validated       bool
+	ValidationRan   bool
+	ValidationError string
+	ValidatedAt     time.Time
+	ValidatedCount  int
+
+	ScheduleChanges []*ScheduleChange
+
+	Throughput *InjectionMetrics
+}
+
+// LaunchReportProducer is a position in the shuffled schedule.
+type LaunchReportProducer struct {
+	Position      int
+	Role          string
+	SeedAccount   string
+	TargetAccount string
+	Weight        int
+}
+
+// LaunchReport gathers what this node saw and did during the launch.
+func (b *BIOS) LaunchReport() *LaunchReport {
+	report := &LaunchReport{
+		GeneratedAt:     time.Now().UTC(),
+		Randomness:      b.Randomness,
+		Transactions:    b.pushedTransactions,
+		TransactionURL:  b.ReportTransactionURL,
+		ScheduleChanges: b.scheduleChanges,
+		Throughput:      b.injectionMetrics,
+	}
+
+	if b.LaunchDisco != nil {
+		report.TargetChainID = b.LaunchDisco.TargetChainID.String()
+		if len(b.TargetChainID) != 0 {
+			report.TargetChainID = b.TargetChainID.String()
+			report.ChainIDDerivation = b.ChainID.derivation()
+		}
+		report.Contents = b.LaunchDisco.TargetContents
+		report.BootSequenceHash = b.BootSequenceHash
+
+		if constitution, err := b.ReadContents(ConstitutionFile, ""); err == nil {
+			hash := ConstitutionHash(constitution)
+			report.ConstitutionHash = hex.EncodeToString(hash)
+			report.ConstitutionAcks = b.Network.ConstitutionAcks(b.Network.OrderedPeers(b.Network.MyNetwork()), hash)
+		}
+	}
+
+	report.Role, report.Producers = b.reportSchedule()
+
+	if v := b.chainValidation; v != nil {
+		report.ValidationRan = true
+		report.Validated = v.Error == ""
+		report.ValidationError = v.Error
+		report.ValidatedAt = v.ValidatedAt
+		report.ValidatedCount = v.Actions
+	}
+
+	return report
+}
+
+// reportSchedule returns our role, and the shuffled schedule.
+func (b *BIOS) reportSchedule() (role string, producers []*LaunchReportProducer) {
+	if len(b.ShuffledProducers) > 0 {
+		role = b.MyRole().String()
+	}
+
+	for idx, peer := range b.ShuffledProducers {
+		position := "Participant"
+		if idx == 0 {
+			position = "Boot node"
+		} else if idx <= b.AppointedProducers {
+			position = "Appointed BP"
+		}
+
+		producers = append(producers, &LaunchReportProducer{
+			Position:      idx + 1,
+			Role:          position,
+			SeedAccount:   string(peer.Discovery.SeedNetworkAccountName),
+			TargetAccount: string(peer.Discovery.TargetAccountName),
+			Weight:        peer.TotalWeight,
+		})
+	}
+
+	return
+}
+
+// WriteLaunchReport renders the launch report to `filename`, as
+// HTML if it ends with `.html`, as Markdown otherwise.
+func (b *BIOS) WriteLaunchReport(filename string) error {
+	report := b.LaunchReport()
+
+	funcs := map[string]interface{}{
+		"txurl": func(id string) string {
+			if report.TransactionURL == "" {
+				return ""
+			}
+			return fmt.Sprintf(report.TransactionURL, id)
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	switch filepath.Ext(filename) {
+	case ".html", ".htm":
+		tpl, err := htmltemplate.New("report").Funcs(funcs).Parse(launchReportHTML)
+		if err != nil {
+			return err
+		}
+		if err := tpl.Execute(buf, report); err != nil {
+			return err
+		}
+	default:
+		tpl, err := template.New("report").Funcs(funcs).Parse(launchReportMarkdown)
+		if err != nil {
+			return err
+		}
+		if err := tpl.Execute(buf, report); err != nil {
+			return err
+		}
+	}
+
+	if err := ioutil.WriteFile(filename, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+
+	b.Log.Printf("Wrote launch report to %q\n", filename)
+
+	return nil
+}
+
+func (b *BIOS) writeLaunchReport() error {
+	if b.ReportFile == "" {
+		return nil
+	}
+	return b.WriteLaunchReport(b.ReportFile)
+}
+
+const launchReportMarkdown = `# Launch report
+
+Generated at {{ .GeneratedAt.Format "2006-01-02 15:04:05 MST" }}{{ if .Role }}, by a node acting as **{{ .Role }}**{{ end }}.
+{{ if .TargetChainID }}
+Target chain ID: ` + "`{{ .TargetChainID }}`" + `{{ if .ChainIDDerivation }} (derived from the {{ .ChainIDDerivation }}){{ end }}
+{{ end }}
+## Launch data
+{{ if .Contents }}
+Name | Reference | Comment
+---- | --------- | -------
+{{ range .Contents }}{{ .Name }} | ` + "`{{ .Ref }}`" + ` | {{ .Comment }}
+{{ end }}{{ else }}
+No launch data recorded.
+{{ end }}{{ if .BootSequenceHash }}
+Boot sequence sha256: ` + "`{{ .BootSequenceHash }}`" + `
+{{ end }}
+## Randomness
+{{ with .Randomness }}
+The producers were shuffled using the {{ .Source }} #{{ .BlockNum }}.
+
+* {{ .ValueLabel }}: ` + "`{{ .BlockHash }}`" + `
+{{ if .MerkleRoot }}* Merkle root: ` + "`{{ .MerkleRoot }}`" + `
+{{ end }}* Seed ({{ or .SeedDerivation "crc64 ECMA of the block hash" }}): ` + "`{{ .Seed }}`" + `
+{{ if .Policy }}* Sources policy: ` + "`{{ .Policy }}`" + `, {{ .Decision }}, after {{ .Rounds }} round(s)
+{{ range .Observations }}  * {{ .Source }}: {{ if .Error }}{{ .Error }}{{ else }}` + "`{{ .BlockHash }}`" + `{{ end }}
+{{ end }}{{ end }}{{ else }}
+No randomness was used by this node (no shuffle).
+{{ end }}
+## Producer schedule
+{{ if .Producers }}
+Position | Role | Seed account | Target account | Weight
+-------- | ---- | ------------ | -------------- | ------
+{{ range .Producers }}{{ .Position }} | {{ .Role }} | {{ .SeedAccount }} | {{ .TargetAccount }} | {{ .Weight }}
+{{ end }}{{ else }}
+No shuffled schedule.
+{{ end }}
+## Boot transactions
+{{ if .Transactions }}
+Step | Label | Actions | Transaction | Pushed at
+---- | ----- | ------- | ----------- | ---------
+{{ range $tx := .Transactions }}{{ .Step }} | {{ .Label }} | {{ .Actions }} | {{ with txurl .TransactionID }}[` + "`{{ $tx.TransactionID }}`" + `]({{ . }}){{ else }}` + "`{{ .TransactionID }}`" + `{{ end }} | {{ .PushedAt.Format "15:04:05" }}
+{{ end }}{{ else }}
+This node did not push any transaction.
+{{ end }}
+{{ if .ConstitutionHash }}## Constitution acknowledgements
+
+Constitution sha256: ` + "`{{ .ConstitutionHash }}`" + `
+
+Seed account | Acknowledged | Public key | Error
+------------ | ------------ | ---------- | -----
+{{ range .ConstitutionAcks }}{{ .Account }} | {{ if .Valid }}yes{{ else }}no{{ end }} | {{ .PublicKey }} | {{ .Error }}
+{{ end }}
+{{ end }}{{ if .ScheduleChanges }}## Producer schedule changes
+
+Version | Proposed at block | Activated at block | Cause | Producers
+------- | ----------------- | ------------------ | ----- | ---------
+{{ range .ScheduleChanges }}{{ .Version }} | {{ .ProposedBlock }} | {{ if .ActivatedBlock }}{{ .ActivatedBlock }}{{ else }}-{{ end }} | {{ if .Unexplained }}**UNEXPLAINED**{{ else }}{{ .Cause }}{{ end }} | {{ range $idx, $name := .Producers }}{{ if $idx }}, {{ end }}{{ $name }}{{ end }}
+{{ end }}
+{{ end }}{{ with .Throughput }}## Injection throughput
+
+{{ .Transactions }} transactions, {{ .Actions }} actions in {{ .Elapsed }}: {{ printf "%.1f" .TPS }} TPS, {{ printf "%.1f" .ActionsPerSecond }} actions/s.
+
+Stage | Time spent | Share
+----- | ---------- | -----
+{{ range .Breakdown }}{{ .Stage }} | {{ .Duration }} | {{ printf "%.1f" .Share }}%
+{{ end }}{{ with .Bottleneck }}
+Limiting stage: **{{ . }}**, {{ $.Throughput.BottleneckHint }}.
+{{ end }}
+{{ end }}## Verification
+{{ if .ValidationRan }}{{ if .Validated }}
+The chain was validated against the {{ .ValidatedCount }} actions of the boot sequence, at {{ .ValidatedAt.Format "2006-01-02 15:04:05 MST" }}: **all good**.
+{{ else }}
+The chain validation against the {{ .ValidatedCount }} actions of the boot sequence **FAILED**, at {{ .ValidatedAt.Format "2006-01-02 15:04:05 MST" }}:
+
+` + "```" + `
+{{ .ValidationError }}
+` + "```" + `
+{{ end }}{{ else }}
+This node did not validate the chain.
+{{ end }}`
+
+const launchReportHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Launch report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ccc; padding: 0.2em 0.6em; }
+code { font-size: 0.9em; }
+.ok { color: green; } .failed { color: red; }
+</style>
+</head>
+<body>
+<h1>Launch report</h1>
+<p>Generated at {{ .GeneratedAt.Format "2006-01-02 15:04:05 MST" }}{{ if .Role }}, by a node acting as <strong>{{ .Role }}</strong>{{ end }}.</p>
+{{ if .TargetChainID }}<p>Target chain ID: <code>{{ .TargetChainID }}</code>{{ if .ChainIDDerivation }} (derived from the {{ .ChainIDDerivation }}){{ end }}</p>{{ end }}
+
+<h2>Launch data</h2>
+{{ if .Contents }}<table>
+<tr><th>Name</th><th>Reference</th><th>Comment</th></tr>
+{{ range .Contents }}<tr><td>{{ .Name }}</td><td><code>{{ .Ref }}</code></td><td>{{ .Comment }}</td></tr>
+{{ end }}</table>{{ else }}<p>No launch data recorded.</p>{{ end }}
+{{ if .BootSequenceHash }}<p>Boot sequence sha256: <code>{{ .BootSequenceHash }}</code></p>{{ end }}
+
+<h2>Randomness</h2>
+{{ with .Randomness }}<p>The producers were shuffled using the {{ .Source }} #{{ .BlockNum }}.</p>
+<ul>
+<li>{{ .ValueLabel }}: <code>{{ .BlockHash }}</code></li>
+{{ if .MerkleRoot }}<li>Merkle root: <code>{{ .MerkleRoot }}</code></li>{{ end }}
+<li>Seed ({{ or .SeedDerivation "crc64 ECMA of the block hash" }}): <code>{{ .Seed }}</code></li>
+{{ if .Policy }}<li>Sources policy: <code>{{ .Policy }}</code>, {{ .Decision }}, after {{ .Rounds }} round(s)<ul>
+{{ range .Observations }}<li>{{ .Source }}: {{ if .Error }}{{ .Error }}{{ else }}<code>{{ .BlockHash }}</code>{{ end }}</li>
+{{ end }}</ul></li>
+{{ end }}</ul>{{ else }}<p>No randomness was used by this node (no shuffle).</p>{{ end }}
+
+<h2>Producer schedule</h2>
+{{ if .Producers }}<table>
+<tr><th>Position</th><th>Role</th><th>Seed account</th><th>Target account</th><th>Weight</th></tr>
+{{ range .Producers }}<tr><td>{{ .Position }}</td><td>{{ .Role }}</td><td>{{ .SeedAccount }}</td><td>{{ .TargetAccount }}</td><td>{{ .Weight }}</td></tr>
+{{ end }}</table>{{ else }}<p>No shuffled schedule.</p>{{ end }}
+
+<h2>Boot transactions</h2>
+{{ if .Transactions }}<table>
+<tr><th>Step</th><th>Label</th><th>Actions</th><th>Transaction</th><th>Pushed at</th></tr>
+{{ range $tx := .Transactions }}<tr><td>{{ .Step }}</td><td>{{ .Label }}</td><td>{{ .Actions }}</td><td>{{ with txurl .TransactionID }}<a href="{{ . }}"><code>{{ $tx.TransactionID }}</code></a>{{ else }}<code>{{ .TransactionID }}</code>{{ end }}</td><td>{{ .PushedAt.Format "15:04:05" }}</td></tr>
+{{ end }}</table>{{ else }}<p>This node did not push any transaction.</p>{{ end }}
+
+{{ if .ConstitutionHash }}<h2>Constitution acknowledgements</h2>
+<p>Constitution sha256: <code>{{ .ConstitutionHash }}</code></p>
+<table>
+<tr><th>Seed account</th><th>Acknowledged</th><th>Public key</th><th>Error</th></tr>
+{{ range .ConstitutionAcks }}<tr><td>{{ .Account }}</td><td>{{ if .Valid }}yes{{ else }}no{{ end }}</td><td><code>{{ .PublicKey }}</code></td><td>{{ .Error }}</td></tr>
+{{ end }}</table>
+{{ end }}
+{{ if .ScheduleChanges }}<h2>Producer schedule changes</h2>
+<table>
+<tr><th>Version</th><th>Proposed at block</th><th>Activated at block</th><th>Cause</th><th>Producers</th></tr>
+{{ range .ScheduleChanges }}<tr><td>{{ .Version }}</td><td>{{ .ProposedBlock }}</td><td>{{ if .ActivatedBlock }}{{ .ActivatedBlock }}{{ else }}-{{ end }}</td><td>{{ if .Unexplained }}<strong class="failed">UNEXPLAINED</strong>{{ else }}{{ .Cause }}{{ end }}</td><td>{{ range $idx, $name := .Producers }}{{ if $idx }}, {{ end }}{{ $name }}{{ end }}</td></tr>
+{{ end }}</table>
+{{ end }}
+{{ with .Throughput }}<h2>Injection throughput</h2>
+<p>{{ .Transactions }} transactions, {{ .Actions }} actions in {{ .Elapsed }}: {{ printf "%.1f" .TPS }} TPS, {{ printf "%.1f" .ActionsPerSecond }} actions/s.</p>
+<table>
+<tr><th>Stage</th><th>Time spent</th><th>Share</th></tr>
+{{ range .Breakdown }}<tr><td>{{ .Stage }}</td><td>{{ .Duration }}</td><td>{{ printf "%.1f" .Share }}%</td></tr>
+{{ end }}</table>
+{{ with .Bottleneck }}<p>Limiting stage: <strong>{{ . }}</strong>, {{ $.Throughput.BottleneckHint }}.</p>{{ end }}
+{{ end }}
+<h2>Verification</h2>
+{{ if .ValidationRan }}{{ if .Validated }}<p class="ok">The chain was validated against the {{ .ValidatedCount }} actions of the boot sequence, at {{ .ValidatedAt.Format "2006-01-02 15:04:05 MST" }}: <strong>all good</strong>.</p>
+{{ else }}<p class="failed">The chain validation against the {{ .ValidatedCount }} actions of the boot sequence <strong>FAILED</strong>, at {{ .ValidatedAt.Format "2006-01-02 15:04:05 MST" }}:</p>
+<pre>{{ .ValidationError }}</pre>{{ end }}{{ else }}<p>This node did not validate the chain.</p>{{ end }}
+</body>
+</html>
+`
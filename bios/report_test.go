@@ -0,0 +1,42 @@
+package bios
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteLaunchReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eos-bios-report")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b := &BIOS{
+		Log:                  NewLogger(),
+		ReportTransactionURL: "https://explorer.example.com/tx/%s",
+		Randomness: &RandomnessProof{
+			Source:    "seed network block",
+			BlockNum:  1234,
+			BlockHash: "00000004d2",
+			Seed:      42,
+		},
+		pushedTransactions: []*PushedTransaction{
+			{Step: "system.newaccount", Label: "Create system accounts", TransactionID: "abcdef", Actions: 3},
+		},
+		chainValidation: &chainValidationOutcome{Actions: 3},
+	}
+
+	for _, name := range []string{"report.md", "report.html"} {
+		filename := filepath.Join(dir, name)
+		assert.NoError(t, b.WriteLaunchReport(filename))
+
+		cnt, err := ioutil.ReadFile(filename)
+		assert.NoError(t, err)
+		assert.Contains(t, string(cnt), "https://explorer.example.com/tx/abcdef")
+		assert.Contains(t, string(cnt), "seed network block #1234")
+		assert.Contains(t, string(cnt), "all good")
+	}
+}
@@ -0,0 +1,206 @@
+package bios
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+)
+
+// SystemAccounts are the accounts checked by AuditResignation, along
+// with `eosio`, when they exist. The boot lease marker is one of them.
+var SystemAccounts = []eos.AccountName{
+	"eosio.msig", "eosio.token", "eosio.ram", "eosio.ramfee", "eosio.names",
+	"eosio.stake", "eosio.burned", "eosio.saving", "eosio.bpay", "eosio.vpay",
+	"eosio.unregd", "eosio.wrap", "eosio.disco", BootLeaseAccount,
+}
+
+// maxAuthorityDepth is how deep permissions delegating to other
+// accounts are followed, like the chain's `max_authority_depth`.
+const maxAuthorityDepth = 6
+
+// ResignationAudit is the outcome of AuditResignation, for anyone to
+// publish.
+type ResignationAudit struct {
+	ChainID      string                   `json:"chain_id"`
+	HeadBlockNum uint32                   `json:"head_block_num"`
+	CheckedAt    time.Time                `json:"checked_at"`
+	Permissions  []*ResignationPermission `json:"permissions"`
+	Problems     []string                 `json:"problems"`
+}
+
+// ResignationPermission is the `owner` or `active` permission of a
+// system account. Controller is the key, or chain of permissions down
+// to a key, that can satisfy it alone, empty when none can.
+type ResignationPermission struct {
+	Account    eos.AccountName `json:"account"`
+	Permission string          `json:"permission"`
+	Authority  eos.Authority   `json:"authority"`
+	Controller string          `json:"controller,omitempty"`
+}
+
+// AuditResignation checks, on the launched chain at `api`, that no
+// single key controls `eosio` nor any of the system accounts, extra
+// accounts included. Missing system accounts are skipped.
+func AuditResignation(api *eos.API, extra []eos.AccountName) (*ResignationAudit, error) {
+	info, err := api.GetInfo()
+	if err != nil {
+		return nil, fmt.Errorf("get info: %s", err)
+	}
+
+	audit := &ResignationAudit{
+		ChainID:      info.ChainID.String(),
+		HeadBlockNum: info.HeadBlockNum,
+		CheckedAt:    time.Now().UTC(),
+	}
+	a := &resignationAuditor{api: api, accounts: map[eos.AccountName]*eos.AccountResp{}}
+
+	seen := map[eos.AccountName]bool{}
+	for idx, account := range append(append([]eos.AccountName{"eosio"}, SystemAccounts...), extra...) {
+		if seen[account] {
+			continue
+		}
+		seen[account] = true
+
+		resp := a.account(account)
+		if resp == nil {
+			if idx == 0 || idx > len(SystemAccounts) {
+				audit.Problems = append(audit.Problems, fmt.Sprintf("account %q not found", account))
+			}
+			continue
+		}
+
+		for _, perm := range resp.Permissions {
+			if perm.PermName != "owner" && perm.PermName != "active" {
+				continue
+			}
+
+			controller := a.singleController(perm.RequiredAuth, 0)
+			audit.Permissions = append(audit.Permissions, &ResignationPermission{
+				Account:    account,
+				Permission: perm.PermName,
+				Authority:  perm.RequiredAuth,
+				Controller: controller,
+			})
+			if controller != "" {
+				audit.Problems = append(audit.Problems, fmt.Sprintf("%s@%s is controlled by %s alone", account, perm.PermName, controller))
+			}
+		}
+	}
+
+	return audit, nil
+}
+
+type resignationAuditor struct {
+	api      *eos.API
+	accounts map[eos.AccountName]*eos.AccountResp
+}
+
+// account returns the account, nil when it isn't created (the node
+// answered get_info already).
+func (a *resignationAuditor) account(name eos.AccountName) *eos.AccountResp {
+	if resp, found := a.accounts[name]; found {
+		return resp
+	}
+
+	resp, err := a.api.GetAccount(name)
+	if err != nil {
+		resp = nil
+	}
+	a.accounts[name] = resp
+	return resp
+}
+
+// singleController returns the key, or chain of permissions down to
+// a key, that satisfies `auth` alone. The null key doesn't count, no
+// one holds it.
+func (a *resignationAuditor) singleController(auth eos.Authority, depth int) string {
+	for _, key := range auth.Keys {
+		if uint32(key.Weight) >= auth.Threshold && key.PublicKey.String() != nullKey.String() {
+			return fmt.Sprintf("key %s", key.PublicKey)
+		}
+	}
+
+	if depth >= maxAuthorityDepth {
+		return ""
+	}
+
+	for _, account := range auth.Accounts {
+		if uint32(account.Weight) < auth.Threshold {
+			continue
+		}
+
+		level := account.Permission
+		resp := a.account(level.Actor)
+		if resp == nil {
+			continue
+		}
+		for _, perm := range resp.Permissions {
+			if perm.PermName != string(level.Permission) {
+				continue
+			}
+			if controller := a.singleController(perm.RequiredAuth, depth+1); controller != "" {
+				return fmt.Sprintf("%s@%s, %s", level.Actor, level.Permission, controller)
+			}
+		}
+	}
+
+	return ""
+}
+
+// Print writes the report, one line per permission.
+func (r *ResignationAudit) Print(w io.Writer) {
+	fmt.Fprintf(w, "Resignation audit of chain %s, at block %d (%s)\n\n", r.ChainID, r.HeadBlockNum, r.CheckedAt.Format(time.RFC3339))
+	for _, perm := range r.Permissions {
+		controller := "no single key"
+		if perm.Controller != "" {
+			controller = perm.Controller + " ALONE"
+		}
+		fmt.Fprintf(w, "- %s@%s: %s\n", perm.Account, perm.Permission, controller)
+	}
+	fmt.Fprintln(w, "")
+
+	if len(r.Problems) == 0 {
+		fmt.Fprintln(w, "No system account is controlled by a single key.")
+		return
+	}
+	for _, problem := range r.Problems {
+		fmt.Fprintf(w, "PROBLEM: %s\n", problem)
+	}
+}
+
+// SignedResignationAudit is a resignation audit signed off by a launch
+// participant, with a key of its seed network account's `active`
+// permission.
+type SignedResignationAudit struct {
+	Audit       *ResignationAudit `json:"audit"`
+	Account     eos.AccountName   `json:"account"`
+	AuditSHA256 string            `json:"audit_sha256"`
+	Signature   string            `json:"signature"`
+}
+
+// SignResignationAudit signs the sha256 of the audit's JSON with the
+// first of `keys` that is part of `allowed`.
+func SignResignationAudit(audit *ResignationAudit, account eos.AccountName, keys []*ecc.PrivateKey, allowed []ecc.PublicKey) (*SignedResignationAudit, error) {
+	auditJSON, err := json.Marshal(audit)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.Sum256(auditJSON)
+	sig, err := signWithActiveKey(keys, allowed, hash[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &SignedResignationAudit{
+		Audit:       audit,
+		Account:     account,
+		AuditSHA256: sha2(auditJSON),
+		Signature:   sig.String(),
+	}, nil
+}
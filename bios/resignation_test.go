@@ -0,0 +1,87 @@
+package bios
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditResignation(t *testing.T) {
+	byAccount := func(threshold int, actors ...string) string {
+		var accounts []string
+		for _, actor := range actors {
+			accounts = append(accounts, fmt.Sprintf(`{"permission": {"actor": %q, "permission": "active"}, "weight": 1}`, actor))
+		}
+		return fmt.Sprintf(`{"threshold": %d, "accounts": [%s]}`, threshold, strings.Join(accounts, ","))
+	}
+	byKey := func(key string) string {
+		return fmt.Sprintf(`{"threshold": 1, "keys": [{"key": %q, "weight": 1}]}`, key)
+	}
+
+	authorities := map[string]string{
+		"eosio":       byAccount(1, "eosio.prods"),
+		"eosio.prods": byAccount(2, "bp1", "bp2", "bp3"),
+		"bp1":         byKey("EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV"),
+		"bp2":         byKey("EOS5cujNHGMYZZ2tgByyNEUaoPLFhZVmGXbZc9BLJeQkKZFqGYEiQ"),
+		"bp3":         byKey("EOS7rKVuR4j4zWWbRR6xPZxENhnpKRNwn3TNYxKDtGNnyfXjvj8r1"),
+		"eosio.msig":  byAccount(1, "eosio"),
+		"eosio.ram":   byKey("EOS1111111111111111111111111111111114T1Anm"),
+		"eosio.token": byKey("EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV"),
+		"eosio.names": byAccount(1, "bp1"),
+		"eosio.lease": byKey("EOS5cujNHGMYZZ2tgByyNEUaoPLFhZVmGXbZc9BLJeQkKZFqGYEiQ"),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/get_info") {
+			fmt.Fprintf(w, `{"chain_id": %q, "head_block_num": 1000}`, strings.Repeat("ab", 32))
+			return
+		}
+
+		var params struct {
+			AccountName string `json:"account_name"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&params))
+		auth, found := authorities[params.AccountName]
+		if !found {
+			http.Error(w, "unknown key", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, `{"permissions": [{"perm_name": "active", "required_auth": %s}, {"perm_name": "owner", "required_auth": %s}]}`, auth, auth)
+	}))
+	defer server.Close()
+
+	audit, err := AuditResignation(eos.New(server.URL), []eos.AccountName{"eosio.custom"})
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat("ab", 32), audit.ChainID)
+	assert.Equal(t, uint32(1000), audit.HeadBlockNum)
+	assert.Len(t, audit.Permissions, 12)
+	assert.Equal(t, []string{
+		`eosio.token@active is controlled by key EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV alone`,
+		`eosio.token@owner is controlled by key EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV alone`,
+		`eosio.names@active is controlled by bp1@active, key EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV alone`,
+		`eosio.names@owner is controlled by bp1@active, key EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV alone`,
+		`eosio.lease@active is controlled by key EOS5cujNHGMYZZ2tgByyNEUaoPLFhZVmGXbZc9BLJeQkKZFqGYEiQ alone`,
+		`eosio.lease@owner is controlled by key EOS5cujNHGMYZZ2tgByyNEUaoPLFhZVmGXbZc9BLJeQkKZFqGYEiQ alone`,
+		`account "eosio.custom" not found`,
+	}, audit.Problems)
+
+	var out bytes.Buffer
+	audit.Print(&out)
+	assert.Contains(t, out.String(), "- eosio@active: no single key\n")
+	assert.Contains(t, out.String(), "- eosio.ram@owner: no single key\n")
+	assert.Contains(t, out.String(), "PROBLEM: eosio.token@active")
+
+	// With one producer left, `eosio.prods` is a single party.
+	authorities["eosio.prods"] = byAccount(1, "bp1")
+	delete(authorities, "eosio.token")
+	delete(authorities, "eosio.names")
+	audit, err = AuditResignation(eos.New(server.URL), nil)
+	assert.NoError(t, err)
+	assert.Contains(t, audit.Problems, `eosio@active is controlled by eosio.prods@active, bp1@active, key EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV alone`)
+}
@@ -0,0 +1,128 @@
+package bios
+
+import (
+	"fmt"
+
+	eos "github.com/eoscanada/eos-go"
+)
+
+// chainResume tracks the actions already present on a target chain,
+// so re-running the boot sequence against a chain that was partially
+// (or completely) booted only pushes what's missing.
+//
+// Existing state must be a prefix of the boot sequence: once a chunk
+// is found missing, all following chunks must be missing too,
+// otherwise the chain doesn't match the launch data. Only the chunks
+// within `window` of the missing one, which were maybe pushed
+// concurrently, can be found after it.
+type chainResume struct {
+	applied   map[string]bool
+	window    int
+	checked   int
+	missing   bool
+	missingAt int
+	skipped   int
+}
+
+// actionKey identifies an action the same way chain validation does.
+func actionKey(act *eos.Action) (string, error) {
+	act.SetToServer(true)
+	data, err := eos.MarshalBinary(act)
+	if err != nil {
+		return "", fmt.Errorf("binary marshalling: %s", err)
+	}
+	return sha2(data), nil
+}
+
+// loadChainResume reads the blocks of the target chain from `from` up
+// to its head, and gathers the actions they hold.
+func (b *BIOS) loadChainResume(from uint32) (*chainResume, error) {
+	info, err := b.TargetNetAPI.GetInfo()
+	if err != nil {
+		return nil, fmt.Errorf("get info: %s", err)
+	}
+
+	resume := &chainResume{applied: map[string]bool{}}
+	for blockNum := from; blockNum <= info.HeadBlockNum; blockNum++ {
+		keys, err := b.blockActionKeys(blockNum)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			resume.applied[key] = true
+		}
+	}
+
+	return resume, nil
+}
+
+// blockActionKeys returns the keys of the actions in a block of the
+// target chain.
+func (b *BIOS) blockActionKeys(blockNum uint32) (out []string, err error) {
+	block, err := b.TargetNetAPI.GetBlockByNum(blockNum)
+	if err != nil {
+		return nil, fmt.Errorf("get block %d: %s", blockNum, err)
+	}
+
+	for _, receipt := range block.Transactions {
+		unpacked, err := receipt.Transaction.Packed.Unpack()
+		if err != nil {
+			return nil, fmt.Errorf("unpacking transaction in block %d: %s", blockNum, err)
+		}
+
+		for _, act := range unpacked.Actions {
+			key, err := actionKey(act)
+			if err != nil {
+				return nil, fmt.Errorf("block %d: %s", blockNum, err)
+			}
+			out = append(out, key)
+		}
+	}
+
+	return out, nil
+}
+
+// alreadyApplied returns whether `chunk` is already on chain, and
+// fails when existing state diverges from the boot sequence.
+func (r *chainResume) alreadyApplied(chunk []*eos.Action) (bool, error) {
+	present := 0
+	for _, act := range chunk {
+		key, err := actionKey(act)
+		if err != nil {
+			return false, err
+		}
+		if r.applied[key] {
+			present++
+		}
+	}
+
+	r.checked++
+	switch {
+	case present == 0:
+		if !r.missing {
+			r.missing, r.missingAt = true, r.checked
+		}
+		return false, nil
+	case present != len(chunk):
+		return false, fmt.Errorf("existing chain state doesn't match launch data: only %d of %d actions of this transaction are on chain", present, len(chunk))
+	case r.missing && r.checked-r.missingAt >= r.window:
+		return false, fmt.Errorf("existing chain state doesn't match launch data: transaction found on chain after a missing one")
+	}
+
+	r.skipped++
+	return true, nil
+}
+
+// chunkAlreadyApplied asks the operation whether the chain holds the
+// outcome of `chunk`, which converges even when the previous run
+// differed, and falls back to finding its actions on chain.
+func (b *BIOS) chunkAlreadyApplied(resume *chainResume, step *OperationType, chunk []*eos.Action) (bool, error) {
+	if skipper, ok := step.Data.(ChunkSkipper); ok {
+		applied, decided, err := skipper.AlreadyApplied(b, chunk)
+		if err != nil || decided {
+			return applied, err
+		}
+	}
+
+	return resume.alreadyApplied(chunk)
+}
@@ -0,0 +1,120 @@
+package bios
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/system"
+	"github.com/stretchr/testify/assert"
+)
+
+func resumeTestChunks(count int) (out [][]*eos.Action) {
+	for i := 0; i < count; i++ {
+		out = append(out, []*eos.Action{
+			system.NewNewAccount(AN("eosio"), AN(fmt.Sprintf("account%da", i+1)), wellKnownPubkey),
+			system.NewNewAccount(AN("eosio"), AN(fmt.Sprintf("account%db", i+1)), wellKnownPubkey),
+		})
+	}
+	return
+}
+
+// onChain builds a chainResume holding the actions of `chunks`.
+func onChain(t *testing.T, window int, chunks ...[]*eos.Action) *chainResume {
+	resume := &chainResume{applied: map[string]bool{}, window: window}
+	for _, chunk := range chunks {
+		for _, act := range chunk {
+			key, err := actionKey(act)
+			assert.NoError(t, err)
+			resume.applied[key] = true
+		}
+	}
+	return resume
+}
+
+func TestChainResumeAlreadyApplied(t *testing.T) {
+	chunks := resumeTestChunks(5)
+
+	// The chain holds a prefix of the boot sequence.
+	resume := onChain(t, 0, chunks[0], chunks[1])
+	for idx, expected := range []bool{true, true, false, false, false} {
+		applied, err := resume.alreadyApplied(chunks[idx])
+		assert.NoError(t, err)
+		assert.Equal(t, expected, applied, "chunk %d", idx)
+	}
+	assert.Equal(t, 2, resume.skipped)
+
+	// Half a transaction can't be on chain.
+	resume = onChain(t, 0, chunks[0], chunks[1][:1])
+	applied, err := resume.alreadyApplied(chunks[0])
+	assert.True(t, applied)
+	assert.NoError(t, err)
+	_, err = resume.alreadyApplied(chunks[1])
+	assert.EqualError(t, err, "existing chain state doesn't match launch data: only 1 of 2 actions of this transaction are on chain")
+
+	// Chunks pushed concurrently with a missing one can land after it,
+	// within the window.
+	resume = onChain(t, 2, chunks[0], chunks[2], chunks[4])
+	for idx, expected := range []bool{true, false, true, false} {
+		applied, err := resume.alreadyApplied(chunks[idx])
+		assert.NoError(t, err)
+		assert.Equal(t, expected, applied, "chunk %d", idx)
+	}
+	_, err = resume.alreadyApplied(chunks[4])
+	assert.EqualError(t, err, "existing chain state doesn't match launch data: transaction found on chain after a missing one")
+
+	resume = onChain(t, 0, chunks[0], chunks[2])
+	resume.alreadyApplied(chunks[0])
+	resume.alreadyApplied(chunks[1])
+	_, err = resume.alreadyApplied(chunks[2])
+	assert.EqualError(t, err, "existing chain state doesn't match launch data: transaction found on chain after a missing one")
+}
+
+func TestLoadChainResume(t *testing.T) {
+	chunks := resumeTestChunks(3)
+	trx := func(chunk []*eos.Action) string {
+		packed, err := eos.NewSignedTransaction(&eos.Transaction{Actions: chunk}).Pack(eos.CompressionNone)
+		assert.NoError(t, err)
+		cnt, err := json.Marshal(packed)
+		assert.NoError(t, err)
+		return fmt.Sprintf(`{"trx": [1, %s]}`, cnt)
+	}
+	blocks := map[uint32]string{
+		1: `{"transactions": []}`,
+		2: fmt.Sprintf(`{"transactions": [%s]}`, trx(chunks[0])),
+		3: fmt.Sprintf(`{"transactions": [%s]}`, trx(chunks[1])),
+	}
+
+	var blocksRead []uint32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/get_info") {
+			fmt.Fprint(w, `{"head_block_num": 3}`)
+			return
+		}
+		var params struct {
+			BlockNumOrID uint32 `json:"block_num_or_id"`
+		}
+		json.NewDecoder(r.Body).Decode(&params)
+		blocksRead = append(blocksRead, params.BlockNumOrID)
+		fmt.Fprint(w, blocks[params.BlockNumOrID])
+	}))
+	defer server.Close()
+
+	b := &BIOS{Log: NewLogger(), TargetNetAPI: eos.New(server.URL)}
+	resume, err := b.loadChainResume(2)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []uint32{2, 3}, blocksRead)
+	assert.Len(t, resume.applied, 4)
+
+	for idx, expected := range []bool{true, true, false} {
+		applied, err := resume.alreadyApplied(chunks[idx])
+		assert.NoError(t, err)
+		assert.Equal(t, expected, applied, "chunk %d", idx)
+	}
+}
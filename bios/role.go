@@ -1,5 +1,11 @@
 package bios
 
+import "fmt"
+
+// Role is our part in a launch, from our position in the shuffled
+// producers: the boot node injects the boot sequence, the appointed
+// block producers join to sign blocks once it hands off, and the
+// other participants join and validate.
 type Role int
 
 const (
@@ -7,3 +13,98 @@ const (
 	RoleABP
 	RoleParticipant
 )
+
+func (r Role) String() string {
+	switch r {
+	case RoleBootNode:
+		return "boot node"
+	case RoleABP:
+		return "appointed block producer"
+	}
+	return "participant"
+}
+
+// RoleRunner is the execution path of a role, run once the producers
+// are shuffled.
+type RoleRunner interface {
+	Role() Role
+	Run() error
+}
+
+// RoleRunner returns the execution path of our role (see MyRole).
+func (b *BIOS) RoleRunner() RoleRunner {
+	switch b.MyRole() {
+	case RoleBootNode:
+		return &bootNodeRunner{b}
+	case RoleABP:
+		return &abpRunner{b}
+	}
+	return &participantRunner{b}
+}
+
+// bootNodeRunner starts the chain, injects the boot sequence and hands
+// off to the appointed block producers.
+type bootNodeRunner struct{ b *BIOS }
+
+func (r *bootNodeRunner) Role() Role { return RoleBootNode }
+
+func (r *bootNodeRunner) Run() error {
+	return r.b.RunBootSequence()
+}
+
+// abpRunner joins the chain, validates it, and verifies we were
+// appointed in the schedule the boot node set, so we are ready to sign
+// blocks when it hands off. With RegProducer, it then registers as a
+// producer.
+type abpRunner struct{ b *BIOS }
+
+func (r *abpRunner) Role() Role { return RoleABP }
+
+func (r *abpRunner) Run() error {
+	if err := r.b.RunJoinNetwork(true, false); err != nil {
+		return err
+	}
+
+	if err := r.b.checkAppointedSchedule(); err != nil {
+		return err
+	}
+
+	return r.b.registerProducer()
+}
+
+// participantRunner joins the chain and validates it, then registers
+// as a producer with RegProducer.
+type participantRunner struct{ b *BIOS }
+
+func (r *participantRunner) Role() Role { return RoleParticipant }
+
+func (r *participantRunner) Run() error {
+	if err := r.b.RunJoinNetwork(true, false); err != nil {
+		return err
+	}
+
+	return r.b.registerProducer()
+}
+
+// checkAppointedSchedule verifies our target account is in the
+// appointed producer schedule, the first one set by `setprods`, as
+// found by the chain validation.
+func (b *BIOS) checkAppointedSchedule() error {
+	account := b.Network.MyPeer.Discovery.TargetAccountName
+
+	for _, change := range b.scheduleChanges {
+		if change.Cause != "setprods" {
+			continue
+		}
+
+		for _, producer := range change.Producers {
+			if producer == account {
+				b.Log.Printf("Account %q is in the appointed producer schedule version %d, ready to sign blocks\n", account, change.Version)
+				return nil
+			}
+		}
+		return fmt.Errorf("account %q is missing from the appointed producer schedule version %d", account, change.Version)
+	}
+
+	return fmt.Errorf("no appointed producer schedule found on chain")
+}
@@ -0,0 +1,52 @@
+package bios
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/eoscanada/eos-bios/bios/disco"
+	eos "github.com/eoscanada/eos-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleRunner(t *testing.T) {
+	b := &BIOS{AppointedProducers: 2, Log: NewLogger()}
+	for i := 0; i < 5; i++ {
+		name := eos.AccountName(fmt.Sprintf("p%d", i))
+		b.ShuffledProducers = append(b.ShuffledProducers, &Peer{Discovery: &disco.Discovery{SeedNetworkAccountName: name, TargetAccountName: name}})
+	}
+
+	for _, test := range []struct {
+		account string
+		role    Role
+	}{
+		{"p0", RoleBootNode},
+		{"p1", RoleABP},
+		{"p2", RoleABP},
+		{"p3", RoleParticipant},
+	} {
+		b.Network = &Network{MyPeer: &Peer{Discovery: &disco.Discovery{TargetAccountName: AN(test.account)}}}
+		assert.Equal(t, test.role, b.RoleRunner().Role(), test.account)
+	}
+
+	assert.Equal(t, "boot node", RoleBootNode.String())
+	assert.Equal(t, "appointed block producer", RoleABP.String())
+	assert.Equal(t, "participant", RoleParticipant.String())
+}
+
+func TestCheckAppointedSchedule(t *testing.T) {
+	b := &BIOS{
+		Log:     NewLogger(),
+		Network: &Network{MyPeer: &Peer{Discovery: &disco.Discovery{TargetAccountName: AN("p2")}}},
+	}
+	assert.EqualError(t, b.checkAppointedSchedule(), "no appointed producer schedule found on chain")
+
+	b.scheduleChanges = []*ScheduleChange{
+		{Version: 1, Cause: "setprods", Producers: []eos.AccountName{"eosio", "p1", "p2"}},
+		{Version: 2, Cause: "votes", Producers: []eos.AccountName{"p3"}},
+	}
+	assert.NoError(t, b.checkAppointedSchedule())
+
+	b.Network.MyPeer.Discovery.TargetAccountName = AN("p3")
+	assert.EqualError(t, b.checkAppointedSchedule(), `account "p3" is missing from the appointed producer schedule version 1`)
+}
@@ -0,0 +1,130 @@
+package bios
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	eos "github.com/eoscanada/eos-go"
+)
+
+// Scaffold is what a new producer team tells `eos-bios init`, to get a
+// starter discovery file and local config.
+type Scaffold struct {
+	SeedNetworkAccountName eos.AccountName
+	SeedNetworkHTTPAddress string
+	TargetAccountName      eos.AccountName
+	TargetP2PAddress       string
+	TargetHTTPAddress      string
+	Website                string
+	KeybaseUser            string
+	GMTOffset              int16
+
+	// SigningKey is the block signing key, AuthorityKey the key of
+	// the owner and active permissions of the target account.
+	SigningKey   string
+	AuthorityKey string
+}
+
+var scaffoldDiscoveryTemplate = template.Must(template.New("discovery").Parse(`# Your discovery file, published to the seed network's eosio.disco
+# contract with 'eos-bios publish'. Check it with 'eos-bios validate'.
+
+# Your account on the SEED network, where discovery happens.
+seed_network_account_name: {{ .SeedNetworkAccountName }}
+seed_network_http_address: {{ .SeedNetworkHTTPAddress }}
+
+# Your votes for the other teams you want to launch with (weights
+# between 0 and 100). They must have published their discovery file.
+seed_network_peers: []
+# - account: someteam1234
+#   comment: "Why you think they're worth that weight"
+#   weight: 10
+
+# Block on the SEED network before we orchestrate the launch. 0 opts
+# you OUT of being selected as the BIOS Boot node.
+seed_network_launch_block: 0
+
+urls:
+{{- if .Website }}
+- {{ .Website }}
+{{- end }}
+{{- if .KeybaseUser }}
+# Your Keybase user, whose PGP key is fetched to encrypt data to you.
+- keybase:{{ .KeybaseUser }}
+{{- end }}
+# - pgp-fingerprint:0123456789abcdef0123456789abcdef01234567
+# - org:Your Organization
+
+gmt_offset: {{ .GMTOffset }}  # in HourMinutes format
+
+target_network_is_test: 1  # 0 = mainnet, anything else is a testnet
+target_p2p_address: {{ .TargetP2PAddress }}  # "none" to be excluded from the automated meshing
+target_http_address: {{ .TargetHTTPAddress }}
+target_account_name: {{ .TargetAccountName }}
+target_appointed_block_producer_signing_key: {{ .SigningKey }}
+target_initial_authority:
+  owner:
+    threshold: 1
+    keys:
+    - key: {{ .AuthorityKey }}
+      weight: 1
+    accounts: []
+    waits: []
+  active:
+    threshold: 1
+    keys:
+    - key: {{ .AuthorityKey }}
+      weight: 1
+    accounts: []
+    waits: []
+
+# The launch data you vote for. Print the section of your local files
+# with 'eos-bios launch hash --ipfs-add', or copy it from the teams
+# you trust.
+target_contents: []
+# - name: boot_sequence.yaml
+#   ref: /ipfs/Qm...
+#   comment: "sha256:..."
+`))
+
+var scaffoldLocalConfigTemplate = template.Must(template.New("local_config").Parse(`# Your local settings, used with '--local-config local_config.yaml':
+# flag names and their values, merged under the flags given on the
+# command line. Encrypt it with 'age -p' or 'gpg --symmetric' when it
+# holds private keys.
+
+my-discovery: my_discovery_file.yaml
+seednet-api: {{ .SeedNetworkHTTPAddress }}
+target-api: {{ .TargetHTTPAddress }}
+
+# Private keys to {{ .SeedNetworkAccountName }} on the seed network, instead of
+# --seednet-keys:
+# seednet-private-keys:
+# - 5K...
+
+# Shell commands run as hooks, instead of the hook_*.sh files:
+# hooks:
+#   init: systemctl stop nodeos
+#   join_network: ./restart-nodeos.sh
+
+# Register {{ .TargetAccountName }} as a producer once the system contract is live:
+# regproducer: true
+# producer-url: {{ if .Website }}{{ .Website }}{{ else }}https://example.com{{ end }}
+`))
+
+// DiscoveryFile renders a commented discovery file.
+func (s *Scaffold) DiscoveryFile() ([]byte, error) {
+	return s.render(scaffoldDiscoveryTemplate)
+}
+
+// LocalConfigFile renders a commented `--local-config` file.
+func (s *Scaffold) LocalConfigFile() ([]byte, error) {
+	return s.render(scaffoldLocalConfigTemplate)
+}
+
+func (s *Scaffold) render(tpl *template.Template) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := tpl.Execute(buf, s); err != nil {
+		return nil, fmt.Errorf("rendering %s: %s", tpl.Name(), err)
+	}
+	return buf.Bytes(), nil
+}
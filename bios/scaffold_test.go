@@ -0,0 +1,47 @@
+package bios
+
+import (
+	"testing"
+
+	"github.com/eoscanada/eos-bios/bios/disco"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScaffold(t *testing.T) {
+	scaffold := &Scaffold{
+		SeedNetworkAccountName: AN("exampleteam1"),
+		SeedNetworkHTTPAddress: "http://stage0.example.com",
+		TargetAccountName:      AN("exampleteam2"),
+		TargetP2PAddress:       "p2p.example.com:9876",
+		TargetHTTPAddress:      "http://localhost:8888",
+		Website:                "https://example.com",
+		KeybaseUser:            "exampleteam",
+		GMTOffset:              -500,
+		SigningKey:             "EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV",
+		AuthorityKey:           "EOS5cujNHGMYZZ2tgByyNEUaoPLFhZVmGXbZc9BLJeQkKZFqGYEiQ",
+	}
+
+	cnt, err := scaffold.DiscoveryFile()
+	assert.NoError(t, err)
+
+	var discovery *disco.Discovery
+	assert.NoError(t, yamlUnmarshal(cnt, &discovery))
+	assert.NoError(t, ValidateDiscovery(discovery))
+	assert.Equal(t, AN("exampleteam1"), discovery.SeedNetworkAccountName)
+	assert.Equal(t, AN("exampleteam2"), discovery.TargetAccountName)
+	assert.Equal(t, []string{"https://example.com", "keybase:exampleteam"}, discovery.URLs)
+	assert.Equal(t, int16(-500), discovery.GMTOffset)
+	assert.Equal(t, "EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV", discovery.TargetAppointedBlockProducerSigningKey.String())
+	assert.Equal(t, "EOS5cujNHGMYZZ2tgByyNEUaoPLFhZVmGXbZc9BLJeQkKZFqGYEiQ", discovery.TargetInitialAuthority.Active.Keys[0].PublicKey.String())
+
+	cnt, err = scaffold.LocalConfigFile()
+	assert.NoError(t, err)
+
+	var config map[string]interface{}
+	assert.NoError(t, yamlUnmarshal(cnt, &config))
+	assert.Equal(t, map[string]interface{}{
+		"my-discovery": "my_discovery_file.yaml",
+		"seednet-api":  "http://stage0.example.com",
+		"target-api":   "http://localhost:8888",
+	}, config)
+}
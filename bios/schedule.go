@@ -0,0 +1,302 @@
+package bios
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/system"
+)
+
+// ScheduleChange is a producer schedule proposed on the target chain,
+// with the blocks where it was proposed and activated, and the actions
+// that explain it.
+type ScheduleChange struct {
+	Version        uint32
+	ProposedBlock  uint32
+	ActivatedBlock uint32 // zero if not activated yet
+	Producers      []eos.AccountName
+	Cause          string
+	Unexplained    bool
+}
+
+func (c *ScheduleChange) String() string {
+	activated := "not activated yet"
+	if c.ActivatedBlock != 0 {
+		activated = fmt.Sprintf("activated at block %d", c.ActivatedBlock)
+	}
+
+	var names []string
+	for _, name := range c.Producers {
+		names = append(names, string(name))
+	}
+
+	cause := c.Cause
+	if c.Unexplained {
+		cause = "UNEXPLAINED"
+	}
+
+	return fmt.Sprintf("version %d proposed at block %d, %s, by %s: %s", c.Version, c.ProposedBlock, activated, cause, strings.Join(names, ", "))
+}
+
+// scheduleTracker follows the schedule changes block by block. A
+// proposed schedule is explained either by a `setprods` action with
+// the same producers, or by votes for registered producers (the
+// system contract elects them in `onblock`), since the previous
+// proposal.
+type scheduleTracker struct {
+	changes     []*ScheduleChange
+	version     uint32
+	setProds    [][]eos.AccountName
+	votes       bool
+	registered  map[eos.AccountName]bool
+	initialized bool
+}
+
+func newScheduleTracker() *scheduleTracker {
+	return &scheduleTracker{registered: map[eos.AccountName]bool{}}
+}
+
+// addBlock processes the header first: a proposal is the outcome of
+// the previous blocks' transactions.
+func (t *scheduleTracker) addBlock(blockNum uint32, header eos.BlockHeader, actions []*eos.Action) {
+	if !t.initialized {
+		t.version = header.ScheduleVersion
+		t.initialized = true
+	}
+
+	if header.NewProducers != nil {
+		change := &ScheduleChange{
+			Version:       header.NewProducers.Version,
+			ProposedBlock: blockNum,
+		}
+		for _, prod := range header.NewProducers.Producers {
+			change.Producers = append(change.Producers, prod.AccountName)
+		}
+		t.explain(change)
+		t.changes = append(t.changes, change)
+	}
+
+	if header.ScheduleVersion != t.version {
+		t.version = header.ScheduleVersion
+		for _, change := range t.changes {
+			if change.Version == header.ScheduleVersion && change.ActivatedBlock == 0 {
+				change.ActivatedBlock = blockNum
+			}
+		}
+	}
+
+	for _, act := range actions {
+		if act.Account != AN("eosio") {
+			continue
+		}
+
+		switch act.Name {
+		case eos.ActN("setprods"):
+			var setProds system.SetProds
+			if err := eos.UnmarshalBinary(act.HexData, &setProds); err != nil {
+				continue
+			}
+			var names []eos.AccountName
+			for _, prod := range setProds.Schedule {
+				names = append(names, prod.ProducerName)
+			}
+			t.setProds = append(t.setProds, names)
+		case eos.ActN("regproducer"):
+			if len(act.Authorization) != 0 {
+				t.registered[act.Authorization[0].Actor] = true
+			}
+		case eos.ActN("voteproducer"):
+			t.votes = true
+		}
+	}
+}
+
+func (t *scheduleTracker) explain(change *ScheduleChange) {
+	defer func() {
+		t.setProds = nil
+		t.votes = false
+	}()
+
+	for _, names := range t.setProds {
+		if sameAccounts(names, change.Producers) {
+			change.Cause = "setprods"
+			return
+		}
+	}
+
+	if t.votes {
+		change.Cause = "votes"
+		for _, name := range change.Producers {
+			if !t.registered[name] {
+				change.Cause = ""
+			}
+		}
+		if change.Cause != "" {
+			return
+		}
+	}
+
+	change.Unexplained = true
+}
+
+func sameAccounts(a, b []eos.AccountName) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	sortedA := append([]eos.AccountName{}, a...)
+	sortedB := append([]eos.AccountName{}, b...)
+	sort.Slice(sortedA, func(i, j int) bool { return sortedA[i] < sortedA[j] })
+	sort.Slice(sortedB, func(i, j int) bool { return sortedB[i] < sortedB[j] })
+
+	for idx := range sortedA {
+		if sortedA[idx] != sortedB[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+// TrackProducerSchedules reads the blocks `from` to `to` (the head
+// block when zero) and returns the schedule changes found. Start at
+// block 1 to see all `regproducer` actions, or some schedules elected
+// by votes will be reported unexplained.
+func TrackProducerSchedules(api *eos.API, from, to uint32) ([]*ScheduleChange, error) {
+	if to == 0 {
+		info, err := api.GetInfo()
+		if err != nil {
+			return nil, fmt.Errorf("get info: %s", err)
+		}
+		to = info.HeadBlockNum
+	}
+
+	tracker := newScheduleTracker()
+	for blockNum := from; blockNum <= to; blockNum++ {
+		block, err := api.GetBlockByNum(blockNum)
+		if err != nil {
+			return nil, fmt.Errorf("get block %d: %s", blockNum, err)
+		}
+
+		var actions []*eos.Action
+		for _, receipt := range block.Transactions {
+			unpacked, err := receipt.Transaction.Packed.Unpack()
+			if err != nil {
+				return nil, fmt.Errorf("unpacking transaction in block %d: %s", blockNum, err)
+			}
+			actions = append(actions, unpacked.Actions...)
+		}
+
+		tracker.addBlock(blockNum, block.BlockHeader, actions)
+	}
+
+	return tracker.changes, nil
+}
+
+// checkProducerSchedules verifies every schedule change on the target
+// chain is explained by the actions it holds.
+func (b *BIOS) checkProducerSchedules() error {
+	changes, err := TrackProducerSchedules(b.TargetNetAPI, 1, 0)
+	if err != nil {
+		return fmt.Errorf("tracking producer schedules: %s", err)
+	}
+	b.scheduleChanges = changes
+
+	for _, change := range changes {
+		b.Log.Printf("- Producer schedule %s\n", change)
+		if change.Unexplained {
+			return fmt.Errorf("producer schedule version %d, proposed at block %d, matches no setprods action nor votes", change.Version, change.ProposedBlock)
+		}
+	}
+
+	// The appointed schedule is the first one set by `setprods`.
+	for _, change := range changes {
+		if change.Cause != "setprods" {
+			continue
+		}
+		if len(change.Producers) != b.scheduleSize() {
+			return fmt.Errorf("appointed producer schedule version %d has %d producers, expected %d", change.Version, len(change.Producers), b.scheduleSize())
+		}
+		break
+	}
+
+	return nil
+}
+
+// ScheduleConfirmTimeout is how long to wait for the appointed
+// producer schedule to be pending or active, once `setprods` is
+// pushed.
+const ScheduleConfirmTimeout = 2 * time.Minute
+
+var scheduleConfirmPollInterval = time.Second
+
+// producerSchedule is a schedule of `get_producer_schedule`.
+type producerSchedule struct {
+	Version   uint32 `json:"version"`
+	Producers []struct {
+		ProducerName    eos.AccountName `json:"producer_name"`
+		BlockSigningKey string          `json:"block_signing_key"`
+	} `json:"producers"`
+}
+
+// matches returns whether the schedule has the producers and keys of
+// `prodKeys`, in the same order.
+func (s *producerSchedule) matches(prodKeys []system.ProducerKey) bool {
+	if s == nil || len(s.Producers) != len(prodKeys) {
+		return false
+	}
+	for idx, prod := range s.Producers {
+		if prod.ProducerName != prodKeys[idx].ProducerName || prod.BlockSigningKey != prodKeys[idx].BlockSigningKey.String() {
+			return false
+		}
+	}
+	return true
+}
+
+// getProducerSchedule calls `get_producer_schedule`, which eos-go
+// doesn't wrap.
+func getProducerSchedule(api *eos.API) (active, pending *producerSchedule, err error) {
+	resp, err := api.HttpClient.Post(api.BaseURL+"/v1/chain/get_producer_schedule", "application/json", strings.NewReader("{}"))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, nil, fmt.Errorf("get_producer_schedule: status %d", resp.StatusCode)
+	}
+
+	var out struct {
+		Active  *producerSchedule `json:"active"`
+		Pending *producerSchedule `json:"pending"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, nil, fmt.Errorf("get_producer_schedule: %s", err)
+	}
+	return out.Active, out.Pending, nil
+}
+
+// waitProducerSchedule polls `get_producer_schedule` until the pending
+// or active schedule is `prodKeys`, for up to `timeout`.
+func (b *BIOS) waitProducerSchedule(prodKeys []system.ProducerKey, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		active, pending, err := getProducerSchedule(b.TargetNetAPI)
+		if err != nil {
+			b.Log.Debugf("target node error: %s\n", err)
+		} else if active.matches(prodKeys) || pending.matches(prodKeys) {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			if err != nil {
+				return fmt.Errorf("producer schedule not confirmed after %s: %s", timeout, err)
+			}
+			return fmt.Errorf("producer schedule not pending nor active after %s", timeout)
+		}
+		time.Sleep(scheduleConfirmPollInterval)
+	}
+}
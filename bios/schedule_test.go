@@ -0,0 +1,92 @@
+package bios
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+	"github.com/eoscanada/eos-go/system"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleTracker(t *testing.T) {
+	proposal := func(version uint32, names ...string) *eos.OptionalProducerSchedule {
+		sched := &eos.OptionalProducerSchedule{}
+		sched.Version = version
+		for _, name := range names {
+			sched.Producers = append(sched.Producers, eos.ProducerKey{AccountName: AN(name)})
+		}
+		return sched
+	}
+	action := func(name, actor string) *eos.Action {
+		return &eos.Action{
+			Account:       AN("eosio"),
+			Name:          eos.ActN(name),
+			Authorization: []eos.PermissionLevel{{Actor: AN(actor), Permission: PN("active")}},
+		}
+	}
+
+	tracker := newScheduleTracker()
+	tracker.addBlock(10, eos.BlockHeader{}, []*eos.Action{
+		action("regproducer", "bp1"),
+		action("regproducer", "bp2"),
+		action("voteproducer", "voter"),
+	})
+	tracker.addBlock(11, eos.BlockHeader{NewProducers: proposal(1, "bp1", "bp2")}, nil)
+	tracker.addBlock(12, eos.BlockHeader{}, nil)
+	tracker.addBlock(13, eos.BlockHeader{ScheduleVersion: 1}, nil)
+
+	// No votes since the last proposal, and bp3 never registered.
+	tracker.addBlock(14, eos.BlockHeader{ScheduleVersion: 1, NewProducers: proposal(2, "bp1", "bp3")}, nil)
+
+	if assert.Len(t, tracker.changes, 2) {
+		assert.Equal(t, uint32(11), tracker.changes[0].ProposedBlock)
+		assert.Equal(t, uint32(13), tracker.changes[0].ActivatedBlock)
+		assert.Equal(t, "votes", tracker.changes[0].Cause)
+		assert.False(t, tracker.changes[0].Unexplained)
+
+		assert.Equal(t, uint32(14), tracker.changes[1].ProposedBlock)
+		assert.Equal(t, uint32(0), tracker.changes[1].ActivatedBlock)
+		assert.True(t, tracker.changes[1].Unexplained)
+	}
+}
+
+func TestSameAccounts(t *testing.T) {
+	assert.True(t, sameAccounts([]eos.AccountName{"b", "a"}, []eos.AccountName{"a", "b"}))
+	assert.False(t, sameAccounts([]eos.AccountName{"a", "b"}, []eos.AccountName{"a", "c"}))
+	assert.False(t, sameAccounts([]eos.AccountName{"a"}, []eos.AccountName{"a", "b"}))
+}
+
+func TestWaitProducerSchedule(t *testing.T) {
+	defer func(interval time.Duration) { scheduleConfirmPollInterval = interval }(scheduleConfirmPollInterval)
+	scheduleConfirmPollInterval = time.Millisecond
+
+	key, err := ecc.NewPublicKey("EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV")
+	assert.NoError(t, err)
+	prodKeys := []system.ProducerKey{{ProducerName: AN("eosio"), BlockSigningKey: key}, {ProducerName: AN("bp1"), BlockSigningKey: key}}
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chain/get_producer_schedule", r.URL.Path)
+		active := `{"version": 0, "producers": [{"producer_name": "eosio", "block_signing_key": "EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV"}]}`
+		pending := "null"
+		if atomic.AddInt32(&calls, 1) >= 3 {
+			pending = `{"version": 1, "producers": [{"producer_name": "eosio", "block_signing_key": "EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV"}, {"producer_name": "bp1", "block_signing_key": "EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV"}]}`
+		}
+		fmt.Fprintf(w, `{"active": %s, "pending": %s, "proposed": null}`, active, pending)
+	}))
+	defer server.Close()
+
+	b := &BIOS{Log: NewLogger(), TargetNetAPI: eos.New(server.URL)}
+	assert.NoError(t, b.waitProducerSchedule(prodKeys, time.Second))
+	assert.Equal(t, int32(3), calls)
+
+	// bp1 comes before eosio: same producers, but not in the shuffled order.
+	err = b.waitProducerSchedule([]system.ProducerKey{prodKeys[1], prodKeys[0]}, 10*time.Millisecond)
+	assert.EqualError(t, err, "producer schedule not pending nor active after 10ms")
+}
@@ -0,0 +1,384 @@
+package bios
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+	"github.com/eoscanada/eos-go/system"
+	"github.com/eoscanada/eos-go/token"
+	"go.starlark.net/starlark"
+)
+
+// ScriptRef points to a Starlark script shipped in the launch data's
+// `target_contents`. The script is therefore pinned by its IPFS
+// hash, and can additionally be pinned by its `sha256`, as printed
+// by `eos-bios launch hash`.
+type ScriptRef struct {
+	ScriptRef string `json:"script_ref"`
+	SHA256    string `json:"sha256"`
+}
+
+// ScriptMaxExecutionSteps and ScriptTimeout bound the Starlark
+// scripts of the boot sequence: a script running over the steps (over
+// all its calls) or the time (per call) fails its step rather than
+// hanging the boot.
+var (
+	ScriptMaxExecutionSteps uint64 = 1000000000
+	ScriptTimeout                  = 5 * time.Minute
+)
+
+// loadScript executes the script, within a sandbox that has no
+// access to the filesystem nor the network, and returns its globals.
+func (b *BIOS) loadScript(ref ScriptRef, predeclared starlark.StringDict) (*starlark.Thread, starlark.StringDict, error) {
+	src, err := b.ReadContents(ref.ScriptRef, ref.SHA256)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	thread := &starlark.Thread{
+		Name: ref.ScriptRef,
+		Print: func(_ *starlark.Thread, msg string) {
+			b.Log.Printf("[%s] %s\n", ref.ScriptRef, msg)
+		},
+		Load: func(_ *starlark.Thread, module string) (starlark.StringDict, error) {
+			return nil, fmt.Errorf("load() is not available in boot scripts")
+		},
+	}
+	thread.SetMaxExecutionSteps(ScriptMaxExecutionSteps)
+
+	if predeclared == nil {
+		predeclared = starlark.StringDict{}
+	}
+	for name, builtin := range scriptBuiltins {
+		predeclared[name] = builtin
+	}
+
+	stop := scriptDeadline(thread)
+	globals, err := starlark.ExecFile(thread, ref.ScriptRef, src, predeclared)
+	stop()
+	if err != nil {
+		return nil, nil, fmt.Errorf("executing script %q: %s", ref.ScriptRef, err)
+	}
+
+	return thread, globals, nil
+}
+
+// scriptDeadline cancels the thread once it ran for ScriptTimeout,
+// until stopped.
+func scriptDeadline(thread *starlark.Thread) (stop func() bool) {
+	return time.AfterFunc(ScriptTimeout, func() {
+		thread.Cancel(fmt.Sprintf("running for over %s", ScriptTimeout))
+	}).Stop
+}
+
+// callScript calls the function `name` defined in a script.
+func callScript(thread *starlark.Thread, globals starlark.StringDict, name string, args ...starlark.Value) (starlark.Value, error) {
+	fn, found := globals[name]
+	if !found {
+		return nil, fmt.Errorf("script %q doesn't define %s()", thread.Name, name)
+	}
+
+	stop := scriptDeadline(thread)
+	res, err := starlark.Call(thread, fn, starlark.Tuple(args), nil)
+	stop()
+	if err != nil {
+		if evalErr, ok := err.(*starlark.EvalError); ok {
+			return nil, fmt.Errorf("calling %s() in %q: %s", name, thread.Name, evalErr.Backtrace())
+		}
+		return nil, fmt.Errorf("calling %s() in %q: %s", name, thread.Name, err)
+	}
+
+	return res, nil
+}
+
+//
+
+// OpScriptActions runs a custom boot step, written in Starlark. The
+// script's function (`actions` by default) receives the `args`
+// of the step and returns a list of actions, built with the
+// `newaccount`, `transfer`, `delegatebw`, `buyrambytes`, `setpriv`
+// and `action` builtins. A `None` in the list ends a transaction.
+type OpScriptActions struct {
+	ScriptRef
+	Function  string
+	Args      map[string]interface{}
+	IsMainnet bool
+}
+
+func (op *OpScriptActions) ResetTestnetOptions() {
+	op.IsMainnet = true
+}
+
+func (op *OpScriptActions) Actions(b *BIOS) (out []*eos.Action, err error) {
+	var producers []starlark.Value
+	for _, prod := range b.ShuffledProducers {
+		producers = append(producers, starlark.String(prod.Discovery.TargetAccountName))
+	}
+
+	thread, globals, err := b.loadScript(op.ScriptRef, starlark.StringDict{
+		"producers":  starlark.NewList(producers),
+		"is_mainnet": starlark.Bool(op.IsMainnet),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := toStarlark(op.Args)
+	if err != nil {
+		return nil, fmt.Errorf("script args: %s", err)
+	}
+
+	function := op.Function
+	if function == "" {
+		function = "actions"
+	}
+
+	res, err := callScript(thread, globals, function, args)
+	if err != nil {
+		return nil, err
+	}
+
+	list, ok := res.(*starlark.List)
+	if !ok {
+		return nil, fmt.Errorf("%s() in %q should return a list, got %s", function, op.ScriptRef.ScriptRef, res.Type())
+	}
+
+	for i := 0; i < list.Len(); i++ {
+		switch el := list.Index(i).(type) {
+		case starlark.NoneType:
+			out = append(out, nil)
+		case *scriptAction:
+			out = append(out, el.action)
+		default:
+			return nil, fmt.Errorf("%s() in %q returned a %s at index %d, expected an action or None", function, op.ScriptRef.ScriptRef, el.Type(), i)
+		}
+	}
+
+	return
+}
+
+//
+
+// transformSnapshot passes each snapshot row through the `transform`
+// function of the snapshot transform script. It receives a dict with
+// `ethereum_address`, `account_name`, `public_key` and `balance`,
+// and returns it (possibly modified), or `None` to drop the row.
+func (b *BIOS) transformSnapshot(ref ScriptRef, snapshot Snapshot) (out Snapshot, err error) {
+	thread, globals, err := b.loadScript(ref, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for idx, hodler := range snapshot {
+		row := starlark.NewDict(4)
+		_ = row.SetKey(starlark.String("ethereum_address"), starlark.String(hodler.EthereumAddress))
+		_ = row.SetKey(starlark.String("account_name"), starlark.String(hodler.AccountName))
+		_ = row.SetKey(starlark.String("public_key"), starlark.String(hodler.EOSPublicKey.String()))
+		_ = row.SetKey(starlark.String("balance"), starlark.String(hodler.Balance.String()))
+
+		res, err := callScript(thread, globals, "transform", row)
+		if err != nil {
+			return nil, fmt.Errorf("snapshot row %d: %s", idx+1, err)
+		}
+
+		if res == starlark.None {
+			continue
+		}
+
+		newRow, ok := res.(*starlark.Dict)
+		if !ok {
+			return nil, fmt.Errorf("snapshot row %d: transform() should return a dict or None, got %s", idx+1, res.Type())
+		}
+
+		get := func(key string) (string, error) {
+			val, found, _ := newRow.Get(starlark.String(key))
+			if !found {
+				return "", fmt.Errorf("snapshot row %d: transform() result lacks %q", idx+1, key)
+			}
+			str, ok := starlark.AsString(val)
+			if !ok {
+				return "", fmt.Errorf("snapshot row %d: %q should be a string", idx+1, key)
+			}
+			return str, nil
+		}
+
+		var fields [4]string
+		for i, key := range []string{"ethereum_address", "account_name", "public_key", "balance"} {
+			if fields[i], err = get(key); err != nil {
+				return nil, err
+			}
+		}
+
+		pubKey, err := ecc.NewPublicKey(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("snapshot row %d: public_key: %s", idx+1, err)
+		}
+
+		balance, err := eos.NewEOSAssetFromString(fields[3])
+		if err != nil {
+			return nil, fmt.Errorf("snapshot row %d: balance: %s", idx+1, err)
+		}
+
+		out = append(out, SnapshotLine{fields[0], pubKey, balance, fields[1]})
+	}
+
+	return
+}
+
+//
+
+// scriptAction wraps an `*eos.Action` built by a script.
+type scriptAction struct {
+	action *eos.Action
+}
+
+func (a *scriptAction) String() string {
+	return fmt.Sprintf("<action %s::%s>", a.action.Account, a.action.Name)
+}
+func (a *scriptAction) Type() string         { return "action" }
+func (a *scriptAction) Freeze()              {}
+func (a *scriptAction) Truth() starlark.Bool { return starlark.True }
+func (a *scriptAction) Hash() (uint32, error) {
+	return 0, fmt.Errorf("unhashable type: action")
+}
+
+var scriptBuiltins = starlark.StringDict{
+	"newaccount": starlark.NewBuiltin("newaccount", func(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var creator, name, pubkey string
+		if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "creator", &creator, "name", &name, "pubkey", &pubkey); err != nil {
+			return nil, err
+		}
+		key, err := ecc.NewPublicKey(pubkey)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", fn.Name(), err)
+		}
+		return &scriptAction{system.NewNewAccount(AN(creator), AN(name), key)}, nil
+	}),
+
+	"transfer": starlark.NewBuiltin("transfer", func(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var from, to, quantity, memo string
+		if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "from", &from, "to", &to, "quantity", &quantity, "memo?", &memo); err != nil {
+			return nil, err
+		}
+		asset, err := eos.NewEOSAssetFromString(quantity)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", fn.Name(), err)
+		}
+		return &scriptAction{token.NewTransfer(AN(from), AN(to), asset, memo)}, nil
+	}),
+
+	"delegatebw": starlark.NewBuiltin("delegatebw", func(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var from, receiver, cpu, net string
+		var transfer bool
+		if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "from", &from, "receiver", &receiver, "cpu", &cpu, "net", &net, "transfer?", &transfer); err != nil {
+			return nil, err
+		}
+		cpuStake, err := eos.NewEOSAssetFromString(cpu)
+		if err != nil {
+			return nil, fmt.Errorf("%s: cpu: %s", fn.Name(), err)
+		}
+		netStake, err := eos.NewEOSAssetFromString(net)
+		if err != nil {
+			return nil, fmt.Errorf("%s: net: %s", fn.Name(), err)
+		}
+		return &scriptAction{system.NewDelegateBW(AN(from), AN(receiver), cpuStake, netStake, transfer)}, nil
+	}),
+
+	"buyrambytes": starlark.NewBuiltin("buyrambytes", func(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var payer, receiver string
+		var bytes int
+		if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "payer", &payer, "receiver", &receiver, "bytes", &bytes); err != nil {
+			return nil, err
+		}
+		return &scriptAction{system.NewBuyRAMBytes(AN(payer), AN(receiver), uint32(bytes))}, nil
+	}),
+
+	"setpriv": starlark.NewBuiltin("setpriv", func(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var account string
+		if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "account", &account); err != nil {
+			return nil, err
+		}
+		return &scriptAction{system.NewSetPriv(AN(account))}, nil
+	}),
+
+	// action(account, name, authorization, hex_data) builds any
+	// action, from its binary-serialized data. `authorization` is a
+	// list of "actor@permission" strings.
+	"action": starlark.NewBuiltin("action", func(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var account, name, hexData string
+		var authorization *starlark.List
+		if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "account", &account, "name", &name, "authorization", &authorization, "hex_data", &hexData); err != nil {
+			return nil, err
+		}
+
+		data, err := hex.DecodeString(hexData)
+		if err != nil {
+			return nil, fmt.Errorf("%s: hex_data: %s", fn.Name(), err)
+		}
+
+		act := &eos.Action{
+			Account:    AN(account),
+			Name:       eos.ActN(name),
+			ActionData: eos.ActionData{HexData: data},
+		}
+
+		for i := 0; i < authorization.Len(); i++ {
+			perm, ok := starlark.AsString(authorization.Index(i))
+			if !ok {
+				return nil, fmt.Errorf("%s: authorization should be a list of strings", fn.Name())
+			}
+			parts := strings.Split(perm, "@")
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("%s: invalid authorization %q, expected actor@permission", fn.Name(), perm)
+			}
+			act.Authorization = append(act.Authorization, eos.PermissionLevel{Actor: AN(parts[0]), Permission: PN(parts[1])})
+		}
+
+		return &scriptAction{act}, nil
+	}),
+}
+
+// toStarlark converts values decoded from YAML/JSON.
+func toStarlark(v interface{}) (starlark.Value, error) {
+	switch val := v.(type) {
+	case nil:
+		return starlark.None, nil
+	case bool:
+		return starlark.Bool(val), nil
+	case string:
+		return starlark.String(val), nil
+	case float64:
+		if val == float64(int64(val)) {
+			return starlark.MakeInt64(int64(val)), nil
+		}
+		return starlark.Float(val), nil
+	case []interface{}:
+		var elems []starlark.Value
+		for _, el := range val {
+			conv, err := toStarlark(el)
+			if err != nil {
+				return nil, err
+			}
+			elems = append(elems, conv)
+		}
+		return starlark.NewList(elems), nil
+	case map[string]interface{}:
+		dict := starlark.NewDict(len(val))
+		for k, el := range val {
+			conv, err := toStarlark(el)
+			if err != nil {
+				return nil, err
+			}
+			if err := dict.SetKey(starlark.String(k), conv); err != nil {
+				return nil, err
+			}
+		}
+		return dict, nil
+	default:
+		return nil, fmt.Errorf("unsupported value %v (%T)", v, v)
+	}
+}
@@ -0,0 +1,108 @@
+package bios
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eoscanada/eos-bios/bios/disco"
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/system"
+	"github.com/stretchr/testify/assert"
+)
+
+func newScriptTestBIOS(t *testing.T, script string) (*BIOS, func()) {
+	dir, err := ioutil.TempDir("", "eos-bios-script")
+	assert.NoError(t, err)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, replaceAllWeirdities("/ipfs/Qmscript")), []byte(script), 0644))
+
+	b := &BIOS{
+		Log:     NewLogger(),
+		Network: &Network{cachePath: dir},
+		LaunchDisco: &disco.Discovery{
+			TargetContents: []disco.ContentRef{{Name: "custom.star", Ref: "/ipfs/Qmscript"}},
+		},
+	}
+	return b, func() { os.RemoveAll(dir) }
+}
+
+func TestOpScriptActions(t *testing.T) {
+	b, cleanup := newScriptTestBIOS(t, `
+def actions(args):
+    out = []
+    for name in args["accounts"]:
+        out.append(setpriv(name))
+    out.append(None)
+    out.append(action("eosio", "noop", ["eosio@active"], "0102"))
+    return out
+`)
+	defer cleanup()
+
+	op := &OpScriptActions{
+		ScriptRef: ScriptRef{ScriptRef: "custom.star"},
+		Args:      map[string]interface{}{"accounts": []interface{}{"eosio.msig", "eosio.wrap"}},
+	}
+	acts, err := op.Actions(b)
+	assert.NoError(t, err)
+	assert.Len(t, acts, 4)
+	assert.Equal(t, system.NewSetPriv(AN("eosio.wrap")), acts[1])
+	assert.Nil(t, acts[2])
+	assert.Equal(t, eos.ActionName("noop"), acts[3].Name)
+	assert.Equal(t, eos.HexBytes{0x01, 0x02}, acts[3].ActionData.HexData)
+
+	op.SHA256 = "deadbeef"
+	_, err = op.Actions(b)
+	assert.Error(t, err)
+}
+
+func TestRunawayScript(t *testing.T) {
+	b, cleanup := newScriptTestBIOS(t, `
+def actions(args):
+    for i in range(1000000000):
+        for j in range(1000000000):
+            pass
+    return []
+`)
+	defer cleanup()
+	op := &OpScriptActions{ScriptRef: ScriptRef{ScriptRef: "custom.star"}}
+
+	defer func(steps uint64) { ScriptMaxExecutionSteps = steps }(ScriptMaxExecutionSteps)
+	ScriptMaxExecutionSteps = 100000
+	_, err := op.Actions(b)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "too many steps")
+	}
+
+	defer func(timeout time.Duration) { ScriptTimeout = timeout }(ScriptTimeout)
+	ScriptMaxExecutionSteps = 0
+	ScriptTimeout = 50 * time.Millisecond
+	_, err = op.Actions(b)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "running for over 50ms")
+	}
+}
+
+func TestTransformSnapshot(t *testing.T) {
+	b, cleanup := newScriptTestBIOS(t, `
+def transform(row):
+    if row["account_name"] == "dropme":
+        return None
+    row["account_name"] = row["account_name"] + "1"
+    return row
+`)
+	defer cleanup()
+
+	snapshot := Snapshot{
+		{EthereumAddress: "0x01", EOSPublicKey: wellKnownPubkey, Balance: eos.Asset{Amount: 10000, Symbol: eos.EOSSymbol}, AccountName: "keepme"},
+		{EthereumAddress: "0x02", EOSPublicKey: wellKnownPubkey, Balance: eos.Asset{Amount: 10000, Symbol: eos.EOSSymbol}, AccountName: "dropme"},
+	}
+
+	out, err := b.transformSnapshot(ScriptRef{ScriptRef: "custom.star"}, snapshot)
+	assert.NoError(t, err)
+	assert.Len(t, out, 1)
+	assert.Equal(t, "keepme1", out[0].AccountName)
+	assert.Equal(t, "0x01", out[0].EthereumAddress)
+}
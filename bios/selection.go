@@ -0,0 +1,91 @@
+package bios
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	eos "github.com/eoscanada/eos-go"
+)
+
+// AppointedSelectionConfig is the boot sequence's `appointed_selection`
+// section: the named policy picking the `appointed_producers` among
+// the shuffled producers, after the boot node. `regions` places
+// producers by seed network account, for `one_per_region`; those not
+// listed are placed by the `gmt_offset` of their discovery file.
+type AppointedSelectionConfig struct {
+	Policy  string                     `json:"policy"`
+	Regions map[eos.AccountName]string `json:"regions"`
+}
+
+// An appointedSelectionPolicy reorders `producers` (the boot node
+// excluded) so that the first `count` are the appointed ones. It must
+// only depend on its inputs, as every participant runs it.
+type appointedSelectionPolicy func(config *AppointedSelectionConfig, producers []*Peer, count int) []*Peer
+
+var appointedSelectionPolicies = map[string]appointedSelectionPolicy{
+	// top appoints the first producers of the shuffle.
+	"top": func(config *AppointedSelectionConfig, producers []*Peer, count int) []*Peer {
+		return producers
+	},
+	// one_per_region appoints the first producer of the shuffle of each
+	// region, then fills the remaining seats in the shuffle's order.
+	"one_per_region": selectOnePerRegion,
+}
+
+const defaultAppointedSelection = "top"
+
+func (c *AppointedSelectionConfig) validate() error {
+	if c == nil {
+		return nil
+	}
+	if _, found := appointedSelectionPolicies[c.policy()]; !found {
+		var names []string
+		for name := range appointedSelectionPolicies {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("appointed_selection: unknown policy %q, expected one of %s", c.Policy, strings.Join(names, ", "))
+	}
+	return nil
+}
+
+func (c *AppointedSelectionConfig) policy() string {
+	if c == nil || c.Policy == "" {
+		return defaultAppointedSelection
+	}
+	return c.Policy
+}
+
+func (c *AppointedSelectionConfig) region(peer *Peer) string {
+	if region, found := c.Regions[peer.Discovery.SeedNetworkAccountName]; found {
+		return region
+	}
+	return fmt.Sprintf("gmt%+d", peer.Discovery.GMTOffset)
+}
+
+// selectAppointed applies the selection policy to the shuffled
+// `producers`, keeping the boot node first.
+func selectAppointed(config *AppointedSelectionConfig, producers []*Peer, count int) []*Peer {
+	if len(producers) <= 1 {
+		return producers
+	}
+
+	selected := appointedSelectionPolicies[config.policy()](config, append([]*Peer{}, producers[1:]...), count)
+	return append([]*Peer{producers[0]}, selected...)
+}
+
+func selectOnePerRegion(config *AppointedSelectionConfig, producers []*Peer, count int) []*Peer {
+	var first, rest []*Peer
+	seen := map[string]bool{}
+	for _, peer := range producers {
+		region := config.region(peer)
+		if len(first) < count && !seen[region] {
+			seen[region] = true
+			first = append(first, peer)
+			continue
+		}
+		rest = append(rest, peer)
+	}
+	return append(first, rest...)
+}
@@ -0,0 +1,75 @@
+package bios
+
+import (
+	"testing"
+
+	"github.com/eoscanada/eos-bios/bios/disco"
+	eos "github.com/eoscanada/eos-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectAppointed(t *testing.T) {
+	peer := func(account string, gmtOffset int16) *Peer {
+		return &Peer{Discovery: &disco.Discovery{SeedNetworkAccountName: AN(account), TargetAccountName: AN(account), GMTOffset: gmtOffset}}
+	}
+	producers := []*Peer{
+		peer("bootnode", -500),
+		peer("alice", -500),
+		peer("bob", -500),
+		peer("carol", 100),
+		peer("dave", 800),
+		peer("eve", 100),
+	}
+	accounts := func(peers []*Peer) (out []string) {
+		for _, peer := range peers {
+			out = append(out, string(peer.Discovery.SeedNetworkAccountName))
+		}
+		return
+	}
+
+	assert.Equal(t, accounts(producers), accounts(selectAppointed(nil, producers, 3)))
+	assert.Equal(t, accounts(producers), accounts(selectAppointed(&AppointedSelectionConfig{Policy: "top"}, producers, 3)))
+
+	config := &AppointedSelectionConfig{Policy: "one_per_region"}
+	assert.NoError(t, config.validate())
+	assert.Equal(t, []string{"bootnode", "alice", "carol", "dave", "bob", "eve"}, accounts(selectAppointed(config, producers, 3)))
+	assert.Equal(t, []string{"bootnode", "alice", "carol", "bob", "dave", "eve"}, accounts(selectAppointed(config, producers, 2)))
+
+	config.Regions = map[eos.AccountName]string{AN("bob"): "asia", AN("dave"): "europe", AN("carol"): "europe"}
+	assert.Equal(t, []string{"bootnode", "alice", "bob", "carol", "dave", "eve"}, accounts(selectAppointed(config, producers, 3)))
+
+	assert.EqualError(t, (&AppointedSelectionConfig{Policy: "lottery"}).validate(), `appointed_selection: unknown policy "lottery", expected one of one_per_region, top`)
+}
+
+func TestAppointProducers(t *testing.T) {
+	peer := func(account string) *Peer {
+		return &Peer{Discovery: &disco.Discovery{SeedNetworkAccountName: AN(account), TargetAccountName: AN(account), GMTOffset: 100}}
+	}
+	regions := map[eos.AccountName]string{AN("alice"): "america", AN("bob"): "america", AN("carol"): "europe", AN("dave"): "asia", AN("eve"): "africa"}
+
+	schedule := func(b *BIOS) (out []string) {
+		for _, key := range (&OpSetProds{}).producerKeys(b) {
+			out = append(out, string(key.ProducerName))
+		}
+		return
+	}
+
+	// `eosio` takes one of the 4 seats, leaving 3 for as many regions.
+	b := &BIOS{
+		AppointedProducers: 4,
+		AppointedSelection: &AppointedSelectionConfig{Policy: "one_per_region", Regions: regions},
+		ShuffledProducers:  []*Peer{peer("bootnode"), peer("alice"), peer("bob"), peer("carol"), peer("dave")},
+	}
+	b.appointProducers()
+	assert.Equal(t, []string{"eosio", "alice", "carol", "dave"}, schedule(b))
+
+	// A fourth region waits behind the producers of the first ones.
+	b.ShuffledProducers = []*Peer{peer("bootnode"), peer("alice"), peer("bob"), peer("carol"), peer("dave"), peer("eve")}
+	b.appointProducers()
+	assert.Equal(t, []string{"eosio", "alice", "carol", "dave"}, schedule(b))
+	var order []string
+	for _, prod := range b.ShuffledProducers {
+		order = append(order, string(prod.Discovery.SeedNetworkAccountName))
+	}
+	assert.Equal(t, []string{"bootnode", "alice", "carol", "dave", "bob", "eve"}, order)
+}
@@ -0,0 +1,106 @@
+package bios
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	eos "github.com/eoscanada/eos-go"
+)
+
+// ShuffleConfig is the boot sequence's `shuffle` section. By default,
+// the top peers are shuffled uniformly. With `weighted`, each position
+// is drawn among the remaining top peers with odds proportional to
+// their weight: `trust` uses their trust graph weight, `scores` the
+// community-assigned `scores` (by seed network account, missing ones
+// count as zero). The draw only uses the launch block's seed, so
+// anyone can verify it: the shuffle audit records these settings and
+// the weights drawn with (see ShuffleAudit).
+type ShuffleConfig struct {
+	Weighted string                    `json:"weighted"`
+	Scores   map[eos.AccountName]int64 `json:"scores"`
+}
+
+func (c *ShuffleConfig) validate() error {
+	if c == nil {
+		return nil
+	}
+
+	switch c.Weighted {
+	case "", "trust":
+	case "scores":
+		for account, score := range c.Scores {
+			if score < 0 {
+				return fmt.Errorf("shuffle: negative score for %q", account)
+			}
+		}
+	default:
+		return fmt.Errorf("shuffle: unknown weighting %q, expected `trust` or `scores`", c.Weighted)
+	}
+	return nil
+}
+
+func (c *ShuffleConfig) weighted() bool {
+	return c != nil && c.Weighted != ""
+}
+
+// weight returns the odds of a peer in a weighted shuffle.
+func (c *ShuffleConfig) weight(peer *Peer) int64 {
+	if c.Weighted == "scores" {
+		return c.Scores[peer.Discovery.SeedNetworkAccountName]
+	}
+	return int64(peer.TotalWeight)
+}
+
+// shuffleTopPeersWith shuffles the same top peers as
+// `shuffleTopPeers`, weighted if the config says so.
+func shuffleTopPeersWith(config *ShuffleConfig, peers []*Peer, src rand.Source) int64 {
+	if !config.weighted() {
+		return shuffleTopPeers(peers, src)
+	}
+
+	shuffleHowMany := int64(math.Min(math.Ceil(float64(len(peers))*0.25), RandomBootFromTop))
+	if shuffleHowMany <= 1 {
+		return shuffleHowMany
+	}
+
+	weightedShuffle(peers[:shuffleHowMany], config.weight, src)
+
+	return shuffleHowMany
+}
+
+// weightedShuffle draws each position in turn among the remaining
+// peers, with odds proportional to their weight. Peers of weight zero
+// keep their order, after all the others.
+func weightedShuffle(peers []*Peer, weight func(*Peer) int64, src rand.Source) {
+	r := rand.New(src)
+
+	for pos := range peers {
+		var total int64
+		for _, peer := range peers[pos:] {
+			if w := weight(peer); w > 0 {
+				total += w
+			}
+		}
+		if total == 0 {
+			return
+		}
+
+		pick := r.Int63n(total)
+		for idx := pos; idx < len(peers); idx++ {
+			w := weight(peers[idx])
+			if w <= 0 {
+				continue
+			}
+			if pick < w {
+				// Keep the remaining peers in order, so the zero
+				// weights stay in theirs.
+				chosen := peers[idx]
+				copy(peers[pos+1:idx+1], peers[pos:idx])
+				peers[pos] = chosen
+				break
+			}
+			pick -= w
+		}
+	}
+}
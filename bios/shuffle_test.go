@@ -41,3 +41,100 @@ func TestShuffling(t *testing.T) {
 		}
 	}
 }
+
+func TestAppointedScheduleSize(t *testing.T) {
+	tests := []struct {
+		numPeers  int
+		appointed int
+		size      int
+		quorum    int
+	}{
+		{30, 21, 21, 15},
+		{30, 7, 7, 5},
+		{4, 7, 4, 3},
+		{1, 21, 1, 1},
+	}
+
+	for _, test := range tests {
+		var peers []*Peer
+		for i := 0; i < test.numPeers; i++ {
+			peers = append(peers, &Peer{Discovery: &disco.Discovery{TargetAccountName: eos.AccountName(fmt.Sprintf("p%d", i))}})
+		}
+		b := &BIOS{ShuffledProducers: peers, AppointedProducers: test.appointed}
+
+		assert.Equal(t, test.size, b.scheduleSize(), fmt.Sprintf("%d peers, %d appointed", test.numPeers, test.appointed))
+		assert.Equal(t, test.quorum, b.HandoffQuorum(), fmt.Sprintf("%d peers, %d appointed", test.numPeers, test.appointed))
+	}
+
+	b := &BIOS{AppointedProducers: 2}
+	for i := 0; i < 5; i++ {
+		b.ShuffledProducers = append(b.ShuffledProducers, &Peer{Discovery: &disco.Discovery{TargetAccountName: eos.AccountName(fmt.Sprintf("p%d", i))}})
+	}
+	assert.False(t, b.IsAppointedBlockProducer("p0"))
+	assert.True(t, b.IsAppointedBlockProducer("p2"))
+	assert.False(t, b.IsAppointedBlockProducer("p3"))
+}
+
+func TestFillProducerSchedule(t *testing.T) {
+	assert.Equal(t, AN("bpa"), accountVariation("bp", 1))
+	assert.Equal(t, AN("bpz"), accountVariation("bp", 26))
+	assert.Equal(t, AN("bp5"), accountVariation("bp", 31))
+	assert.Equal(t, AN("bpaa"), accountVariation("bp", 32))
+	assert.Equal(t, AN("bpa5"), accountVariation("bp", 62))
+	assert.Equal(t, AN("bpba"), accountVariation("bp", 63))
+	assert.Equal(t, AN("producer12aa"), accountVariation("producer1234", 32))
+
+	var peers []*Peer
+	for _, name := range []string{"eosio", "bpone", "producer1234"} {
+		peers = append(peers, &Peer{Discovery: &disco.Discovery{TargetAccountName: AN(name)}})
+	}
+	filled := fillProducerSchedule(peers, MaxAppointedProducers)
+	assert.Len(t, filled, 1+MaxAppointedProducers)
+
+	seen := map[eos.AccountName]bool{}
+	for _, prod := range filled {
+		name := string(prod.Discovery.TargetAccountName)
+		assert.False(t, seen[prod.Discovery.TargetAccountName], name)
+		seen[prod.Discovery.TargetAccountName] = true
+		assert.True(t, len(name) <= 12, name)
+		assert.Equal(t, "", strings.Trim(name, variationChars), name)
+	}
+}
+
+func TestWeightedShuffle(t *testing.T) {
+	newPeers := func() (peers []*Peer) {
+		for i := 0; i < 20; i++ {
+			peers = append(peers, &Peer{Discovery: &disco.Discovery{SeedNetworkAccountName: eos.AccountName(fmt.Sprintf("p%d", i))}})
+		}
+		return
+	}
+	config := &ShuffleConfig{
+		Weighted: "scores",
+		Scores:   map[eos.AccountName]int64{"p0": 1, "p1": 1000, "p2": 1},
+	}
+	assert.NoError(t, config.validate())
+
+	// Deterministic for a given seed.
+	first, second := newPeers(), newPeers()
+	assert.Equal(t, int64(5), shuffleTopPeersWith(config, first, rand.NewSource(42)))
+	shuffleTopPeersWith(config, second, rand.NewSource(42))
+	assert.Equal(t, first, second)
+
+	heavyFirst := 0
+	for seed := int64(0); seed < 200; seed++ {
+		peers := newPeers()
+		shuffleTopPeersWith(config, peers, rand.NewSource(seed))
+
+		// Unscored peers keep their order, after the scored ones.
+		assert.Equal(t, "p3", peers[3].AccountName())
+		assert.Equal(t, "p4", peers[4].AccountName())
+		assert.Equal(t, "p5", peers[5].AccountName())
+
+		if peers[0].AccountName() == "p1" {
+			heavyFirst++
+		}
+	}
+	assert.True(t, heavyFirst > 190, fmt.Sprintf("heaviest peer first %d times out of 200", heavyFirst))
+
+	assert.Error(t, (&ShuffleConfig{Weighted: "stake"}).validate())
+}
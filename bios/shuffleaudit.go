@@ -0,0 +1,380 @@
+package bios
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/eoscanada/eos-bios/bios/beacon"
+	"github.com/eoscanada/eos-bios/bios/btc"
+	"github.com/eoscanada/eos-bios/bios/disco"
+	eos "github.com/eoscanada/eos-go"
+)
+
+// ShuffleAuditFilename is written once the producers are shuffled, for
+// anyone to re-run the shuffle with `eos-bios verify-shuffle`.
+const ShuffleAuditFilename = "shuffle_audit.json"
+
+// ShuffleAudit is everything the shuffle depends on, and what came out
+// of it: the seed along with its derivation inputs, the shuffle and
+// appointed selection settings of the boot sequence, the producers
+// shuffled (by weight, the unreachable ones left out as published by
+// the probe's publisher) and the resulting schedule, clones included.
+// The boot node is first, the appointed producers follow.
+type ShuffleAudit struct {
+	CreatedAt          time.Time                 `json:"created_at"`
+	Account            eos.AccountName           `json:"account"`
+	LaunchDataHash     string                    `json:"launch_data_hash"`
+	BootSequenceHash   string                    `json:"boot_sequence_hash"`
+	Randomness         *RandomnessProof          `json:"randomness"`
+	Shuffle            *ShuffleConfig            `json:"shuffle,omitempty"`
+	AppointedProducers int                       `json:"appointed_producers"`
+	AppointedSelection *AppointedSelectionConfig `json:"appointed_selection,omitempty"`
+	Excluded           []string                  `json:"excluded,omitempty"`
+	Exclusion          *PublishedExclusion       `json:"published_exclusion,omitempty"`
+	Candidates         []*ShuffleAuditPeer       `json:"candidates"`
+	Schedule           []*ShuffleAuditPeer       `json:"schedule"`
+}
+
+type ShuffleAuditPeer struct {
+	SeedAccount   eos.AccountName `json:"seed_account"`
+	TargetAccount eos.AccountName `json:"target_account"`
+	Weight        int             `json:"weight"`
+	GMTOffset     int16           `json:"gmt_offset"`
+}
+
+// SignedShuffleAudit is the file written: the audit as signed, along
+// with its signature, by a key of `account`'s `active` permission on
+// the seed network.
+type SignedShuffleAudit struct {
+	Audit     json.RawMessage        `json:"audit"`
+	Signature *ShuffleAuditSignature `json:"signature,omitempty"`
+}
+
+type ShuffleAuditSignature struct {
+	Account     eos.AccountName `json:"account"`
+	AuditSHA256 string          `json:"audit_sha256"`
+	Signature   string          `json:"signature"`
+}
+
+func shuffleAuditPeers(peers []*Peer) (out []*ShuffleAuditPeer) {
+	for _, peer := range peers {
+		out = append(out, &ShuffleAuditPeer{
+			SeedAccount:   peer.Discovery.SeedNetworkAccountName,
+			TargetAccount: peer.Discovery.TargetAccountName,
+			Weight:        peer.TotalWeight,
+			GMTOffset:     peer.Discovery.GMTOffset,
+		})
+	}
+	return
+}
+
+// ShuffleAudit records the shuffle of ShuffledProducers, nil before
+// the producers are shuffled.
+func (b *BIOS) ShuffleAudit() *ShuffleAudit {
+	if b.Randomness == nil || b.shuffleCandidates == nil {
+		return nil
+	}
+
+	audit := &ShuffleAudit{
+		CreatedAt:          time.Now().UTC().Truncate(time.Second),
+		Account:            b.Network.MyPeer.Discovery.SeedNetworkAccountName,
+		BootSequenceHash:   b.BootSequenceHash,
+		Randomness:         b.Randomness,
+		Shuffle:            b.Shuffle,
+		AppointedProducers: b.AppointedProducers,
+		AppointedSelection: b.AppointedSelection,
+		Excluded:           b.shuffleExcluded,
+		Exclusion:          b.shuffleExclusion,
+		Candidates:         shuffleAuditPeers(b.shuffleCandidates),
+		Schedule:           shuffleAuditPeers(b.ShuffledProducers),
+	}
+	if b.LaunchDisco != nil {
+		audit.LaunchDataHash = LaunchDataHash(b.LaunchDisco.TargetContents)
+	}
+	return audit
+}
+
+// writeShuffleAudit writes the shuffle audit to ShuffleAuditFilename,
+// signed with SeedNetKeys when one of them is an active key of our
+// seed network account, and publishes it to the artifact stores.
+func (b *BIOS) writeShuffleAudit() error {
+	audit := b.ShuffleAudit()
+	if audit == nil {
+		return nil
+	}
+
+	auditJSON, err := json.Marshal(audit)
+	if err != nil {
+		return err
+	}
+	signed := &SignedShuffleAudit{Audit: auditJSON}
+
+	signed.Signature, err = b.signShuffleAudit(auditJSON)
+	if err != nil {
+		b.Log.Printf("WARN: shuffle audit left unsigned: %s\n", err)
+	}
+
+	cnt, err := json.MarshalIndent(signed, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(ShuffleAuditFilename, cnt, 0644); err != nil {
+		return err
+	}
+
+	b.Log.Printf("Wrote the shuffle audit to %q, anyone can re-run the shuffle with `eos-bios verify-shuffle %s`\n", ShuffleAuditFilename, ShuffleAuditFilename)
+	b.publishArtifact(ShuffleAuditFilename, cnt)
+	return nil
+}
+
+func (b *BIOS) signShuffleAudit(auditJSON []byte) (*ShuffleAuditSignature, error) {
+	if len(b.SeedNetKeys) == 0 {
+		return nil, fmt.Errorf("no seed network keys to sign with")
+	}
+
+	account := b.Network.MyPeer.Discovery.SeedNetworkAccountName
+	activeKeys, err := b.Network.ActivePublicKeys(account)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, _ := hex.DecodeString(sha2(auditJSON))
+	sig, err := signWithActiveKey(b.SeedNetKeys, activeKeys, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ShuffleAuditSignature{
+		Account:     account,
+		AuditSHA256: sha2(auditJSON),
+		Signature:   sig.String(),
+	}, nil
+}
+
+// VerifyShuffleAudit decodes a shuffle audit, re-derives its seed from
+// the randomness inputs and re-runs the shuffle from its candidates,
+// failing if it doesn't come to the same schedule. It returns the
+// signature, nil if the audit isn't signed: check it against the seed
+// network with `ShuffleAuditSignature.Verify`.
+//
+// The inputs are taken from the audit as is: check them against their
+// sources with VerifyRandomnessSource and VerifyShuffleCandidates.
+func VerifyShuffleAudit(cnt []byte) (*ShuffleAudit, *ShuffleAuditSignature, error) {
+	var signed *SignedShuffleAudit
+	if err := json.Unmarshal(cnt, &signed); err != nil {
+		return nil, nil, fmt.Errorf("decoding shuffle audit: %s", err)
+	}
+	if signed == nil || len(signed.Audit) == 0 {
+		return nil, nil, fmt.Errorf("no audit in the file")
+	}
+
+	var audit *ShuffleAudit
+	if err := json.Unmarshal(signed.Audit, &audit); err != nil {
+		return nil, nil, fmt.Errorf("decoding shuffle audit: %s", err)
+	}
+	if audit.Randomness == nil {
+		return nil, nil, fmt.Errorf("no randomness in the audit")
+	}
+
+	if sig := signed.Signature; sig != nil {
+		if sig.AuditSHA256 != sha2(signed.Audit) {
+			return nil, nil, fmt.Errorf("signature covers audit %s, file has %s", sig.AuditSHA256, sha2(signed.Audit))
+		}
+		if sig.Account != audit.Account {
+			return nil, nil, fmt.Errorf("audit of %s signed by %s", audit.Account, sig.Account)
+		}
+	}
+
+	if err := verifyRandomnessSeed(audit.Randomness); err != nil {
+		return nil, nil, err
+	}
+	if exclusion := audit.Exclusion; exclusion != nil {
+		if exclusion.Seed != audit.Randomness.Seed {
+			return nil, nil, fmt.Errorf("exclusion published for seed %d, the audit's is %d", exclusion.Seed, audit.Randomness.Seed)
+		}
+		if strings.Join(exclusion.Excluded, ",") != strings.Join(audit.Excluded, ",") {
+			return nil, nil, fmt.Errorf("%s published the exclusion of %s, the audit excludes %s", exclusion.Account, orNone(exclusion.Excluded), orNone(audit.Excluded))
+		}
+	}
+	if err := audit.Shuffle.validate(); err != nil {
+		return nil, nil, err
+	}
+	if err := audit.AppointedSelection.validate(); err != nil {
+		return nil, nil, err
+	}
+
+	var peers []*Peer
+	for _, candidate := range audit.Candidates {
+		peers = append(peers, &Peer{
+			Discovery:   &disco.Discovery{SeedNetworkAccountName: candidate.SeedAccount, TargetAccountName: candidate.TargetAccount, GMTOffset: candidate.GMTOffset},
+			TotalWeight: candidate.Weight,
+		})
+	}
+	shuffleTopPeersWith(audit.Shuffle, peers, rand.NewSource(audit.Randomness.Seed))
+	peers = selectAppointed(audit.AppointedSelection, peers, audit.AppointedProducers)
+	schedule := shuffleAuditPeers(fillProducerSchedule(peers, audit.AppointedProducers))
+
+	if len(schedule) != len(audit.Schedule) {
+		return nil, nil, fmt.Errorf("re-running the shuffle gives %d producers, the audit lists %d", len(schedule), len(audit.Schedule))
+	}
+	for idx, expected := range schedule {
+		actual := audit.Schedule[idx]
+		if actual.SeedAccount != expected.SeedAccount || actual.TargetAccount != expected.TargetAccount {
+			return nil, nil, fmt.Errorf("position %d: re-running the shuffle gives %s (%s), the audit lists %s (%s)", idx+1, expected.SeedAccount, expected.TargetAccount, actual.SeedAccount, actual.TargetAccount)
+		}
+	}
+
+	return audit, signed.Signature, nil
+}
+
+// VerifyShuffleCandidates checks the candidates of a shuffle audit,
+// and their weights, against the discovery graph on the seed network
+// of `net`: every producer of the auditing account's network must be
+// a candidate with the same target account and weight, or excluded as
+// unreachable. The graph is read as it is now, so producers who
+// updated their discovery files since the launch show up too.
+func VerifyShuffleCandidates(audit *ShuffleAudit, net *Network) error {
+	if err := net.updateDiscoveryGraph(); err != nil {
+		return fmt.Errorf("updating discovery graph: %s", err)
+	}
+
+	network := net.NetworkThatIncludes(audit.Account)
+	if network == nil {
+		return fmt.Errorf("%s isn't part of any network of the discovery graph", audit.Account)
+	}
+
+	peers := net.OrderedPeers(network)
+	expected := map[eos.AccountName]*Peer{}
+	for _, peer := range peers {
+		expected[peer.Discovery.SeedNetworkAccountName] = peer
+	}
+	excluded := map[string]bool{}
+	for _, account := range audit.Excluded {
+		excluded[account] = true
+	}
+
+	var problems []string
+	seen := map[eos.AccountName]bool{}
+	for _, candidate := range audit.Candidates {
+		seen[candidate.SeedAccount] = true
+
+		peer := expected[candidate.SeedAccount]
+		switch {
+		case peer == nil:
+			problems = append(problems, fmt.Sprintf("%s isn't in the network", candidate.SeedAccount))
+		case excluded[string(candidate.SeedAccount)]:
+			problems = append(problems, fmt.Sprintf("%s is both a candidate and excluded", candidate.SeedAccount))
+		case peer.Discovery.TargetAccountName != candidate.TargetAccount:
+			problems = append(problems, fmt.Sprintf("%s has target account %s, the audit lists %s", candidate.SeedAccount, peer.Discovery.TargetAccountName, candidate.TargetAccount))
+		case peer.TotalWeight != candidate.Weight:
+			problems = append(problems, fmt.Sprintf("%s has weight %d, the audit lists %d", candidate.SeedAccount, peer.TotalWeight, candidate.Weight))
+		}
+	}
+	for _, peer := range peers {
+		account := peer.Discovery.SeedNetworkAccountName
+		if !seen[account] && !excluded[string(account)] {
+			problems = append(problems, fmt.Sprintf("%s is left out of the candidates", account))
+		}
+	}
+
+	if len(problems) != 0 {
+		return fmt.Errorf("candidates differ from the discovery graph: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// VerifyRandomnessSource fetches the block or beacon round of `proof`
+// from its source, and checks it has the hash or output the seed was
+// derived from: the seed network at `seedNetAPI`, the default Bitcoin
+// explorers, drand relays or NIST beacon.
+func VerifyRandomnessSource(proof *RandomnessProof, seedNetAPI *eos.API) error {
+	var value, merkleRoot string
+	switch proof.Provider {
+	case EntropyBitcoin:
+		var sources []btc.Source
+		for _, explorer := range btc.DefaultExplorers {
+			sources = append(sources, &btc.Esplora{URL: explorer})
+		}
+		header, _, err := btc.FetchBlock(sources, proof.BlockNum, 1)
+		if err != nil {
+			return fmt.Errorf("fetching %s %d: %s", proof.Source, proof.BlockNum, err)
+		}
+		value, merkleRoot = header.Hash, header.MerkleRoot
+	case EntropyDrand:
+		out, err := (&beacon.Drand{}).Round(uint64(proof.BlockNum))
+		if err != nil {
+			return fmt.Errorf("fetching %s %d: %s", proof.Source, proof.BlockNum, err)
+		}
+		value = hex.EncodeToString(out.Value)
+	case EntropyNIST:
+		var chain uint64
+		if _, err := fmt.Sscanf(proof.Source, "NIST beacon chain %d pulse", &chain); err != nil {
+			return fmt.Errorf("no NIST beacon chain in %q", proof.Source)
+		}
+		out, err := (&beacon.NIST{Chain: chain}).Pulse(uint64(proof.BlockNum))
+		if err != nil {
+			return fmt.Errorf("fetching %s %d: %s", proof.Source, proof.BlockNum, err)
+		}
+		value = hex.EncodeToString(out.Value)
+	default:
+		if seedNetAPI == nil {
+			return fmt.Errorf("no seed network to fetch %s %d from", proof.Source, proof.BlockNum)
+		}
+		block, err := seedNetAPI.GetBlockByNum(proof.BlockNum)
+		if err != nil {
+			return fmt.Errorf("fetching %s %d: %s", proof.Source, proof.BlockNum, err)
+		}
+		value = hex.EncodeToString(block.ID)
+	}
+
+	if value != proof.BlockHash {
+		return fmt.Errorf("%s %d is %s, the audit says %s", proof.Source, proof.BlockNum, value, proof.BlockHash)
+	}
+	if merkleRoot != proof.MerkleRoot {
+		return fmt.Errorf("%s %d has merkle root %s, the audit says %s", proof.Source, proof.BlockNum, merkleRoot, proof.MerkleRoot)
+	}
+	return nil
+}
+
+// verifyRandomnessSeed re-derives the seed from the block hash (and
+// merkle root, for Bitcoin) or beacon output of `proof`.
+func verifyRandomnessSeed(proof *RandomnessProof) error {
+	var seed int64
+	switch proof.Provider {
+	case EntropyBitcoin:
+		var err error
+		seed, _, err = BitcoinSeed(proof.BlockHash, proof.MerkleRoot)
+		if err != nil {
+			return err
+		}
+	case EntropyDrand, EntropyNIST:
+		value, err := hex.DecodeString(proof.BlockHash)
+		if err != nil || len(value) < 8 {
+			return fmt.Errorf("invalid beacon output %q", proof.BlockHash)
+		}
+		seed, _ = beaconSeed("beacon output", value)
+	default:
+		hash, err := hex.DecodeString(proof.BlockHash)
+		if err != nil {
+			return fmt.Errorf("invalid block hash %q", proof.BlockHash)
+		}
+		seed = seedFromBlockHash(hash)
+	}
+
+	if seed != proof.Seed {
+		return fmt.Errorf("%s %d derives seed %d, the audit says %d", proof.Source, proof.BlockNum, seed, proof.Seed)
+	}
+	return nil
+}
+
+// Verify checks the audit was signed by a key of the account's
+// `active` permission on the seed network at `api`, and returns that
+// key.
+func (s *ShuffleAuditSignature) Verify(api *eos.API) (string, error) {
+	return verifyActiveSignature(api, s.Account, s.AuditSHA256, s.Signature)
+}
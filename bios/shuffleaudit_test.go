@@ -0,0 +1,188 @@
+package bios
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/eoscanada/eos-bios/bios/disco"
+	eos "github.com/eoscanada/eos-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShuffleAudit(t *testing.T) {
+	blockHash := "00000d3c2e2d8fd37ae1b3ed85f7e31bfe01b0bf4f21a9c08a7fdd31b6bd1e8a"
+	hash, _ := hex.DecodeString(blockHash)
+
+	var peers []*Peer
+	for idx := 0; idx < 12; idx++ {
+		account := AN(fmt.Sprintf("producer%s", string('a'+rune(idx))))
+		peers = append(peers, &Peer{Discovery: &disco.Discovery{SeedNetworkAccountName: account, TargetAccountName: account}, TotalWeight: 100 - idx})
+	}
+
+	b := &BIOS{
+		Log:                NewLogger(),
+		Network:            &Network{MyPeer: peers[0]},
+		BootSequenceHash:   "abcd",
+		AppointedProducers: 21,
+		Randomness:         &RandomnessProof{Provider: EntropySeedNetwork, Source: "seed network block", BlockNum: 100, BlockHash: blockHash, Seed: seedFromBlockHash(hash)},
+		shuffleCandidates:  append([]*Peer{}, peers...),
+		shuffleExcluded:    []string{"unreachable"},
+	}
+	shuffleTopPeersWith(nil, peers, rand.NewSource(b.Randomness.Seed))
+	b.ShuffledProducers = fillProducerSchedule(peers, b.AppointedProducers)
+	assert.Len(t, b.ShuffledProducers, 22)
+
+	sign := func(audit *ShuffleAudit) []byte {
+		auditJSON, _ := json.Marshal(audit)
+		cnt, _ := json.Marshal(&SignedShuffleAudit{Audit: auditJSON})
+		return cnt
+	}
+
+	audit, sig, err := VerifyShuffleAudit(sign(b.ShuffleAudit()))
+	assert.NoError(t, err)
+	assert.Nil(t, sig)
+	if assert.NotNil(t, audit) {
+		assert.Equal(t, []string{"unreachable"}, audit.Excluded)
+		assert.Equal(t, b.ShuffledProducers[0].Discovery.SeedNetworkAccountName, audit.Schedule[0].SeedAccount)
+	}
+
+	tampered := b.ShuffleAudit()
+	tampered.Schedule[0], tampered.Schedule[1] = tampered.Schedule[1], tampered.Schedule[0]
+	_, _, err = VerifyShuffleAudit(sign(tampered))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "position 1: re-running the shuffle gives")
+
+	tampered = b.ShuffleAudit()
+	tampered.Randomness = &RandomnessProof{Source: "seed network block", BlockNum: 100, BlockHash: blockHash, Seed: 42}
+	_, _, err = VerifyShuffleAudit(sign(tampered))
+	assert.EqualError(t, err, fmt.Sprintf("seed network block 100 derives seed %d, the audit says 42", b.Randomness.Seed))
+
+	auditJSON, _ := json.Marshal(b.ShuffleAudit())
+	cnt, _ := json.Marshal(&SignedShuffleAudit{Audit: auditJSON, Signature: &ShuffleAuditSignature{Account: peers[0].Discovery.SeedNetworkAccountName, AuditSHA256: "beef"}})
+	_, _, err = VerifyShuffleAudit(cnt)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "signature covers audit beef")
+}
+
+func TestShuffleAuditWeighted(t *testing.T) {
+	var peers []*Peer
+	scores := map[eos.AccountName]int64{}
+	for idx := 0; idx < 8; idx++ {
+		account := AN(fmt.Sprintf("producer%s", string('a'+rune(idx))))
+		peers = append(peers, &Peer{Discovery: &disco.Discovery{SeedNetworkAccountName: account, TargetAccountName: account}})
+		scores[account] = int64(idx * 10)
+	}
+
+	b := &BIOS{
+		Log:                NewLogger(),
+		Network:            &Network{MyPeer: peers[0]},
+		AppointedProducers: 4,
+		Shuffle:            &ShuffleConfig{Weighted: "scores", Scores: scores},
+		Randomness:         &RandomnessProof{Provider: EntropyDrand, Source: "drand", BlockNum: 7, BlockHash: "0102030405060708090a"},
+		shuffleCandidates:  append([]*Peer{}, peers...),
+	}
+	b.Randomness.Seed, _ = beaconSeed("randomness", []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10})
+	shuffleTopPeersWith(b.Shuffle, peers, rand.NewSource(b.Randomness.Seed))
+	b.ShuffledProducers = peers
+
+	auditJSON, _ := json.Marshal(b.ShuffleAudit())
+	cnt, _ := json.Marshal(&SignedShuffleAudit{Audit: auditJSON})
+	audit, _, err := VerifyShuffleAudit(cnt)
+	assert.NoError(t, err)
+	if assert.NotNil(t, audit) {
+		assert.Equal(t, int64(70), audit.Shuffle.Scores[AN("producerh")])
+	}
+
+	// Other scores, same seed: not the audited schedule.
+	var tampered *ShuffleAudit
+	_ = json.Unmarshal(auditJSON, &tampered)
+	tampered.Shuffle.Scores = map[eos.AccountName]int64{AN("producera"): 1}
+	auditJSON, _ = json.Marshal(tampered)
+	cnt, _ = json.Marshal(&SignedShuffleAudit{Audit: auditJSON})
+	_, _, err = VerifyShuffleAudit(cnt)
+	assert.Error(t, err)
+}
+
+func TestShuffleAuditSelection(t *testing.T) {
+	var peers []*Peer
+	for idx := 0; idx < 6; idx++ {
+		account := AN(fmt.Sprintf("producer%s", string('a'+rune(idx))))
+		peers = append(peers, &Peer{Discovery: &disco.Discovery{SeedNetworkAccountName: account, TargetAccountName: account, GMTOffset: int16(idx / 2 * 100)}})
+	}
+
+	b := &BIOS{
+		Log:                NewLogger(),
+		Network:            &Network{MyPeer: peers[0]},
+		AppointedProducers: 4,
+		AppointedSelection: &AppointedSelectionConfig{Policy: "one_per_region"},
+		Randomness:         &RandomnessProof{Source: "seed network block", BlockNum: 1, BlockHash: "beef", Seed: seedFromBlockHash([]byte{0xbe, 0xef})},
+		shuffleCandidates:  append([]*Peer{}, peers...),
+	}
+	b.ShuffledProducers = fillProducerSchedule(selectAppointed(b.AppointedSelection, peers, b.AppointedProducers), b.AppointedProducers)
+
+	auditJSON, _ := json.Marshal(b.ShuffleAudit())
+	cnt, _ := json.Marshal(&SignedShuffleAudit{Audit: auditJSON})
+	_, _, err := VerifyShuffleAudit(cnt)
+	assert.NoError(t, err)
+
+	// Appointing the top instead isn't the audited schedule.
+	b.AppointedSelection = nil
+	auditJSON, _ = json.Marshal(b.ShuffleAudit())
+	cnt, _ = json.Marshal(&SignedShuffleAudit{Audit: auditJSON})
+	_, _, err = VerifyShuffleAudit(cnt)
+	assert.Error(t, err)
+}
+
+func TestVerifyRandomnessSource(t *testing.T) {
+	blockHash := "00000d3c2e2d8fd37ae1b3ed85f7e31bfe01b0bf4f21a9c08a7fdd31b6bd1e8a"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"id": %q, "block_num": 100}`, blockHash)
+	}))
+	defer server.Close()
+
+	proof := &RandomnessProof{Provider: EntropySeedNetwork, Source: "seed network block", BlockNum: 100, BlockHash: blockHash}
+	assert.NoError(t, VerifyRandomnessSource(proof, eos.New(server.URL)))
+
+	proof.BlockHash = "00000d3c" + strings.Repeat("00", 28)
+	assert.EqualError(t, VerifyRandomnessSource(proof, eos.New(server.URL)), fmt.Sprintf("seed network block 100 is %s, the audit says %s", blockHash, proof.BlockHash))
+	assert.Error(t, VerifyRandomnessSource(proof, nil))
+}
+
+func TestVerifyShuffleCandidates(t *testing.T) {
+	discovery := func(account string, peers ...string) *Peer {
+		d := &disco.Discovery{SeedNetworkAccountName: AN(account), TargetAccountName: AN(account)}
+		for _, peer := range peers {
+			d.SeedNetworkPeers = append(d.SeedNetworkPeers, &disco.PeerLink{Account: AN(peer), Weight: 1})
+		}
+		return &Peer{Discovery: d}
+	}
+	net := NewNetwork("/tmp/shuffle-candidates-test-cache", &disco.Discovery{SeedNetworkAccountName: AN("alice")}, nil, "eosio.disco", nil)
+	net.Log = NewLogger()
+	net.allNodesFetchFunc = func() error {
+		net.allNodes.AddNode(discovery("alice", "bob", "carol"))
+		net.allNodes.AddNode(discovery("bob", "alice", "carol"))
+		net.allNodes.AddNode(discovery("carol", "alice"))
+		return nil
+	}
+
+	audit := &ShuffleAudit{
+		Account: AN("alice"),
+		Candidates: []*ShuffleAuditPeer{
+			{SeedAccount: AN("alice"), TargetAccount: AN("alice"), Weight: 2},
+			{SeedAccount: AN("carol"), TargetAccount: AN("carol"), Weight: 2},
+		},
+		Excluded: []string{"bob"},
+	}
+	assert.NoError(t, VerifyShuffleCandidates(audit, net))
+
+	// Weights inflated, and a producer dropped without being excluded.
+	audit.Candidates[1].Weight = 50
+	audit.Excluded = nil
+	assert.EqualError(t, VerifyShuffleCandidates(audit, net), "candidates differ from the discovery graph: carol has weight 2, the audit lists 50; bob is left out of the candidates")
+}
@@ -0,0 +1,156 @@
+package bios
+
+import (
+	"fmt"
+	"strings"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+)
+
+// MultiSigner combines the signatures of several signers, for
+// accounts whose authority requires more than one key (like a
+// two-person account, with one key in a local wallet and the other on
+// a hardware device behind a signing service).
+//
+// `get_required_keys` is given the keys of all the signers, and each
+// signer then signs with the required keys it holds.
+type MultiSigner struct {
+	Signers []eos.Signer
+}
+
+func NewMultiSigner(signers ...eos.Signer) *MultiSigner {
+	return &MultiSigner{Signers: signers}
+}
+
+func (s *MultiSigner) AvailableKeys() (out []ecc.PublicKey, err error) {
+	for _, signer := range s.Signers {
+		keys, err := signer.AvailableKeys()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, keys...)
+	}
+	return
+}
+
+// ImportPrivateKey imports into the first signer, the local key bag.
+func (s *MultiSigner) ImportPrivateKey(wifPrivKey string) error {
+	return s.Signers[0].ImportPrivateKey(wifPrivKey)
+}
+
+func (s *MultiSigner) Sign(tx *eos.SignedTransaction, chainID []byte, requiredKeys ...ecc.PublicKey) (*eos.SignedTransaction, error) {
+	remaining := map[string]ecc.PublicKey{}
+	for _, key := range requiredKeys {
+		remaining[key.String()] = key
+	}
+
+	var signatures []ecc.Signature
+	for _, signer := range s.Signers {
+		available, err := signer.AvailableKeys()
+		if err != nil {
+			return nil, err
+		}
+
+		var keys []ecc.PublicKey
+		for _, key := range available {
+			if _, found := remaining[key.String()]; found {
+				keys = append(keys, key)
+				delete(remaining, key.String())
+			}
+		}
+		if len(keys) == 0 {
+			continue
+		}
+
+		// Each signer signs a copy without the others' signatures, some
+		// return them along with theirs.
+		unsigned := *tx
+		unsigned.Signatures = nil
+		signed, err := signer.Sign(&unsigned, chainID, keys...)
+		if err != nil {
+			return nil, fmt.Errorf("signing with %s: %s", joinKeys(keys), err)
+		}
+		signatures = append(signatures, signed.Signatures...)
+	}
+
+	if len(remaining) != 0 {
+		var missing []ecc.PublicKey
+		for _, key := range remaining {
+			missing = append(missing, key)
+		}
+		return nil, fmt.Errorf("no signer holds the required keys %s", joinKeys(missing))
+	}
+
+	tx.Signatures = append(tx.Signatures, signatures...)
+	return tx, nil
+}
+
+func joinKeys(keys []ecc.PublicKey) string {
+	var out []string
+	for _, key := range keys {
+		out = append(out, key.String())
+	}
+	return strings.Join(out, ", ")
+}
+
+// NewSigner creates a signer from its specification:
+//
+//	keys:<file>              private keys in a file, one per line
+//	keystore:<file>          the same, encrypted, see LoadKeystore
+//	wallet:<url>[#<name>]    a `keosd` wallet, or any signing service
+//	                         (hardware wallet bridge, remote signer)
+//	                         speaking its API
+//	wallet:[#<name>]         the local `keosd`, see DiscoverKeosdURL
+//	remote:<url>             an HTTP signing service, see HTTPSigner
+//	vault:<address>/<mount>/<key>
+//	                         a Vault transit key, see VaultSigner
+//	ledger:[<path>]          a Ledger device, see LedgerSigner
+func NewSigner(spec string) (eos.Signer, error) {
+	parts := strings.SplitN(spec, ":", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid signer %q, expected `keys:<file>`, `keystore:<file>`, `wallet:<url>`, `remote:<url>`, `vault:<location>` or `ledger:[<path>]`", spec)
+	}
+
+	switch parts[0] {
+	case "keys":
+		keyBag := eos.NewKeyBag()
+		if err := keyBag.ImportFromFile(parts[1]); err != nil {
+			return nil, fmt.Errorf("importing keys from %q: %s", parts[1], err)
+		}
+		return keyBag, nil
+	case "keystore":
+		return LoadKeystore(parts[1])
+	case "wallet":
+		walletURL, walletName := parts[1], "default"
+		if idx := strings.LastIndex(walletURL, "#"); idx != -1 {
+			walletURL, walletName = walletURL[:idx], walletURL[idx+1:]
+		}
+		if walletURL == "" {
+			walletURL = DiscoverKeosdURL()
+		}
+		return eos.NewWalletSigner(eos.New(walletURL), walletName), nil
+	case "remote":
+		return NewHTTPSigner(parts[1]), nil
+	case "vault":
+		return NewVaultSigner(parts[1])
+	case "ledger":
+		return NewLedgerSigner(parts[1])
+	}
+
+	return nil, fmt.Errorf("invalid signer %q, expected `keys:<file>`, `keystore:<file>`, `wallet:<url>`, `remote:<url>`, `vault:<location>` or `ledger:[<path>]`", spec)
+}
+
+// AuthorityWeight sums the weights of the keys of `auth` found in
+// `keys`.
+func AuthorityWeight(auth eos.Authority, keys []ecc.PublicKey) (weight uint32) {
+	for _, keyWeight := range auth.Keys {
+		for _, key := range keys {
+			if key.String() == keyWeight.PublicKey.String() {
+				weight += uint32(keyWeight.Weight)
+				break
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,67 @@
+package bios
+
+import (
+	"testing"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiSignerSign(t *testing.T) {
+	// A local key bag and a "device", each holding one of the keys of a
+	// two-person account.
+	local := eos.NewKeyBag()
+	assert.NoError(t, local.Add("5KQwrPbwdL6PhXujxW37FSSQZ1JiwsST4cqQzDeyXtP79zkvFD3"))
+	device := eos.NewKeyBag()
+	assert.NoError(t, device.Add("5JiSS5bccNykcSD3yZjtiKVZSKJpPgiqeZjVeZAxLv7uqTacab6"))
+	signer := NewMultiSigner(local, device)
+
+	localKey, err := ecc.NewPublicKey("EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV")
+	assert.NoError(t, err)
+	deviceKey, err := ecc.NewPublicKey("EOS5RrQmquP8JqtJLnV6Q7xoPLXStntHoBHWz1bgT4wkYaVFz7r3o")
+	assert.NoError(t, err)
+	otherKey, err := ecc.NewPublicKey("EOS6eW8Ph9ER6TmxNoWP6cpfGSWKvMhRdU9fiP2fgoYbNa4SydKzw")
+	assert.NoError(t, err)
+
+	keys, err := signer.AvailableKeys()
+	assert.NoError(t, err)
+	assert.Equal(t, "EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV, EOS5RrQmquP8JqtJLnV6Q7xoPLXStntHoBHWz1bgT4wkYaVFz7r3o", joinKeys(keys))
+
+	chainID := make([]byte, 32)
+	newTx := func() *eos.SignedTransaction {
+		return eos.NewSignedTransaction(&eos.Transaction{Actions: []*eos.Action{{Account: AN("eosio"), Name: eos.ActN("setcode")}}})
+	}
+	signedBy := func(bag *eos.KeyBag, key ecc.PublicKey) ecc.Signature {
+		signed, err := bag.Sign(newTx(), chainID, key)
+		if !assert.NoError(t, err) || !assert.Len(t, signed.Signatures, 1) {
+			return ecc.Signature{}
+		}
+		return signed.Signatures[0]
+	}
+	localSig, deviceSig := signedBy(local, localKey), signedBy(device, deviceKey)
+
+	cosigner := eos.NewKeyBag()
+	assert.NoError(t, cosigner.Add("5Kdsp7CTyPJGMSCUeU2UweC6FHuVx8G5bHPHuJzfwvfYH3FwKEw"))
+	cosignerSig := signedBy(cosigner, otherKey)
+
+	// Each signer signs with the keys it holds. Key bags return the
+	// signatures they're given along with theirs, those already on the
+	// transaction are kept once.
+	tx := newTx()
+	tx.Signatures = []ecc.Signature{cosignerSig}
+	signed, err := signer.Sign(tx, chainID, deviceKey, localKey)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []ecc.Signature{cosignerSig, localSig, deviceSig}, signed.Signatures)
+	}
+
+	signed, err = signer.Sign(newTx(), chainID, deviceKey)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []ecc.Signature{deviceSig}, signed.Signatures)
+	}
+
+	tx = newTx()
+	_, err = signer.Sign(tx, chainID, localKey, otherKey)
+	assert.EqualError(t, err, "no signer holds the required keys EOS6eW8Ph9ER6TmxNoWP6cpfGSWKvMhRdU9fiP2fgoYbNa4SydKzw")
+	assert.Len(t, tx.Signatures, 0)
+}
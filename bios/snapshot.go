@@ -2,8 +2,11 @@ package bios
 
 import (
 	"bytes"
+	"compress/gzip"
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 
 	"github.com/eoscanada/eos-go"
 	"github.com/eoscanada/eos-go/ecc"
@@ -11,6 +14,65 @@ import (
 
 type Snapshot []SnapshotLine
 
+// SnapshotFiles are the names the snapshot can have in the launch
+// data, looked for in that order. Whatever the name, the format is
+// detected from the content (see snapshotRecords).
+var SnapshotFiles = []string{"snapshot.csv", "snapshot.csv.gz", "snapshot.json"}
+
+// snapshotContentRef returns the ref of the snapshot in the launch
+// data.
+func (b *BIOS) snapshotContentRef() (string, error) {
+	for _, name := range SnapshotFiles {
+		if ref, err := b.GetContentsCacheRef(name); err == nil {
+			return ref, nil
+		}
+	}
+	return "", fmt.Errorf("none of %q found in target contents", SnapshotFiles)
+}
+
+// LoadSnapshot reads the snapshot agreed upon in the launch data, from
+// the local cache.
+func (b *BIOS) LoadSnapshot() (Snapshot, error) {
+	snapshotFile, err := b.snapshotContentRef()
+	if err != nil {
+		return nil, err
+	}
+
+	rawSnapshot, err := b.Network.ReadFromCache(snapshotFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshot file: %s", err)
+	}
+
+	snapshotData, err := NewSnapshot(rawSnapshot)
+	if err != nil {
+		return nil, fmt.Errorf("loading snapshot: %s", err)
+	}
+
+	if b.SnapshotTransform != nil {
+		snapshotData, err = b.transformSnapshot(*b.SnapshotTransform, snapshotData)
+		if err != nil {
+			return nil, fmt.Errorf("transforming snapshot: %s", err)
+		}
+	}
+
+	if len(snapshotData) == 0 {
+		return nil, fmt.Errorf("snapshot is empty or not loaded")
+	}
+
+	return snapshotData, nil
+}
+
+var wellKnownPubkey, _ = ecc.NewPublicKey("EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV")
+
+// snapshotPublicKey is the key the snapshot account will be created
+// with, taking `--hack-voting-accounts` into account.
+func (b *BIOS) snapshotPublicKey(hodler SnapshotLine) ecc.PublicKey {
+	if b.HackVotingAccounts {
+		return wellKnownPubkey
+	}
+	return hodler.EOSPublicKey
+}
+
 type SnapshotLine struct {
 	EthereumAddress string
 	EOSPublicKey    ecc.PublicKey
@@ -18,10 +80,10 @@ type SnapshotLine struct {
 	AccountName     string
 }
 
+// NewSnapshot reads `eth_address,account_name,eos_public_key,balance`
+// rows, in any of the formats of snapshotRecords.
 func NewSnapshot(content []byte) (out Snapshot, err error) {
-	reader := csv.NewReader(bytes.NewBuffer(content))
-	reader.LazyQuotes = true
-	allRecords, err := reader.ReadAll()
+	allRecords, err := snapshotRecords(content)
 	if err != nil {
 		return
 	}
@@ -47,6 +109,97 @@ func NewSnapshot(content []byte) (out Snapshot, err error) {
 	return
 }
 
+// snapshotRecords decodes the rows of a snapshot, detecting its format
+// from the content: CSV, gzip-compressed CSV, or a JSON array whose
+// rows are either arrays of the CSV columns, or objects with
+// `eth_address`, `account_name`, `eos_public_key` and `balance`.
+func snapshotRecords(content []byte) ([][]string, error) {
+	if bytes.HasPrefix(content, []byte{0x1f, 0x8b}) {
+		reader, err := gzip.NewReader(bytes.NewReader(content))
+		if err != nil {
+			return nil, fmt.Errorf("gzip: %s", err)
+		}
+		content, err = ioutil.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("gzip: %s", err)
+		}
+	}
+
+	if trimmed := bytes.TrimSpace(content); bytes.HasPrefix(trimmed, []byte("[")) {
+		return snapshotJSONRecords(trimmed)
+	}
+
+	reader := csv.NewReader(bytes.NewBuffer(content))
+	reader.LazyQuotes = true
+	reader.FieldsPerRecord = -1 // checked by the callers
+	return reader.ReadAll()
+}
+
+type snapshotJSONRow struct {
+	EthereumAddress string `json:"eth_address"`
+	AccountName     string `json:"account_name"`
+	EOSPublicKey    string `json:"eos_public_key"`
+	Balance         string `json:"balance"`
+}
+
+func snapshotJSONRecords(content []byte) (out [][]string, err error) {
+	var rows []json.RawMessage
+	if err := json.Unmarshal(content, &rows); err != nil {
+		return nil, fmt.Errorf("json: %s", err)
+	}
+
+	for idx, raw := range rows {
+		var record []string
+		if bytes.HasPrefix(raw, []byte("[")) {
+			err = json.Unmarshal(raw, &record)
+		} else {
+			var row snapshotJSONRow
+			err = json.Unmarshal(raw, &row)
+			record = []string{row.EthereumAddress, row.AccountName, row.EOSPublicKey, row.Balance}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("json row %d: %s", idx+1, err)
+		}
+		out = append(out, record)
+	}
+	return out, nil
+}
+
+// TokenSnapshot is the distribution of an additional token, to
+// accounts that exist on chain.
+type TokenSnapshot []TokenSnapshotLine
+
+type TokenSnapshotLine struct {
+	AccountName string
+	Balance     eos.Asset
+}
+
+// NewTokenSnapshot reads `account_name,balance` lines, where
+// `balance` includes the symbol, like `10.0000 COMM`.
+func NewTokenSnapshot(content []byte) (out TokenSnapshot, err error) {
+	reader := csv.NewReader(bytes.NewBuffer(content))
+	reader.LazyQuotes = true
+	allRecords, err := reader.ReadAll()
+	if err != nil {
+		return
+	}
+
+	for idx, el := range allRecords {
+		if len(el) != 2 {
+			return nil, fmt.Errorf("line %d: should have 2 elements per line", idx+1)
+		}
+
+		newAsset, err := eos.NewAsset(el[1])
+		if err != nil {
+			return out, fmt.Errorf("line %d: %s", idx+1, err)
+		}
+
+		out = append(out, TokenSnapshotLine{el[0], newAsset})
+	}
+
+	return
+}
+
 type UnregdSnapshot []UnregdSnapshotLine
 
 type UnregdSnapshotLine struct {
@@ -0,0 +1,75 @@
+package bios
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSnapshotFormats(t *testing.T) {
+	csvContent := []byte(`0x01,holder1,EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV,10.0000 EOS
+0x02,holder2,EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV,5.0000 EOS
+`)
+
+	var gzipped bytes.Buffer
+	writer := gzip.NewWriter(&gzipped)
+	_, err := writer.Write(csvContent)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+
+	jsonObjects := []byte(` [
+  {"eth_address": "0x01", "account_name": "holder1", "eos_public_key": "EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV", "balance": "10.0000 EOS"},
+  {"eth_address": "0x02", "account_name": "holder2", "eos_public_key": "EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV", "balance": "5.0000 EOS"}
+]`)
+	jsonArrays := []byte(`[
+  ["0x01", "holder1", "EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV", "10.0000 EOS"],
+  ["0x02", "holder2", "EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV", "5.0000 EOS"]
+]`)
+
+	expected, err := NewSnapshot(csvContent)
+	assert.NoError(t, err)
+	assert.Len(t, expected, 2)
+	assert.Equal(t, "holder2", expected[1].AccountName)
+
+	for name, content := range map[string][]byte{"gzip": gzipped.Bytes(), "json objects": jsonObjects, "json arrays": jsonArrays} {
+		snapshot, err := NewSnapshot(content)
+		assert.NoError(t, err, name)
+		assert.Equal(t, expected, snapshot, name)
+
+		report, err := ValidateSnapshot(content, nil)
+		assert.NoError(t, err, name)
+		assert.Equal(t, 2, report.Rows, name)
+		assert.Empty(t, report.Problems, name)
+	}
+
+	_, err = NewSnapshot([]byte(`[{"eth_address": 1}]`))
+	assert.Error(t, err)
+	_, err = NewSnapshot([]byte{0x1f, 0x8b, 0x00})
+	assert.Error(t, err)
+
+	assert.True(t, IsLaunchContentFile("snapshot.csv.gz"))
+	assert.True(t, IsLaunchContentFile("snapshot.json"))
+}
+
+func TestNewTokenSnapshot(t *testing.T) {
+	snapshot, err := NewTokenSnapshot([]byte("holder1,10.0000 COMM\nholder2,0.5000 COMM\n"))
+	assert.NoError(t, err)
+	if assert.Len(t, snapshot, 2) {
+		assert.Equal(t, "holder2", snapshot[1].AccountName)
+		assert.Equal(t, int64(5000), snapshot[1].Balance.Amount)
+		assert.Equal(t, "COMM", snapshot[1].Balance.Symbol.Symbol)
+	}
+
+	_, err = NewTokenSnapshot([]byte("holder1,10.0000 COMM,extra\n"))
+	assert.EqualError(t, err, "line 1: should have 2 elements per line")
+
+	_, err = NewTokenSnapshot([]byte("holder1,10.0000 COMM\nholder2,lots\n"))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "line 2: ")
+	}
+
+	_, err = NewTokenSnapshot([]byte("holder1,10.0000 COMM\nholder2\n"))
+	assert.Error(t, err)
+}
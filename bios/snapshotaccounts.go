@@ -0,0 +1,105 @@
+package bios
+
+import (
+	"fmt"
+	"sync"
+
+	eos "github.com/eoscanada/eos-go"
+)
+
+// snapshotLookupWorkers bounds the concurrent requests of a batch of
+// snapshot account lookups.
+const snapshotLookupWorkers = 8
+
+// snapshotAccount is the state of a snapshot account on the target
+// chain. `account` is nil when it isn't created yet.
+type snapshotAccount struct {
+	account  *eos.AccountResp
+	cpu, net eos.Asset
+	err      error
+}
+
+// lookupSnapshotAccount reads an account, with what's staked to it.
+func (b *BIOS) lookupSnapshotAccount(name eos.AccountName) *snapshotAccount {
+	resp, err := b.TargetNetAPI.GetAccount(name)
+	if err != nil {
+		// Not created yet. If the node is unreachable, the push
+		// fails and is retried anyway.
+		return &snapshotAccount{}
+	}
+
+	out := &snapshotAccount{account: resp}
+	out.cpu, out.net, out.err = b.stakedResources(name)
+	return out
+}
+
+// stakedResources reads the `userres` row of an account, in the
+// system contract.
+func (b *BIOS) stakedResources(name eos.AccountName) (cpu, net eos.Asset, err error) {
+	rowsJSON, err := b.TargetNetAPI.GetTableRows(
+		eos.GetTableRowsRequest{
+			JSON:  true,
+			Scope: string(name),
+			Code:  "eosio",
+			Table: "userres",
+			Limit: 1,
+		},
+	)
+	if err != nil {
+		return cpu, net, fmt.Errorf("get userres rows of %q: %s", name, err)
+	}
+
+	var rows []struct {
+		NetWeight eos.Asset `json:"net_weight"`
+		CPUWeight eos.Asset `json:"cpu_weight"`
+	}
+	if err := rowsJSON.JSONToStructs(&rows); err != nil {
+		return cpu, net, fmt.Errorf("reading userres rows of %q: %s", name, err)
+	}
+	if len(rows) == 0 {
+		return eos.NewEOSAsset(0), eos.NewEOSAsset(0), nil
+	}
+	return rows[0].CPUWeight, rows[0].NetWeight, nil
+}
+
+// lookupAccount returns the state of a snapshot account. With a
+// LookupBatch, the accounts following it in the snapshot are looked up
+// along, and kept for their own transactions. Each is used once, as
+// pushing transactions makes them stale.
+func (op *OpSnapshotCreateAccounts) lookupAccount(b *BIOS, name eos.AccountName) *snapshotAccount {
+	if found, ok := op.lookups[name]; ok {
+		delete(op.lookups, name)
+		return found
+	}
+
+	start, ok := op.position[name]
+	if op.LookupBatch <= 1 || !ok {
+		return b.lookupSnapshotAccount(name)
+	}
+
+	end := start + op.LookupBatch
+	if end > len(op.accounts) {
+		end = len(op.accounts)
+	}
+	batch := op.accounts[start:end]
+
+	results := make([]*snapshotAccount, len(batch))
+	workers := make(chan bool, snapshotLookupWorkers)
+	var wg sync.WaitGroup
+	for idx, account := range batch {
+		wg.Add(1)
+		workers <- true
+		go func(idx int, account eos.AccountName) {
+			defer wg.Done()
+			results[idx] = b.lookupSnapshotAccount(account)
+			<-workers
+		}(idx, account)
+	}
+	wg.Wait()
+
+	op.lookups = map[eos.AccountName]*snapshotAccount{}
+	for idx, account := range batch[1:] {
+		op.lookups[account] = results[idx+1]
+	}
+	return results[0]
+}
@@ -0,0 +1,253 @@
+package bios
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+)
+
+// SnapshotConfig is the `snapshot` section of the boot sequence.
+// TotalSupply, when set, is the sum of the snapshot balances agreed
+// upon.
+type SnapshotConfig struct {
+	TotalSupply string `json:"total_supply"`
+
+	// Split is how the balance of each snapshot account is split
+	// between liquid and staked tokens.
+	Split *StakeSplit `json:"split"`
+}
+
+// StakeSplit stakes MinStake to each of CPU and NET first, then keeps
+// up to Liquid liquid, and stakes the rest, CPUPercent of it to CPU
+// and the remainder to NET. Balances under twice MinStake get
+// nothing. Defaults are 0.2500 EOS, 10.0000 EOS and 50%.
+type StakeSplit struct {
+	MinStake   string `json:"min_stake"`
+	Liquid     string `json:"liquid"`
+	CPUPercent *int64 `json:"cpu_percent"`
+}
+
+var defaultStakeSplit = stakeSplit{minStake: 2500, liquid: 100000, cpuPercent: 50}
+
+type stakeSplit struct {
+	minStake, liquid, cpuPercent int64
+}
+
+func (c *SnapshotConfig) validate() error {
+	if _, err := c.totalSupply(); err != nil {
+		return err
+	}
+	_, err := c.stakeSplit()
+	return err
+}
+
+func (c *SnapshotConfig) stakeSplit() (stakeSplit, error) {
+	out := defaultStakeSplit
+	if c == nil || c.Split == nil {
+		return out, nil
+	}
+
+	for _, field := range []struct {
+		name  string
+		value string
+		out   *int64
+	}{
+		{"min_stake", c.Split.MinStake, &out.minStake},
+		{"liquid", c.Split.Liquid, &out.liquid},
+	} {
+		if field.value == "" {
+			continue
+		}
+		asset, err := eos.NewEOSAssetFromString(field.value)
+		if err != nil || asset.Amount < 0 {
+			return out, fmt.Errorf("snapshot: invalid split %s %q", field.name, field.value)
+		}
+		*field.out = asset.Amount
+	}
+
+	if percent := c.Split.CPUPercent; percent != nil {
+		if *percent < 0 || *percent > 100 {
+			return out, fmt.Errorf("snapshot: split cpu_percent must be between 0 and 100")
+		}
+		out.cpuPercent = *percent
+	}
+	return out, nil
+}
+
+// splitStakes returns what's staked to CPU and NET out of a snapshot
+// balance, and what's left liquid.
+func (c *SnapshotConfig) splitStakes(balance eos.Asset) (cpu, net, xfer eos.Asset) {
+	split, _ := c.stakeSplit() // checked when loading the boot sequence
+	return split.apply(balance)
+}
+
+func (s stakeSplit) apply(balance eos.Asset) (cpu, net, xfer eos.Asset) {
+	if balance.Amount < 2*s.minStake {
+		return
+	}
+
+	cpu = eos.NewEOSAsset(s.minStake)
+	net = eos.NewEOSAsset(s.minStake)
+
+	remainder := eos.NewEOSAsset(balance.Amount - 2*s.minStake)
+	if remainder.Amount <= s.liquid {
+		return cpu, net, remainder
+	}
+
+	staked := remainder.Amount - s.liquid
+	cpuShare := staked * s.cpuPercent / 100
+	cpu.Amount += cpuShare
+	net.Amount += staked - cpuShare
+
+	return cpu, net, eos.NewEOSAsset(s.liquid)
+}
+
+func (c *SnapshotConfig) totalSupply() (*eos.Asset, error) {
+	if c == nil || c.TotalSupply == "" {
+		return nil, nil
+	}
+
+	total, err := eos.NewEOSAssetFromString(c.TotalSupply)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot: invalid total_supply %q: %s", c.TotalSupply, err)
+	}
+	return &total, nil
+}
+
+// SnapshotProblem is a row of the snapshot failing validation.
+type SnapshotProblem struct {
+	Line    int
+	Account string
+	Problem string
+}
+
+// SnapshotReport is the outcome of ValidateSnapshot.
+type SnapshotReport struct {
+	Rows     int
+	Total    eos.Asset
+	Problems []*SnapshotProblem
+}
+
+func (r *SnapshotReport) problem(line int, account, format string, args ...interface{}) {
+	r.Problems = append(r.Problems, &SnapshotProblem{Line: line, Account: account, Problem: fmt.Sprintf(format, args...)})
+}
+
+// Print writes the report, one line per problem.
+func (r *SnapshotReport) Print(w io.Writer) {
+	fmt.Fprintf(w, "Snapshot: %d rows, balances totalling %s\n", r.Rows, r.Total)
+	for _, problem := range r.Problems {
+		if problem.Line == 0 {
+			fmt.Fprintf(w, "- %s\n", problem.Problem)
+		} else {
+			fmt.Fprintf(w, "- line %d (account %q): %s\n", problem.Line, problem.Account, problem.Problem)
+		}
+	}
+}
+
+// ValidateSnapshot checks all the rows of a snapshot, rather than
+// stopping at the first bad one like NewSnapshot: account names must
+// be unique, public keys valid, balances positive, and they must add
+// up to `totalSupply` when it's not nil.
+func ValidateSnapshot(content []byte, totalSupply *eos.Asset) (*SnapshotReport, error) {
+	allRecords, err := snapshotRecords(content)
+	if err != nil {
+		return nil, err
+	}
+
+	return validateSnapshotRecords(allRecords, totalSupply), nil
+}
+
+func validateSnapshotRecords(allRecords [][]string, totalSupply *eos.Asset) *SnapshotReport {
+	report := &SnapshotReport{Total: eos.NewEOSAsset(0)}
+	seen := map[string]int{}
+	for idx, el := range allRecords {
+		line := idx + 1
+		report.Rows++
+
+		if len(el) != 4 {
+			report.problem(line, "", "should have 4 elements, has %d", len(el))
+			continue
+		}
+
+		account := el[1]
+		if first, ok := seen[account]; ok {
+			report.problem(line, account, "duplicate account name, first on line %d", first)
+		} else {
+			seen[account] = line
+		}
+
+		if _, err := ecc.NewPublicKey(el[2]); err != nil {
+			report.problem(line, account, "malformed public key %q: %s", el[2], err)
+		}
+
+		balance, err := eos.NewEOSAssetFromString(el[3])
+		if err != nil {
+			report.problem(line, account, "malformed balance %q: %s", el[3], err)
+			continue
+		}
+		if balance.Amount <= 0 {
+			report.problem(line, account, "balance %s isn't positive", balance)
+		}
+		report.Total.Amount += balance.Amount
+	}
+
+	if totalSupply != nil && report.Total.Amount != totalSupply.Amount {
+		report.problem(0, "", "balances total %s, the agreed total supply is %s", report.Total, *totalSupply)
+	}
+
+	return report
+}
+
+// validateSnapshot validates the snapshot of the launch data, if any,
+// printing the report when it has problems. With a
+// `snapshot_transform`, it's the transformed snapshot, the one
+// injected, that's validated.
+func (b *BIOS) validateSnapshot() error {
+	ref, err := b.snapshotContentRef()
+	if err != nil {
+		return nil // no snapshot in this launch
+	}
+
+	content, err := b.Network.ReadFromCache(ref)
+	if err != nil {
+		return fmt.Errorf("reading snapshot file: %s", err)
+	}
+
+	totalSupply, _ := b.SnapshotConfig.totalSupply() // checked when loading the boot sequence
+	var report *SnapshotReport
+	if b.SnapshotTransform == nil {
+		report, err = ValidateSnapshot(content, totalSupply)
+		if err != nil {
+			return fmt.Errorf("reading snapshot: %s", err)
+		}
+	} else {
+		snapshot, err := NewSnapshot(content)
+		if err != nil {
+			return fmt.Errorf("reading snapshot: %s", err)
+		}
+		snapshot, err = b.transformSnapshot(*b.SnapshotTransform, snapshot)
+		if err != nil {
+			return fmt.Errorf("transforming snapshot: %s", err)
+		}
+
+		var records [][]string
+		for _, hodler := range snapshot {
+			records = append(records, []string{hodler.EthereumAddress, hodler.AccountName, hodler.EOSPublicKey.String(), hodler.Balance.String()})
+		}
+		report = validateSnapshotRecords(records, totalSupply)
+	}
+	if len(report.Problems) == 0 {
+		return nil
+	}
+
+	var out bytes.Buffer
+	report.Print(&out)
+	b.Log.Printf("%s", out.String())
+	if b.SnapshotTransform != nil {
+		return fmt.Errorf("transformed snapshot has %d problems, see above", len(report.Problems))
+	}
+	return fmt.Errorf("snapshot has %d problems, see above", len(report.Problems))
+}
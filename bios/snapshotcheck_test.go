@@ -0,0 +1,128 @@
+package bios
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/eoscanada/eos-bios/bios/disco"
+	eos "github.com/eoscanada/eos-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSnapshot(t *testing.T) {
+	snapshot := []byte(`0x01,holder1,EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV,10.0000 EOS
+0x02,holder2,EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV,5.0000 EOS
+`)
+	total := eos.NewEOSAsset(150000)
+	report, err := ValidateSnapshot(snapshot, &total)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Rows)
+	assert.Empty(t, report.Problems)
+
+	snapshot = append(snapshot, []byte(`0x03,holder1,EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV,1.0000 EOS
+0x04,holder4,not-a-key,0.0000 EOS
+0x05,holder5
+`)...)
+	report, err = ValidateSnapshot(snapshot, &total)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, report.Rows)
+
+	var problems []string
+	for _, problem := range report.Problems {
+		problems = append(problems, problem.Problem)
+	}
+	assert.Equal(t, []string{
+		"duplicate account name, first on line 1",
+		`malformed public key "not-a-key": invalid public key`,
+		"balance 0.0000 EOS isn't positive",
+		"should have 4 elements, has 2",
+		"balances total 16.0000 EOS, the agreed total supply is 15.0000 EOS",
+	}, problems)
+	assert.Equal(t, 3, report.Problems[0].Line)
+	assert.Equal(t, "holder1", report.Problems[0].Account)
+
+	buf := &bytes.Buffer{}
+	report.Print(buf)
+	assert.Contains(t, buf.String(), `- line 4 (account "holder4"): balance 0.0000 EOS isn't positive`)
+
+	// Without an agreed total supply
+	report, err = ValidateSnapshot(snapshot, nil)
+	assert.NoError(t, err)
+	assert.Len(t, report.Problems, 4)
+}
+
+func TestSnapshotConfig(t *testing.T) {
+	var none *SnapshotConfig
+	total, err := none.totalSupply()
+	assert.NoError(t, err)
+	assert.Nil(t, total)
+
+	assert.Error(t, (&SnapshotConfig{TotalSupply: "lots"}).validate())
+}
+
+func TestSnapshotStakeSplit(t *testing.T) {
+	var none *SnapshotConfig
+	cpu, net, xfer := none.splitStakes(eos.NewEOSAsset(120000))
+	assert.Equal(t, eos.NewEOSAsset(10000), cpu)
+	assert.Equal(t, eos.NewEOSAsset(10000), net)
+	assert.Equal(t, eos.NewEOSAsset(100000), xfer)
+
+	cpuPercent := int64(75)
+	config := &SnapshotConfig{Split: &StakeSplit{MinStake: "1.0000 EOS", Liquid: "2.0000", CPUPercent: &cpuPercent}}
+	assert.NoError(t, config.validate())
+
+	cpu, net, xfer = config.splitStakes(eos.NewEOSAsset(120000)) // 12 EOS
+	assert.Equal(t, eos.NewEOSAsset(70000), cpu)                 // 1 + 6
+	assert.Equal(t, eos.NewEOSAsset(30000), net)                 // 1 + 2
+	assert.Equal(t, eos.NewEOSAsset(20000), xfer)
+
+	cpu, net, xfer = config.splitStakes(eos.NewEOSAsset(30000))
+	assert.Equal(t, eos.NewEOSAsset(10000), cpu)
+	assert.Equal(t, eos.NewEOSAsset(10000), net)
+	assert.Equal(t, eos.NewEOSAsset(10000), xfer)
+
+	cpu, net, xfer = config.splitStakes(eos.NewEOSAsset(10000))
+	assert.Equal(t, int64(0), cpu.Amount+net.Amount+xfer.Amount)
+
+	// All liquid.
+	zero := int64(0)
+	config = &SnapshotConfig{Split: &StakeSplit{MinStake: "0.0000", Liquid: "1000000000.0000", CPUPercent: &zero}}
+	cpu, net, xfer = config.splitStakes(eos.NewEOSAsset(120000))
+	assert.Equal(t, int64(0), cpu.Amount+net.Amount)
+	assert.Equal(t, eos.NewEOSAsset(120000), xfer)
+
+	tooMuch := int64(101)
+	assert.Error(t, (&SnapshotConfig{Split: &StakeSplit{CPUPercent: &tooMuch}}).validate())
+	assert.Error(t, (&SnapshotConfig{Split: &StakeSplit{Liquid: "ten"}}).validate())
+}
+
+func TestValidateTransformedSnapshot(t *testing.T) {
+	b, cleanup := newScriptTestBIOS(t, `
+def transform(row):
+    if row["ethereum_address"] == "0x03":
+        return None
+    if row["account_name"] == "holder2":
+        row["account_name"] = "holder1"
+    return row
+`)
+	defer cleanup()
+
+	snapshot := `0x01,holder1,EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV,10.0000 EOS
+0x03,holder1,EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV,1.0000 EOS
+`
+	assert.NoError(t, ioutil.WriteFile(b.Network.FileNameFromCache("/ipfs/Qmsnapshot"), []byte(snapshot), 0644))
+	b.LaunchDisco.TargetContents = append(b.LaunchDisco.TargetContents, disco.ContentRef{Name: "snapshot.csv", Ref: "/ipfs/Qmsnapshot"})
+	b.SnapshotConfig = &SnapshotConfig{TotalSupply: "10.0000 EOS"}
+
+	// The duplicate is dropped by the transform.
+	assert.Error(t, b.validateSnapshot(), "raw snapshot")
+	b.SnapshotTransform = &ScriptRef{ScriptRef: "custom.star"}
+	assert.NoError(t, b.validateSnapshot())
+
+	// One is introduced by it.
+	snapshot += "0x02,holder2,EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV,5.0000 EOS\n"
+	assert.NoError(t, ioutil.WriteFile(b.Network.FileNameFromCache("/ipfs/Qmsnapshot"), []byte(snapshot), 0644))
+	b.SnapshotConfig = &SnapshotConfig{TotalSupply: "15.0000 EOS"}
+	assert.EqualError(t, b.validateSnapshot(), "transformed snapshot has 1 problems, see above")
+}
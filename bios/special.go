@@ -0,0 +1,252 @@
+package bios
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+	"github.com/eoscanada/eos-go/system"
+	"github.com/eoscanada/eos-go/token"
+)
+
+// SpecialAccountsFile is the name, in the launch data's
+// `target_contents`, of the definition of the accounts injected apart
+// from the snapshot, like `b1`.
+const SpecialAccountsFile = "special_accounts.yaml"
+
+// SpecialAccount is an account with its own balances and authorities,
+// injected by the `snapshot.special_accounts` step. Its snapshot row,
+// if any, is skipped by `snapshot.create_accounts`.
+type SpecialAccount struct {
+	Name    eos.AccountName `json:"name"`
+	Comment string          `json:"comment"`
+
+	// Pubkey is the owner and active key, unless Owner and Active
+	// authorities are given.
+	Pubkey string         `json:"pubkey"`
+	Owner  *eos.Authority `json:"owner"`
+	Active *eos.Authority `json:"active"`
+
+	CPUStake string `json:"cpu_stake"`
+	NetStake string `json:"net_stake"`
+	// Balance is transferred liquid, after the stakes.
+	Balance string `json:"balance"`
+	BuyRAM  uint64 `json:"buy_ram_bytes"`
+
+	// Vesting is enforced by the system contract, like its
+	// `validate_b1_vesting`: the vested amount must be staked.
+	Vesting *SpecialVesting `json:"vesting"`
+}
+
+// SpecialVesting is the schedule under which the staked tokens unlock,
+// from Start (RFC 3339) over Duration (like `8760h`).
+type SpecialVesting struct {
+	Amount   string `json:"amount"`
+	Start    string `json:"start"`
+	Duration string `json:"duration"`
+}
+
+// specialAccount is a validated SpecialAccount.
+type specialAccount struct {
+	name              eos.AccountName
+	owner, active     eos.Authority
+	cpu, net, balance eos.Asset
+	buyRAM            uint32
+}
+
+// NewSpecialAccounts reads and validates a special accounts
+// definition.
+func NewSpecialAccounts(content []byte) ([]*SpecialAccount, error) {
+	var definition struct {
+		SpecialAccounts []*SpecialAccount `json:"special_accounts"`
+	}
+	if err := yamlUnmarshal(content, &definition); err != nil {
+		return nil, err
+	}
+
+	seen := map[eos.AccountName]bool{}
+	for idx, account := range definition.SpecialAccounts {
+		if seen[account.Name] {
+			return nil, fmt.Errorf("special account %d: %q defined twice", idx+1, account.Name)
+		}
+		seen[account.Name] = true
+
+		if _, err := account.parse(); err != nil {
+			return nil, fmt.Errorf("special account %d (%q): %s", idx+1, account.Name, err)
+		}
+	}
+	return definition.SpecialAccounts, nil
+}
+
+func (a *SpecialAccount) parse() (out *specialAccount, err error) {
+	if len(a.Name) == 0 || len(a.Name) > 12 {
+		return nil, fmt.Errorf("invalid account name")
+	}
+	out = &specialAccount{name: a.Name, buyRAM: uint32(a.BuyRAM)}
+
+	switch {
+	case a.Owner != nil && a.Active != nil:
+		if a.Pubkey != "" {
+			return nil, fmt.Errorf("pubkey and owner/active authorities are exclusive")
+		}
+		out.owner, out.active = *a.Owner, *a.Active
+	case a.Owner != nil || a.Active != nil:
+		return nil, fmt.Errorf("both the owner and active authorities are needed")
+	default:
+		pubKey, err := ecc.NewPublicKey(a.Pubkey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pubkey %q: %s", a.Pubkey, err)
+		}
+		auth := eos.Authority{Threshold: 1, Keys: []eos.KeyWeight{{PublicKey: pubKey, Weight: 1}}}
+		out.owner, out.active = auth, auth
+	}
+
+	for _, field := range []struct {
+		name  string
+		value string
+		out   *eos.Asset
+	}{
+		{"cpu_stake", a.CPUStake, &out.cpu},
+		{"net_stake", a.NetStake, &out.net},
+		{"balance", a.Balance, &out.balance},
+	} {
+		*field.out = eos.NewEOSAsset(0)
+		if field.value == "" {
+			continue
+		}
+		*field.out, err = eos.NewEOSAssetFromString(field.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %s", field.name, field.value, err)
+		}
+		if field.out.Amount < 0 {
+			return nil, fmt.Errorf("negative %s", field.name)
+		}
+	}
+
+	if v := a.Vesting; v != nil {
+		amount, err := eos.NewEOSAssetFromString(v.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vesting amount %q: %s", v.Amount, err)
+		}
+		if staked := out.cpu.Amount + out.net.Amount; amount.Amount > staked {
+			return nil, fmt.Errorf("vesting amount %s is more than the %s staked", amount, eos.NewEOSAsset(staked))
+		}
+		if _, err := time.Parse(time.RFC3339, v.Start); err != nil {
+			return nil, fmt.Errorf("invalid vesting start %q: %s", v.Start, err)
+		}
+		if duration, err := time.ParseDuration(v.Duration); err != nil || duration <= 0 {
+			return nil, fmt.Errorf("invalid vesting duration %q", v.Duration)
+		}
+	}
+
+	return out, nil
+}
+
+// loadSpecialAccounts reads the special accounts of the launch data,
+// none when it has no such definition. With `pinnedSHA256`, the
+// definition must match it.
+func (b *BIOS) loadSpecialAccounts(pinnedSHA256 string) ([]*SpecialAccount, error) {
+	ref, err := b.GetContentsCacheRef(SpecialAccountsFile)
+	if err != nil {
+		if pinnedSHA256 != "" {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	content, err := b.Network.ReadFromCache(ref)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %s", SpecialAccountsFile, err)
+	}
+	if pinnedSHA256 != "" && !strings.EqualFold(sha2(content), pinnedSHA256) {
+		return nil, fmt.Errorf("%s has sha256 %s, pinned to %s", SpecialAccountsFile, sha2(content), pinnedSHA256)
+	}
+
+	accounts, err := NewSpecialAccounts(content)
+	if err != nil {
+		return nil, fmt.Errorf("loading %s: %s", SpecialAccountsFile, err)
+	}
+	return accounts, nil
+}
+
+// Stages of the `snapshot.special_accounts` step.
+const (
+	SpecialAccountsCreate = "create"
+	SpecialAccountsFund   = "fund"
+)
+
+// OpSpecialAccounts injects the special accounts. Short names like
+// `b1` can only be created before the system contract is set, so the
+// step can be split: one with `only: create` early in the boot
+// sequence, and one with `only: fund` once the system contract is
+// there.
+type OpSpecialAccounts struct {
+	Only string `json:"only"`
+	// DefinitionSHA256 pins the special accounts definition.
+	DefinitionSHA256 string `json:"definition_sha256"`
+}
+
+func (op *OpSpecialAccounts) ResetTestnetOptions() {}
+
+func (op *OpSpecialAccounts) Actions(b *BIOS) (out []*eos.Action, err error) {
+	switch op.Only {
+	case "", SpecialAccountsCreate, SpecialAccountsFund:
+	default:
+		return nil, fmt.Errorf("only must be %q or %q", SpecialAccountsCreate, SpecialAccountsFund)
+	}
+
+	accounts, err := b.loadSpecialAccounts(op.DefinitionSHA256)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, account := range accounts {
+		special, _ := account.parse() // validated when loading
+
+		if op.Only != SpecialAccountsFund {
+			newAccount := system.NewNewAccount(AN("eosio"), special.name, ecc.PublicKey{}) // overridden just below
+			newAccount.ActionData = eos.NewActionData(system.NewAccount{
+				Creator: AN("eosio"),
+				Name:    special.name,
+				Owner:   special.owner,
+				Active:  special.active,
+			})
+			out = append(out, newAccount, nil)
+		}
+		if op.Only == SpecialAccountsCreate {
+			continue
+		}
+
+		if special.cpu.Amount != 0 || special.net.Amount != 0 {
+			out = append(out, system.NewDelegateBW(AN("eosio"), special.name, special.cpu, special.net, true))
+		}
+		if special.buyRAM != 0 {
+			out = append(out, system.NewBuyRAMBytes(AN("eosio"), special.name, special.buyRAM))
+		}
+		if special.balance.Amount != 0 {
+			out = append(out, token.NewTransfer(AN("eosio"), special.name, special.balance, "Special account "+string(special.name)))
+		}
+		if len(out) != 0 && out[len(out)-1] != nil {
+			out = append(out, nil)
+		}
+	}
+
+	return
+}
+
+// specialAccountNames returns the names of the special accounts of
+// the launch data, if any.
+func (b *BIOS) specialAccountNames() (map[string]bool, error) {
+	accounts, err := b.loadSpecialAccounts("")
+	if err != nil {
+		return nil, err
+	}
+
+	out := map[string]bool{}
+	for _, account := range accounts {
+		out[string(account.Name)] = true
+	}
+	return out, nil
+}
@@ -0,0 +1,106 @@
+package bios
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eoscanada/eos-bios/bios/disco"
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/system"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSpecialAccounts = `special_accounts:
+- name: b1
+  pubkey: EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV
+  cpu_stake: 50.0000 EOS
+  net_stake: 50.0000 EOS
+  balance: 10.0000 EOS
+  vesting:
+    amount: 100.0000 EOS
+    start: 2018-06-01T00:00:00Z
+    duration: 87600h
+- name: recovery
+  owner:
+    threshold: 1
+    accounts:
+    - permission: {actor: eosio.prods, permission: active}
+      weight: 1
+  active:
+    threshold: 1
+    accounts:
+    - permission: {actor: eosio.prods, permission: active}
+      weight: 1
+  balance: 1.0000 EOS
+`
+
+func TestNewSpecialAccounts(t *testing.T) {
+	accounts, err := NewSpecialAccounts([]byte(testSpecialAccounts))
+	assert.NoError(t, err)
+	assert.Len(t, accounts, 2)
+
+	for _, bad := range []string{
+		"special_accounts:\n- name: b1\n  pubkey: EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV\n- name: b1\n  pubkey: EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV\n",
+		"special_accounts:\n- name: b1\n  pubkey: not-a-key\n",
+		"special_accounts:\n- name: b1\n  owner: {threshold: 1}\n",
+		"special_accounts:\n- name: b1\n  pubkey: EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV\n  cpu_stake: 1.0000 EOS\n  vesting: {amount: 2.0000 EOS, start: 2018-06-01T00:00:00Z, duration: 1h}\n",
+		"special_accounts:\n- name: b1\n  pubkey: EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV\n  cpu_stake: 2.0000 EOS\n  vesting: {amount: 2.0000 EOS, start: tomorrow, duration: 1h}\n",
+	} {
+		_, err := NewSpecialAccounts([]byte(bad))
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestSpecialAccountsActions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eos-bios-special")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, replaceAllWeirdities("/ipfs/Qmspecial")), []byte(testSpecialAccounts), 0644))
+	snapshot := "0x00000000000000000000000000000000000000b1,b1,EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV,100.0000 EOS\n0x0000000000000000000000000000000000000001,a1,EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV,10.0000 EOS\n"
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, replaceAllWeirdities("/ipfs/Qmsnapshot")), []byte(snapshot), 0644))
+
+	b := &BIOS{
+		Log:     NewLogger(),
+		Network: &Network{cachePath: dir},
+		LaunchDisco: &disco.Discovery{
+			TargetContents: []disco.ContentRef{
+				{Name: "snapshot.csv", Ref: "/ipfs/Qmsnapshot"},
+				{Name: SpecialAccountsFile, Ref: "/ipfs/Qmspecial"},
+			},
+		},
+	}
+
+	acts, err := (&OpSpecialAccounts{Only: SpecialAccountsCreate}).Actions(b)
+	assert.NoError(t, err)
+	assert.Len(t, ChunkifyActions(acts), 2)
+	newAccount := acts[2].ActionData.Data.(system.NewAccount)
+	assert.Equal(t, AN("recovery"), newAccount.Name)
+	assert.Equal(t, AN("eosio.prods"), newAccount.Owner.Accounts[0].Permission.Actor)
+
+	acts, err = (&OpSpecialAccounts{Only: SpecialAccountsFund, DefinitionSHA256: strings.ToUpper(sha2([]byte(testSpecialAccounts)))}).Actions(b)
+	assert.NoError(t, err)
+	chunks := ChunkifyActions(acts)
+	if assert.Len(t, chunks, 2) {
+		assert.Len(t, chunks[0], 2) // delegatebw and transfer
+		assert.Len(t, chunks[1], 1)
+	}
+
+	acts, err = (&OpSpecialAccounts{}).Actions(b)
+	assert.NoError(t, err)
+	assert.Len(t, ChunkifyActions(acts), 4)
+
+	_, err = (&OpSpecialAccounts{DefinitionSHA256: strings.Repeat("0", 64)}).Actions(b)
+	assert.Error(t, err)
+	_, err = (&OpSpecialAccounts{Only: "both"}).Actions(b)
+	assert.Error(t, err)
+
+	// The snapshot row of b1 is left to the special accounts.
+	op := &OpSnapshotCreateAccounts{}
+	_, err = op.Actions(b)
+	assert.NoError(t, err)
+	assert.Equal(t, []eos.AccountName{AN("a1")}, op.accounts)
+}
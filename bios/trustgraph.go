@@ -0,0 +1,97 @@
+package bios
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/ryanuber/columnize"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// trustLink is a weighted link of the discovery graph, from the peer
+// listing it in its `seed_network_peers`.
+type trustLink struct {
+	From   *Peer
+	To     *Peer
+	Weight float64
+}
+
+// trustGraph returns the peers of `network` sorted by weight, then
+// account, and its links sorted by peer.
+func trustGraph(network *simple.WeightedDirectedGraph) (peers []*Peer, links []*trustLink) {
+	if network == nil {
+		return
+	}
+
+	for _, node := range network.Nodes() {
+		peers = append(peers, node.(*Peer))
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		if peers[i].TotalWeight != peers[j].TotalWeight {
+			return peers[i].TotalWeight > peers[j].TotalWeight
+		}
+		return peers[i].AccountName() < peers[j].AccountName()
+	})
+
+	for _, edge := range network.WeightedEdges() {
+		links = append(links, &trustLink{From: edge.From().(*Peer), To: edge.To().(*Peer), Weight: edge.Weight()})
+	}
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].From.AccountName() != links[j].From.AccountName() {
+			return links[i].From.AccountName() < links[j].From.AccountName()
+		}
+		return links[i].To.AccountName() < links[j].To.AccountName()
+	})
+
+	return
+}
+
+// WriteTrustGraphDOT writes the discovery graph of `network` in
+// Graphviz's DOT language (render with `dot -Tsvg`), peers labelled
+// with their computed weight, links with theirs.
+func WriteTrustGraphDOT(w io.Writer, network *simple.WeightedDirectedGraph) {
+	peers, links := trustGraph(network)
+
+	fmt.Fprintln(w, "digraph discovery {")
+	for _, peer := range peers {
+		fmt.Fprintf(w, "  %q [label=%q];\n", peer.AccountName(), fmt.Sprintf("%s\n%d", peer.AccountName(), peer.TotalWeight))
+	}
+	for _, link := range links {
+		fmt.Fprintf(w, "  %q -> %q [label=\"%g\"];\n", link.From.AccountName(), link.To.AccountName(), link.Weight)
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// PrintTrustGraph prints each peer of `network` with its computed
+// weight, the peers pulling it in and those it pulls in, with the
+// weights of their links.
+func PrintTrustGraph(w io.Writer, network *simple.WeightedDirectedGraph) {
+	peers, links := trustGraph(network)
+
+	trustedBy := map[string][]string{}
+	trusts := map[string][]string{}
+	for _, link := range links {
+		from, to := link.From.AccountName(), link.To.AccountName()
+		trustedBy[to] = append(trustedBy[to], fmt.Sprintf("%s (%g)", from, link.Weight))
+		trusts[from] = append(trusts[from], fmt.Sprintf("%s (%g)", to, link.Weight))
+	}
+
+	columns := []string{
+		"Seed Account | Target Acct | Weight | Trusted by | Trusts",
+		"------------ | ----------- | ------ | ---------- | ------",
+	}
+	for _, peer := range peers {
+		account := peer.AccountName()
+		columns = append(columns, fmt.Sprintf("%s | %s | %d | %s | %s", account, peer.Discovery.TargetAccountName, peer.TotalWeight, orNone(trustedBy[account]), orNone(trusts[account])))
+	}
+	fmt.Fprintln(w, columnize.SimpleFormat(columns))
+}
+
+func orNone(in []string) string {
+	if len(in) == 0 {
+		return "-"
+	}
+	return strings.Join(in, ", ")
+}
@@ -0,0 +1,44 @@
+package bios
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/eoscanada/eos-bios/bios/disco"
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+func TestTrustGraph(t *testing.T) {
+	network := simple.NewWeightedDirectedGraph(0, 0)
+	peers := map[string]*Peer{}
+	for _, name := range []string{"bpone", "bptwo", "bpthree"} {
+		peers[name] = &Peer{Discovery: &disco.Discovery{SeedNetworkAccountName: AN(name), TargetAccountName: AN(name + "target")}}
+		network.AddNode(peers[name])
+	}
+	link := func(from, to string, weight uint8) {
+		network.SetWeightedEdge(&PeerEdge{FromPeer: peers[from], ToPeer: peers[to], PeerLink: &disco.PeerLink{Account: AN(to), Weight: weight}})
+	}
+	link("bpone", "bptwo", 10)
+	link("bpthree", "bptwo", 20)
+	link("bptwo", "bpone", 5)
+	peers["bptwo"].TotalWeight = 30
+	peers["bpone"].TotalWeight = 5
+
+	var dot bytes.Buffer
+	WriteTrustGraphDOT(&dot, network)
+	assert.Equal(t, `digraph discovery {
+  "bptwo" [label="bptwo\n30"];
+  "bpone" [label="bpone\n5"];
+  "bpthree" [label="bpthree\n0"];
+  "bpone" -> "bptwo" [label="10"];
+  "bpthree" -> "bptwo" [label="20"];
+  "bptwo" -> "bpone" [label="5"];
+}
+`, dot.String())
+
+	var table bytes.Buffer
+	PrintTrustGraph(&table, network)
+	assert.Contains(t, table.String(), "bptwo         bptwotarget    30      bpone (10), bpthree (20)  bpone (5)")
+	assert.Contains(t, table.String(), "bpthree       bpthreetarget  0       -                         bptwo (20)")
+}
@@ -0,0 +1,78 @@
+package bios
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// WebhookTokenEnv holds a bearer token sent to the webhooks, for them
+// to authenticate the BIOS.
+const WebhookTokenEnv = "EOS_BIOS_WEBHOOK_TOKEN"
+
+// WebhookPayload is POSTed as JSON to a hook's webhook, with the same
+// data its shell hook gets.
+type WebhookPayload struct {
+	Hook string            `json:"hook"`
+	Args []string          `json:"args"`
+	Env  map[string]string `json:"env"`
+}
+
+var webhookClient = &http.Client{Timeout: 30 * time.Second}
+
+// ParseWebhooks validates the `webhooks` of a local config, mapping
+// hook names (see HookNames and their aliases) to http(s) URLs, and
+// returns the URLs by hook name.
+func ParseWebhooks(config map[string]string) (map[string]string, error) {
+	return parseHooksConfig(config, func(value string) error {
+		u, err := url.Parse(value)
+		if err != nil {
+			return err
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%q isn't an http(s) URL", value)
+		}
+		return nil
+	})
+}
+
+// postWebhook POSTs the hook's payload to `target`, failing the hook
+// unless it answers with a 2xx status.
+func postWebhook(target, hookName string, args []string, env map[string]string) error {
+	if args == nil {
+		args = []string{}
+	}
+	body, err := json.Marshal(&WebhookPayload{Hook: hookName, Args: args, Env: env})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token := os.Getenv(WebhookTokenEnv); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		cnt, _ := ioutil.ReadAll(resp.Body)
+		if len(cnt) > 200 {
+			cnt = cnt[:200]
+		}
+		return fmt.Errorf("%s returned status %d: %q", target, resp.StatusCode, cnt)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/eoscanada/eos-bios/bios"
+	"github.com/eoscanada/eos-bios/bios/disco"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var assembleCmd = &cobra.Command{
+	Use:   "assemble [submission.yaml...]",
+	Short: "Merge the discovery files submitted by each team into the canonical list of the launch's producers",
+	Long: `Merge the discovery files submitted by each team into the canonical list of the launch's producers
+
+Each submission is one team's discovery file. They're validated, then
+sorted by seed network account name (their peer links sorted too), so
+the same submissions always give the same file, written to
+--assemble-output along with its sha256.
+
+Submissions sharing an account name, a block signing key, a Keybase
+user or a PGP fingerprint are rejected: the conflicts are printed, one
+per line, and nothing is written. Exits with code 1 then.
+`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var submissions []*disco.Discovery
+		for _, filename := range args {
+			discovery, err := bios.LoadDiscoveryFromFile(filename)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "loading %q: %s\n", filename, err)
+				os.Exit(1)
+			}
+			submissions = append(submissions, discovery)
+		}
+
+		roster, conflicts, err := bios.AssembleProducers(submissions)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "assembling: %s\n", err)
+			os.Exit(1)
+		}
+		if len(conflicts) != 0 {
+			fmt.Println("Conflicts between the submissions:")
+			bios.PrintProducerConflicts(os.Stdout, conflicts)
+			os.Exit(1)
+		}
+
+		cnt, err := roster.Marshal()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "encoding: %s\n", err)
+			os.Exit(1)
+		}
+
+		outputFile := viper.GetString("assemble-output")
+		if err := ioutil.WriteFile(outputFile, cnt, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "writing %q: %s\n", outputFile, err)
+			os.Exit(1)
+		}
+		fmt.Printf("%d producers written to %q, sha256 %x\n", len(roster.Producers), outputFile, sha256.Sum256(cnt))
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(assembleCmd)
+
+	assembleCmd.Flags().StringP("assemble-output", "", "producers.yaml", "Where to write the assembled list of producers")
+
+	for _, flag := range []string{"assemble-output"} {
+		if err := viper.BindPFlag(flag, assembleCmd.Flags().Lookup(flag)); err != nil {
+			panic(err)
+		}
+	}
+}
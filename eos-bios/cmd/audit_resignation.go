@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/eoscanada/eos-bios/bios"
+	eos "github.com/eoscanada/eos-go"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var auditResignationCmd = &cobra.Command{
+	Use:   "audit-resignation",
+	Short: "Check no single key controls eosio nor the system accounts of the launched chain",
+	Long: `Check no single key controls eosio nor the system accounts of the launched chain
+
+Reads the owner and active authorities of 'eosio' and of the system
+accounts (eosio.msig, eosio.token, eosio.ram, ... and those of
+--resignation-accounts) on the target network, following the accounts
+they delegate to, and reports any that a single key can satisfy alone.
+The null key, which no one holds, doesn't count.
+
+Any launch participant can run it. With --resignation-sign, the report
+is signed with your seed network key, and written along with the
+signature to --resignation-output, for publication.
+
+Exits with code 1 when a system account is controlled by a single key.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		targetNetHTTP := viper.GetString("target-api")
+		if targetNetHTTP == "" {
+			fmt.Fprintln(os.Stderr, "missing --target-api")
+			os.Exit(1)
+		}
+
+		var extra []eos.AccountName
+		for _, account := range viper.GetStringSlice("resignation-accounts") {
+			extra = append(extra, eos.AccountName(account))
+		}
+
+		audit, err := bios.AuditResignation(eos.New(targetNetHTTP), extra)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "auditing resignation: %s\n", err)
+			os.Exit(1)
+		}
+		audit.Print(os.Stdout)
+
+		if viper.GetBool("resignation-sign") {
+			signed, err := signResignationAudit(audit)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "signing the report: %s\n", err)
+				os.Exit(1)
+			}
+
+			cnt, _ := json.MarshalIndent(signed, "", "  ")
+			outputFile := viper.GetString("resignation-output")
+			if err := ioutil.WriteFile(outputFile, cnt, 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "writing %q: %s\n", outputFile, err)
+				os.Exit(1)
+			}
+			fmt.Printf("\nReport signed off by %s, written to %q\n", signed.Account, outputFile)
+		}
+
+		if len(audit.Problems) != 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func signResignationAudit(audit *bios.ResignationAudit) (*bios.SignedResignationAudit, error) {
+	net, err := fetchNetwork(true, false)
+	if err != nil {
+		return nil, fmt.Errorf("fetch network: %s", err)
+	}
+
+	keys, err := seedNetKeys(net)
+	if err != nil {
+		return nil, err
+	}
+
+	account := net.MyPeer.Discovery.SeedNetworkAccountName
+	activeKeys, err := net.ActivePublicKeys(account)
+	if err != nil {
+		return nil, err
+	}
+
+	return bios.SignResignationAudit(audit, account, keys, activeKeys)
+}
+
+func init() {
+	RootCmd.AddCommand(auditResignationCmd)
+
+	auditResignationCmd.Flags().StringSliceP("resignation-accounts", "", nil, "Accounts to check besides eosio and the well-known system accounts")
+	auditResignationCmd.Flags().BoolP("resignation-sign", "", false, "Sign the report with your seed network key")
+	auditResignationCmd.Flags().StringP("resignation-output", "", "resignation_audit.json", "Where to write the signed report")
+
+	for _, flag := range []string{"resignation-accounts", "resignation-sign", "resignation-output"} {
+		if err := viper.BindPFlag(flag, auditResignationCmd.Flags().Lookup(flag)); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -34,6 +34,8 @@ The "publish_kickstart_data" will also be run, giving you the opportunity to dis
 Boot is what happens when you run "eos-bios orchestrate" and you are selected to be the BIOS Boot node.
 `,
 	Run: func(cmd *cobra.Command, args []string) {
+		refuseInReadOnly("boot")
+
 		net, err := fetchNetwork(viper.GetBool("single"), viper.GetBool("download-refs"))
 		if err != nil {
 			log.Fatalln("fetch network:", err)
@@ -60,6 +62,20 @@ Boot is what happens when you run "eos-bios orchestrate" and you are selected to
 		b.SingleOnly = viper.GetBool("single")
 		b.OverrideBootSequenceFile = viper.GetString("override-bootseq")
 		b.ReuseGenesis = viper.GetBool("reuse-genesis")
+		b.ExportAccountsFile = viper.GetString("export-accounts")
+		b.KickstartChunkSize = viper.GetInt("kickstart-chunk-size")
+		b.KickstartPGP = viper.GetBool("kickstart-pgp")
+		if viper.GetBool("kickstart-ipfs-publish") {
+			if !b.KickstartPGP {
+				log.Fatalln("--kickstart-ipfs-publish publishes the encrypted kickstart payload, it needs --kickstart-pgp")
+			}
+			b.KickstartIPFSAPI = viper.GetString("ipfs-api")
+			b.KickstartPinningService = viper.GetString("kickstart-pinning-service")
+		}
+		b.CanaryTransactions = viper.GetInt("canary")
+		b.InjectionConcurrency = viper.GetInt("injection-concurrency")
+		b.InjectionMaxTPS = viper.GetFloat64("injection-max-tps")
+		b.BreakBootLease = viper.GetBool("break-lease")
 
 		if err := b.Init(); err != nil {
 			log.Fatalf("BIOS initialization error: %s", err)
@@ -78,11 +94,20 @@ func init() {
 
 	bootCmd.Flags().BoolP("single", "s", false, "Don't try to discover the world, just boot a local instance.")
 	bootCmd.Flags().BoolP("download-refs", "d", false, "Download refs from network.")
-	bootCmd.Flags().BoolP("reuse-genesis", "", false, "Re-load genesis data from genesis.json, genesis.pub and genesis.key instead of creating a new one.")
+	bootCmd.Flags().BoolP("reuse-genesis", "", false, "Re-load genesis data from genesis.json, genesis.pub and genesis.key instead of creating a new one. Steps already applied to the target chain are then verified and skipped, resuming from boot_checkpoint.json when present.")
 	bootCmd.Flags().BoolP("reset", "", false, "Remove the published genesis data from the seed_network, so that others don't accidentally join a defunc or restarted network.")
 	bootCmd.Flags().StringP("override-bootseq", "", "", "Override the boot_sequence.yaml file with a local file path (don't used the published one)")
+	bootCmd.Flags().StringP("export-accounts", "", "", "After injection, write the manifest of created accounts to this file (CSV, or JSON if the file ends with .json)")
+	bootCmd.Flags().IntP("canary", "", 2, "Number of transactions of high-volume steps (like the snapshot injection) pushed and verified on chain before the rest of the step. 0 disables canaries, except for steps setting their own canary.")
+	bootCmd.Flags().IntP("injection-concurrency", "", 1, "Number of transactions pushed at once, for steps whose transactions are independent (like snapshot.create_accounts with a batch_size). Canary transactions still go one by one.")
+	bootCmd.Flags().Float64P("injection-max-tps", "", 0, "Maximum transactions pushed per second, 0 for no limit. Pushes slow down when the node reports it's busy, and speed back up to this rate.")
+	bootCmd.Flags().BoolP("break-lease", "", false, "Boot even if the target chain holds the lease marker (the eosio.lease account) of another operator's boot. Only use when that boot is abandoned.")
+	bootCmd.Flags().IntP("kickstart-chunk-size", "", 0, "Compress the kickstart payload (genesis and p2p addresses) and split it in chunks of that many characters, written to kickstart.chunks and passed to the boot_publish_kickstart hook. Participants paste them when joining with --single.")
+	bootCmd.Flags().BoolP("kickstart-pgp", "", false, "Encrypt the kickstart payload (chain ID, genesis, p2p addresses and the ephemeral eosio private key) to the PGP keys of the appointed producers, from the keybase users of their discovery files, and write it to kickstart.pgp for distribution.")
+	bootCmd.Flags().BoolP("kickstart-ipfs-publish", "", false, "Add the encrypted kickstart payload of --kickstart-pgp to the IPFS node at --ipfs-api, and print its CID for the appointed producers to join with --kickstart-ipfs")
+	bootCmd.Flags().StringP("kickstart-pinning-service", "", "", "Also pin the kickstart payload published to IPFS with this IPFS Pinning Service API endpoint (ex: https://api.pinata.cloud/psa), its access token in $EOS_BIOS_PINNING_SERVICE_TOKEN")
 
-	for _, flag := range []string{"single", "download-refs", "override-bootseq", "reset", "reuse-genesis"} {
+	for _, flag := range []string{"single", "download-refs", "override-bootseq", "reset", "reuse-genesis", "export-accounts", "kickstart-chunk-size", "kickstart-pgp", "kickstart-ipfs-publish", "kickstart-pinning-service", "canary", "injection-concurrency", "injection-max-tps", "break-lease"} {
 		if err := viper.BindPFlag(flag, bootCmd.Flags().Lookup(flag)); err != nil {
 			panic(err)
 		}
@@ -2,10 +2,14 @@ package cmd
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/eoscanada/eos-bios/bios"
 	"github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
 	"github.com/ipfs/go-ipfs-api"
 	"github.com/spf13/viper"
 )
@@ -17,27 +21,29 @@ func fetchNetwork(single, downloadRefs bool) (*bios.Network, error) {
 		return nil, fmt.Errorf("loading %q: %s", discoFile, err)
 	}
 
-	ipfs := bios.NewIPFS(viper.GetString("ipfs"))
+	gateways := strings.Split(viper.GetString("ipfs"), ",")
+	ipfs := bios.NewIPFS(gateways[0], gateways[1:]...)
 
 	seedNetHTTP := viper.GetString("seednet-api")
 	if seedNetHTTP == "" {
 		seedNetHTTP = discovery.SeedNetworkHTTPAddress
 	}
-	if seedNetHTTP == "" && !single {
+	if seedNetHTTP == "" && !single && viper.GetString("offline-bundle") == "" {
 		return nil, fmt.Errorf("missing `seed_network_http_address` and no `--seednet-api` override provided")
 	}
 
 	seedNetAPI := eos.New(seedNetHTTP)
 
-	keyBag := eos.NewKeyBag()
-	err = keyBag.ImportFromFile(viper.GetString("seednet-keys"))
-	if err != nil {
-		fmt.Println("WARN: you might want to simply rename privkeys.keys to seed_network.keys")
-		return nil, fmt.Errorf("importing keys: %s", err)
+	if viper.GetBool("read-only") {
+		seedNetAPI.SetSigner(bios.ReadOnlySigner{})
+	} else {
+		signer, err := seedNetSigner()
+		if err != nil {
+			return nil, err
+		}
+		seedNetAPI.SetSigner(signer)
 	}
 
-	seedNetAPI.SetSigner(keyBag)
-
 	logger := bios.NewLogger()
 	logger.Debug = viper.GetBool("verbose")
 
@@ -49,6 +55,17 @@ func fetchNetwork(single, downloadRefs bool) (*bios.Network, error) {
 		seedNetAPI,
 	)
 	net.Log = logger
+	net.Mirrors = viper.GetStringSlice("mirror")
+	net.APICache.TTL = viper.GetDuration("api-cache-ttl")
+	net.APICache.Offline = viper.GetBool("offline-cache")
+
+	if bundle := viper.GetString("offline-bundle"); bundle != "" {
+		manifest, err := net.UseOfflineBundle(bundle)
+		if err != nil {
+			return nil, fmt.Errorf("offline bundle %q: %s", bundle, err)
+		}
+		logger.Printf("Using offline bundle %q, prepared at %s, with %d files verified\n", bundle, manifest.CreatedAt.Format(time.RFC3339), len(manifest.Files))
+	}
 
 	if single {
 		net.SetLocalNetwork()
@@ -67,8 +84,68 @@ func fetchNetwork(single, downloadRefs bool) (*bios.Network, error) {
 	return net, nil
 }
 
+// seedNetSigner signs for the seed network according to
+// `--signing-mode`: `keybag` signs natively with the keys of
+// `--seednet-keys`, of the encrypted `--seednet-keystore`, or the
+// `seednet-private-keys` of the `--local-config`, and
+// `wallet` with the local keosd, unless `--seednet-signer` says
+// otherwise. Either way, `--seednet-signer` adds signers.
+func seedNetSigner() (eos.Signer, error) {
+	var signers []eos.Signer
+
+	specs := viper.GetStringSlice("seednet-signer")
+	switch mode := viper.GetString("signing-mode"); mode {
+	case "keybag":
+		if keystore := viper.GetString("seednet-keystore"); keystore != "" {
+			keyBag, err := bios.LoadKeystore(keystore)
+			if err != nil {
+				return nil, fmt.Errorf("loading keystore: %s", err)
+			}
+			signers = append(signers, keyBag)
+			break
+		}
+
+		if keys := viper.GetStringSlice("seednet-private-keys"); len(keys) != 0 {
+			keyBag := eos.NewKeyBag()
+			for _, key := range keys {
+				if err := keyBag.Add(key); err != nil {
+					return nil, fmt.Errorf("invalid private key in the local config: %s", err)
+				}
+			}
+			signers = append(signers, keyBag)
+			break
+		}
+
+		keyBag := eos.NewKeyBag()
+		if err := keyBag.ImportFromFile(viper.GetString("seednet-keys")); err != nil {
+			fmt.Println("WARN: you might want to simply rename privkeys.keys to seed_network.keys, or use --signing-mode=wallet")
+			return nil, fmt.Errorf("importing keys: %s", err)
+		}
+		signers = append(signers, keyBag)
+	case "wallet":
+		if len(specs) == 0 {
+			specs = []string{"wallet:"}
+		}
+	default:
+		return nil, fmt.Errorf("invalid --signing-mode %q, expected `keybag` or `wallet`", mode)
+	}
+
+	for _, spec := range specs {
+		signer, err := bios.NewSigner(spec)
+		if err != nil {
+			return nil, err
+		}
+		signers = append(signers, signer)
+	}
+
+	if len(signers) == 1 {
+		return signers[0], nil
+	}
+	return bios.NewMultiSigner(signers...), nil
+}
+
 func ipfsClient() (*shell.IdOutput, *shell.Shell) {
-	ipfsClient := shell.NewShell(ipfsAPIAddress)
+	ipfsClient := shell.NewShell(viper.GetString("ipfs-api"))
 
 	fmt.Printf("Pinging ipfs node... ")
 	info, err := ipfsClient.ID()
@@ -82,6 +159,73 @@ func ipfsClient() (*shell.IdOutput, *shell.Shell) {
 	return info, ipfsClient
 }
 
+// seedNetKeys returns the private keys loaded for the seed network,
+// to sign things other than transactions.
+func seedNetKeys(net *bios.Network) (out []*ecc.PrivateKey, err error) {
+	signers := []eos.Signer{net.SeedNetAPI.Signer}
+	if multi, ok := net.SeedNetAPI.Signer.(*bios.MultiSigner); ok {
+		signers = multi.Signers
+	}
+
+	for _, signer := range signers {
+		if keyBag, ok := signer.(*eos.KeyBag); ok {
+			out = append(out, keyBag.Keys...)
+		}
+	}
+
+	if len(out) == 0 {
+		return nil, fmt.Errorf("seed network keys not available")
+	}
+	return out, nil
+}
+
+// regProducerConfig reads the --regproducer settings, which can be in
+// the local config.
+func regProducerConfig(net *bios.Network) (*bios.RegProducerConfig, error) {
+	config := &bios.RegProducerConfig{
+		BlockSigningPublicKey: net.MyPeer.Discovery.TargetAppointedBlockProducerSigningKey,
+		URL:                   viper.GetString("producer-url"),
+		Location:              uint16(viper.GetInt("producer-location")),
+		Timeout:               viper.GetDuration("regproducer-timeout"),
+	}
+
+	if key := viper.GetString("block-signing-public-key"); key != "" {
+		pubKey, err := ecc.NewPublicKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid block-signing-public-key: %s", err)
+		}
+		config.BlockSigningPublicKey = pubKey
+	}
+
+	var signers []eos.Signer
+	for _, spec := range viper.GetStringSlice("regproducer-signer") {
+		signer, err := bios.NewSigner(spec)
+		if err != nil {
+			return nil, err
+		}
+		signers = append(signers, signer)
+	}
+	switch len(signers) {
+	case 0:
+		return nil, fmt.Errorf("missing --regproducer-signer, for the active permission of your target account")
+	case 1:
+		config.Signer = signers[0]
+	default:
+		config.Signer = bios.NewMultiSigner(signers...)
+	}
+
+	return config, nil
+}
+
+// refuseInReadOnly stops commands that only exist to sign or
+// broadcast something.
+func refuseInReadOnly(command string) {
+	if viper.GetBool("read-only") {
+		fmt.Fprintf(os.Stderr, "%q is not available in --read-only mode\n", command)
+		os.Exit(1)
+	}
+}
+
 func setupBIOS(net *bios.Network) (b *bios.BIOS, err error) {
 	targetNetHTTP := viper.GetString("target-api")
 	if targetNetHTTP == "" {
@@ -92,14 +236,96 @@ func setupBIOS(net *bios.Network) (b *bios.BIOS, err error) {
 	}
 
 	targetNetAPI := eos.New(targetNetHTTP)
-	targetNetAPI.SetSigner(eos.NewKeyBag())
+	if viper.GetBool("read-only") {
+		targetNetAPI.SetSigner(bios.ReadOnlySigner{})
+	} else {
+		targetNetAPI.SetSigner(eos.NewKeyBag())
+	}
 
 	if viper.GetBool("fast-inject") {
 		targetNetAPI.EnableKeepAlives()
 	}
 
 	b = bios.NewBIOS(net.Log, net, targetNetAPI)
+	b.ReadOnly = viper.GetBool("read-only")
 	b.WriteActions = viper.GetBool("write-actions")
 	b.HackVotingAccounts = viper.GetBool("hack-voting-accounts")
+	b.ReportFile = viper.GetString("report")
+	b.ReportTransactionURL = viper.GetString("report-tx-url")
+	b.DNSSeeds = viper.GetStringSlice("dns-seed")
+	b.BitcoindRPC = viper.GetString("bitcoind-rpc")
+	b.BitcoinPollInterval = viper.GetDuration("btc-poll-interval")
+	b.PrintSeed = viper.GetBool("print-seed")
+	b.KickstartIPFS = viper.GetString("kickstart-ipfs")
+	b.KickstartFile = viper.GetString("kickstart-file")
+	b.BootSequenceSHA256 = viper.GetString("bootseq-sha256")
+	b.BootSequenceSignatureQuorum = viper.GetString("bootseq-signatures")
+	b.TargetReadyTimeout = viper.GetDuration("target-ready-timeout")
+
+	if viper.GetBool("regproducer") {
+		if b.RegProducer, err = regProducerConfig(net); err != nil {
+			return nil, fmt.Errorf("regproducer: %s", err)
+		}
+	}
+
+	if b.Hooks, err = bios.ParseHooks(viper.GetStringMapString("hooks")); err != nil {
+		return nil, fmt.Errorf("local config: %s", err)
+	}
+	if b.Webhooks, err = bios.ParseWebhooks(viper.GetStringMapString("webhooks")); err != nil {
+		return nil, fmt.Errorf("local config: %s", err)
+	}
+
+	if keyFile := viper.GetString("pgp-secret-key"); keyFile != "" {
+		b.KickstartKeyring, err = ioutil.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading PGP secret key: %s", err)
+		}
+		b.KickstartPassphrase = func() (string, error) {
+			return promptPassphrase(fmt.Sprintf("Passphrase of the PGP secret key %s: ", keyFile), bios.PGPPassphraseEnv)
+		}
+	}
+
+	if b.ArchiveFile = viper.GetString("archive"); b.ArchiveFile != "" && !b.ReadOnly {
+		// Without keys (like with a wallet only), the archive is
+		// written unsigned.
+		b.ArchiveKeys, _ = seedNetKeys(net)
+	}
+
+	if !b.ReadOnly {
+		// Without keys (like with a wallet only), heartbeats aren't
+		// sent and the shuffle audit is written unsigned.
+		b.SeedNetKeys, _ = seedNetKeys(net)
+	}
+
+	if target := viper.GetString("firehose"); target != "" {
+		b.Firehose, err = bios.NewFirehose(target)
+		if err != nil {
+			return nil, fmt.Errorf("setting up firehose: %s", err)
+		}
+	}
+
+	if filename := viper.GetString("audit-log"); filename != "" {
+		b.AuditLog, err = bios.OpenAuditLog(filename)
+		if err != nil {
+			return nil, fmt.Errorf("opening audit log: %s", err)
+		}
+	}
+
+	for _, spec := range viper.GetStringSlice("artifact-store") {
+		store, err := bios.NewArtifactStore(spec)
+		if err != nil {
+			return nil, err
+		}
+		b.ArtifactStores = append(b.ArtifactStores, store)
+	}
+
+	if addr := viper.GetString("health-addr"); addr != "" {
+		go b.ServeHealth(addr)
+	}
+
+	if addr := viper.GetString("dashboard-addr"); addr != "" {
+		go b.ServeDashboard(addr)
+	}
+
 	return b, nil
 }
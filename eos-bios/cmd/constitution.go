@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/eoscanada/eos-bios/bios"
+	"github.com/ryanuber/columnize"
+	"github.com/spf13/cobra"
+)
+
+var constitutionAcksCmd = &cobra.Command{
+	Use:   "constitution-acks",
+	Short: "Show which launch participants acknowledged the constitution agreed upon in the launch data",
+	Long: `Show which launch participants acknowledged the constitution agreed upon in the launch data
+
+Participants acknowledge the constitution with:
+
+    eos-bios publish --ack-constitution constitution.md
+
+which signs its sha256 with their seed network key, and publishes the
+signature along with their discovery file.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		net, err := fetchNetwork(false, true)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fetch network: %s\n", err)
+			os.Exit(1)
+		}
+
+		constitution, err := net.ConsensusConstitution()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+		hash := bios.ConstitutionHash(constitution)
+
+		fmt.Printf("Constitution sha256: %x\n\n", hash)
+
+		acks := net.ConstitutionAcks(net.OrderedPeers(net.MyNetwork()), hash)
+
+		columns := []string{
+			"Seed Account | Acknowledged | Public Key | Error",
+			"------------ | ------------ | ---------- | -----",
+		}
+		count := 0
+		for _, ack := range acks {
+			status := "no"
+			if ack.Valid {
+				status = "yes"
+				count++
+			}
+			columns = append(columns, fmt.Sprintf("%s | %s | %s | %s", ack.Account, status, ack.PublicKey, ack.Error))
+		}
+		fmt.Println(columnize.SimpleFormat(columns))
+		fmt.Println("")
+		fmt.Printf("%d of %d participants acknowledged the constitution.\n", count, len(acks))
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(constitutionAcksCmd)
+}
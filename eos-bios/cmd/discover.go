@@ -14,7 +14,9 @@
 package cmd
 
 import (
+	"fmt"
 	"log"
+	"os"
 
 	"github.com/eoscanada/eos-bios/bios"
 	"github.com/spf13/cobra"
@@ -35,7 +37,24 @@ var discoverCmd = &cobra.Command{
 			net.CalculateNetworkWeights(elect)
 		}
 
-		net.PrintOrderedPeers(nil)
+		net.PrintOrderedPeers(nil, bios.DefaultAppointedProducers)
+
+		if viper.GetBool("graph") {
+			fmt.Println("Trust graph of your network, with the weight of each link:")
+			bios.PrintTrustGraph(os.Stdout, net.MyNetwork())
+		}
+
+		if dotFile := viper.GetString("graph-dot"); dotFile != "" {
+			out := os.Stdout
+			if dotFile != "-" {
+				out, err = os.Create(dotFile)
+				if err != nil {
+					log.Fatalln("creating DOT file:", err)
+				}
+				defer out.Close()
+			}
+			bios.WriteTrustGraphDOT(out, net.MyNetwork())
+		}
 
 		if viper.GetBool("serve") {
 			bios.Serve(net)
@@ -46,8 +65,12 @@ var discoverCmd = &cobra.Command{
 func init() {
 	RootCmd.AddCommand(discoverCmd)
 	discoverCmd.Flags().BoolP("serve", "", false, "Serve the discovery visualization on http://localhost:10101")
+	discoverCmd.Flags().BoolP("graph", "", false, "Print the trust graph: each peer's computed weight, who pulls it in and whom it pulls in, with the weight of their links")
+	discoverCmd.Flags().StringP("graph-dot", "", "", "Write the trust graph in Graphviz DOT to this file (- for stdout), render it with `dot -Tsvg`")
 
-	if err := viper.BindPFlag("serve", discoverCmd.Flags().Lookup("serve")); err != nil {
-		panic(err)
+	for _, flag := range []string{"serve", "graph", "graph-dot"} {
+		if err := viper.BindPFlag(flag, discoverCmd.Flags().Lookup(flag)); err != nil {
+			panic(err)
+		}
 	}
 }
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/eoscanada/eos-bios/bios"
+	"github.com/spf13/cobra"
+)
+
+var dnsRecordsCmd = &cobra.Command{
+	Use:   "dns-records [domain]",
+	Short: "Print the DNS TXT records publishing the p2p endpoints of your network, for use as a DNS seed",
+	Long: `Print the DNS TXT records publishing the p2p endpoints of your network, for use as a DNS seed
+
+Add the records to the zone of [domain], and have participants use it
+with '--dns-seed [domain]', or list it under 'dns_seeds' in the boot
+sequence. Endpoints not tied to a participant can be added as SRV
+records under '_eos-p2p._tcp.[domain]'.
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		net, err := fetchNetwork(false, false)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fetch network: %s\n", err)
+			os.Exit(1)
+		}
+
+		for _, record := range bios.DNSSeedZone(args[0], net.OrderedPeers(net.MyNetwork())) {
+			fmt.Println(record)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(dnsRecordsCmd)
+}
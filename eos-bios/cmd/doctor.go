@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/eoscanada/eos-bios/bios"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose your local environment, and print how to fix what's wrong",
+	Long: `Diagnose your local environment, and print how to fix what's wrong
+
+Checks your nodeos (--target-api) and keosd (KEOSD_URL, or found from
+its config.ini) are reachable, the ports your node listens on, the disk
+space for the chain data, the versions of nodeos, keosd, cleos, docker,
+gpg and keybase, your proxy settings and the DNS resolution of the seed
+network and IPFS gateways.
+
+Addresses are taken from your discovery file when it's there. Unlike
+'preflight', no launch data is needed, so run it early and often.
+Exits with code 1 when a check fails.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		config := &bios.DoctorConfig{
+			TargetAPI:   viper.GetString("target-api"),
+			KeosdURL:    bios.DiscoverKeosdURL(),
+			DataDir:     viper.GetString("doctor-data-dir"),
+			MinFreeDisk: uint64(viper.GetFloat64("doctor-min-disk") * 1e9),
+			Listen:      viper.GetStringSlice("doctor-listen"),
+		}
+
+		seedNetAPI := viper.GetString("seednet-api")
+		if discovery, err := bios.LoadDiscoveryFromFile(viper.GetString("my-discovery")); err == nil {
+			if config.TargetAPI == "" {
+				config.TargetAPI = discovery.TargetHTTPAddress
+			}
+			if seedNetAPI == "" {
+				seedNetAPI = discovery.SeedNetworkHTTPAddress
+			}
+			if len(config.Listen) == 0 {
+				config.Listen = doctorListenAddresses(discovery.TargetHTTPAddress, discovery.TargetP2PAddress)
+			}
+		} else {
+			fmt.Printf("No discovery file loaded (%s), checking with flags only\n\n", err)
+		}
+
+		if seedNetAPI != "" {
+			config.Endpoints = append(config.Endpoints, seedNetAPI)
+		}
+		for _, gateway := range strings.Split(viper.GetString("ipfs"), ",") {
+			if gateway != "" {
+				config.Endpoints = append(config.Endpoints, gateway)
+			}
+		}
+
+		checks := bios.Doctor(config)
+		bios.PrintDoctor(os.Stdout, checks)
+
+		if !bios.DoctorPassed(checks) {
+			os.Exit(1)
+		}
+	},
+}
+
+// doctorListenAddresses are the ports of your node, from the addresses
+// published in your discovery file.
+func doctorListenAddresses(httpAddress, p2pAddress string) (out []string) {
+	if u, err := url.Parse(httpAddress); err == nil && u.Port() != "" {
+		out = append(out, net.JoinHostPort("", u.Port()))
+	}
+	if _, port, err := net.SplitHostPort(p2pAddress); err == nil {
+		out = append(out, net.JoinHostPort("", port))
+	}
+	return
+}
+
+func init() {
+	RootCmd.AddCommand(doctorCmd)
+
+	doctorCmd.Flags().StringP("doctor-data-dir", "", ".", "Directory holding your chain data, to check its free space")
+	doctorCmd.Flags().Float64P("doctor-min-disk", "", 50, "Free space needed for the chain data, in GB")
+	doctorCmd.Flags().StringSliceP("doctor-listen", "", nil, "host:port your node listens on, defaults to the ports of your discovery file's target_http_address and target_p2p_address (can be repeated)")
+
+	for _, flag := range []string{"doctor-data-dir", "doctor-min-disk", "doctor-listen"} {
+		if err := viper.BindPFlag(flag, doctorCmd.Flags().Lookup(flag)); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/eoscanada/eos-bios/bios"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// hashCmd computes the hashes pinned in the launch data, with the
+// algorithm eos-bios checks them with.
+var hashCmd = &cobra.Command{
+	Use:   "hash",
+	Short: "Compute the contract and snapshot hashes pinned in the launch data",
+	Long: `Compute the contract and snapshot hashes pinned in the launch data
+
+Uses the exact algorithm eos-bios checks them with, so the authors of
+the launch data and every producer verifying it get the same hashes:
+the sha256 of a file, in the 'sha256:<hex>' of its target_contents
+comment, is checked when it's downloaded, and the contract hash, in
+the 'contract:<hex>' of the comment of a .wasm, when the contract is
+set. See 'launch hash' to hash a whole directory of launch files at
+once.
+`,
+}
+
+var hashContractsCmd = &cobra.Command{
+	Use:   "contracts",
+	Short: "Print the contract hash of --code and --abi: the sha256 of the concatenated sha256 of both files",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		wasmFile, abiFile := viper.GetString("code"), viper.GetString("abi")
+		if wasmFile == "" || abiFile == "" {
+			fmt.Fprintln(os.Stderr, "--code and --abi are required")
+			os.Exit(1)
+		}
+
+		hash, err := bios.HashCodeFiles(wasmFile, abiFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "hashing contract: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(hash)
+	},
+}
+
+var hashSnapshotCmd = &cobra.Command{
+	Use:   "snapshot [file.csv...]",
+	Short: "Print the sha256 of snapshot files, as written in the `sha256:<hex>` of their `target_contents` comment",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, filename := range args {
+			hash, err := bios.HashFile(filename)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "hashing %q: %s\n", filename, err)
+				os.Exit(1)
+			}
+
+			if len(args) == 1 {
+				fmt.Println(hash)
+			} else {
+				fmt.Printf("%s  %s\n", hash, filename)
+			}
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(hashCmd)
+	hashCmd.AddCommand(hashContractsCmd)
+	hashCmd.AddCommand(hashSnapshotCmd)
+
+	hashContractsCmd.Flags().StringP("code", "", "", "Contract code file (.wasm)")
+	hashContractsCmd.Flags().StringP("abi", "", "", "Contract ABI file (.abi)")
+
+	for _, flag := range []string{"code", "abi"} {
+		if err := viper.BindPFlag(flag, hashContractsCmd.Flags().Lookup(flag)); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/eoscanada/eos-bios/bios"
+	eos "github.com/eoscanada/eos-go"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var heartbeatServerCmd = &cobra.Command{
+	Use:   "heartbeat-server",
+	Short: "Run the coordination endpoint participants publish their readiness heartbeats to",
+	Long: `Run the coordination endpoint participants publish their readiness heartbeats to
+
+Serves 'POST /heartbeats', where the eos-bios of each participant
+publishes its signed heartbeat ("waiting", then "ready" once its node
+is prepared for its role) while waiting for the launch, and
+'GET /heartbeats', listing the latest of each. Point the boot
+sequence's 'heartbeat' section 'url' to it. It also relays the
+exclusion of unreachable producers the 'probe' section's publisher
+signs, on '/exclusion'.
+
+Heartbeats are kept in memory. Those sent in the future are refused.
+With --seednet-api, so are those not signed by a key of their
+account's active permission. The boot node checks their signatures
+against the seed network all the same.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var seedNetAPI *eos.API
+		if seedNetHTTP := viper.GetString("seednet-api"); seedNetHTTP != "" {
+			seedNetAPI = eos.New(seedNetHTTP)
+		} else {
+			fmt.Println("WARNING: without --seednet-api, heartbeat signatures aren't verified")
+		}
+
+		addr := viper.GetString("heartbeat-addr")
+		fmt.Printf("Serving heartbeats on http://%s/heartbeats\n", addr)
+		if err := http.ListenAndServe(addr, bios.NewHeartbeatServer(seedNetAPI)); err != nil {
+			fmt.Fprintf(os.Stderr, "heartbeat server: %s\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(heartbeatServerCmd)
+
+	heartbeatServerCmd.Flags().StringP("heartbeat-addr", "", "0.0.0.0:10102", "Address to serve heartbeats on")
+
+	for _, flag := range []string{"heartbeat-addr"} {
+		if err := viper.BindPFlag(flag, heartbeatServerCmd.Flags().Lookup(flag)); err != nil {
+			panic(err)
+		}
+	}
+}
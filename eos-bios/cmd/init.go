@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eoscanada/eos-bios/bios"
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init [directory]",
+	Short: "Write a starter discovery file and local config for your team",
+	Long: `Write a starter discovery file and local config for your team
+
+Writes my_discovery_file.yaml and local_config.yaml, with comments
+explaining each field, to the directory (the current one by default).
+Values not given with the --init-* flags are prompted for, when
+running in a terminal.
+
+Without --init-signing-key or --init-authority-key, a key pair is
+generated for each, and the private keys written to target.keys:
+move them to your node's config and to a keystore or a hardware
+wallet, and delete that file.
+
+Existing files are left alone, unless --init-force.
+`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := "."
+		if len(args) == 1 {
+			dir = args[0]
+		}
+
+		discoveryFile := filepath.Join(dir, "my_discovery_file.yaml")
+		localConfigFile := filepath.Join(dir, "local_config.yaml")
+		keysFile := filepath.Join(dir, "target.keys")
+		if !viper.GetBool("init-force") {
+			for _, filename := range []string{discoveryFile, localConfigFile, keysFile} {
+				if _, err := os.Stat(filename); err == nil {
+					fmt.Fprintf(os.Stderr, "%q exists, use --init-force to overwrite it\n", filename)
+					os.Exit(1)
+				}
+			}
+		}
+
+		prompt := newInitPrompter()
+		scaffold := &bios.Scaffold{
+			SeedNetworkAccountName: eos.AccountName(prompt.value("init-seed-account", "Your account on the seed network", "")),
+			SeedNetworkHTTPAddress: prompt.value("init-seed-api", "Seed network HTTP address", "http://localhost:8888"),
+			TargetAccountName:      eos.AccountName(prompt.value("init-target-account", "Your account on the network to launch (12 characters)", "")),
+			TargetP2PAddress:       prompt.value("init-p2p-address", "Your node's p2p address (host:port, or none)", "localhost:9876"),
+			TargetHTTPAddress:      prompt.value("init-http-address", "Your node's HTTP address", "http://localhost:8888"),
+			Website:                prompt.value("init-website", "Your website, serving /bp.json", ""),
+			KeybaseUser:            prompt.value("init-keybase", "Your Keybase user", ""),
+			GMTOffset:              int16(viper.GetInt("init-gmt-offset")),
+			SigningKey:             viper.GetString("init-signing-key"),
+			AuthorityKey:           viper.GetString("init-authority-key"),
+		}
+
+		var generated []string
+		for _, key := range []*string{&scaffold.SigningKey, &scaffold.AuthorityKey} {
+			if *key != "" {
+				continue
+			}
+			privKey, err := ecc.NewRandomPrivateKey()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "generating key: %s\n", err)
+				os.Exit(1)
+			}
+			*key = privKey.PublicKey().String()
+			generated = append(generated, fmt.Sprintf("%s  // %s", privKey, *key))
+		}
+
+		discovery, err := scaffold.DiscoveryFile()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		localConfig, err := scaffold.LocalConfigFile()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		if len(generated) != 0 {
+			writeInitFile(keysFile, []byte(strings.Join(generated, "\n")+"\n"), 0600)
+		}
+		writeInitFile(discoveryFile, discovery, 0644)
+		writeInitFile(localConfigFile, localConfig, 0644)
+
+		if err := bios.ValidateDiscoveryFile(discoveryFile); err != nil {
+			fmt.Fprintf(os.Stderr, "\nWARN: %s needs fixing: %s\n", discoveryFile, err)
+		}
+		fmt.Println("\nAdd your votes and the launch data to your discovery file, then run 'eos-bios publish'.")
+	},
+}
+
+// initPrompter reads the values not set with flags from the terminal.
+type initPrompter struct {
+	reader      *bufio.Reader
+	interactive bool
+}
+
+func newInitPrompter() *initPrompter {
+	return &initPrompter{
+		reader:      bufio.NewReader(os.Stdin),
+		interactive: terminal.IsTerminal(int(os.Stdin.Fd())),
+	}
+}
+
+func (p *initPrompter) value(flag, question, defaultValue string) string {
+	if value := viper.GetString(flag); value != "" || !p.interactive {
+		if value == "" {
+			return defaultValue
+		}
+		return value
+	}
+
+	if defaultValue != "" {
+		fmt.Printf("%s [%s]: ", question, defaultValue)
+	} else {
+		fmt.Printf("%s: ", question)
+	}
+	answer, _ := p.reader.ReadString('\n')
+	if answer = strings.TrimSpace(answer); answer != "" {
+		return answer
+	}
+	return defaultValue
+}
+
+func writeInitFile(filename string, content []byte, perm os.FileMode) {
+	if err := ioutil.WriteFile(filename, content, perm); err != nil {
+		fmt.Fprintf(os.Stderr, "writing %q: %s\n", filename, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Wrote %s\n", filename)
+}
+
+func init() {
+	RootCmd.AddCommand(initCmd)
+
+	initCmd.Flags().StringP("init-seed-account", "", "", "Your account on the seed network")
+	initCmd.Flags().StringP("init-seed-api", "", "", "HTTP address of the seed network")
+	initCmd.Flags().StringP("init-target-account", "", "", "Your account on the network to launch")
+	initCmd.Flags().StringP("init-p2p-address", "", "", "p2p address (host:port) of your node on the network to launch, or none")
+	initCmd.Flags().StringP("init-http-address", "", "", "HTTP address of your node on the network to launch")
+	initCmd.Flags().StringP("init-website", "", "", "Your website, serving /bp.json")
+	initCmd.Flags().StringP("init-keybase", "", "", "Your Keybase user, whose PGP key is used to encrypt data to you")
+	initCmd.Flags().IntP("init-gmt-offset", "", 0, "Your time zone, in HourMinutes format (ex: -500)")
+	initCmd.Flags().StringP("init-signing-key", "", "", "Your block signing public key, generated when left out")
+	initCmd.Flags().StringP("init-authority-key", "", "", "Public key of your target account's owner and active permissions, generated when left out")
+	initCmd.Flags().BoolP("init-force", "", false, "Overwrite existing files")
+
+	for _, flag := range []string{"init-seed-account", "init-seed-api", "init-target-account", "init-p2p-address", "init-http-address", "init-website", "init-keybase", "init-gmt-offset", "init-signing-key", "init-authority-key", "init-force"} {
+		if err := viper.BindPFlag(flag, initCmd.Flags().Lookup(flag)); err != nil {
+			panic(err)
+		}
+	}
+}
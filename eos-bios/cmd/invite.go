@@ -31,6 +31,8 @@ var inviteCmd = &cobra.Command{
 	Short: "Invite a fellow block producer to the seed network where you have access to",
 	Args:  cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
+		refuseInReadOnly("invite")
+
 		net, err := fetchNetwork(true, false)
 		if err != nil {
 			log.Fatalln("fetch network:", err)
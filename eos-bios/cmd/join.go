@@ -24,7 +24,14 @@ import (
 var joinCmd = &cobra.Command{
 	Use:   "join",
 	Short: "Triggers the hooks to join an already running network",
-	Long:  `This will run the "join_network" hook with data discovered from the network pointed to by the seed_discovery_url.`,
+	Long: `This will run the "join_network" hook with data discovered from the network pointed to by the seed_discovery_url.
+
+Appointed producers handed an encrypted kickstart payload by the boot
+node (see 'boot --kickstart-pgp') follow with it instead of waiting for
+the genesis: pass --kickstart-file kickstart.pgp, or --kickstart-ipfs
+with its CID. It's decrypted with your gpg, or the key of
+--pgp-secret-key, then the node joins the boot node and its peers, and
+validates the boot sequence with --validate.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if !viper.GetBool("mainnet") {
 			net, err := fetchNetwork(false, true)
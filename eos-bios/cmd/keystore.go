@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/eoscanada/eos-bios/bios"
+	"github.com/spf13/cobra"
+)
+
+var keystoreCmd = &cobra.Command{
+	Use:   "keystore",
+	Short: "Manage encrypted keystores, for --seednet-keystore",
+}
+
+var keystoreEncryptCmd = &cobra.Command{
+	Use:   "encrypt [keys file] [keystore file]",
+	Short: "Encrypt a keys file into a keystore",
+	Long: `Encrypt a keys file into a keystore
+
+The keys file holds private keys, one per line, like seed_network.keys.
+The passphrase is taken from $EOS_BIOS_KEYSTORE_PASSPHRASE, the same
+variable used to decrypt the keystore with --seednet-keystore. Delete
+the plain keys file once you've checked the keystore works.
+`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		passphrase := os.Getenv(bios.KeystorePassphraseEnv)
+		if passphrase == "" {
+			fmt.Fprintf(os.Stderr, "set %s to the passphrase to encrypt with\n", bios.KeystorePassphraseEnv)
+			os.Exit(1)
+		}
+
+		keys, err := ioutil.ReadFile(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "reading keys: %s\n", err)
+			os.Exit(1)
+		}
+
+		keystore, err := bios.EncryptKeystore(keys, passphrase)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "encrypting keys: %s\n", err)
+			os.Exit(1)
+		}
+
+		if err := ioutil.WriteFile(args[1], keystore, 0600); err != nil {
+			fmt.Fprintf(os.Stderr, "writing keystore: %s\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Wrote keystore %q, use it with --seednet-keystore %s\n", args[1], args[1])
+	},
+}
+
+func init() {
+	keystoreCmd.AddCommand(keystoreEncryptCmd)
+	RootCmd.AddCommand(keystoreCmd)
+}
@@ -0,0 +1,276 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eoscanada/eos-bios/bios"
+	shell "github.com/ipfs/go-ipfs-api"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// launchCmd groups the tools used to assemble the launch data
+var launchCmd = &cobra.Command{
+	Use:   "launch",
+	Short: "Tools to assemble the launch data referenced by discovery files",
+}
+
+var launchHashCmd = &cobra.Command{
+	Use:   "hash [directory]",
+	Short: "Compute the hashes of local launch files and print the corresponding `target_contents` section",
+	Long: `Hashes the boot sequence, snapshots and contract files found in the directory (the current one by default).
+
+Each file gets its sha256, and contracts (a .wasm with a matching .abi) also get their contract hash: the sha256 of the concatenated sha256 of both files.
+
+Once pasted in the target_contents of your discovery file, eos-bios checks the sha256 of each file when it is downloaded, and in 'preflight', and the contract hash in 'preflight' and before setting the contract's code.
+
+With --ipfs-add, files are also added to the IPFS node at --ipfs-api so the printed section contains the actual refs to paste in your discovery file.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := "."
+		if len(args) == 1 {
+			dir = args[0]
+		}
+
+		contents, err := bios.LaunchContentHashes(dir)
+		if err != nil {
+			log.Fatalln("hashing launch files:", err)
+		}
+
+		if len(contents) == 0 {
+			fmt.Printf("No launch files found in %q\n", dir)
+			os.Exit(1)
+		}
+
+		refs := map[string]string{}
+		if viper.GetBool("ipfs-add") {
+			_, ipfs := ipfsClient()
+			for _, content := range contents {
+				fl, err := os.Open(content.Path)
+				if err != nil {
+					log.Fatalln("opening file:", err)
+				}
+
+				hash, err := ipfs.Add(fl)
+				fl.Close()
+				if err != nil {
+					log.Fatalf("adding %q to ipfs: %s", content.Path, err)
+				}
+				refs[content.Name] = "/ipfs/" + hash
+			}
+		}
+
+		fmt.Println("target_contents:")
+		for _, content := range contents {
+			ref := refs[content.Name]
+			if ref == "" {
+				ref = "/ipfs/FILL_ME"
+			}
+
+			comment := "sha256:" + content.SHA256
+			if content.ContractHash != "" {
+				comment += " contract:" + content.ContractHash
+			}
+
+			fmt.Printf("  - name: %s\n", content.Name)
+			fmt.Printf("    ref: %s\n", ref)
+			fmt.Printf("    comment: %q\n", comment)
+		}
+	},
+}
+
+var launchFreezeSnapshotCmd = &cobra.Command{
+	Use:   "freeze-snapshot",
+	Short: "Wait for the ERC-20 freeze block, and produce the canonical snapshot at that height",
+	Long: `Wait for the ERC-20 freeze block, and produce the canonical snapshot at that height
+
+Waits for --freeze-block to be buried under --eth-confirmations on the
+Ethereum node at --eth-rpc, then reads the registrations (LogRegister)
+and the token holders (Transfer) up to that block, and their balances
+at that exact height.
+
+Writes snapshot.csv (registered holders), snapshot_unregistered.csv
+(holders without a valid EOS key) and freeze.json (freeze block hash,
+counts and sha256 of both files) in --freeze-output. Account names are
+derived from the sha256 of the lowercase Ethereum address.
+
+With --ipfs-add, the files are added to the IPFS node at --ipfs-api,
+and they're published to each --artifact-store. The printed
+target_contents section goes in your discovery file.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		config := freezeConfig()
+		config.Confirmations = uint64(viper.GetInt64("eth-confirmations"))
+
+		blockHash, err := config.WaitFreezeBlock()
+		if err != nil {
+			log.Fatalln("waiting for the freeze block:", err)
+		}
+		fmt.Printf("Freeze block %d reached, hash %s\n", config.FreezeBlock, blockHash)
+
+		snapshot, err := config.Snapshot(blockHash)
+		if err != nil {
+			log.Fatalln("extracting snapshot:", err)
+		}
+
+		meta, err := json.MarshalIndent(snapshot, "", "  ")
+		if err != nil {
+			log.Fatalln("encoding freeze.json:", err)
+		}
+
+		files := []struct {
+			name    string
+			content []byte
+			sha256  string
+		}{
+			{"snapshot.csv", snapshot.Snapshot, snapshot.SnapshotSHA256},
+			{"snapshot_unregistered.csv", snapshot.Unregistered, snapshot.UnregisteredSHA256},
+			{"freeze.json", meta, ""},
+		}
+
+		dir := viper.GetString("freeze-output")
+		if err := os.MkdirAll(dir, 0777); err != nil {
+			log.Fatalln("creating output directory:", err)
+		}
+
+		var stores []bios.ArtifactStore
+		for _, spec := range viper.GetStringSlice("artifact-store") {
+			store, err := bios.NewArtifactStore(spec)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			stores = append(stores, store)
+		}
+
+		var ipfs *shell.Shell
+		if viper.GetBool("ipfs-add") {
+			_, ipfs = ipfsClient()
+		}
+
+		refs := map[string]string{}
+		for _, file := range files {
+			if err := ioutil.WriteFile(filepath.Join(dir, file.name), file.content, 0666); err != nil {
+				log.Fatalf("writing %s: %s", file.name, err)
+			}
+
+			if ipfs != nil {
+				hash, err := ipfs.Add(bytes.NewReader(file.content))
+				if err != nil {
+					log.Fatalf("adding %q to ipfs: %s", file.name, err)
+				}
+				refs[file.name] = "/ipfs/" + hash
+			}
+
+			for _, store := range stores {
+				location, err := store.Put(file.name, file.content)
+				if err != nil {
+					fmt.Printf("WARN: publishing %q: %s\n", file.name, err)
+					continue
+				}
+				fmt.Printf("Published %q to %s\n", file.name, location)
+			}
+		}
+
+		fmt.Printf("%d registered and %d unregistered holders (%d invalid keys), written to %q\n", snapshot.RegisteredCount, snapshot.UnregisteredCount, snapshot.InvalidKeys, dir)
+		fmt.Println("target_contents:")
+		for _, file := range files[:2] {
+			ref := refs[file.name]
+			if ref == "" {
+				ref = "/ipfs/FILL_ME"
+			}
+			fmt.Printf("  - name: %s\n", file.name)
+			fmt.Printf("    ref: %s\n", ref)
+			fmt.Printf("    comment: %q\n", fmt.Sprintf("sha256:%s freeze block %d (%s)", file.sha256, snapshot.FreezeBlock, snapshot.FreezeBlockHash))
+		}
+	},
+}
+
+var launchVerifySnapshotCmd = &cobra.Command{
+	Use:   "verify-snapshot [snapshot.csv]",
+	Short: "Verify a snapshot against the ERC-20 token and registrations at the freeze block",
+	Long: `Verify a snapshot against the ERC-20 token and registrations at the freeze block
+
+Recomputes the registered holders, their EOS key and balance at
+--freeze-block from the Ethereum node at --eth-rpc, like
+freeze-snapshot does, and diffs them against the rows of the snapshot
+(snapshot.csv in the current directory by default), matched by
+Ethereum address: rows with another key or balance, holders not
+registered or without tokens, and registered holders missing.
+
+Exits with a non-zero status when the snapshot doesn't match.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		filename := "snapshot.csv"
+		if len(args) == 1 {
+			filename = args[0]
+		}
+
+		content, err := ioutil.ReadFile(filename)
+		if err != nil {
+			log.Fatalln("reading snapshot:", err)
+		}
+
+		report, err := freezeConfig().VerifySnapshot(content)
+		if err != nil {
+			log.Fatalln("verifying snapshot:", err)
+		}
+
+		report.Print(os.Stdout)
+		if len(report.Problems) != 0 {
+			fmt.Printf("%q doesn't match the Ethereum state at block %d: %d problems\n", filename, viper.GetInt64("freeze-block"), len(report.Problems))
+			os.Exit(1)
+		}
+		fmt.Printf("%q matches the Ethereum state at block %d\n", filename, viper.GetInt64("freeze-block"))
+	},
+}
+
+func freezeConfig() *bios.FreezeConfig {
+	config := &bios.FreezeConfig{
+		EthereumRPC:          viper.GetString("eth-rpc"),
+		TokenContract:        strings.ToLower(viper.GetString("eth-token-contract")),
+		RegistrationContract: strings.ToLower(viper.GetString("eth-registration-contract")),
+		FreezeBlock:          uint64(viper.GetInt64("freeze-block")),
+		FromBlock:            uint64(viper.GetInt64("eth-from-block")),
+		Log:                  bios.NewLogger(),
+	}
+	if config.EthereumRPC == "" || config.FreezeBlock == 0 {
+		log.Fatalln("--eth-rpc and --freeze-block are required")
+	}
+	return config
+}
+
+func init() {
+	RootCmd.AddCommand(launchCmd)
+	launchCmd.AddCommand(launchHashCmd)
+	launchCmd.AddCommand(launchFreezeSnapshotCmd)
+	launchCmd.AddCommand(launchVerifySnapshotCmd)
+
+	launchCmd.PersistentFlags().BoolP("ipfs-add", "", false, "Add the files to the IPFS node at --ipfs-api to obtain their refs")
+
+	// Shared by freeze-snapshot and verify-snapshot.
+	launchCmd.PersistentFlags().StringP("eth-rpc", "", "", "Ethereum JSON-RPC endpoint, of a node able to answer calls at the freeze block (archive node)")
+	launchCmd.PersistentFlags().Int64P("freeze-block", "", 0, "Ethereum block agreed upon for the freeze")
+	launchCmd.PersistentFlags().Int64P("eth-from-block", "", 0, "First Ethereum block to read logs from, like the one the token was deployed at")
+	launchCmd.PersistentFlags().StringP("eth-token-contract", "", bios.DefaultTokenContract, "ERC-20 token contract")
+	launchCmd.PersistentFlags().StringP("eth-registration-contract", "", bios.DefaultRegistrationContract, "Contract emitting LogRegister(address,string) when holders register their EOS key")
+
+	launchFreezeSnapshotCmd.Flags().Int64P("eth-confirmations", "", 12, "Blocks to wait past the freeze block before extracting")
+	launchFreezeSnapshotCmd.Flags().StringP("freeze-output", "", ".", "Directory to write the snapshot files to")
+
+	for _, flag := range []string{"ipfs-add", "eth-rpc", "freeze-block", "eth-from-block", "eth-token-contract", "eth-registration-contract"} {
+		if err := viper.BindPFlag(flag, launchCmd.PersistentFlags().Lookup(flag)); err != nil {
+			panic(err)
+		}
+	}
+	for _, flag := range []string{"eth-confirmations", "freeze-output"} {
+		if err := viper.BindPFlag(flag, launchFreezeSnapshotCmd.Flags().Lookup(flag)); err != nil {
+			panic(err)
+		}
+	}
+}
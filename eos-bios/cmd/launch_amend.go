@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/eoscanada/eos-bios/bios"
+	"github.com/ryanuber/columnize"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var launchAmendCmd = &cobra.Command{
+	Use:   "amend",
+	Short: "Propose late changes to the launch data, and track their approval by the top producers",
+	Long: `Propose late changes to the launch data, and track their approval by the top producers
+
+1. The proposer prepares the amended 'target_contents' in a discovery
+   file, and runs 'launch amend propose' to produce 'amendment.json'.
+2. Each producer reviews it and runs 'launch amend approve', producing a
+   detached approval signed with their seed network key.
+3. Anyone runs 'launch amend status' over the collected approvals to
+   know when the quorum (more than two thirds of the top producers) is
+   reached.
+`,
+}
+
+var launchAmendProposeCmd = &cobra.Command{
+	Use:   "propose [discovery_file.yaml]",
+	Short: "Propose the target_contents of a discovery file as an amendment to the current launch data",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		proposed, err := bios.LoadDiscoveryFromFile(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "loading %q: %s\n", args[0], err)
+			os.Exit(1)
+		}
+
+		net, err := fetchNetwork(false, false)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fetch network: %s\n", err)
+			os.Exit(1)
+		}
+
+		amendment, err := net.NewAmendment(viper.GetString("title"), viper.GetString("description"), proposed.TargetContents)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+
+		writeJSONFile(viper.GetString("output"), amendment)
+		fmt.Printf("Amendment %x written to %q, replacing launch data %s.\n", amendment.Hash(), viper.GetString("output"), amendment.PreviousHash)
+	},
+}
+
+var launchAmendApproveCmd = &cobra.Command{
+	Use:   "approve [amendment.json]",
+	Short: "Sign a detached approval of an amendment with your seed network key",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		refuseInReadOnly("launch amend approve")
+
+		amendment := loadAmendment(args[0])
+
+		net, err := fetchNetwork(false, false)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fetch network: %s\n", err)
+			os.Exit(1)
+		}
+
+		keys, err := seedNetKeys(net)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+
+		approval, err := net.ApproveAmendment(amendment, keys)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+
+		output := viper.GetString("output")
+		if output == "amendment.json" {
+			output = fmt.Sprintf("%s.approval.json", approval.Account)
+		}
+		writeJSONFile(output, approval)
+		fmt.Printf("Approval of amendment %s written to %q, send it to the proposer.\n", approval.Hash, output)
+	},
+}
+
+var launchAmendStatusCmd = &cobra.Command{
+	Use:   "status [amendment.json] [approval.json...]",
+	Short: "Verify the approvals of an amendment, and tell whether the quorum is reached",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		amendment := loadAmendment(args[0])
+
+		var approvals []*bios.AmendmentApproval
+		for _, filename := range args[1:] {
+			cnt, err := ioutil.ReadFile(filename)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "reading %q: %s\n", filename, err)
+				os.Exit(1)
+			}
+
+			var approval bios.AmendmentApproval
+			if err := json.Unmarshal(cnt, &approval); err != nil {
+				fmt.Fprintf(os.Stderr, "decoding %q: %s\n", filename, err)
+				os.Exit(1)
+			}
+			approvals = append(approvals, &approval)
+		}
+
+		net, err := fetchNetwork(false, false)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fetch network: %s\n", err)
+			os.Exit(1)
+		}
+
+		status := net.AmendmentStatus(amendment, approvals)
+
+		fmt.Printf("Amendment %s: %q\n\n", status.Hash, amendment.Title)
+		columns := []string{
+			"Seed Account | Top producer | Valid | Error",
+			"------------ | ------------ | ----- | -----",
+		}
+		for _, approval := range status.Approvals {
+			columns = append(columns, fmt.Sprintf("%s | %t | %t | %s", approval.Account, approval.Top, approval.Valid, approval.Error))
+		}
+		fmt.Println(columnize.SimpleFormat(columns))
+		fmt.Println("")
+		fmt.Printf("%d approvals from top producers, quorum is %d.\n", status.Approved, status.Quorum)
+
+		if !status.Reached {
+			fmt.Println("Quorum NOT reached.")
+			os.Exit(1)
+		}
+		fmt.Println("Quorum reached: producers can update their target_contents.")
+	},
+}
+
+func loadAmendment(filename string) *bios.Amendment {
+	cnt, err := ioutil.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "reading %q: %s\n", filename, err)
+		os.Exit(1)
+	}
+
+	amendment, err := bios.LoadAmendment(cnt)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "decoding %q: %s\n", filename, err)
+		os.Exit(1)
+	}
+
+	return amendment
+}
+
+func writeJSONFile(filename string, v interface{}) {
+	cnt, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "encoding: %s\n", err)
+		os.Exit(1)
+	}
+
+	if err := ioutil.WriteFile(filename, cnt, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "writing %q: %s\n", filename, err)
+		os.Exit(1)
+	}
+}
+
+func init() {
+	launchCmd.AddCommand(launchAmendCmd)
+	launchAmendCmd.AddCommand(launchAmendProposeCmd, launchAmendApproveCmd, launchAmendStatusCmd)
+
+	launchAmendCmd.PersistentFlags().StringP("output", "o", "amendment.json", "File to write the amendment (or the approval, defaults to <account>.approval.json) to")
+	launchAmendProposeCmd.Flags().StringP("title", "", "", "Short title of the amendment")
+	launchAmendProposeCmd.Flags().StringP("description", "", "", "Why the launch data needs to change")
+
+	if err := viper.BindPFlag("output", launchAmendCmd.PersistentFlags().Lookup("output")); err != nil {
+		panic(err)
+	}
+	for _, flag := range []string{"title", "description"} {
+		if err := viper.BindPFlag(flag, launchAmendProposeCmd.Flags().Lookup(flag)); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"fmt"
 	"log"
+	"os"
 
+	"github.com/eoscanada/eos-bios/bios"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -18,6 +21,12 @@ var listCmd = &cobra.Command{
 		}
 
 		net.ListNetworks(viper.GetBool("verbose"))
+
+		if conflicts := bios.ProducerConflicts(net.OrderedPeers(net.MyNetwork())); len(conflicts) != 0 {
+			fmt.Println("")
+			fmt.Println("Conflicts between the producers of your network (account names, block signing keys, Keybase users or PGP fingerprints they share):")
+			bios.PrintProducerConflicts(os.Stdout, conflicts)
+		}
 	},
 }
 
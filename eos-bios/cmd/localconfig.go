@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/eoscanada/eos-bios/bios"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// loadLocalConfig merges the settings of `--local-config` in the
+// configuration, under the flags set on the command line. It's a YAML
+// file of flag names and values, which can also hold the private keys
+// to the seed network account, as `seednet-private-keys`, shell
+// commands to run as hooks, under `hooks` (see bios.ParseHooks), and
+// URLs to POST hooks to, under `webhooks`. Encrypted with `age -p` or
+// `gpg --symmetric`, it's only ever decrypted in memory.
+func loadLocalConfig(filename string) error {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	encryption := bios.LocalConfigEncryption(content)
+	plain, err := bios.DecryptLocalConfig(content, func() (string, error) {
+		return promptPassphrase(fmt.Sprintf("Passphrase for %s (%s): ", filename, encryption), bios.LocalConfigPassphraseEnv)
+	})
+	if err != nil {
+		return err
+	}
+
+	viper.SetConfigType("yaml")
+	if err := viper.MergeConfig(bytes.NewReader(plain)); err != nil {
+		return fmt.Errorf("parsing: %s", err)
+	}
+
+	if encryption == bios.LocalConfigPlain && len(viper.GetStringSlice("seednet-private-keys")) != 0 {
+		fmt.Fprintf(os.Stderr, "WARN: %s holds private keys in plain text, encrypt it with `age -p` or `gpg --symmetric`\n", filename)
+	}
+
+	return nil
+}
+
+// promptPassphrase reads a passphrase from the terminal, unless it's
+// in the `env` variable.
+func promptPassphrase(prompt, env string) (string, error) {
+	if passphrase := os.Getenv(env); passphrase != "" {
+		return passphrase, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		return "", fmt.Errorf("no terminal to prompt for it, set %s", env)
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	passphrase, err := terminal.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(passphrase), nil
+}
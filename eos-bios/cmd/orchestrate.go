@@ -14,6 +14,7 @@
 package cmd
 
 import (
+	"fmt"
 	"log"
 
 	"github.com/spf13/cobra"
@@ -26,29 +27,38 @@ var orchestrateCmd = &cobra.Command{
 	Short: "Automate all the operations to launch a new network, by collaborating with other in the launch.",
 	Long:  `This operation will auto-select the roles, based on a discovered Network shared amongst participants.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		net, err := fetchNetwork(false, true)
-		if err != nil {
-			log.Fatalln("fetch network:", err)
+		if err := runOrchestrate(); err != nil {
+			log.Fatalln(err)
 		}
+	},
+}
 
-		if elect := viper.GetString("elect"); elect != "" {
-			net.CalculateNetworkWeights(elect)
-		}
+// runOrchestrate is the orchestrate mode, shared with the Windows
+// service.
+func runOrchestrate() error {
+	net, err := fetchNetwork(false, true)
+	if err != nil {
+		return fmt.Errorf("fetch network: %s", err)
+	}
 
-		b, err := setupBIOS(net)
-		if err != nil {
-			log.Fatalln("bios setup:", err)
-		}
+	if elect := viper.GetString("elect"); elect != "" {
+		net.CalculateNetworkWeights(elect)
+	}
 
-		if err := b.Init(); err != nil {
-			log.Fatalf("BIOS initialization error: %s", err)
-		}
+	b, err := setupBIOS(net)
+	if err != nil {
+		return fmt.Errorf("bios setup: %s", err)
+	}
 
-		if err := b.StartOrchestrate(); err != nil {
-			log.Fatalf("error orchestrating: %s", err)
-		}
+	if err := b.Init(); err != nil {
+		return fmt.Errorf("BIOS initialization error: %s", err)
+	}
 
-	},
+	if err := b.StartOrchestrate(); err != nil {
+		return fmt.Errorf("error orchestrating: %s", err)
+	}
+
+	return nil
 }
 
 func init() {
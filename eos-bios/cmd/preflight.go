@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/eoscanada/eos-bios/bios"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var preflightCmd = &cobra.Command{
+	Use:   "preflight",
+	Short: "Run the launch-day checklist and print a go/no-go verdict",
+	Long: `Run the launch-day checklist and print a go/no-go verdict
+
+Checks the launch data consensus, the downloaded contents and their
+sha256, renders the whole boot sequence (loading the snapshot and
+contracts), verifies your wallet keys against your seed network
+account, the seed network (source of the launch randomness), your
+clock, your target node and its version, connectivity to the other
+participants' p2p endpoints, their bp.json (account name, block
+signing key and org name) against their discovery files, and whether
+the boot sequence's quorum of producers is already present, and its
+launch time.
+
+Run it hours before the launch window. Exits with code 1 on a no-go.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		net, err := fetchNetwork(false, true)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fetch network: %s\n", err)
+			os.Exit(1)
+		}
+
+		b, err := setupBIOS(net)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "bios setup: %s\n", err)
+			os.Exit(1)
+		}
+
+		if err := b.Init(); err != nil {
+			fmt.Fprintf(os.Stderr, "bios init: %s\n", err)
+			os.Exit(1)
+		}
+
+		checks := b.Preflight(viper.GetString("node-version"))
+
+		fmt.Println("")
+		bios.PrintPreflight(os.Stdout, checks)
+
+		if !bios.PreflightPassed(checks) {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(preflightCmd)
+
+	preflightCmd.Flags().StringP("node-version", "", "", "Expected prefix of your target node's server_version (ex: v1.0.5)")
+
+	for _, flag := range []string{"node-version"} {
+		if err := viper.BindPFlag(flag, preflightCmd.Flags().Lookup(flag)); err != nil {
+			panic(err)
+		}
+	}
+}
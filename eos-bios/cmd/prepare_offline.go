@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/eoscanada/eos-bios/bios"
+	"github.com/spf13/cobra"
+)
+
+var prepareOfflineCmd = &cobra.Command{
+	Use:   "prepare-offline [directory]",
+	Short: "Fetch everything the launch needs into a verified bundle, to boot from a network-restricted environment",
+	Long: `Fetch everything the launch needs into a verified bundle, to boot from a network-restricted environment
+
+Discovers the network, downloads the launch contents (contracts,
+snapshot, boot sequence), fetches the peers' PGP keys, and renders the
+whole boot sequence to make sure nothing is missing. Everything is
+written to the directory, along with a manifest of sha256 hashes.
+
+Copy the directory to the boot machine, and run with
+--offline-bundle [directory]: the bundle is verified, then the seed
+network, IPFS and third-party APIs are never called. Your private keys
+are NOT part of the bundle.
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		net, err := fetchNetwork(false, true)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fetch network: %s\n", err)
+			os.Exit(1)
+		}
+
+		b, err := setupBIOS(net)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "bios setup: %s\n", err)
+			os.Exit(1)
+		}
+
+		if err := b.Init(); err != nil {
+			fmt.Fprintf(os.Stderr, "bios init: %s\n", err)
+			os.Exit(1)
+		}
+
+		if err := b.CheckOfflineContents(); err != nil {
+			fmt.Fprintf(os.Stderr, "launch data incomplete, %s\n", err)
+			os.Exit(1)
+		}
+
+		manifest, err := net.PrepareOfflineBundle(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "preparing bundle: %s\n", err)
+			os.Exit(1)
+		}
+
+		if _, err := bios.VerifyOfflineBundle(args[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "verifying bundle: %s\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Offline bundle written to %q: %d peers, %d files, launch data %s\n", args[0], manifest.Peers, len(manifest.Files), manifest.LaunchDataHash)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(prepareOfflineCmd)
+}
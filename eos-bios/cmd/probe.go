@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/eoscanada/eos-bios/bios"
+	"github.com/spf13/cobra"
+)
+
+var probeCmd = &cobra.Command{
+	Use:   "probe",
+	Short: "Probe the target API and p2p endpoints of the producers of the launch",
+	Long: `Probe the target API and p2p endpoints of the producers of the launch
+
+Dials every producer's endpoints like the boot sequence's "probe"
+section does before shuffling, retrying those not answering for its
+"window", and prints who is unreachable along with the exclusion hash.
+
+When the boot sequence excludes unreachable producers, everyone goes
+with the exclusion its "publisher" signs and publishes: run it in the
+agreed window, right after the launch block, to see how it compares
+from your network.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		net, err := fetchNetwork(false, true)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fetch network: %s\n", err)
+			os.Exit(1)
+		}
+
+		b, err := setupBIOS(net)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "bios setup: %s\n", err)
+			os.Exit(1)
+		}
+
+		if err := b.Init(); err != nil {
+			fmt.Fprintf(os.Stderr, "bios init: %s\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Println("")
+		bios.PrintProbeResults(os.Stdout, b.ProbeLaunchProducers())
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(probeCmd)
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/eoscanada/eos-bios/bios"
+	eos "github.com/eoscanada/eos-go"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var proveAccountCmd = &cobra.Command{
+	Use:   "prove-account [account_name]",
+	Short: "Show the transactions and blocks that created and funded a genesis account",
+	Long: `Show the transactions and blocks that created and funded a genesis account
+
+Reads the target network's blocks (or an index exported earlier with
+--proof-export to a .json file), and indexes every account created,
+with the transaction and block that created it, staked its CPU and
+network bandwidth, and transferred its liquid balance from 'eosio'.
+
+Use --proof-export to write the whole index, as JSON or CSV, so
+holders and support teams can trace any genesis balance without
+reading the chain again.
+`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 && viper.GetString("proof-export") == "" {
+			fmt.Fprintln(os.Stderr, "specify an account name, or --proof-export")
+			os.Exit(1)
+		}
+
+		var idx bios.AccountProofIndex
+		var err error
+		if indexFile := viper.GetString("proof-index"); indexFile != "" {
+			idx, err = bios.LoadAccountProofIndex(indexFile)
+		} else {
+			targetNetHTTP := viper.GetString("target-api")
+			if targetNetHTTP == "" {
+				fmt.Fprintln(os.Stderr, "missing --target-api or --proof-index")
+				os.Exit(1)
+			}
+			idx, err = bios.BuildAccountProofIndex(eos.New(targetNetHTTP), 1, uint32(viper.GetInt("proof-last-block")))
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "building proof index: %s\n", err)
+			os.Exit(1)
+		}
+
+		if exportFile := viper.GetString("proof-export"); exportFile != "" {
+			if err := idx.Write(exportFile); err != nil {
+				fmt.Fprintf(os.Stderr, "exporting proof index: %s\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Proofs for %d accounts written to %q\n", len(idx), exportFile)
+		}
+
+		if len(args) == 0 {
+			return
+		}
+
+		proof := idx[eos.AccountName(args[0])]
+		if proof == nil {
+			fmt.Fprintf(os.Stderr, "account %q not found in the genesis\n", args[0])
+			os.Exit(1)
+		}
+
+		fmt.Printf("Account %q\n", proof.Account)
+		if proof.CreatedInTransaction != "" {
+			fmt.Printf("- created in transaction %s, block %d\n", proof.CreatedInTransaction, proof.CreatedInBlock)
+		}
+		if proof.StakedInTransaction != "" {
+			fmt.Printf("- staked %s CPU and %s network in transaction %s, block %d\n", proof.StakedCPU, proof.StakedNet, proof.StakedInTransaction, proof.StakedInBlock)
+		}
+		if proof.FundedInTransaction != "" {
+			fmt.Printf("- funded with %s liquid in transaction %s, block %d\n", proof.Liquid, proof.FundedInTransaction, proof.FundedInBlock)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(proveAccountCmd)
+
+	proveAccountCmd.Flags().StringP("proof-index", "", "", "Read the proofs from this JSON index, exported earlier, instead of the target network")
+	proveAccountCmd.Flags().StringP("proof-export", "", "", "Write the proofs of all accounts to this file, as JSON if it ends with .json, as CSV otherwise")
+	proveAccountCmd.Flags().IntP("proof-last-block", "", 0, "Last target network block to read, defaults to the head block")
+
+	for _, flag := range []string{"proof-index", "proof-export", "proof-last-block"} {
+		if err := viper.BindPFlag(flag, proveAccountCmd.Flags().Lookup(flag)); err != nil {
+			panic(err)
+		}
+	}
+}
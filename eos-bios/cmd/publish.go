@@ -4,13 +4,17 @@ package cmd
 
 import (
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	humanize "github.com/dustin/go-humanize"
+	"github.com/eoscanada/eos-bios/bios"
 	"github.com/eoscanada/eos-bios/bios/disco"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var publishCmd = &cobra.Command{
@@ -18,6 +22,8 @@ var publishCmd = &cobra.Command{
 	Short: "Publish my discovery file to the seed network",
 	Long:  ``,
 	Run: func(cmd *cobra.Command, args []string) {
+		refuseInReadOnly("publish")
+
 		net, err := fetchNetwork(false, false)
 		if err != nil {
 			log.Fatalln("fetch network:", err)
@@ -36,6 +42,13 @@ var publishCmd = &cobra.Command{
 
 		fmt.Printf("- Target time: %s (%s%s)\n", humanize.Time(launchTime), launchTime.Format(time.RFC1123Z), past)
 
+		if constitutionFile := viper.GetString("ack-constitution"); constitutionFile != "" {
+			if err := ackConstitution(net, constitutionFile); err != nil {
+				fmt.Println("acknowledging constitution:", err)
+				os.Exit(1)
+			}
+		}
+
 		_, err = net.SeedNetAPI.SignPushActions(
 			disco.NewUpdateDiscovery(net.MyPeer.Discovery.SeedNetworkAccountName, net.MyPeer.Discovery),
 		)
@@ -49,6 +62,50 @@ var publishCmd = &cobra.Command{
 	},
 }
 
+// ackConstitution adds the signed acknowledgement of the
+// constitution to the `urls` of the discovery we publish.
+func ackConstitution(net *bios.Network, constitutionFile string) error {
+	content, err := ioutil.ReadFile(constitutionFile)
+	if err != nil {
+		return err
+	}
+
+	keys, err := seedNetKeys(net)
+	if err != nil {
+		return err
+	}
+
+	myDisco := net.MyPeer.Discovery
+	activeKeys, err := net.ActivePublicKeys(myDisco.SeedNetworkAccountName)
+	if err != nil {
+		return err
+	}
+
+	hash := bios.ConstitutionHash(content)
+	ack, err := bios.SignConstitution(keys, activeKeys, hash)
+	if err != nil {
+		return err
+	}
+
+	var urls []string
+	for _, url := range myDisco.URLs {
+		if !strings.HasPrefix(url, "constitution-ack:") {
+			urls = append(urls, url)
+		}
+	}
+	myDisco.URLs = append(urls, ack)
+
+	fmt.Printf("- Acknowledging constitution with sha256 %x\n", hash)
+
+	return nil
+}
+
 func init() {
 	RootCmd.AddCommand(publishCmd)
+
+	publishCmd.Flags().StringP("ack-constitution", "", "", "Sign the sha256 of this constitution file with your seed network key, and publish the acknowledgement along with your discovery file")
+
+	if err := viper.BindPFlag("ack-constitution", publishCmd.Flags().Lookup("ack-constitution")); err != nil {
+		panic(err)
+	}
 }
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/eoscanada/eos-bios/bios"
+	eos "github.com/eoscanada/eos-go"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var scheduleHistoryCmd = &cobra.Command{
+	Use:   "schedule-history",
+	Short: "List the producer schedule changes on the target network, and flag the unexplained ones",
+	Long: `List the producer schedule changes on the target network, and flag the unexplained ones
+
+Reads the target network's blocks, records where each producer schedule
+version was proposed and activated, and checks it corresponds to a
+'setprods' action or to votes for registered producers. Exits with
+code 1 when a change is unexplained.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		targetNetHTTP := viper.GetString("target-api")
+		if targetNetHTTP == "" {
+			fmt.Fprintln(os.Stderr, "missing --target-api")
+			os.Exit(1)
+		}
+
+		firstBlock := uint32(viper.GetInt("first-block"))
+		lastBlock := uint32(viper.GetInt("last-block"))
+
+		changes, err := bios.TrackProducerSchedules(eos.New(targetNetHTTP), firstBlock, lastBlock)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+
+		unexplained := 0
+		for _, change := range changes {
+			fmt.Println("-", change)
+			if change.Unexplained {
+				unexplained++
+			}
+		}
+		fmt.Println("")
+		fmt.Printf("%d schedule changes, %d unexplained.\n", len(changes), unexplained)
+
+		if unexplained != 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(scheduleHistoryCmd)
+
+	scheduleHistoryCmd.Flags().IntP("first-block", "", 1, "First target network block to read. Start at 1 to see all producer registrations.")
+	scheduleHistoryCmd.Flags().IntP("last-block", "", 0, "Last target network block to read, defaults to the head block")
+
+	for _, flag := range []string{"first-block", "last-block"} {
+		if err := viper.BindPFlag(flag, scheduleHistoryCmd.Flags().Lookup(flag)); err != nil {
+			panic(err)
+		}
+	}
+}
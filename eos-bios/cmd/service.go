@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+var serviceCmd = &cobra.Command{
+	Use:   "service",
+	Short: "Install and run the orchestrate mode as a Windows service",
+	Long: `Install and run the orchestrate mode as a Windows service
+
+From the directory holding your discovery file, keys and hooks, run:
+
+    eos-bios service install --seednet-api https://... [other flags]
+
+The flags given to 'install' are those the service runs 'orchestrate'
+with, from that same directory. Its output goes to
+'eos-bios-service.log' there, next to 'output.log'.
+
+Start it with 'sc start eos-bios' or from the Services console. On
+other systems, run 'orchestrate' under your usual supervisor.
+`,
+}
+
+var serviceInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install the orchestrate mode as a Windows service, running from the current directory",
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "current directory: %s\n", err)
+			os.Exit(1)
+		}
+
+		name := viper.GetString("service-name")
+		if err := installService(name, dir, serviceFlags(cmd)); err != nil {
+			fmt.Fprintf(os.Stderr, "installing service: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Service %q installed, running from %q\n", name, dir)
+	},
+}
+
+var serviceUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove the Windows service",
+	Run: func(cmd *cobra.Command, args []string) {
+		name := viper.GetString("service-name")
+		if err := uninstallService(name); err != nil {
+			fmt.Fprintf(os.Stderr, "removing service: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Service %q removed\n", name)
+	},
+}
+
+var serviceRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run the orchestrate mode under the Windows service manager (used by the installed service)",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runService(viper.GetString("service-name"), viper.GetString("service-dir")); err != nil {
+			fmt.Fprintf(os.Stderr, "running service: %s\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// serviceFlags are the flags given to `service install`, passed on to
+// the service's `orchestrate`.
+func serviceFlags(cmd *cobra.Command) (out []string) {
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		if flag.Name == "service-name" || flag.Name == "service-dir" {
+			return
+		}
+
+		if slice, ok := flag.Value.(pflag.SliceValue); ok {
+			for _, value := range slice.GetSlice() {
+				out = append(out, fmt.Sprintf("--%s=%s", flag.Name, value))
+			}
+			return
+		}
+		out = append(out, fmt.Sprintf("--%s=%s", flag.Name, flag.Value.String()))
+	})
+	return
+}
+
+func init() {
+	RootCmd.AddCommand(serviceCmd)
+	serviceCmd.AddCommand(serviceInstallCmd, serviceUninstallCmd, serviceRunCmd)
+
+	serviceCmd.PersistentFlags().StringP("service-name", "", "eos-bios", "Name of the Windows service")
+	serviceRunCmd.Flags().StringP("service-dir", "", ".", "Directory to run from, holding the discovery file, keys and hooks")
+
+	if err := viper.BindPFlag("service-name", serviceCmd.PersistentFlags().Lookup("service-name")); err != nil {
+		panic(err)
+	}
+	if err := viper.BindPFlag("service-dir", serviceRunCmd.Flags().Lookup("service-dir")); err != nil {
+		panic(err)
+	}
+}
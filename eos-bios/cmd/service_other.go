@@ -0,0 +1,20 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import "fmt"
+
+var errServiceNotSupported = fmt.Errorf("services are only supported on Windows, run `orchestrate` under systemd or your usual supervisor")
+
+func installService(name, dir string, flags []string) error {
+	return errServiceNotSupported
+}
+
+func uninstallService(name string) error {
+	return errServiceNotSupported
+}
+
+func runService(name, dir string) error {
+	return errServiceNotSupported
+}
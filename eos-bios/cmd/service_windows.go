@@ -0,0 +1,127 @@
+//go:build windows
+// +build windows
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+const serviceLogFile = "eos-bios-service.log"
+
+func installService(name, dir string, flags []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("locating eos-bios: %s", err)
+	}
+
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("connecting to the service manager: %s", err)
+	}
+	defer m.Disconnect()
+
+	if s, err := m.OpenService(name); err == nil {
+		s.Close()
+		return fmt.Errorf("service %q already exists, uninstall it first", name)
+	}
+
+	args := append([]string{"service", "run", "--service-name", name, "--service-dir", dir}, flags...)
+	s, err := m.CreateService(name, exe, mgr.Config{
+		DisplayName: fmt.Sprintf("eos-bios orchestrate (%s)", name),
+		Description: "Orchestrates the launch of an EOS.IO network with the other participants",
+		StartType:   mgr.StartManual,
+	}, args...)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	return nil
+}
+
+func uninstallService(name string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("connecting to the service manager: %s", err)
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("service %q: %s", name, err)
+	}
+	defer s.Close()
+
+	return s.Delete()
+}
+
+// runService runs orchestrate from the service's directory, under the
+// service manager, or straight from the console to try it out.
+func runService(name, dir string) error {
+	isService, err := svc.IsWindowsService()
+	if err != nil {
+		return fmt.Errorf("detecting service mode: %s", err)
+	}
+
+	if err := os.Chdir(dir); err != nil {
+		return fmt.Errorf("service directory: %s", err)
+	}
+
+	if !isService {
+		return runOrchestrate()
+	}
+
+	// Services have no console, keep everything written to it.
+	logFile, err := os.OpenFile(filepath.Join(dir, serviceLogFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0666)
+	if err != nil {
+		return fmt.Errorf("opening %s: %s", serviceLogFile, err)
+	}
+	defer logFile.Close()
+	os.Stdout = logFile
+	os.Stderr = logFile
+	log.SetOutput(logFile)
+
+	return svc.Run(name, &orchestrateService{})
+}
+
+type orchestrateService struct{}
+
+func (s *orchestrateService) Execute(args []string, requests <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
+	changes <- svc.Status{State: svc.StartPending}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- runOrchestrate()
+	}()
+
+	changes <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				log.Println(err)
+				return true, 1
+			}
+			log.Println("orchestrate done")
+			return false, 0
+
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				changes <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				log.Println("Service stop requested, orchestrate interrupted")
+				changes <- svc.Status{State: svc.StopPending}
+				return false, 0
+			}
+		}
+	}
+}
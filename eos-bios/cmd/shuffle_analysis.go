@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/eoscanada/eos-bios/bios"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var shuffleAnalysisCmd = &cobra.Command{
+	Use:   "shuffle-analysis",
+	Short: "Run the producers shuffle over a range of seed network blocks, and report positional statistics",
+	Long: `Run the producers shuffle over a range of seed network blocks, and report positional statistics
+
+The shuffle is seeded by the hash of the seed network's launch
+block, or the block or beacon output of the boot sequence's entropy
+provider. This runs it over many historical seed network block hashes,
+seeded as that provider does, with your network's current peers and
+the boot sequence's "shuffle" weighting, so everyone can confirm that
+no position is favored beyond the weights before agreeing on it.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		net, err := fetchNetwork(false, true)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fetch network: %s\n", err)
+			os.Exit(1)
+		}
+
+		b, err := setupBIOS(net)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "bios setup: %s\n", err)
+			os.Exit(1)
+		}
+
+		if err := b.Init(); err != nil {
+			fmt.Fprintf(os.Stderr, "bios init: %s\n", err)
+			os.Exit(1)
+		}
+
+		toBlock := uint32(viper.GetInt("to-block"))
+		if toBlock == 0 {
+			toBlock, err = net.GetLastBlockNum()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "getting seed network head block: %s\n", err)
+				os.Exit(1)
+			}
+		}
+
+		blocks := uint32(viper.GetInt("blocks"))
+		if blocks == 0 || blocks >= toBlock {
+			fmt.Fprintf(os.Stderr, "invalid --blocks %d, with --to-block %d\n", blocks, toBlock)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Fetching seed network blocks %d to %d", toBlock-blocks+1, toBlock)
+		var hashes [][]byte
+		for blockNum := toBlock - blocks + 1; blockNum <= toBlock; blockNum++ {
+			hash, err := net.GetBlockHeight(blockNum)
+			if err != nil {
+				fmt.Println(" failed")
+				fmt.Fprintf(os.Stderr, "fetching block %d: %s\n", blockNum, err)
+				os.Exit(1)
+			}
+			hashes = append(hashes, hash)
+			if len(hashes)%100 == 0 {
+				fmt.Printf(".")
+			}
+		}
+		fmt.Println(" done")
+		fmt.Println("")
+
+		peers := net.OrderedPeers(net.MyNetwork())
+		report := bios.AnalyzeShuffleFairness(b.Shuffle, b.EntropyProvider.Name(), peers, hashes)
+		report.Print(os.Stdout)
+
+		if report.Biased() {
+			os.Exit(2)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(shuffleAnalysisCmd)
+
+	shuffleAnalysisCmd.Flags().IntP("blocks", "", 1000, "Number of seed network blocks to run the shuffle over")
+	shuffleAnalysisCmd.Flags().IntP("to-block", "", 0, "Last seed network block to use (defaults to the head block)")
+
+	for _, flag := range []string{"blocks", "to-block"} {
+		if err := viper.BindPFlag(flag, shuffleAnalysisCmd.Flags().Lookup(flag)); err != nil {
+			panic(err)
+		}
+	}
+}
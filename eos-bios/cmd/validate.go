@@ -15,24 +15,32 @@ package cmd
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/eoscanada/eos-bios/bios"
+	eos "github.com/eoscanada/eos-go"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 // validateCmd represents the validate command
 var validateCmd = &cobra.Command{
-	Use:   "validate [some_file.yaml]",
+	Use:   "validate [some_file.yaml|snapshot.csv]",
 	Short: "Validate check for the integrity of a local discovery file by default, or another file.",
-	Long:  "Check your files before you put them out, as to not break the network being crafted.",
+	Long:  "Check your files before you put them out, as to not break the network being crafted. A .csv, .csv.gz or snapshot*.json file is checked as a snapshot: unique account names, valid public keys and positive balances, adding up to --total-supply when set.",
 	Args:  cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		filename := viper.GetString("my-discovery")
 		if len(args) == 1 {
 			filename = args[0]
 		}
+		if strings.HasSuffix(filename, ".csv") || strings.HasSuffix(filename, ".csv.gz") || (strings.HasPrefix(filepath.Base(filename), "snapshot") && strings.HasSuffix(filename, ".json")) {
+			validateSnapshot(filename)
+			return
+		}
 		if err := bios.ValidateDiscoveryFile(filename); err != nil {
 			fmt.Println("Error:", err)
 			os.Exit(1)
@@ -41,6 +49,40 @@ var validateCmd = &cobra.Command{
 	},
 }
 
+func validateSnapshot(filename string) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+
+	var totalSupply *eos.Asset
+	if total := viper.GetString("total-supply"); total != "" {
+		asset, err := eos.NewEOSAssetFromString(total)
+		if err != nil {
+			fmt.Println("Error: invalid --total-supply:", err)
+			os.Exit(1)
+		}
+		totalSupply = &asset
+	}
+
+	report, err := bios.ValidateSnapshot(content, totalSupply)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	report.Print(os.Stdout)
+	if len(report.Problems) != 0 {
+		os.Exit(1)
+	}
+	fmt.Println("File valid:", filename)
+}
+
 func init() {
 	RootCmd.AddCommand(validateCmd)
+
+	validateCmd.Flags().StringP("total-supply", "", "", "When validating a snapshot, the sum its balances must add up to, like the total_supply of the boot sequence's snapshot section (ex: 1000000000.0000 EOS)")
+	if err := viper.BindPFlag("total-supply", validateCmd.Flags().Lookup("total-supply")); err != nil {
+		panic(err)
+	}
 }
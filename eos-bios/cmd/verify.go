@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/eoscanada/eos-bios/bios"
+	eos "github.com/eoscanada/eos-go"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify [audit.jsonl]",
+	Short: "Replay the boot node's audit log against the target network",
+	Long: `Replay the boot node's audit log against the target network
+
+Reads an audit log written with --audit-log (a local file, an http(s)
+URL or an /ipfs/ reference of a published copy), and checks on the
+target network that every transaction it holds is in the block it
+claims. In the boot window, from block 1 to the last claimed block,
+it also checks no other transaction has actions authorized by eosio.
+
+Exits with code 1 when anything doesn't match.
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		targetNetHTTP := viper.GetString("target-api")
+		if targetNetHTTP == "" {
+			fmt.Fprintln(os.Stderr, "missing --target-api")
+			os.Exit(1)
+		}
+
+		cnt, err := readAuditLogSource(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "reading %s: %s\n", args[0], err)
+			os.Exit(1)
+		}
+
+		entries, err := bios.ReadAuditLog(bytes.NewReader(cnt))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "parsing %s: %s\n", args[0], err)
+			os.Exit(1)
+		}
+
+		verification, err := bios.VerifyAuditLog(eos.New(targetNetHTTP), entries)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "verifying: %s\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("%d transactions found in their blocks, boot window from block 1 to %d.\n", verification.Transactions, verification.LastBlock)
+		if len(verification.Problems) == 0 {
+			fmt.Println("The audit log matches the target network.")
+			return
+		}
+
+		fmt.Println("")
+		for _, problem := range verification.Problems {
+			fmt.Println("-", problem)
+		}
+		fmt.Println("")
+		fmt.Printf("%d problems found.\n", len(verification.Problems))
+		os.Exit(1)
+	},
+}
+
+func readAuditLogSource(source string) ([]byte, error) {
+	switch {
+	case strings.HasPrefix(source, "/ipfs/"):
+		gateways := strings.Split(viper.GetString("ipfs"), ",")
+		return bios.NewIPFS(gateways[0], gateways[1:]...).Get(source)
+	case strings.HasPrefix(source, "http://"), strings.HasPrefix(source, "https://"):
+		resp, err := http.Get(source)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("status %d", resp.StatusCode)
+		}
+		return ioutil.ReadAll(resp.Body)
+	}
+	return ioutil.ReadFile(source)
+}
+
+func init() {
+	RootCmd.AddCommand(verifyCmd)
+}
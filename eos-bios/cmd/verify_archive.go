@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/eoscanada/eos-bios/bios"
+	eos "github.com/eoscanada/eos-go"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var verifyArchiveCmd = &cobra.Command{
+	Use:   "verify-archive [archive.tar.gz]",
+	Short: "Verify a launch archive against its manifest and signature",
+	Long: `Verify a launch archive against its manifest and signature
+
+Checks every file of an archive written with --archive against the
+sha256 of its manifest. With --seednet-api, also checks the manifest
+was signed by a key of the archiving account's active permission on
+the seed network.
+
+Exits with code 1 when anything doesn't match.
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		manifest, sig, err := bios.VerifyLaunchArchive(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "archive verification failed: %s\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Archive of %s, acting as %s, created at %s\n", manifest.Account, manifest.Role, manifest.CreatedAt)
+		fmt.Printf("Launch data hash: %s\n", manifest.LaunchDataHash)
+		if manifest.TargetChainID != "" {
+			fmt.Printf("Target chain ID: %s\n", manifest.TargetChainID)
+		}
+
+		var names []string
+		for name := range manifest.Files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Printf("\n%d files match the manifest:\n", len(names))
+		for _, name := range names {
+			fmt.Printf("- %s  %s\n", manifest.Files[name], name)
+		}
+		for _, name := range manifest.Missing {
+			fmt.Printf("- (not archived)  %s\n", name)
+		}
+		fmt.Println("")
+
+		if sig == nil {
+			fmt.Println("WARNING: the archive is not signed")
+			return
+		}
+
+		seedNetHTTP := viper.GetString("seednet-api")
+		if seedNetHTTP == "" {
+			fmt.Printf("Signed by %s, pass --seednet-api to check the signature against its active permission\n", sig.Account)
+			return
+		}
+
+		pubKey, err := sig.Verify(eos.New(seedNetHTTP))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "signature verification failed: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Signed by %s with %s, part of its active permission\n", sig.Account, pubKey)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(verifyArchiveCmd)
+}
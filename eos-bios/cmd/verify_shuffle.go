@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/eoscanada/eos-bios/bios"
+	"github.com/eoscanada/eos-bios/bios/disco"
+	eos "github.com/eoscanada/eos-go"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var verifyShuffleCmd = &cobra.Command{
+	Use:   "verify-shuffle [shuffle_audit.json]",
+	Short: "Re-run the producer shuffle of a shuffle audit, and check its signature",
+	Long: `Re-run the producer shuffle of a shuffle audit, and check its signature
+
+Re-derives the seed of a shuffle audit written during the launch from
+its randomness inputs (block hash, merkle root or beacon output),
+re-runs the shuffle of its candidates, and checks it comes to the same
+boot node and appointed producers.
+
+With --seednet-api, also checks the inputs of the shuffle against
+their sources: the launch block (or Bitcoin block, or beacon round,
+from their public sources) has the hash the seed was derived from, and
+the candidates and their weights are those of the discovery graph on
+the seed network. It then checks the audit was signed by a key of the
+auditing account's active permission. Without it, those inputs are
+taken from the audit unverified.
+
+Exits with code 1 when anything doesn't match.
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cnt, err := ioutil.ReadFile(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "reading shuffle audit: %s\n", err)
+			os.Exit(1)
+		}
+
+		audit, sig, err := bios.VerifyShuffleAudit(cnt)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "shuffle verification failed: %s\n", err)
+			os.Exit(1)
+		}
+
+		proof := audit.Randomness
+		fmt.Printf("Shuffle audit of %s, created at %s\n", audit.Account, audit.CreatedAt)
+		fmt.Printf("Launch data hash: %s, boot sequence hash: %s\n", audit.LaunchDataHash, audit.BootSequenceHash)
+		fmt.Printf("Seeded from %s %d: %s %s\n", proof.Source, proof.BlockNum, proof.ValueLabel(), proof.BlockHash)
+		if proof.MerkleRoot != "" {
+			fmt.Printf("Merkle root: %s\n", proof.MerkleRoot)
+		}
+		if proof.BlockTime != nil {
+			fmt.Printf("Block time: %s\n", proof.BlockTime)
+		}
+		fmt.Printf("Seed: %d, re-derived\n", proof.Seed)
+		for _, account := range audit.Excluded {
+			fmt.Printf("Excluded as unreachable: %s\n", account)
+		}
+		if audit.Exclusion != nil {
+			fmt.Printf("Exclusion published by %s\n", audit.Exclusion.Account)
+		}
+
+		fmt.Printf("\nRe-running the shuffle of %d candidates gives the same schedule:\n", len(audit.Candidates))
+		for idx, producer := range audit.Schedule {
+			role := "appointed producer"
+			switch {
+			case idx == 0:
+				role = "boot node"
+			case idx > audit.AppointedProducers:
+				role = "participant"
+			}
+			fmt.Printf("%3d. %-12s  %-12s  %s\n", idx+1, producer.SeedAccount, producer.TargetAccount, role)
+		}
+		fmt.Println("")
+
+		seedNetHTTP := viper.GetString("seednet-api")
+		if seedNetHTTP == "" {
+			fmt.Println("WARNING: the randomness, candidates and weights are taken from the audit unverified, pass --seednet-api to check them against their sources")
+		} else {
+			seedNetAPI := eos.New(seedNetHTTP)
+			if err := bios.VerifyRandomnessSource(proof, seedNetAPI); err != nil {
+				fmt.Fprintf(os.Stderr, "randomness verification failed: %s\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Checked %s %d against its source\n", proof.Source, proof.BlockNum)
+
+			net := bios.NewNetwork(viper.GetString("cache-path"), &disco.Discovery{SeedNetworkAccountName: audit.Account}, nil, seedNetworkContract, seedNetAPI)
+			net.Log = bios.NewLogger()
+			if err := bios.VerifyShuffleCandidates(audit, net); err != nil {
+				fmt.Fprintf(os.Stderr, "candidates verification failed: %s\n", err)
+				os.Exit(1)
+			}
+			fmt.Println("Checked the candidates and their weights against the discovery graph")
+
+			if exclusion := audit.Exclusion; exclusion != nil {
+				pubKey, err := exclusion.Verify(seedNetAPI)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "published exclusion verification failed: %s\n", err)
+					os.Exit(1)
+				}
+				fmt.Printf("Exclusion signed by %s with %s, part of its active permission\n", exclusion.Account, pubKey)
+			}
+		}
+
+		if sig == nil {
+			fmt.Println("WARNING: the shuffle audit is not signed")
+			return
+		}
+
+		if seedNetHTTP == "" {
+			fmt.Printf("Signed by %s, pass --seednet-api to check the signature against its active permission\n", sig.Account)
+			return
+		}
+
+		pubKey, err := sig.Verify(eos.New(seedNetHTTP))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "signature verification failed: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Signed by %s with %s, part of its active permission\n", sig.Account, pubKey)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(verifyShuffleCmd)
+}
@@ -0,0 +1,19 @@
+#!/bin/bash -e
+
+# `boot_publish_kickstart` hook, called when booting with
+# `--kickstart-chunk-size`.
+# $1 = number of chunks
+# $2 = file holding the chunks, one per line
+# $3... = the chunks, in order
+#
+# Participants paste the chunks, in any order, when prompted for the
+# genesis data (`eos-bios join --single`). Each chunk carries its
+# position and a checksum, and the reassembled payload is verified.
+
+echo "Kickstart payload split in $1 chunks, see $2"
+
+# Post each chunk to your channel of choice, ex:
+# shift 2
+# for chunk in "$@"; do
+#   curl -s -X POST -d "{\"text\": \"$chunk\"}" "$SLACK_WEBHOOK_URL"
+# done
@@ -0,0 +1,15 @@
+#!/bin/bash -e
+
+# `step_deadline` hook, called each time a boot sequence step exceeds
+# its `deadline` again.
+# $1 = level ("warning", then "critical", then "emergency")
+# $2 = step label
+# $3 = step operation
+# $4 = time spent on the step so far
+# $5 = deadline of the step
+# $6 = fallback behavior ("", "retry", "skip" or "abort")
+
+echo "[$1] Boot step \"$2\" ($3) running for $4, over its $5 deadline"
+
+# Page your team here, ex:
+# curl -s -X POST -d "{\"text\": \"[$1] eos-bios step $3 stalled for $4\"}" "$SLACK_WEBHOOK_URL"